@@ -0,0 +1,325 @@
+package acls
+
+import (
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/common"
+	"strings"
+)
+
+// ResourceType is the type of resource an ACL applies to. Values are the same as Kafka's.
+type ResourceType int8
+
+const (
+	ResourceTypeUnknown         ResourceType = 0
+	ResourceTypeAny             ResourceType = 1
+	ResourceTypeTopic           ResourceType = 2
+	ResourceTypeGroup           ResourceType = 3
+	ResourceTypeCluster         ResourceType = 4
+	ResourceTypeTransactionalID ResourceType = 5
+)
+
+// PatternType determines how an ACL's resource name is matched against the name of a resource. Values are the same as
+// Kafka's.
+type PatternType int8
+
+const (
+	PatternTypeUnknown PatternType = 0
+	// PatternTypeAny is only used in filters, and matches ACLs with any pattern type
+	PatternTypeAny PatternType = 1
+	// PatternTypeMatch is only used in filters, and matches ACLs which would apply to the filter's resource name
+	PatternTypeMatch    PatternType = 2
+	PatternTypeLiteral  PatternType = 3
+	PatternTypePrefixed PatternType = 4
+)
+
+// Operation is the operation an ACL allows or denies. Values are the same as Kafka's.
+type Operation int8
+
+const (
+	OperationUnknown         Operation = 0
+	OperationAny             Operation = 1
+	OperationAll             Operation = 2
+	OperationRead            Operation = 3
+	OperationWrite           Operation = 4
+	OperationCreate          Operation = 5
+	OperationDelete          Operation = 6
+	OperationAlter           Operation = 7
+	OperationDescribe        Operation = 8
+	OperationClusterAction   Operation = 9
+	OperationDescribeConfigs Operation = 10
+	OperationAlterConfigs    Operation = 11
+	OperationIdempotentWrite Operation = 12
+)
+
+// Permission determines whether an ACL allows or denies an operation. Values are the same as Kafka's.
+type Permission int8
+
+const (
+	PermissionUnknown Permission = 0
+	PermissionAny     Permission = 1
+	PermissionDeny    Permission = 2
+	PermissionAllow   Permission = 3
+)
+
+const (
+	// ClusterResourceName is the name of the single cluster resource
+	ClusterResourceName = "kafka-cluster"
+	// WildcardResourceName in a literal ACL matches all resources of the ACL's resource type
+	WildcardResourceName = "*"
+	// WildcardPrincipal in an ACL matches all principals
+	WildcardPrincipal = "User:*"
+	// WildcardHost in an ACL matches all hosts
+	WildcardHost = "*"
+	// AnonymousPrincipal is the principal of connections which have not authenticated
+	AnonymousPrincipal  = "User:ANONYMOUS"
+	userPrincipalPrefix = "User:"
+)
+
+// UserPrincipal returns the ACL principal for an authenticated user
+func UserPrincipal(user string) string {
+	return userPrincipalPrefix + user
+}
+
+/*
+AclEntry is a single ACL. As with Kafka, it allows or denies a principal connecting from a host an operation on the
+resources which match its resource type, name and pattern type. Principals are in the form User:<name>.
+*/
+type AclEntry struct {
+	ResourceType ResourceType
+	ResourceName string
+	PatternType  PatternType
+	Principal    string
+	Host         string
+	Operation    Operation
+	Permission   Permission
+}
+
+// Validate checks the ACL can be created
+func (a *AclEntry) Validate() error {
+	switch a.ResourceType {
+	case ResourceTypeTopic, ResourceTypeGroup, ResourceTypeTransactionalID:
+	case ResourceTypeCluster:
+		if a.ResourceName != ClusterResourceName {
+			return invalidAclError("cluster ACL resource name must be %s", ClusterResourceName)
+		}
+	default:
+		return invalidAclError("unsupported ACL resource type %d", a.ResourceType)
+	}
+	if a.ResourceName == "" {
+		return invalidAclError("ACL resource name must be specified")
+	}
+	switch a.PatternType {
+	case PatternTypeLiteral:
+	case PatternTypePrefixed:
+		if a.ResourceName == WildcardResourceName {
+			return invalidAclError("ACL with prefixed pattern type cannot have wildcard resource name")
+		}
+	default:
+		return invalidAclError("unsupported ACL pattern type %d", a.PatternType)
+	}
+	if !strings.HasPrefix(a.Principal, userPrincipalPrefix) || len(a.Principal) == len(userPrincipalPrefix) {
+		return invalidAclError("invalid ACL principal %s - must be in the form User:<name>", a.Principal)
+	}
+	if a.Host == "" {
+		return invalidAclError("ACL host must be specified")
+	}
+	if a.Operation < OperationAll || a.Operation > OperationIdempotentWrite {
+		return invalidAclError("unsupported ACL operation %d", a.Operation)
+	}
+	if a.Permission != PermissionAllow && a.Permission != PermissionDeny {
+		return invalidAclError("unsupported ACL permission type %d", a.Permission)
+	}
+	return nil
+}
+
+// matchesResource returns true if the ACL applies to the resource with the provided name
+func (a *AclEntry) matchesResource(resourceName string) bool {
+	switch a.PatternType {
+	case PatternTypeLiteral:
+		return a.ResourceName == resourceName || a.ResourceName == WildcardResourceName
+	case PatternTypePrefixed:
+		return strings.HasPrefix(resourceName, a.ResourceName)
+	default:
+		return false
+	}
+}
+
+// Less orders ACLs by resource, then principal, host, operation and permission
+func (a *AclEntry) Less(other *AclEntry) bool {
+	if a.ResourceType != other.ResourceType {
+		return a.ResourceType < other.ResourceType
+	}
+	if a.ResourceName != other.ResourceName {
+		return a.ResourceName < other.ResourceName
+	}
+	if a.PatternType != other.PatternType {
+		return a.PatternType < other.PatternType
+	}
+	if a.Principal != other.Principal {
+		return a.Principal < other.Principal
+	}
+	if a.Host != other.Host {
+		return a.Host < other.Host
+	}
+	if a.Operation != other.Operation {
+		return a.Operation < other.Operation
+	}
+	return a.Permission < other.Permission
+}
+
+func (a *AclEntry) Serialize(buff []byte) []byte {
+	buff = append(buff, byte(a.ResourceType))
+	buff = appendString(buff, a.ResourceName)
+	buff = append(buff, byte(a.PatternType))
+	buff = appendString(buff, a.Principal)
+	buff = appendString(buff, a.Host)
+	return append(buff, byte(a.Operation), byte(a.Permission))
+}
+
+func (a *AclEntry) Deserialize(buff []byte, offset int) int {
+	a.ResourceType = ResourceType(buff[offset])
+	offset++
+	a.ResourceName, offset = readString(buff, offset)
+	a.PatternType = PatternType(buff[offset])
+	offset++
+	a.Principal, offset = readString(buff, offset)
+	a.Host, offset = readString(buff, offset)
+	a.Operation = Operation(buff[offset])
+	offset++
+	a.Permission = Permission(buff[offset])
+	return offset + 1
+}
+
+/*
+AclFilter matches ACLs, for describing and deleting them. Nil names, principals and hosts match any value, and
+ResourceTypeAny, PatternTypeAny, OperationAny and PermissionAny match any value of that field. PatternTypeMatch matches
+any ACL which would apply to a resource with the filter's resource name.
+*/
+type AclFilter struct {
+	ResourceType ResourceType
+	ResourceName *string
+	PatternType  PatternType
+	Principal    *string
+	Host         *string
+	Operation    Operation
+	Permission   Permission
+}
+
+// Validate checks the filter does not contain unknown values
+func (f *AclFilter) Validate() error {
+	if f.ResourceType == ResourceTypeUnknown {
+		return invalidAclError("ACL filter resource type must not be unknown")
+	}
+	if f.PatternType == PatternTypeUnknown || f.PatternType > PatternTypePrefixed {
+		return invalidAclError("unsupported ACL filter pattern type %d", f.PatternType)
+	}
+	if f.Operation == OperationUnknown {
+		return invalidAclError("ACL filter operation must not be unknown")
+	}
+	if f.Permission == PermissionUnknown {
+		return invalidAclError("ACL filter permission type must not be unknown")
+	}
+	return nil
+}
+
+func (f *AclFilter) Matches(acl *AclEntry) bool {
+	if f.ResourceType != ResourceTypeAny && f.ResourceType != acl.ResourceType {
+		return false
+	}
+	switch f.PatternType {
+	case PatternTypeAny:
+		if f.ResourceName != nil && *f.ResourceName != acl.ResourceName {
+			return false
+		}
+	case PatternTypeMatch:
+		if f.ResourceName != nil && !acl.matchesResource(*f.ResourceName) {
+			return false
+		}
+	default:
+		if f.PatternType != acl.PatternType || (f.ResourceName != nil && *f.ResourceName != acl.ResourceName) {
+			return false
+		}
+	}
+	if f.Principal != nil && *f.Principal != acl.Principal {
+		return false
+	}
+	if f.Host != nil && *f.Host != acl.Host {
+		return false
+	}
+	if f.Operation != OperationAny && f.Operation != acl.Operation {
+		return false
+	}
+	return f.Permission == PermissionAny || f.Permission == acl.Permission
+}
+
+func (f *AclFilter) Serialize(buff []byte) []byte {
+	buff = append(buff, byte(f.ResourceType))
+	buff = appendOptionalString(buff, f.ResourceName)
+	buff = append(buff, byte(f.PatternType))
+	buff = appendOptionalString(buff, f.Principal)
+	buff = appendOptionalString(buff, f.Host)
+	return append(buff, byte(f.Operation), byte(f.Permission))
+}
+
+func (f *AclFilter) Deserialize(buff []byte, offset int) int {
+	f.ResourceType = ResourceType(buff[offset])
+	offset++
+	f.ResourceName, offset = readOptionalString(buff, offset)
+	f.PatternType = PatternType(buff[offset])
+	offset++
+	f.Principal, offset = readOptionalString(buff, offset)
+	f.Host, offset = readOptionalString(buff, offset)
+	f.Operation = Operation(buff[offset])
+	offset++
+	f.Permission = Permission(buff[offset])
+	return offset + 1
+}
+
+func SerializeAcls(buff []byte, acls []AclEntry) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(acls)))
+	for _, acl := range acls {
+		buff = acl.Serialize(buff)
+	}
+	return buff
+}
+
+func DeserializeAcls(buff []byte, offset int) ([]AclEntry, int) {
+	numAcls := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	acls := make([]AclEntry, numAcls)
+	for i := 0; i < numAcls; i++ {
+		offset = acls[i].Deserialize(buff, offset)
+	}
+	return acls, offset
+}
+
+func invalidAclError(msg string, args ...interface{}) error {
+	return common.NewTektiteErrorf(common.InvalidConfiguration, msg, args...)
+}
+
+func appendString(buff []byte, s string) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(s)))
+	return append(buff, s...)
+}
+
+func readString(buff []byte, offset int) (string, int) {
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	return string(buff[offset : offset+ln]), offset + ln
+}
+
+func appendOptionalString(buff []byte, s *string) []byte {
+	if s == nil {
+		return append(buff, 0)
+	}
+	buff = append(buff, 1)
+	return appendString(buff, *s)
+}
+
+func readOptionalString(buff []byte, offset int) (*string, int) {
+	if buff[offset] == 0 {
+		return nil, offset + 1
+	}
+	s, offset := readString(buff, offset+1)
+	return &s, offset
+}
@@ -0,0 +1,141 @@
+package acls
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValidateAcl(t *testing.T) {
+	valid := AclEntry{
+		ResourceType: ResourceTypeTopic,
+		ResourceName: "topic1",
+		PatternType:  PatternTypeLiteral,
+		Principal:    "User:alice",
+		Host:         WildcardHost,
+		Operation:    OperationRead,
+		Permission:   PermissionAllow,
+	}
+	require.NoError(t, valid.Validate())
+
+	invalid := []func(acl *AclEntry){
+		func(acl *AclEntry) { acl.ResourceType = ResourceTypeAny },
+		func(acl *AclEntry) { acl.ResourceType = ResourceTypeCluster },
+		func(acl *AclEntry) { acl.ResourceName = "" },
+		func(acl *AclEntry) { acl.PatternType = PatternTypeMatch },
+		func(acl *AclEntry) {
+			acl.PatternType = PatternTypePrefixed
+			acl.ResourceName = WildcardResourceName
+		},
+		func(acl *AclEntry) { acl.Principal = "alice" },
+		func(acl *AclEntry) { acl.Principal = "User:" },
+		func(acl *AclEntry) { acl.Host = "" },
+		func(acl *AclEntry) { acl.Operation = OperationAny },
+		func(acl *AclEntry) { acl.Permission = PermissionAny },
+	}
+	for _, mutate := range invalid {
+		acl := valid
+		mutate(&acl)
+		err := acl.Validate()
+		require.Error(t, err)
+		require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+	}
+
+	cluster := valid
+	cluster.ResourceType = ResourceTypeCluster
+	cluster.ResourceName = ClusterResourceName
+	require.NoError(t, cluster.Validate())
+}
+
+func TestFilterMatches(t *testing.T) {
+	literal := AclEntry{
+		ResourceType: ResourceTypeTopic,
+		ResourceName: "orders",
+		PatternType:  PatternTypeLiteral,
+		Principal:    "User:alice",
+		Host:         WildcardHost,
+		Operation:    OperationRead,
+		Permission:   PermissionAllow,
+	}
+	prefixed := literal
+	prefixed.ResourceName = "ord"
+	prefixed.PatternType = PatternTypePrefixed
+	wildcard := literal
+	wildcard.ResourceName = WildcardResourceName
+	group := literal
+	group.ResourceType = ResourceTypeGroup
+
+	matchAll := AclFilter{
+		ResourceType: ResourceTypeAny,
+		PatternType:  PatternTypeAny,
+		Operation:    OperationAny,
+		Permission:   PermissionAny,
+	}
+	for _, acl := range []AclEntry{literal, prefixed, wildcard, group} {
+		require.True(t, matchAll.Matches(&acl))
+	}
+
+	filter := matchAll
+	filter.ResourceType = ResourceTypeTopic
+	filter.ResourceName = common.StrPtr("orders")
+	require.True(t, filter.Matches(&literal))
+	require.False(t, filter.Matches(&prefixed))
+	require.False(t, filter.Matches(&wildcard))
+	require.False(t, filter.Matches(&group))
+
+	// Match finds all the ACLs which apply to the resource
+	filter.PatternType = PatternTypeMatch
+	require.True(t, filter.Matches(&literal))
+	require.True(t, filter.Matches(&prefixed))
+	require.True(t, filter.Matches(&wildcard))
+	require.False(t, filter.Matches(&group))
+
+	filter.PatternType = PatternTypePrefixed
+	filter.ResourceName = common.StrPtr("ord")
+	require.False(t, filter.Matches(&literal))
+	require.True(t, filter.Matches(&prefixed))
+
+	filter = matchAll
+	filter.Principal = common.StrPtr("User:bob")
+	require.False(t, filter.Matches(&literal))
+	filter.Principal = common.StrPtr("User:alice")
+	require.True(t, filter.Matches(&literal))
+	filter.Host = common.StrPtr("10.0.0.1")
+	require.False(t, filter.Matches(&literal))
+	filter.Host = nil
+	filter.Operation = OperationWrite
+	require.False(t, filter.Matches(&literal))
+	filter.Operation = OperationRead
+	filter.Permission = PermissionDeny
+	require.False(t, filter.Matches(&literal))
+	filter.Permission = PermissionAllow
+	require.True(t, filter.Matches(&literal))
+}
+
+func TestSerializeDeserializeAcls(t *testing.T) {
+	entries := []AclEntry{
+		{ResourceType: ResourceTypeTopic, ResourceName: "topic1", PatternType: PatternTypeLiteral,
+			Principal: "User:alice", Host: "*", Operation: OperationRead, Permission: PermissionAllow},
+		{ResourceType: ResourceTypeGroup, ResourceName: "group", PatternType: PatternTypePrefixed,
+			Principal: "User:bob", Host: "10.0.0.1", Operation: OperationAll, Permission: PermissionDeny},
+	}
+	buff := []byte{1, 2, 3}
+	buff = SerializeAcls(buff, entries)
+	deserialized, offset := DeserializeAcls(buff, 3)
+	require.Equal(t, entries, deserialized)
+	require.Equal(t, len(buff), offset)
+
+	filter := AclFilter{
+		ResourceType: ResourceTypeAny,
+		ResourceName: common.StrPtr("topic1"),
+		PatternType:  PatternTypeMatch,
+		Host:         common.StrPtr("*"),
+		Operation:    OperationAny,
+		Permission:   PermissionAllow,
+	}
+	buff = filter.Serialize([]byte{1, 2, 3})
+	var deserializedFilter AclFilter
+	offset = deserializedFilter.Deserialize(buff, 3)
+	require.Equal(t, filter, deserializedFilter)
+	require.Equal(t, len(buff), offset)
+}
@@ -0,0 +1,263 @@
+package acls
+
+import (
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
+	log "github.com/spirit-labs/tektite/logger"
+	"sync"
+	"time"
+)
+
+// Authorizer decides whether the principal of a connection is allowed to perform an operation on a resource
+type Authorizer interface {
+	// Authorize returns true if the connection is allowed to perform the operation on the named resource
+	Authorize(authContext *auth.Context, host string, resourceType ResourceType, resourceName string,
+		operation Operation) bool
+	// AuthorizeAnyResource returns true if the connection is allowed to perform the operation on at least one resource
+	// of the provided type
+	AuthorizeAnyResource(authContext *auth.Context, host string, resourceType ResourceType, operation Operation) bool
+}
+
+/*
+Manager is the Authorizer used by agents. ACLs are stored on the controller and the manager holds a copy of them, which is
+refreshed periodically, so ACLs created or deleted via another agent take effect within the refresh interval.
+As with Kafka's ACL authorizer:
+  - super users are allowed to perform any operation
+  - an operation is denied if any matching ACL denies it, otherwise it is allowed if any matching ACL allows it
+  - allowing read, write, delete or alter implies allowing describe, and allowing alter configs implies allowing describe
+    configs
+  - if there are no ACLs at all for a resource, operations on it are denied unless AllowEveryoneIfNoAclFound is set
+
+Until the ACLs have been loaded from the controller for the first time, only super users are authorized - otherwise
+AllowEveryoneIfNoAclFound would allow every operation while the agent is starting.
+*/
+type Manager struct {
+	cfg           Conf
+	clientFactory ControlClientFactory
+	superUsers    map[string]struct{}
+	lock          sync.RWMutex
+	started       bool
+	acls          map[ResourceType][]AclEntry
+	loaded        bool
+	refreshTimer  *common.TimerHandle
+}
+
+type ControlClient interface {
+	GetAcls() ([]AclEntry, error)
+}
+
+type ControlClientFactory func() (ControlClient, error)
+
+type Conf struct {
+	// Enabled determines whether requests are authorized. If not enabled, all requests are allowed.
+	Enabled bool
+	// SuperUsers are principals, in the form User:<name>, which are allowed to perform any operation
+	SuperUsers                []string
+	AllowEveryoneIfNoAclFound bool
+	RefreshInterval           time.Duration
+}
+
+func NewConf() Conf {
+	return Conf{
+		RefreshInterval: DefaultRefreshInterval,
+	}
+}
+
+func (c *Conf) Validate() error {
+	return nil
+}
+
+const DefaultRefreshInterval = 5 * time.Second
+
+func NewManager(cfg Conf, clientFactory ControlClientFactory) *Manager {
+	superUsers := make(map[string]struct{}, len(cfg.SuperUsers))
+	for _, superUser := range cfg.SuperUsers {
+		superUsers[superUser] = struct{}{}
+	}
+	return &Manager{
+		cfg:           cfg,
+		clientFactory: clientFactory,
+		superUsers:    superUsers,
+		acls:          map[ResourceType][]AclEntry{},
+	}
+}
+
+func (m *Manager) Start() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.started {
+		return nil
+	}
+	m.started = true
+	if m.cfg.Enabled {
+		// The controller may not be available yet, so ACLs are loaded on the first refresh
+		m.scheduleRefresh(0)
+	}
+	return nil
+}
+
+func (m *Manager) Stop() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.started {
+		return nil
+	}
+	if m.refreshTimer != nil {
+		m.refreshTimer.Stop()
+		m.refreshTimer = nil
+	}
+	m.started = false
+	return nil
+}
+
+// must be called with the lock held
+func (m *Manager) scheduleRefresh(delay time.Duration) {
+	m.refreshTimer = common.ScheduleTimer(delay, false, func() {
+		if err := m.Refresh(); err != nil {
+			log.Debugf("failed to refresh acls: %v", err)
+		}
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if m.started {
+			m.scheduleRefresh(m.cfg.RefreshInterval)
+		}
+	})
+}
+
+// Refresh reloads the ACLs from the controller
+func (m *Manager) Refresh() error {
+	if !m.cfg.Enabled {
+		return nil
+	}
+	cl, err := m.clientFactory()
+	if err != nil {
+		return err
+	}
+	entries, err := cl.GetAcls()
+	if err != nil {
+		return err
+	}
+	m.SetAcls(entries)
+	return nil
+}
+
+func (m *Manager) SetAcls(entries []AclEntry) {
+	acls := map[ResourceType][]AclEntry{}
+	for _, entry := range entries {
+		acls[entry.ResourceType] = append(acls[entry.ResourceType], entry)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.acls = acls
+	m.loaded = true
+}
+
+func (m *Manager) Authorize(authContext *auth.Context, host string, resourceType ResourceType, resourceName string,
+	operation Operation) bool {
+	if !m.cfg.Enabled {
+		return true
+	}
+	principal := principalFromAuthContext(authContext)
+	if m.isSuperUser(principal) {
+		return true
+	}
+	acls, loaded := m.getAcls(resourceType)
+	if !loaded {
+		return false
+	}
+	var found, allowed bool
+	for _, acl := range acls {
+		if !acl.matchesResource(resourceName) {
+			continue
+		}
+		found = true
+		if !acl.appliesTo(principal, host) {
+			continue
+		}
+		if acl.Permission == PermissionDeny {
+			if acl.Operation == OperationAll || acl.Operation == operation {
+				return false
+			}
+		} else if impliesOperation(acl.Operation, operation) {
+			allowed = true
+		}
+	}
+	if !found {
+		return m.cfg.AllowEveryoneIfNoAclFound
+	}
+	return allowed
+}
+
+func (m *Manager) AuthorizeAnyResource(authContext *auth.Context, host string, resourceType ResourceType,
+	operation Operation) bool {
+	if !m.cfg.Enabled {
+		return true
+	}
+	principal := principalFromAuthContext(authContext)
+	if m.isSuperUser(principal) {
+		return true
+	}
+	acls, loaded := m.getAcls(resourceType)
+	if !loaded {
+		return false
+	}
+	if len(acls) == 0 {
+		return m.cfg.AllowEveryoneIfNoAclFound
+	}
+	allowed := false
+	for _, acl := range acls {
+		if !acl.appliesTo(principal, host) {
+			continue
+		}
+		if acl.Permission == PermissionDeny {
+			// Only a deny for all resources of the type prevents the operation on any resource
+			if acl.PatternType == PatternTypeLiteral && acl.ResourceName == WildcardResourceName &&
+				(acl.Operation == OperationAll || acl.Operation == operation) {
+				return false
+			}
+		} else if impliesOperation(acl.Operation, operation) {
+			allowed = true
+		}
+	}
+	return allowed
+}
+
+// getAcls returns the ACLs for the resource type, and whether the ACLs have been loaded yet
+func (m *Manager) getAcls(resourceType ResourceType) ([]AclEntry, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.acls[resourceType], m.loaded
+}
+
+func (m *Manager) isSuperUser(principal string) bool {
+	_, ok := m.superUsers[principal]
+	return ok
+}
+
+func principalFromAuthContext(authContext *auth.Context) string {
+	if !authContext.Authenticated || authContext.Principal == nil {
+		return AnonymousPrincipal
+	}
+	return UserPrincipal(*authContext.Principal)
+}
+
+func (a *AclEntry) appliesTo(principal string, host string) bool {
+	return (a.Principal == principal || a.Principal == WildcardPrincipal) &&
+		(a.Host == host || a.Host == WildcardHost)
+}
+
+// impliesOperation returns true if an ACL for aclOperation allows operation
+func impliesOperation(aclOperation Operation, operation Operation) bool {
+	if aclOperation == OperationAll || aclOperation == operation {
+		return true
+	}
+	switch operation {
+	case OperationDescribe:
+		return aclOperation == OperationRead || aclOperation == OperationWrite || aclOperation == OperationDelete ||
+			aclOperation == OperationAlter
+	case OperationDescribeConfigs:
+		return aclOperation == OperationAlterConfigs
+	default:
+		return false
+	}
+}
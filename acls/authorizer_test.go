@@ -0,0 +1,158 @@
+package acls
+
+import (
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAuthorize(t *testing.T) {
+	cfg := NewConf()
+	cfg.Enabled = true
+	cfg.SuperUsers = []string{"User:admin"}
+	mgr := NewManager(cfg, nil)
+	mgr.SetAcls([]AclEntry{
+		allowAcl(ResourceTypeTopic, "orders", PatternTypeLiteral, "User:alice", OperationRead),
+		allowAcl(ResourceTypeTopic, "payments-", PatternTypePrefixed, "User:alice", OperationWrite),
+		allowAcl(ResourceTypeTopic, WildcardResourceName, PatternTypeLiteral, WildcardPrincipal, OperationAlterConfigs),
+		denyAcl(ResourceTypeTopic, "payments-secret", PatternTypeLiteral, "User:alice", OperationAll),
+		allowAcl(ResourceTypeGroup, "group1", PatternTypeLiteral, "User:alice", OperationRead),
+	})
+	alice := authContext("alice")
+
+	require.True(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.False(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "orders", OperationWrite))
+	// Read implies describe
+	require.True(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "orders", OperationDescribe))
+	// Alter configs implies describe configs, and the wildcard ACLs apply to all topics and principals
+	require.True(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "orders", OperationDescribeConfigs))
+	require.True(t, mgr.Authorize(authContext("bob"), "10.0.0.1", ResourceTypeTopic, "other", OperationAlterConfigs))
+	// Prefixed
+	require.True(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "payments-eu", OperationWrite))
+	require.False(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "payment", OperationWrite))
+	// Deny takes precedence
+	require.False(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeTopic, "payments-secret", OperationWrite))
+	// Other principals
+	require.False(t, mgr.Authorize(authContext("bob"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.False(t, mgr.Authorize(&auth.Context{}, "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	// Super users can do anything
+	require.True(t, mgr.Authorize(authContext("admin"), "10.0.0.1", ResourceTypeCluster, ClusterResourceName,
+		OperationAlter))
+	// Groups
+	require.True(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeGroup, "group1", OperationRead))
+	require.False(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeGroup, "group2", OperationRead))
+	// No ACLs for the cluster
+	require.False(t, mgr.Authorize(alice, "10.0.0.1", ResourceTypeCluster, ClusterResourceName, OperationDescribe))
+
+	// Any resource
+	require.True(t, mgr.AuthorizeAnyResource(alice, "10.0.0.1", ResourceTypeTopic, OperationWrite))
+	require.False(t, mgr.AuthorizeAnyResource(authContext("bob"), "10.0.0.1", ResourceTypeTopic, OperationWrite))
+	require.False(t, mgr.AuthorizeAnyResource(alice, "10.0.0.1", ResourceTypeTransactionalID, OperationWrite))
+}
+
+func TestAuthorizeHost(t *testing.T) {
+	cfg := NewConf()
+	cfg.Enabled = true
+	mgr := NewManager(cfg, nil)
+	acl := allowAcl(ResourceTypeTopic, "orders", PatternTypeLiteral, "User:alice", OperationRead)
+	acl.Host = "10.0.0.1"
+	mgr.SetAcls([]AclEntry{acl})
+	require.True(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.False(t, mgr.Authorize(authContext("alice"), "10.0.0.2", ResourceTypeTopic, "orders", OperationRead))
+}
+
+func TestAuthorizeNoAclFound(t *testing.T) {
+	cfg := NewConf()
+	cfg.Enabled = true
+	mgr := NewManager(cfg, nil)
+	mgr.SetAcls(nil)
+	require.False(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+
+	cfg.AllowEveryoneIfNoAclFound = true
+	mgr = NewManager(cfg, nil)
+	mgr.SetAcls(nil)
+	require.True(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.True(t, mgr.AuthorizeAnyResource(authContext("alice"), "10.0.0.1", ResourceTypeTopic, OperationWrite))
+	// Once there is an ACL for the resource, it must allow the operation
+	mgr.SetAcls([]AclEntry{allowAcl(ResourceTypeTopic, "orders", PatternTypeLiteral, "User:bob", OperationRead)})
+	require.False(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.True(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "other", OperationRead))
+}
+
+func TestAuthorizeBeforeAclsLoaded(t *testing.T) {
+	cfg := NewConf()
+	cfg.Enabled = true
+	cfg.AllowEveryoneIfNoAclFound = true
+	cfg.SuperUsers = []string{"User:admin"}
+	mgr := NewManager(cfg, nil)
+	// Nothing is allowed by AllowEveryoneIfNoAclFound until the ACLs have been loaded
+	require.False(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.False(t, mgr.AuthorizeAnyResource(authContext("alice"), "10.0.0.1", ResourceTypeTopic, OperationRead))
+	require.True(t, mgr.Authorize(authContext("admin"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	mgr.SetAcls(nil)
+	require.True(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.True(t, mgr.AuthorizeAnyResource(authContext("alice"), "10.0.0.1", ResourceTypeTopic, OperationRead))
+}
+
+func TestAuthorizeDisabled(t *testing.T) {
+	mgr := NewManager(NewConf(), nil)
+	mgr.SetAcls([]AclEntry{denyAcl(ResourceTypeTopic, "orders", PatternTypeLiteral, "User:alice", OperationAll)})
+	require.True(t, mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead))
+	require.True(t, mgr.AuthorizeAnyResource(authContext("alice"), "10.0.0.1", ResourceTypeTopic, OperationRead))
+}
+
+func TestRefresh(t *testing.T) {
+	cl := &testControlClient{acls: []AclEntry{
+		allowAcl(ResourceTypeTopic, "orders", PatternTypeLiteral, "User:alice", OperationRead),
+	}}
+	cfg := NewConf()
+	cfg.Enabled = true
+	cfg.RefreshInterval = 10 * time.Millisecond
+	mgr := NewManager(cfg, func() (ControlClient, error) {
+		return cl, nil
+	})
+	err := mgr.Start()
+	require.NoError(t, err)
+	defer func() {
+		err := mgr.Stop()
+		require.NoError(t, err)
+	}()
+	testutils.WaitUntil(t, func() (bool, error) {
+		return mgr.Authorize(authContext("alice"), "10.0.0.1", ResourceTypeTopic, "orders", OperationRead), nil
+	})
+}
+
+func authContext(user string) *auth.Context {
+	return &auth.Context{Principal: common.StrPtr(user), Authenticated: true}
+}
+
+func allowAcl(resourceType ResourceType, resourceName string, patternType PatternType, principal string,
+	operation Operation) AclEntry {
+	return AclEntry{
+		ResourceType: resourceType,
+		ResourceName: resourceName,
+		PatternType:  patternType,
+		Principal:    principal,
+		Host:         WildcardHost,
+		Operation:    operation,
+		Permission:   PermissionAllow,
+	}
+}
+
+func denyAcl(resourceType ResourceType, resourceName string, patternType PatternType, principal string,
+	operation Operation) AclEntry {
+	acl := allowAcl(resourceType, resourceName, patternType, principal, operation)
+	acl.Permission = PermissionDeny
+	return acl
+}
+
+type testControlClient struct {
+	acls []AclEntry
+}
+
+func (t *testControlClient) GetAcls() ([]AclEntry, error) {
+	return t.acls, nil
+}
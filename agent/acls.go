@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	log "github.com/spirit-labs/tektite/logger"
+)
+
+const securityDisabledMsg = "authorization is not enabled"
+
+func (a *Agent) HandleCreateAclsRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.CreateAclsRequest) *kafkaprotocol.CreateAclsResponse {
+	var resp kafkaprotocol.CreateAclsResponse
+	resp.Results = make([]kafkaprotocol.CreateAclsResponseAclCreationResult, len(req.Creations))
+	if !a.cfg.AclConf.Enabled {
+		for i := range resp.Results {
+			resp.Results[i].ErrorCode = kafkaprotocol.ErrorCodeSecurityDisabled
+			resp.Results[i].ErrorMessage = common.StrPtr(securityDisabledMsg)
+		}
+		return &resp
+	}
+	var entries []acls.AclEntry
+	var entryIndexes []int
+	for i, creation := range req.Creations {
+		entry := acls.AclEntry{
+			ResourceType: acls.ResourceType(creation.ResourceType),
+			ResourceName: common.SafeDerefStringPtr(creation.ResourceName),
+			PatternType:  acls.PatternType(creation.ResourcePatternType),
+			Principal:    common.SafeDerefStringPtr(creation.Principal),
+			Host:         common.SafeDerefStringPtr(creation.Host),
+			Operation:    acls.Operation(creation.Operation),
+			Permission:   acls.Permission(creation.PermissionType),
+		}
+		if hdr.RequestApiVersion == 0 {
+			// Pattern types were added in version 1, before which all ACLs were literal
+			entry.PatternType = acls.PatternTypeLiteral
+		}
+		if err := entry.Validate(); err != nil {
+			resp.Results[i].ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
+			resp.Results[i].ErrorMessage = common.StrPtr(err.Error())
+			continue
+		}
+		entries = append(entries, entry)
+		entryIndexes = append(entryIndexes, i)
+	}
+	if len(entries) == 0 {
+		return &resp
+	}
+	if err := a.createAcls(entries); err != nil {
+		errCode := aclErrorCode(err)
+		for _, index := range entryIndexes {
+			resp.Results[index].ErrorCode = errCode
+			resp.Results[index].ErrorMessage = common.StrPtr(err.Error())
+		}
+		return &resp
+	}
+	a.refreshAcls()
+	return &resp
+}
+
+func (a *Agent) HandleDescribeAclsRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DescribeAclsRequest) *kafkaprotocol.DescribeAclsResponse {
+	var resp kafkaprotocol.DescribeAclsResponse
+	resp.Resources = []kafkaprotocol.DescribeAclsResponseDescribeAclsResource{}
+	if !a.cfg.AclConf.Enabled {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeSecurityDisabled
+		resp.ErrorMessage = common.StrPtr(securityDisabledMsg)
+		return &resp
+	}
+	filter := acls.AclFilter{
+		ResourceType: acls.ResourceType(req.ResourceTypeFilter),
+		ResourceName: req.ResourceNameFilter,
+		PatternType:  acls.PatternType(req.PatternTypeFilter),
+		Principal:    req.PrincipalFilter,
+		Host:         req.HostFilter,
+		Operation:    acls.Operation(req.Operation),
+		Permission:   acls.Permission(req.PermissionType),
+	}
+	if hdr.RequestApiVersion == 0 {
+		filter.PatternType = acls.PatternTypeLiteral
+	}
+	if err := filter.Validate(); err != nil {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
+		resp.ErrorMessage = common.StrPtr(err.Error())
+		return &resp
+	}
+	entries, err := a.getAcls()
+	if err != nil {
+		resp.ErrorCode = aclErrorCode(err)
+		resp.ErrorMessage = common.StrPtr(err.Error())
+		return &resp
+	}
+	// The ACLs are sorted by resource, so ACLs for the same resource are adjacent
+	var resource *kafkaprotocol.DescribeAclsResponseDescribeAclsResource
+	for i := range entries {
+		entry := &entries[i]
+		if !filter.Matches(entry) {
+			continue
+		}
+		if resource == nil || acls.ResourceType(resource.ResourceType) != entry.ResourceType ||
+			*resource.ResourceName != entry.ResourceName || acls.PatternType(resource.PatternType) != entry.PatternType {
+			resp.Resources = append(resp.Resources, kafkaprotocol.DescribeAclsResponseDescribeAclsResource{
+				ResourceType: int8(entry.ResourceType),
+				ResourceName: common.StrPtr(entry.ResourceName),
+				PatternType:  int8(entry.PatternType),
+			})
+			resource = &resp.Resources[len(resp.Resources)-1]
+		}
+		resource.Acls = append(resource.Acls, kafkaprotocol.DescribeAclsResponseAclDescription{
+			Principal:      common.StrPtr(entry.Principal),
+			Host:           common.StrPtr(entry.Host),
+			Operation:      int8(entry.Operation),
+			PermissionType: int8(entry.Permission),
+		})
+	}
+	return &resp
+}
+
+func (a *Agent) HandleDeleteAclsRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DeleteAclsRequest) *kafkaprotocol.DeleteAclsResponse {
+	var resp kafkaprotocol.DeleteAclsResponse
+	resp.FilterResults = make([]kafkaprotocol.DeleteAclsResponseDeleteAclsFilterResult, len(req.Filters))
+	if !a.cfg.AclConf.Enabled {
+		for i := range resp.FilterResults {
+			resp.FilterResults[i].ErrorCode = kafkaprotocol.ErrorCodeSecurityDisabled
+			resp.FilterResults[i].ErrorMessage = common.StrPtr(securityDisabledMsg)
+		}
+		return &resp
+	}
+	var filters []acls.AclFilter
+	var filterIndexes []int
+	for i, filterData := range req.Filters {
+		resp.FilterResults[i].MatchingAcls = []kafkaprotocol.DeleteAclsResponseDeleteAclsMatchingAcl{}
+		filter := acls.AclFilter{
+			ResourceType: acls.ResourceType(filterData.ResourceTypeFilter),
+			ResourceName: filterData.ResourceNameFilter,
+			PatternType:  acls.PatternType(filterData.PatternTypeFilter),
+			Principal:    filterData.PrincipalFilter,
+			Host:         filterData.HostFilter,
+			Operation:    acls.Operation(filterData.Operation),
+			Permission:   acls.Permission(filterData.PermissionType),
+		}
+		if hdr.RequestApiVersion == 0 {
+			filter.PatternType = acls.PatternTypeLiteral
+		}
+		if err := filter.Validate(); err != nil {
+			resp.FilterResults[i].ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
+			resp.FilterResults[i].ErrorMessage = common.StrPtr(err.Error())
+			continue
+		}
+		filters = append(filters, filter)
+		filterIndexes = append(filterIndexes, i)
+	}
+	if len(filters) == 0 {
+		return &resp
+	}
+	deleted, err := a.deleteAcls(filters)
+	if err != nil {
+		errCode := aclErrorCode(err)
+		for _, index := range filterIndexes {
+			resp.FilterResults[index].ErrorCode = errCode
+			resp.FilterResults[index].ErrorMessage = common.StrPtr(err.Error())
+		}
+		return &resp
+	}
+	for i, index := range filterIndexes {
+		result := &resp.FilterResults[index]
+		for _, entry := range deleted[i] {
+			result.MatchingAcls = append(result.MatchingAcls, kafkaprotocol.DeleteAclsResponseDeleteAclsMatchingAcl{
+				ResourceType:   int8(entry.ResourceType),
+				ResourceName:   common.StrPtr(entry.ResourceName),
+				PatternType:    int8(entry.PatternType),
+				Principal:      common.StrPtr(entry.Principal),
+				Host:           common.StrPtr(entry.Host),
+				Operation:      int8(entry.Operation),
+				PermissionType: int8(entry.Permission),
+			})
+		}
+	}
+	a.refreshAcls()
+	return &resp
+}
+
+func aclErrorCode(err error) int16 {
+	if common.IsTektiteErrorWithCode(err, common.InvalidConfiguration) {
+		return kafkaprotocol.ErrorCodeInvalidRequest
+	}
+	return kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeRequestTimedOut)
+}
+
+// refreshAcls applies changed ACLs on this agent straight away - other agents will pick them up on their next refresh
+func (a *Agent) refreshAcls() {
+	if err := a.aclManager.Refresh(); err != nil {
+		log.Warnf("failed to refresh acls: %v", err)
+	}
+}
+
+func (a *Agent) createAcls(entries []acls.AclEntry) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.CreateAcls(entries)
+}
+
+func (a *Agent) deleteAcls(filters []acls.AclFilter) ([][]acls.AclEntry, error) {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.DeleteAcls(filters)
+}
+
+func (a *Agent) getAcls() ([]acls.AclEntry, error) {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetAcls()
+}
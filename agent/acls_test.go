@@ -0,0 +1,286 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCreateDescribeDeleteAcls(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 3} {
+		testCreateDescribeDeleteAcls(t, apiVersion)
+	}
+}
+
+func testCreateDescribeDeleteAcls(t *testing.T, apiVersion int16) {
+	cfg := NewConf()
+	cfg.AclConf.Enabled = true
+	// Connections in the test are not authenticated
+	cfg.AclConf.SuperUsers = []string{acls.AnonymousPrincipal}
+	agent, _, tearDown := setupAgent(t, nil, cfg)
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	createResp := sendCreateAcls(t, conn, &kafkaprotocol.CreateAclsRequest{
+		Creations: []kafkaprotocol.CreateAclsRequestAclCreation{
+			aclCreation(acls.ResourceTypeTopic, "topic1", "User:alice", acls.OperationRead, acls.PermissionAllow),
+			aclCreation(acls.ResourceTypeTopic, "topic1", "User:bob", acls.OperationWrite, acls.PermissionDeny),
+			aclCreation(acls.ResourceTypeGroup, "group1", "User:alice", acls.OperationRead, acls.PermissionAllow),
+			// invalid principal
+			aclCreation(acls.ResourceTypeTopic, "topic1", "alice", acls.OperationRead, acls.PermissionAllow),
+		},
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(createResp.Results[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(createResp.Results[1].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(createResp.Results[2].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(createResp.Results[3].ErrorCode))
+	require.NotNil(t, createResp.Results[3].ErrorMessage)
+
+	describeResp := sendDescribeAcls(t, conn, &kafkaprotocol.DescribeAclsRequest{
+		ResourceTypeFilter: int8(acls.ResourceTypeAny),
+		PatternTypeFilter:  int8(acls.PatternTypeAny),
+		Operation:          int8(acls.OperationAny),
+		PermissionType:     int8(acls.PermissionAny),
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(describeResp.ErrorCode))
+	require.Equal(t, 2, len(describeResp.Resources))
+	topicResource := describeResp.Resources[0]
+	require.Equal(t, int8(acls.ResourceTypeTopic), topicResource.ResourceType)
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(topicResource.ResourceName))
+	if apiVersion >= 1 {
+		require.Equal(t, int8(acls.PatternTypeLiteral), topicResource.PatternType)
+	}
+	require.Equal(t, 2, len(topicResource.Acls))
+	require.Equal(t, "User:alice", common.SafeDerefStringPtr(topicResource.Acls[0].Principal))
+	require.Equal(t, int8(acls.OperationRead), topicResource.Acls[0].Operation)
+	require.Equal(t, "User:bob", common.SafeDerefStringPtr(topicResource.Acls[1].Principal))
+	require.Equal(t, int8(acls.PermissionDeny), topicResource.Acls[1].PermissionType)
+	require.Equal(t, int8(acls.ResourceTypeGroup), describeResp.Resources[1].ResourceType)
+
+	// Filter on principal
+	describeResp = sendDescribeAcls(t, conn, &kafkaprotocol.DescribeAclsRequest{
+		ResourceTypeFilter: int8(acls.ResourceTypeTopic),
+		PatternTypeFilter:  int8(acls.PatternTypeAny),
+		PrincipalFilter:    common.StrPtr("User:bob"),
+		Operation:          int8(acls.OperationAny),
+		PermissionType:     int8(acls.PermissionAny),
+	}, apiVersion)
+	require.Equal(t, 1, len(describeResp.Resources))
+	require.Equal(t, 1, len(describeResp.Resources[0].Acls))
+
+	// The new ACLs apply to this agent straight away
+	require.True(t, agent.aclManager.Authorize(testAuthContext("alice"), "127.0.0.1", acls.ResourceTypeTopic,
+		"topic1", acls.OperationRead))
+	require.False(t, agent.aclManager.Authorize(testAuthContext("bob"), "127.0.0.1", acls.ResourceTypeTopic,
+		"topic1", acls.OperationWrite))
+
+	deleteResp := sendDeleteAcls(t, conn, &kafkaprotocol.DeleteAclsRequest{
+		Filters: []kafkaprotocol.DeleteAclsRequestDeleteAclsFilter{
+			{
+				ResourceTypeFilter: int8(acls.ResourceTypeTopic),
+				ResourceNameFilter: common.StrPtr("topic1"),
+				PatternTypeFilter:  int8(acls.PatternTypeLiteral),
+				Operation:          int8(acls.OperationAny),
+				PermissionType:     int8(acls.PermissionAny),
+			},
+			// invalid resource type
+			{
+				ResourceTypeFilter: int8(acls.ResourceTypeUnknown),
+				PatternTypeFilter:  int8(acls.PatternTypeAny),
+				Operation:          int8(acls.OperationAny),
+				PermissionType:     int8(acls.PermissionAny),
+			},
+		},
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(deleteResp.FilterResults[0].ErrorCode))
+	require.Equal(t, 2, len(deleteResp.FilterResults[0].MatchingAcls))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(deleteResp.FilterResults[1].ErrorCode))
+
+	describeResp = sendDescribeAcls(t, conn, &kafkaprotocol.DescribeAclsRequest{
+		ResourceTypeFilter: int8(acls.ResourceTypeAny),
+		PatternTypeFilter:  int8(acls.PatternTypeAny),
+		Operation:          int8(acls.OperationAny),
+		PermissionType:     int8(acls.PermissionAny),
+	}, apiVersion)
+	require.Equal(t, 1, len(describeResp.Resources))
+	require.Equal(t, int8(acls.ResourceTypeGroup), describeResp.Resources[0].ResourceType)
+}
+
+func TestAclsSecurityDisabled(t *testing.T) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+	createResp := sendCreateAcls(t, conn, &kafkaprotocol.CreateAclsRequest{
+		Creations: []kafkaprotocol.CreateAclsRequestAclCreation{
+			aclCreation(acls.ResourceTypeTopic, "topic1", "User:alice", acls.OperationRead, acls.PermissionAllow),
+		},
+	}, 3)
+	require.Equal(t, kafkaprotocol.ErrorCodeSecurityDisabled, int(createResp.Results[0].ErrorCode))
+	describeResp := sendDescribeAcls(t, conn, &kafkaprotocol.DescribeAclsRequest{
+		ResourceTypeFilter: int8(acls.ResourceTypeAny),
+		PatternTypeFilter:  int8(acls.PatternTypeAny),
+		Operation:          int8(acls.OperationAny),
+		PermissionType:     int8(acls.PermissionAny),
+	}, 3)
+	require.Equal(t, kafkaprotocol.ErrorCodeSecurityDisabled, int(describeResp.ErrorCode))
+}
+
+func TestRequestsAuthorized(t *testing.T) {
+	allowedTopic := "allowed-topic"
+	deniedTopic := "denied-topic"
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: allowedTopic, PartitionCount: 1},
+		{Name: deniedTopic, PartitionCount: 1},
+	}
+	cfg := NewConf()
+	cfg.AclConf.Enabled = true
+	agent, _, tearDown := setupAgent(t, topicInfos, cfg)
+	defer tearDown(t)
+	cl, err := agent.controller.Client()
+	require.NoError(t, err)
+	err = cl.CreateAcls([]acls.AclEntry{
+		{ResourceType: acls.ResourceTypeTopic, ResourceName: allowedTopic, PatternType: acls.PatternTypeLiteral,
+			Principal: acls.AnonymousPrincipal, Host: acls.WildcardHost, Operation: acls.OperationWrite,
+			Permission: acls.PermissionAllow},
+	})
+	require.NoError(t, err)
+	err = agent.aclManager.Refresh()
+	require.NoError(t, err)
+
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	// Only the allowed topic is written to
+	batch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10)
+	r, err := conn.SendRequest(&kafkaprotocol.ProduceRequest{
+		Acks:      -1,
+		TimeoutMs: 1234,
+		TopicData: []kafkaprotocol.ProduceRequestTopicProduceData{
+			{Name: common.StrPtr(deniedTopic), PartitionData: []kafkaprotocol.ProduceRequestPartitionProduceData{
+				{Index: 0, Records: [][]byte{batch}},
+			}},
+			{Name: common.StrPtr(allowedTopic), PartitionData: []kafkaprotocol.ProduceRequestPartitionProduceData{
+				{Index: 0, Records: [][]byte{batch}},
+			}},
+		},
+	}, kafkaprotocol.APIKeyProduce, 9, &kafkaprotocol.ProduceResponse{})
+	require.NoError(t, err)
+	produceResp := r.(*kafkaprotocol.ProduceResponse)
+	require.Equal(t, 2, len(produceResp.Responses))
+	errCodes := map[string]int16{}
+	for _, topicResp := range produceResp.Responses {
+		errCodes[*topicResp.Name] = topicResp.PartitionResponses[0].ErrorCode
+	}
+	require.Equal(t, map[string]int16{
+		allowedTopic: kafkaprotocol.ErrorCodeNone,
+		deniedTopic:  kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+	}, errCodes)
+
+	// Write does not allow read
+	r, err = conn.SendRequest(&kafkaprotocol.FetchRequest{
+		MaxWaitMs: 5000,
+		MaxBytes:  10000,
+		RackId:    common.StrPtr(""),
+		Topics: []kafkaprotocol.FetchRequestFetchTopic{
+			{Topic: common.StrPtr(allowedTopic), Partitions: []kafkaprotocol.FetchRequestFetchPartition{
+				{Partition: 0, PartitionMaxBytes: 10000},
+			}},
+		},
+	}, kafkaprotocol.APIKeyFetch, 12, &kafkaprotocol.FetchResponse{})
+	require.NoError(t, err)
+	fetchResp := r.(*kafkaprotocol.FetchResponse)
+	require.Equal(t, 1, len(fetchResp.Responses))
+	require.Equal(t, kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+		int(fetchResp.Responses[0].Partitions[0].ErrorCode))
+
+	// Write implies describe, so only the allowed topic is listed in metadata
+	r, err = conn.SendRequest(&kafkaprotocol.MetadataRequest{}, kafkaprotocol.APIKeyMetadata, 12,
+		&kafkaprotocol.MetadataResponse{})
+	require.NoError(t, err)
+	metadataResp := r.(*kafkaprotocol.MetadataResponse)
+	require.Equal(t, 1, len(metadataResp.Topics))
+	require.Equal(t, allowedTopic, common.SafeDerefStringPtr(metadataResp.Topics[0].Name))
+
+	r, err = conn.SendRequest(&kafkaprotocol.MetadataRequest{
+		Topics: []kafkaprotocol.MetadataRequestMetadataRequestTopic{{Name: common.StrPtr(deniedTopic)}},
+	}, kafkaprotocol.APIKeyMetadata, 12, &kafkaprotocol.MetadataResponse{})
+	require.NoError(t, err)
+	metadataResp = r.(*kafkaprotocol.MetadataResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeTopicAuthorizationFailed, int(metadataResp.Topics[0].ErrorCode))
+	require.Equal(t, 0, len(metadataResp.Topics[0].Partitions))
+
+	// No ACLs for the cluster or groups
+	createResp := sendCreateAcls(t, conn, &kafkaprotocol.CreateAclsRequest{
+		Creations: []kafkaprotocol.CreateAclsRequestAclCreation{
+			aclCreation(acls.ResourceTypeTopic, deniedTopic, acls.AnonymousPrincipal, acls.OperationAll,
+				acls.PermissionAllow),
+		},
+	}, 3)
+	require.Equal(t, kafkaprotocol.ErrorCodeClusterAuthorizationFailed, int(createResp.Results[0].ErrorCode))
+
+	r, err = conn.SendRequest(&kafkaprotocol.HeartbeatRequest{
+		GroupId:  common.StrPtr("group1"),
+		MemberId: common.StrPtr("member1"),
+	}, kafkaprotocol.ApiKeyHeartbeat, 4, &kafkaprotocol.HeartbeatResponse{})
+	require.NoError(t, err)
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupAuthorizationFailed,
+		int(r.(*kafkaprotocol.HeartbeatResponse).ErrorCode))
+}
+
+func aclCreation(resourceType acls.ResourceType, resourceName string, principal string, operation acls.Operation,
+	permission acls.Permission) kafkaprotocol.CreateAclsRequestAclCreation {
+	return kafkaprotocol.CreateAclsRequestAclCreation{
+		ResourceType:        int8(resourceType),
+		ResourceName:        common.StrPtr(resourceName),
+		ResourcePatternType: int8(acls.PatternTypeLiteral),
+		Principal:           common.StrPtr(principal),
+		Host:                common.StrPtr(acls.WildcardHost),
+		Operation:           int8(operation),
+		PermissionType:      int8(permission),
+	}
+}
+
+func testAuthContext(user string) *auth.Context {
+	return &auth.Context{Principal: common.StrPtr(user), Authenticated: true}
+}
+
+func sendCreateAcls(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.CreateAclsRequest,
+	apiVersion int16) *kafkaprotocol.CreateAclsResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyCreateAcls, apiVersion, &kafkaprotocol.CreateAclsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.CreateAclsResponse)
+	require.Equal(t, len(req.Creations), len(resp.Results))
+	return resp
+}
+
+func sendDescribeAcls(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.DescribeAclsRequest,
+	apiVersion int16) *kafkaprotocol.DescribeAclsResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDescribeAcls, apiVersion, &kafkaprotocol.DescribeAclsResponse{})
+	require.NoError(t, err)
+	return r.(*kafkaprotocol.DescribeAclsResponse)
+}
+
+func sendDeleteAcls(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.DeleteAclsRequest,
+	apiVersion int16) *kafkaprotocol.DeleteAclsResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDeleteAcls, apiVersion, &kafkaprotocol.DeleteAclsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.DeleteAclsResponse)
+	require.Equal(t, len(req.Filters), len(resp.FilterResults))
+	return resp
+}
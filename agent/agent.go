@@ -2,6 +2,8 @@ package agent
 
 import (
 	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
@@ -10,9 +12,11 @@ import (
 	"github.com/spirit-labs/tektite/group"
 	"github.com/spirit-labs/tektite/kafkaserver2"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/metrics"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/pusher"
+	"github.com/spirit-labs/tektite/quota"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/spirit-labs/tektite/transport"
@@ -37,10 +41,16 @@ type Agent struct {
 	fetchCache               *fetchcache.Cache
 	groupCoordinator         *group.Coordinator
 	txCoordinator            *tx.Coordinator
+	quotaManager             *quota.Manager
+	aclManager               *acls.Manager
 	topicMetaCache           *topicmeta.LocalCache
 	manifold                 *membershipChangedManifold
 	partitionLeaders         map[string]map[int]map[int]int32
 	clusterMembershipFactory ClusterMembershipFactory
+	tableGetter              sst.TableGetter
+	metrics                  *agentMetrics
+	metricsRegistry          *metrics.Registry
+	metricsServer            *metrics.Server
 }
 
 func NewAgent(cfg Conf, objStore objstore.Client) (*Agent, error) {
@@ -81,6 +91,8 @@ func NewAgentWithFactories(cfg Conf, objStore objstore.Client, connectionFactory
 	agent := &Agent{
 		cfg:              cfg,
 		partitionLeaders: map[string]map[int]map[int]int32{},
+		metrics:          newAgentMetrics(),
+		metricsRegistry:  metrics.NewRegistry(),
 	}
 	agent.controller = control.NewController(cfg.ControllerConf, objStore, connectionFactory, transportServer)
 	agent.controlClientCache = control.NewClientCache(cfg.MaxControllerClients, agent.controller.Client)
@@ -89,7 +101,7 @@ func NewAgentWithFactories(cfg Conf, objStore objstore.Client, connectionFactory
 		return cl, err
 	})
 	transportServer.RegisterHandler(transport.HandlerIDMetaLocalCacheTopicAdded, agent.topicMetaCache.HandleTopicAdded)
-	transportServer.RegisterHandler(transport.HandlerIDMetaLocalCacheTopicDeleted, agent.topicMetaCache.HandleTopicDeleted)
+	transportServer.RegisterHandler(transport.HandlerIDMetaLocalCacheTopicDeleted, agent.handleTopicDeleted)
 	clientFactory := func() (pusher.ControlClient, error) {
 		return agent.controller.Client()
 	}
@@ -105,7 +117,17 @@ func NewAgentWithFactories(cfg Conf, objStore objstore.Client, connectionFactory
 	agent.fetchCache = fetchCache
 	getter := &fetchCacheGetter{fetchCache: fetchCache}
 	agent.controller.SetTableGetter(getter.get)
-	tablePusher, err := pusher.NewTablePusher(cfg.PusherConf, agent.topicMetaCache, objStore, clientFactory, getter.get, partitionHashes, agent)
+	agent.tableGetter = getter.get
+	dataBucketName := cfg.PusherConf.DataBucketName
+	filterCache, err := sst.NewBloomFilterCache(func(tableID sst.SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+		return objstore.GetRangeWithTimeout(objStore, dataBucketName, string(tableID), rangeStart, rangeEnd,
+			objstore.DefaultCallTimeout)
+	}, sst.DefaultBloomFilterCacheMaxSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+	tablePusher, err := pusher.NewTablePusher(cfg.PusherConf, agent.topicMetaCache, objStore, clientFactory, getter.get,
+		filterCache.GetBloomFilter, partitionHashes, agent)
 	if err != nil {
 		return nil, err
 	}
@@ -120,15 +142,23 @@ func NewAgentWithFactories(cfg Conf, objStore objstore.Client, connectionFactory
 	transportServer.RegisterHandler(transport.HandlerIDFetcherTableRegisteredNotification, bf.HandleTableRegisteredNotification)
 	agent.batchFetcher = bf
 	groupCoord, err := group.NewCoordinator(cfg.GroupCoordinatorConf, agent.topicMetaCache,
-		agent.controlClientCache, connectionFactory, getter.get)
+		agent.controlClientCache, connectionFactory, getter.get, filterCache.GetBloomFilter)
 	if err != nil {
 		return nil, err
 	}
 	agent.groupCoordinator = groupCoord
-	agent.txCoordinator = tx.NewCoordinator(cfg.TxCoordinatorConf, agent.controlClientCache, getter.get, connectionFactory,
-		agent.topicMetaCache, partitionHashes)
+	agent.txCoordinator = tx.NewCoordinator(cfg.TxCoordinatorConf, agent.controlClientCache, getter.get,
+		filterCache.GetBloomFilter, connectionFactory, agent.topicMetaCache, partitionHashes)
+	agent.quotaManager = quota.NewManager(cfg.QuotaConf, func() (quota.ControlClient, error) {
+		return agent.controlClientCache.GetClient()
+	})
+	agent.aclManager = acls.NewManager(cfg.AclConf, func() (acls.ControlClient, error) {
+		return agent.controlClientCache.GetClient()
+	})
+	saslAuthManager := auth.NewScramSaslAuthManager(agent.lookupUserCredentials)
 	agent.kafkaServer = kafkaserver2.NewKafkaServer(cfg.KafkaListenerConfig.Address,
-		cfg.KafkaListenerConfig.TLSConfig, cfg.KafkaListenerConfig.AuthenticationType, agent.newKafkaHandler)
+		cfg.KafkaListenerConfig.TLSConfig, cfg.KafkaListenerConfig.AuthenticationType, saslAuthManager,
+		agent.newKafkaHandler)
 	agent.manifold = &membershipChangedManifold{listeners: []MembershipListener{agent.controller.MembershipChanged,
 		bf.MembershipChanged, fetchCache.MembershipChanged, groupCoord.MembershipChanged}}
 	agent.clusterMembershipFactory = clusterMembershipFactory
@@ -138,6 +168,10 @@ func NewAgentWithFactories(cfg Conf, objStore objstore.Client, connectionFactory
 	}
 	agent.compactionWorkersService = lsm.NewCompactionWorkerService(cfg.CompactionWorkersConf, objStore,
 		clFactory, true)
+	agent.registerMetrics(agent.metricsRegistry)
+	if cfg.MetricsConf.Enabled {
+		agent.metricsServer = metrics.NewServer(cfg.MetricsConf, agent.metricsRegistry)
+	}
 	return agent, nil
 }
 
@@ -160,6 +194,12 @@ func (a *Agent) Start() error {
 	if err := a.txCoordinator.Start(); err != nil {
 		return err
 	}
+	if err := a.quotaManager.Start(); err != nil {
+		return err
+	}
+	if err := a.aclManager.Start(); err != nil {
+		return err
+	}
 	if err := a.kafkaServer.Start(); err != nil {
 		return err
 	}
@@ -173,6 +213,11 @@ func (a *Agent) Start() error {
 	if err := a.transportServer.Start(); err != nil {
 		return err
 	}
+	if a.metricsServer != nil {
+		if err := a.metricsServer.Start(); err != nil {
+			return err
+		}
+	}
 	// We delay creation to start as we need to know the cluster and kafka listen addresses which aren't known until
 	// start of the socket servers as they could be using an ephemeral port
 	membershipData := common.MembershipData{
@@ -194,6 +239,11 @@ func (a *Agent) Stop() error {
 	if !a.started {
 		return nil
 	}
+	if a.metricsServer != nil {
+		if err := a.metricsServer.Stop(); err != nil {
+			return err
+		}
+	}
 	if err := a.compactionWorkersService.Stop(); err != nil {
 		return err
 	}
@@ -203,6 +253,12 @@ func (a *Agent) Stop() error {
 	if err := a.kafkaServer.Stop(); err != nil {
 		return err
 	}
+	if err := a.aclManager.Stop(); err != nil {
+		return err
+	}
+	if err := a.quotaManager.Stop(); err != nil {
+		return err
+	}
 	if err := a.txCoordinator.Stop(); err != nil {
 		return err
 	}
@@ -227,6 +283,17 @@ func (a *Agent) Stop() error {
 	return nil
 }
 
+// handleTopicDeleted updates the topic cache, and then removes the committed offsets of the deleted topic, which are
+// stored with the consumer groups, not with the topic
+func (a *Agent) handleTopicDeleted(ctx *transport.ConnectionContext, buff []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	if err := a.topicMetaCache.HandleTopicDeleted(ctx, buff, responseBuff, responseWriter); err != nil {
+		return err
+	}
+	go a.groupCoordinator.ReclaimDeletedTopicOffsets()
+	return nil
+}
+
 func (a *Agent) DeliveredClusterVersion() int {
 	return int(atomic.LoadInt64(&a.manifold.deliveredClusterVersion))
 }
@@ -255,6 +322,15 @@ func (a *Agent) ClusterListenAddress() string {
 	return a.transportServer.Address()
 }
 
+// lookupUserCredentials retrieves stored SCRAM credentials from the controller when a Kafka client authenticates
+func (a *Agent) lookupUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	cl, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return auth.UserCredentials{}, false, err
+	}
+	return cl.GetUserCredentials(username, mechanism)
+}
+
 type membershipChangedManifold struct {
 	listeners               []MembershipListener
 	deliveredClusterVersion int64
@@ -0,0 +1,687 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"math"
+)
+
+/*
+The methods in this file check the connection's principal is authorized for each of the resources a request operates on.
+The operations required are the same as Kafka's. Requests which change state are split into the parts which are
+authorized, which are handled as usual, and the parts which are not, which are failed with an authorization error. For
+requests which just read state, the response is filtered instead.
+*/
+
+const authorizationFailedMsg = "authorization failed"
+
+func (k *kafkaHandler) authorize(resourceType acls.ResourceType, resourceName string, operation acls.Operation) bool {
+	authContext := k.ctx.AuthContext()
+	return k.authorizer.Authorize(&authContext, k.ctx.ClientHost(), resourceType, resourceName, operation)
+}
+
+func (k *kafkaHandler) authorizeCluster(operation acls.Operation) bool {
+	return k.authorize(acls.ResourceTypeCluster, acls.ClusterResourceName, operation)
+}
+
+func (k *kafkaHandler) authorizeTopic(topic *string, operation acls.Operation) bool {
+	return k.authorize(acls.ResourceTypeTopic, common.SafeDerefStringPtr(topic), operation)
+}
+
+func (k *kafkaHandler) authorizeGroup(groupID *string, operation acls.Operation) bool {
+	return k.authorize(acls.ResourceTypeGroup, common.SafeDerefStringPtr(groupID), operation)
+}
+
+func (k *kafkaHandler) authorizeTransactionalID(transactionalID *string, operation acls.Operation) bool {
+	return k.authorize(acls.ResourceTypeTransactionalID, common.SafeDerefStringPtr(transactionalID), operation)
+}
+
+// authorizeConfigResource returns the error code to use if the operation on the config resource is not authorized, or
+// ErrorCodeNone if it is. Unsupported resource types are left for the handler to reject.
+func (k *kafkaHandler) authorizeConfigResource(resourceType int8, resourceName *string, operation acls.Operation) int16 {
+	switch resourceType {
+	case resourceTypeTopic:
+		if !k.authorizeTopic(resourceName, operation) {
+			return kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		}
+	case resourceTypeBroker:
+		if !k.authorizeCluster(operation) {
+			return kafkaprotocol.ErrorCodeClusterAuthorizationFailed
+		}
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
+// splitAuthorized splits items into those which are authorized and those which are not. If all are authorized, items
+// is returned as is.
+func splitAuthorized[T any](items []T, authorized func(item *T) bool) ([]T, []T) {
+	var allowed, denied []T
+	for i := range items {
+		if authorized(&items[i]) {
+			if denied != nil {
+				allowed = append(allowed, items[i])
+			}
+			continue
+		}
+		if denied == nil {
+			allowed = append(make([]T, 0, len(items)), items[:i]...)
+		}
+		denied = append(denied, items[i])
+	}
+	if denied == nil {
+		return items, nil
+	}
+	return allowed, denied
+}
+
+// authorizeProduceRequest returns the part of the request which is authorized, or nil if none of it is, along with a
+// response for the part which is not, or nil if all of it is. The same applies to the other authorizeXRequest methods.
+func (k *kafkaHandler) authorizeProduceRequest(
+	req *kafkaprotocol.ProduceRequest) (*kafkaprotocol.ProduceRequest, *kafkaprotocol.ProduceResponse) {
+	if req.TransactionalId != nil && !k.authorizeTransactionalID(req.TransactionalId, acls.OperationWrite) {
+		return nil, produceErrorResponse(req.TopicData, kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed)
+	}
+	authorized, unauthorized := splitAuthorized(req.TopicData,
+		func(topic *kafkaprotocol.ProduceRequestTopicProduceData) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationWrite)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	errResp := produceErrorResponse(unauthorized, kafkaprotocol.ErrorCodeTopicAuthorizationFailed)
+	if len(authorized) == 0 {
+		return nil, errResp
+	}
+	authorizedReq := *req
+	authorizedReq.TopicData = authorized
+	return &authorizedReq, errResp
+}
+
+func produceErrorResponse(topics []kafkaprotocol.ProduceRequestTopicProduceData,
+	errCode int16) *kafkaprotocol.ProduceResponse {
+	var resp kafkaprotocol.ProduceResponse
+	resp.Responses = make([]kafkaprotocol.ProduceResponseTopicProduceResponse, len(topics))
+	for i, topic := range topics {
+		resp.Responses[i].Name = topic.Name
+		resp.Responses[i].PartitionResponses = make([]kafkaprotocol.ProduceResponsePartitionProduceResponse,
+			len(topic.PartitionData))
+		for j, partition := range topic.PartitionData {
+			resp.Responses[i].PartitionResponses[j] = kafkaprotocol.ProduceResponsePartitionProduceResponse{
+				Index:           partition.Index,
+				ErrorCode:       errCode,
+				ErrorMessage:    common.StrPtr(authorizationFailedMsg),
+				BaseOffset:      -1,
+				LogAppendTimeMs: -1,
+				LogStartOffset:  -1,
+			}
+		}
+	}
+	return &resp
+}
+
+// authorizeFetchTopic is applied by the fetcher to every partition fetched, including those held in a fetch session
+func (k *kafkaHandler) authorizeFetchTopic(topic *string) bool {
+	return k.authorizeTopic(topic, acls.OperationRead)
+}
+
+func (k *kafkaHandler) authorizeListOffsetsResponse(resp *kafkaprotocol.ListOffsetsResponse) {
+	for i := range resp.Topics {
+		topic := &resp.Topics[i]
+		if k.authorizeTopic(topic.Name, acls.OperationDescribe) {
+			continue
+		}
+		for j := range topic.Partitions {
+			topic.Partitions[j] = kafkaprotocol.ListOffsetsResponseListOffsetsPartitionResponse{
+				PartitionIndex: topic.Partitions[j].PartitionIndex,
+				ErrorCode:      kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+				Timestamp:      -1,
+				Offset:         -1,
+				LeaderEpoch:    -1,
+			}
+		}
+	}
+}
+
+// authorizeMetadataResponse removes the topics which cannot be described when all topics were requested. Topics which
+// were requested explicitly are returned with an error instead, but a topic requested by id is reported as unknown so
+// as not to reveal that it exists.
+func (k *kafkaHandler) authorizeMetadataResponse(req *kafkaprotocol.MetadataRequest,
+	resp *kafkaprotocol.MetadataResponse) {
+	if req.Topics == nil {
+		resp.Topics, _ = splitAuthorized(resp.Topics, func(topic *kafkaprotocol.MetadataResponseMetadataResponseTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationDescribe)
+		})
+		return
+	}
+	for i := range resp.Topics {
+		topic := &resp.Topics[i]
+		if topic.Name == nil || k.authorizeTopic(topic.Name, acls.OperationDescribe) {
+			continue
+		}
+		errCode := int16(kafkaprotocol.ErrorCodeTopicAuthorizationFailed)
+		name := topic.Name
+		if req.Topics[i].Name == nil {
+			errCode = kafkaprotocol.ErrorCodeUnknownTopicID
+			name = nil
+		}
+		*topic = kafkaprotocol.MetadataResponseMetadataResponseTopic{
+			ErrorCode:                 errCode,
+			Name:                      name,
+			TopicId:                   req.Topics[i].TopicId,
+			Partitions:                []kafkaprotocol.MetadataResponseMetadataResponsePartition{},
+			TopicAuthorizedOperations: math.MinInt32,
+		}
+	}
+}
+
+func (k *kafkaHandler) authorizeOffsetCommitRequest(
+	req *kafkaprotocol.OffsetCommitRequest) (*kafkaprotocol.OffsetCommitRequest, *kafkaprotocol.OffsetCommitResponse) {
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return nil, offsetCommitErrorResponse(req.Topics, kafkaprotocol.ErrorCodeGroupAuthorizationFailed)
+	}
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationRead)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	errResp := offsetCommitErrorResponse(unauthorized, kafkaprotocol.ErrorCodeTopicAuthorizationFailed)
+	if len(authorized) == 0 {
+		return nil, errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, errResp
+}
+
+func offsetCommitErrorResponse(topics []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic,
+	errCode int16) *kafkaprotocol.OffsetCommitResponse {
+	var resp kafkaprotocol.OffsetCommitResponse
+	resp.Topics = make([]kafkaprotocol.OffsetCommitResponseOffsetCommitResponseTopic, len(topics))
+	for i, topic := range topics {
+		resp.Topics[i].Name = topic.Name
+		resp.Topics[i].Partitions = make([]kafkaprotocol.OffsetCommitResponseOffsetCommitResponsePartition,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			resp.Topics[i].Partitions[j].PartitionIndex = partition.PartitionIndex
+			resp.Topics[i].Partitions[j].ErrorCode = errCode
+		}
+	}
+	return &resp
+}
+
+// authorizeOffsetFetchResponse fails groups which cannot be described. Topics which cannot be described are failed if
+// they were requested explicitly, or removed if all topics were requested.
+func (k *kafkaHandler) authorizeOffsetFetchResponse(req *kafkaprotocol.OffsetFetchRequest,
+	resp *kafkaprotocol.OffsetFetchResponse) {
+	if len(req.Groups) > 0 {
+		// Version 8 and higher can fetch offsets for multiple groups
+		for i := range resp.Groups {
+			group := &resp.Groups[i]
+			if !k.authorizeGroup(group.GroupId, acls.OperationDescribe) {
+				group.ErrorCode = kafkaprotocol.ErrorCodeGroupAuthorizationFailed
+				group.Topics = []kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopics{}
+				continue
+			}
+			filterOffsetFetchTopics(k, &group.Topics, req.Groups[i].Topics == nil,
+				func(topic *kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopics) bool {
+					return k.authorizeTopic(topic.Name, acls.OperationDescribe)
+				},
+				func(topic *kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopics) {
+					for j := range topic.Partitions {
+						topic.Partitions[j] = kafkaprotocol.OffsetFetchResponseOffsetFetchResponsePartitions{
+							PartitionIndex:       topic.Partitions[j].PartitionIndex,
+							CommittedOffset:      -1,
+							CommittedLeaderEpoch: -1,
+							ErrorCode:            kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+						}
+					}
+				})
+		}
+		return
+	}
+	errCode := int16(kafkaprotocol.ErrorCodeTopicAuthorizationFailed)
+	if !k.authorizeGroup(req.GroupId, acls.OperationDescribe) {
+		// The top level error code is only sent in version 2 and higher, so the partitions are failed too
+		errCode = kafkaprotocol.ErrorCodeGroupAuthorizationFailed
+		resp.ErrorCode = errCode
+	}
+	filterOffsetFetchTopics(k, &resp.Topics, req.Topics == nil,
+		func(topic *kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopic) bool {
+			return errCode != kafkaprotocol.ErrorCodeGroupAuthorizationFailed &&
+				k.authorizeTopic(topic.Name, acls.OperationDescribe)
+		},
+		func(topic *kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopic) {
+			for j := range topic.Partitions {
+				topic.Partitions[j] = kafkaprotocol.OffsetFetchResponseOffsetFetchResponsePartition{
+					PartitionIndex:       topic.Partitions[j].PartitionIndex,
+					CommittedOffset:      -1,
+					CommittedLeaderEpoch: -1,
+					ErrorCode:            errCode,
+				}
+			}
+		})
+}
+
+// filterOffsetFetchTopics removes the unauthorized topics if all topics were requested, otherwise it fails them
+func filterOffsetFetchTopics[T any](k *kafkaHandler, topics *[]T, allTopics bool, authorized func(topic *T) bool,
+	fail func(topic *T)) {
+	if allTopics {
+		*topics, _ = splitAuthorized(*topics, authorized)
+		return
+	}
+	for i := range *topics {
+		if topic := &(*topics)[i]; !authorized(topic) {
+			fail(topic)
+		}
+	}
+}
+
+func (k *kafkaHandler) authorizeFindCoordinatorResponse(req *kafkaprotocol.FindCoordinatorRequest,
+	resp *kafkaprotocol.FindCoordinatorResponse) {
+	authorized := func(key *string) (bool, int16) {
+		switch req.KeyType {
+		case 0:
+			return k.authorizeGroup(key, acls.OperationDescribe), kafkaprotocol.ErrorCodeGroupAuthorizationFailed
+		case 1:
+			return k.authorizeTransactionalID(key, acls.OperationDescribe),
+				kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed
+		default:
+			// invalid key types are rejected by the coordinator
+			return true, kafkaprotocol.ErrorCodeNone
+		}
+	}
+	if resp.Coordinators != nil {
+		for i := range resp.Coordinators {
+			coordinator := &resp.Coordinators[i]
+			if ok, errCode := authorized(coordinator.Key); !ok {
+				*coordinator = kafkaprotocol.FindCoordinatorResponseCoordinator{
+					Key:          coordinator.Key,
+					NodeId:       -1,
+					Host:         common.StrPtr(""),
+					Port:         -1,
+					ErrorCode:    errCode,
+					ErrorMessage: common.StrPtr(authorizationFailedMsg),
+				}
+			}
+		}
+		return
+	}
+	if ok, errCode := authorized(req.Key); !ok {
+		resp.NodeId = -1
+		resp.Host = common.StrPtr("")
+		resp.Port = -1
+		resp.ErrorCode = errCode
+		resp.ErrorMessage = common.StrPtr(authorizationFailedMsg)
+	}
+}
+
+// authorizeListGroupsResponse removes the groups which cannot be described, unless the cluster can be described
+func (k *kafkaHandler) authorizeListGroupsResponse(resp *kafkaprotocol.ListGroupsResponse) {
+	if k.authorizeCluster(acls.OperationDescribe) {
+		return
+	}
+	resp.Groups, _ = splitAuthorized(resp.Groups, func(group *kafkaprotocol.ListGroupsResponseListedGroup) bool {
+		return k.authorizeGroup(group.GroupId, acls.OperationDescribe)
+	})
+}
+
+func (k *kafkaHandler) authorizeDescribeGroupsResponse(resp *kafkaprotocol.DescribeGroupsResponse) {
+	for i := range resp.Groups {
+		group := &resp.Groups[i]
+		if k.authorizeGroup(group.GroupId, acls.OperationDescribe) {
+			continue
+		}
+		*group = kafkaprotocol.DescribeGroupsResponseDescribedGroup{
+			ErrorCode:            kafkaprotocol.ErrorCodeGroupAuthorizationFailed,
+			GroupId:              group.GroupId,
+			GroupState:           common.StrPtr(""),
+			ProtocolType:         common.StrPtr(""),
+			ProtocolData:         common.StrPtr(""),
+			Members:              []kafkaprotocol.DescribeGroupsResponseDescribedGroupMember{},
+			AuthorizedOperations: math.MinInt32,
+		}
+	}
+}
+
+func (k *kafkaHandler) authorizeConsumerGroupDescribeResponse(resp *kafkaprotocol.ConsumerGroupDescribeResponse) {
+	for i := range resp.Groups {
+		group := &resp.Groups[i]
+		if k.authorizeGroup(group.GroupId, acls.OperationDescribe) {
+			continue
+		}
+		*group = kafkaprotocol.ConsumerGroupDescribeResponseDescribedGroup{
+			ErrorCode:            kafkaprotocol.ErrorCodeGroupAuthorizationFailed,
+			GroupId:              group.GroupId,
+			GroupState:           common.StrPtr(""),
+			AssignorName:         common.StrPtr(""),
+			Members:              []kafkaprotocol.ConsumerGroupDescribeResponseMember{},
+			AuthorizedOperations: math.MinInt32,
+		}
+	}
+}
+
+func (k *kafkaHandler) authorizeDeleteGroupsRequest(
+	req *kafkaprotocol.DeleteGroupsRequest) (*kafkaprotocol.DeleteGroupsRequest, *kafkaprotocol.DeleteGroupsResponse) {
+	authorized, unauthorized := splitAuthorized(req.GroupsNames, func(groupID **string) bool {
+		return k.authorizeGroup(*groupID, acls.OperationDelete)
+	})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.DeleteGroupsResponse
+	errResp.Results = make([]kafkaprotocol.DeleteGroupsResponseDeletableGroupResult, len(unauthorized))
+	for i, groupID := range unauthorized {
+		errResp.Results[i].GroupId = groupID
+		errResp.Results[i].ErrorCode = kafkaprotocol.ErrorCodeGroupAuthorizationFailed
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	return &kafkaprotocol.DeleteGroupsRequest{GroupsNames: authorized}, &errResp
+}
+
+func (k *kafkaHandler) authorizeOffsetDeleteRequest(
+	req *kafkaprotocol.OffsetDeleteRequest) (*kafkaprotocol.OffsetDeleteRequest, *kafkaprotocol.OffsetDeleteResponse) {
+	if !k.authorizeGroup(req.GroupId, acls.OperationDelete) {
+		return nil, &kafkaprotocol.OffsetDeleteResponse{ErrorCode: kafkaprotocol.ErrorCodeGroupAuthorizationFailed}
+	}
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.OffsetDeleteRequestOffsetDeleteRequestTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationRead)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.OffsetDeleteResponse
+	errResp.Topics = make([]kafkaprotocol.OffsetDeleteResponseOffsetDeleteResponseTopic, len(unauthorized))
+	for i, topic := range unauthorized {
+		errResp.Topics[i].Name = topic.Name
+		errResp.Topics[i].Partitions = make([]kafkaprotocol.OffsetDeleteResponseOffsetDeleteResponsePartition,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			errResp.Topics[i].Partitions[j].PartitionIndex = partition.PartitionIndex
+			errResp.Topics[i].Partitions[j].ErrorCode = kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		}
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, &errResp
+}
+
+func alterClientQuotasErrorResponse(req *kafkaprotocol.AlterClientQuotasRequest,
+	errCode int16) *kafkaprotocol.AlterClientQuotasResponse {
+	var resp kafkaprotocol.AlterClientQuotasResponse
+	resp.Entries = make([]kafkaprotocol.AlterClientQuotasResponseEntryData, len(req.Entries))
+	for i, entry := range req.Entries {
+		result := &resp.Entries[i]
+		result.ErrorCode = errCode
+		result.ErrorMessage = common.StrPtr(authorizationFailedMsg)
+		result.Entity = make([]kafkaprotocol.AlterClientQuotasResponseEntityData, len(entry.Entity))
+		for j, entityData := range entry.Entity {
+			result.Entity[j] = kafkaprotocol.AlterClientQuotasResponseEntityData{
+				EntityType: entityData.EntityType,
+				EntityName: entityData.EntityName,
+			}
+		}
+	}
+	return &resp
+}
+
+func (k *kafkaHandler) authorizeInitProducerIdRequest(req *kafkaprotocol.InitProducerIdRequest) int16 {
+	if req.TransactionalId != nil {
+		if !k.authorizeTransactionalID(req.TransactionalId, acls.OperationWrite) {
+			return kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed
+		}
+		return kafkaprotocol.ErrorCodeNone
+	}
+	// An idempotent producer must be able to write to the cluster, or to at least one topic
+	if !k.authorizeCluster(acls.OperationIdempotentWrite) {
+		authContext := k.ctx.AuthContext()
+		if !k.authorizer.AuthorizeAnyResource(&authContext, k.ctx.ClientHost(), acls.ResourceTypeTopic,
+			acls.OperationWrite) {
+			return kafkaprotocol.ErrorCodeClusterAuthorizationFailed
+		}
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
+func (k *kafkaHandler) authorizeAddOffsetsToTxnRequest(req *kafkaprotocol.AddOffsetsToTxnRequest) int16 {
+	if !k.authorizeTransactionalID(req.TransactionalId, acls.OperationWrite) {
+		return kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed
+	}
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return kafkaprotocol.ErrorCodeGroupAuthorizationFailed
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
+// authorizeAddPartitionsToTxnRequest returns an error response if any of the request is not authorized. As with Kafka,
+// if some topics are not authorized then none of the partitions are added.
+func (k *kafkaHandler) authorizeAddPartitionsToTxnRequest(
+	req *kafkaprotocol.AddPartitionsToTxnRequest) *kafkaprotocol.AddPartitionsToTxnResponse {
+	txnAuthorized := k.authorizeTransactionalID(req.V3AndBelowTransactionalId, acls.OperationWrite)
+	topicsAuthorized := make([]bool, len(req.V3AndBelowTopics))
+	allAuthorized := txnAuthorized
+	for i := range req.V3AndBelowTopics {
+		topicsAuthorized[i] = k.authorizeTopic(req.V3AndBelowTopics[i].Name, acls.OperationWrite)
+		allAuthorized = allAuthorized && topicsAuthorized[i]
+	}
+	if allAuthorized {
+		return nil
+	}
+	var resp kafkaprotocol.AddPartitionsToTxnResponse
+	resp.ResultsByTopicV3AndBelow = make([]kafkaprotocol.AddPartitionsToTxnResponseAddPartitionsToTxnTopicResult,
+		len(req.V3AndBelowTopics))
+	for i, topic := range req.V3AndBelowTopics {
+		var errCode int16
+		if !txnAuthorized {
+			errCode = kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed
+		} else if !topicsAuthorized[i] {
+			errCode = kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		} else {
+			errCode = kafkaprotocol.ErrorCodeOperationNotAttempted
+		}
+		result := &resp.ResultsByTopicV3AndBelow[i]
+		result.Name = topic.Name
+		result.ResultsByPartition = make([]kafkaprotocol.AddPartitionsToTxnResponseAddPartitionsToTxnPartitionResult,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			result.ResultsByPartition[j].PartitionIndex = partition
+			result.ResultsByPartition[j].PartitionErrorCode = errCode
+		}
+	}
+	return &resp
+}
+
+func (k *kafkaHandler) authorizeTxnOffsetCommitRequest(
+	req *kafkaprotocol.TxnOffsetCommitRequest) (*kafkaprotocol.TxnOffsetCommitRequest,
+	*kafkaprotocol.TxnOffsetCommitResponse) {
+	if !k.authorizeTransactionalID(req.TransactionalId, acls.OperationWrite) {
+		return nil, txnOffsetCommitErrorResponse(req.Topics, kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed)
+	}
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return nil, txnOffsetCommitErrorResponse(req.Topics, kafkaprotocol.ErrorCodeGroupAuthorizationFailed)
+	}
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.TxnOffsetCommitRequestTxnOffsetCommitRequestTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationRead)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	errResp := txnOffsetCommitErrorResponse(unauthorized, kafkaprotocol.ErrorCodeTopicAuthorizationFailed)
+	if len(authorized) == 0 {
+		return nil, errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, errResp
+}
+
+func txnOffsetCommitErrorResponse(topics []kafkaprotocol.TxnOffsetCommitRequestTxnOffsetCommitRequestTopic,
+	errCode int16) *kafkaprotocol.TxnOffsetCommitResponse {
+	var resp kafkaprotocol.TxnOffsetCommitResponse
+	resp.Topics = make([]kafkaprotocol.TxnOffsetCommitResponseTxnOffsetCommitResponseTopic, len(topics))
+	for i, topic := range topics {
+		resp.Topics[i].Name = topic.Name
+		resp.Topics[i].Partitions = make([]kafkaprotocol.TxnOffsetCommitResponseTxnOffsetCommitResponsePartition,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			resp.Topics[i].Partitions[j].PartitionIndex = partition.PartitionIndex
+			resp.Topics[i].Partitions[j].ErrorCode = errCode
+		}
+	}
+	return &resp
+}
+
+// authorizeCreateTopicsRequest authorizes creating all topics if the cluster allows create, otherwise each topic must
+func (k *kafkaHandler) authorizeCreateTopicsRequest(
+	req *kafkaprotocol.CreateTopicsRequest) (*kafkaprotocol.CreateTopicsRequest, *kafkaprotocol.CreateTopicsResponse) {
+	if k.authorizeCluster(acls.OperationCreate) {
+		return req, nil
+	}
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.CreateTopicsRequestCreatableTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationCreate)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.CreateTopicsResponse
+	errResp.Topics = make([]kafkaprotocol.CreateTopicsResponseCreatableTopicResult, len(unauthorized))
+	for i, topic := range unauthorized {
+		errResp.Topics[i].Name = topic.Name
+		setCreateTopicError(&errResp.Topics[i], kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+			authorizationFailedMsg)
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, &errResp
+}
+
+func (k *kafkaHandler) authorizeDeleteTopicsRequest(
+	req *kafkaprotocol.DeleteTopicsRequest) (*kafkaprotocol.DeleteTopicsRequest, *kafkaprotocol.DeleteTopicsResponse) {
+	authorized, unauthorized := splitAuthorized(req.TopicNames, func(topicName **string) bool {
+		return k.authorizeTopic(*topicName, acls.OperationDelete)
+	})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.DeleteTopicsResponse
+	errResp.Responses = make([]kafkaprotocol.DeleteTopicsResponseDeletableTopicResult, len(unauthorized))
+	for i, topicName := range unauthorized {
+		errResp.Responses[i].Name = topicName
+		errResp.Responses[i].ErrorCode = kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		errResp.Responses[i].ErrorMessage = common.StrPtr(authorizationFailedMsg)
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.TopicNames = authorized
+	return &authorizedReq, &errResp
+}
+
+func (k *kafkaHandler) authorizeCreatePartitionsRequest(
+	req *kafkaprotocol.CreatePartitionsRequest) (*kafkaprotocol.CreatePartitionsRequest,
+	*kafkaprotocol.CreatePartitionsResponse) {
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.CreatePartitionsRequestCreatePartitionsTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationAlter)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.CreatePartitionsResponse
+	errResp.Results = make([]kafkaprotocol.CreatePartitionsResponseCreatePartitionsTopicResult, len(unauthorized))
+	for i, topic := range unauthorized {
+		errResp.Results[i].Name = topic.Name
+		errResp.Results[i].ErrorCode = kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		errResp.Results[i].ErrorMessage = common.StrPtr(authorizationFailedMsg)
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, &errResp
+}
+
+func (k *kafkaHandler) authorizeDeleteRecordsRequest(
+	req *kafkaprotocol.DeleteRecordsRequest) (*kafkaprotocol.DeleteRecordsRequest, *kafkaprotocol.DeleteRecordsResponse) {
+	authorized, unauthorized := splitAuthorized(req.Topics,
+		func(topic *kafkaprotocol.DeleteRecordsRequestDeleteRecordsTopic) bool {
+			return k.authorizeTopic(topic.Name, acls.OperationDelete)
+		})
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	var errResp kafkaprotocol.DeleteRecordsResponse
+	errResp.Topics = make([]kafkaprotocol.DeleteRecordsResponseDeleteRecordsTopicResult, len(unauthorized))
+	for i, topic := range unauthorized {
+		errResp.Topics[i].Name = topic.Name
+		errResp.Topics[i].Partitions = make([]kafkaprotocol.DeleteRecordsResponseDeleteRecordsPartitionResult,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			errResp.Topics[i].Partitions[j].PartitionIndex = partition.PartitionIndex
+			errResp.Topics[i].Partitions[j].LowWatermark = -1
+			errResp.Topics[i].Partitions[j].ErrorCode = kafkaprotocol.ErrorCodeTopicAuthorizationFailed
+		}
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, &errResp
+}
+
+func (k *kafkaHandler) authorizeDescribeConfigsResponse(resp *kafkaprotocol.DescribeConfigsResponse) {
+	for i := range resp.Results {
+		result := &resp.Results[i]
+		errCode := k.authorizeConfigResource(result.ResourceType, result.ResourceName, acls.OperationDescribeConfigs)
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			result.Configs = []kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult{}
+			setDescribeConfigsError(result, errCode, authorizationFailedMsg)
+		}
+	}
+}
+
+func (k *kafkaHandler) authorizeIncrementalAlterConfigsRequest(
+	req *kafkaprotocol.IncrementalAlterConfigsRequest) (*kafkaprotocol.IncrementalAlterConfigsRequest,
+	*kafkaprotocol.IncrementalAlterConfigsResponse) {
+	var errResp kafkaprotocol.IncrementalAlterConfigsResponse
+	authorized, _ := splitAuthorized(req.Resources,
+		func(resource *kafkaprotocol.IncrementalAlterConfigsRequestAlterConfigsResource) bool {
+			errCode := k.authorizeConfigResource(resource.ResourceType, resource.ResourceName, acls.OperationAlterConfigs)
+			if errCode == kafkaprotocol.ErrorCodeNone {
+				return true
+			}
+			errResp.Responses = append(errResp.Responses,
+				kafkaprotocol.IncrementalAlterConfigsResponseAlterConfigsResourceResponse{
+					ErrorCode:    errCode,
+					ErrorMessage: common.StrPtr(authorizationFailedMsg),
+					ResourceType: resource.ResourceType,
+					ResourceName: resource.ResourceName,
+				})
+			return false
+		})
+	if len(errResp.Responses) == 0 {
+		return req, nil
+	}
+	if len(authorized) == 0 {
+		return nil, &errResp
+	}
+	authorizedReq := *req
+	authorizedReq.Resources = authorized
+	return &authorizedReq, &errResp
+}
@@ -0,0 +1,235 @@
+package agent
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	log "github.com/spirit-labs/tektite/logger"
+)
+
+// Kafka quota entity types
+const (
+	quotaEntityTypeUser     = "user"
+	quotaEntityTypeClientID = "client-id"
+)
+
+// Kafka quota filter match types
+const (
+	quotaMatchTypeExact     = 0
+	quotaMatchTypeDefault   = 1
+	quotaMatchTypeSpecified = 2
+)
+
+func (a *Agent) HandleDescribeClientQuotasRequest(
+	req *kafkaprotocol.DescribeClientQuotasRequest) *kafkaprotocol.DescribeClientQuotasResponse {
+	var resp kafkaprotocol.DescribeClientQuotasResponse
+	if err := validateQuotaFilter(req); err != nil {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
+		resp.ErrorMessage = common.StrPtr(err.Error())
+		return &resp
+	}
+	configs, err := a.getClientQuotas()
+	if err != nil {
+		resp.ErrorCode = kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeRequestTimedOut)
+		resp.ErrorMessage = common.StrPtr(err.Error())
+		return &resp
+	}
+	resp.Entries = []kafkaprotocol.DescribeClientQuotasResponseEntryData{}
+	for _, config := range configs {
+		if !quotaFilterMatches(req, &config.Entity) {
+			continue
+		}
+		entry := kafkaprotocol.DescribeClientQuotasResponseEntryData{
+			Values: make([]kafkaprotocol.DescribeClientQuotasResponseValueData, 0, len(config.Quotas)),
+		}
+		for _, component := range []struct {
+			entityType string
+			name       control.QuotaEntityName
+		}{{quotaEntityTypeUser, config.Entity.User}, {quotaEntityTypeClientID, config.Entity.ClientID}} {
+			if component.name.Type == control.QuotaEntityNameNone {
+				continue
+			}
+			entityData := kafkaprotocol.DescribeClientQuotasResponseEntityData{
+				EntityType: common.StrPtr(component.entityType),
+			}
+			if component.name.Type == control.QuotaEntityNameSpecific {
+				entityData.EntityName = common.StrPtr(component.name.Name)
+			}
+			entry.Entity = append(entry.Entity, entityData)
+		}
+		for _, key := range []string{control.QuotaKeyConsumerByteRate, control.QuotaKeyProducerByteRate} {
+			if value, ok := config.Quotas[key]; ok {
+				entry.Values = append(entry.Values, kafkaprotocol.DescribeClientQuotasResponseValueData{
+					Key:   common.StrPtr(key),
+					Value: value,
+				})
+			}
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return &resp
+}
+
+func validateQuotaFilter(req *kafkaprotocol.DescribeClientQuotasRequest) error {
+	seen := map[string]struct{}{}
+	for _, component := range req.Components {
+		entityType := common.SafeDerefStringPtr(component.EntityType)
+		if entityType != quotaEntityTypeUser && entityType != quotaEntityTypeClientID {
+			return errors.Errorf("unsupported quota entity type: %s", entityType)
+		}
+		if _, exists := seen[entityType]; exists {
+			return errors.Errorf("duplicate quota filter component entity type: %s", entityType)
+		}
+		seen[entityType] = struct{}{}
+		switch component.MatchType {
+		case quotaMatchTypeExact:
+			if component.Match == nil {
+				return errors.Errorf("quota filter component for %s must specify a name to match", entityType)
+			}
+		case quotaMatchTypeDefault, quotaMatchTypeSpecified:
+		default:
+			return errors.Errorf("unsupported quota filter match type: %d", component.MatchType)
+		}
+	}
+	return nil
+}
+
+func quotaFilterMatches(req *kafkaprotocol.DescribeClientQuotasRequest, entity *control.ClientQuotaEntity) bool {
+	var userMatched, clientIDMatched bool
+	for _, component := range req.Components {
+		name := &entity.ClientID
+		matched := &clientIDMatched
+		if common.SafeDerefStringPtr(component.EntityType) == quotaEntityTypeUser {
+			name = &entity.User
+			matched = &userMatched
+		}
+		switch component.MatchType {
+		case quotaMatchTypeExact:
+			if name.Type != control.QuotaEntityNameSpecific || name.Name != *component.Match {
+				return false
+			}
+		case quotaMatchTypeDefault:
+			if name.Type != control.QuotaEntityNameDefault {
+				return false
+			}
+		case quotaMatchTypeSpecified:
+			if name.Type == control.QuotaEntityNameNone {
+				return false
+			}
+		}
+		*matched = true
+	}
+	if req.Strict {
+		// The entity must not have any components which aren't in the filter
+		if entity.User.Type != control.QuotaEntityNameNone && !userMatched {
+			return false
+		}
+		if entity.ClientID.Type != control.QuotaEntityNameNone && !clientIDMatched {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *Agent) HandleAlterClientQuotasRequest(
+	req *kafkaprotocol.AlterClientQuotasRequest) *kafkaprotocol.AlterClientQuotasResponse {
+	var resp kafkaprotocol.AlterClientQuotasResponse
+	resp.Entries = make([]kafkaprotocol.AlterClientQuotasResponseEntryData, len(req.Entries))
+	var alterations []control.ClientQuotaAlteration
+	var alterationIndexes []int
+	for i, entry := range req.Entries {
+		result := &resp.Entries[i]
+		result.Entity = make([]kafkaprotocol.AlterClientQuotasResponseEntityData, len(entry.Entity))
+		for j, entityData := range entry.Entity {
+			result.Entity[j] = kafkaprotocol.AlterClientQuotasResponseEntityData{
+				EntityType: entityData.EntityType,
+				EntityName: entityData.EntityName,
+			}
+		}
+		alteration, err := createClientQuotaAlteration(&entry)
+		if err == nil {
+			err = control.ValidateClientQuotaAlteration(&alteration)
+		}
+		if err != nil {
+			result.ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
+			result.ErrorMessage = common.StrPtr(err.Error())
+			continue
+		}
+		alterations = append(alterations, alteration)
+		alterationIndexes = append(alterationIndexes, i)
+	}
+	if len(alterations) == 0 {
+		return &resp
+	}
+	if err := a.alterClientQuotas(alterations, req.ValidateOnly); err != nil {
+		errCode := kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeRequestTimedOut)
+		if common.IsTektiteErrorWithCode(err, common.InvalidConfiguration) {
+			errCode = kafkaprotocol.ErrorCodeInvalidRequest
+		}
+		for _, index := range alterationIndexes {
+			resp.Entries[index].ErrorCode = errCode
+			resp.Entries[index].ErrorMessage = common.StrPtr(err.Error())
+		}
+		return &resp
+	}
+	if !req.ValidateOnly {
+		// Apply the new quotas on this agent straight away - other agents will pick them up on their next refresh
+		if err := a.quotaManager.Refresh(); err != nil {
+			log.Warnf("failed to refresh client quotas: %v", err)
+		}
+	}
+	return &resp
+}
+
+func createClientQuotaAlteration(
+	entry *kafkaprotocol.AlterClientQuotasRequestEntryData) (control.ClientQuotaAlteration, error) {
+	var alteration control.ClientQuotaAlteration
+	for _, entityData := range entry.Entity {
+		var name *control.QuotaEntityName
+		entityType := common.SafeDerefStringPtr(entityData.EntityType)
+		switch entityType {
+		case quotaEntityTypeUser:
+			name = &alteration.Entity.User
+		case quotaEntityTypeClientID:
+			name = &alteration.Entity.ClientID
+		default:
+			return control.ClientQuotaAlteration{}, errors.Errorf("unsupported quota entity type: %s", entityType)
+		}
+		if name.Type != control.QuotaEntityNameNone {
+			return control.ClientQuotaAlteration{}, errors.Errorf("duplicate quota entity type: %s", entityType)
+		}
+		if entityData.EntityName == nil {
+			name.Type = control.QuotaEntityNameDefault
+		} else {
+			name.Type = control.QuotaEntityNameSpecific
+			name.Name = *entityData.EntityName
+		}
+	}
+	alteration.Ops = make([]control.ClientQuotaOp, len(entry.Ops))
+	for i, op := range entry.Ops {
+		alteration.Ops[i] = control.ClientQuotaOp{
+			Key:    common.SafeDerefStringPtr(op.Key),
+			Value:  op.Value,
+			Remove: op.Remove,
+		}
+	}
+	return alteration, nil
+}
+
+func (a *Agent) getClientQuotas() ([]control.ClientQuotaConfig, error) {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetClientQuotas()
+}
+
+func (a *Agent) alterClientQuotas(alterations []control.ClientQuotaAlteration, validateOnly bool) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.AlterClientQuotas(alterations, validateOnly)
+}
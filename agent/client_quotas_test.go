@@ -0,0 +1,271 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAlterDescribeClientQuotas(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1} {
+		testAlterDescribeClientQuotas(t, apiVersion)
+	}
+}
+
+func testAlterDescribeClientQuotas(t *testing.T, apiVersion int16) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	userEntity := []kafkaprotocol.AlterClientQuotasRequestEntityData{
+		{EntityType: common.StrPtr(quotaEntityTypeUser), EntityName: common.StrPtr("user1")},
+	}
+	userAndDefaultClientEntity := []kafkaprotocol.AlterClientQuotasRequestEntityData{
+		{EntityType: common.StrPtr(quotaEntityTypeUser), EntityName: common.StrPtr("user1")},
+		{EntityType: common.StrPtr(quotaEntityTypeClientID)},
+	}
+	req := &kafkaprotocol.AlterClientQuotasRequest{
+		Entries: []kafkaprotocol.AlterClientQuotasRequestEntryData{
+			{Entity: userEntity, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: 1000},
+				{Key: common.StrPtr(control.QuotaKeyConsumerByteRate), Value: 2000},
+			}},
+			{Entity: userAndDefaultClientEntity, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: 3000},
+			}},
+			// unsupported key
+			{Entity: userEntity, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr("request_percentage"), Value: 10},
+			}},
+			// unsupported entity type
+			{Entity: []kafkaprotocol.AlterClientQuotasRequestEntityData{
+				{EntityType: common.StrPtr("ip"), EntityName: common.StrPtr("127.0.0.1")},
+			}, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: 1000},
+			}},
+		},
+		ValidateOnly: true,
+	}
+	resp := sendAlterClientQuotas(t, conn, req, apiVersion)
+	require.Equal(t, 4, len(resp.Entries))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[1].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(resp.Entries[2].ErrorCode))
+	require.NotNil(t, resp.Entries[2].ErrorMessage)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(resp.Entries[3].ErrorCode))
+	require.Equal(t, "ip", common.SafeDerefStringPtr(resp.Entries[3].Entity[0].EntityType))
+
+	// Nothing is altered when validating only
+	describeResp := sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(describeResp.ErrorCode))
+	require.Equal(t, 0, len(describeResp.Entries))
+
+	req.ValidateOnly = false
+	resp = sendAlterClientQuotas(t, conn, req, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[1].ErrorCode))
+
+	// All quotas
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(describeResp.ErrorCode))
+	require.Equal(t, 2, len(describeResp.Entries))
+	entry := describeResp.Entries[0]
+	require.Equal(t, 1, len(entry.Entity))
+	require.Equal(t, quotaEntityTypeUser, common.SafeDerefStringPtr(entry.Entity[0].EntityType))
+	require.Equal(t, "user1", common.SafeDerefStringPtr(entry.Entity[0].EntityName))
+	require.Equal(t, []kafkaprotocol.DescribeClientQuotasResponseValueData{
+		{Key: common.StrPtr(control.QuotaKeyConsumerByteRate), Value: 2000},
+		{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: 1000},
+	}, entry.Values)
+	entry = describeResp.Entries[1]
+	require.Equal(t, 2, len(entry.Entity))
+	require.Equal(t, quotaEntityTypeClientID, common.SafeDerefStringPtr(entry.Entity[1].EntityType))
+	require.Nil(t, entry.Entity[1].EntityName)
+	require.Equal(t, []kafkaprotocol.DescribeClientQuotasResponseValueData{
+		{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: 3000},
+	}, entry.Values)
+
+	// Strict match on the user only returns the user entity
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{
+		Components: []kafkaprotocol.DescribeClientQuotasRequestComponentData{
+			{EntityType: common.StrPtr(quotaEntityTypeUser), MatchType: quotaMatchTypeExact, Match: common.StrPtr("user1")},
+		},
+		Strict: true,
+	}, apiVersion)
+	require.Equal(t, 1, len(describeResp.Entries))
+	require.Equal(t, 1, len(describeResp.Entries[0].Entity))
+
+	// Non strict match returns both
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{
+		Components: []kafkaprotocol.DescribeClientQuotasRequestComponentData{
+			{EntityType: common.StrPtr(quotaEntityTypeUser), MatchType: quotaMatchTypeSpecified},
+		},
+	}, apiVersion)
+	require.Equal(t, 2, len(describeResp.Entries))
+
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{
+		Components: []kafkaprotocol.DescribeClientQuotasRequestComponentData{
+			{EntityType: common.StrPtr(quotaEntityTypeClientID), MatchType: quotaMatchTypeDefault},
+		},
+	}, apiVersion)
+	require.Equal(t, 1, len(describeResp.Entries))
+	require.Equal(t, 2, len(describeResp.Entries[0].Entity))
+
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{
+		Components: []kafkaprotocol.DescribeClientQuotasRequestComponentData{
+			{EntityType: common.StrPtr("ip"), MatchType: quotaMatchTypeSpecified},
+		},
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(describeResp.ErrorCode))
+
+	// Remove the quotas
+	resp = sendAlterClientQuotas(t, conn, &kafkaprotocol.AlterClientQuotasRequest{
+		Entries: []kafkaprotocol.AlterClientQuotasRequestEntryData{
+			{Entity: userEntity, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Remove: true},
+				{Key: common.StrPtr(control.QuotaKeyConsumerByteRate), Remove: true},
+			}},
+			{Entity: userAndDefaultClientEntity, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Remove: true},
+			}},
+		},
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[1].ErrorCode))
+	describeResp = sendDescribeClientQuotas(t, conn, &kafkaprotocol.DescribeClientQuotasRequest{}, apiVersion)
+	require.Equal(t, 0, len(describeResp.Entries))
+}
+
+func TestProduceThrottledByQuota(t *testing.T) {
+	topicName := "test-topic-1"
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: topicName, PartitionCount: 10},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	batch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10)
+	// The quota allows one batch every 100 seconds
+	quota := float64(len(batch)) / 100
+	resp := sendAlterClientQuotas(t, conn, &kafkaprotocol.AlterClientQuotasRequest{
+		Entries: []kafkaprotocol.AlterClientQuotasRequestEntryData{
+			{Entity: []kafkaprotocol.AlterClientQuotasRequestEntityData{
+				{EntityType: common.StrPtr(quotaEntityTypeClientID)},
+			}, Ops: []kafkaprotocol.AlterClientQuotasRequestOpData{
+				{Key: common.StrPtr(control.QuotaKeyProducerByteRate), Value: quota},
+			}},
+		},
+	}, 1)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Entries[0].ErrorCode))
+
+	req := kafkaprotocol.ProduceRequest{
+		Acks:      -1,
+		TimeoutMs: 1234,
+		TopicData: []kafkaprotocol.ProduceRequestTopicProduceData{
+			{
+				Name: common.StrPtr(topicName),
+				PartitionData: []kafkaprotocol.ProduceRequestPartitionProduceData{
+					{Index: 1, Records: [][]byte{batch}},
+				},
+			},
+		},
+	}
+	start := time.Now()
+	r, err := conn.SendRequest(&req, kafkaprotocol.APIKeyProduce, 9, &kafkaprotocol.ProduceResponse{})
+	require.NoError(t, err)
+	produceResp := r.(*kafkaprotocol.ProduceResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(produceResp.Responses[0].PartitionResponses[0].ErrorCode))
+	// A new sensor measures the rate over at least 10 seconds, so the rate is 10 * quota and the client would have to
+	// wait 90 seconds for it to fall back to the quota. Throttle time is capped to the full 11 second window.
+	throttleTimeMs := int(produceResp.ThrottleTimeMs)
+	require.Equal(t, 11000, throttleTimeMs)
+
+	// The connection is muted, so another request on it is not handled until the throttle time has passed. We don't
+	// want to wait that long, so we check the agent is still serving other connections while it's muted
+	conn2 := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn2.Close()
+		require.NoError(t, err)
+	}()
+	describeResp := sendDescribeClientQuotas(t, conn2, &kafkaprotocol.DescribeClientQuotasRequest{}, 1)
+	require.Equal(t, 1, len(describeResp.Entries))
+	require.Less(t, time.Since(start), time.Duration(throttleTimeMs)*time.Millisecond)
+}
+
+func TestUnauthorizedProduceNotThrottled(t *testing.T) {
+	topicName := "test-topic-1"
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: topicName, PartitionCount: 1},
+	}
+	cfg := NewConf()
+	cfg.AclConf.Enabled = true
+	agent, _, tearDown := setupAgent(t, topicInfos, cfg)
+	defer tearDown(t)
+
+	batch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10)
+	cl, err := agent.controller.Client()
+	require.NoError(t, err)
+	// The quota allows one batch every 100 seconds
+	err = cl.AlterClientQuotas([]control.ClientQuotaAlteration{
+		{Entity: control.ClientQuotaEntity{ClientID: control.QuotaEntityName{Type: control.QuotaEntityNameDefault}},
+			Ops: []control.ClientQuotaOp{{Key: control.QuotaKeyProducerByteRate, Value: float64(len(batch)) / 100}}},
+	}, false)
+	require.NoError(t, err)
+	err = agent.quotaManager.Refresh()
+	require.NoError(t, err)
+	err = agent.aclManager.Refresh()
+	require.NoError(t, err)
+
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+	// There are no ACLs, so the produce is not authorized and must not count towards the quota
+	r, err := conn.SendRequest(&kafkaprotocol.ProduceRequest{
+		Acks:      -1,
+		TimeoutMs: 1234,
+		TopicData: []kafkaprotocol.ProduceRequestTopicProduceData{
+			{Name: common.StrPtr(topicName), PartitionData: []kafkaprotocol.ProduceRequestPartitionProduceData{
+				{Index: 0, Records: [][]byte{batch}},
+			}},
+		},
+	}, kafkaprotocol.APIKeyProduce, 9, &kafkaprotocol.ProduceResponse{})
+	require.NoError(t, err)
+	produceResp := r.(*kafkaprotocol.ProduceResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+		int(produceResp.Responses[0].PartitionResponses[0].ErrorCode))
+	require.Equal(t, 0, int(produceResp.ThrottleTimeMs))
+}
+
+func sendAlterClientQuotas(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.AlterClientQuotasRequest,
+	apiVersion int16) *kafkaprotocol.AlterClientQuotasResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyAlterClientQuotas, apiVersion,
+		&kafkaprotocol.AlterClientQuotasResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.AlterClientQuotasResponse)
+	require.Equal(t, len(req.Entries), len(resp.Entries))
+	return resp
+}
+
+func sendDescribeClientQuotas(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.DescribeClientQuotasRequest,
+	apiVersion int16) *kafkaprotocol.DescribeClientQuotasResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDescribeClientQuotas, apiVersion,
+		&kafkaprotocol.DescribeClientQuotasResponse{})
+	require.NoError(t, err)
+	return r.(*kafkaprotocol.DescribeClientQuotasResponse)
+}
@@ -8,16 +8,22 @@ import (
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/topicmeta"
+	"math"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
 )
 
 func (a *Agent) HandleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.MetadataRequest) (*kafkaprotocol.MetadataResponse, error) {
 	resp := &kafkaprotocol.MetadataResponse{}
+	// We don't support authorized operations, INT32_MIN indicates they are not provided
+	resp.ClusterAuthorizedOperations = math.MinInt32
 	resp.Topics = make([]kafkaprotocol.MetadataResponseMetadataResponseTopic, len(req.Topics))
 	for i, topicData := range req.Topics {
 		resp.Topics[i].Name = topicData.Name
+		resp.Topics[i].TopicId = topicData.TopicId
+		resp.Topics[i].TopicAuthorizedOperations = math.MinInt32
 	}
 	err := a.handleMetadataRequest(hdr, req, resp)
 	if err != nil {
@@ -60,6 +66,17 @@ func getAZFromClientID(clientID string) string {
 	return clientID[ind:]
 }
 
+// getOtherAzs returns the AZs, other than the provided AZ, which contain agents, in the order they are first seen
+func getOtherAzs(az string, agents []control.AgentMeta) []string {
+	var azs []string
+	for _, meta := range agents {
+		if meta.Location != az && !slices.Contains(azs, meta.Location) {
+			azs = append(azs, meta.Location)
+		}
+	}
+	return azs
+}
+
 func getAgentsInAz(az string, agents []control.AgentMeta) []control.AgentMeta {
 	var agentsInSameAz []control.AgentMeta
 	for _, meta := range agents {
@@ -74,7 +91,7 @@ func (a *Agent) handleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kaf
 	clientID := common.SafeDerefStringPtr(hdr.ClientId)
 	az := getAZFromClientID(clientID)
 	if az == "" {
-		log.Warnf("Kafka client connecting with a ClientID (\"%s\") which does not contain availability zone. This means there may be unwanted cross AZ traffic. Please append tek_az=<availability zone> to the client id.",
+		log.Debugf("Kafka client connecting with a ClientID (\"%s\") which does not contain availability zone. Produce requests may be sent across availability zones. Consumers can set client.rack to fetch from their own availability zone.",
 			clientID)
 	}
 	client, err := a.controlClientCache.GetClient()
@@ -93,8 +110,11 @@ func (a *Agent) handleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kaf
 		log.Warnf("There are no agents available for request availability zone: %s - availability zone %s will be chosen instead", az, azOther)
 		agents = getAgentsInAz(azOther, clusterMetadata)
 	}
-	resp.Brokers = make([]kafkaprotocol.MetadataResponseMetadataResponseBroker, len(agents))
-	for i, agent := range agents {
+	// Partition leaders are chosen from the agents in the client's AZ, but we return agents in all AZs, with the AZ as
+	// the rack. Agents in other AZs are returned as replicas of each partition, so consumers which set client.rack can
+	// be directed to fetch from an agent in their own AZ using the preferred read replica in the fetch response.
+	resp.Brokers = make([]kafkaprotocol.MetadataResponseMetadataResponseBroker, len(clusterMetadata))
+	for i, agent := range clusterMetadata {
 		host, sPort, err := net.SplitHostPort(agent.KafkaAddress)
 		if err != nil {
 			return err
@@ -103,12 +123,21 @@ func (a *Agent) handleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kaf
 		if err != nil {
 			return err
 		}
+		location := agent.Location
 		resp.Brokers[i] = kafkaprotocol.MetadataResponseMetadataResponseBroker{
 			Host:   &host,
 			Port:   int32(port),
 			NodeId: agent.ID,
+			Rack:   &location,
 		}
 	}
+	var replicaAgents [][]control.AgentMeta
+	for _, otherAz := range getOtherAzs(agents[0].Location, clusterMetadata) {
+		replicaAgents = append(replicaAgents, getAgentsInAz(otherAz, clusterMetadata))
+	}
+	// Admin requests such as CreateTopics are sent to the controller, but they can be handled by any agent, so we choose
+	// one in the same AZ
+	resp.ControllerId = agents[0].ID
 	// In version 1 and higher, an empty array indicates "request metadata for no topics," and a null array is used to
 	// indicate "request metadata for all topics."
 	if req.Topics == nil {
@@ -119,23 +148,40 @@ func (a *Agent) handleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kaf
 		}
 		resp.Topics = make([]kafkaprotocol.MetadataResponseMetadataResponseTopic, len(topicInfos))
 		for i, topicInfo := range topicInfos {
-			top, err := a.populateTopicMetadata(&topicInfo, agents)
+			top, err := a.populateTopicMetadata(&topicInfo, agents, replicaAgents)
 			if err != nil {
 				return err
 			}
 			resp.Topics[i] = *top
 		}
 	} else {
+		var allTopicInfos []topicmeta.TopicInfo
 		for i, top := range req.Topics {
-			topicName := common.SafeDerefStringPtr(top.Name)
-			topicInfo, _, exists, err := client.GetTopicInfo(topicName)
-			if err != nil {
-				return err
+			var topicInfo topicmeta.TopicInfo
+			var exists bool
+			if top.Name == nil {
+				// In version 12 and higher, topics can be requested by id
+				if allTopicInfos == nil {
+					allTopicInfos, err = client.GetAllTopicInfos()
+					if err != nil {
+						return err
+					}
+				}
+				topicInfo, exists = findTopicInfoByKafkaTopicID(allTopicInfos, top.TopicId)
+				if !exists {
+					resp.Topics[i].ErrorCode = kafkaprotocol.ErrorCodeUnknownTopicID
+					continue
+				}
+			} else {
+				topicInfo, _, exists, err = client.GetTopicInfo(*top.Name)
+				if err != nil {
+					return err
+				}
 			}
 			if !exists {
 				resp.Topics[i].ErrorCode = kafkaprotocol.ErrorCodeUnknownTopicOrPartition
 			} else {
-				top, err := a.populateTopicMetadata(&topicInfo, agents)
+				top, err := a.populateTopicMetadata(&topicInfo, agents, replicaAgents)
 				if err != nil {
 					return err
 				}
@@ -146,6 +192,19 @@ func (a *Agent) handleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kaf
 	return err
 }
 
+func findTopicInfoByKafkaTopicID(topicInfos []topicmeta.TopicInfo, kafkaTopicID []byte) (topicmeta.TopicInfo, bool) {
+	topicID, ok := topicmeta.TopicIDFromKafkaTopicID(kafkaTopicID)
+	if !ok {
+		return topicmeta.TopicInfo{}, false
+	}
+	for _, info := range topicInfos {
+		if info.ID == topicID {
+			return info, true
+		}
+	}
+	return topicmeta.TopicInfo{}, false
+}
+
 func (a *Agent) IsLeader(topicID int, partitionID int) (bool, error) {
 	partHash, err := a.partitionHashes.GetPartitionHash(topicID, partitionID)
 	if err != nil {
@@ -157,9 +216,12 @@ func (a *Agent) IsLeader(topicID int, partitionID int) (bool, error) {
 	return leader.ID == a.MemberID(), nil
 }
 
-func (a *Agent) populateTopicMetadata(topicInfo *topicmeta.TopicInfo, agents []control.AgentMeta) (*kafkaprotocol.MetadataResponseMetadataResponseTopic, error) {
+func (a *Agent) populateTopicMetadata(topicInfo *topicmeta.TopicInfo, agents []control.AgentMeta,
+	replicaAgents [][]control.AgentMeta) (*kafkaprotocol.MetadataResponseMetadataResponseTopic, error) {
 	var topic kafkaprotocol.MetadataResponseMetadataResponseTopic
 	topic.Name = &topicInfo.Name
+	topic.TopicId = topicInfo.KafkaTopicID()
+	topic.TopicAuthorizedOperations = math.MinInt32
 	topic.Partitions = make([]kafkaprotocol.MetadataResponseMetadataResponsePartition, topicInfo.PartitionCount)
 	for i := 0; i < topicInfo.PartitionCount; i++ {
 		var part kafkaprotocol.MetadataResponseMetadataResponsePartition
@@ -172,8 +234,17 @@ func (a *Agent) populateTopicMetadata(topicInfo *topicmeta.TopicInfo, agents []c
 		index := common.CalcMemberForHash(partHash, len(agents))
 		leader := agents[index]
 		part.LeaderId = leader.ID
-		// We don't fill in the replica nodes -if a produce returns NotLeaderOrFollower then the client will request
-		// metadata again and get the correct leader
+		// We don't have leader epochs, so clients will not perform leader epoch validation
+		part.LeaderEpoch = -1
+		// The replicas are the leader, and the agent which serves the partition in each of the other AZs. These are the
+		// agents that a fetch can be directed to with a preferred read replica.
+		part.ReplicaNodes = make([]int32, 0, 1+len(replicaAgents))
+		part.ReplicaNodes = append(part.ReplicaNodes, leader.ID)
+		for _, azAgents := range replicaAgents {
+			part.ReplicaNodes = append(part.ReplicaNodes, azAgents[common.CalcMemberForHash(partHash, len(azAgents))].ID)
+		}
+		part.IsrNodes = part.ReplicaNodes
+		part.OfflineReplicas = []int32{}
 		topic.Partitions[i] = part
 	}
 	return &topic, nil
@@ -1,8 +1,13 @@
 package agent
 
 import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	"github.com/spirit-labs/tektite/objstore/localfs"
+	"github.com/spirit-labs/tektite/objstore/minio"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestInvalidMembershipUpdateInterval(t *testing.T) {
@@ -42,3 +47,161 @@ func TestInvalidConsumerGroupInitialJoinDelayInterval(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, "invalid value for consumer-group-initial-join-delay-ms must be >= 0 ms", err.Error())
 }
+
+func TestInvalidKafkaAuthenticationType(t *testing.T) {
+	conf := CommandConf{}
+	conf.MembershipUpdateIntervalMs = 100
+	conf.MembershipEvictionIntervalMs = 100
+	conf.KafkaAuthenticationType = "PLAIN"
+	_, err := CreateConfFromCommandConf(conf)
+	require.Error(t, err)
+	require.Equal(t, "invalid value for kafka-authentication-type must be one of SCRAM-SHA-256 or SCRAM-SHA-512", err.Error())
+}
+
+func TestMetricsListenAddress(t *testing.T) {
+	conf := CommandConf{}
+	conf.MembershipUpdateIntervalMs = 100
+	conf.MembershipEvictionIntervalMs = 100
+	cfg, err := CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.False(t, cfg.MetricsConf.Enabled)
+
+	conf.MetricsListenAddress = "localhost:9102"
+	cfg, err = CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.True(t, cfg.MetricsConf.Enabled)
+	require.Equal(t, "localhost:9102", cfg.MetricsConf.ListenAddress)
+	require.Equal(t, "/metrics", cfg.MetricsConf.Path)
+}
+
+func TestKafkaAuthorization(t *testing.T) {
+	conf := CommandConf{}
+	conf.MembershipUpdateIntervalMs = 100
+	conf.MembershipEvictionIntervalMs = 100
+	cfg, err := CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.False(t, cfg.AclConf.Enabled)
+
+	conf.KafkaAuthorizationEnabled = true
+	conf.KafkaSuperUsers = []string{"User:admin"}
+	cfg, err = CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.True(t, cfg.AclConf.Enabled)
+	require.Equal(t, []string{"User:admin"}, cfg.AclConf.SuperUsers)
+}
+
+func TestTableFormatAndCompression(t *testing.T) {
+	conf := CommandConf{}
+	conf.MembershipUpdateIntervalMs = 100
+	conf.MembershipEvictionIntervalMs = 100
+	cfg, err := CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.Equal(t, common.DataFormatV1, cfg.PusherConf.DataFormat)
+	require.Equal(t, compress.CompressionTypeNone, cfg.PusherConf.TableCompression)
+
+	conf.TableFormat = 2
+	conf.TableCompression = "zstd"
+	conf.TableBloomFilterBitsPerKey = 10
+	cfg, err = CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, common.DataFormatV2, cfg.PusherConf.DataFormat)
+	require.Equal(t, common.DataFormatV2, cfg.CompactionWorkersConf.DataFormat)
+	require.Equal(t, common.DataFormatV2, cfg.ControllerConf.DataFormat)
+	require.Equal(t, compress.CompressionTypeZstd, cfg.PusherConf.TableCompression)
+	require.Equal(t, compress.CompressionTypeZstd, cfg.CompactionWorkersConf.TableCompression)
+	require.Equal(t, 10, cfg.PusherConf.TableBloomFilterBitsPerKey)
+	require.Equal(t, 10, cfg.CompactionWorkersConf.TableBloomFilterBitsPerKey)
+
+	conf.TableCompression = "none"
+	conf.TableFormat = 1
+	cfg, err = CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.Error(t, cfg.Validate())
+	conf.TableCompression = "zstd"
+	conf.TableBloomFilterBitsPerKey = 0
+
+	conf.TableFormat = 1
+	cfg, err = CreateConfFromCommandConf(conf)
+	require.NoError(t, err)
+	require.Error(t, cfg.Validate())
+
+	conf.TableFormat = 3
+	_, err = CreateConfFromCommandConf(conf)
+	require.Error(t, err)
+	require.Equal(t, "invalid value for table-format must be one of 1 or 2", err.Error())
+
+	conf.TableFormat = 2
+	conf.TableCompression = "gzip"
+	_, err = CreateConfFromCommandConf(conf)
+	require.Error(t, err)
+}
+
+func TestCreateObjStoreClient(t *testing.T) {
+	conf := CommandConf{}
+	_, err := createObjStoreClient(conf)
+	require.Error(t, err)
+	require.Equal(t, "missing flags for minio object store: --obj-store-username, --obj-store-password, --obj-store-url",
+		err.Error())
+
+	conf.ObjStoreType = ObjStoreTypeMinio
+	conf.ObjStoreUsername = "some-user"
+	conf.ObjStorePassword = "some-pwd"
+	_, err = createObjStoreClient(conf)
+	require.Error(t, err)
+	require.Equal(t, "missing flags for minio object store: --obj-store-url", err.Error())
+	conf.ObjStoreURL = "127.0.0.1:9000"
+	client, err := createObjStoreClient(conf)
+	require.NoError(t, err)
+	require.IsType(t, &minio.Client{}, client)
+
+	// A credentials chain doesn't need a username and password
+	conf = CommandConf{ObjStoreURL: "s3.amazonaws.com", ObjStoreCredentials: "chain", ObjStoreSecure: true,
+		ObjStoreRegion: "eu-west-2", ObjStoreBucketLookup: "dns", ObjStoreServerSideEncryption: "sse-s3"}
+	client, err = createObjStoreClient(conf)
+	require.NoError(t, err)
+	require.IsType(t, &minio.Client{}, client)
+	conf.ObjStoreServerSideEncryption = "sse-kms"
+	_, err = createObjStoreClient(conf)
+	require.Error(t, err)
+	conf.ObjStoreSseKmsKeyID = "some-key"
+	_, err = createObjStoreClient(conf)
+	require.NoError(t, err)
+	conf.ObjStoreBucketLookup = "virtual"
+	_, err = createObjStoreClient(conf)
+	require.Error(t, err)
+
+	conf = CommandConf{ObjStoreType: ObjStoreTypeLocal}
+	_, err = createObjStoreClient(conf)
+	require.Error(t, err)
+	require.Equal(t, "missing flags for local object store: --obj-store-dir", err.Error())
+	conf.ObjStoreDir = t.TempDir()
+	client, err = createObjStoreClient(conf)
+	require.NoError(t, err)
+	require.IsType(t, &localfs.Client{}, client)
+
+	conf.ObjStoreType = "s3"
+	_, err = createObjStoreClient(conf)
+	require.Error(t, err)
+	require.Equal(t, "invalid value for obj-store-type must be one of minio or local", err.Error())
+}
+
+func TestCreateObjStoreRetryConf(t *testing.T) {
+	conf := CommandConf{ObjStoreMaxRetries: 5, ObjStoreRetryInitialBackoffMs: 50, ObjStoreRetryMaxBackoffMs: 1000}
+	retryConf, err := createObjStoreRetryConf(conf)
+	require.NoError(t, err)
+	require.Equal(t, 5, retryConf.MaxRetries)
+	require.Equal(t, 50*time.Millisecond, retryConf.InitialBackoff)
+	require.Equal(t, 1*time.Second, retryConf.MaxBackoff)
+
+	conf.ObjStoreMaxRetries = -1
+	_, err = createObjStoreRetryConf(conf)
+	require.Error(t, err)
+	require.Equal(t, "invalid value for obj-store-max-retries must be >= 0", err.Error())
+
+	conf.ObjStoreMaxRetries = 0
+	conf.ObjStoreRetryMaxBackoffMs = -1
+	_, err = createObjStoreRetryConf(conf)
+	require.Error(t, err)
+	require.Equal(t, "invalid value for obj-store-retry-max-backoff-ms must be >= 0 ms", err.Error())
+}
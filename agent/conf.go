@@ -3,34 +3,62 @@ package agent
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
 	"github.com/spirit-labs/tektite/asl/conf"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
 	"github.com/spirit-labs/tektite/control"
 	"github.com/spirit-labs/tektite/fetchcache"
 	"github.com/spirit-labs/tektite/fetcher"
 	"github.com/spirit-labs/tektite/group"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/metrics"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/objstore/localfs"
 	"github.com/spirit-labs/tektite/objstore/minio"
 	"github.com/spirit-labs/tektite/pusher"
+	"github.com/spirit-labs/tektite/quota"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/spirit-labs/tektite/tx"
 	"net"
+	"strings"
 	"time"
 )
 
 type CommandConf struct {
-	ObjStoreUsername                string `help:"username for the object store" required:""`
-	ObjStorePassword                string `help:"password for the object store" required:""`
-	ObjStoreURL                     string `help:"url of the object store" required:""`
-	ClusterName                     string `help:"name of the agent cluster" required:""`
-	Location                        string `help:"location (e.g. availability zone) that the agent runs in" required:""`
-	KafkaListenAddress              string `help:"address to listen on for kafka connections"`
-	InternalListenAddress           string `help:"address to listen on for internal connections"`
-	MembershipUpdateIntervalMs      int    `help:"interval between updating cluster membership in ms" default:"5000"`
-	MembershipEvictionIntervalMs    int    `help:"interval after which member will be evicted from the cluster" default:"20000"`
-	ConsumerGroupInitialJoinDelayMs int    `name:"consumer-group-initial-join-delay-ms" help:"initial delay to wait for more consumers to join a new consumer group before performing the first rebalance, in ms" default:"3000"`
+	ObjStoreType                    string   `help:"type of the object store - one of minio or local. A local object store keeps objects in a directory on the local file system" default:"minio"`
+	ObjStoreUsername                string   `help:"username for the object store. Required for minio with static credentials"`
+	ObjStorePassword                string   `help:"password for the object store. Required for minio with static credentials"`
+	ObjStoreURL                     string   `help:"url of the object store. Required for minio"`
+	ObjStoreDir                     string   `help:"directory to keep objects in for a local object store. Required for local"`
+	ObjStoreSecure                  bool     `help:"whether to connect to the object store with TLS"`
+	ObjStoreTLSTrustedCertsPath     string   `name:"obj-store-tls-trusted-certs-path" help:"path to a PEM encoded file containing certificates of trusted object store servers and/or certificate authorities. If not set, the system certificates are used"`
+	ObjStoreTLSNoVerify             bool     `name:"obj-store-tls-no-verify" help:"set to true to disable object store certificate verification. WARNING use only for testing"`
+	ObjStoreRegion                  string   `help:"region of the object store. If not set, it is looked up from the object store"`
+	ObjStoreBucketLookup            string   `help:"how object store buckets are addressed - one of auto, dns (virtual-hosted style) or path" default:"auto"`
+	ObjStoreCredentials             string   `help:"where object store credentials come from - one of static or chain. static uses obj-store-username and obj-store-password, chain uses AWS or minio environment variables, credentials files or the IAM role of the instance" default:"static"`
+	ObjStoreServerSideEncryption    string   `help:"server side encryption of objects - one of none, sse-s3 or sse-kms" default:"none"`
+	ObjStoreSseKmsKeyID             string   `name:"obj-store-sse-kms-key-id" help:"KMS key id for sse-kms server side encryption"`
+	ObjStoreMaxRetries              int      `help:"max number of times an object store operation is retried after a transient error" default:"3"`
+	ObjStoreRetryInitialBackoffMs   int      `help:"max delay before the first retry of an object store operation in ms. Each retry is delayed by a random time up to a max which doubles with each retry" default:"100"`
+	ObjStoreRetryMaxBackoffMs       int      `help:"max delay before retrying an object store operation in ms" default:"5000"`
+	ClusterName                     string   `help:"name of the agent cluster" required:""`
+	Location                        string   `help:"location (e.g. availability zone) that the agent runs in" required:""`
+	KafkaListenAddress              string   `help:"address to listen on for kafka connections"`
+	InternalListenAddress           string   `help:"address to listen on for internal connections"`
+	MembershipUpdateIntervalMs      int      `help:"interval between updating cluster membership in ms" default:"5000"`
+	MembershipEvictionIntervalMs    int      `help:"interval after which member will be evicted from the cluster" default:"20000"`
+	ConsumerGroupInitialJoinDelayMs int      `name:"consumer-group-initial-join-delay-ms" help:"initial delay to wait for more consumers to join a new consumer group before performing the first rebalance, in ms" default:"3000"`
+	KafkaAuthenticationType         string   `help:"authentication required for kafka connections - one of SCRAM-SHA-256 or SCRAM-SHA-512. If not set, no authentication is required"`
+	MetricsListenAddress            string   `help:"address to serve prometheus metrics on at /metrics. If not set, metrics are not served"`
+	KafkaAuthorizationEnabled       bool     `help:"whether kafka requests are authorized using ACLs. If not set, all requests are allowed"`
+	KafkaSuperUsers                 []string `help:"principals, in the form User:<name>, which are allowed to perform any operation when kafka authorization is enabled"`
+	TableFormat                     int      `help:"format of the SSTables written by the cluster - 1 or 2. Format 2 is required for table compression and bloom filters" default:"1"`
+	TableCompression                string   `help:"compression for SSTable data blocks - one of none, snappy, lz4 or zstd" default:"none"`
+	TableBloomFilterBitsPerKey      int      `help:"bits per key of the bloom filter in each SSTable, used to skip tables in point lookups. If 0, no bloom filters are built" default:"0"`
 
 	TopicName string `name:"topic-name" help:"name of the topic"`
 }
@@ -69,6 +97,13 @@ func CreateConfFromCommandConf(commandConf CommandConf) (Conf, error) {
 	}
 	cfg.KafkaListenerConfig.Address = kafkaAddress
 	cfg.ClusterListenerConfig.Address = clusterAddress
+	if commandConf.KafkaAuthenticationType != "" &&
+		commandConf.KafkaAuthenticationType != auth.AuthenticationSaslScramSha256 &&
+		commandConf.KafkaAuthenticationType != auth.AuthenticationSaslScramSha512 {
+		return Conf{}, errors.Errorf("invalid value for kafka-authentication-type must be one of %s or %s",
+			auth.AuthenticationSaslScramSha256, auth.AuthenticationSaslScramSha512)
+	}
+	cfg.KafkaListenerConfig.AuthenticationType = commandConf.KafkaAuthenticationType
 	dataBucketName := commandConf.ClusterName + "-data"
 	// configure cluster membership
 	cfg.ClusterMembershipConfig.BucketName = dataBucketName
@@ -100,6 +135,10 @@ func CreateConfFromCommandConf(commandConf CommandConf) (Conf, error) {
 	cfg.CompactionWorkersConf.SSTableBucketName = dataBucketName
 	// configure table pusher
 	cfg.PusherConf.DataBucketName = dataBucketName
+	// configure table format
+	if err := configureTableFormat(&cfg, commandConf); err != nil {
+		return Conf{}, err
+	}
 	// configure fetcher
 	cfg.FetcherConf.DataBucketName = dataBucketName
 	// configure fetch cache
@@ -112,9 +151,41 @@ func CreateConfFromCommandConf(commandConf CommandConf) (Conf, error) {
 	if err != nil {
 		return Conf{}, err
 	}
+	// configure metrics
+	if commandConf.MetricsListenAddress != "" {
+		cfg.MetricsConf.Enabled = true
+		cfg.MetricsConf.ListenAddress = commandConf.MetricsListenAddress
+	}
+	// configure authorization
+	cfg.AclConf.Enabled = commandConf.KafkaAuthorizationEnabled
+	cfg.AclConf.SuperUsers = commandConf.KafkaSuperUsers
 	return cfg, nil
 }
 
+func configureTableFormat(cfg *Conf, commandConf CommandConf) error {
+	if commandConf.TableFormat != 0 {
+		format := common.DataFormat(commandConf.TableFormat)
+		if format != common.DataFormatV1 && format != common.DataFormatV2 {
+			return errors.Errorf("invalid value for table-format must be one of %d or %d", common.DataFormatV1,
+				common.DataFormatV2)
+		}
+		cfg.PusherConf.DataFormat = format
+		cfg.CompactionWorkersConf.DataFormat = format
+		cfg.ControllerConf.DataFormat = format
+	}
+	if commandConf.TableCompression != "" {
+		compressionType, err := compress.ParseCompressionType(commandConf.TableCompression)
+		if err != nil {
+			return errors.Wrap(err, "invalid value for table-compression")
+		}
+		cfg.PusherConf.TableCompression = compressionType
+		cfg.CompactionWorkersConf.TableCompression = compressionType
+	}
+	cfg.PusherConf.TableBloomFilterBitsPerKey = commandConf.TableBloomFilterBitsPerKey
+	cfg.CompactionWorkersConf.TableBloomFilterBitsPerKey = commandConf.TableBloomFilterBitsPerKey
+	return nil
+}
+
 func validateDurationMs(configName string, durationMs int, minDurationMs int) (time.Duration, error) {
 	if durationMs < minDurationMs {
 		return 0, errors.Errorf("invalid value for %s must be >= %d ms", configName, minDurationMs)
@@ -127,19 +198,94 @@ func CreateAgentFromCommandConf(commandConf CommandConf) (*Agent, error) {
 	if err != nil {
 		return nil, err
 	}
-	minioConf := minio.Conf{
-		Endpoint: commandConf.ObjStoreURL,
-		Username: commandConf.ObjStoreUsername,
-		Password: commandConf.ObjStorePassword,
-		Secure:   false,
+	objStoreClient, err := createObjStoreClient(commandConf)
+	if err != nil {
+		return nil, err
+	}
+	retryConf, err := createObjStoreRetryConf(commandConf)
+	if err != nil {
+		return nil, err
 	}
-	objStore := minio.NewMinioClient(minioConf)
+	objStore := objstore.NewRetryingClient(objStoreClient, retryConf)
 	if err := objStore.Start(); err != nil {
 		return nil, err
 	}
 	return NewAgent(cfg, objStore)
 }
 
+const (
+	ObjStoreTypeMinio = "minio"
+	ObjStoreTypeLocal = "local"
+)
+
+func createObjStoreClient(commandConf CommandConf) (objstore.Client, error) {
+	switch commandConf.ObjStoreType {
+	case ObjStoreTypeMinio, "":
+		staticCredentials := commandConf.ObjStoreCredentials == "" ||
+			minio.CredentialsSource(commandConf.ObjStoreCredentials) == minio.CredentialsStatic
+		var missing []string
+		if staticCredentials && commandConf.ObjStoreUsername == "" {
+			missing = append(missing, "--obj-store-username")
+		}
+		if staticCredentials && commandConf.ObjStorePassword == "" {
+			missing = append(missing, "--obj-store-password")
+		}
+		if commandConf.ObjStoreURL == "" {
+			missing = append(missing, "--obj-store-url")
+		}
+		if len(missing) > 0 {
+			return nil, errors.Errorf("missing flags for minio object store: %s", strings.Join(missing, ", "))
+		}
+		minioConf := minio.Conf{
+			Endpoint:             commandConf.ObjStoreURL,
+			Username:             commandConf.ObjStoreUsername,
+			Password:             commandConf.ObjStorePassword,
+			Secure:               commandConf.ObjStoreSecure,
+			TLSTrustedCertsPath:  commandConf.ObjStoreTLSTrustedCertsPath,
+			TLSNoVerify:          commandConf.ObjStoreTLSNoVerify,
+			Region:               commandConf.ObjStoreRegion,
+			BucketLookup:         minio.BucketLookup(commandConf.ObjStoreBucketLookup),
+			Credentials:          minio.CredentialsSource(commandConf.ObjStoreCredentials),
+			ServerSideEncryption: minio.ServerSideEncryption(commandConf.ObjStoreServerSideEncryption),
+			SSEKMSKeyID:          commandConf.ObjStoreSseKmsKeyID,
+			// Requests are retried by the objstore.RetryingClient which wraps the client
+			MaxAttempts: 1,
+		}
+		if err := minioConf.Validate(); err != nil {
+			return nil, errors.Wrap(err, "invalid minio object store configuration")
+		}
+		return minio.NewMinioClient(minioConf), nil
+	case ObjStoreTypeLocal:
+		if commandConf.ObjStoreDir == "" {
+			return nil, errors.New("missing flags for local object store: --obj-store-dir")
+		}
+		return localfs.NewLocalFsClient(localfs.Conf{Dir: commandConf.ObjStoreDir}), nil
+	default:
+		return nil, errors.Errorf("invalid value for obj-store-type must be one of %s or %s", ObjStoreTypeMinio,
+			ObjStoreTypeLocal)
+	}
+}
+
+func createObjStoreRetryConf(commandConf CommandConf) (objstore.RetryConf, error) {
+	if commandConf.ObjStoreMaxRetries < 0 {
+		return objstore.RetryConf{}, errors.New("invalid value for obj-store-max-retries must be >= 0")
+	}
+	initialBackoff, err := validateDurationMs("obj-store-retry-initial-backoff-ms",
+		commandConf.ObjStoreRetryInitialBackoffMs, 0)
+	if err != nil {
+		return objstore.RetryConf{}, err
+	}
+	maxBackoff, err := validateDurationMs("obj-store-retry-max-backoff-ms", commandConf.ObjStoreRetryMaxBackoffMs, 0)
+	if err != nil {
+		return objstore.RetryConf{}, err
+	}
+	return objstore.RetryConf{
+		MaxRetries:     commandConf.ObjStoreMaxRetries,
+		InitialBackoff: initialBackoff,
+		MaxBackoff:     maxBackoff,
+	}, nil
+}
+
 func selectNetworkInterface() (string, error) {
 	addresses, err := net.InterfaceAddrs()
 	if err != nil {
@@ -171,6 +317,9 @@ type Conf struct {
 	FetchCacheConf          fetchcache.Conf
 	GroupCoordinatorConf    group.Conf
 	TxCoordinatorConf       tx.Conf
+	MetricsConf             metrics.Conf
+	QuotaConf               quota.Conf
+	AclConf                 acls.Conf
 	MaxControllerClients    int
 }
 
@@ -184,6 +333,9 @@ func NewConf() Conf {
 		FetchCacheConf:          fetchcache.NewConf(),
 		GroupCoordinatorConf:    group.NewConf(),
 		TxCoordinatorConf:       tx.NewConf(),
+		MetricsConf:             metrics.NewConf(),
+		QuotaConf:               quota.NewConf(),
+		AclConf:                 acls.NewConf(),
 		MaxControllerClients:    DefaultMaxControllerClients,
 	}
 }
@@ -221,6 +373,15 @@ func (c *Conf) Validate() error {
 	if err := c.TxCoordinatorConf.Validate(); err != nil {
 		return err
 	}
+	if err := c.MetricsConf.Validate(); err != nil {
+		return err
+	}
+	if err := c.QuotaConf.Validate(); err != nil {
+		return err
+	}
+	if err := c.AclConf.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -232,8 +393,12 @@ type ListenerConfig struct {
 }
 
 func (l *ListenerConfig) Validate() error {
-	// TODO
-	return nil
+	switch l.AuthenticationType {
+	case "", auth.AuthenticationTLS, auth.AuthenticationSaslScramSha256, auth.AuthenticationSaslScramSha512:
+		return nil
+	default:
+		return errors.Errorf("invalid authentication type %s", l.AuthenticationType)
+	}
 }
 
 // FIXME - get rid of these once create/delete topic is complete
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"sort"
+)
+
+// Kafka config resource types
+const (
+	resourceTypeTopic  = 2
+	resourceTypeBroker = 4
+)
+
+// Kafka config sources
+const (
+	configSourceDynamicTopic = 1
+	configSourceDefault      = 5
+)
+
+// Kafka config types
+const (
+	configTypeString = 2
+	configTypeInt    = 3
+	configTypeLong   = 5
+	configTypeList   = 7
+)
+
+func (a *Agent) HandleDescribeConfigsRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DescribeConfigsRequest) *kafkaprotocol.DescribeConfigsResponse {
+	var resp kafkaprotocol.DescribeConfigsResponse
+	resp.Results = make([]kafkaprotocol.DescribeConfigsResponseDescribeConfigsResult, len(req.Resources))
+	for i, resource := range req.Resources {
+		result := &resp.Results[i]
+		result.ResourceType = resource.ResourceType
+		result.ResourceName = resource.ResourceName
+		switch resource.ResourceType {
+		case resourceTypeTopic:
+			topicName := common.SafeDerefStringPtr(resource.ResourceName)
+			info, exists, err := a.topicMetaCache.GetTopicInfo(topicName)
+			if err != nil {
+				setDescribeConfigsError(result, topicErrorCode(err), err.Error())
+				continue
+			}
+			if !exists {
+				setDescribeConfigsError(result, kafkaprotocol.ErrorCodeUnknownTopicOrPartition,
+					fmt.Sprintf("topic: %s does not exist", topicName))
+				continue
+			}
+			result.Configs = describeTopicConfigs(hdr.RequestApiVersion, req, &info, resource.ConfigurationKeys)
+		case resourceTypeBroker:
+			// Agents have no broker configs which can be described or altered via the Kafka API
+			result.Configs = []kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult{}
+		default:
+			setDescribeConfigsError(result, kafkaprotocol.ErrorCodeInvalidRequest,
+				fmt.Sprintf("unsupported resource type: %d", resource.ResourceType))
+		}
+	}
+	return &resp
+}
+
+func setDescribeConfigsError(result *kafkaprotocol.DescribeConfigsResponseDescribeConfigsResult, errCode int16,
+	errMsg string) {
+	result.ErrorCode = errCode
+	result.ErrorMessage = &errMsg
+}
+
+// describeTopicConfigs returns the supported configs with their current or default values, along with any other
+// configs which have been set on the topic. If keys are provided only those configs are returned.
+func describeTopicConfigs(version int16, req *kafkaprotocol.DescribeConfigsRequest, info *topicmeta.TopicInfo,
+	keys []*string) []kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult {
+	var names []string
+	if keys == nil {
+		for _, def := range topicmeta.ConfigDefs {
+			names = append(names, def.Name)
+		}
+		for name := range info.Configs {
+			if _, ok := topicmeta.GetConfigDef(name); !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	} else {
+		for _, key := range keys {
+			names = append(names, common.SafeDerefStringPtr(key))
+		}
+	}
+	configs := make([]kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult, 0, len(names))
+	for _, name := range names {
+		value, isSet := info.GetConfig(name)
+		def, isKnown := topicmeta.GetConfigDef(name)
+		if !isSet && !isKnown {
+			// As with Kafka, unknown configs are not returned
+			continue
+		}
+		if !isSet {
+			value = def.DefaultValue
+		}
+		config := kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult{
+			Name:       common.StrPtr(name),
+			Value:      common.StrPtr(value),
+			ConfigType: configTypeString,
+		}
+		if version == 0 {
+			config.IsDefault = !isSet
+		} else if isSet {
+			config.ConfigSource = configSourceDynamicTopic
+		} else {
+			config.ConfigSource = configSourceDefault
+		}
+		if isKnown {
+			config.ConfigType = kafkaConfigType(def.Type)
+			if req.IncludeDocumentation {
+				config.Documentation = common.StrPtr(def.Documentation)
+			}
+		}
+		if req.IncludeSynonyms {
+			config.Synonyms = []kafkaprotocol.DescribeConfigsResponseDescribeConfigsSynonym{
+				{Name: config.Name, Value: config.Value, Source: config.ConfigSource},
+			}
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+func kafkaConfigType(configType topicmeta.ConfigType) int8 {
+	switch configType {
+	case topicmeta.ConfigTypeInt:
+		return configTypeInt
+	case topicmeta.ConfigTypeLong:
+		return configTypeLong
+	case topicmeta.ConfigTypeList:
+		return configTypeList
+	default:
+		return configTypeString
+	}
+}
+
+func (a *Agent) HandleIncrementalAlterConfigsRequest(
+	req *kafkaprotocol.IncrementalAlterConfigsRequest) *kafkaprotocol.IncrementalAlterConfigsResponse {
+	var resp kafkaprotocol.IncrementalAlterConfigsResponse
+	resp.Responses = make([]kafkaprotocol.IncrementalAlterConfigsResponseAlterConfigsResourceResponse,
+		len(req.Resources))
+	for i, resource := range req.Resources {
+		result := &resp.Responses[i]
+		result.ResourceType = resource.ResourceType
+		result.ResourceName = resource.ResourceName
+		var errCode int16
+		var errMsg string
+		switch resource.ResourceType {
+		case resourceTypeTopic:
+			alterations := make([]topicmeta.ConfigAlteration, len(resource.Configs))
+			for j, config := range resource.Configs {
+				alterations[j] = topicmeta.ConfigAlteration{
+					Name:      common.SafeDerefStringPtr(config.Name),
+					Operation: topicmeta.ConfigOperation(config.ConfigOperation),
+					Value:     common.SafeDerefStringPtr(config.Value),
+				}
+			}
+			if err := a.alterTopicConfigs(common.SafeDerefStringPtr(resource.ResourceName), alterations,
+				req.ValidateOnly); err != nil {
+				errCode = topicErrorCode(err)
+				errMsg = err.Error()
+			}
+		case resourceTypeBroker:
+			errCode = kafkaprotocol.ErrorCodeInvalidRequest
+			errMsg = "broker configs cannot be altered"
+		default:
+			errCode = kafkaprotocol.ErrorCodeInvalidRequest
+			errMsg = fmt.Sprintf("unsupported resource type: %d", resource.ResourceType)
+		}
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			result.ErrorCode = errCode
+			result.ErrorMessage = &errMsg
+		}
+	}
+	return &resp
+}
+
+func (a *Agent) alterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.AlterTopicConfigs(topicName, alterations, validateOnly)
+}
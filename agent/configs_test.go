@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDescribeConfigs(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 2, 3, 4} {
+		testDescribeConfigs(t, apiVersion)
+	}
+}
+
+func testDescribeConfigs(t *testing.T, apiVersion int16) {
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: "topic1", PartitionCount: 3, RetentionTime: 1 * time.Hour,
+			Configs: map[string]string{"some.config": "foo"}},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	req := &kafkaprotocol.DescribeConfigsRequest{
+		Resources: []kafkaprotocol.DescribeConfigsRequestDescribeConfigsResource{
+			{ResourceType: resourceTypeTopic, ResourceName: common.StrPtr("topic1")},
+			{ResourceType: resourceTypeTopic, ResourceName: common.StrPtr("topic1"),
+				ConfigurationKeys: []*string{common.StrPtr(topicmeta.ConfigMaxMessageBytes), common.StrPtr("unknown")}},
+			{ResourceType: resourceTypeTopic, ResourceName: common.StrPtr("unknown")},
+			{ResourceType: resourceTypeBroker, ResourceName: common.StrPtr("0")},
+		},
+		IncludeSynonyms:      true,
+		IncludeDocumentation: true,
+	}
+	resp := sendDescribeConfigs(t, conn, req, apiVersion)
+	require.Equal(t, 4, len(resp.Results))
+
+	result := resp.Results[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(result.ErrorCode))
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(result.ResourceName))
+	configs := map[string]kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult{}
+	for _, config := range result.Configs {
+		configs[common.SafeDerefStringPtr(config.Name)] = config
+	}
+	require.Equal(t, len(topicmeta.ConfigDefs)+1, len(configs))
+	checkDescribedConfig(t, apiVersion, configs[topicmeta.ConfigRetentionMs], "3600000", true)
+	checkDescribedConfig(t, apiVersion, configs[topicmeta.ConfigRetentionBytes], "-1", false)
+	checkDescribedConfig(t, apiVersion, configs[topicmeta.ConfigCleanupPolicy], "delete", false)
+	checkDescribedConfig(t, apiVersion, configs["some.config"], "foo", true)
+	if apiVersion >= 3 {
+		require.Equal(t, configTypeLong, int(configs[topicmeta.ConfigRetentionMs].ConfigType))
+		require.Equal(t, configTypeList, int(configs[topicmeta.ConfigCleanupPolicy].ConfigType))
+		require.NotNil(t, configs[topicmeta.ConfigRetentionMs].Documentation)
+	}
+	if apiVersion >= 1 {
+		require.Equal(t, 1, len(configs[topicmeta.ConfigRetentionMs].Synonyms))
+	}
+
+	// Only requested keys which are known are returned
+	result = resp.Results[1]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(result.ErrorCode))
+	require.Equal(t, 1, len(result.Configs))
+	checkDescribedConfig(t, apiVersion, result.Configs[0], "2147483647", false)
+
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(resp.Results[2].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Results[3].ErrorCode))
+}
+
+func checkDescribedConfig(t *testing.T, apiVersion int16,
+	config kafkaprotocol.DescribeConfigsResponseDescribeConfigsResourceResult, expectedValue string, isSet bool) {
+	require.Equal(t, expectedValue, common.SafeDerefStringPtr(config.Value))
+	if apiVersion == 0 {
+		require.Equal(t, !isSet, config.IsDefault)
+	} else if isSet {
+		require.Equal(t, configSourceDynamicTopic, int(config.ConfigSource))
+	} else {
+		require.Equal(t, configSourceDefault, int(config.ConfigSource))
+	}
+}
+
+func TestIncrementalAlterConfigs(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1} {
+		testIncrementalAlterConfigs(t, apiVersion)
+	}
+}
+
+func testIncrementalAlterConfigs(t *testing.T, apiVersion int16) {
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: "topic1", PartitionCount: 3, RetentionTime: 1 * time.Hour},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	alterations := []kafkaprotocol.IncrementalAlterConfigsRequestAlterableConfig{
+		{Name: common.StrPtr(topicmeta.ConfigRetentionMs), ConfigOperation: int8(topicmeta.ConfigOperationDelete)},
+		{Name: common.StrPtr(topicmeta.ConfigMaxMessageBytes), ConfigOperation: int8(topicmeta.ConfigOperationSet),
+			Value: common.StrPtr("1000")},
+	}
+	// validate only
+	result := sendIncrementalAlterConfigs(t, conn, resourceTypeTopic, "topic1", alterations, true, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(result.ErrorCode))
+	require.Equal(t, 1*time.Hour, getTopicInfo(t, agent, "topic1").RetentionTime)
+
+	result = sendIncrementalAlterConfigs(t, conn, resourceTypeTopic, "topic1", alterations, false, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(result.ErrorCode))
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(result.ResourceName))
+	info := getTopicInfo(t, agent, "topic1")
+	require.Equal(t, time.Duration(0), info.RetentionTime)
+	require.Equal(t, 1000, info.MaxMessageBytes())
+
+	// The agent's cached topic info must be updated
+	cached, exists, err := agent.topicMetaCache.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, info, cached)
+
+	result = sendIncrementalAlterConfigs(t, conn, resourceTypeTopic, "topic1",
+		[]kafkaprotocol.IncrementalAlterConfigsRequestAlterableConfig{
+			{Name: common.StrPtr(topicmeta.ConfigMaxMessageBytes), ConfigOperation: int8(topicmeta.ConfigOperationSet),
+				Value: common.StrPtr("foo")},
+		}, false, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidConfig, int(result.ErrorCode))
+	require.NotNil(t, result.ErrorMessage)
+
+	result = sendIncrementalAlterConfigs(t, conn, resourceTypeTopic, "unknown", alterations, false, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(result.ErrorCode))
+
+	result = sendIncrementalAlterConfigs(t, conn, resourceTypeBroker, "0", alterations, false, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(result.ErrorCode))
+}
+
+func sendDescribeConfigs(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.DescribeConfigsRequest,
+	apiVersion int16) *kafkaprotocol.DescribeConfigsResponse {
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDescribeConfigs, apiVersion, &kafkaprotocol.DescribeConfigsResponse{})
+	require.NoError(t, err)
+	return r.(*kafkaprotocol.DescribeConfigsResponse)
+}
+
+func sendIncrementalAlterConfigs(t *testing.T, conn *KafkaApiConnection, resourceType int8, resourceName string,
+	configs []kafkaprotocol.IncrementalAlterConfigsRequestAlterableConfig, validateOnly bool,
+	apiVersion int16) kafkaprotocol.IncrementalAlterConfigsResponseAlterConfigsResourceResponse {
+	req := &kafkaprotocol.IncrementalAlterConfigsRequest{
+		Resources: []kafkaprotocol.IncrementalAlterConfigsRequestAlterConfigsResource{
+			{ResourceType: resourceType, ResourceName: common.StrPtr(resourceName), Configs: configs},
+		},
+		ValidateOnly: validateOnly,
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyIncrementalAlterConfigs, apiVersion,
+		&kafkaprotocol.IncrementalAlterConfigsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.IncrementalAlterConfigsResponse)
+	require.Equal(t, 1, len(resp.Responses))
+	return resp.Responses[0]
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+)
+
+func (a *Agent) HandleDeleteRecordsRequest(req *kafkaprotocol.DeleteRecordsRequest) *kafkaprotocol.DeleteRecordsResponse {
+	var resp kafkaprotocol.DeleteRecordsResponse
+	resp.Topics = make([]kafkaprotocol.DeleteRecordsResponseDeleteRecordsTopicResult, len(req.Topics))
+	for i, topic := range req.Topics {
+		resp.Topics[i].Name = topic.Name
+		resp.Topics[i].Partitions = make([]kafkaprotocol.DeleteRecordsResponseDeleteRecordsPartitionResult,
+			len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			result := &resp.Topics[i].Partitions[j]
+			result.PartitionIndex = partition.PartitionIndex
+			result.LowWatermark = -1
+			logStartOffset, err := a.deleteRecords(common.SafeDerefStringPtr(topic.Name), int(partition.PartitionIndex),
+				partition.Offset)
+			if err != nil {
+				result.ErrorCode = deleteRecordsErrorCode(err)
+				continue
+			}
+			result.LowWatermark = logStartOffset
+		}
+	}
+	return &resp
+}
+
+func (a *Agent) deleteRecords(topicName string, partitionID int, offset int64) (int64, error) {
+	info, exists, err := a.topicMetaCache.GetTopicInfo(topicName)
+	if err != nil {
+		return 0, err
+	}
+	if !exists || partitionID < 0 || partitionID >= info.PartitionCount {
+		return 0, common.NewTektiteErrorf(common.TopicDoesNotExist, "unknown topic: %s or partition: %d", topicName,
+			partitionID)
+	}
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return 0, err
+	}
+	return client.DeleteRecords(info.ID, partitionID, offset)
+}
+
+// deleteRecordsErrorCode maps errors returned from the controller when deleting records
+func deleteRecordsErrorCode(err error) int16 {
+	if common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist) {
+		return kafkaprotocol.ErrorCodeUnknownTopicOrPartition
+	}
+	if common.IsTektiteErrorWithCode(err, common.OffsetOutOfRange) {
+		return kafkaprotocol.ErrorCodeOffsetOutOfRange
+	}
+	// The client will retry on request timed out
+	return kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeRequestTimedOut)
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestDeleteRecords(t *testing.T) {
+	topicName := "test-topic-1"
+	partitionID := 3
+	topicInfos := []topicmeta.TopicInfo{
+		{
+			Name:           topicName,
+			PartitionCount: 10,
+		},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+
+	address := agent.Conf().KafkaListenerConfig.Address
+	// Produce batches with records at timestamps 1000-1009, 2000-2009, 3000-3009
+	offsetStart := 0
+	var batches [][]byte
+	for i := 1; i <= 3; i++ {
+		var msgs []testutils.RawKafkaMessage
+		for j := 0; j < 10; j++ {
+			msgs = append(msgs, testutils.RawKafkaMessage{
+				Timestamp: int64(1000*i + j),
+				Key:       []byte(fmt.Sprintf("key%09d", offsetStart+j)),
+				Value:     []byte(fmt.Sprintf("val%09d", offsetStart+j)),
+			})
+		}
+		batch := testutils.CreateKafkaRecordBatch(msgs, int64(offsetStart))
+		sendProduceBatch(t, topicName, partitionID, address, batch)
+		batches = append(batches, batch)
+		offsetStart += len(msgs)
+	}
+
+	cl, err := NewKafkaApiClient()
+	require.NoError(t, err)
+	conn, err := cl.NewConnection(address)
+	require.NoError(t, err)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	partResp := sendFetchFromOffset(t, conn, topicName, partitionID, 0)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	require.Equal(t, bytes.Join(batches, nil), bytes.Join(partResp.Records, nil))
+	require.Equal(t, 0, int(partResp.LogStartOffset))
+
+	results := sendDeleteRecords(t, conn, topicName, map[int32]int64{int32(partitionID): 15, 10: 0, 4: 31})
+	require.Equal(t, 3, len(results))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(results[int32(partitionID)].ErrorCode))
+	require.Equal(t, 15, int(results[int32(partitionID)].LowWatermark))
+	// Unknown partition
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(results[10].ErrorCode))
+	require.Equal(t, -1, int(results[10].LowWatermark))
+	// Past the high watermark
+	require.Equal(t, kafkaprotocol.ErrorCodeOffsetOutOfRange, int(results[4].ErrorCode))
+
+	// Fetch before the log start offset fails
+	testutils.WaitUntil(t, func() (bool, error) {
+		partResp = sendFetchFromOffset(t, conn, topicName, partitionID, 0)
+		return int(partResp.ErrorCode) == kafkaprotocol.ErrorCodeOffsetOutOfRange, nil
+	})
+	require.Equal(t, 15, int(partResp.LogStartOffset))
+	require.Equal(t, 0, len(partResp.Records))
+
+	// The batch containing the log start offset is still returned
+	partResp = sendFetchFromOffset(t, conn, topicName, partitionID, 15)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	require.Equal(t, bytes.Join(batches[1:], nil), bytes.Join(partResp.Records, nil))
+	require.Equal(t, 15, int(partResp.LogStartOffset))
+
+	// Offsets are listed from the log start offset
+	listResp := sendListOffsets(t, conn, topicName, partitionID, -2)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(listResp.ErrorCode))
+	require.Equal(t, 15, int(listResp.Offset))
+	listResp = sendListOffsets(t, conn, topicName, partitionID, 1000)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(listResp.ErrorCode))
+	require.Equal(t, 15, int(listResp.Offset))
+	listResp = sendListOffsets(t, conn, topicName, partitionID, 2007)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(listResp.ErrorCode))
+	require.Equal(t, 17, int(listResp.Offset))
+
+	// Delete all records
+	results = sendDeleteRecords(t, conn, topicName, map[int32]int64{int32(partitionID): -1})
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(results[int32(partitionID)].ErrorCode))
+	require.Equal(t, 30, int(results[int32(partitionID)].LowWatermark))
+	listResp = sendListOffsets(t, conn, topicName, partitionID, -2)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(listResp.ErrorCode))
+	require.Equal(t, 30, int(listResp.Offset))
+}
+
+func sendDeleteRecords(t *testing.T, conn *KafkaApiConnection, topicName string,
+	offsets map[int32]int64) map[int32]kafkaprotocol.DeleteRecordsResponseDeleteRecordsPartitionResult {
+	req := &kafkaprotocol.DeleteRecordsRequest{
+		Topics: []kafkaprotocol.DeleteRecordsRequestDeleteRecordsTopic{
+			{Name: common.StrPtr(topicName)},
+		},
+	}
+	for partitionID, offset := range offsets {
+		req.Topics[0].Partitions = append(req.Topics[0].Partitions, kafkaprotocol.DeleteRecordsRequestDeleteRecordsPartition{
+			PartitionIndex: partitionID,
+			Offset:         offset,
+		})
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDeleteRecords, 2, &kafkaprotocol.DeleteRecordsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.DeleteRecordsResponse)
+	require.Equal(t, 1, len(resp.Topics))
+	require.Equal(t, topicName, common.SafeDerefStringPtr(resp.Topics[0].Name))
+	results := map[int32]kafkaprotocol.DeleteRecordsResponseDeleteRecordsPartitionResult{}
+	for _, result := range resp.Topics[0].Partitions {
+		results[result.PartitionIndex] = result
+	}
+	return results
+}
+
+func sendFetchFromOffset(t *testing.T, conn *KafkaApiConnection, topicName string, partitionID int,
+	fetchOffset int64) kafkaprotocol.FetchResponsePartitionData {
+	req := &kafkaprotocol.FetchRequest{
+		MaxBytes: math.MaxInt32,
+		Topics: []kafkaprotocol.FetchRequestFetchTopic{
+			{
+				Topic: common.StrPtr(topicName),
+				Partitions: []kafkaprotocol.FetchRequestFetchPartition{
+					{
+						Partition:         int32(partitionID),
+						FetchOffset:       fetchOffset,
+						PartitionMaxBytes: math.MaxInt32,
+					},
+				},
+			},
+		},
+		RackId: common.StrPtr(""),
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyFetch, 11, &kafkaprotocol.FetchResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.FetchResponse)
+	require.Equal(t, 1, len(resp.Responses))
+	require.Equal(t, 1, len(resp.Responses[0].Partitions))
+	return resp.Responses[0].Partitions[0]
+}
@@ -21,6 +21,14 @@ import (
 )
 
 func TestFetchSimple(t *testing.T) {
+	testFetchSimple(t, 4)
+}
+
+func TestFetchSimpleFlexibleVersion(t *testing.T) {
+	testFetchSimple(t, 12)
+}
+
+func testFetchSimple(t *testing.T, apiVersion int16) {
 	topicName := "test-topic-1"
 	partitionID := 12
 	topicInfos := []topicmeta.TopicInfo{
@@ -64,11 +72,12 @@ func TestFetchSimple(t *testing.T) {
 				},
 			},
 		},
+		RackId: common.StrPtr(""),
 	}
 
 	fetchResp := kafkaprotocol.FetchResponse{}
 
-	r, err := conn.SendRequest(&fetchReq, kafkaprotocol.APIKeyFetch, 4, &fetchResp)
+	r, err := conn.SendRequest(&fetchReq, kafkaprotocol.APIKeyFetch, apiVersion, &fetchResp)
 	res, ok := r.(*kafkaprotocol.FetchResponse)
 	require.True(t, ok)
 
@@ -79,6 +88,9 @@ func TestFetchSimple(t *testing.T) {
 	require.Equal(t, 1, len(topicResp.Partitions))
 	partResp := topicResp.Partitions[0]
 	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	if apiVersion >= 11 {
+		require.Equal(t, -1, int(partResp.PreferredReadReplica))
+	}
 	receivedBatches := partResp.Records
 	require.Equal(t, 1, len(receivedBatches))
 
@@ -396,6 +408,11 @@ func (f *fetchRunner) sendFetch(req *kafkaprotocol.FetchRequest) *kafkaprotocol.
 
 func produceBatch(t *testing.T, topicName string, partitionID int, address string) []byte {
 	batch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 100)
+	sendProduceBatch(t, topicName, partitionID, address, batch)
+	return batch
+}
+
+func sendProduceBatch(t *testing.T, topicName string, partitionID int, address string, batch []byte) {
 	req := kafkaprotocol.ProduceRequest{
 		TransactionalId: nil,
 		Acks:            -1,
@@ -431,7 +448,6 @@ func produceBatch(t *testing.T, topicName string, partitionID int, address strin
 	partResp := produceResp.Responses[0].PartitionResponses[0]
 	require.Equal(t, int16(kafkaprotocol.ErrorCodeNone), partResp.ErrorCode)
 	require.Equal(t, (*string)(nil), partResp.ErrorMessage)
-	return batch
 }
 
 func waitForDeliveredClusterVersion(t *testing.T, agents ...*Agent) {
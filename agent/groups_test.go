@@ -175,6 +175,100 @@ func TestConsumerGroups(t *testing.T) {
 	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(leaveGroupResp.ErrorCode))
 }
 
+func TestConsumerGroupHeartbeat(t *testing.T) {
+	topicName := "test-topic-1"
+	topicInfos := []topicmeta.TopicInfo{
+		{
+			Name:           topicName,
+			PartitionCount: 10,
+		},
+	}
+	cfg := NewConf()
+	agent, _, tearDown := setupAgent(t, topicInfos, cfg)
+	defer tearDown(t)
+
+	cl, err := NewKafkaApiClient()
+	require.NoError(t, err)
+
+	conn, err := cl.NewConnection(agent.Conf().KafkaListenerConfig.Address)
+	require.NoError(t, err)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	groupID := "test-group"
+	heartbeatReq := &kafkaprotocol.ConsumerGroupHeartbeatRequest{
+		GroupId:              common.StrPtr(groupID),
+		MemberId:             common.StrPtr("member-1"),
+		RebalanceTimeoutMs:   30000,
+		SubscribedTopicNames: []*string{common.StrPtr(topicName)},
+	}
+	heartbeatResp := &kafkaprotocol.ConsumerGroupHeartbeatResponse{}
+	r, err := conn.SendRequest(heartbeatReq, kafkaprotocol.APIKeyConsumerGroupHeartbeat, 0, heartbeatResp)
+	require.NoError(t, err)
+	heartbeatResp = r.(*kafkaprotocol.ConsumerGroupHeartbeatResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(heartbeatResp.ErrorCode))
+	require.Equal(t, "member-1", common.SafeDerefStringPtr(heartbeatResp.MemberId))
+	require.Equal(t, 1, int(heartbeatResp.MemberEpoch))
+	require.NotNil(t, heartbeatResp.Assignment)
+	require.Equal(t, 1, len(heartbeatResp.Assignment.TopicPartitions))
+	topicInfo, exists, err := agent.topicMetaCache.GetTopicInfo(topicName)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, topicInfo.KafkaTopicID(), heartbeatResp.Assignment.TopicPartitions[0].TopicId)
+	require.Equal(t, []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, heartbeatResp.Assignment.TopicPartitions[0].Partitions)
+
+	// Offsets are committed with the member epoch
+	offsetCommitReq := &kafkaprotocol.OffsetCommitRequest{
+		GroupId:                   common.StrPtr(groupID),
+		GenerationIdOrMemberEpoch: heartbeatResp.MemberEpoch,
+		MemberId:                  common.StrPtr("member-1"),
+		Topics: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic{
+			{
+				Name: common.StrPtr(topicName),
+				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
+					{PartitionIndex: 3, CommittedOffset: 1234},
+				},
+			},
+		},
+	}
+	offsetCommitResp := &kafkaprotocol.OffsetCommitResponse{}
+	r, err = conn.SendRequest(offsetCommitReq, kafkaprotocol.APIKeyOffsetCommit, 9, offsetCommitResp)
+	require.NoError(t, err)
+	offsetCommitResp = r.(*kafkaprotocol.OffsetCommitResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(offsetCommitResp.Topics[0].Partitions[0].ErrorCode))
+
+	describeReq := &kafkaprotocol.ConsumerGroupDescribeRequest{
+		GroupIds: []*string{common.StrPtr(groupID)},
+	}
+	describeResp := &kafkaprotocol.ConsumerGroupDescribeResponse{}
+	r, err = conn.SendRequest(describeReq, kafkaprotocol.APIKeyConsumerGroupDescribe, 0, describeResp)
+	require.NoError(t, err)
+	describeResp = r.(*kafkaprotocol.ConsumerGroupDescribeResponse)
+	require.Equal(t, 1, len(describeResp.Groups))
+	described := describeResp.Groups[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(described.ErrorCode))
+	require.Equal(t, "Stable", common.SafeDerefStringPtr(described.GroupState))
+	require.Equal(t, "uniform", common.SafeDerefStringPtr(described.AssignorName))
+	require.Equal(t, 1, len(described.Members))
+	require.Equal(t, "member-1", common.SafeDerefStringPtr(described.Members[0].MemberId))
+	require.Equal(t, topicName, common.SafeDerefStringPtr(described.Members[0].Assignment.TopicPartitions[0].TopicName))
+
+	heartbeatReq = &kafkaprotocol.ConsumerGroupHeartbeatRequest{
+		GroupId:            common.StrPtr(groupID),
+		MemberId:           common.StrPtr("member-1"),
+		MemberEpoch:        -1,
+		RebalanceTimeoutMs: -1,
+	}
+	heartbeatResp = &kafkaprotocol.ConsumerGroupHeartbeatResponse{}
+	r, err = conn.SendRequest(heartbeatReq, kafkaprotocol.APIKeyConsumerGroupHeartbeat, 0, heartbeatResp)
+	require.NoError(t, err)
+	heartbeatResp = r.(*kafkaprotocol.ConsumerGroupHeartbeatResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(heartbeatResp.ErrorCode))
+	require.Equal(t, -1, int(heartbeatResp.MemberEpoch))
+}
+
 func TestFindCoordinatorError(t *testing.T) {
 	topicName := "test-topic-1"
 	topicInfos := []topicmeta.TopicInfo{
@@ -299,7 +393,8 @@ func TestSyncGroupError(t *testing.T) {
 	r, err := conn.SendRequest(syncReq, kafkaprotocol.ApiKeySyncGroup, 0, syncResp)
 	require.NoError(t, err)
 	syncResp = r.(*kafkaprotocol.SyncGroupResponse)
-	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, int(syncResp.ErrorCode))
+	// The group isn't in memory, so the coordinator must be checked before the group can be loaded
+	require.Equal(t, kafkaprotocol.ErrorCodeCoordinatorNotAvailable, int(syncResp.ErrorCode))
 }
 
 func TestOffsetCommitError(t *testing.T) {
@@ -80,8 +80,11 @@ func (k *KafkaApiConnection) createRequestAndRegisterHandler(req KafkaProtocolRe
 	hdr.RequestApiVersion = apiVersion
 	hdr.ClientId = common.StrPtr(k.cl.clientID)
 	requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
-	buff := hdr.Write(requestHeaderVersion, nil, nil)
-	buff = req.Write(apiVersion, buff, nil)
+	hdrSize, hdrTagSizes := hdr.CalcSize(requestHeaderVersion, nil)
+	reqSize, tagSizes := req.CalcSize(apiVersion, nil)
+	buff := make([]byte, 0, hdrSize+reqSize)
+	buff = hdr.Write(requestHeaderVersion, buff, hdrTagSizes)
+	buff = req.Write(apiVersion, buff, tagSizes)
 	ch := make(chan KafkaProtocolMessage, 1)
 	k.respHandlers[k.correlationIDSeq] = respHolder{
 		resp:              resp,
@@ -122,4 +125,5 @@ type KafkaProtocolMessage interface {
 type KafkaProtocolRequest interface {
 	KafkaProtocolMessage
 	HeaderVersions(version int16) (int16, int16)
+	CalcSize(version int16, tagSizes []int) (int, []int)
 }
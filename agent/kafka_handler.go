@@ -1,35 +1,107 @@
 package agent
 
 import (
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	"github.com/spirit-labs/tektite/kafkaserver2"
+	"sync/atomic"
+	"time"
 )
 
 func (a *Agent) newKafkaHandler(ctx kafkaserver2.ConnectionContext) kafkaprotocol.RequestHandler {
 	return &kafkaHandler{
-		agent: a,
-		ctx:   ctx,
+		agent:      a,
+		ctx:        ctx,
+		authorizer: a.aclManager,
 	}
 }
 
 type kafkaHandler struct {
-	agent *Agent
-	ctx   kafkaserver2.ConnectionContext
+	agent      *Agent
+	ctx        kafkaserver2.ConnectionContext
+	authorizer acls.Authorizer
+	// mutedUntil is the time, in Unix nanoseconds, until which the connection is muted as the client exceeded a quota
+	mutedUntil atomic.Int64
 }
 
-func (k *kafkaHandler) HandleProduceRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.ProduceRequest,
+func (k *kafkaHandler) HandleProduceRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.ProduceRequest,
 	completionFunc func(resp *kafkaprotocol.ProduceResponse) error) error {
-	return k.agent.tablePusher.HandleProduceRequest(req, completionFunc)
+	k.waitIfMuted()
+	start := time.Now()
+	produceBytes := produceRequestBytes(req)
+	k.agent.metrics.produceBytes.Add(float64(produceBytes))
+	authorizedReq, errResp := k.authorizeProduceRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	// Only data the principal is authorized to produce counts towards its quota
+	if authorizedReq != req {
+		produceBytes = produceRequestBytes(authorizedReq)
+	}
+	throttleTime := k.recordQuotaUsage(control.QuotaKeyProducerByteRate, hdr, produceBytes)
+	return k.agent.tablePusher.HandleProduceRequest(authorizedReq, func(resp *kafkaprotocol.ProduceResponse) error {
+		k.agent.metrics.produceLatency.Observe(time.Since(start).Seconds())
+		if errResp != nil {
+			resp.Responses = append(resp.Responses, errResp.Responses...)
+		}
+		resp.ThrottleTimeMs = int32(throttleTime.Milliseconds())
+		return completionFunc(resp)
+	})
 }
 
-func (k *kafkaHandler) HandleFetchRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.FetchRequest,
+func (k *kafkaHandler) HandleFetchRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.FetchRequest,
 	completionFunc func(resp *kafkaprotocol.FetchResponse) error) error {
-	return k.agent.batchFetcher.HandleFetchRequest(req, completionFunc)
+	k.waitIfMuted()
+	start := time.Now()
+	return k.agent.batchFetcher.HandleFetchRequest(hdr, req, k.authorizeFetchTopic,
+		func(resp *kafkaprotocol.FetchResponse) error {
+			k.agent.metrics.fetchLatency.Observe(time.Since(start).Seconds())
+			fetchBytes := fetchResponseBytes(resp)
+			k.agent.metrics.fetchBytes.Add(float64(fetchBytes))
+			throttleTime := k.recordQuotaUsage(control.QuotaKeyConsumerByteRate, hdr, fetchBytes)
+			resp.ThrottleTimeMs = int32(throttleTime.Milliseconds())
+			return completionFunc(resp)
+		})
+}
+
+// recordQuotaUsage records the bytes produced or fetched against any quota for the connection's user and client id.
+// If the client has exceeded its quota the connection is muted for the returned throttle time.
+func (k *kafkaHandler) recordQuotaUsage(quotaKey string, hdr *kafkaprotocol.RequestHeader, bytes int) time.Duration {
+	var user *string
+	if authContext := k.ctx.AuthContext(); authContext.Authenticated {
+		user = authContext.Principal
+	}
+	throttleTime := k.agent.quotaManager.RecordAndGetThrottleTime(quotaKey, user,
+		common.SafeDerefStringPtr(hdr.ClientId), bytes)
+	if throttleTime > 0 {
+		k.agent.metrics.throttledRequests.WithLabelValues(quotaKey).Inc()
+		mutedUntil := time.Now().Add(throttleTime).UnixNano()
+		for {
+			current := k.mutedUntil.Load()
+			if current >= mutedUntil || k.mutedUntil.CompareAndSwap(current, mutedUntil) {
+				break
+			}
+		}
+	}
+	return throttleTime
+}
+
+// waitIfMuted waits until any throttle time from a previous request has passed. Requests on a connection are handled
+// one at a time, so as with Kafka, this prevents a client which ignores the throttle time it was sent from exceeding its
+// quota by sending more requests.
+func (k *kafkaHandler) waitIfMuted() {
+	if wait := time.Until(time.Unix(0, k.mutedUntil.Load())); wait > 0 {
+		time.Sleep(wait)
+	}
 }
 
 func (k *kafkaHandler) HandleListOffsetsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.ListOffsetsRequest,
 	completionFunc func(resp *kafkaprotocol.ListOffsetsResponse) error) error {
-	return completionFunc(k.agent.HandleListOffsetsRequest(req))
+	resp := k.agent.HandleListOffsetsRequest(req)
+	k.authorizeListOffsetsResponse(resp)
+	return completionFunc(resp)
 }
 
 func (k *kafkaHandler) HandleMetadataRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.MetadataRequest,
@@ -38,15 +110,23 @@ func (k *kafkaHandler) HandleMetadataRequest(hdr *kafkaprotocol.RequestHeader, r
 	if err != nil {
 		return err
 	}
+	k.authorizeMetadataResponse(req, resp)
 	return completionFunc(resp)
 }
 
 func (k *kafkaHandler) HandleOffsetCommitRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.OffsetCommitRequest, completionFunc func(resp *kafkaprotocol.OffsetCommitResponse) error) error {
-	resp, err := k.agent.groupCoordinator.OffsetCommit(req)
+	authorizedReq, errResp := k.authorizeOffsetCommitRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp, err := k.agent.groupCoordinator.OffsetCommit(authorizedReq)
 	if err != nil {
 		return err
 	}
+	if errResp != nil {
+		resp.Topics = append(resp.Topics, errResp.Topics...)
+	}
 	return completionFunc(resp)
 }
 
@@ -56,81 +136,329 @@ func (k *kafkaHandler) HandleOffsetFetchRequest(_ *kafkaprotocol.RequestHeader,
 	if err != nil {
 		return err
 	}
+	k.authorizeOffsetFetchResponse(req, resp)
 	return completionFunc(resp)
 }
 
-func (k *kafkaHandler) HandleFindCoordinatorRequest(_ *kafkaprotocol.RequestHeader,
+func (k *kafkaHandler) HandleFindCoordinatorRequest(hdr *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.FindCoordinatorRequest,
 	completionFunc func(resp *kafkaprotocol.FindCoordinatorResponse) error) error {
-	return k.agent.groupCoordinator.HandleFindCoordinatorRequest(req, completionFunc)
+	return k.agent.groupCoordinator.HandleFindCoordinatorRequest(hdr, req,
+		func(resp *kafkaprotocol.FindCoordinatorResponse) error {
+			k.authorizeFindCoordinatorResponse(req, resp)
+			return completionFunc(resp)
+		})
 }
 
 func (k *kafkaHandler) HandleJoinGroupRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.JoinGroupRequest,
 	completionFunc func(resp *kafkaprotocol.JoinGroupResponse) error) error {
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return completionFunc(&kafkaprotocol.JoinGroupResponse{
+			ErrorCode:    kafkaprotocol.ErrorCodeGroupAuthorizationFailed,
+			GenerationId: -1,
+			ProtocolName: common.StrPtr(""),
+			Leader:       common.StrPtr(""),
+			MemberId:     common.StrPtr(""),
+		})
+	}
 	return k.agent.groupCoordinator.HandleJoinGroupRequest(hdr, req, completionFunc)
 }
 
 func (k *kafkaHandler) HandleHeartbeatRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.HeartbeatRequest,
 	completionFunc func(resp *kafkaprotocol.HeartbeatResponse) error) error {
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return completionFunc(&kafkaprotocol.HeartbeatResponse{ErrorCode: kafkaprotocol.ErrorCodeGroupAuthorizationFailed})
+	}
 	return k.agent.groupCoordinator.HandleHeartbeatRequest(req, completionFunc)
 }
 
-func (k *kafkaHandler) HandleLeaveGroupRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.LeaveGroupRequest,
+func (k *kafkaHandler) HandleLeaveGroupRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.LeaveGroupRequest,
 	completionFunc func(resp *kafkaprotocol.LeaveGroupResponse) error) error {
-	return k.agent.groupCoordinator.HandleLeaveGroupRequest(req, completionFunc)
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return completionFunc(&kafkaprotocol.LeaveGroupResponse{ErrorCode: kafkaprotocol.ErrorCodeGroupAuthorizationFailed})
+	}
+	return k.agent.groupCoordinator.HandleLeaveGroupRequest(hdr, req, completionFunc)
 }
 
 func (k *kafkaHandler) HandleSyncGroupRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.SyncGroupRequest,
 	completionFunc func(resp *kafkaprotocol.SyncGroupResponse) error) error {
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return completionFunc(&kafkaprotocol.SyncGroupResponse{ErrorCode: kafkaprotocol.ErrorCodeGroupAuthorizationFailed})
+	}
 	return k.agent.groupCoordinator.HandleSyncGroupRequest(req, completionFunc)
 }
 
+func (k *kafkaHandler) HandleListGroupsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.ListGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.ListGroupsResponse) error) error {
+	return k.agent.groupCoordinator.HandleListGroupsRequest(req, func(resp *kafkaprotocol.ListGroupsResponse) error {
+		k.authorizeListGroupsResponse(resp)
+		return completionFunc(resp)
+	})
+}
+
+func (k *kafkaHandler) HandleDescribeGroupsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.DescribeGroupsResponse) error) error {
+	return k.agent.groupCoordinator.HandleDescribeGroupsRequest(req,
+		func(resp *kafkaprotocol.DescribeGroupsResponse) error {
+			k.authorizeDescribeGroupsResponse(resp)
+			return completionFunc(resp)
+		})
+}
+
+func (k *kafkaHandler) HandleConsumerGroupHeartbeatRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest,
+	completionFunc func(resp *kafkaprotocol.ConsumerGroupHeartbeatResponse) error) error {
+	if !k.authorizeGroup(req.GroupId, acls.OperationRead) {
+		return completionFunc(&kafkaprotocol.ConsumerGroupHeartbeatResponse{ErrorCode: kafkaprotocol.ErrorCodeGroupAuthorizationFailed})
+	}
+	return k.agent.groupCoordinator.HandleConsumerGroupHeartbeatRequest(hdr, req, completionFunc)
+}
+
+func (k *kafkaHandler) HandleConsumerGroupDescribeRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.ConsumerGroupDescribeRequest,
+	completionFunc func(resp *kafkaprotocol.ConsumerGroupDescribeResponse) error) error {
+	return k.agent.groupCoordinator.HandleConsumerGroupDescribeRequest(req,
+		func(resp *kafkaprotocol.ConsumerGroupDescribeResponse) error {
+			k.authorizeConsumerGroupDescribeResponse(resp)
+			return completionFunc(resp)
+		})
+}
+
+func (k *kafkaHandler) HandleDeleteGroupsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.DeleteGroupsResponse) error) error {
+	authorizedReq, errResp := k.authorizeDeleteGroupsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	return k.agent.groupCoordinator.HandleDeleteGroupsRequest(authorizedReq,
+		func(resp *kafkaprotocol.DeleteGroupsResponse) error {
+			if errResp != nil {
+				resp.Results = append(resp.Results, errResp.Results...)
+			}
+			return completionFunc(resp)
+		})
+}
+
+func (k *kafkaHandler) HandleOffsetDeleteRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.OffsetDeleteRequest,
+	completionFunc func(resp *kafkaprotocol.OffsetDeleteResponse) error) error {
+	authorizedReq, errResp := k.authorizeOffsetDeleteRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	return k.agent.groupCoordinator.HandleOffsetDeleteRequest(authorizedReq,
+		func(resp *kafkaprotocol.OffsetDeleteResponse) error {
+			if errResp != nil {
+				resp.Topics = append(resp.Topics, errResp.Topics...)
+			}
+			return completionFunc(resp)
+		})
+}
+
+func (k *kafkaHandler) HandleDescribeClientQuotasRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DescribeClientQuotasRequest,
+	completionFunc func(resp *kafkaprotocol.DescribeClientQuotasResponse) error) error {
+	if !k.authorizeCluster(acls.OperationDescribeConfigs) {
+		return completionFunc(&kafkaprotocol.DescribeClientQuotasResponse{
+			ErrorCode:    kafkaprotocol.ErrorCodeClusterAuthorizationFailed,
+			ErrorMessage: common.StrPtr(authorizationFailedMsg),
+		})
+	}
+	return completionFunc(k.agent.HandleDescribeClientQuotasRequest(req))
+}
+
+func (k *kafkaHandler) HandleAlterClientQuotasRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.AlterClientQuotasRequest,
+	completionFunc func(resp *kafkaprotocol.AlterClientQuotasResponse) error) error {
+	if !k.authorizeCluster(acls.OperationAlterConfigs) {
+		return completionFunc(alterClientQuotasErrorResponse(req, kafkaprotocol.ErrorCodeClusterAuthorizationFailed))
+	}
+	return completionFunc(k.agent.HandleAlterClientQuotasRequest(req))
+}
+
 func (k *kafkaHandler) HandleApiVersionsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.ApiVersionsRequest,
 	completionFunc func(resp *kafkaprotocol.ApiVersionsResponse) error) error {
 	var resp kafkaprotocol.ApiVersionsResponse
 	resp.ApiKeys = kafkaprotocol.SupportedAPIVersions
+	// Tagged fields are always written, so we must explicitly specify that there are no finalized features
+	resp.FinalizedFeaturesEpoch = -1
 	return completionFunc(&resp)
 }
 
-func (k *kafkaHandler) HandleInitProducerIdRequest(_ *kafkaprotocol.RequestHeader,
+func (k *kafkaHandler) HandleInitProducerIdRequest(hdr *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.InitProducerIdRequest, completionFunc func(resp *kafkaprotocol.InitProducerIdResponse) error) error {
-	return completionFunc(k.agent.txCoordinator.HandleInitProducerID(req))
+	if errCode := k.authorizeInitProducerIdRequest(req); errCode != kafkaprotocol.ErrorCodeNone {
+		return completionFunc(&kafkaprotocol.InitProducerIdResponse{ErrorCode: errCode, ProducerId: -1, ProducerEpoch: -1})
+	}
+	return completionFunc(k.agent.txCoordinator.HandleInitProducerID(hdr, req))
 }
 
 func (k *kafkaHandler) HandleAddOffsetsToTxnRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.AddOffsetsToTxnRequest, completionFunc func(resp *kafkaprotocol.AddOffsetsToTxnResponse) error) error {
+	if errCode := k.authorizeAddOffsetsToTxnRequest(req); errCode != kafkaprotocol.ErrorCodeNone {
+		return completionFunc(&kafkaprotocol.AddOffsetsToTxnResponse{ErrorCode: errCode})
+	}
 	return completionFunc(k.agent.txCoordinator.HandleAddOffsetsToTxn(req))
 }
 
 func (k *kafkaHandler) HandleAddPartitionsToTxnRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.AddPartitionsToTxnRequest, completionFunc func(resp *kafkaprotocol.AddPartitionsToTxnResponse) error) error {
+	if errResp := k.authorizeAddPartitionsToTxnRequest(req); errResp != nil {
+		return completionFunc(errResp)
+	}
 	return completionFunc(k.agent.txCoordinator.HandleAddPartitionsToTxn(req))
 }
 
 func (k *kafkaHandler) HandleTxnOffsetCommitRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.TxnOffsetCommitRequest, completionFunc func(resp *kafkaprotocol.TxnOffsetCommitResponse) error) error {
-	resp, err := k.agent.groupCoordinator.OffsetCommitTransactional(req)
+	authorizedReq, errResp := k.authorizeTxnOffsetCommitRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp, err := k.agent.groupCoordinator.OffsetCommitTransactional(authorizedReq)
 	if err != nil {
 		return err
 	}
+	if errResp != nil {
+		resp.Topics = append(resp.Topics, errResp.Topics...)
+	}
 	return completionFunc(resp)
 }
 
 func (k *kafkaHandler) HandleEndTxnRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.EndTxnRequest,
 	completionFunc func(resp *kafkaprotocol.EndTxnResponse) error) error {
+	if !k.authorizeTransactionalID(req.TransactionalId, acls.OperationWrite) {
+		return completionFunc(&kafkaprotocol.EndTxnResponse{
+			ErrorCode: kafkaprotocol.ErrorCodeTransactionalIDAuthorizationFailed,
+		})
+	}
 	return completionFunc(k.agent.txCoordinator.HandleEndTxn(req))
 }
 
 func (k *kafkaHandler) HandleSaslAuthenticateRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.SaslAuthenticateRequest,
 	completionFunc func(resp *kafkaprotocol.SaslAuthenticateResponse) error) error {
-	//TODO implement me
-	panic("implement me")
+	return k.ctx.HandleSaslAuthenticateRequest(req, completionFunc)
 }
 
 func (k *kafkaHandler) HandleSaslHandshakeRequest(_ *kafkaprotocol.RequestHeader,
 	req *kafkaprotocol.SaslHandshakeRequest,
 	completionFunc func(resp *kafkaprotocol.SaslHandshakeResponse) error) error {
-	//TODO implement me
-	panic("implement me")
+	return k.ctx.HandleSaslHandshakeRequest(req, completionFunc)
+}
+
+func (k *kafkaHandler) HandleCreateTopicsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.CreateTopicsRequest,
+	completionFunc func(resp *kafkaprotocol.CreateTopicsResponse) error) error {
+	authorizedReq, errResp := k.authorizeCreateTopicsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp := k.agent.HandleCreateTopicsRequest(authorizedReq)
+	if errResp != nil {
+		resp.Topics = append(resp.Topics, errResp.Topics...)
+	}
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleDeleteTopicsRequest(_ *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteTopicsRequest,
+	completionFunc func(resp *kafkaprotocol.DeleteTopicsResponse) error) error {
+	authorizedReq, errResp := k.authorizeDeleteTopicsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp := k.agent.HandleDeleteTopicsRequest(authorizedReq)
+	if errResp != nil {
+		resp.Responses = append(resp.Responses, errResp.Responses...)
+	}
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleCreatePartitionsRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.CreatePartitionsRequest,
+	completionFunc func(resp *kafkaprotocol.CreatePartitionsResponse) error) error {
+	authorizedReq, errResp := k.authorizeCreatePartitionsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp := k.agent.HandleCreatePartitionsRequest(authorizedReq)
+	if errResp != nil {
+		resp.Results = append(resp.Results, errResp.Results...)
+	}
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleDeleteRecordsRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DeleteRecordsRequest,
+	completionFunc func(resp *kafkaprotocol.DeleteRecordsResponse) error) error {
+	authorizedReq, errResp := k.authorizeDeleteRecordsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp := k.agent.HandleDeleteRecordsRequest(authorizedReq)
+	if errResp != nil {
+		resp.Topics = append(resp.Topics, errResp.Topics...)
+	}
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleDescribeConfigsRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.DescribeConfigsRequest,
+	completionFunc func(resp *kafkaprotocol.DescribeConfigsResponse) error) error {
+	resp := k.agent.HandleDescribeConfigsRequest(hdr, req)
+	k.authorizeDescribeConfigsResponse(resp)
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleIncrementalAlterConfigsRequest(_ *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.IncrementalAlterConfigsRequest,
+	completionFunc func(resp *kafkaprotocol.IncrementalAlterConfigsResponse) error) error {
+	authorizedReq, errResp := k.authorizeIncrementalAlterConfigsRequest(req)
+	if authorizedReq == nil {
+		return completionFunc(errResp)
+	}
+	resp := k.agent.HandleIncrementalAlterConfigsRequest(authorizedReq)
+	if errResp != nil {
+		resp.Responses = append(resp.Responses, errResp.Responses...)
+	}
+	return completionFunc(resp)
+}
+
+func (k *kafkaHandler) HandleDescribeAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeAclsRequest,
+	completionFunc func(resp *kafkaprotocol.DescribeAclsResponse) error) error {
+	if !k.authorizeCluster(acls.OperationDescribe) {
+		return completionFunc(&kafkaprotocol.DescribeAclsResponse{
+			ErrorCode:    kafkaprotocol.ErrorCodeClusterAuthorizationFailed,
+			ErrorMessage: common.StrPtr(authorizationFailedMsg),
+			Resources:    []kafkaprotocol.DescribeAclsResponseDescribeAclsResource{},
+		})
+	}
+	return completionFunc(k.agent.HandleDescribeAclsRequest(hdr, req))
+}
+
+func (k *kafkaHandler) HandleCreateAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.CreateAclsRequest,
+	completionFunc func(resp *kafkaprotocol.CreateAclsResponse) error) error {
+	if !k.authorizeCluster(acls.OperationAlter) {
+		var resp kafkaprotocol.CreateAclsResponse
+		resp.Results = make([]kafkaprotocol.CreateAclsResponseAclCreationResult, len(req.Creations))
+		for i := range resp.Results {
+			resp.Results[i].ErrorCode = kafkaprotocol.ErrorCodeClusterAuthorizationFailed
+			resp.Results[i].ErrorMessage = common.StrPtr(authorizationFailedMsg)
+		}
+		return completionFunc(&resp)
+	}
+	return completionFunc(k.agent.HandleCreateAclsRequest(hdr, req))
+}
+
+func (k *kafkaHandler) HandleDeleteAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteAclsRequest,
+	completionFunc func(resp *kafkaprotocol.DeleteAclsResponse) error) error {
+	if !k.authorizeCluster(acls.OperationAlter) {
+		var resp kafkaprotocol.DeleteAclsResponse
+		resp.FilterResults = make([]kafkaprotocol.DeleteAclsResponseDeleteAclsFilterResult, len(req.Filters))
+		for i := range resp.FilterResults {
+			resp.FilterResults[i].ErrorCode = kafkaprotocol.ErrorCodeClusterAuthorizationFailed
+			resp.FilterResults[i].ErrorMessage = common.StrPtr(authorizationFailedMsg)
+			resp.FilterResults[i].MatchingAcls = []kafkaprotocol.DeleteAclsResponseDeleteAclsMatchingAcl{}
+		}
+		return completionFunc(&resp)
+	}
+	return completionFunc(k.agent.HandleDeleteAclsRequest(hdr, req))
 }
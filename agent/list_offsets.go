@@ -1,11 +1,17 @@
 package agent
 
 import (
+	"fmt"
+	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/iteration"
 	"github.com/spirit-labs/tektite/kafkaencoding"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/offsets"
+	"github.com/spirit-labs/tektite/queryutils"
 )
 
 func (a *Agent) HandleListOffsetsRequest(req *kafkaprotocol.ListOffsetsRequest) *kafkaprotocol.ListOffsetsResponse {
@@ -37,12 +43,18 @@ func (a *Agent) handleListOffsetsRequest(req *kafkaprotocol.ListOffsetsRequest)
 		resp.Topics[i].Name = topicInfo.Name
 		for j, partition := range topicInfo.Partitions {
 			resp.Topics[i].Partitions[j].PartitionIndex = partition.PartitionIndex
+			resp.Topics[i].Partitions[j].Timestamp = -1
+			// We don't have leader epochs
+			resp.Topics[i].Partitions[j].LeaderEpoch = -1
 		}
 	}
 	getOffsetRequests := make([]offsets.GetOffsetTopicInfo, 0, len(req.Topics))
 	type respIndex struct {
-		topicIndex int
-		partIndex  int
+		topicIndex  int
+		partIndex   int
+		topicID     int
+		partitionID int
+		timestamp   int64
 	}
 	var respIndexes []respIndex
 	for i, topicInfo := range req.Topics {
@@ -58,25 +70,25 @@ func (a *Agent) handleListOffsetsRequest(req *kafkaprotocol.ListOffsetsRequest)
 		for j, partInfo := range topicInfo.Partitions {
 			if !exists {
 				resp.Topics[i].Partitions[j].ErrorCode = kafkaprotocol.ErrorCodeUnknownTopicOrPartition
-			} else if partInfo.Timestamp == -2 || partInfo.Timestamp == -4 {
-				// earliest - just return zero - this should be ok as if first offset is > 0 then iterator will
-				// just skip to that on fetch
-				resp.Topics[i].Partitions[j].Offset = 0
-			} else if partInfo.Timestamp == -1 {
-				// latest
-				getOffsetTopicInfo.PartitionIDs = append(getOffsetTopicInfo.PartitionIDs, int(partInfo.PartitionIndex))
-				respIndexes = append(respIndexes, respIndex{
-					topicIndex: i,
-					partIndex:  j,
-				})
-			} else {
-				// by timestamp
-				// TODO currently not supported
+				continue
+			}
+			if partInfo.Timestamp < 0 && partInfo.Timestamp != listOffsetsLatest &&
+				partInfo.Timestamp != listOffsetsEarliest && partInfo.Timestamp != listOffsetsMaxTimestamp &&
+				partInfo.Timestamp != listOffsetsEarliestLocal {
 				return &resp, &kafkaencoding.KafkaError{
 					ErrorCode: kafkaprotocol.ErrorCodeInvalidRequest,
-					ErrorMsg:  "list offsets by timestamp currently not supported",
+					ErrorMsg:  fmt.Sprintf("list offsets with timestamp %d currently not supported", partInfo.Timestamp),
 				}
 			}
+			// We always need the last readable offset, as no data after that can be returned
+			getOffsetTopicInfo.PartitionIDs = append(getOffsetTopicInfo.PartitionIDs, int(partInfo.PartitionIndex))
+			respIndexes = append(respIndexes, respIndex{
+				topicIndex:  i,
+				partIndex:   j,
+				topicID:     info.ID,
+				partitionID: int(partInfo.PartitionIndex),
+				timestamp:   partInfo.Timestamp,
+			})
 		}
 		if exists {
 			getOffsetTopicInfo.TopicID = info.ID
@@ -93,9 +105,148 @@ func (a *Agent) handleListOffsetsRequest(req *kafkaprotocol.ListOffsetsRequest)
 	for _, topicOff := range offs {
 		for _, partOff := range topicOff.PartitionInfos {
 			respInd := respIndexes[k]
-			resp.Topics[respInd.topicIndex].Partitions[respInd.partIndex].Offset = partOff.Offset
+			partResp := &resp.Topics[respInd.topicIndex].Partitions[respInd.partIndex]
+			lastOffset := partOff.Offset
+			if req.IsolationLevel == isolationLevelReadCommitted {
+				// read_committed consumers can only see data up to the last stable offset
+				lastOffset = partOff.LastStableOffset
+			}
+			switch respInd.timestamp {
+			case listOffsetsLatest:
+				// As with Kafka, latest is the offset of the next record to be written, i.e. the high watermark
+				partResp.Offset = lastOffset + 1
+			case listOffsetsEarliest, listOffsetsEarliestLocal:
+				partResp.Offset, err = a.getEarliestOffset(client, respInd.topicID, respInd.partitionID, lastOffset,
+					partOff.LogStartOffset)
+				if err != nil {
+					return &resp, err
+				}
+			case listOffsetsMaxTimestamp:
+				partResp.Offset, partResp.Timestamp, err = a.getOffsetForMaxTimestamp(client, respInd.topicID,
+					respInd.partitionID, lastOffset)
+				if err != nil {
+					return &resp, err
+				}
+			default:
+				partResp.Offset, partResp.Timestamp, err = a.getOffsetForTimestamp(client, respInd.topicID,
+					respInd.partitionID, respInd.timestamp, lastOffset)
+				if err != nil {
+					return &resp, err
+				}
+				if partResp.Offset != -1 && partResp.Offset < partOff.LogStartOffset {
+					// The record is in a batch which contains the log start offset, but has been deleted
+					partResp.Offset = partOff.LogStartOffset
+				}
+			}
 			k++
 		}
 	}
 	return &resp, nil
 }
+
+const (
+	listOffsetsLatest        = -1
+	listOffsetsEarliest      = -2
+	listOffsetsMaxTimestamp  = -3
+	listOffsetsEarliestLocal = -4
+
+	isolationLevelReadCommitted = 1
+)
+
+// getEarliestOffset returns the offset of the first batch stored for the partition, or the log start offset if that is
+// later, as the first batch can contain records which have been deleted. If the partition has no data then this is the
+// offset after the last readable offset, the same as the latest offset.
+func (a *Agent) getEarliestOffset(client control.Client, topicID int, partitionID int, lastReadableOffset int64,
+	logStartOffset int64) (int64, error) {
+	iter, err := a.createPartitionIterator(client, topicID, partitionID, lastReadableOffset, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	ok, kv, err := iter.Next()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return lastReadableOffset + 1, nil
+	}
+	baseOffset := kafkaencoding.BaseOffset(kv.Value)
+	if baseOffset < logStartOffset {
+		return logStartOffset, nil
+	}
+	return baseOffset, nil
+}
+
+// getOffsetForTimestamp returns the offset and timestamp of the first record in the partition with a timestamp >= the
+// provided timestamp. If there is no such record, -1 is returned for both.
+func (a *Agent) getOffsetForTimestamp(client control.Client, topicID int, partitionID int, timestamp int64,
+	lastReadableOffset int64) (int64, int64, error) {
+	iter, err := a.createPartitionIterator(client, topicID, partitionID, lastReadableOffset,
+		func(tables lsm.OverlappingTables) lsm.OverlappingTables {
+			// Tables which only contain data before the timestamp don't need to be searched
+			return tables.FilterByMaxTimestamp(timestamp)
+		})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer iter.Close()
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return 0, 0, err
+		}
+		if !ok {
+			return -1, -1, nil
+		}
+		offset, recordTimestamp, found := kafkaencoding.FindOffsetForTimestamp(kv.Value, timestamp)
+		if found {
+			return offset, recordTimestamp, nil
+		}
+	}
+}
+
+// getOffsetForMaxTimestamp returns the offset and timestamp of the first record in the partition with the largest
+// timestamp. If the partition has no data, -1 is returned for both.
+func (a *Agent) getOffsetForMaxTimestamp(client control.Client, topicID int, partitionID int,
+	lastReadableOffset int64) (int64, int64, error) {
+	iter, err := a.createPartitionIterator(client, topicID, partitionID, lastReadableOffset, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer iter.Close()
+	offset, maxTimestamp := int64(-1), int64(-1)
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return 0, 0, err
+		}
+		if !ok {
+			return offset, maxTimestamp, nil
+		}
+		if kafkaencoding.MaxTimestamp(kv.Value) > maxTimestamp {
+			offset, maxTimestamp = kafkaencoding.FindOffsetForMaxTimestamp(kv.Value)
+		}
+	}
+}
+
+func (a *Agent) createPartitionIterator(client control.Client, topicID int, partitionID int, lastReadableOffset int64,
+	filter func(tables lsm.OverlappingTables) lsm.OverlappingTables) (iteration.Iterator, error) {
+	partHash, err := a.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	keyStart := make([]byte, 0, 17)
+	keyStart = append(keyStart, partHash...)
+	keyStart = append(keyStart, common.EntryTypeTopicData)
+	keyEnd := make([]byte, 0, 25)
+	keyEnd = append(keyEnd, keyStart...)
+	keyEnd = encoding.KeyEncodeInt(keyEnd, lastReadableOffset+1)
+	tables, err := client.QueryTablesInRange(keyStart, keyEnd)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		tables = filter(tables)
+	}
+	return queryutils.CreateIteratorForTables(tables, keyStart, keyEnd, a.tableGetter)
+}
@@ -2,26 +2,35 @@ package agent
 
 import (
 	"errors"
+	"fmt"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/testutils"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
 
 func TestListLatestOffset(t *testing.T) {
+	// Latest is the next offset to be written
 	off := int64(123213)
-	testListOffsets(t, off, off, -1, nil, kafkaprotocol.ErrorCodeNone)
+	testListOffsets(t, off+1, off, -1, nil, kafkaprotocol.ErrorCodeNone)
 }
 
 func TestListEarliestOffset1(t *testing.T) {
+	// No data in partition, so earliest is the next offset to be written
 	off := int64(123213)
-	testListOffsets(t, 0, off, -2, nil, kafkaprotocol.ErrorCodeNone)
+	testListOffsets(t, off+1, off, -2, nil, kafkaprotocol.ErrorCodeNone)
 }
 
 func TestListEarliestOffset2(t *testing.T) {
 	off := int64(123213)
-	testListOffsets(t, 0, off, -4, nil, kafkaprotocol.ErrorCodeNone)
+	testListOffsets(t, off+1, off, -4, nil, kafkaprotocol.ErrorCodeNone)
+}
+
+func TestListOffsetUnsupportedTimestamp(t *testing.T) {
+	off := int64(123213)
+	testListOffsets(t, 0, off, -5, nil, kafkaprotocol.ErrorCodeInvalidRequest)
 }
 
 func TestListOffsetInjectUnavailable(t *testing.T) {
@@ -89,5 +98,109 @@ func testListOffsets(t *testing.T, expectedOffset int64, offset int64, timestamp
 	require.Equal(t, partitionID, int(resp.Topics[0].Partitions[0].PartitionIndex))
 	if expectedErrCode == kafkaprotocol.ErrorCodeNone {
 		require.Equal(t, expectedOffset, resp.Topics[0].Partitions[0].Offset)
+		if timestamp == listOffsetsEarliest || timestamp == listOffsetsEarliestLocal {
+			latestResp := sendListOffsets(t, conn, "topic-00003", partitionID, listOffsetsLatest)
+			require.Equal(t, kafkaprotocol.ErrorCodeNone, int(latestResp.ErrorCode))
+			require.LessOrEqual(t, expectedOffset, latestResp.Offset)
+		}
+	}
+}
+
+func TestListOffsetsByTimestamp(t *testing.T) {
+	topicName := "test-topic-1"
+	partitionID := 7
+	topicInfos := []topicmeta.TopicInfo{
+		{
+			Name:           topicName,
+			PartitionCount: 10,
+		},
+	}
+	cfg := NewConf()
+	agent, _, tearDown := setupAgent(t, topicInfos, cfg)
+	defer tearDown(t)
+
+	address := agent.Conf().KafkaListenerConfig.Address
+	// Produce batches in separate tables with records at timestamps 1000-1009, 2000-2009, 3000-3009
+	offsetStart := 0
+	for i := 1; i <= 3; i++ {
+		var msgs []testutils.RawKafkaMessage
+		for j := 0; j < 10; j++ {
+			msgs = append(msgs, testutils.RawKafkaMessage{
+				Timestamp: int64(1000*i + j),
+				Key:       []byte(fmt.Sprintf("key%09d", offsetStart+j)),
+				Value:     []byte(fmt.Sprintf("val%09d", offsetStart+j)),
+			})
+		}
+		sendProduceBatch(t, topicName, partitionID, address, testutils.CreateKafkaRecordBatch(msgs, int64(offsetStart)))
+		offsetStart += len(msgs)
+	}
+
+	cl, err := NewKafkaApiClient()
+	require.NoError(t, err)
+	conn, err := cl.NewConnection(address)
+	require.NoError(t, err)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	testCases := []struct {
+		timestamp         int64
+		expectedOffset    int64
+		expectedTimestamp int64
+	}{
+		{timestamp: 0, expectedOffset: 0, expectedTimestamp: 1000},
+		{timestamp: 1000, expectedOffset: 0, expectedTimestamp: 1000},
+		{timestamp: 1005, expectedOffset: 5, expectedTimestamp: 1005},
+		{timestamp: 1500, expectedOffset: 10, expectedTimestamp: 2000},
+		{timestamp: 2009, expectedOffset: 19, expectedTimestamp: 2009},
+		{timestamp: 3000, expectedOffset: 20, expectedTimestamp: 3000},
+		{timestamp: 3009, expectedOffset: 29, expectedTimestamp: 3009},
+		{timestamp: 3010, expectedOffset: -1, expectedTimestamp: -1},
+	}
+	for _, tc := range testCases {
+		partResp := sendListOffsets(t, conn, topicName, partitionID, tc.timestamp)
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+		require.Equal(t, tc.expectedOffset, partResp.Offset, "timestamp %d", tc.timestamp)
+		require.Equal(t, tc.expectedTimestamp, partResp.Timestamp, "timestamp %d", tc.timestamp)
 	}
+
+	// earliest
+	partResp := sendListOffsets(t, conn, topicName, partitionID, -2)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	require.Equal(t, int64(0), partResp.Offset)
+
+	// latest
+	partResp = sendListOffsets(t, conn, topicName, partitionID, -1)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	require.Equal(t, int64(30), partResp.Offset)
+
+	// max timestamp
+	partResp = sendListOffsets(t, conn, topicName, partitionID, -3)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	require.Equal(t, int64(29), partResp.Offset)
+	require.Equal(t, int64(3009), partResp.Timestamp)
+}
+
+func sendListOffsets(t *testing.T, conn *KafkaApiConnection, topicName string, partitionID int,
+	timestamp int64) kafkaprotocol.ListOffsetsResponseListOffsetsPartitionResponse {
+	req := &kafkaprotocol.ListOffsetsRequest{
+		Topics: []kafkaprotocol.ListOffsetsRequestListOffsetsTopic{
+			{
+				Name: common.StrPtr(topicName),
+				Partitions: []kafkaprotocol.ListOffsetsRequestListOffsetsPartition{
+					{
+						PartitionIndex: int32(partitionID),
+						Timestamp:      timestamp,
+					},
+				},
+			},
+		},
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyListOffsets, 1, &kafkaprotocol.ListOffsetsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.ListOffsetsResponse)
+	require.Equal(t, 1, len(resp.Topics))
+	require.Equal(t, 1, len(resp.Topics[0].Partitions))
+	return resp.Topics[0].Partitions[0]
 }
@@ -75,34 +75,49 @@ func TestMetadataOnlySameAZ(t *testing.T) {
 
 	// Send requests with a client id which doesn't match any AZs - it should choose the first AZ
 	resp := sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "foo")
-	verifyBrokers(t, expectedAgents1, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents1, resp)
 
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "tek_az=foo")
-	verifyBrokers(t, expectedAgents1, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents1, resp)
 
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "ws_az=foo")
-	verifyBrokers(t, expectedAgents1, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents1, resp)
 
 	// Send requests matching the first AZ
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "tek_az=az-true")
-	verifyBrokers(t, expectedAgents1, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents1, resp)
 
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "ws_az=az-true")
-	verifyBrokers(t, expectedAgents1, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents1, resp)
 
 	// Send requests matching the second AZ
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "tek_az=az-false")
-	verifyBrokers(t, expectedAgents2, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents2, resp)
 
 	resp = sendMetadataRequest(t, agents[0], &kafkaprotocol.MetadataRequest{}, "ws_az=az-false")
-	verifyBrokers(t, expectedAgents2, resp)
+	verifyBrokers(t, agents, resp)
 	verifyTopics(t, numTopics, expectedAgents2, resp)
+
+	// Agents in the other AZ are returned as replicas, so consumers can be directed to fetch from them
+	for _, topic := range resp.Topics {
+		var topicIndex int
+		_, err := fmt.Sscanf(*topic.Name, "topic-%05d", &topicIndex)
+		require.NoError(t, err)
+		topicID := topicmeta.TopicIDSequenceBase + topicIndex
+		for _, partition := range topic.Partitions {
+			partHash, err := parthash.CreatePartitionHash(topicID, int(partition.PartitionIndex))
+			require.NoError(t, err)
+			replica := expectedAgents1[common.CalcMemberForHash(partHash, len(expectedAgents1))]
+			require.Equal(t, []int32{partition.LeaderId, replica.MemberID()}, partition.ReplicaNodes)
+			require.Equal(t, partition.ReplicaNodes, partition.IsrNodes)
+		}
+	}
 }
 
 func TestMetadataNoTopics(t *testing.T) {
@@ -173,6 +188,55 @@ func TestMetadataSingleTopicUnknown(t *testing.T) {
 	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(resp.Topics[0].ErrorCode))
 }
 
+func TestMetadataTopicIDs(t *testing.T) {
+	t.Parallel()
+	cfg := NewConf()
+	numAgents := 5
+	agents, tearDown := setupAgents(t, cfg, numAgents, func(i int) string {
+		return "az1"
+	})
+	defer tearDown(t)
+	numTopics := 3
+	setupNumTopics(t, numTopics, agents[0])
+
+	for i := 0; i < numTopics; i++ {
+		topicName := fmt.Sprintf("topic-%05d", i)
+		// lookup by name returns the topic id
+		req := &kafkaprotocol.MetadataRequest{}
+		req.Topics = []kafkaprotocol.MetadataRequestMetadataRequestTopic{
+			{
+				Name: common.StrPtr(topicName),
+			},
+		}
+		resp := sendMetadataRequestWithVersion(t, agents[0], req, "", 12)
+		require.Equal(t, 1, len(resp.Topics))
+		verifySingleTopic(t, topicName, topicmeta.TopicIDSequenceBase+i, 1+i*2, agents, resp.Topics[0])
+		topicID := resp.Topics[0].TopicId
+		require.Equal(t, 16, len(topicID))
+
+		// lookup by topic id returns the topic name
+		req.Topics = []kafkaprotocol.MetadataRequestMetadataRequestTopic{
+			{
+				TopicId: topicID,
+			},
+		}
+		resp = sendMetadataRequestWithVersion(t, agents[0], req, "", 12)
+		require.Equal(t, 1, len(resp.Topics))
+		verifySingleTopic(t, topicName, topicmeta.TopicIDSequenceBase+i, 1+i*2, agents, resp.Topics[0])
+		require.Equal(t, topicID, resp.Topics[0].TopicId)
+	}
+
+	req := &kafkaprotocol.MetadataRequest{}
+	req.Topics = []kafkaprotocol.MetadataRequestMetadataRequestTopic{
+		{
+			TopicId: make([]byte, 16),
+		},
+	}
+	resp := sendMetadataRequestWithVersion(t, agents[0], req, "", 12)
+	require.Equal(t, 1, len(resp.Topics))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicID, int(resp.Topics[0].ErrorCode))
+}
+
 func TestMetadataControllerUnavailable(t *testing.T) {
 	t.Parallel()
 	cfg := NewConf()
@@ -266,6 +330,11 @@ func setupNumTopics(t *testing.T, numTopics int, agent *Agent) {
 }
 
 func sendMetadataRequest(t *testing.T, agent *Agent, req *kafkaprotocol.MetadataRequest, clientID string) *kafkaprotocol.MetadataResponse {
+	return sendMetadataRequestWithVersion(t, agent, req, clientID, 1)
+}
+
+func sendMetadataRequestWithVersion(t *testing.T, agent *Agent, req *kafkaprotocol.MetadataRequest, clientID string,
+	apiVersion int16) *kafkaprotocol.MetadataResponse {
 	cl, err := NewKafkaApiClientWithClientID(clientID)
 	require.NoError(t, err)
 	conn, err := cl.NewConnection(agent.Conf().KafkaListenerConfig.Address)
@@ -275,7 +344,7 @@ func sendMetadataRequest(t *testing.T, agent *Agent, req *kafkaprotocol.Metadata
 		require.NoError(t, err)
 	}()
 	resp := &kafkaprotocol.MetadataResponse{}
-	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyMetadata, 1, resp)
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyMetadata, apiVersion, resp)
 	require.NoError(t, err)
 	return r.(*kafkaprotocol.MetadataResponse)
 }
@@ -326,6 +395,7 @@ func verifyBrokers(t *testing.T, agents []*Agent, resp *kafkaprotocol.MetadataRe
 		address, port := splitHostPort(t, agent.cfg.KafkaListenerConfig.Address)
 		require.Equal(t, address, common.SafeDerefStringPtr(broker.Host))
 		require.Equal(t, port, int(broker.Port))
+		require.Equal(t, agent.cfg.FetchCacheConf.AzInfo, common.SafeDerefStringPtr(broker.Rack))
 	}
 }
 
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/metrics"
+)
+
+type agentMetrics struct {
+	produceLatency    prometheus.Histogram
+	produceBytes      prometheus.Counter
+	fetchLatency      prometheus.Histogram
+	fetchBytes        prometheus.Counter
+	throttledRequests *prometheus.CounterVec
+}
+
+func newAgentMetrics() *agentMetrics {
+	return &agentMetrics{
+		produceLatency: metrics.NewHistogram("kafka", "produce_duration_seconds",
+			"time taken to handle produce requests, including writing the data to object storage", metrics.LatencyBuckets),
+		produceBytes: metrics.NewCounter("kafka", "produce_bytes_total",
+			"number of bytes of record batches received in produce requests"),
+		fetchLatency: metrics.NewHistogram("kafka", "fetch_duration_seconds",
+			"time taken to handle fetch requests, including any time waiting for data", metrics.LatencyBuckets),
+		fetchBytes: metrics.NewCounter("kafka", "fetch_bytes_total",
+			"number of bytes of record batches returned in fetch responses"),
+		throttledRequests: metrics.NewCounterVec("kafka", "throttled_requests_total",
+			"number of produce and fetch requests throttled as the client exceeded its quota", "quota"),
+	}
+}
+
+// registerMetrics registers the metrics of the agent and all of its components with the registry
+func (a *Agent) registerMetrics(registry *metrics.Registry) {
+	registry.MustRegister(a.metrics.produceLatency, a.metrics.produceBytes, a.metrics.fetchLatency,
+		a.metrics.fetchBytes, a.metrics.throttledRequests)
+	a.controller.RegisterMetrics(registry)
+	a.tablePusher.RegisterMetrics(registry)
+	a.fetchCache.RegisterMetrics(registry)
+	a.groupCoordinator.RegisterMetrics(registry)
+}
+
+func (a *Agent) MetricsRegistry() *metrics.Registry {
+	return a.metricsRegistry
+}
+
+// MetricsListenAddress returns the address that metrics are served on, or empty string if metrics are not enabled
+func (a *Agent) MetricsListenAddress() string {
+	if a.metricsServer == nil {
+		return ""
+	}
+	return a.metricsServer.ListenAddress()
+}
+
+func produceRequestBytes(req *kafkaprotocol.ProduceRequest) int {
+	size := 0
+	for _, topicData := range req.TopicData {
+		for _, partitionData := range topicData.PartitionData {
+			for _, records := range partitionData.Records {
+				size += len(records)
+			}
+		}
+	}
+	return size
+}
+
+func fetchResponseBytes(resp *kafkaprotocol.FetchResponse) int {
+	size := 0
+	for _, topicResp := range resp.Responses {
+		for _, partitionResp := range topicResp.Partitions {
+			for _, records := range partitionResp.Records {
+				size += len(records)
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	topicName := "test-topic-1"
+	topicInfos := []topicmeta.TopicInfo{
+		{
+			Name:           topicName,
+			PartitionCount: 10,
+		},
+	}
+	cfg := NewConf()
+	metricsAddress, err := common.AddressWithPort("localhost")
+	require.NoError(t, err)
+	cfg.MetricsConf.Enabled = true
+	cfg.MetricsConf.ListenAddress = metricsAddress
+	agent, _, tearDown := setupAgent(t, topicInfos, cfg)
+	defer tearDown(t)
+	require.Equal(t, metricsAddress, agent.MetricsListenAddress())
+
+	batch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 100)
+	sendProduceBatch(t, topicName, 1, agent.KafkaListenAddress(), batch)
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/metrics", metricsAddress))
+	require.NoError(t, err)
+	defer func() {
+		err := resp.Body.Close()
+		require.NoError(t, err)
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	bytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	body := string(bytes)
+
+	require.Contains(t, body, fmt.Sprintf("tektite_kafka_produce_bytes_total %d\n", len(batch)))
+	require.Contains(t, body, "tektite_kafka_produce_duration_seconds_count 1\n")
+	require.Contains(t, body, "tektite_table_pusher_table_push_bytes_count 1\n")
+	require.Contains(t, body, "tektite_table_pusher_produced_batches_total 1\n")
+	require.Contains(t, body, `tektite_controller_rpcs_total{result="ok",rpc="register_l0_table"} 1`)
+	// Topic metadata is also written to the LSM
+	require.Regexp(t, "\ntektite_lsm_l0_tables [1-9]\n", body)
+	require.Contains(t, body, "tektite_group_coordinator_rebalances_total 0\n")
+	require.Contains(t, body, "tektite_fetch_cache_gets_total")
+	require.Contains(t, body, "go_goroutines")
+}
+
+func TestMetricsNotServedByDefault(t *testing.T) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	require.Equal(t, "", agent.MetricsListenAddress())
+	// Metrics are still collected
+	families, err := agent.MetricsRegistry().Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+}
@@ -28,6 +28,14 @@ func init() {
 }
 
 func TestProduceSimple(t *testing.T) {
+	testProduceSimple(t, 3)
+}
+
+func TestProduceSimpleFlexibleVersion(t *testing.T) {
+	testProduceSimple(t, 9)
+}
+
+func testProduceSimple(t *testing.T, apiVersion int16) {
 	topicName := "test-topic-1"
 	topicID := 1001
 	partitionID := 12
@@ -76,7 +84,7 @@ func TestProduceSimple(t *testing.T) {
 	}()
 
 	var resp kafkaprotocol.ProduceResponse
-	r, err := conn.SendRequest(&req, kafkaprotocol.APIKeyProduce, 3, &resp)
+	r, err := conn.SendRequest(&req, kafkaprotocol.APIKeyProduce, apiVersion, &resp)
 	produceResp, ok := r.(*kafkaprotocol.ProduceResponse)
 	require.True(t, ok)
 
@@ -85,6 +93,10 @@ func TestProduceSimple(t *testing.T) {
 	partResp := produceResp.Responses[0].PartitionResponses[0]
 	require.Equal(t, int16(kafkaprotocol.ErrorCodeNone), partResp.ErrorCode)
 	require.Equal(t, (*string)(nil), partResp.ErrorMessage)
+	if apiVersion >= 5 {
+		require.Equal(t, -1, int(partResp.LogAppendTimeMs))
+		require.Equal(t, -1, int(partResp.LogStartOffset))
+	}
 
 	controllerCl, err := agent.controller.Client()
 	require.NoError(t, err)
@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/stretchr/testify/require"
+	"github.com/xdg-go/scram"
+	"testing"
+)
+
+func TestSaslScramSha256(t *testing.T) {
+	testSaslScram(t, auth.AuthenticationSaslScramSha256, scram.SHA256)
+}
+
+func TestSaslScramSha512(t *testing.T) {
+	testSaslScram(t, auth.AuthenticationSaslScramSha512, scram.SHA512)
+}
+
+func testSaslScram(t *testing.T, mechanism string, hashGen scram.HashGeneratorFcn) {
+	t.Parallel()
+	agent, conn, tearDown := setupSaslAgent(t, mechanism)
+	defer tearDown(t)
+
+	username := "some-user"
+	password := "some-password"
+	putUserCredentials(t, agent, username, password, mechanism)
+
+	handshakeResp := sendSaslHandshake(t, conn, mechanism)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(handshakeResp.ErrorCode))
+	var mechanisms []string
+	for _, mech := range handshakeResp.Mechanisms {
+		mechanisms = append(mechanisms, common.SafeDerefStringPtr(mech))
+	}
+	require.Equal(t, []string{auth.AuthenticationSaslScramSha256, auth.AuthenticationSaslScramSha512}, mechanisms)
+
+	ok := runScramConversation(t, conn, hashGen, username, password)
+	require.True(t, ok)
+
+	// Now authenticated we can send other requests
+	req := &kafkaprotocol.InitProducerIdRequest{}
+	resp := &kafkaprotocol.InitProducerIdResponse{}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyInitProducerId, 0, resp)
+	require.NoError(t, err)
+	resp = r.(*kafkaprotocol.InitProducerIdResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+}
+
+func TestSaslScramWrongPassword(t *testing.T) {
+	t.Parallel()
+	agent, conn, tearDown := setupSaslAgent(t, auth.AuthenticationSaslScramSha256)
+	defer tearDown(t)
+
+	username := "some-user"
+	putUserCredentials(t, agent, username, "some-password", auth.AuthenticationSaslScramSha256)
+
+	handshakeResp := sendSaslHandshake(t, conn, auth.AuthenticationSaslScramSha256)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(handshakeResp.ErrorCode))
+	ok := runScramConversation(t, conn, scram.SHA256, username, "wrong-password")
+	require.False(t, ok)
+}
+
+func TestSaslScramUnknownUser(t *testing.T) {
+	t.Parallel()
+	_, conn, tearDown := setupSaslAgent(t, auth.AuthenticationSaslScramSha256)
+	defer tearDown(t)
+
+	handshakeResp := sendSaslHandshake(t, conn, auth.AuthenticationSaslScramSha256)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(handshakeResp.ErrorCode))
+	ok := runScramConversation(t, conn, scram.SHA256, "unknown-user", "some-password")
+	require.False(t, ok)
+}
+
+func TestSaslUnsupportedMechanism(t *testing.T) {
+	t.Parallel()
+	_, conn, tearDown := setupSaslAgent(t, auth.AuthenticationSaslScramSha256)
+	defer tearDown(t)
+
+	handshakeResp := sendSaslHandshake(t, conn, "PLAIN")
+	require.Equal(t, kafkaprotocol.ErrorCodeUnsupportedSaslMechanism, int(handshakeResp.ErrorCode))
+}
+
+func TestSaslAuthenticateWithoutHandshake(t *testing.T) {
+	t.Parallel()
+	_, conn, tearDown := setupSaslAgent(t, auth.AuthenticationSaslScramSha256)
+	defer tearDown(t)
+
+	req := &kafkaprotocol.SaslAuthenticateRequest{AuthBytes: []byte("foo")}
+	resp := &kafkaprotocol.SaslAuthenticateResponse{}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeySaslAuthenticate, 1, resp)
+	require.NoError(t, err)
+	resp = r.(*kafkaprotocol.SaslAuthenticateResponse)
+	require.Equal(t, kafkaprotocol.ErrorCodeIllegalSaslState, int(resp.ErrorCode))
+}
+
+func setupSaslAgent(t *testing.T, mechanism string) (*Agent, *KafkaApiConnection, func(t *testing.T)) {
+	cfg := NewConf()
+	cfg.KafkaListenerConfig.AuthenticationType = mechanism
+	agents, tearDown := setupAgents(t, cfg, 1, func(i int) string {
+		return "az1"
+	})
+	cl, err := NewKafkaApiClient()
+	require.NoError(t, err)
+	conn, err := cl.NewConnection(agents[0].Conf().KafkaListenerConfig.Address)
+	require.NoError(t, err)
+	return agents[0], conn, func(t *testing.T) {
+		err := conn.Close()
+		require.NoError(t, err)
+		tearDown(t)
+	}
+}
+
+func putUserCredentials(t *testing.T, agent *Agent, username string, password string, mechanism string) {
+	cl, err := agent.controller.Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+	storedKey, serverKey, salt := auth.CreateUserScramCreds(password, mechanism)
+	err = cl.PutUserCredentials(username, mechanism, storedKey, serverKey, salt, auth.NumIters)
+	require.NoError(t, err)
+}
+
+func sendSaslHandshake(t *testing.T, conn *KafkaApiConnection, mechanism string) *kafkaprotocol.SaslHandshakeResponse {
+	req := &kafkaprotocol.SaslHandshakeRequest{Mechanism: common.StrPtr(mechanism)}
+	resp := &kafkaprotocol.SaslHandshakeResponse{}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeySaslHandshake, 1, resp)
+	require.NoError(t, err)
+	return r.(*kafkaprotocol.SaslHandshakeResponse)
+}
+
+// runScramConversation runs the client side of a SCRAM conversation with the agent using SaslAuthenticate requests,
+// it returns true if authentication succeeded
+func runScramConversation(t *testing.T, conn *KafkaApiConnection, hashGen scram.HashGeneratorFcn, username string,
+	password string) bool {
+	scramClient, err := hashGen.NewClient(username, password, "")
+	require.NoError(t, err)
+	clConv := scramClient.NewConversation()
+	clientMsg, err := clConv.Step("")
+	require.NoError(t, err)
+	for !clConv.Done() {
+		req := &kafkaprotocol.SaslAuthenticateRequest{AuthBytes: []byte(clientMsg)}
+		resp := &kafkaprotocol.SaslAuthenticateResponse{}
+		r, err := conn.SendRequest(req, kafkaprotocol.APIKeySaslAuthenticate, 1, resp)
+		require.NoError(t, err)
+		resp = r.(*kafkaprotocol.SaslAuthenticateResponse)
+		if resp.ErrorCode != kafkaprotocol.ErrorCodeNone {
+			require.Equal(t, kafkaprotocol.ErrorCodeSaslAuthenticationFailed, int(resp.ErrorCode))
+			return false
+		}
+		clientMsg, err = clConv.Step(string(resp.AuthBytes))
+		if err != nil {
+			return false
+		}
+	}
+	return clConv.Valid()
+}
@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/topicmeta"
+)
+
+const (
+	// defaultPartitionCount is used when a topic is created without specifying the number of partitions, as in Kafka
+	defaultPartitionCount = 1
+	maxTopicNameLength    = 249
+)
+
+func (a *Agent) HandleCreateTopicsRequest(req *kafkaprotocol.CreateTopicsRequest) *kafkaprotocol.CreateTopicsResponse {
+	var resp kafkaprotocol.CreateTopicsResponse
+	resp.Topics = make([]kafkaprotocol.CreateTopicsResponseCreatableTopicResult, len(req.Topics))
+	names := make(map[string]int, len(req.Topics))
+	for _, topic := range req.Topics {
+		names[common.SafeDerefStringPtr(topic.Name)]++
+	}
+	for i := range req.Topics {
+		topic := &req.Topics[i]
+		result := &resp.Topics[i]
+		result.Name = topic.Name
+		topicName := common.SafeDerefStringPtr(topic.Name)
+		if names[topicName] > 1 {
+			setCreateTopicError(result, kafkaprotocol.ErrorCodeInvalidRequest,
+				fmt.Sprintf("topic %s specified more than once", topicName))
+			continue
+		}
+		info, errCode, errMsg := createTopicInfo(topic)
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			setCreateTopicError(result, errCode, errMsg)
+			continue
+		}
+		if !req.ValidateOnly {
+			if err := a.createTopic(info); err != nil {
+				setCreateTopicError(result, topicErrorCode(err), err.Error())
+				continue
+			}
+		}
+		result.NumPartitions = int32(info.PartitionCount)
+		result.ReplicationFactor = topic.ReplicationFactor
+		if result.ReplicationFactor < 1 {
+			result.ReplicationFactor = 1
+		}
+	}
+	return &resp
+}
+
+func setCreateTopicError(result *kafkaprotocol.CreateTopicsResponseCreatableTopicResult, errCode int16, errMsg string) {
+	result.ErrorCode = errCode
+	result.ErrorMessage = &errMsg
+	result.NumPartitions = -1
+	result.ReplicationFactor = -1
+}
+
+func (a *Agent) createTopic(info topicmeta.TopicInfo) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.CreateTopic(info)
+}
+
+// createTopicInfo validates the requested topic and creates the topic info. Replica assignments and replication factor
+// are ignored as data is replicated by the object store.
+func createTopicInfo(topic *kafkaprotocol.CreateTopicsRequestCreatableTopic) (topicmeta.TopicInfo, int16, string) {
+	topicName := common.SafeDerefStringPtr(topic.Name)
+	if errMsg := validateTopicName(topicName); errMsg != "" {
+		return topicmeta.TopicInfo{}, kafkaprotocol.ErrorCodeInvalidTopicException, errMsg
+	}
+	partitionCount := int(topic.NumPartitions)
+	if len(topic.Assignments) > 0 {
+		partitionCount = len(topic.Assignments)
+	} else if partitionCount == -1 {
+		partitionCount = defaultPartitionCount
+	}
+	if partitionCount < 1 {
+		return topicmeta.TopicInfo{}, kafkaprotocol.ErrorCodeInvalidPartitions,
+			fmt.Sprintf("invalid number of partitions: %d", topic.NumPartitions)
+	}
+	info := topicmeta.TopicInfo{
+		Name:           topicName,
+		PartitionCount: partitionCount,
+	}
+	for _, config := range topic.Configs {
+		if err := info.SetConfig(common.SafeDerefStringPtr(config.Name), common.SafeDerefStringPtr(config.Value)); err != nil {
+			return topicmeta.TopicInfo{}, kafkaprotocol.ErrorCodeInvalidConfig, err.Error()
+		}
+	}
+	return info, kafkaprotocol.ErrorCodeNone, ""
+}
+
+func validateTopicName(topicName string) string {
+	if topicName == "" {
+		return "topic name must not be empty"
+	}
+	if topicName == "." || topicName == ".." {
+		return fmt.Sprintf("topic name cannot be %s", topicName)
+	}
+	if len(topicName) > maxTopicNameLength {
+		return fmt.Sprintf("topic name cannot be longer than %d characters", maxTopicNameLength)
+	}
+	for _, c := range topicName {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return fmt.Sprintf("topic name %s contains illegal characters, only ASCII alphanumerics, '.', '_' and '-' are allowed",
+				topicName)
+		}
+	}
+	return ""
+}
+
+func (a *Agent) HandleDeleteTopicsRequest(req *kafkaprotocol.DeleteTopicsRequest) *kafkaprotocol.DeleteTopicsResponse {
+	var resp kafkaprotocol.DeleteTopicsResponse
+	resp.Responses = make([]kafkaprotocol.DeleteTopicsResponseDeletableTopicResult, len(req.TopicNames))
+	for i, topicName := range req.TopicNames {
+		result := &resp.Responses[i]
+		result.Name = topicName
+		if err := a.deleteTopic(common.SafeDerefStringPtr(topicName)); err != nil {
+			result.ErrorCode = topicErrorCode(err)
+			errMsg := err.Error()
+			result.ErrorMessage = &errMsg
+		}
+	}
+	return &resp
+}
+
+func (a *Agent) deleteTopic(topicName string) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.DeleteTopic(topicName)
+}
+
+func (a *Agent) HandleCreatePartitionsRequest(req *kafkaprotocol.CreatePartitionsRequest) *kafkaprotocol.CreatePartitionsResponse {
+	var resp kafkaprotocol.CreatePartitionsResponse
+	resp.Results = make([]kafkaprotocol.CreatePartitionsResponseCreatePartitionsTopicResult, len(req.Topics))
+	for i, topic := range req.Topics {
+		result := &resp.Results[i]
+		result.Name = topic.Name
+		if err := a.createPartitions(common.SafeDerefStringPtr(topic.Name), int(topic.Count), req.ValidateOnly); err != nil {
+			result.ErrorCode = topicErrorCode(err)
+			errMsg := err.Error()
+			result.ErrorMessage = &errMsg
+		}
+	}
+	return &resp
+}
+
+func (a *Agent) createPartitions(topicName string, partitionCount int, validateOnly bool) error {
+	client, err := a.controlClientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	if validateOnly {
+		info, _, exists, err := client.GetTopicInfo(topicName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return common.NewTektiteErrorf(common.TopicDoesNotExist, "topic: %s does not exist", topicName)
+		}
+		if partitionCount <= info.PartitionCount {
+			return common.NewTektiteErrorf(common.InvalidPartitionCount,
+				"topic: %s currently has %d partitions, partition count can only be increased",
+				topicName, info.PartitionCount)
+		}
+		return nil
+	}
+	return client.CreatePartitions(topicName, partitionCount)
+}
+
+// topicErrorCode maps errors returned from the controller when creating, deleting, adding partitions to or altering
+// the configs of topics
+func topicErrorCode(err error) int16 {
+	if common.IsTektiteErrorWithCode(err, common.TopicAlreadyExists) {
+		return kafkaprotocol.ErrorCodeTopicAlreadyExists
+	}
+	if common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist) {
+		return kafkaprotocol.ErrorCodeUnknownTopicOrPartition
+	}
+	if common.IsTektiteErrorWithCode(err, common.InvalidPartitionCount) {
+		return kafkaprotocol.ErrorCodeInvalidPartitions
+	}
+	if common.IsTektiteErrorWithCode(err, common.InvalidConfiguration) {
+		return kafkaprotocol.ErrorCodeInvalidConfig
+	}
+	// The client will retry on request timed out
+	return kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeRequestTimedOut)
+}
@@ -0,0 +1,281 @@
+package agent
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCreateTopics(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 4, 5, 6} {
+		testCreateTopics(t, apiVersion)
+	}
+}
+
+func testCreateTopics(t *testing.T, apiVersion int16) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	req := &kafkaprotocol.CreateTopicsRequest{
+		Topics: []kafkaprotocol.CreateTopicsRequestCreatableTopic{
+			{
+				Name:              common.StrPtr("topic1"),
+				NumPartitions:     10,
+				ReplicationFactor: 3,
+			},
+			{
+				Name:              common.StrPtr("topic2"),
+				NumPartitions:     -1,
+				ReplicationFactor: -1,
+				Configs: []kafkaprotocol.CreateTopicsRequestCreatableTopicConfig{
+					{Name: common.StrPtr("retention.ms"), Value: common.StrPtr("3600000")},
+					{Name: common.StrPtr("retention.bytes"), Value: common.StrPtr("1000000")},
+					{Name: common.StrPtr("cleanup.policy"), Value: common.StrPtr("delete")},
+				},
+			},
+		},
+	}
+	resp := sendCreateTopics(t, conn, req, apiVersion)
+	require.Equal(t, 2, len(resp.Topics))
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(resp.Topics[0].Name))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].ErrorCode))
+	require.Equal(t, "topic2", common.SafeDerefStringPtr(resp.Topics[1].Name))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[1].ErrorCode))
+	if apiVersion >= 5 {
+		require.Equal(t, 10, int(resp.Topics[0].NumPartitions))
+		require.Equal(t, 3, int(resp.Topics[0].ReplicationFactor))
+		require.Equal(t, 1, int(resp.Topics[1].NumPartitions))
+		require.Equal(t, 1, int(resp.Topics[1].ReplicationFactor))
+	}
+
+	info := getTopicInfo(t, agent, "topic1")
+	require.Equal(t, 10, info.PartitionCount)
+	require.Equal(t, time.Duration(0), info.RetentionTime)
+	require.Equal(t, 0, int(info.RetentionBytes))
+	info = getTopicInfo(t, agent, "topic2")
+	require.Equal(t, 1, info.PartitionCount)
+	require.Equal(t, 1*time.Hour, info.RetentionTime)
+	require.Equal(t, 1000000, int(info.RetentionBytes))
+	require.Equal(t, map[string]string{topicmeta.ConfigCleanupPolicy: "delete"}, info.Configs)
+
+	// Already exists
+	resp = sendCreateTopics(t, conn, &kafkaprotocol.CreateTopicsRequest{
+		Topics: []kafkaprotocol.CreateTopicsRequestCreatableTopic{
+			{Name: common.StrPtr("topic1"), NumPartitions: 10},
+		},
+	}, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeTopicAlreadyExists, int(resp.Topics[0].ErrorCode))
+}
+
+func TestCreateTopicsInvalid(t *testing.T) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	req := &kafkaprotocol.CreateTopicsRequest{
+		Topics: []kafkaprotocol.CreateTopicsRequestCreatableTopic{
+			{Name: common.StrPtr(""), NumPartitions: 1},
+			{Name: common.StrPtr("illegal/name"), NumPartitions: 1},
+			{Name: common.StrPtr("topic1"), NumPartitions: 0},
+			{
+				Name:          common.StrPtr("topic2"),
+				NumPartitions: 1,
+				Configs: []kafkaprotocol.CreateTopicsRequestCreatableTopicConfig{
+					{Name: common.StrPtr("retention.ms"), Value: common.StrPtr("foo")},
+				},
+			},
+			{Name: common.StrPtr("topic3"), NumPartitions: 1},
+			{Name: common.StrPtr("topic3"), NumPartitions: 1},
+		},
+	}
+	resp := sendCreateTopics(t, conn, req, 5)
+	require.Equal(t, 6, len(resp.Topics))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidTopicException, int(resp.Topics[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidTopicException, int(resp.Topics[1].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidPartitions, int(resp.Topics[2].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidConfig, int(resp.Topics[3].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(resp.Topics[4].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(resp.Topics[5].ErrorCode))
+	for _, topicResp := range resp.Topics {
+		require.NotNil(t, topicResp.ErrorMessage)
+		_, _, exists, err := getControlClient(t, agent).GetTopicInfo(common.SafeDerefStringPtr(topicResp.Name))
+		require.NoError(t, err)
+		require.False(t, exists)
+	}
+}
+
+func TestCreateTopicsValidateOnly(t *testing.T) {
+	agent, _, tearDown := setupAgent(t, nil, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	resp := sendCreateTopics(t, conn, &kafkaprotocol.CreateTopicsRequest{
+		Topics: []kafkaprotocol.CreateTopicsRequestCreatableTopic{
+			{Name: common.StrPtr("topic1"), NumPartitions: 10},
+		},
+		ValidateOnly: true,
+	}, 5)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].ErrorCode))
+	require.Equal(t, 10, int(resp.Topics[0].NumPartitions))
+	_, _, exists, err := getControlClient(t, agent).GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestDeleteTopics(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 4, 5} {
+		testDeleteTopics(t, apiVersion)
+	}
+}
+
+func testDeleteTopics(t *testing.T, apiVersion int16) {
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: "topic1", PartitionCount: 3},
+		{Name: "topic2", PartitionCount: 3},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	req := &kafkaprotocol.DeleteTopicsRequest{
+		TopicNames: []*string{common.StrPtr("topic1"), common.StrPtr("unknown")},
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyDeleteTopics, apiVersion, &kafkaprotocol.DeleteTopicsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.DeleteTopicsResponse)
+	require.Equal(t, 2, len(resp.Responses))
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(resp.Responses[0].Name))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Responses[0].ErrorCode))
+	require.Equal(t, "unknown", common.SafeDerefStringPtr(resp.Responses[1].Name))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(resp.Responses[1].ErrorCode))
+
+	cl := getControlClient(t, agent)
+	_, _, exists, err := cl.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, _, exists, err = cl.GetTopicInfo("topic2")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestCreatePartitions(t *testing.T) {
+	for _, apiVersion := range []int16{0, 1, 2, 3} {
+		testCreatePartitions(t, apiVersion)
+	}
+}
+
+func testCreatePartitions(t *testing.T, apiVersion int16) {
+	topicInfos := []topicmeta.TopicInfo{
+		{Name: "topic1", PartitionCount: 3},
+	}
+	agent, _, tearDown := setupAgent(t, topicInfos, NewConf())
+	defer tearDown(t)
+	conn := createTopicsTestConnection(t, agent)
+	defer func() {
+		err := conn.Close()
+		require.NoError(t, err)
+	}()
+
+	// validate only
+	resp := sendCreatePartitions(t, conn, "topic1", 5, true, apiVersion)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 3, getTopicInfo(t, agent, "topic1").PartitionCount)
+
+	resp = sendCreatePartitions(t, conn, "topic1", 5, false, apiVersion)
+	require.Equal(t, "topic1", common.SafeDerefStringPtr(resp.Name))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 5, getTopicInfo(t, agent, "topic1").PartitionCount)
+
+	// Can only increase
+	for _, validateOnly := range []bool{true, false} {
+		resp = sendCreatePartitions(t, conn, "topic1", 4, validateOnly, apiVersion)
+		require.Equal(t, kafkaprotocol.ErrorCodeInvalidPartitions, int(resp.ErrorCode))
+		require.NotNil(t, resp.ErrorMessage)
+		resp = sendCreatePartitions(t, conn, "unknown", 4, validateOnly, apiVersion)
+		require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(resp.ErrorCode))
+	}
+	require.Equal(t, 5, getTopicInfo(t, agent, "topic1").PartitionCount)
+
+	// Make sure we can produce to the new partition
+	sendProduceBatch(t, "topic1", 4, agent.Conf().KafkaListenerConfig.Address,
+		testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10))
+}
+
+func createTopicsTestConnection(t *testing.T, agent *Agent) *KafkaApiConnection {
+	cl, err := NewKafkaApiClient()
+	require.NoError(t, err)
+	conn, err := cl.NewConnection(agent.Conf().KafkaListenerConfig.Address)
+	require.NoError(t, err)
+	return conn
+}
+
+func sendCreateTopics(t *testing.T, conn *KafkaApiConnection, req *kafkaprotocol.CreateTopicsRequest,
+	apiVersion int16) *kafkaprotocol.CreateTopicsResponse {
+	for {
+		r, err := conn.SendRequest(req, kafkaprotocol.APIKeyCreateTopics, apiVersion, &kafkaprotocol.CreateTopicsResponse{})
+		require.NoError(t, err)
+		resp := r.(*kafkaprotocol.CreateTopicsResponse)
+		require.Equal(t, len(req.Topics), len(resp.Topics))
+		retry := false
+		for _, topicResp := range resp.Topics {
+			if topicResp.ErrorCode == kafkaprotocol.ErrorCodeRequestTimedOut {
+				// controller not available yet
+				retry = true
+			}
+		}
+		if !retry {
+			return resp
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func sendCreatePartitions(t *testing.T, conn *KafkaApiConnection, topicName string, count int, validateOnly bool,
+	apiVersion int16) kafkaprotocol.CreatePartitionsResponseCreatePartitionsTopicResult {
+	req := &kafkaprotocol.CreatePartitionsRequest{
+		Topics: []kafkaprotocol.CreatePartitionsRequestCreatePartitionsTopic{
+			{Name: common.StrPtr(topicName), Count: int32(count)},
+		},
+		ValidateOnly: validateOnly,
+	}
+	r, err := conn.SendRequest(req, kafkaprotocol.APIKeyCreatePartitions, apiVersion, &kafkaprotocol.CreatePartitionsResponse{})
+	require.NoError(t, err)
+	resp := r.(*kafkaprotocol.CreatePartitionsResponse)
+	require.Equal(t, 1, len(resp.Results))
+	return resp.Results[0]
+}
+
+func getControlClient(t *testing.T, agent *Agent) control.Client {
+	cl, err := agent.controlClientCache.GetClient()
+	require.NoError(t, err)
+	return cl
+}
+
+func getTopicInfo(t *testing.T, agent *Agent, topicName string) topicmeta.TopicInfo {
+	info, _, exists, err := getControlClient(t, agent).GetTopicInfo(topicName)
+	require.NoError(t, err)
+	require.True(t, exists)
+	return info
+}
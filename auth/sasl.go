@@ -1,28 +1,58 @@
 package auth
 
 type SaslAuthManager struct {
-	scramManager *ScramManager
+	mechanisms []string
+	factories  map[string]conversationFactory
 }
 
+type conversationFactory func() (SaslConversation, error)
+
 func NewSaslAuthManager(scramManager *ScramManager) (*SaslAuthManager, error) {
-	return &SaslAuthManager{
-		scramManager: scramManager,
-	}, nil
+	s := &SaslAuthManager{
+		factories: map[string]conversationFactory{},
+	}
+	s.addMechanism(scramManager.AuthType(), func() (SaslConversation, error) {
+		return scramManager.NewConversation()
+	})
+	return s, nil
+}
+
+// NewScramSaslAuthManager creates a SaslAuthManager which supports both SCRAM-SHA-256 and SCRAM-SHA-512, with stored
+// credentials retrieved using the provided lookup
+func NewScramSaslAuthManager(lookup CredentialsLookup) *SaslAuthManager {
+	s := &SaslAuthManager{
+		factories: map[string]conversationFactory{},
+	}
+	for _, mechanism := range []string{AuthenticationSaslScramSha256, AuthenticationSaslScramSha512} {
+		mech := mechanism
+		s.addMechanism(mech, func() (SaslConversation, error) {
+			return NewLookupScramConversation(mech, lookup)
+		})
+	}
+	return s
+}
+
+func (s *SaslAuthManager) addMechanism(mechanism string, factory conversationFactory) {
+	s.mechanisms = append(s.mechanisms, mechanism)
+	s.factories[mechanism] = factory
 }
 
 func (s *SaslAuthManager) CreateConversation(mechanism string) (SaslConversation, bool, error) {
-	if mechanism == s.scramManager.authType {
-		conv, err := s.scramManager.NewConversation()
-		if err != nil {
-			return nil, false, err
-		}
-		return conv, true, nil
+	factory, ok := s.factories[mechanism]
+	if !ok {
+		return nil, false, nil
+	}
+	conv, err := factory()
+	if err != nil {
+		return nil, false, err
 	}
-	return nil, false, nil
+	return conv, true, nil
 }
 
-func (s *SaslAuthManager) ScramAuthType() string {
-	return s.scramManager.AuthType()
+// EnabledMechanisms returns the SASL mechanisms supported by this manager, these are sent back to the client in the
+// SaslHandshakeResponse
+func (s *SaslAuthManager) EnabledMechanisms() []string {
+	return s.mechanisms
 }
 
 type SaslConversation interface {
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/xdg-go/scram"
+	"sync"
+)
+
+// UserCredentials are the stored SCRAM credentials for a user. The password itself is never stored, only the salted
+// keys derived from it.
+type UserCredentials struct {
+	Salt      string
+	Iters     int
+	StoredKey []byte
+	ServerKey []byte
+	// Sequence is incremented each time the credentials for a user are updated
+	Sequence int
+}
+
+func (u *UserCredentials) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(u.Salt)))
+	buff = append(buff, u.Salt...)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(u.Iters))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(u.StoredKey)))
+	buff = append(buff, u.StoredKey...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(u.ServerKey)))
+	buff = append(buff, u.ServerKey...)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(u.Sequence))
+	return buff
+}
+
+func (u *UserCredentials) Deserialize(buff []byte, offset int) int {
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	u.Salt = string(buff[offset : offset+ln])
+	offset += ln
+	u.Iters = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	u.StoredKey = make([]byte, ln)
+	copy(u.StoredKey, buff[offset:offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	u.ServerKey = make([]byte, ln)
+	copy(u.ServerKey, buff[offset:offset+ln])
+	offset += ln
+	u.Sequence = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	return offset
+}
+
+// CredentialsLookup retrieves the stored credentials for the user and SASL mechanism. If the user has no credentials
+// for the mechanism then false is returned.
+type CredentialsLookup func(username string, mechanism string) (UserCredentials, bool, error)
+
+// NewLookupScramConversation creates a server side SCRAM conversation for the mechanism which retrieves stored
+// credentials using the lookup.
+func NewLookupScramConversation(mechanism string, lookup CredentialsLookup) (*LookupScramConversation, error) {
+	var hashGenFunc scram.HashGeneratorFcn
+	if mechanism == AuthenticationSaslScramSha256 {
+		hashGenFunc = scram.SHA256
+	} else if mechanism == AuthenticationSaslScramSha512 {
+		hashGenFunc = scram.SHA512
+	} else {
+		return nil, errors.Errorf("unsupported SCRAM mechanism %s", mechanism)
+	}
+	conv := &LookupScramConversation{
+		mechanism: mechanism,
+		lookup:    lookup,
+	}
+	// A server is cheap to create and creating one per conversation allows the lookup to record the credentials
+	// sequence on the conversation itself
+	server, err := hashGenFunc.NewServer(conv.lookupCredential)
+	if err != nil {
+		return nil, err
+	}
+	conv.conv = server.NewConversation()
+	return conv, nil
+}
+
+type LookupScramConversation struct {
+	lock          sync.Mutex
+	mechanism     string
+	lookup        CredentialsLookup
+	conv          *scram.ServerConversation
+	principal     string
+	credsSequence int
+}
+
+func (l *LookupScramConversation) lookupCredential(username string) (scram.StoredCredentials, error) {
+	creds, ok, err := l.lookup(username, l.mechanism)
+	if err != nil {
+		return scram.StoredCredentials{}, err
+	}
+	if !ok {
+		return scram.StoredCredentials{}, errors.New("unknown user")
+	}
+	// Called with the conversation lock held from Process
+	l.credsSequence = creds.Sequence
+	return scram.StoredCredentials{
+		KeyFactors: scram.KeyFactors{
+			Salt:  creds.Salt,
+			Iters: creds.Iters,
+		},
+		StoredKey: creds.StoredKey,
+		ServerKey: creds.ServerKey,
+	}, nil
+}
+
+func (l *LookupScramConversation) Process(request []byte) (resp []byte, complete bool, failed bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	r, err := l.conv.Step(string(request))
+	if err != nil {
+		// Log auth failures at info
+		log.Infof("Kafka API SASL %s authentication failure: %v", l.mechanism, err)
+		return nil, false, true
+	}
+	if l.conv.Valid() {
+		// Authentication succeeded
+		l.principal = l.conv.Username()
+	}
+	return []byte(r), l.conv.Valid(), false
+}
+
+func (l *LookupScramConversation) Principal() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.principal
+}
+
+func (l *LookupScramConversation) CredentialsSequence() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.credsSequence
+}
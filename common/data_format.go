@@ -4,10 +4,14 @@ type DataFormat byte
 
 const (
 	DataFormatV1 DataFormat = 1
+	// DataFormatV2 stores SSTable entries in fixed size blocks which can be compressed
+	DataFormatV2 DataFormat = 2
 )
 
 type MetadataFormat byte
 
 const (
 	MetadataFormatV1 MetadataFormat = 1
+	// MetadataFormatV2 adds the max timestamp of tables, and whether they contain only topic data
+	MetadataFormatV2 MetadataFormat = 2
 )
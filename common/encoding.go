@@ -1,6 +1,9 @@
 package common
 
 const (
-	EntryTypeTopicData      = 0
-	EntryTypeOffsetSnapshot = 1
+	EntryTypeTopicData          = 0
+	EntryTypeOffsetSnapshot     = 1
+	EntryTypeLogStart           = 2
+	EntryTypeOpenTransaction    = 3
+	EntryTypeAbortedTransaction = 4
 )
@@ -86,3 +86,11 @@ const (
 	InvalidConfiguration ErrCode = iota + 3000
 	InternalError        ErrCode = iota + 5000
 )
+
+// Error codes are sent over the wire, so codes added after the ones above are given explicit values to make sure that
+// existing codes keep their values
+const (
+	UserDoesNotExist      ErrCode = 2016
+	InvalidPartitionCount ErrCode = 2017
+	OffsetOutOfRange      ErrCode = 2018
+)
@@ -0,0 +1,19 @@
+package common
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestErrorCodeValues(t *testing.T) {
+	// Error codes are sent over the wire so their values must never change
+	require.Equal(t, 1000, int(ParseError))
+	require.Equal(t, 1004, int(WasmError))
+	require.Equal(t, 2005, int(Unavailable))
+	require.Equal(t, 2015, int(TopicDoesNotExist))
+	require.Equal(t, 2016, int(UserDoesNotExist))
+	require.Equal(t, 2017, int(InvalidPartitionCount))
+	require.Equal(t, 2018, int(OffsetOutOfRange))
+	require.Equal(t, 3016, int(InvalidConfiguration))
+	require.Equal(t, 5017, int(InternalError))
+}
@@ -0,0 +1,141 @@
+package compress
+
+import (
+	"fmt"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+)
+
+type CompressionType byte
+
+const (
+	CompressionTypeNone   CompressionType = 0
+	CompressionTypeSnappy CompressionType = 1
+	CompressionTypeLz4    CompressionType = 2
+	CompressionTypeZstd   CompressionType = 3
+)
+
+var compressionTypeNames = map[CompressionType]string{
+	CompressionTypeNone:   "none",
+	CompressionTypeSnappy: "snappy",
+	CompressionTypeLz4:    "lz4",
+	CompressionTypeZstd:   "zstd",
+}
+
+func (c CompressionType) String() string {
+	name, ok := compressionTypeNames[c]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", c)
+	}
+	return name
+}
+
+// ParseCompressionType parses one of none, snappy, lz4 or zstd
+func ParseCompressionType(s string) (CompressionType, error) {
+	for compressionType, name := range compressionTypeNames {
+		if strings.EqualFold(s, name) {
+			return compressionType, nil
+		}
+	}
+	return 0, errors.Errorf("unknown compression type %s must be one of none, snappy, lz4 or zstd", s)
+}
+
+var (
+	lz4Compressors = sync.Pool{New: func() any {
+		return &lz4.Compressor{}
+	}}
+	// zstd encoders and decoders can be used concurrently for EncodeAll and DecodeAll
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			panic(err) // only fails with invalid options
+		}
+		return encoder
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		if err != nil {
+			panic(err) // only fails with invalid options
+		}
+		return decoder
+	})
+)
+
+// Compress appends the compressed data to buff. If the data does not get smaller when compressed, buff is returned
+// unchanged and false is returned, so the caller can store the data uncompressed instead.
+func Compress(compressionType CompressionType, buff []byte, data []byte) ([]byte, bool, error) {
+	start := len(buff)
+	switch compressionType {
+	case CompressionTypeNone:
+		return buff, false, nil
+	case CompressionTypeSnappy:
+		buff = ensureCapacity(buff, snappy.MaxEncodedLen(len(data)))
+		encoded := snappy.Encode(buff[start:cap(buff)], data)
+		buff = buff[:start+len(encoded)]
+	case CompressionTypeLz4:
+		buff = ensureCapacity(buff, lz4.CompressBlockBound(len(data)))
+		compressor := lz4Compressors.Get().(*lz4.Compressor)
+		n, err := compressor.CompressBlock(data, buff[start:cap(buff)])
+		lz4Compressors.Put(compressor)
+		if err != nil {
+			return buff[:start], false, err
+		}
+		if n == 0 {
+			// incompressible
+			return buff[:start], false, nil
+		}
+		buff = buff[:start+n]
+	case CompressionTypeZstd:
+		buff = zstdEncoder().EncodeAll(data, buff)
+	default:
+		return buff, false, errors.Errorf("unknown compression type %d", compressionType)
+	}
+	if len(buff)-start >= len(data) {
+		return buff[:start], false, nil
+	}
+	return buff, true, nil
+}
+
+// Decompress decompresses the data, which must decompress to exactly decompressedLen bytes
+func Decompress(compressionType CompressionType, data []byte, decompressedLen int) ([]byte, error) {
+	buff := make([]byte, decompressedLen)
+	var n int
+	var err error
+	switch compressionType {
+	case CompressionTypeNone:
+		n = copy(buff, data)
+	case CompressionTypeSnappy:
+		var decoded []byte
+		decoded, err = snappy.Decode(buff, data)
+		n = len(decoded)
+	case CompressionTypeLz4:
+		n, err = lz4.UncompressBlock(data, buff)
+	case CompressionTypeZstd:
+		var decoded []byte
+		decoded, err = zstdDecoder().DecodeAll(data, buff[:0])
+		n = len(decoded)
+		buff = decoded
+	default:
+		return nil, errors.Errorf("unknown compression type %d", compressionType)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decompress %s data", compressionType)
+	}
+	if n != decompressedLen {
+		return nil, errors.Errorf("%s data decompressed to %d bytes, expected %d", compressionType, n, decompressedLen)
+	}
+	return buff, nil
+}
+
+func ensureCapacity(buff []byte, extra int) []byte {
+	if cap(buff)-len(buff) >= extra {
+		return buff
+	}
+	grown := make([]byte, len(buff), len(buff)+extra)
+	copy(grown, buff)
+	return grown
+}
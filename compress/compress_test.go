@@ -0,0 +1,62 @@
+package compress
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCompressDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte("somekey-somevalue-"), 1000)
+	for _, compressionType := range []CompressionType{CompressionTypeSnappy, CompressionTypeLz4, CompressionTypeZstd} {
+		t.Run(compressionType.String(), func(t *testing.T) {
+			prefix := []byte("prefix")
+			buff, compressed, err := Compress(compressionType, prefix, data)
+			require.NoError(t, err)
+			require.True(t, compressed)
+			require.Equal(t, prefix, buff[:len(prefix)])
+			require.Less(t, len(buff)-len(prefix), len(data))
+
+			decompressed, err := Decompress(compressionType, buff[len(prefix):], len(data))
+			require.NoError(t, err)
+			require.Equal(t, data, decompressed)
+		})
+	}
+}
+
+func TestCompressIncompressible(t *testing.T) {
+	data := make([]byte, 1000)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	for _, compressionType := range []CompressionType{CompressionTypeNone, CompressionTypeSnappy, CompressionTypeLz4,
+		CompressionTypeZstd} {
+		buff, compressed, err := Compress(compressionType, []byte("prefix"), data)
+		require.NoError(t, err)
+		require.False(t, compressed)
+		require.Equal(t, []byte("prefix"), buff)
+	}
+}
+
+func TestDecompressWrongLength(t *testing.T) {
+	data := bytes.Repeat([]byte("somevalue"), 100)
+	buff, compressed, err := Compress(CompressionTypeSnappy, nil, data)
+	require.NoError(t, err)
+	require.True(t, compressed)
+	_, err = Decompress(CompressionTypeSnappy, buff, len(data)+1)
+	require.Error(t, err)
+}
+
+func TestParseCompressionType(t *testing.T) {
+	for _, compressionType := range []CompressionType{CompressionTypeNone, CompressionTypeSnappy, CompressionTypeLz4,
+		CompressionTypeZstd} {
+		parsed, err := ParseCompressionType(compressionType.String())
+		require.NoError(t, err)
+		require.Equal(t, compressionType, parsed)
+	}
+	parsed, err := ParseCompressionType("ZSTD")
+	require.NoError(t, err)
+	require.Equal(t, CompressionTypeZstd, parsed)
+	_, err = ParseCompressionType("gzip")
+	require.Error(t, err)
+}
@@ -0,0 +1,176 @@
+package control
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/sst"
+	"sort"
+	"sync"
+)
+
+/*
+AclStore lives on the controller and persists ACLs in the LSM. As with client quotas, the number of ACLs is small, so
+they are all stored under a single key and held in memory once loaded.
+*/
+type AclStore struct {
+	kvStore
+	lock    sync.Mutex
+	loaded  bool
+	entries map[acls.AclEntry]struct{}
+}
+
+const aclsVersion uint16 = 1
+
+func NewAclStore(lsmHolder lsmReceiver, tableGetter sst.TableGetter, objStore objstore.Client,
+	dataBucketName string, dataFormat common.DataFormat) *AclStore {
+	return &AclStore{
+		kvStore: kvStore{
+			lsmHolder:      lsmHolder,
+			tableGetter:    tableGetter,
+			objStore:       objStore,
+			dataBucketName: dataBucketName,
+			dataFormat:     dataFormat,
+		},
+	}
+}
+
+func (a *AclStore) Stop() {
+	a.stopping.Store(true)
+}
+
+// CreateAcls adds the ACLs. Creating an ACL which already exists has no effect.
+func (a *AclStore) CreateAcls(entries []acls.AclEntry) error {
+	for i := range entries {
+		if err := entries[i].Validate(); err != nil {
+			return err
+		}
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if err := a.maybeLoad(); err != nil {
+		return err
+	}
+	newEntries := make(map[acls.AclEntry]struct{}, len(a.entries)+len(entries))
+	for entry := range a.entries {
+		newEntries[entry] = struct{}{}
+	}
+	for _, entry := range entries {
+		newEntries[entry] = struct{}{}
+	}
+	if err := a.write(newEntries); err != nil {
+		return err
+	}
+	a.entries = newEntries
+	return nil
+}
+
+// DeleteAcls deletes all ACLs matching any of the filters, and returns the ACLs which were deleted for each filter
+func (a *AclStore) DeleteAcls(filters []acls.AclFilter) ([][]acls.AclEntry, error) {
+	for i := range filters {
+		if err := filters[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if err := a.maybeLoad(); err != nil {
+		return nil, err
+	}
+	deleted := make([][]acls.AclEntry, len(filters))
+	newEntries := make(map[acls.AclEntry]struct{}, len(a.entries))
+	for _, entry := range sortedAcls(a.entries) {
+		matched := false
+		for i := range filters {
+			if filters[i].Matches(&entry) {
+				deleted[i] = append(deleted[i], entry)
+				matched = true
+			}
+		}
+		if !matched {
+			newEntries[entry] = struct{}{}
+		}
+	}
+	if len(newEntries) != len(a.entries) {
+		if err := a.write(newEntries); err != nil {
+			return nil, err
+		}
+		a.entries = newEntries
+	}
+	return deleted, nil
+}
+
+func (a *AclStore) GetAcls() ([]acls.AclEntry, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if err := a.maybeLoad(); err != nil {
+		return nil, err
+	}
+	return sortedAcls(a.entries), nil
+}
+
+func (a *AclStore) write(entries map[acls.AclEntry]struct{}) error {
+	key, err := createAclsKey()
+	if err != nil {
+		return err
+	}
+	// Encode a version number before the data
+	value := binary.BigEndian.AppendUint16(nil, aclsVersion)
+	value = acls.SerializeAcls(value, sortedAcls(entries))
+	return a.writeKvDirect(common.KV{
+		Key:   encoding.EncodeVersion(key, 0),
+		Value: value,
+	})
+}
+
+func (a *AclStore) maybeLoad() error {
+	if a.loaded {
+		return nil
+	}
+	key, err := createAclsKey()
+	if err != nil {
+		return err
+	}
+	value, err := a.getLatestValueWithKey(key)
+	if err != nil {
+		return err
+	}
+	a.entries = map[acls.AclEntry]struct{}{}
+	if len(value) > 0 {
+		version := binary.BigEndian.Uint16(value)
+		if version != aclsVersion {
+			return errors.Errorf("invalid acls version %d", version)
+		}
+		entries, _ := acls.DeserializeAcls(value, 2)
+		for _, entry := range entries {
+			a.entries[entry] = struct{}{}
+		}
+	}
+	a.loaded = true
+	return nil
+}
+
+func sortedAcls(entries map[acls.AclEntry]struct{}) []acls.AclEntry {
+	sorted := make([]acls.AclEntry, 0, len(entries))
+	for entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	// Sort so results are deterministic
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Less(&sorted[j])
+	})
+	return sorted
+}
+
+func createAclsKey() ([]byte, error) {
+	hash, err := parthash.CreateHash([]byte("acls"))
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, 24)
+	return append(key, hash...), nil
+}
@@ -3,6 +3,9 @@ package control
 import (
 	"encoding/binary"
 	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/topicmeta"
@@ -22,7 +25,8 @@ type Client interface {
 
 	QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.OverlappingTables, error)
 
-	RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, error)
+	RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, int64, int64,
+		error)
 
 	PollForJob() (lsm.CompactionJob, error)
 
@@ -34,10 +38,35 @@ type Client interface {
 
 	DeleteTopic(topicName string) error
 
+	CreatePartitions(topicName string, partitionCount int) error
+
+	AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error
+
 	GetCoordinatorInfo(key string) (memberID int32, address string, groupEpoch int, err error)
 
 	GenerateSequence(sequenceName string) (int64, error)
 
+	PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte, salt string,
+		iters int) error
+
+	DeleteUserCredentials(username string, mechanism string) error
+
+	GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error)
+
+	GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error)
+
+	AlterClientQuotas(alterations []ClientQuotaAlteration, validateOnly bool) error
+
+	GetClientQuotas() ([]ClientQuotaConfig, error)
+
+	CreateAcls(entries []acls.AclEntry) error
+
+	DeleteAcls(filters []acls.AclFilter) ([][]acls.AclEntry, error)
+
+	GetAcls() ([]acls.AclEntry, error)
+
+	DeleteRecords(topicID int, partitionID int, offset int64) (int64, error)
+
 	Close() error
 }
 
@@ -64,7 +93,7 @@ func (c *client) RegisterL0Table(sequence int64, regEntry lsm.RegistrationEntry)
 		Sequence:      sequence,
 		RegEntry:      regEntry,
 	}
-	request := req.Serialize(createRequestBuffer())
+	request := req.Serialize(createRequestBufferWithVersion(tableMetadataRPCVersion), tableMetadataFormat)
 	_, err = conn.SendRPC(transport.HandlerIDControllerRegisterL0Table, request)
 	return err
 }
@@ -78,7 +107,7 @@ func (c *client) ApplyLsmChanges(regBatch lsm.RegistrationBatch) error {
 		LeaderVersion: c.leaderVersion,
 		RegBatch:      regBatch,
 	}
-	request := req.Serialize(createRequestBuffer())
+	request := req.Serialize(createRequestBufferWithVersion(tableMetadataRPCVersion), tableMetadataFormat)
 	_, err = conn.SendRPC(transport.HandlerIDControllerApplyChanges, request)
 	return err
 }
@@ -93,19 +122,20 @@ func (c *client) QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.Overlap
 		KeyStart:      keyStart,
 		KeyEnd:        keyEnd,
 	}
-	request := req.Serialize(createRequestBuffer())
+	request := req.Serialize(createRequestBufferWithVersion(tableMetadataRPCVersion))
 	respBuff, err := conn.SendRPC(transport.HandlerIDControllerQueryTablesInRange, request)
 	if err != nil {
 		return nil, err
 	}
-	queryRes, _ := lsm.DeserializeOverlappingTables(respBuff, 0)
+	queryRes, _ := lsm.DeserializeOverlappingTables(respBuff, 0, tableMetadataFormat)
 	return queryRes, nil
 }
 
-func (c *client) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, error) {
+func (c *client) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, int64,
+	int64, error) {
 	conn, err := c.getConnection()
 	if err != nil {
-		return 0, err
+		return 0, 0, 0, err
 	}
 	req := RegisterTableListenerRequest{
 		LeaderVersion: c.leaderVersion,
@@ -117,11 +147,13 @@ func (c *client) RegisterTableListener(topicID int, partitionID int, memberID in
 	request := req.Serialize(createRequestBuffer())
 	respBuff, err := conn.SendRPC(transport.HandlerIDControllerRegisterTableListener, request)
 	if err != nil {
-		return 0, err
+		return 0, 0, 0, err
 	}
 	var resp RegisterTableListenerResponse
-	resp.Deserialize(respBuff, 0)
-	return resp.LastReadableOffset, nil
+	if _, err := resp.Deserialize(respBuff, 0); err != nil {
+		return 0, 0, 0, err
+	}
+	return resp.LastReadableOffset, resp.LastStableOffset, resp.LogStartOffset, nil
 }
 
 func (c *client) PrePush(infos []offsets.GenerateOffsetTopicInfo, epochInfos []EpochInfo) ([]offsets.OffsetTopicInfo,
@@ -141,7 +173,9 @@ func (c *client) PrePush(infos []offsets.GenerateOffsetTopicInfo, epochInfos []E
 		return nil, 0, nil, err
 	}
 	var resp PrePushResponse
-	resp.Deserialize(respBuff, 0)
+	if _, err := resp.Deserialize(respBuff, 0); err != nil {
+		return nil, 0, nil, err
+	}
 	return resp.Offsets, resp.Sequence, resp.EpochsOK, nil
 }
 
@@ -160,7 +194,9 @@ func (c *client) GetOffsetInfos(infos []offsets.GetOffsetTopicInfo) ([]offsets.O
 		return nil, err
 	}
 	var resp GetOffsetInfoResponse
-	resp.Deserialize(respBuff, 0)
+	if _, err := resp.Deserialize(respBuff, 0); err != nil {
+		return nil, err
+	}
 	return resp.OffsetInfos, nil
 }
 
@@ -169,12 +205,13 @@ func (c *client) PollForJob() (lsm.CompactionJob, error) {
 	if err != nil {
 		return lsm.CompactionJob{}, err
 	}
-	respBuff, err := conn.SendRPC(transport.HandlerIDControllerPollForJob, createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerPollForJob,
+		createRequestBufferWithVersion(tableMetadataRPCVersion))
 	if err != nil {
 		return lsm.CompactionJob{}, err
 	}
 	var job lsm.CompactionJob
-	job.Deserialize(respBuff, 0)
+	job.Deserialize(respBuff, 0, tableMetadataFormat)
 	return job, nil
 }
 
@@ -243,6 +280,37 @@ func (c *client) DeleteTopic(topicName string) error {
 	return err
 }
 
+func (c *client) CreatePartitions(topicName string, partitionCount int) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := CreatePartitionsRequest{
+		LeaderVersion:  c.leaderVersion,
+		TopicName:      topicName,
+		PartitionCount: partitionCount,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerCreatePartitions, buff)
+	return err
+}
+
+func (c *client) AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := AlterTopicConfigsRequest{
+		LeaderVersion: c.leaderVersion,
+		TopicName:     topicName,
+		Alterations:   alterations,
+		ValidateOnly:  validateOnly,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerAlterTopicConfigs, buff)
+	return err
+}
+
 func (c *client) GetCoordinatorInfo(groupID string) (int32, string, int, error) {
 	conn, err := c.getConnection()
 	if err != nil {
@@ -281,6 +349,185 @@ func (c *client) GenerateSequence(sequenceName string) (int64, error) {
 	return resp.Sequence, nil
 }
 
+func (c *client) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := PutUserCredentialsRequest{
+		LeaderVersion: c.leaderVersion,
+		Username:      username,
+		Mechanism:     mechanism,
+		StoredKey:     storedKey,
+		ServerKey:     serverKey,
+		Salt:          salt,
+		Iters:         iters,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerPutUserCredentials, buff)
+	return err
+}
+
+func (c *client) DeleteUserCredentials(username string, mechanism string) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := DeleteUserCredentialsRequest{
+		LeaderVersion: c.leaderVersion,
+		Username:      username,
+		Mechanism:     mechanism,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerDeleteUserCredentials, buff)
+	return err
+}
+
+func (c *client) GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return auth.UserCredentials{}, false, err
+	}
+	req := GetUserCredentialsRequest{
+		LeaderVersion: c.leaderVersion,
+		Username:      username,
+		Mechanism:     mechanism,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerGetUserCredentials, buff)
+	if err != nil {
+		return auth.UserCredentials{}, false, err
+	}
+	var resp GetUserCredentialsResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.Creds, resp.Exists, nil
+}
+
+func (c *client) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return lsm.PartitionRetention{}, err
+	}
+	req := GetPartitionRetentionRequest{
+		LeaderVersion: c.leaderVersion,
+		PartitionHash: partitionHash,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerGetPartitionRetention, buff)
+	if err != nil {
+		return lsm.PartitionRetention{}, err
+	}
+	var resp GetPartitionRetentionResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.Retention, nil
+}
+
+func (c *client) AlterClientQuotas(alterations []ClientQuotaAlteration, validateOnly bool) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := AlterClientQuotasRequest{
+		LeaderVersion: c.leaderVersion,
+		Alterations:   alterations,
+		ValidateOnly:  validateOnly,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerAlterClientQuotas, buff)
+	return err
+}
+
+func (c *client) GetClientQuotas() ([]ClientQuotaConfig, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return nil, err
+	}
+	req := GetClientQuotasRequest{
+		LeaderVersion: c.leaderVersion,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerGetClientQuotas, buff)
+	if err != nil {
+		return nil, err
+	}
+	var resp GetClientQuotasResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.Quotas, nil
+}
+
+func (c *client) CreateAcls(entries []acls.AclEntry) error {
+	conn, err := c.getConnection()
+	if err != nil {
+		return err
+	}
+	req := CreateAclsRequest{
+		LeaderVersion: c.leaderVersion,
+		Acls:          entries,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	_, err = conn.SendRPC(transport.HandlerIDControllerCreateAcls, buff)
+	return err
+}
+
+func (c *client) DeleteAcls(filters []acls.AclFilter) ([][]acls.AclEntry, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return nil, err
+	}
+	req := DeleteAclsRequest{
+		LeaderVersion: c.leaderVersion,
+		Filters:       filters,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerDeleteAcls, buff)
+	if err != nil {
+		return nil, err
+	}
+	var resp DeleteAclsResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.Deleted, nil
+}
+
+func (c *client) GetAcls() ([]acls.AclEntry, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return nil, err
+	}
+	req := GetAclsRequest{
+		LeaderVersion: c.leaderVersion,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerGetAcls, buff)
+	if err != nil {
+		return nil, err
+	}
+	var resp GetAclsResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.Acls, nil
+}
+
+func (c *client) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	conn, err := c.getConnection()
+	if err != nil {
+		return 0, err
+	}
+	req := DeleteRecordsRequest{
+		LeaderVersion: c.leaderVersion,
+		TopicID:       topicID,
+		PartitionID:   partitionID,
+		Offset:        offset,
+	}
+	buff := req.Serialize(createRequestBuffer())
+	respBuff, err := conn.SendRPC(transport.HandlerIDControllerDeleteRecords, buff)
+	if err != nil {
+		return 0, err
+	}
+	var resp DeleteRecordsResponse
+	resp.Deserialize(respBuff, 0)
+	return resp.LogStartOffset, nil
+}
+
 func (c *client) Close() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -332,8 +579,21 @@ func (c *client) createConnection() error {
 	return nil
 }
 
+const (
+	// tableMetadataFormat is the format of the table metadata sent in requests and responses
+	tableMetadataFormat = common.MetadataFormatV2
+	// tableMetadataRPCVersion is the rpc version of requests which send or receive table metadata. Version 2 encodes the
+	// table metadata with common.MetadataFormatV2, all other requests are still version 1. Controllers which don't
+	// support version 2 reject it, rather than misparsing the metadata.
+	tableMetadataRPCVersion = 2
+)
+
 func createRequestBuffer() []byte {
-	buff := make([]byte, 0, 128)                  // Initial size guess
-	buff = binary.BigEndian.AppendUint16(buff, 1) // rpc version - currently 1
+	return createRequestBufferWithVersion(1)
+}
+
+func createRequestBufferWithVersion(rpcVersion uint16) []byte {
+	buff := make([]byte, 0, 128) // Initial size guess
+	buff = binary.BigEndian.AppendUint16(buff, rpcVersion)
 	return buff
 }
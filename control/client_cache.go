@@ -1,6 +1,8 @@
 package control
 
 import (
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/offsets"
@@ -129,15 +131,15 @@ func (c *clientWrapper) RegisterL0Table(sequence int64, regEntry lsm.Registratio
 }
 
 func (c *clientWrapper) RegisterTableListener(topicID int, partitionID int, memberID int32,
-	resetSequence int64) (int64, error) {
+	resetSequence int64) (int64, int64, int64, error) {
 	if c.injectedError != nil {
-		return 0, c.injectedError
+		return 0, 0, 0, c.injectedError
 	}
-	lro, err := c.client.RegisterTableListener(topicID, partitionID, memberID, resetSequence)
+	lro, lso, logStart, err := c.client.RegisterTableListener(topicID, partitionID, memberID, resetSequence)
 	if err != nil {
 		c.closeConnection()
 	}
-	return lro, err
+	return lro, lso, logStart, err
 }
 
 func (c *clientWrapper) GetOffsetInfos(infos []offsets.GetOffsetTopicInfo) ([]offsets.OffsetTopicInfo, error) {
@@ -217,6 +219,29 @@ func (c *clientWrapper) DeleteTopic(topicName string) error {
 	return err
 }
 
+func (c *clientWrapper) CreatePartitions(topicName string, partitionCount int) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.CreatePartitions(topicName, partitionCount)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
+func (c *clientWrapper) AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration,
+	validateOnly bool) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.AlterTopicConfigs(topicName, alterations, validateOnly)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
 func (c *clientWrapper) GetCoordinatorInfo(groupID string) (int32, string, int, error) {
 	if c.injectedError != nil {
 		return 0, "", 0, c.injectedError
@@ -239,6 +264,117 @@ func (c *clientWrapper) GenerateSequence(sequenceName string) (int64, error) {
 	return seq, err
 }
 
+func (c *clientWrapper) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.PutUserCredentials(username, mechanism, storedKey, serverKey, salt, iters)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
+func (c *clientWrapper) DeleteUserCredentials(username string, mechanism string) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.DeleteUserCredentials(username, mechanism)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
+func (c *clientWrapper) GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	if c.injectedError != nil {
+		return auth.UserCredentials{}, false, c.injectedError
+	}
+	creds, exists, err := c.client.GetUserCredentials(username, mechanism)
+	if err != nil {
+		c.closeConnection()
+	}
+	return creds, exists, err
+}
+
+func (c *clientWrapper) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	if c.injectedError != nil {
+		return lsm.PartitionRetention{}, c.injectedError
+	}
+	retention, err := c.client.GetPartitionRetention(partitionHash)
+	if err != nil {
+		c.closeConnection()
+	}
+	return retention, err
+}
+
+func (c *clientWrapper) AlterClientQuotas(alterations []ClientQuotaAlteration, validateOnly bool) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.AlterClientQuotas(alterations, validateOnly)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
+func (c *clientWrapper) GetClientQuotas() ([]ClientQuotaConfig, error) {
+	if c.injectedError != nil {
+		return nil, c.injectedError
+	}
+	quotas, err := c.client.GetClientQuotas()
+	if err != nil {
+		c.closeConnection()
+	}
+	return quotas, err
+}
+
+func (c *clientWrapper) CreateAcls(entries []acls.AclEntry) error {
+	if c.injectedError != nil {
+		return c.injectedError
+	}
+	err := c.client.CreateAcls(entries)
+	if err != nil {
+		c.closeConnection()
+	}
+	return err
+}
+
+func (c *clientWrapper) DeleteAcls(filters []acls.AclFilter) ([][]acls.AclEntry, error) {
+	if c.injectedError != nil {
+		return nil, c.injectedError
+	}
+	deleted, err := c.client.DeleteAcls(filters)
+	if err != nil {
+		c.closeConnection()
+	}
+	return deleted, err
+}
+
+func (c *clientWrapper) GetAcls() ([]acls.AclEntry, error) {
+	if c.injectedError != nil {
+		return nil, c.injectedError
+	}
+	entries, err := c.client.GetAcls()
+	if err != nil {
+		c.closeConnection()
+	}
+	return entries, err
+}
+
+func (c *clientWrapper) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	if c.injectedError != nil {
+		return 0, c.injectedError
+	}
+	logStartOffset, err := c.client.DeleteRecords(topicID, partitionID, offset)
+	if err != nil {
+		c.closeConnection()
+	}
+	return logStartOffset, err
+}
+
 func (c *clientWrapper) closeConnection() {
 	// always close connection on error
 	if err := c.Close(); err != nil {
@@ -0,0 +1,297 @@
+package control
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/sst"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Supported client quota keys
+const (
+	QuotaKeyProducerByteRate = "producer_byte_rate"
+	QuotaKeyConsumerByteRate = "consumer_byte_rate"
+)
+
+type QuotaEntityNameType uint8
+
+const (
+	// QuotaEntityNameNone means the entity does not include the component
+	QuotaEntityNameNone QuotaEntityNameType = iota
+	// QuotaEntityNameSpecific means the component matches the name exactly
+	QuotaEntityNameSpecific
+	// QuotaEntityNameDefault means the component matches any name which does not have its own quota
+	QuotaEntityNameDefault
+)
+
+type QuotaEntityName struct {
+	Type QuotaEntityNameType
+	Name string
+}
+
+/*
+ClientQuotaEntity identifies what a client quota applies to. As with Kafka, an entity is a user, a client id, or a user
+and client id, where each can be a specific name or the default. Users are the principals of authenticated connections.
+*/
+type ClientQuotaEntity struct {
+	User     QuotaEntityName
+	ClientID QuotaEntityName
+}
+
+type ClientQuotaConfig struct {
+	Entity ClientQuotaEntity
+	Quotas map[string]float64
+}
+
+type ClientQuotaOp struct {
+	Key    string
+	Value  float64
+	Remove bool
+}
+
+type ClientQuotaAlteration struct {
+	Entity ClientQuotaEntity
+	Ops    []ClientQuotaOp
+}
+
+// ValidateClientQuotaAlteration checks the entity and quota keys and values of an alteration
+func ValidateClientQuotaAlteration(alteration *ClientQuotaAlteration) error {
+	if alteration.Entity.User.Type == QuotaEntityNameNone && alteration.Entity.ClientID.Type == QuotaEntityNameNone {
+		return common.NewTektiteErrorf(common.InvalidConfiguration, "quota entity must specify a user or client id")
+	}
+	for _, op := range alteration.Ops {
+		if op.Key != QuotaKeyProducerByteRate && op.Key != QuotaKeyConsumerByteRate {
+			return common.NewTektiteErrorf(common.InvalidConfiguration, "unsupported quota key %s", op.Key)
+		}
+		if !op.Remove && (op.Value <= 0 || math.IsInf(op.Value, 0) || math.IsNaN(op.Value)) {
+			return common.NewTektiteErrorf(common.InvalidConfiguration, "invalid value %v for quota %s - must be > 0",
+				op.Value, op.Key)
+		}
+	}
+	return nil
+}
+
+/*
+ClientQuotas lives on the controller and persists client quotas in the LSM. The number of quota entities is small, so
+they are all stored under a single key and held in memory once loaded.
+*/
+type ClientQuotas struct {
+	kvStore
+	lock   sync.Mutex
+	loaded bool
+	quotas map[ClientQuotaEntity]map[string]float64
+}
+
+const clientQuotasVersion uint16 = 1
+
+func NewClientQuotas(lsmHolder lsmReceiver, tableGetter sst.TableGetter, objStore objstore.Client,
+	dataBucketName string, dataFormat common.DataFormat) *ClientQuotas {
+	return &ClientQuotas{
+		kvStore: kvStore{
+			lsmHolder:      lsmHolder,
+			tableGetter:    tableGetter,
+			objStore:       objStore,
+			dataBucketName: dataBucketName,
+			dataFormat:     dataFormat,
+		},
+	}
+}
+
+func (c *ClientQuotas) Stop() {
+	c.stopping.Store(true)
+}
+
+func (c *ClientQuotas) AlterClientQuotas(alterations []ClientQuotaAlteration, validateOnly bool) error {
+	for i := range alterations {
+		if err := ValidateClientQuotaAlteration(&alterations[i]); err != nil {
+			return err
+		}
+	}
+	if validateOnly {
+		return nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.maybeLoad(); err != nil {
+		return err
+	}
+	newQuotas := make(map[ClientQuotaEntity]map[string]float64, len(c.quotas))
+	for entity, quotas := range c.quotas {
+		newQuotas[entity] = quotas
+	}
+	for _, alteration := range alterations {
+		quotas := map[string]float64{}
+		for key, value := range newQuotas[alteration.Entity] {
+			quotas[key] = value
+		}
+		for _, op := range alteration.Ops {
+			if op.Remove {
+				delete(quotas, op.Key)
+			} else {
+				quotas[op.Key] = op.Value
+			}
+		}
+		if len(quotas) == 0 {
+			delete(newQuotas, alteration.Entity)
+		} else {
+			newQuotas[alteration.Entity] = quotas
+		}
+	}
+	key, err := createClientQuotasKey()
+	if err != nil {
+		return err
+	}
+	// Encode a version number before the data
+	value := binary.BigEndian.AppendUint16(nil, clientQuotasVersion)
+	value = serializeClientQuotaConfigs(value, quotasToConfigs(newQuotas))
+	if err := c.writeKvDirect(common.KV{
+		Key:   encoding.EncodeVersion(key, 0),
+		Value: value,
+	}); err != nil {
+		return err
+	}
+	c.quotas = newQuotas
+	return nil
+}
+
+func (c *ClientQuotas) GetClientQuotas() ([]ClientQuotaConfig, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.maybeLoad(); err != nil {
+		return nil, err
+	}
+	return quotasToConfigs(c.quotas), nil
+}
+
+func (c *ClientQuotas) maybeLoad() error {
+	if c.loaded {
+		return nil
+	}
+	key, err := createClientQuotasKey()
+	if err != nil {
+		return err
+	}
+	value, err := c.getLatestValueWithKey(key)
+	if err != nil {
+		return err
+	}
+	c.quotas = map[ClientQuotaEntity]map[string]float64{}
+	if len(value) > 0 {
+		version := binary.BigEndian.Uint16(value)
+		if version != clientQuotasVersion {
+			return errors.Errorf("invalid client quotas version %d", version)
+		}
+		configs, _ := deserializeClientQuotaConfigs(value, 2)
+		for _, config := range configs {
+			c.quotas[config.Entity] = config.Quotas
+		}
+	}
+	c.loaded = true
+	return nil
+}
+
+func quotasToConfigs(quotas map[ClientQuotaEntity]map[string]float64) []ClientQuotaConfig {
+	configs := make([]ClientQuotaConfig, 0, len(quotas))
+	for entity, entityQuotas := range quotas {
+		configs = append(configs, ClientQuotaConfig{Entity: entity, Quotas: entityQuotas})
+	}
+	// Sort so results are deterministic
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Entity.less(&configs[j].Entity)
+	})
+	return configs
+}
+
+func (e *ClientQuotaEntity) less(other *ClientQuotaEntity) bool {
+	if e.User != other.User {
+		return e.User.less(&other.User)
+	}
+	return e.ClientID.less(&other.ClientID)
+}
+
+func (n *QuotaEntityName) less(other *QuotaEntityName) bool {
+	if n.Type != other.Type {
+		return n.Type < other.Type
+	}
+	return n.Name < other.Name
+}
+
+func createClientQuotasKey() ([]byte, error) {
+	hash, err := parthash.CreateHash([]byte("client_quotas"))
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, 24)
+	return append(key, hash...), nil
+}
+
+func (n *QuotaEntityName) Serialize(buff []byte) []byte {
+	buff = append(buff, byte(n.Type))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(n.Name)))
+	return append(buff, n.Name...)
+}
+
+func (n *QuotaEntityName) Deserialize(buff []byte, offset int) int {
+	n.Type = QuotaEntityNameType(buff[offset])
+	offset++
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	n.Name = string(buff[offset : offset+ln])
+	return offset + ln
+}
+
+func (e *ClientQuotaEntity) Serialize(buff []byte) []byte {
+	buff = e.User.Serialize(buff)
+	return e.ClientID.Serialize(buff)
+}
+
+func (e *ClientQuotaEntity) Deserialize(buff []byte, offset int) int {
+	offset = e.User.Deserialize(buff, offset)
+	return e.ClientID.Deserialize(buff, offset)
+}
+
+func serializeClientQuotaConfigs(buff []byte, configs []ClientQuotaConfig) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(configs)))
+	for _, config := range configs {
+		buff = config.Entity.Serialize(buff)
+		keys := make([]string, 0, len(config.Quotas))
+		for key := range config.Quotas {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(keys)))
+		for _, key := range keys {
+			buff = binary.BigEndian.AppendUint32(buff, uint32(len(key)))
+			buff = append(buff, key...)
+			buff = binary.BigEndian.AppendUint64(buff, math.Float64bits(config.Quotas[key]))
+		}
+	}
+	return buff
+}
+
+func deserializeClientQuotaConfigs(buff []byte, offset int) ([]ClientQuotaConfig, int) {
+	numConfigs := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	configs := make([]ClientQuotaConfig, numConfigs)
+	for i := 0; i < numConfigs; i++ {
+		offset = configs[i].Entity.Deserialize(buff, offset)
+		numQuotas := int(binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		configs[i].Quotas = make(map[string]float64, numQuotas)
+		for j := 0; j < numQuotas; j++ {
+			ln := int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			key := string(buff[offset : offset+ln])
+			offset += ln
+			configs[i].Quotas[key] = math.Float64frombits(binary.BigEndian.Uint64(buff[offset:]))
+			offset += 8
+		}
+	}
+	return configs, offset
+}
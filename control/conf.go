@@ -14,6 +14,7 @@ type Conf struct {
 	SSTableBucketName                string
 	DataFormat common.DataFormat
 	TableNotificationInterval time.Duration
+	RetentionCheckInterval time.Duration
 	LsmConf                          lsm.Conf
 	SequencesBlockSize int
 	AzInfo                   string
@@ -28,6 +29,7 @@ func NewConf() Conf {
 		SSTableBucketName:                "tektite-data",
 		DataFormat: common.DataFormatV1,
 		TableNotificationInterval: 5 * time.Second,
+		RetentionCheckInterval: 10 * time.Second,
 		LsmConf:                          lsm.NewConf(),
 		SequencesBlockSize: 100,
 	}
@@ -4,10 +4,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/metrics"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/sst"
@@ -34,6 +36,8 @@ type Controller struct {
 	lsmHolder                  *LsmHolder
 	offsetsCache               *offsets.Cache
 	topicMetaManager           *topicmeta.Manager
+	retentionProvider          *partitionRetentionProvider
+	retentionEnforcer          *retentionEnforcer
 	currentMembership          cluster.MembershipState
 	clusterState               []AgentMeta
 	clusterStateSameAZ         []AgentMeta
@@ -41,7 +45,13 @@ type Controller struct {
 	groupCoordinatorController *CoordinatorController
 	tableGetter                sst.TableGetter
 	sequences                  *Sequences
+	userCredentials            *UserCredentials
+	clientQuotas               *ClientQuotas
+	aclStore                   *AclStore
+	recordsDeleter             *RecordsDeleter
 	memberID                   int32
+	rpcs                       *prometheus.CounterVec
+	rpcDuration                *prometheus.HistogramVec
 }
 
 func NewController(cfg Conf, objStoreClient objstore.Client, connectionFactory transport.ConnectionFactory,
@@ -54,10 +64,59 @@ func NewController(cfg Conf, objStoreClient objstore.Client, connectionFactory t
 		tableListeners:             newTableListeners(cfg.TableNotificationInterval, connectionFactory),
 		groupCoordinatorController: NewGroupCoordinatorController(),
 		memberID:                   -1,
+		rpcs: metrics.NewCounterVec("controller", "rpcs_total",
+			"number of controller RPCs handled by this agent", "rpc", "result"),
+		rpcDuration: metrics.NewHistogramVec("controller", "rpc_duration_seconds",
+			"time taken to handle controller RPCs", metrics.LatencyBuckets, "rpc"),
 	}
 	return control
 }
 
+func (c *Controller) RegisterMetrics(registry *metrics.Registry) {
+	registry.MustRegister(c.rpcs, c.rpcDuration,
+		metrics.NewGaugeFunc("lsm", "l0_tables", "number of tables in L0, only reported by the cluster leader",
+			func() float64 {
+				counts, _ := c.getLsmStats()
+				return float64(counts[0])
+			}),
+		metrics.NewGaugeFunc("lsm", "compaction_queued_jobs",
+			"number of compaction jobs waiting for a worker, only reported by the cluster leader", func() float64 {
+				_, stats := c.getLsmStats()
+				return float64(stats.QueuedJobs)
+			}),
+		metrics.NewGaugeFunc("lsm", "compaction_in_progress_jobs",
+			"number of compaction jobs being processed, only reported by the cluster leader", func() float64 {
+				_, stats := c.getLsmStats()
+				return float64(stats.InProgressJobs)
+			}))
+}
+
+// registerHandler registers the handler for a controller RPC, recording the number and duration of requests
+func (c *Controller) registerHandler(handlerID int, rpcName string, handler transport.RequestHandler) {
+	c.transportServer.RegisterHandler(handlerID, func(ctx *transport.ConnectionContext, request []byte,
+		responseBuff []byte, responseWriter transport.ResponseWriter) error {
+		start := time.Now()
+		return handler(ctx, request, responseBuff, func(response []byte, err error) error {
+			result := "ok"
+			if err != nil {
+				result = "error"
+			}
+			c.rpcs.WithLabelValues(rpcName, result).Inc()
+			c.rpcDuration.WithLabelValues(rpcName).Observe(time.Since(start).Seconds())
+			return responseWriter(response, err)
+		})
+	})
+}
+
+func (c *Controller) getLsmStats() (map[int]int, lsm.CompactionStats) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.lsmHolder == nil {
+		return nil, lsm.CompactionStats{}
+	}
+	return c.lsmHolder.GetStats()
+}
+
 const (
 	objStoreCallTimeout      = 5 * time.Second
 	unavailabilityRetryDelay = 1 * time.Second
@@ -70,19 +129,31 @@ func (c *Controller) Start() error {
 		return nil
 	}
 	// Register the handlers
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerRegisterL0Table, c.handleRegisterL0Table)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerApplyChanges, c.handleApplyChanges)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerRegisterTableListener, c.handleRegisterTableListener)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerQueryTablesInRange, c.handleQueryTablesInRange)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerPrepush, c.handlePrePush)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerGetOffsetInfo, c.handleGetOffsetInfo)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerPollForJob, c.handlePollForJob)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerGetAllTopicInfos, c.handleGetAllTopicInfos)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerGetTopicInfo, c.handleGetTopicInfo)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerCreateTopic, c.handleCreateTopic)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerDeleteTopic, c.handleDeleteTopic)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerGetGroupCoordinatorInfo, c.handleGetGroupCoordinatorInfo)
-	c.transportServer.RegisterHandler(transport.HandlerIDControllerGenerateSequence, c.handleGenerateSequenceRequest)
+	c.registerHandler(transport.HandlerIDControllerRegisterL0Table, "register_l0_table", c.handleRegisterL0Table)
+	c.registerHandler(transport.HandlerIDControllerApplyChanges, "apply_changes", c.handleApplyChanges)
+	c.registerHandler(transport.HandlerIDControllerRegisterTableListener, "register_table_listener", c.handleRegisterTableListener)
+	c.registerHandler(transport.HandlerIDControllerQueryTablesInRange, "query_tables_in_range", c.handleQueryTablesInRange)
+	c.registerHandler(transport.HandlerIDControllerPrepush, "pre_push", c.handlePrePush)
+	c.registerHandler(transport.HandlerIDControllerGetOffsetInfo, "get_offset_info", c.handleGetOffsetInfo)
+	c.registerHandler(transport.HandlerIDControllerPollForJob, "poll_for_job", c.handlePollForJob)
+	c.registerHandler(transport.HandlerIDControllerGetAllTopicInfos, "get_all_topic_infos", c.handleGetAllTopicInfos)
+	c.registerHandler(transport.HandlerIDControllerGetTopicInfo, "get_topic_info", c.handleGetTopicInfo)
+	c.registerHandler(transport.HandlerIDControllerCreateTopic, "create_topic", c.handleCreateTopic)
+	c.registerHandler(transport.HandlerIDControllerDeleteTopic, "delete_topic", c.handleDeleteTopic)
+	c.registerHandler(transport.HandlerIDControllerCreatePartitions, "create_partitions", c.handleCreatePartitions)
+	c.registerHandler(transport.HandlerIDControllerAlterTopicConfigs, "alter_topic_configs", c.handleAlterTopicConfigs)
+	c.registerHandler(transport.HandlerIDControllerGetGroupCoordinatorInfo, "get_group_coordinator_info", c.handleGetGroupCoordinatorInfo)
+	c.registerHandler(transport.HandlerIDControllerGenerateSequence, "generate_sequence", c.handleGenerateSequenceRequest)
+	c.registerHandler(transport.HandlerIDControllerPutUserCredentials, "put_user_credentials", c.handlePutUserCredentialsRequest)
+	c.registerHandler(transport.HandlerIDControllerDeleteUserCredentials, "delete_user_credentials", c.handleDeleteUserCredentialsRequest)
+	c.registerHandler(transport.HandlerIDControllerGetUserCredentials, "get_user_credentials", c.handleGetUserCredentialsRequest)
+	c.registerHandler(transport.HandlerIDControllerGetPartitionRetention, "get_partition_retention", c.handleGetPartitionRetentionRequest)
+	c.registerHandler(transport.HandlerIDControllerAlterClientQuotas, "alter_client_quotas", c.handleAlterClientQuotasRequest)
+	c.registerHandler(transport.HandlerIDControllerGetClientQuotas, "get_client_quotas", c.handleGetClientQuotasRequest)
+	c.registerHandler(transport.HandlerIDControllerCreateAcls, "create_acls", c.handleCreateAclsRequest)
+	c.registerHandler(transport.HandlerIDControllerDeleteAcls, "delete_acls", c.handleDeleteAclsRequest)
+	c.registerHandler(transport.HandlerIDControllerGetAcls, "get_acls", c.handleGetAclsRequest)
+	c.registerHandler(transport.HandlerIDControllerDeleteRecords, "delete_records", c.handleDeleteRecordsRequest)
 	c.tableListeners.start()
 	c.started = true
 	return nil
@@ -103,6 +174,11 @@ func (c *Controller) SetTableGetter(getter sst.TableGetter) {
 }
 
 func (c *Controller) stop() error {
+	if c.retentionEnforcer != nil {
+		// Stopped first as it uses the LSM and the offsets cache
+		c.retentionEnforcer.stop()
+		c.retentionEnforcer = nil
+	}
 	if c.lsmHolder != nil {
 		if err := c.lsmHolder.stop(); err != nil {
 			return err
@@ -119,11 +195,28 @@ func (c *Controller) stop() error {
 		c.offsetsCache.Stop()
 		c.offsetsCache = nil
 	}
+	c.retentionProvider = nil
 	c.tableListeners.stop()
 	if c.sequences != nil {
 		c.sequences.Stop()
 		c.sequences = nil
 	}
+	if c.userCredentials != nil {
+		c.userCredentials.Stop()
+		c.userCredentials = nil
+	}
+	if c.clientQuotas != nil {
+		c.clientQuotas.Stop()
+		c.clientQuotas = nil
+	}
+	if c.aclStore != nil {
+		c.aclStore.Stop()
+		c.aclStore = nil
+	}
+	if c.recordsDeleter != nil {
+		c.recordsDeleter.Stop()
+		c.recordsDeleter = nil
+	}
 	c.currentMembership = cluster.MembershipState{}
 	c.started = false
 	return nil
@@ -168,8 +261,29 @@ func (c *Controller) MembershipChanged(thisMemberID int32, newState cluster.Memb
 				return err
 			}
 			c.offsetsCache = cache
+			c.retentionProvider = &partitionRetentionProvider{
+				topicMetaManager: topicMetaManager,
+				offsetsCache:     cache,
+			}
+			// Topic retentions are used by the LSM to drop tables which only contain expired data
+			lsmHolder.lsmManager.SetRetentionProvider(c.retentionProvider)
 			c.sequences = NewSequences(lsmHolder, c.tableGetter, c.objStoreClient, c.cfg.SSTableBucketName,
 				c.cfg.DataFormat, int64(c.cfg.SequencesBlockSize))
+			c.userCredentials = NewUserCredentials(lsmHolder, c.tableGetter, c.objStoreClient, c.cfg.SSTableBucketName,
+				c.cfg.DataFormat)
+			c.clientQuotas = NewClientQuotas(lsmHolder, c.tableGetter, c.objStoreClient, c.cfg.SSTableBucketName,
+				c.cfg.DataFormat)
+			c.aclStore = NewAclStore(lsmHolder, c.tableGetter, c.objStoreClient, c.cfg.SSTableBucketName,
+				c.cfg.DataFormat)
+			recordsDeleter, err := NewRecordsDeleter(lsmHolder, c.tableGetter, c.objStoreClient,
+				c.cfg.SSTableBucketName, c.cfg.DataFormat, cache)
+			if err != nil {
+				return err
+			}
+			c.recordsDeleter = recordsDeleter
+			c.retentionEnforcer = newRetentionEnforcer(c.cfg.RetentionCheckInterval, topicMetaManager,
+				cache, recordsDeleter)
+			c.retentionEnforcer.start()
 		}
 	} else {
 		// This controller is not leader
@@ -231,7 +345,7 @@ func (c *Controller) handleRegisterL0Table(_ *transport.ConnectionContext, reque
 		return nil
 	}
 	var req RegisterL0Request
-	req.Deserialize(request, 2)
+	req.Deserialize(request, 2, tableMetadataFormatForRequest(request))
 	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
 		return responseWriter(nil, err)
 	}
@@ -242,7 +356,7 @@ func (c *Controller) handleRegisterL0Table(_ *transport.ConnectionContext, reque
 		if err != nil {
 			return responseWriter(nil, err)
 		}
-		offsetInfos, tableIDs, err := c.offsetsCache.MaybeReleaseOffsets(req.Sequence, req.RegEntry.TableID)
+		offsetInfos, tableIDs, err := c.offsetsCache.MaybeReleaseOffsets(req.Sequence, req.RegEntry.TableID, int64(req.RegEntry.TableSize))
 		if err != nil {
 			return err
 		}
@@ -264,7 +378,7 @@ func (c *Controller) handleApplyChanges(_ *transport.ConnectionContext, request
 		return nil
 	}
 	var req ApplyChangesRequest
-	req.Deserialize(request, 2)
+	req.Deserialize(request, 2, tableMetadataFormatForRequest(request))
 	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
 		return responseWriter(nil, err)
 	}
@@ -297,6 +411,14 @@ func (c *Controller) handleRegisterTableListener(_ *transport.ConnectionContext,
 	if err != nil {
 		return responseWriter(nil, err)
 	}
+	lso, _, err := c.offsetsCache.GetLastStableOffset(req.TopicID, req.PartitionID)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	logStart, _, err := c.offsetsCache.GetLogStart(req.TopicID, req.PartitionID)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
 	var memberAddress string
 	for _, member := range c.currentMembership.Members {
 		if member.ID == req.MemberID {
@@ -311,6 +433,8 @@ func (c *Controller) handleRegisterTableListener(_ *transport.ConnectionContext,
 	c.tableListeners.maybeRegisterListenerForPartition(req.MemberID, memberAddress, req.TopicID, req.PartitionID, req.ResetSequence)
 	resp := RegisterTableListenerResponse{
 		LastReadableOffset: lro,
+		LastStableOffset:   lso,
+		LogStartOffset:     logStart.Offset,
 	}
 	responseBuff = resp.Serialize(responseBuff)
 	return responseWriter(responseBuff, nil)
@@ -331,7 +455,7 @@ func (c *Controller) handleQueryTablesInRange(_ *transport.ConnectionContext, re
 	if err != nil {
 		return responseWriter(nil, err)
 	}
-	responseBuff = res.Serialize(responseBuff)
+	responseBuff = res.Serialize(responseBuff, tableMetadataFormatForRequest(request))
 	return responseWriter(responseBuff, nil)
 }
 
@@ -342,7 +466,9 @@ func (c *Controller) handlePrePush(_ *transport.ConnectionContext, request []byt
 		return nil
 	}
 	var req PrePushRequest
-	req.Deserialize(request, 2)
+	if _, err := req.Deserialize(request, 2); err != nil {
+		return responseWriter(nil, err)
+	}
 	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
 		return responseWriter(nil, err)
 	}
@@ -392,7 +518,17 @@ func (c *Controller) handleGetOffsetInfo(_ *transport.ConnectionContext, request
 			if err != nil {
 				return responseWriter(nil, err)
 			}
+			lso, _, err := c.offsetsCache.GetLastStableOffset(topicInfo.TopicID, partitionID)
+			if err != nil {
+				return responseWriter(nil, err)
+			}
+			logStart, _, err := c.offsetsCache.GetLogStart(topicInfo.TopicID, partitionID)
+			if err != nil {
+				return responseWriter(nil, err)
+			}
 			resp.OffsetInfos[i].PartitionInfos[j].Offset = offset
+			resp.OffsetInfos[i].PartitionInfos[j].LastStableOffset = lso
+			resp.OffsetInfos[i].PartitionInfos[j].LogStartOffset = logStart.Offset
 		}
 	}
 	responseBuff = resp.Serialize(responseBuff)
@@ -412,7 +548,7 @@ func (c *Controller) handlePollForJob(ctx *transport.ConnectionContext, request
 			}
 			return
 		}
-		buff := job.Serialize(nil)
+		buff := job.Serialize(nil, tableMetadataFormatForRequest(request))
 		responseBuff = append(responseBuff, buff...)
 		if err := responseWriter(responseBuff, nil); err != nil {
 			log.Errorf("failed to write response %v", err)
@@ -501,6 +637,43 @@ func (c *Controller) handleDeleteTopic(_ *transport.ConnectionContext, request [
 	return responseWriter(responseBuff, nil)
 }
 
+func (c *Controller) handleCreatePartitions(_ *transport.ConnectionContext, request []byte, responseBuff []byte, responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req CreatePartitionsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	err := c.topicMetaManager.CreatePartitions(req.TopicName, req.PartitionCount)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleAlterTopicConfigs(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req AlterTopicConfigsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	err := c.topicMetaManager.AlterTopicConfigs(req.TopicName, req.Alterations, req.ValidateOnly)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
 func (c *Controller) handleGetGroupCoordinatorInfo(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
 	responseWriter transport.ResponseWriter) error {
 	c.lock.RLock()
@@ -547,6 +720,240 @@ func (c *Controller) handleGenerateSequenceRequest(_ *transport.ConnectionContex
 	return responseWriter(responseBuff, nil)
 }
 
+func (c *Controller) handlePutUserCredentialsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req PutUserCredentialsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	err := c.userCredentials.PutUserCredentials(req.Username, req.Mechanism, req.StoredKey, req.ServerKey, req.Salt,
+		req.Iters)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleDeleteUserCredentialsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req DeleteUserCredentialsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	if err := c.userCredentials.DeleteUserCredentials(req.Username, req.Mechanism); err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleGetUserCredentialsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req GetUserCredentialsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	creds, exists, err := c.userCredentials.LookupUserCredentials(req.Username, req.Mechanism)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := GetUserCredentialsResponse{
+		Exists: exists,
+		Creds:  creds,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleGetPartitionRetentionRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req GetPartitionRetentionRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	retention, err := c.retentionProvider.GetPartitionRetention(req.PartitionHash)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := GetPartitionRetentionResponse{
+		Retention: retention,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleAlterClientQuotasRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req AlterClientQuotasRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	if err := c.clientQuotas.AlterClientQuotas(req.Alterations, req.ValidateOnly); err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleGetClientQuotasRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req GetClientQuotasRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	quotas, err := c.clientQuotas.GetClientQuotas()
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := GetClientQuotasResponse{
+		Quotas: quotas,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleCreateAclsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req CreateAclsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	if err := c.aclStore.CreateAcls(req.Acls); err != nil {
+		return responseWriter(nil, err)
+	}
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleDeleteAclsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req DeleteAclsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	deleted, err := c.aclStore.DeleteAcls(req.Filters)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := DeleteAclsResponse{
+		Deleted: deleted,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleGetAclsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req GetAclsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	entries, err := c.aclStore.GetAcls()
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := GetAclsResponse{
+		Acls: entries,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
+func (c *Controller) handleDeleteRecordsRequest(_ *transport.ConnectionContext, request []byte, responseBuff []byte,
+	responseWriter transport.ResponseWriter) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.requestChecks(request, responseWriter) {
+		return nil
+	}
+	var req DeleteRecordsRequest
+	req.Deserialize(request, 2)
+	if err := c.checkLeaderVersion(req.LeaderVersion); err != nil {
+		return responseWriter(nil, err)
+	}
+	logStartOffset, err := c.recordsDeleter.DeleteRecords(req.TopicID, req.PartitionID, req.Offset)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	// Notify any fetchers of the new log start offset, so they reject fetches before it
+	lro, _, err := c.offsetsCache.GetLastReadableOffset(req.TopicID, req.PartitionID)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	lso, _, err := c.offsetsCache.GetLastStableOffset(req.TopicID, req.PartitionID)
+	if err != nil {
+		return responseWriter(nil, err)
+	}
+	offsetInfos := []offsets.OffsetTopicInfo{{
+		TopicID: req.TopicID,
+		PartitionInfos: []offsets.OffsetPartitionInfo{{
+			PartitionID:      req.PartitionID,
+			Offset:           lro,
+			LastStableOffset: lso,
+			LogStartOffset:   logStartOffset,
+		}},
+	}}
+	if err := c.tableListeners.sendTableRegisteredNotification([]sst.SSTableID{}, offsetInfos); err != nil {
+		return responseWriter(nil, err)
+	}
+	resp := DeleteRecordsResponse{
+		LogStartOffset: logStartOffset,
+	}
+	responseBuff = resp.Serialize(responseBuff)
+	return responseWriter(responseBuff, nil)
+}
+
 func (c *Controller) requestChecks(request []byte, responseWriter transport.ResponseWriter) bool {
 	var err error
 	err = c.checkStarted()
@@ -568,13 +975,21 @@ func (c *Controller) requestChecks(request []byte, responseWriter transport.Resp
 
 func checkRPCVersion(request []byte) error {
 	rpcVersion := binary.BigEndian.Uint16(request)
-	if rpcVersion != 1 {
-		// Currently just 1
+	if rpcVersion != 1 && rpcVersion != tableMetadataRPCVersion {
 		return errors.New("invalid rpc version")
 	}
 	return nil
 }
 
+// tableMetadataFormatForRequest returns the format of the table metadata in the request, and in its response. Clients
+// which send version 1 requests only understand common.MetadataFormatV1.
+func tableMetadataFormatForRequest(request []byte) common.MetadataFormat {
+	if binary.BigEndian.Uint16(request) == 1 {
+		return common.MetadataFormatV1
+	}
+	return tableMetadataFormat
+}
+
 func (c *Controller) checkStarted() error {
 	if !c.started {
 		return common.NewTektiteErrorf(common.Unavailable, "controller is not started")
@@ -2,14 +2,23 @@ package control
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/objstore/dev"
 	"github.com/spirit-labs/tektite/offsets"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/queryutils"
+	"github.com/spirit-labs/tektite/sst"
+	"github.com/spirit-labs/tektite/testutils"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/spirit-labs/tektite/transport"
 	"github.com/stretchr/testify/require"
@@ -455,6 +464,89 @@ func TestControllerCreateGetDeleteTopics(t *testing.T) {
 	}
 }
 
+func TestControllerCreatePartitions(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err = cl.Close()
+		require.NoError(t, err)
+	}()
+
+	err = cl.CreateTopic(topicmeta.TopicInfo{Name: "topic1", PartitionCount: 3})
+	require.NoError(t, err)
+	info, _, _, err := cl.GetTopicInfo("topic1")
+	require.NoError(t, err)
+
+	// Load the offsets for the topic before partitions are added
+	_, _, err = controllers[0].OffsetsCache().GetLastReadableOffset(info.ID, 0)
+	require.NoError(t, err)
+
+	err = cl.CreatePartitions("topic1", 5)
+	require.NoError(t, err)
+	received, _, exists, err := cl.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	info.PartitionCount = 5
+	require.Equal(t, info, received)
+
+	// Offsets must be available for the new partitions
+	lro, exists, err := controllers[0].OffsetsCache().GetLastReadableOffset(info.ID, 4)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, -1, int(lro))
+
+	err = cl.CreatePartitions("topic1", 5)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidPartitionCount))
+	err = cl.CreatePartitions("unknown", 5)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist))
+}
+
+func TestControllerAlterTopicConfigs(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err = cl.Close()
+		require.NoError(t, err)
+	}()
+
+	err = cl.CreateTopic(topicmeta.TopicInfo{Name: "topic1", PartitionCount: 3})
+	require.NoError(t, err)
+
+	err = cl.AlterTopicConfigs("topic1", []topicmeta.ConfigAlteration{
+		{Name: topicmeta.ConfigRetentionMs, Operation: topicmeta.ConfigOperationSet, Value: "10000"},
+		{Name: topicmeta.ConfigMaxMessageBytes, Operation: topicmeta.ConfigOperationSet, Value: "1000"},
+	}, false)
+	require.NoError(t, err)
+	info, _, exists, err := cl.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, 10*time.Second, info.RetentionTime)
+	require.Equal(t, 1000, info.MaxMessageBytes())
+
+	err = cl.AlterTopicConfigs("topic1", []topicmeta.ConfigAlteration{
+		{Name: topicmeta.ConfigMaxMessageBytes, Operation: topicmeta.ConfigOperationSet, Value: "foo"},
+	}, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+	err = cl.AlterTopicConfigs("unknown", nil, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist))
+}
+
 func TestControllerGetAllTopicInfos(t *testing.T) {
 	objStore := dev.NewInMemStore(0)
 	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
@@ -515,6 +607,463 @@ func TestControllerGenerateSequence(t *testing.T) {
 	}
 }
 
+func TestControllerUserCredentials(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+	controllers[0].SetTableGetter(func(tableID sst.SSTableID) (*sst.SSTable, error) {
+		buff, err := objStore.Get(context.Background(), controllers[0].cfg.SSTableBucketName, string(tableID))
+		if err != nil {
+			return nil, err
+		}
+		var table sst.SSTable
+		table.Deserialize(buff, 0)
+		return &table, nil
+	})
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+
+	username := "some-user"
+	_, exists, err := cl.GetUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	storedKey, serverKey, salt := auth.CreateUserScramCreds("some-password", auth.AuthenticationSaslScramSha256)
+	err = cl.PutUserCredentials(username, auth.AuthenticationSaslScramSha256, storedKey, serverKey, salt, auth.NumIters)
+	require.NoError(t, err)
+
+	creds, exists, err := cl.GetUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, auth.UserCredentials{
+		Salt:      salt,
+		Iters:     auth.NumIters,
+		StoredKey: storedKey,
+		ServerKey: serverKey,
+		Sequence:  0,
+	}, creds)
+
+	// Credentials are stored per mechanism
+	_, exists, err = cl.GetUserCredentials(username, auth.AuthenticationSaslScramSha512)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Update the password - sequence should increment
+	storedKey, serverKey, salt = auth.CreateUserScramCreds("other-password", auth.AuthenticationSaslScramSha256)
+	err = cl.PutUserCredentials(username, auth.AuthenticationSaslScramSha256, storedKey, serverKey, salt, auth.NumIters)
+	require.NoError(t, err)
+	creds, exists, err = cl.GetUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, salt, creds.Salt)
+	require.Equal(t, 1, creds.Sequence)
+
+	err = cl.DeleteUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.NoError(t, err)
+	_, exists, err = cl.GetUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	err = cl.DeleteUserCredentials(username, auth.AuthenticationSaslScramSha256)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.UserDoesNotExist))
+
+	err = cl.PutUserCredentials(username, "PLAIN", storedKey, serverKey, salt, auth.NumIters)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+}
+
+func TestControllerClientQuotas(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+	controllers[0].SetTableGetter(func(tableID sst.SSTableID) (*sst.SSTable, error) {
+		buff, err := objStore.Get(context.Background(), controllers[0].cfg.SSTableBucketName, string(tableID))
+		if err != nil {
+			return nil, err
+		}
+		var table sst.SSTable
+		table.Deserialize(buff, 0)
+		return &table, nil
+	})
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+
+	quotas, err := cl.GetClientQuotas()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(quotas))
+
+	userEntity := ClientQuotaEntity{User: QuotaEntityName{Type: QuotaEntityNameSpecific, Name: "some-user"}}
+	defaultClientEntity := ClientQuotaEntity{ClientID: QuotaEntityName{Type: QuotaEntityNameDefault}}
+	alterations := []ClientQuotaAlteration{
+		{Entity: userEntity, Ops: []ClientQuotaOp{
+			{Key: QuotaKeyProducerByteRate, Value: 1000},
+			{Key: QuotaKeyConsumerByteRate, Value: 2000},
+		}},
+		{Entity: defaultClientEntity, Ops: []ClientQuotaOp{
+			{Key: QuotaKeyProducerByteRate, Value: 3000},
+		}},
+	}
+
+	// validate only
+	err = cl.AlterClientQuotas(alterations, true)
+	require.NoError(t, err)
+	quotas, err = cl.GetClientQuotas()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(quotas))
+
+	err = cl.AlterClientQuotas(alterations, false)
+	require.NoError(t, err)
+	quotas, err = cl.GetClientQuotas()
+	require.NoError(t, err)
+	require.Equal(t, []ClientQuotaConfig{
+		{Entity: defaultClientEntity, Quotas: map[string]float64{QuotaKeyProducerByteRate: 3000}},
+		{Entity: userEntity, Quotas: map[string]float64{QuotaKeyProducerByteRate: 1000, QuotaKeyConsumerByteRate: 2000}},
+	}, quotas)
+
+	// Removing the last quota of an entity removes the entity
+	err = cl.AlterClientQuotas([]ClientQuotaAlteration{
+		{Entity: userEntity, Ops: []ClientQuotaOp{{Key: QuotaKeyConsumerByteRate, Remove: true}}},
+		{Entity: defaultClientEntity, Ops: []ClientQuotaOp{{Key: QuotaKeyProducerByteRate, Remove: true}}},
+	}, false)
+	require.NoError(t, err)
+	quotas, err = cl.GetClientQuotas()
+	require.NoError(t, err)
+	require.Equal(t, []ClientQuotaConfig{
+		{Entity: userEntity, Quotas: map[string]float64{QuotaKeyProducerByteRate: 1000}},
+	}, quotas)
+
+	// Quotas are loaded from storage by a new instance
+	loaded := NewClientQuotas(controllers[0].lsmHolder, controllers[0].tableGetter, objStore,
+		controllers[0].cfg.SSTableBucketName, controllers[0].cfg.DataFormat)
+	quotas, err = loaded.GetClientQuotas()
+	require.NoError(t, err)
+	require.Equal(t, []ClientQuotaConfig{
+		{Entity: userEntity, Quotas: map[string]float64{QuotaKeyProducerByteRate: 1000}},
+	}, quotas)
+
+	err = cl.AlterClientQuotas([]ClientQuotaAlteration{
+		{Entity: userEntity, Ops: []ClientQuotaOp{{Key: "request_percentage", Value: 10}}},
+	}, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+
+	err = cl.AlterClientQuotas([]ClientQuotaAlteration{
+		{Entity: userEntity, Ops: []ClientQuotaOp{{Key: QuotaKeyProducerByteRate, Value: -1}}},
+	}, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+
+	err = cl.AlterClientQuotas([]ClientQuotaAlteration{
+		{Ops: []ClientQuotaOp{{Key: QuotaKeyProducerByteRate, Value: 1000}}},
+	}, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+}
+
+func TestControllerAcls(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+	controllers[0].SetTableGetter(func(tableID sst.SSTableID) (*sst.SSTable, error) {
+		buff, err := objStore.Get(context.Background(), controllers[0].cfg.SSTableBucketName, string(tableID))
+		if err != nil {
+			return nil, err
+		}
+		var table sst.SSTable
+		table.Deserialize(buff, 0)
+		return &table, nil
+	})
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+
+	entries, err := cl.GetAcls()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(entries))
+
+	topicAcl := acls.AclEntry{
+		ResourceType: acls.ResourceTypeTopic,
+		ResourceName: "some-topic",
+		PatternType:  acls.PatternTypeLiteral,
+		Principal:    "User:some-user",
+		Host:         acls.WildcardHost,
+		Operation:    acls.OperationRead,
+		Permission:   acls.PermissionAllow,
+	}
+	prefixedTopicAcl := topicAcl
+	prefixedTopicAcl.ResourceName = "some-"
+	prefixedTopicAcl.PatternType = acls.PatternTypePrefixed
+	groupAcl := topicAcl
+	groupAcl.ResourceType = acls.ResourceTypeGroup
+	groupAcl.ResourceName = "some-group"
+
+	err = cl.CreateAcls([]acls.AclEntry{groupAcl, topicAcl, prefixedTopicAcl})
+	require.NoError(t, err)
+	// Creating an existing ACL has no effect
+	err = cl.CreateAcls([]acls.AclEntry{topicAcl})
+	require.NoError(t, err)
+	entries, err = cl.GetAcls()
+	require.NoError(t, err)
+	require.Equal(t, []acls.AclEntry{prefixedTopicAcl, topicAcl, groupAcl}, entries)
+
+	// ACLs are loaded from storage by a new instance
+	loaded := NewAclStore(controllers[0].lsmHolder, controllers[0].tableGetter, objStore,
+		controllers[0].cfg.SSTableBucketName, controllers[0].cfg.DataFormat)
+	entries, err = loaded.GetAcls()
+	require.NoError(t, err)
+	require.Equal(t, []acls.AclEntry{prefixedTopicAcl, topicAcl, groupAcl}, entries)
+
+	deleted, err := cl.DeleteAcls([]acls.AclFilter{
+		{
+			ResourceType: acls.ResourceTypeTopic,
+			ResourceName: common.StrPtr("some-topic"),
+			PatternType:  acls.PatternTypeMatch,
+			Operation:    acls.OperationAny,
+			Permission:   acls.PermissionAny,
+		},
+		{
+			ResourceType: acls.ResourceTypeTransactionalID,
+			PatternType:  acls.PatternTypeAny,
+			Operation:    acls.OperationAny,
+			Permission:   acls.PermissionAny,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]acls.AclEntry{{prefixedTopicAcl, topicAcl}, {}}, deleted)
+	entries, err = cl.GetAcls()
+	require.NoError(t, err)
+	require.Equal(t, []acls.AclEntry{groupAcl}, entries)
+
+	invalidAcl := topicAcl
+	invalidAcl.Principal = "some-user"
+	err = cl.CreateAcls([]acls.AclEntry{invalidAcl})
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+
+	_, err = cl.DeleteAcls([]acls.AclFilter{{}})
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+}
+
+func TestControllerGetPartitionRetention(t *testing.T) {
+	controllers, tearDown := setupControllers(t, 1)
+	defer tearDown(t)
+
+	updateMembership(t, 1, 1, controllers, 0)
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+
+	err = cl.CreateTopic(topicmeta.TopicInfo{
+		Name:           "topic1",
+		PartitionCount: 3,
+		RetentionTime:  2 * time.Hour,
+	})
+	require.NoError(t, err)
+	info, _, exists, err := cl.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	for partitionID := 0; partitionID < 3; partitionID++ {
+		partHash, err := parthash.CreatePartitionHash(info.ID, partitionID)
+		require.NoError(t, err)
+		retention, err := cl.GetPartitionRetention(partHash)
+		require.NoError(t, err)
+		require.Equal(t, lsm.PartitionRetention{Retention: 2 * time.Hour}, retention)
+	}
+
+	// Unknown partition
+	partHash, err := parthash.CreatePartitionHash(info.ID, 3)
+	require.NoError(t, err)
+	retention, err := cl.GetPartitionRetention(partHash)
+	require.NoError(t, err)
+	require.Equal(t, lsm.PartitionRetention{}, retention)
+
+	// Topic with retention bytes - the min offset depends on the size of data written
+	err = cl.CreateTopic(topicmeta.TopicInfo{
+		Name:           "topic2",
+		PartitionCount: 1,
+		RetentionBytes: 1000,
+	})
+	require.NoError(t, err)
+	info, _, exists, err = cl.GetTopicInfo("topic2")
+	require.NoError(t, err)
+	require.True(t, exists)
+	partHash, err = parthash.CreatePartitionHash(info.ID, 0)
+	require.NoError(t, err)
+	var lastOffset int64
+	for i := 0; i < 3; i++ {
+		offs, seq, err := controllers[0].offsetsCache.GenerateOffsets([]offsets.GenerateOffsetTopicInfo{
+			{TopicID: info.ID, PartitionInfos: []offsets.GenerateOffsetPartitionInfo{{PartitionID: 0, NumOffsets: 10}}},
+		})
+		require.NoError(t, err)
+		_, _, err = controllers[0].offsetsCache.MaybeReleaseOffsets(seq, []byte(sst.CreateSSTableId()), 1000)
+		require.NoError(t, err)
+		lastOffset = offs[0].PartitionInfos[0].Offset
+	}
+	// Size based retention is not applied until the enforcer runs
+	retention, err = cl.GetPartitionRetention(partHash)
+	require.NoError(t, err)
+	require.Equal(t, lsm.PartitionRetention{}, retention)
+
+	err = controllers[0].retentionEnforcer.enforce()
+	require.NoError(t, err)
+	retention, err = cl.GetPartitionRetention(partHash)
+	require.NoError(t, err)
+	// Only the last table must be retained
+	require.Equal(t, lsm.PartitionRetention{MinOffset: lastOffset - 9}, retention)
+
+	// The log start has been advanced and persisted
+	logStart, _, err := controllers[0].offsetsCache.GetLogStart(info.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, offsets.LogStart{Offset: lastOffset - 9, TruncatedOffset: lastOffset - 9}, logStart)
+	cache, err := offsets.NewOffsetsCache(controllers[0].topicMetaManager, controllers[0].lsmHolder,
+		controllers[0].objStoreClient, controllers[0].cfg.SSTableBucketName)
+	require.NoError(t, err)
+	err = cache.Start()
+	require.NoError(t, err)
+	logStart, _, err = cache.GetLogStart(info.ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, offsets.LogStart{Offset: lastOffset - 9, TruncatedOffset: lastOffset - 9}, logStart)
+}
+
+func TestControllerDeleteRecords(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	controllers, _, tearDown := setupControllersWithObjectStore(t, 1, objStore)
+	defer tearDown(t)
+	controllers[0].SetTableGetter(func(tableID sst.SSTableID) (*sst.SSTable, error) {
+		buff, err := objStore.Get(context.Background(), controllers[0].cfg.SSTableBucketName, string(tableID))
+		if err != nil {
+			return nil, err
+		}
+		var table sst.SSTable
+		table.Deserialize(buff, 0)
+		return &table, nil
+	})
+
+	updateMembership(t, 1, 1, controllers, 0)
+	setupTopics(t, controllers[0])
+
+	cl, err := controllers[0].Client()
+	require.NoError(t, err)
+	defer func() {
+		err := cl.Close()
+		require.NoError(t, err)
+	}()
+
+	// Write 10 batches of 10 records each
+	topicID, partitionID := 1000, 1
+	_, seq, err := controllers[0].offsetsCache.GenerateOffsets([]offsets.GenerateOffsetTopicInfo{
+		{TopicID: topicID, PartitionInfos: []offsets.GenerateOffsetPartitionInfo{{PartitionID: partitionID, NumOffsets: 100}}},
+	})
+	require.NoError(t, err)
+	partHash, err := parthash.CreatePartitionHash(topicID, partitionID)
+	require.NoError(t, err)
+	var kvs []common.KV
+	for i := 0; i < 10; i++ {
+		kvs = append(kvs, common.KV{
+			Key:   encoding.EncodeVersion(createTopicDataKey(partHash, int64(i*10)), 0),
+			Value: testutils.CreateKafkaRecordBatchWithIncrementingKVs(i*10, 10),
+		})
+	}
+	err = controllers[0].recordsDeleter.writeKvsDirect(kvs)
+	require.NoError(t, err)
+	_, _, err = controllers[0].offsetsCache.MaybeReleaseOffsets(seq, []byte(sst.CreateSSTableId()), 1000)
+	require.NoError(t, err)
+
+	getBatchOffsets := func() []int64 {
+		iter, err := queryutils.CreateIteratorForKeyRange(createTopicDataKey(partHash, 0),
+			createTopicDataKey(partHash, 100), controllers[0].lsmHolder, controllers[0].tableGetter)
+		require.NoError(t, err)
+		var baseOffsets []int64
+		for {
+			ok, kv, err := iter.Next()
+			require.NoError(t, err)
+			if !ok {
+				return baseOffsets
+			}
+			baseOffsets = append(baseOffsets, kafkaencoding.BaseOffset(kv.Value))
+		}
+	}
+	getLogStartOffset := func() int64 {
+		infos, err := cl.GetOffsetInfos([]offsets.GetOffsetTopicInfo{{TopicID: topicID, PartitionIDs: []int{partitionID}}})
+		require.NoError(t, err)
+		return infos[0].PartitionInfos[0].LogStartOffset
+	}
+	require.Equal(t, []int64{0, 10, 20, 30, 40, 50, 60, 70, 80, 90}, getBatchOffsets())
+	require.Equal(t, 0, int(getLogStartOffset()))
+
+	// The batch containing the log start offset is retained
+	logStartOffset, err := cl.DeleteRecords(topicID, partitionID, 35)
+	require.NoError(t, err)
+	require.Equal(t, 35, int(logStartOffset))
+	require.Equal(t, []int64{30, 40, 50, 60, 70, 80, 90}, getBatchOffsets())
+	require.Equal(t, 35, int(getLogStartOffset()))
+
+	// The log start offset does not move backwards
+	logStartOffset, err = cl.DeleteRecords(topicID, partitionID, 20)
+	require.NoError(t, err)
+	require.Equal(t, 35, int(logStartOffset))
+	require.Equal(t, []int64{30, 40, 50, 60, 70, 80, 90}, getBatchOffsets())
+
+	logStartOffset, err = cl.DeleteRecords(topicID, partitionID, 70)
+	require.NoError(t, err)
+	require.Equal(t, 70, int(logStartOffset))
+	require.Equal(t, []int64{70, 80, 90}, getBatchOffsets())
+
+	// Cannot delete past the high watermark
+	_, err = cl.DeleteRecords(topicID, partitionID, 101)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.OffsetOutOfRange))
+
+	// -1 deletes up to the high watermark
+	logStartOffset, err = cl.DeleteRecords(topicID, partitionID, -1)
+	require.NoError(t, err)
+	require.Equal(t, 100, int(logStartOffset))
+	require.Equal(t, 0, len(getBatchOffsets()))
+	require.Equal(t, 100, int(getLogStartOffset()))
+
+	// Unknown topic
+	_, err = cl.DeleteRecords(2000, 0, 10)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist))
+
+	// The log start is loaded from storage by a new offsets cache
+	cache, err := offsets.NewOffsetsCache(controllers[0].topicMetaManager, controllers[0].lsmHolder, objStore,
+		controllers[0].cfg.SSTableBucketName)
+	require.NoError(t, err)
+	err = cache.Start()
+	require.NoError(t, err)
+	logStart, _, err := cache.GetLogStart(topicID, partitionID)
+	require.NoError(t, err)
+	require.Equal(t, offsets.LogStart{Offset: 100, TruncatedOffset: 100}, logStart)
+}
+
 func setupControllers(t *testing.T, numMembers int) ([]*Controller, func(t *testing.T)) {
 	objStore := dev.NewInMemStore(0)
 	controllers, _, tearDown := setupControllersWithObjectStore(t, numMembers, objStore)
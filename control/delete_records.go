@@ -0,0 +1,186 @@
+package control
+
+import (
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/offsets"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/queryutils"
+	"github.com/spirit-labs/tektite/sst"
+	"math"
+	"sync"
+)
+
+/*
+RecordsDeleter lives on the controller and handles deleting records from the start of a partition. Deleting records
+advances the log start offset of the partition, which is tracked by the offsets cache and persisted in the LSM along
+with prefix tombstones covering the record batches which have been deleted.
+
+Records are stored in batches keyed by the base offset of the batch, so a batch which contains the new log start offset
+cannot be deleted. In this case the batch is retained, and fetches before the log start offset are rejected.
+*/
+type RecordsDeleter struct {
+	kvStore
+	lock            sync.Mutex
+	offsetsCache    *offsets.Cache
+	partitionHashes *parthash.PartitionHashes
+}
+
+// initial number of offsets before the new log start offset that we search for the batch containing it
+const batchSearchWindow = 1000
+
+func NewRecordsDeleter(lsmHolder lsmReceiver, tableGetter sst.TableGetter, objStore objstore.Client,
+	dataBucketName string, dataFormat common.DataFormat, offsetsCache *offsets.Cache) (*RecordsDeleter, error) {
+	partHashes, err := parthash.NewPartitionHashes(0)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordsDeleter{
+		kvStore: kvStore{
+			lsmHolder:      lsmHolder,
+			tableGetter:    tableGetter,
+			objStore:       objStore,
+			dataBucketName: dataBucketName,
+			dataFormat:     dataFormat,
+		},
+		offsetsCache:    offsetsCache,
+		partitionHashes: partHashes,
+	}, nil
+}
+
+func (r *RecordsDeleter) Stop() {
+	r.stopping.Store(true)
+}
+
+// DeleteRecords deletes all records in the partition before the provided offset, and returns the resulting log start
+// offset. An offset of -1 deletes all records up to the high watermark. The log start offset never moves backwards, so
+// deleting before the current log start offset has no effect.
+func (r *RecordsDeleter) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	lro, exists, err := r.offsetsCache.GetLastReadableOffset(topicID, partitionID)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, common.NewTektiteErrorf(common.TopicDoesNotExist, "DeleteRecords: unknown topic: %d", topicID)
+	}
+	highWatermark := lro + 1
+	if offset == -1 {
+		offset = highWatermark
+	}
+	if offset < 0 || offset > highWatermark {
+		return 0, common.NewTektiteErrorf(common.OffsetOutOfRange,
+			"cannot delete records before offset %d - high watermark is %d", offset, highWatermark)
+	}
+	logStart, _, err := r.offsetsCache.GetLogStart(topicID, partitionID)
+	if err != nil {
+		return 0, err
+	}
+	if offset <= logStart.Offset {
+		return logStart.Offset, nil
+	}
+	partHash, err := r.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return 0, err
+	}
+	truncatedOffset, err := r.findTruncatedOffset(partHash, offset, logStart.TruncatedOffset)
+	if err != nil {
+		return 0, err
+	}
+	newLogStart := offsets.LogStart{Offset: offset, TruncatedOffset: truncatedOffset}
+	// The prefix deletes and the log start are written in the same table, so they are applied atomically. Keys must be
+	// in order, and the log start key comes after all the data keys for the partition.
+	kvs := createRecordsPrefixDeleteKVs(partHash, logStart.TruncatedOffset, truncatedOffset)
+	kvs = append(kvs, offsets.CreateLogStartKV(partHash, newLogStart))
+	if err := r.writeKvsDirect(kvs); err != nil {
+		return 0, err
+	}
+	newLogStart, _, err = r.offsetsCache.AdvanceLogStart(topicID, partitionID, newLogStart)
+	if err != nil {
+		return 0, err
+	}
+	return newLogStart.Offset, nil
+}
+
+// findTruncatedOffset finds the offset before which all record batches can be removed for the new log start offset.
+// This is the base offset of the batch containing the log start offset, if there is one, otherwise it is the log start
+// offset. We search backwards from the log start offset, widening the search each time we don't find a batch.
+func (r *RecordsDeleter) findTruncatedOffset(partHash []byte, offset int64, prevTruncatedOffset int64) (int64, error) {
+	keyEnd := createTopicDataKey(partHash, offset+1)
+	window := int64(batchSearchWindow)
+	for {
+		start := offset - window
+		if start < prevTruncatedOffset {
+			start = prevTruncatedOffset
+		}
+		iter, err := queryutils.CreateIteratorForKeyRange(createTopicDataKey(partHash, start), keyEnd, r.lsmHolder,
+			r.tableGetter)
+		if err != nil {
+			return 0, err
+		}
+		var lastBatch []byte
+		for {
+			ok, kv, err := iter.Next()
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				break
+			}
+			lastBatch = kv.Value
+		}
+		if lastBatch != nil {
+			baseOffset := kafkaencoding.BaseOffset(lastBatch)
+			if baseOffset+int64(kafkaencoding.LastOffsetDelta(lastBatch)) >= offset {
+				// The batch contains the log start offset, so must be retained
+				return baseOffset, nil
+			}
+			return offset, nil
+		}
+		if start == prevTruncatedOffset {
+			return offset, nil
+		}
+		window *= 2
+	}
+}
+
+func createTopicDataKey(partHash []byte, offset int64) []byte {
+	key := make([]byte, 0, 25)
+	key = append(key, partHash...)
+	key = append(key, common.EntryTypeTopicData)
+	return encoding.KeyEncodeInt(key, offset)
+}
+
+// createRecordsPrefixDeleteKVs creates prefix tombstones which together cover the keys of the record batches with base
+// offsets in the range [fromOffset, toOffset). A prefix tombstone deletes all keys which start with the prefix, so we
+// split the range into blocks of offsets which share a common key prefix, taking the largest block possible each time.
+func createRecordsPrefixDeleteKVs(partHash []byte, fromOffset int64, toOffset int64) []common.KV {
+	var kvs []common.KV
+	// Offsets are encoded in keys as big endian with the sign bit flipped
+	lo := uint64(fromOffset) ^ encoding.SignBitMask
+	hi := uint64(toOffset) ^ encoding.SignBitMask
+	for lo < hi {
+		// the number of trailing bytes of the offset not included in the prefix
+		trailing := 0
+		for trailing < 7 {
+			blockSize := uint64(1) << (8 * (trailing + 1))
+			if lo%blockSize != 0 || hi-lo < blockSize {
+				break
+			}
+			trailing++
+		}
+		prefix := make([]byte, 0, 33)
+		prefix = append(prefix, partHash...)
+		prefix = append(prefix, common.EntryTypeTopicData)
+		prefix = binary.BigEndian.AppendUint64(prefix, lo)
+		prefix = prefix[:len(prefix)-trailing]
+		// prefix delete tombstones have special version math.MaxUint64 which identifies them in MergingIterator
+		kvs = append(kvs, common.KV{Key: encoding.EncodeVersion(prefix, math.MaxUint64)})
+		lo += uint64(1) << (8 * trailing)
+	}
+	return kvs
+}
@@ -0,0 +1,67 @@
+package control
+
+import (
+	"bytes"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/stretchr/testify/require"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCreateRecordsPrefixDeleteKVs(t *testing.T) {
+	partHash, err := parthash.CreatePartitionHash(1000, 1)
+	require.NoError(t, err)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 0, 0)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 0, 1)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 0, 256)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 0, 65536)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 255, 257)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 1000, 1000000)
+	testCreateRecordsPrefixDeleteKVs(t, partHash, 12345678, 1234567890123)
+	for i := 0; i < 100; i++ {
+		from := rand.Int63n(1000000)
+		to := from + rand.Int63n(1000000)
+		testCreateRecordsPrefixDeleteKVs(t, partHash, from, to)
+	}
+}
+
+func testCreateRecordsPrefixDeleteKVs(t *testing.T, partHash []byte, from int64, to int64) {
+	kvs := createRecordsPrefixDeleteKVs(partHash, from, to)
+	if from == to {
+		require.Equal(t, 0, len(kvs))
+		return
+	}
+	var prefixes [][]byte
+	for i, kv := range kvs {
+		require.Equal(t, 0, len(kv.Value))
+		// Prefix tombstones have version math.MaxUint64
+		require.Equal(t, uint64(math.MaxUint64), encoding.DecodeKeyVersion(kv.Key))
+		if i > 0 {
+			require.True(t, bytes.Compare(kvs[i-1].Key, kv.Key) < 0)
+		}
+		prefixes = append(prefixes, kv.Key[:len(kv.Key)-8])
+	}
+	// The number of prefixes is bounded by the number of bytes in the offset
+	require.LessOrEqual(t, len(prefixes), 2*255*8)
+	isDeleted := func(offset int64) bool {
+		key := createTopicDataKey(partHash, offset)
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, offset := range []int64{from - 1, from, from + 1, (from + to) / 2, to - 1, to, to + 1} {
+		if offset < 0 {
+			continue
+		}
+		require.Equal(t, offset >= from && offset < to, isDeleted(offset), "offset %d from %d to %d", offset, from, to)
+	}
+	for i := 0; i < 100; i++ {
+		offset := from + rand.Int63n(to-from)
+		require.True(t, isDeleted(offset))
+	}
+}
@@ -0,0 +1,95 @@
+package control
+
+import (
+	"errors"
+	"github.com/spirit-labs/tektite/common"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/queryutils"
+	"github.com/spirit-labs/tektite/sst"
+	"sync/atomic"
+	"time"
+)
+
+type lsmReceiver interface {
+	ApplyLsmChanges(regBatch lsm.RegistrationBatch, completionFunc func(error) error) error
+	QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.OverlappingTables, error)
+}
+
+// kvStore writes KVs directly to the LSM and looks up the latest value for a key. It is used by controller components
+// that persist their own state, such as sequences and user credentials.
+type kvStore struct {
+	stopping       atomic.Bool
+	lsmHolder      lsmReceiver
+	tableGetter    sst.TableGetter
+	objStore       objstore.Client
+	dataBucketName string
+	dataFormat     common.DataFormat
+}
+
+// TODO combine with similar in topicmeta manager?
+func (s *kvStore) writeKvDirect(kv common.KV) error {
+	return s.writeKvsDirect([]common.KV{kv})
+}
+
+// writeKvsDirect writes the KVs, which must be in key order, in a single table
+func (s *kvStore) writeKvsDirect(kvs []common.KV) error {
+	iter := common.NewKvSliceIterator(kvs)
+	// Build ssTable
+	table, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTable(s.dataFormat, 0, 0, iter)
+	if err != nil {
+		return err
+	}
+	tableID := sst.CreateSSTableId()
+	// Push ssTable to object store
+	tableData := table.Serialize()
+	if err := s.putWithRetry(tableID, tableData); err != nil {
+		return err
+	}
+	// Register table with LSM
+	regEntry := lsm.RegistrationEntry{
+		Level:            0,
+		TableID:          []byte(tableID),
+		MinVersion:       minVersion,
+		MaxVersion:       maxVersion,
+		KeyStart:         smallestKey,
+		KeyEnd:           largestKey,
+		DeleteRatio:      table.DeleteRatio(),
+		AddedTime:        uint64(time.Now().UnixMilli()),
+		NumEntries:       uint64(table.NumEntries()),
+		TableSize:        uint64(table.SizeBytes()),
+		NumPrefixDeletes: uint32(table.NumPrefixDeletes()),
+	}
+	batch := lsm.RegistrationBatch{
+		Registrations: []lsm.RegistrationEntry{regEntry},
+	}
+	ch := make(chan error, 1)
+	if err := s.lsmHolder.ApplyLsmChanges(batch, func(err error) error {
+		ch <- err
+		return nil
+	}); err != nil {
+		return err
+	}
+	return <-ch
+}
+
+func (s *kvStore) putWithRetry(key string, value []byte) error {
+	for {
+		err := objstore.PutWithTimeout(s.objStore, s.dataBucketName, key, value, objStoreCallTimeout)
+		if err == nil {
+			return nil
+		}
+		if s.stopping.Load() {
+			return errors.New("kv store is stopping")
+		}
+		if common.IsUnavailableError(err) {
+			log.Warnf("Unable to write type info due to unavailability, will retry after delay: %v", err)
+			time.Sleep(unavailabilityRetryDelay)
+		}
+	}
+}
+
+func (s *kvStore) getLatestValueWithKey(key []byte) ([]byte, error) {
+	return queryutils.GetLatestValueWithKey(key, s.lsmHolder, s.tableGetter, nil)
+}
@@ -207,6 +207,25 @@ func (s *LsmHolder) QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.Over
 	return s.lsmManager.QueryTablesInRange(keyStart, keyEnd)
 }
 
+func (s *LsmHolder) HasPrefixDeletesInRange(keyStart []byte, keyEnd []byte) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.checkStarted(); err != nil {
+		return false, err
+	}
+	return s.lsmManager.HasPrefixDeletesInRange(keyStart, keyEnd)
+}
+
+// GetStats returns the table counts for each level and the compaction stats
+func (s *LsmHolder) GetStats() (map[int]int, lsm.CompactionStats) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if !s.started {
+		return nil, lsm.CompactionStats{}
+	}
+	return s.lsmManager.GetLevelTableCounts(), s.lsmManager.GetCompactionStats()
+}
+
 func (s *LsmHolder) checkStarted() error {
 	if !s.started {
 		return common.NewTektiteErrorf(common.Unavailable, "lsm holder is not started")
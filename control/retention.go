@@ -0,0 +1,132 @@
+package control
+
+import (
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/offsets"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"sync"
+	"time"
+)
+
+// partitionRetentionProvider provides the retention of topic partitions to the LSM. Time based retention comes from the
+// topic metadata, and records before the truncated offset of the partition's log start can also be removed. This is
+// called during compaction with the LSM lock held, so it must only read state that is already in memory.
+type partitionRetentionProvider struct {
+	topicMetaManager *topicmeta.Manager
+	offsetsCache     *offsets.Cache
+}
+
+func (p *partitionRetentionProvider) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	retention, ok, err := p.topicMetaManager.GetPartitionRetention(partitionHash)
+	if err != nil || !ok {
+		return lsm.PartitionRetention{}, err
+	}
+	return lsm.PartitionRetention{
+		Retention:       retention.RetentionTime,
+		MinOffset:       p.offsetsCache.GetTruncatedOffset(retention.TopicID, retention.PartitionID),
+		Compacted:       retention.Compacted,
+		DeleteRetention: retention.DeleteRetention,
+	}, nil
+}
+
+func (p *partitionRetentionProvider) GetMaxRetentionInRange(partitionHashStart []byte, partitionHashEnd []byte) (time.Duration, error) {
+	return p.topicMetaManager.GetMaxRetentionInRange(partitionHashStart, partitionHashEnd)
+}
+
+// retentionEnforcer periodically applies size and time based retention. For each partition of a topic with a retention
+// size or time the records before the minimum retained offset are deleted, which advances and persists the log start of
+// the partition, so the deleted records can no longer be fetched and are removed by compaction. This is done on a timer
+// rather than when the LSM asks for the retention of a partition, as advancing the log start can load the partition
+// from the LSM, which must not be done with the LSM lock held.
+type retentionEnforcer struct {
+	lock             sync.Mutex
+	started          bool
+	checkInterval    time.Duration
+	timer            *time.Timer
+	topicMetaManager *topicmeta.Manager
+	offsetsCache     *offsets.Cache
+	recordsDeleter   *RecordsDeleter
+}
+
+func newRetentionEnforcer(checkInterval time.Duration, topicMetaManager *topicmeta.Manager,
+	offsetsCache *offsets.Cache, recordsDeleter *RecordsDeleter) *retentionEnforcer {
+	return &retentionEnforcer{
+		checkInterval:    checkInterval,
+		topicMetaManager: topicMetaManager,
+		offsetsCache:     offsetsCache,
+		recordsDeleter:   recordsDeleter,
+	}
+}
+
+func (s *retentionEnforcer) start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return
+	}
+	s.scheduleTimer()
+	s.started = true
+}
+
+func (s *retentionEnforcer) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.started {
+		return
+	}
+	s.timer.Stop()
+	s.started = false
+}
+
+func (s *retentionEnforcer) scheduleTimer() {
+	s.timer = time.AfterFunc(s.checkInterval, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if !s.started {
+			return
+		}
+		if err := s.enforce(); err != nil {
+			log.Warnf("failed to apply retention: %v", err)
+		}
+		s.scheduleTimer()
+	})
+}
+
+func (s *retentionEnforcer) enforce() error {
+	infos, err := s.topicMetaManager.GetAllTopicInfos()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMilli()
+	for _, info := range infos {
+		if !info.IsDeleteEnabled() || (info.RetentionBytes <= 0 && info.RetentionTime <= 0) {
+			continue
+		}
+		for partitionID := 0; partitionID < info.PartitionCount; partitionID++ {
+			var minOffset int64
+			if info.RetentionBytes > 0 {
+				minOffset, err = s.offsetsCache.GetMinRetainedOffset(info.ID, partitionID, info.RetentionBytes)
+				if err != nil {
+					return err
+				}
+			}
+			if info.RetentionTime > 0 {
+				minOffsetForTime, err := s.offsetsCache.GetMinRetainedOffsetForTime(info.ID, partitionID,
+					now-info.RetentionTime.Milliseconds())
+				if err != nil {
+					return err
+				}
+				minOffset = max(minOffset, minOffsetForTime)
+			}
+			if minOffset == 0 {
+				continue
+			}
+			// Does nothing if the log start offset is already at or after the min offset
+			if _, err := s.recordsDeleter.DeleteRecords(info.ID, partitionID, minOffset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
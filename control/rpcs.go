@@ -2,31 +2,57 @@ package control
 
 import (
 	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/topicmeta"
+	"math"
+	"time"
 )
 
+/*
+The serialized forms of the following RPCs start with a version, as topic metadata does, so that a node which gets a
+version it does not understand, from a node running a different release, rejects it rather than misparsing it. The
+version must be incremented whenever the serialized form changes.
+*/
+const (
+	registerTableListenerResponseVersion uint16 = 1
+	prePushRequestVersion                uint16 = 1
+	prePushResponseVersion               uint16 = 1
+	tablesRegisteredNotificationVersion  uint16 = 1
+	getOffsetInfoResponseVersion         uint16 = 1
+)
+
+func checkRPCStructVersion(buff []byte, offset int, version uint16, name string) (int, error) {
+	serializedVersion := binary.BigEndian.Uint16(buff[offset:])
+	if serializedVersion != version {
+		return 0, errors.Errorf("invalid %s version %d", name, serializedVersion)
+	}
+	return offset + 2, nil
+}
+
 type RegisterL0Request struct {
 	LeaderVersion int
 	Sequence      int64
 	RegEntry      lsm.RegistrationEntry
 }
 
-func (r *RegisterL0Request) Serialize(buff []byte) []byte {
+func (r *RegisterL0Request) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = binary.BigEndian.AppendUint64(buff, uint64(r.LeaderVersion))
 	buff = binary.BigEndian.AppendUint64(buff, uint64(r.Sequence))
-	return r.RegEntry.Serialize(buff)
+	return r.RegEntry.Serialize(buff, format)
 }
 
-func (r *RegisterL0Request) Deserialize(buff []byte, offset int) int {
+func (r *RegisterL0Request) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	r.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
 	r.Sequence = int64(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
-	return r.RegEntry.Deserialize(buff, offset)
+	return r.RegEntry.Deserialize(buff, offset, format)
 }
 
 type ApplyChangesRequest struct {
@@ -34,15 +60,15 @@ type ApplyChangesRequest struct {
 	RegBatch      lsm.RegistrationBatch
 }
 
-func (a *ApplyChangesRequest) Serialize(buff []byte) []byte {
+func (a *ApplyChangesRequest) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = binary.BigEndian.AppendUint64(buff, uint64(a.LeaderVersion))
-	return a.RegBatch.Serialize(buff)
+	return a.RegBatch.Serialize(buff, format)
 }
 
-func (a *ApplyChangesRequest) Deserialize(buff []byte, offset int) int {
+func (a *ApplyChangesRequest) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	a.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
-	return a.RegBatch.Deserialize(buff, offset)
+	return a.RegBatch.Deserialize(buff, offset, format)
 }
 
 type QueryTablesInRangeRequest struct {
@@ -111,16 +137,29 @@ func (f *RegisterTableListenerRequest) Deserialize(buff []byte, offset int) int
 
 type RegisterTableListenerResponse struct {
 	LastReadableOffset int64
+	LastStableOffset   int64
+	LogStartOffset     int64
 }
 
 func (g *RegisterTableListenerResponse) Serialize(buff []byte) []byte {
-	return binary.BigEndian.AppendUint64(buff, uint64(g.LastReadableOffset))
+	buff = binary.BigEndian.AppendUint16(buff, registerTableListenerResponseVersion)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LastReadableOffset))
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LastStableOffset))
+	return binary.BigEndian.AppendUint64(buff, uint64(g.LogStartOffset))
 }
 
-func (g *RegisterTableListenerResponse) Deserialize(buff []byte, offset int) int {
+func (g *RegisterTableListenerResponse) Deserialize(buff []byte, offset int) (int, error) {
+	offset, err := checkRPCStructVersion(buff, offset, registerTableListenerResponseVersion, "register table listener response")
+	if err != nil {
+		return 0, err
+	}
 	g.LastReadableOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
-	return offset
+	g.LastStableOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	g.LogStartOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	return offset, nil
 }
 
 type PrePushRequest struct {
@@ -135,6 +174,7 @@ type EpochInfo struct {
 }
 
 func (g *PrePushRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint16(buff, prePushRequestVersion)
 	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
 	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.Infos)))
 	for _, topicInfo := range g.Infos {
@@ -143,6 +183,16 @@ func (g *PrePushRequest) Serialize(buff []byte) []byte {
 		for _, partitionInfo := range topicInfo.PartitionInfos {
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partitionInfo.PartitionID))
 			buff = binary.BigEndian.AppendUint32(buff, uint32(partitionInfo.NumOffsets))
+			buff = binary.BigEndian.AppendUint32(buff, uint32(len(partitionInfo.TxEvents)))
+			for _, event := range partitionInfo.TxEvents {
+				buff = binary.BigEndian.AppendUint64(buff, uint64(event.ProducerID))
+				buff = binary.BigEndian.AppendUint64(buff, uint64(event.Offset))
+				if event.End {
+					buff = append(buff, 1)
+				} else {
+					buff = append(buff, 0)
+				}
+			}
 		}
 	}
 	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.EpochInfos)))
@@ -154,7 +204,11 @@ func (g *PrePushRequest) Serialize(buff []byte) []byte {
 	return buff
 }
 
-func (g *PrePushRequest) Deserialize(buff []byte, offset int) int {
+func (g *PrePushRequest) Deserialize(buff []byte, offset int) (int, error) {
+	offset, err := checkRPCStructVersion(buff, offset, prePushRequestVersion, "pre-push request")
+	if err != nil {
+		return 0, err
+	}
 	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
 	lInfos := int(binary.BigEndian.Uint32(buff[offset:]))
@@ -173,6 +227,20 @@ func (g *PrePushRequest) Deserialize(buff []byte, offset int) int {
 			offset += 8
 			partitionInfo.NumOffsets = int(binary.BigEndian.Uint32(buff[offset:]))
 			offset += 4
+			numEvents := int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			if numEvents > 0 {
+				partitionInfo.TxEvents = make([]offsets.TxEvent, numEvents)
+				for k := 0; k < numEvents; k++ {
+					event := &partitionInfo.TxEvents[k]
+					event.ProducerID = int64(binary.BigEndian.Uint64(buff[offset:]))
+					offset += 8
+					event.Offset = int64(binary.BigEndian.Uint64(buff[offset:]))
+					offset += 8
+					event.End = buff[offset] == 1
+					offset++
+				}
+			}
 		}
 	}
 	lInfos = int(binary.BigEndian.Uint32(buff[offset:]))
@@ -187,7 +255,7 @@ func (g *PrePushRequest) Deserialize(buff []byte, offset int) int {
 		groupEpochInfo.Epoch = int(binary.BigEndian.Uint64(buff[offset:]))
 		offset += 8
 	}
-	return offset
+	return offset, nil
 }
 
 type PrePushResponse struct {
@@ -197,6 +265,7 @@ type PrePushResponse struct {
 }
 
 func (g *PrePushResponse) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint16(buff, prePushResponseVersion)
 	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.Offsets)))
 	for _, offset := range g.Offsets {
 		buff = binary.BigEndian.AppendUint64(buff, uint64(offset.TopicID))
@@ -204,6 +273,10 @@ func (g *PrePushResponse) Serialize(buff []byte) []byte {
 		for _, partOffset := range offset.PartitionInfos {
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.PartitionID))
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.Offset))
+			buff = binary.BigEndian.AppendUint32(buff, uint32(len(partOffset.TxFirstOffsets)))
+			for _, txFirstOffset := range partOffset.TxFirstOffsets {
+				buff = binary.BigEndian.AppendUint64(buff, uint64(txFirstOffset))
+			}
 		}
 	}
 	buff = binary.BigEndian.AppendUint64(buff, uint64(g.Sequence))
@@ -218,7 +291,11 @@ func (g *PrePushResponse) Serialize(buff []byte) []byte {
 	return buff
 }
 
-func (g *PrePushResponse) Deserialize(buff []byte, offset int) int {
+func (g *PrePushResponse) Deserialize(buff []byte, offset int) (int, error) {
+	offset, err := checkRPCStructVersion(buff, offset, prePushResponseVersion, "pre-push response")
+	if err != nil {
+		return 0, err
+	}
 	numOffsets := int(binary.BigEndian.Uint32(buff[offset:]))
 	offset += 4
 	g.Offsets = make([]offsets.OffsetTopicInfo, numOffsets)
@@ -233,9 +310,20 @@ func (g *PrePushResponse) Deserialize(buff []byte, offset int) int {
 			offset += 8
 			off := int64(binary.BigEndian.Uint64(buff[offset:]))
 			offset += 8
+			numTxFirstOffsets := int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			var txFirstOffsets []int64
+			if numTxFirstOffsets > 0 {
+				txFirstOffsets = make([]int64, numTxFirstOffsets)
+				for k := 0; k < numTxFirstOffsets; k++ {
+					txFirstOffsets[k] = int64(binary.BigEndian.Uint64(buff[offset:]))
+					offset += 8
+				}
+			}
 			partInfos[j] = offsets.OffsetPartitionInfo{
-				PartitionID: partitionID,
-				Offset:      off,
+				PartitionID:    partitionID,
+				Offset:         off,
+				TxFirstOffsets: txFirstOffsets,
 			}
 		}
 		g.Offsets[i] = offsets.OffsetTopicInfo{
@@ -254,7 +342,7 @@ func (g *PrePushResponse) Deserialize(buff []byte, offset int) int {
 		}
 		offset++
 	}
-	return offset
+	return offset, nil
 }
 
 type GetAllTopicInfosRequest struct {
@@ -377,6 +465,87 @@ func (g *DeleteTopicRequest) Deserialize(buff []byte, offset int) int {
 	return offset
 }
 
+type CreatePartitionsRequest struct {
+	LeaderVersion  int
+	TopicName      string
+	PartitionCount int
+}
+
+func (g *CreatePartitionsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.TopicName)))
+	buff = append(buff, g.TopicName...)
+	return binary.BigEndian.AppendUint64(buff, uint64(g.PartitionCount))
+}
+
+func (g *CreatePartitionsRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	g.TopicName = string(buff[offset : offset+ln])
+	offset += ln
+	g.PartitionCount = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	return offset
+}
+
+type AlterTopicConfigsRequest struct {
+	LeaderVersion int
+	TopicName     string
+	Alterations   []topicmeta.ConfigAlteration
+	ValidateOnly  bool
+}
+
+func (g *AlterTopicConfigsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.TopicName)))
+	buff = append(buff, g.TopicName...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.Alterations)))
+	for _, alteration := range g.Alterations {
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(alteration.Name)))
+		buff = append(buff, alteration.Name...)
+		buff = append(buff, byte(alteration.Operation))
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(alteration.Value)))
+		buff = append(buff, alteration.Value...)
+	}
+	if g.ValidateOnly {
+		buff = append(buff, 1)
+	} else {
+		buff = append(buff, 0)
+	}
+	return buff
+}
+
+func (g *AlterTopicConfigsRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	g.TopicName = string(buff[offset : offset+ln])
+	offset += ln
+	numAlterations := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	if numAlterations > 0 {
+		g.Alterations = make([]topicmeta.ConfigAlteration, numAlterations)
+		for i := 0; i < numAlterations; i++ {
+			ln = int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			g.Alterations[i].Name = string(buff[offset : offset+ln])
+			offset += ln
+			g.Alterations[i].Operation = topicmeta.ConfigOperation(buff[offset])
+			offset++
+			ln = int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			g.Alterations[i].Value = string(buff[offset : offset+ln])
+			offset += ln
+		}
+	}
+	g.ValidateOnly = buff[offset] == 1
+	offset++
+	return offset
+}
+
 type GetGroupCoordinatorInfoRequest struct {
 	LeaderVersion int
 	GroupID       string
@@ -433,6 +602,7 @@ type TablesRegisteredNotification struct {
 }
 
 func (r *TablesRegisteredNotification) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint16(buff, tablesRegisteredNotificationVersion)
 	buff = binary.BigEndian.AppendUint64(buff, uint64(r.Sequence))
 	buff = binary.BigEndian.AppendUint64(buff, uint64(r.LeaderVersion))
 	buff = binary.BigEndian.AppendUint32(buff, uint32(len(r.TableIDs)))
@@ -447,12 +617,18 @@ func (r *TablesRegisteredNotification) Serialize(buff []byte) []byte {
 		for _, partInfo := range info.PartitionInfos {
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partInfo.PartitionID))
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partInfo.Offset))
+			buff = binary.BigEndian.AppendUint64(buff, uint64(partInfo.LastStableOffset))
+			buff = binary.BigEndian.AppendUint64(buff, uint64(partInfo.LogStartOffset))
 		}
 	}
 	return buff
 }
 
-func (r *TablesRegisteredNotification) Deserialize(buff []byte, offset int) int {
+func (r *TablesRegisteredNotification) Deserialize(buff []byte, offset int) (int, error) {
+	offset, err := checkRPCStructVersion(buff, offset, tablesRegisteredNotificationVersion, "tables registered notification")
+	if err != nil {
+		return 0, err
+	}
 	r.Sequence = int64(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
 	r.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
@@ -485,9 +661,13 @@ func (r *TablesRegisteredNotification) Deserialize(buff []byte, offset int) int
 			offset += 8
 			partInfo.Offset = int64(binary.BigEndian.Uint64(buff[offset:]))
 			offset += 8
+			partInfo.LastStableOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+			offset += 8
+			partInfo.LogStartOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+			offset += 8
 		}
 	}
-	return offset
+	return offset, nil
 }
 
 type GenerateSequenceRequest struct {
@@ -569,6 +749,7 @@ type GetOffsetInfoResponse struct {
 }
 
 func (g *GetOffsetInfoResponse) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint16(buff, getOffsetInfoResponseVersion)
 	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.OffsetInfos)))
 	for _, offset := range g.OffsetInfos {
 		buff = binary.BigEndian.AppendUint64(buff, uint64(offset.TopicID))
@@ -576,12 +757,18 @@ func (g *GetOffsetInfoResponse) Serialize(buff []byte) []byte {
 		for _, partOffset := range offset.PartitionInfos {
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.PartitionID))
 			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.Offset))
+			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.LastStableOffset))
+			buff = binary.BigEndian.AppendUint64(buff, uint64(partOffset.LogStartOffset))
 		}
 	}
 	return buff
 }
 
-func (g *GetOffsetInfoResponse) Deserialize(buff []byte, offset int) int {
+func (g *GetOffsetInfoResponse) Deserialize(buff []byte, offset int) (int, error) {
+	offset, err := checkRPCStructVersion(buff, offset, getOffsetInfoResponseVersion, "get offset info response")
+	if err != nil {
+		return 0, err
+	}
 	numOffsets := int(binary.BigEndian.Uint32(buff[offset:]))
 	offset += 4
 	g.OffsetInfos = make([]offsets.OffsetTopicInfo, numOffsets)
@@ -596,9 +783,15 @@ func (g *GetOffsetInfoResponse) Deserialize(buff []byte, offset int) int {
 			offset += 8
 			off := int64(binary.BigEndian.Uint64(buff[offset:]))
 			offset += 8
+			lso := int64(binary.BigEndian.Uint64(buff[offset:]))
+			offset += 8
+			logStartOffset := int64(binary.BigEndian.Uint64(buff[offset:]))
+			offset += 8
 			partInfos[j] = offsets.OffsetPartitionInfo{
-				PartitionID: partitionID,
-				Offset:      off,
+				PartitionID:      partitionID,
+				Offset:           off,
+				LastStableOffset: lso,
+				LogStartOffset:   logStartOffset,
 			}
 		}
 		g.OffsetInfos[i] = offsets.OffsetTopicInfo{
@@ -606,5 +799,403 @@ func (g *GetOffsetInfoResponse) Deserialize(buff []byte, offset int) int {
 			PartitionInfos: partInfos,
 		}
 	}
+	return offset, nil
+}
+
+type PutUserCredentialsRequest struct {
+	LeaderVersion int
+	Username      string
+	Mechanism     string
+	StoredKey     []byte
+	ServerKey     []byte
+	Salt          string
+	Iters         int
+}
+
+func (p *PutUserCredentialsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(p.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(p.Username)))
+	buff = append(buff, p.Username...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(p.Mechanism)))
+	buff = append(buff, p.Mechanism...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(p.StoredKey)))
+	buff = append(buff, p.StoredKey...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(p.ServerKey)))
+	buff = append(buff, p.ServerKey...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(p.Salt)))
+	buff = append(buff, p.Salt...)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(p.Iters))
+	return buff
+}
+
+func (p *PutUserCredentialsRequest) Deserialize(buff []byte, offset int) int {
+	p.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	p.Username = string(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	p.Mechanism = string(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	p.StoredKey = common.ByteSliceCopy(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	p.ServerKey = common.ByteSliceCopy(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	p.Salt = string(buff[offset : offset+ln])
+	offset += ln
+	p.Iters = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
 	return offset
 }
+
+type DeleteUserCredentialsRequest struct {
+	LeaderVersion int
+	Username      string
+	Mechanism     string
+}
+
+func (d *DeleteUserCredentialsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(d.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(d.Username)))
+	buff = append(buff, d.Username...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(d.Mechanism)))
+	buff = append(buff, d.Mechanism...)
+	return buff
+}
+
+func (d *DeleteUserCredentialsRequest) Deserialize(buff []byte, offset int) int {
+	d.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	d.Username = string(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	d.Mechanism = string(buff[offset : offset+ln])
+	offset += ln
+	return offset
+}
+
+type GetUserCredentialsRequest struct {
+	LeaderVersion int
+	Username      string
+	Mechanism     string
+}
+
+func (g *GetUserCredentialsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.Username)))
+	buff = append(buff, g.Username...)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.Mechanism)))
+	buff = append(buff, g.Mechanism...)
+	return buff
+}
+
+func (g *GetUserCredentialsRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	g.Username = string(buff[offset : offset+ln])
+	offset += ln
+	ln = int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	g.Mechanism = string(buff[offset : offset+ln])
+	offset += ln
+	return offset
+}
+
+type GetUserCredentialsResponse struct {
+	Exists bool
+	Creds  auth.UserCredentials
+}
+
+func (g *GetUserCredentialsResponse) Serialize(buff []byte) []byte {
+	if g.Exists {
+		buff = append(buff, 1)
+	} else {
+		buff = append(buff, 0)
+	}
+	return g.Creds.Serialize(buff)
+}
+
+func (g *GetUserCredentialsResponse) Deserialize(buff []byte, offset int) int {
+	g.Exists = buff[offset] == 1
+	offset++
+	return g.Creds.Deserialize(buff, offset)
+}
+
+type GetPartitionRetentionRequest struct {
+	LeaderVersion int
+	PartitionHash []byte
+}
+
+func (g *GetPartitionRetentionRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.PartitionHash)))
+	buff = append(buff, g.PartitionHash...)
+	return buff
+}
+
+func (g *GetPartitionRetentionRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	g.PartitionHash = common.ByteSliceCopy(buff[offset : offset+ln])
+	offset += ln
+	return offset
+}
+
+type GetPartitionRetentionResponse struct {
+	Retention lsm.PartitionRetention
+}
+
+func (g *GetPartitionRetentionResponse) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.Retention.Retention))
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.Retention.MinOffset))
+	if g.Retention.Compacted {
+		buff = append(buff, 1)
+	} else {
+		buff = append(buff, 0)
+	}
+	return binary.BigEndian.AppendUint64(buff, uint64(g.Retention.DeleteRetention))
+}
+
+func (g *GetPartitionRetentionResponse) Deserialize(buff []byte, offset int) int {
+	g.Retention.Retention = time.Duration(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	g.Retention.MinOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	g.Retention.Compacted = buff[offset] == 1
+	offset++
+	g.Retention.DeleteRetention = time.Duration(binary.BigEndian.Uint64(buff[offset:]))
+	return offset + 8
+}
+
+type AlterClientQuotasRequest struct {
+	LeaderVersion int
+	Alterations   []ClientQuotaAlteration
+	ValidateOnly  bool
+}
+
+func (a *AlterClientQuotasRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(a.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(a.Alterations)))
+	for _, alteration := range a.Alterations {
+		buff = alteration.Entity.Serialize(buff)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(alteration.Ops)))
+		for _, op := range alteration.Ops {
+			buff = binary.BigEndian.AppendUint32(buff, uint32(len(op.Key)))
+			buff = append(buff, op.Key...)
+			buff = binary.BigEndian.AppendUint64(buff, math.Float64bits(op.Value))
+			if op.Remove {
+				buff = append(buff, 1)
+			} else {
+				buff = append(buff, 0)
+			}
+		}
+	}
+	if a.ValidateOnly {
+		buff = append(buff, 1)
+	} else {
+		buff = append(buff, 0)
+	}
+	return buff
+}
+
+func (a *AlterClientQuotasRequest) Deserialize(buff []byte, offset int) int {
+	a.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	numAlterations := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	if numAlterations > 0 {
+		a.Alterations = make([]ClientQuotaAlteration, numAlterations)
+		for i := 0; i < numAlterations; i++ {
+			offset = a.Alterations[i].Entity.Deserialize(buff, offset)
+			numOps := int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			if numOps > 0 {
+				a.Alterations[i].Ops = make([]ClientQuotaOp, numOps)
+				for j := 0; j < numOps; j++ {
+					ln := int(binary.BigEndian.Uint32(buff[offset:]))
+					offset += 4
+					a.Alterations[i].Ops[j].Key = string(buff[offset : offset+ln])
+					offset += ln
+					a.Alterations[i].Ops[j].Value = math.Float64frombits(binary.BigEndian.Uint64(buff[offset:]))
+					offset += 8
+					a.Alterations[i].Ops[j].Remove = buff[offset] == 1
+					offset++
+				}
+			}
+		}
+	}
+	a.ValidateOnly = buff[offset] == 1
+	offset++
+	return offset
+}
+
+type GetClientQuotasRequest struct {
+	LeaderVersion int
+}
+
+func (g *GetClientQuotasRequest) Serialize(buff []byte) []byte {
+	return binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+}
+
+func (g *GetClientQuotasRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	return offset + 8
+}
+
+type GetClientQuotasResponse struct {
+	Quotas []ClientQuotaConfig
+}
+
+func (g *GetClientQuotasResponse) Serialize(buff []byte) []byte {
+	return serializeClientQuotaConfigs(buff, g.Quotas)
+}
+
+func (g *GetClientQuotasResponse) Deserialize(buff []byte, offset int) int {
+	g.Quotas, offset = deserializeClientQuotaConfigs(buff, offset)
+	return offset
+}
+
+type CreateAclsRequest struct {
+	LeaderVersion int
+	Acls          []acls.AclEntry
+}
+
+func (c *CreateAclsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(c.LeaderVersion))
+	return acls.SerializeAcls(buff, c.Acls)
+}
+
+func (c *CreateAclsRequest) Deserialize(buff []byte, offset int) int {
+	c.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	c.Acls, offset = acls.DeserializeAcls(buff, offset)
+	return offset
+}
+
+type DeleteAclsRequest struct {
+	LeaderVersion int
+	Filters       []acls.AclFilter
+}
+
+func (d *DeleteAclsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(d.LeaderVersion))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(d.Filters)))
+	for _, filter := range d.Filters {
+		buff = filter.Serialize(buff)
+	}
+	return buff
+}
+
+func (d *DeleteAclsRequest) Deserialize(buff []byte, offset int) int {
+	d.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	numFilters := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	d.Filters = make([]acls.AclFilter, numFilters)
+	for i := 0; i < numFilters; i++ {
+		offset = d.Filters[i].Deserialize(buff, offset)
+	}
+	return offset
+}
+
+type DeleteAclsResponse struct {
+	// Deleted holds the ACLs deleted by each filter in the request
+	Deleted [][]acls.AclEntry
+}
+
+func (d *DeleteAclsResponse) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(d.Deleted)))
+	for _, deleted := range d.Deleted {
+		buff = acls.SerializeAcls(buff, deleted)
+	}
+	return buff
+}
+
+func (d *DeleteAclsResponse) Deserialize(buff []byte, offset int) int {
+	numFilters := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	d.Deleted = make([][]acls.AclEntry, numFilters)
+	for i := 0; i < numFilters; i++ {
+		d.Deleted[i], offset = acls.DeserializeAcls(buff, offset)
+	}
+	return offset
+}
+
+type GetAclsRequest struct {
+	LeaderVersion int
+}
+
+func (g *GetAclsRequest) Serialize(buff []byte) []byte {
+	return binary.BigEndian.AppendUint64(buff, uint64(g.LeaderVersion))
+}
+
+func (g *GetAclsRequest) Deserialize(buff []byte, offset int) int {
+	g.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	return offset + 8
+}
+
+type GetAclsResponse struct {
+	Acls []acls.AclEntry
+}
+
+func (g *GetAclsResponse) Serialize(buff []byte) []byte {
+	return acls.SerializeAcls(buff, g.Acls)
+}
+
+func (g *GetAclsResponse) Deserialize(buff []byte, offset int) int {
+	g.Acls, offset = acls.DeserializeAcls(buff, offset)
+	return offset
+}
+
+type DeleteRecordsRequest struct {
+	LeaderVersion int
+	TopicID       int
+	PartitionID   int
+	Offset        int64
+}
+
+func (d *DeleteRecordsRequest) Serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint64(buff, uint64(d.LeaderVersion))
+	buff = binary.BigEndian.AppendUint64(buff, uint64(d.TopicID))
+	buff = binary.BigEndian.AppendUint64(buff, uint64(d.PartitionID))
+	return binary.BigEndian.AppendUint64(buff, uint64(d.Offset))
+}
+
+func (d *DeleteRecordsRequest) Deserialize(buff []byte, offset int) int {
+	d.LeaderVersion = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	d.TopicID = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	d.PartitionID = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	d.Offset = int64(binary.BigEndian.Uint64(buff[offset:]))
+	return offset + 8
+}
+
+type DeleteRecordsResponse struct {
+	LogStartOffset int64
+}
+
+func (d *DeleteRecordsResponse) Serialize(buff []byte) []byte {
+	return binary.BigEndian.AppendUint64(buff, uint64(d.LogStartOffset))
+}
+
+func (d *DeleteRecordsResponse) Deserialize(buff []byte, offset int) int {
+	d.LogStartOffset = int64(binary.BigEndian.Uint64(buff[offset:]))
+	return offset + 8
+}
@@ -1,6 +1,10 @@
 package control
 
 import (
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/sst"
@@ -30,9 +34,9 @@ func TestSerializeDeserializeRegisterL0Request(t *testing.T) {
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = req.Serialize(buff)
+	buff = req.Serialize(buff, common.MetadataFormatV2)
 	var req2 RegisterL0Request
-	off := req2.Deserialize(buff, 3)
+	off := req2.Deserialize(buff, 3, common.MetadataFormatV2)
 	require.Equal(t, req, req2)
 	require.Equal(t, off, len(buff))
 }
@@ -71,9 +75,9 @@ func TestSerializeDeserializeApplyChangesRequest(t *testing.T) {
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = req.Serialize(buff)
+	buff = req.Serialize(buff, common.MetadataFormatV2)
 	var req2 ApplyChangesRequest
-	off := req2.Deserialize(buff, 3)
+	off := req2.Deserialize(buff, 3, common.MetadataFormatV2)
 	require.Equal(t, req, req2)
 	require.Equal(t, off, len(buff))
 }
@@ -132,16 +136,28 @@ func TestSerializeDeserializeRegisterTableListenerRequest(t *testing.T) {
 func TestSerializeDeserializeRegisterTableListenerResponse(t *testing.T) {
 	req := RegisterTableListenerResponse{
 		LastReadableOffset: 234234,
+		LastStableOffset:   234200,
+		LogStartOffset:     1000,
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
 	buff = req.Serialize(buff)
 	var req2 RegisterTableListenerResponse
-	off := req2.Deserialize(buff, 3)
+	off, err := req2.Deserialize(buff, 3)
+	require.NoError(t, err)
 	require.Equal(t, req, req2)
 	require.Equal(t, off, len(buff))
 }
 
+func TestDeserializeUnknownVersion(t *testing.T) {
+	resp := RegisterTableListenerResponse{LastReadableOffset: 234234}
+	buff := resp.Serialize(nil)
+	binary.BigEndian.PutUint16(buff, registerTableListenerResponseVersion+1)
+	var resp2 RegisterTableListenerResponse
+	_, err := resp2.Deserialize(buff, 0)
+	require.Error(t, err)
+}
+
 func TestSerializeDeserializeGetOffsetsRequest(t *testing.T) {
 	req := PrePushRequest{
 		LeaderVersion: 4536,
@@ -150,7 +166,11 @@ func TestSerializeDeserializeGetOffsetsRequest(t *testing.T) {
 				TopicID: 1234,
 				PartitionInfos: []offsets.GenerateOffsetPartitionInfo{
 					{PartitionID: 23, NumOffsets: 345},
-					{PartitionID: 45, NumOffsets: 455},
+					{PartitionID: 45, NumOffsets: 455, TxEvents: []offsets.TxEvent{
+						{ProducerID: 3453, Offset: 0},
+						{ProducerID: 6576, Offset: 12},
+						{ProducerID: 3453, Offset: 454, End: true},
+					}},
 					{PartitionID: 567, NumOffsets: 23},
 				},
 			},
@@ -187,7 +207,8 @@ func TestSerializeDeserializeGetOffsetsRequest(t *testing.T) {
 	buff = append(buff, 1, 2, 3)
 	buff = req.Serialize(buff)
 	var req2 PrePushRequest
-	off := req2.Deserialize(buff, 3)
+	off, err := req2.Deserialize(buff, 3)
+	require.NoError(t, err)
 	require.Equal(t, req, req2)
 	require.Equal(t, off, len(buff))
 }
@@ -203,8 +224,9 @@ func TestSerializeDeserializeGetOffsetsResponse(t *testing.T) {
 						Offset:      234234,
 					},
 					{
-						PartitionID: 345,
-						Offset:      3453454,
+						PartitionID:    345,
+						Offset:         3453454,
+						TxFirstOffsets: []int64{3453450, -1},
 					},
 				},
 			},
@@ -231,7 +253,8 @@ func TestSerializeDeserializeGetOffsetsResponse(t *testing.T) {
 	buff = append(buff, 1, 2, 3)
 	buff = resp.Serialize(buff)
 	var resp2 PrePushResponse
-	off := resp2.Deserialize(buff, 3)
+	off, err := resp2.Deserialize(buff, 3)
+	require.NoError(t, err)
 	require.Equal(t, resp, resp2)
 	require.Equal(t, off, len(buff))
 }
@@ -366,6 +389,41 @@ func TestSerializeDeserializeDeleteTopicRequest(t *testing.T) {
 	require.Equal(t, off, len(buff))
 }
 
+func TestSerializeDeserializeCreatePartitionsRequest(t *testing.T) {
+	req := CreatePartitionsRequest{
+		LeaderVersion:  123,
+		TopicName:      "some-topic",
+		PartitionCount: 23,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 CreatePartitionsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeAlterTopicConfigsRequest(t *testing.T) {
+	req := AlterTopicConfigsRequest{
+		LeaderVersion: 123,
+		TopicName:     "some-topic",
+		Alterations: []topicmeta.ConfigAlteration{
+			{Name: "retention.ms", Operation: topicmeta.ConfigOperationSet, Value: "1000"},
+			{Name: "max.message.bytes", Operation: topicmeta.ConfigOperationDelete},
+			{Name: "cleanup.policy", Operation: topicmeta.ConfigOperationAppend, Value: "delete"},
+		},
+		ValidateOnly: true,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 AlterTopicConfigsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
 func TestSerializeDeserializeGetGroupCoordinatorInfoRequest(t *testing.T) {
 	req := GetGroupCoordinatorInfoRequest{
 		LeaderVersion: 123,
@@ -405,12 +463,16 @@ func TestSerializeDeserializeTableRegisteredNotification(t *testing.T) {
 				TopicID: 1234,
 				PartitionInfos: []offsets.OffsetPartitionInfo{
 					{
-						PartitionID: 234,
-						Offset:      66788,
+						PartitionID:      234,
+						Offset:           66788,
+						LastStableOffset: 66700,
+						LogStartOffset:   1000,
 					},
 					{
-						PartitionID: 56756,
-						Offset:      23432,
+						PartitionID:      56756,
+						Offset:           23432,
+						LastStableOffset: 23432,
+						LogStartOffset:   0,
 					},
 				},
 			},
@@ -418,8 +480,10 @@ func TestSerializeDeserializeTableRegisteredNotification(t *testing.T) {
 				TopicID: 345435,
 				PartitionInfos: []offsets.OffsetPartitionInfo{
 					{
-						PartitionID: 5465,
-						Offset:      678678,
+						PartitionID:      5465,
+						Offset:           678678,
+						LastStableOffset: 678678,
+						LogStartOffset:   670000,
 					},
 				},
 			},
@@ -429,7 +493,8 @@ func TestSerializeDeserializeTableRegisteredNotification(t *testing.T) {
 	buff = append(buff, 1, 2, 3)
 	buff = notif.Serialize(buff)
 	var notif2 TablesRegisteredNotification
-	off := notif2.Deserialize(buff, 3)
+	off, err := notif2.Deserialize(buff, 3)
+	require.NoError(t, err)
 	require.Equal(t, notif, notif2)
 	require.Equal(t, off, len(buff))
 }
@@ -487,16 +552,22 @@ func TestSerializeDeserializeGetOffsetInfoResponse(t *testing.T) {
 				TopicID: 1234,
 				PartitionInfos: []offsets.OffsetPartitionInfo{
 					{
-						PartitionID: 234,
-						Offset:      42354,
+						PartitionID:      234,
+						Offset:           42354,
+						LastStableOffset: 42350,
+						LogStartOffset:   400,
 					},
 					{
-						PartitionID: 3232,
-						Offset:      424354,
+						PartitionID:      3232,
+						Offset:           424354,
+						LastStableOffset: 424354,
+						LogStartOffset:   0,
 					},
 					{
-						PartitionID: 23,
-						Offset:      34534,
+						PartitionID:      23,
+						Offset:           34534,
+						LastStableOffset: 34500,
+						LogStartOffset:   34000,
 					},
 				},
 			},
@@ -506,7 +577,318 @@ func TestSerializeDeserializeGetOffsetInfoResponse(t *testing.T) {
 	buff = append(buff, 1, 2, 3)
 	buff = req.Serialize(buff)
 	var req2 GetOffsetInfoResponse
+	off, err := req2.Deserialize(buff, 3)
+	require.NoError(t, err)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializePutUserCredentialsRequest(t *testing.T) {
+	req := PutUserCredentialsRequest{
+		LeaderVersion: 123,
+		Username:      "some-user",
+		Mechanism:     auth.AuthenticationSaslScramSha256,
+		StoredKey:     []byte("some-stored-key"),
+		ServerKey:     []byte("some-server-key"),
+		Salt:          "some-salt",
+		Iters:         4096,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 PutUserCredentialsRequest
 	off := req2.Deserialize(buff, 3)
 	require.Equal(t, req, req2)
 	require.Equal(t, off, len(buff))
 }
+
+func TestSerializeDeserializeDeleteUserCredentialsRequest(t *testing.T) {
+	req := DeleteUserCredentialsRequest{
+		LeaderVersion: 123,
+		Username:      "some-user",
+		Mechanism:     auth.AuthenticationSaslScramSha512,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 DeleteUserCredentialsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetUserCredentialsRequest(t *testing.T) {
+	req := GetUserCredentialsRequest{
+		LeaderVersion: 123,
+		Username:      "some-user",
+		Mechanism:     auth.AuthenticationSaslScramSha256,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 GetUserCredentialsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetUserCredentialsResponse(t *testing.T) {
+	resp := GetUserCredentialsResponse{
+		Exists: true,
+		Creds: auth.UserCredentials{
+			Salt:      "some-salt",
+			Iters:     4096,
+			StoredKey: []byte("some-stored-key"),
+			ServerKey: []byte("some-server-key"),
+			Sequence:  23,
+		},
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 GetUserCredentialsResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetPartitionRetentionRequest(t *testing.T) {
+	req := GetPartitionRetentionRequest{
+		LeaderVersion: 123,
+		PartitionHash: []byte("some-partition-hash"),
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 GetPartitionRetentionRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetPartitionRetentionResponse(t *testing.T) {
+	resp := GetPartitionRetentionResponse{
+		Retention: lsm.PartitionRetention{
+			Retention:       3 * time.Hour,
+			MinOffset:       12345,
+			Compacted:       true,
+			DeleteRetention: 24 * time.Hour,
+		},
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 GetPartitionRetentionResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeAlterClientQuotasRequest(t *testing.T) {
+	req := AlterClientQuotasRequest{
+		LeaderVersion: 123,
+		Alterations: []ClientQuotaAlteration{
+			{
+				Entity: ClientQuotaEntity{
+					User: QuotaEntityName{Type: QuotaEntityNameSpecific, Name: "some-user"},
+				},
+				Ops: []ClientQuotaOp{
+					{Key: QuotaKeyProducerByteRate, Value: 1024.5},
+					{Key: QuotaKeyConsumerByteRate, Remove: true},
+				},
+			},
+			{
+				Entity: ClientQuotaEntity{
+					User:     QuotaEntityName{Type: QuotaEntityNameDefault},
+					ClientID: QuotaEntityName{Type: QuotaEntityNameSpecific, Name: "some-client"},
+				},
+				Ops: []ClientQuotaOp{
+					{Key: QuotaKeyConsumerByteRate, Value: 2048},
+				},
+			},
+		},
+		ValidateOnly: true,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 AlterClientQuotasRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetClientQuotasRequest(t *testing.T) {
+	req := GetClientQuotasRequest{
+		LeaderVersion: 123,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 GetClientQuotasRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetClientQuotasResponse(t *testing.T) {
+	resp := GetClientQuotasResponse{
+		Quotas: []ClientQuotaConfig{
+			{
+				Entity: ClientQuotaEntity{
+					ClientID: QuotaEntityName{Type: QuotaEntityNameDefault},
+				},
+				Quotas: map[string]float64{QuotaKeyProducerByteRate: 1000, QuotaKeyConsumerByteRate: 2000},
+			},
+			{
+				Entity: ClientQuotaEntity{
+					User:     QuotaEntityName{Type: QuotaEntityNameSpecific, Name: "some-user"},
+					ClientID: QuotaEntityName{Type: QuotaEntityNameSpecific, Name: "some-client"},
+				},
+				Quotas: map[string]float64{QuotaKeyProducerByteRate: 3000.25},
+			},
+		},
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 GetClientQuotasResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
+
+func testAcls() []acls.AclEntry {
+	return []acls.AclEntry{
+		{
+			ResourceType: acls.ResourceTypeTopic,
+			ResourceName: "some-topic",
+			PatternType:  acls.PatternTypeLiteral,
+			Principal:    "User:some-user",
+			Host:         acls.WildcardHost,
+			Operation:    acls.OperationRead,
+			Permission:   acls.PermissionAllow,
+		},
+		{
+			ResourceType: acls.ResourceTypeGroup,
+			ResourceName: "some-group-",
+			PatternType:  acls.PatternTypePrefixed,
+			Principal:    "User:other-user",
+			Host:         "10.0.0.1",
+			Operation:    acls.OperationAll,
+			Permission:   acls.PermissionDeny,
+		},
+	}
+}
+
+func TestSerializeDeserializeCreateAclsRequest(t *testing.T) {
+	req := CreateAclsRequest{
+		LeaderVersion: 123,
+		Acls:          testAcls(),
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 CreateAclsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeDeleteAclsRequest(t *testing.T) {
+	req := DeleteAclsRequest{
+		LeaderVersion: 123,
+		Filters: []acls.AclFilter{
+			{
+				ResourceType: acls.ResourceTypeTopic,
+				ResourceName: common.StrPtr("some-topic"),
+				PatternType:  acls.PatternTypeMatch,
+				Operation:    acls.OperationAny,
+				Permission:   acls.PermissionAny,
+			},
+			{
+				ResourceType: acls.ResourceTypeAny,
+				PatternType:  acls.PatternTypeAny,
+				Principal:    common.StrPtr("User:some-user"),
+				Host:         common.StrPtr("*"),
+				Operation:    acls.OperationWrite,
+				Permission:   acls.PermissionDeny,
+			},
+		},
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 DeleteAclsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeDeleteAclsResponse(t *testing.T) {
+	resp := DeleteAclsResponse{
+		Deleted: [][]acls.AclEntry{testAcls(), {}, testAcls()[1:]},
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 DeleteAclsResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetAclsRequest(t *testing.T) {
+	req := GetAclsRequest{
+		LeaderVersion: 123,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 GetAclsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeGetAclsResponse(t *testing.T) {
+	resp := GetAclsResponse{
+		Acls: testAcls(),
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 GetAclsResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeDeleteRecordsRequest(t *testing.T) {
+	req := DeleteRecordsRequest{
+		LeaderVersion: 123,
+		TopicID:       2323,
+		PartitionID:   12,
+		Offset:        345345,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = req.Serialize(buff)
+	var req2 DeleteRecordsRequest
+	off := req2.Deserialize(buff, 3)
+	require.Equal(t, req, req2)
+	require.Equal(t, off, len(buff))
+}
+
+func TestSerializeDeserializeDeleteRecordsResponse(t *testing.T) {
+	resp := DeleteRecordsResponse{
+		LogStartOffset: 345345,
+	}
+	var buff []byte
+	buff = append(buff, 1, 2, 3)
+	buff = resp.Serialize(buff)
+	var resp2 DeleteRecordsResponse
+	off := resp2.Deserialize(buff, 3)
+	require.Equal(t, resp, resp2)
+	require.Equal(t, off, len(buff))
+}
@@ -317,8 +317,8 @@ func (l *tableAddedListener) sendNotificationNoLock(buff []byte) error {
 	// We copy the buffer and change the sequence to avoid serializing multiple times
 	copied := make([]byte, len(buff))
 	copy(copied, buff)
-	// This assumes sequence is the first member in the serialized form
-	binary.BigEndian.PutUint64(copied, uint64(l.sequence))
+	// This assumes sequence is the first member after the version in the serialized form
+	binary.BigEndian.PutUint64(copied[2:], uint64(l.sequence))
 	l.sequence++
 	l.lastSentTime = arista.NanoTime()
 	if err := conn.SendOneway(transport.HandlerIDFetcherTableRegisteredNotification, copied); err != nil {
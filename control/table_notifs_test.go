@@ -22,7 +22,7 @@ func TestSinglePartitionTableNotification(t *testing.T) {
 	defer tearDown(t)
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	// trigger a notification
@@ -57,7 +57,7 @@ func testMultiplePartitionTableNotification(t *testing.T, topicID int, partition
 	defer tearDown(t)
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(topicID, partitionID, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(topicID, partitionID, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	// trigger a notification
@@ -101,7 +101,7 @@ func TestRegisterTableListenerReturnsLRO(t *testing.T) {
 	cl, receiver, tearDown := setupAndRegisterReceiver(t)
 	defer tearDown(t)
 
-	lro, err := cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
+	lro, _, _, err := cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
 	require.NoError(t, err)
 	require.Equal(t, -1, int(lro))
 
@@ -125,7 +125,7 @@ func TestRegisterTableListenerReturnsLRO(t *testing.T) {
 	notif := receiver.getNotifications()[0]
 	verifyTableRegisteredNotification(t, 0, tableID, writtenOffs, notif)
 
-	lro, err = cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
+	lro, _, _, err = cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
 	require.NoError(t, err)
 	require.Equal(t, 124, int(lro))
 }
@@ -137,16 +137,16 @@ func TestMultipleRegistrations(t *testing.T) {
 
 	// register for more than one partition
 
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
-	_, err = cl.RegisterTableListener(1000, 2, receiver.memberID, 0)
+	_, _, _, err = cl.RegisterTableListener(1000, 2, receiver.memberID, 0)
 	require.NoError(t, err)
 
-	_, err = cl.RegisterTableListener(1001, 1, receiver.memberID, 0)
+	_, _, _, err = cl.RegisterTableListener(1001, 1, receiver.memberID, 0)
 	require.NoError(t, err)
 
-	_, err = cl.RegisterTableListener(1001, 0, receiver.memberID, 0)
+	_, _, _, err = cl.RegisterTableListener(1001, 0, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	// trigger a notification
@@ -196,7 +196,7 @@ func TestMultipleReceivers(t *testing.T) {
 	defer tearDown(t)
 
 	for _, receiver := range receivers {
-		_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+		_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 		require.NoError(t, err)
 	}
 
@@ -248,7 +248,7 @@ func TestNotRegisteredForPartition(t *testing.T) {
 	defer tearDown(t)
 
 	// register for different partition
-	_, err := cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 1, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	// trigger a notification
@@ -292,7 +292,7 @@ func TestMultipleNotifications(t *testing.T) {
 	defer tearDown(t)
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	numNotifs := 10
@@ -340,7 +340,7 @@ func TestNotificationLeaderVersion(t *testing.T) {
 	defer tearDown(t)
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	offsetInfos := []offsets.GenerateOffsetTopicInfo{
@@ -370,7 +370,7 @@ func TestInvalidateListeners(t *testing.T) {
 	cl, receivers, tearDown := setupAndRegisterReceivers(t, numReceivers)
 	defer tearDown(t)
 	for _, receiver := range receivers {
-		_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+		_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 		require.NoError(t, err)
 	}
 
@@ -415,7 +415,7 @@ func TestInvalidateListeners(t *testing.T) {
 	}
 
 	// Invalidate the first one by sending next resetSequence
-	_, err := cl.RegisterTableListener(1000, 3, receivers[0].memberID, 1)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receivers[0].memberID, 1)
 	require.NoError(t, err)
 
 	// Send another notification
@@ -490,7 +490,7 @@ func TestListenersRemovedOnMembershipChange(t *testing.T) {
 	require.NoError(t, err)
 
 	for _, receiver := range receivers {
-		_, err = cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+		_, _, _, err = cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 		require.NoError(t, err)
 	}
 
@@ -565,7 +565,7 @@ func TestPeriodicNotification(t *testing.T) {
 	receiver := receivers[0]
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	testutils.WaitUntil(t, func() (bool, error) {
@@ -585,7 +585,7 @@ func TestNotificationWithMultipleTables(t *testing.T) {
 	defer tearDown(t)
 
 	// register for notifications
-	_, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
+	_, _, _, err := cl.RegisterTableListener(1000, 3, receiver.memberID, 0)
 	require.NoError(t, err)
 
 	offsetInfos := []offsets.GenerateOffsetTopicInfo{
@@ -738,7 +738,9 @@ type notificationReceiver struct {
 func (n *notificationReceiver) receivedNotification(_ *transport.ConnectionContext, request []byte, _ []byte,
 	_ transport.ResponseWriter) error {
 	var notif TablesRegisteredNotification
-	notif.Deserialize(request, 0)
+	if _, err := notif.Deserialize(request, 0); err != nil {
+		return err
+	}
 	n.lock.Lock()
 	defer n.lock.Unlock()
 	n.received = append(n.received, notif)
@@ -0,0 +1,144 @@
+package control
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/sst"
+	"sync"
+)
+
+/*
+UserCredentials lives on the controller and persists SCRAM user credentials in the LSM. Credentials are stored per
+user and per SASL mechanism, as the stored and server keys depend on the hash function of the mechanism.
+*/
+type UserCredentials struct {
+	kvStore
+	lock sync.Mutex
+}
+
+const userCredentialsVersion uint16 = 1
+
+func NewUserCredentials(lsmHolder lsmReceiver, tableGetter sst.TableGetter, objStore objstore.Client,
+	dataBucketName string, dataFormat common.DataFormat) *UserCredentials {
+	return &UserCredentials{
+		kvStore: kvStore{
+			lsmHolder:      lsmHolder,
+			tableGetter:    tableGetter,
+			objStore:       objStore,
+			dataBucketName: dataBucketName,
+			dataFormat:     dataFormat,
+		},
+	}
+}
+
+func (u *UserCredentials) Stop() {
+	u.stopping.Store(true)
+}
+
+func (u *UserCredentials) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	if err := checkScramMechanism(mechanism); err != nil {
+		return err
+	}
+	if iters != auth.NumIters {
+		return common.NewTektiteErrorf(common.InvalidConfiguration, "invalid iterations %d - must be %d", iters,
+			auth.NumIters)
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	key, err := createUserCredentialsKey(username, mechanism)
+	if err != nil {
+		return err
+	}
+	existing, exists, err := u.lookupUserCredentials(key)
+	if err != nil {
+		return err
+	}
+	creds := auth.UserCredentials{
+		Salt:      salt,
+		Iters:     iters,
+		StoredKey: storedKey,
+		ServerKey: serverKey,
+	}
+	if exists {
+		// User already exists - this is a password update
+		creds.Sequence = existing.Sequence + 1
+	}
+	// Encode a version number before the data
+	value := binary.BigEndian.AppendUint16(nil, userCredentialsVersion)
+	value = creds.Serialize(value)
+	return u.writeKvDirect(common.KV{
+		Key:   encoding.EncodeVersion(key, 0),
+		Value: value,
+	})
+}
+
+func (u *UserCredentials) DeleteUserCredentials(username string, mechanism string) error {
+	if err := checkScramMechanism(mechanism); err != nil {
+		return err
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	key, err := createUserCredentialsKey(username, mechanism)
+	if err != nil {
+		return err
+	}
+	_, exists, err := u.lookupUserCredentials(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return common.NewTektiteErrorf(common.UserDoesNotExist, "user: %s does not have %s credentials", username,
+			mechanism)
+	}
+	// Write a tombstone (nil value)
+	return u.writeKvDirect(common.KV{
+		Key: encoding.EncodeVersion(key, 0),
+	})
+}
+
+func (u *UserCredentials) LookupUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	key, err := createUserCredentialsKey(username, mechanism)
+	if err != nil {
+		return auth.UserCredentials{}, false, err
+	}
+	return u.lookupUserCredentials(key)
+}
+
+func (u *UserCredentials) lookupUserCredentials(key []byte) (auth.UserCredentials, bool, error) {
+	value, err := u.getLatestValueWithKey(key)
+	if err != nil {
+		return auth.UserCredentials{}, false, err
+	}
+	if len(value) == 0 {
+		return auth.UserCredentials{}, false, nil
+	}
+	version := binary.BigEndian.Uint16(value)
+	if version != userCredentialsVersion {
+		return auth.UserCredentials{}, false, errors.Errorf("invalid user credentials version %d", version)
+	}
+	var creds auth.UserCredentials
+	creds.Deserialize(value, 2)
+	return creds, true, nil
+}
+
+func createUserCredentialsKey(username string, mechanism string) ([]byte, error) {
+	hash, err := parthash.CreateHash([]byte("user_creds." + mechanism + "." + username))
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, 24)
+	return append(key, hash...), nil
+}
+
+func checkScramMechanism(mechanism string) error {
+	if mechanism != auth.AuthenticationSaslScramSha256 && mechanism != auth.AuthenticationSaslScramSha512 {
+		return common.NewTektiteErrorf(common.InvalidConfiguration, "unsupported SASL mechanism %s", mechanism)
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/binary"
 	"github.com/dgraph-io/ristretto"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/consistent"
+	"github.com/spirit-labs/tektite/metrics"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/transport"
 	"sync"
@@ -240,6 +242,22 @@ func (c *Cache) GetStats() CacheStats {
 	return c.stats
 }
 
+func (c *Cache) RegisterMetrics(registry *metrics.Registry) {
+	counter := func(name string, help string, stat *int64) prometheus.Collector {
+		return metrics.NewCounterFunc("fetch_cache", name, help, func() float64 {
+			return float64(atomic.LoadInt64(stat))
+		})
+	}
+	registry.MustRegister(
+		counter("gets_total", "number of gets served by the fetch cache on this agent", &c.stats.Gets),
+		counter("hits_total", "number of gets found in the fetch cache", &c.stats.Hits),
+		counter("misses_total", "number of gets not found in the fetch cache and loaded from the object store",
+			&c.stats.Misses),
+		counter("not_found_total", "number of gets not found in the fetch cache or the object store",
+			&c.stats.NotFound),
+	)
+}
+
 func sendBytesResponse(responseWriter transport.ResponseWriter, responseBuff []byte, tableBytes []byte) error {
 	responseBuff = binary.BigEndian.AppendUint32(responseBuff, uint32(len(tableBytes)))
 	responseBuff = append(responseBuff, tableBytes...)
@@ -0,0 +1,282 @@
+package fetcher
+
+import (
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	fetchSessionInitialEpoch = 0
+	fetchSessionFinalEpoch   = -1
+	noFetchSessionID         = 0
+)
+
+/*
+fetchSessionCache implements incremental fetch sessions (KIP-227). A consumer creates a session by sending a full fetch
+with session id 0 and epoch 0. The session remembers the partitions the consumer is interested in, along with their
+fetch positions and the high watermark and last stable offset last returned to the consumer. Subsequent fetches are
+incremental - they only contain partitions which have been added or whose fetch position has changed, and partitions
+which are no longer of interest are listed in ForgottenTopicsData. The response to an incremental fetch only contains
+partitions which have changed - i.e. those with records, errors or a changed high watermark or last stable offset. For
+consumers subscribed to many partitions this avoids sending and processing the whole partition list on every poll.
+The cache holds a bounded number of sessions. When it is full, a new session can only be created by evicting a session
+which has not been used recently, or one with fewer partitions than the new session, as larger sessions benefit the most
+from incremental fetches. If no session can be evicted, the consumer falls back to full fetches.
+*/
+type fetchSessionCache struct {
+	lock            sync.Mutex
+	maxSessions     int
+	evictionTimeout time.Duration
+	sessions        map[int32]*fetchSession
+}
+
+type fetchSession struct {
+	lock       sync.Mutex
+	id         int32
+	epoch      int32
+	lastUsed   time.Time
+	partitions map[string]map[int32]*cachedPartition
+	size       int
+}
+
+type cachedPartition struct {
+	fetchOffset       int64
+	partitionMaxBytes int32
+	highWatermark     int64
+	lastStableOffset  int64
+	logStartOffset    int64
+}
+
+func newFetchSessionCache(maxSessions int, evictionTimeout time.Duration) *fetchSessionCache {
+	return &fetchSessionCache{
+		maxSessions:     maxSessions,
+		evictionTimeout: evictionTimeout,
+		sessions:        map[int32]*fetchSession{},
+	}
+}
+
+// prepareFetch applies the fetch session parameters in the request. It returns the request that should actually be
+// executed - for an incremental fetch this contains all the partitions in the session. If a session is returned, the
+// response must be passed to completeFetch before it is sent. A non-zero error code is returned if the session is
+// unknown or the epoch is not the one expected.
+func (c *fetchSessionCache) prepareFetch(req *kafkaprotocol.FetchRequest) (*kafkaprotocol.FetchRequest, *fetchSession,
+	bool, int16) {
+	if req.SessionEpoch == fetchSessionInitialEpoch || req.SessionEpoch == fetchSessionFinalEpoch {
+		// A full fetch - any existing session is closed
+		if req.SessionId != noFetchSessionID {
+			c.removeSession(req.SessionId)
+		}
+		if req.SessionEpoch == fetchSessionFinalEpoch {
+			// Sessionless fetch
+			return req, nil, true, kafkaprotocol.ErrorCodeNone
+		}
+		return req, c.createSession(req), true, kafkaprotocol.ErrorCodeNone
+	}
+	session, ok := c.getSession(req.SessionId)
+	if !ok {
+		return nil, nil, false, kafkaprotocol.ErrorCodeFetchSessionIDNotFound
+	}
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	if req.SessionEpoch != session.epoch {
+		return nil, nil, false, kafkaprotocol.ErrorCodeInvalidFetchSessionEpoch
+	}
+	session.epoch = nextFetchSessionEpoch(session.epoch)
+	session.update(req)
+	return session.createFullRequest(req), session, false, kafkaprotocol.ErrorCodeNone
+}
+
+func (c *fetchSessionCache) createSession(req *kafkaprotocol.FetchRequest) *fetchSession {
+	session := &fetchSession{
+		epoch:      nextFetchSessionEpoch(fetchSessionInitialEpoch),
+		lastUsed:   time.Now(),
+		partitions: map[string]map[int32]*cachedPartition{},
+	}
+	session.update(req)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.sessions) >= c.maxSessions && !c.maybeEvict(session.size) {
+		// The consumer will carry on with full fetches
+		return nil
+	}
+	for {
+		id := rand.Int31()
+		if _, exists := c.sessions[id]; id != noFetchSessionID && !exists {
+			session.id = id
+			break
+		}
+	}
+	c.sessions[session.id] = session
+	return session
+}
+
+// maybeEvict evicts a session to make room for a new session with the provided number of partitions. Sessions which
+// have not been used within the eviction timeout are evicted first, otherwise the smallest session is evicted if it is
+// smaller than the new session.
+func (c *fetchSessionCache) maybeEvict(newSessionSize int) bool {
+	if len(c.sessions) == 0 {
+		return false
+	}
+	now := time.Now()
+	smallestID, smallestSize := int32(noFetchSessionID), math.MaxInt
+	for id, session := range c.sessions {
+		session.lock.Lock()
+		lastUsed, size := session.lastUsed, session.size
+		session.lock.Unlock()
+		if now.Sub(lastUsed) >= c.evictionTimeout {
+			delete(c.sessions, id)
+			return true
+		}
+		if size < smallestSize {
+			smallestID, smallestSize = id, size
+		}
+	}
+	if smallestSize < newSessionSize {
+		delete(c.sessions, smallestID)
+		return true
+	}
+	return false
+}
+
+func (c *fetchSessionCache) getSession(sessionID int32) (*fetchSession, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	session, ok := c.sessions[sessionID]
+	return session, ok
+}
+
+func (c *fetchSessionCache) removeSession(sessionID int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.sessions, sessionID)
+}
+
+func (c *fetchSessionCache) numSessions() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.sessions)
+}
+
+func nextFetchSessionEpoch(epoch int32) int32 {
+	if epoch == math.MaxInt32 {
+		// wrap around, skipping the initial epoch
+		return 1
+	}
+	return epoch + 1
+}
+
+// update adds or updates the partitions in the request and removes any forgotten partitions. Must be called with the
+// session lock held.
+func (s *fetchSession) update(req *kafkaprotocol.FetchRequest) {
+	s.lastUsed = time.Now()
+	for _, topicData := range req.Topics {
+		topicName := *topicData.Topic
+		topicPartitions, ok := s.partitions[topicName]
+		if !ok {
+			topicPartitions = map[int32]*cachedPartition{}
+			s.partitions[topicName] = topicPartitions
+		}
+		for _, partitionData := range topicData.Partitions {
+			cached, ok := topicPartitions[partitionData.Partition]
+			if !ok {
+				// -1 ensures the partition is included in the next response
+				cached = &cachedPartition{highWatermark: -1, lastStableOffset: -1, logStartOffset: -1}
+				topicPartitions[partitionData.Partition] = cached
+				s.size++
+			}
+			cached.fetchOffset = partitionData.FetchOffset
+			cached.partitionMaxBytes = partitionData.PartitionMaxBytes
+		}
+	}
+	for _, forgotten := range req.ForgottenTopicsData {
+		topicName := *forgotten.Topic
+		topicPartitions, ok := s.partitions[topicName]
+		if !ok {
+			continue
+		}
+		for _, partitionID := range forgotten.Partitions {
+			if _, ok := topicPartitions[partitionID]; ok {
+				delete(topicPartitions, partitionID)
+				s.size--
+			}
+		}
+		if len(topicPartitions) == 0 {
+			delete(s.partitions, topicName)
+		}
+	}
+}
+
+// removeTopics removes the topics from the session
+func (s *fetchSession) removeTopics(topics []kafkaprotocol.FetchRequestFetchTopic) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, topic := range topics {
+		topicPartitions, ok := s.partitions[*topic.Topic]
+		if !ok {
+			continue
+		}
+		s.size -= len(topicPartitions)
+		delete(s.partitions, *topic.Topic)
+	}
+}
+
+// createFullRequest creates a request containing all the partitions in the session. Must be called with the session
+// lock held.
+func (s *fetchSession) createFullRequest(req *kafkaprotocol.FetchRequest) *kafkaprotocol.FetchRequest {
+	fullReq := *req
+	fullReq.ForgottenTopicsData = nil
+	fullReq.Topics = make([]kafkaprotocol.FetchRequestFetchTopic, 0, len(s.partitions))
+	for topicName, topicPartitions := range s.partitions {
+		name := topicName
+		partitions := make([]kafkaprotocol.FetchRequestFetchPartition, 0, len(topicPartitions))
+		for partitionID, cached := range topicPartitions {
+			partitions = append(partitions, kafkaprotocol.FetchRequestFetchPartition{
+				Partition:         partitionID,
+				FetchOffset:       cached.fetchOffset,
+				PartitionMaxBytes: cached.partitionMaxBytes,
+			})
+		}
+		fullReq.Topics = append(fullReq.Topics, kafkaprotocol.FetchRequestFetchTopic{
+			Topic:      &name,
+			Partitions: partitions,
+		})
+	}
+	return &fullReq
+}
+
+// completeFetch records the high watermark, last stable offset and log start offset returned for each partition. For an
+// incremental fetch partitions which have not changed since they were last returned are removed from the response.
+func (s *fetchSession) completeFetch(resp *kafkaprotocol.FetchResponse, full bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	resp.SessionId = s.id
+	topicResponses := resp.Responses[:0]
+	for _, topicResp := range resp.Responses {
+		topicPartitions := s.partitions[*topicResp.Topic]
+		partitionResponses := topicResp.Partitions[:0]
+		for _, partitionResp := range topicResp.Partitions {
+			cached, ok := topicPartitions[partitionResp.PartitionIndex]
+			changed := true
+			if ok {
+				changed = partitionResp.ErrorCode != kafkaprotocol.ErrorCodeNone || len(partitionResp.Records) > 0 ||
+					partitionResp.HighWatermark != cached.highWatermark ||
+					partitionResp.LastStableOffset != cached.lastStableOffset ||
+					partitionResp.LogStartOffset != cached.logStartOffset
+				cached.highWatermark = partitionResp.HighWatermark
+				cached.lastStableOffset = partitionResp.LastStableOffset
+				cached.logStartOffset = partitionResp.LogStartOffset
+			}
+			if full || changed {
+				partitionResponses = append(partitionResponses, partitionResp)
+			}
+		}
+		if len(partitionResponses) > 0 {
+			topicResp.Partitions = partitionResponses
+			topicResponses = append(topicResponses, topicResp)
+		}
+	}
+	resp.Responses = topicResponses
+}
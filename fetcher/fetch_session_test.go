@@ -0,0 +1,199 @@
+package fetcher
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestFetchSessionIncrementalFetch(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+
+	batches1, _ := setupForPartition(t, defaultTopicID, defaultTopicName, 23, 1000, 10999, 10999, 10, 2, topicProvider, controlClient, objStore)
+	setupForPartition(t, defaultTopicID, defaultTopicName, 24, 3000, 12999, 12999, 10, 2, topicProvider, controlClient, objStore)
+	batches3, _ := setupForPartition(t, defaultTopicID, defaultTopicName, 25, 7000, 16999, 16999, 10, 2, topicProvider, controlClient, objStore)
+
+	// Create the session with a full fetch
+	req := createSessionFetchRequest(0, 0, map[int32]int64{23: 3000, 24: 130000, 25: 11000})
+	resp := sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	sessionID := resp.SessionId
+	require.NotEqual(t, 0, int(sessionID))
+	verifyPartitionRecordsInResponse(t, resp, defaultTopicName, 23, batches1[2:])
+	verifyPartitionRecordsInResponse(t, resp, defaultTopicName, 24, nil)
+	verifyPartitionRecordsInResponse(t, resp, defaultTopicName, 25, batches3[4:])
+	require.Equal(t, 1, fetcher.fetchSessions.numSessions())
+
+	// Incremental fetch with the new fetch positions - nothing has changed so no partitions are returned
+	req = createSessionFetchRequest(sessionID, 1, map[int32]int64{23: 11000, 25: 17000})
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, sessionID, resp.SessionId)
+	require.Equal(t, 0, len(resp.Responses))
+
+	// Move the fetch position of one partition back - only that partition is returned
+	req = createSessionFetchRequest(sessionID, 2, map[int32]int64{23: 5000})
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, sessionID, resp.SessionId)
+	verifySinglePartitionResponse(t, resp, defaultTopicName, 23, batches1[4:])
+
+	// Stale epoch
+	req = createSessionFetchRequest(sessionID, 2, nil)
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidFetchSessionEpoch, int(resp.ErrorCode))
+
+	// Unknown session
+	req = createSessionFetchRequest(sessionID+1, 3, nil)
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeFetchSessionIDNotFound, int(resp.ErrorCode))
+
+	// Forget a partition and move the fetch position of another back - the forgotten partition is not returned
+	req = createSessionFetchRequest(sessionID, 3, map[int32]int64{25: 15000})
+	req.ForgottenTopicsData = []kafkaprotocol.FetchRequestForgottenTopic{
+		{
+			Topic:      common.StrPtr(defaultTopicName),
+			Partitions: []int32{23},
+		},
+	}
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	verifySinglePartitionResponse(t, resp, defaultTopicName, 25, batches3[8:])
+
+	// Close the session with a sessionless fetch
+	req = createSessionFetchRequest(sessionID, -1, map[int32]int64{24: 13000})
+	resp = sendFetchWithVersion(t, req, 12, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 0, int(resp.SessionId))
+	verifySinglePartitionResponse(t, resp, defaultTopicName, 24, nil)
+	require.Equal(t, 0, fetcher.fetchSessions.numSessions())
+}
+
+func TestFetchSessionNotCreatedBeforeVersion7(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+
+	batches, _ := setupForPartition(t, defaultTopicID, defaultTopicName, 23, 1000, 10999, 10999, 10, 2, topicProvider, controlClient, objStore)
+
+	req := createSessionFetchRequest(0, 0, map[int32]int64{23: 1000})
+	resp := sendFetchWithVersion(t, req, 6, fetcher)
+	require.Equal(t, 0, int(resp.SessionId))
+	verifySinglePartitionResponse(t, resp, defaultTopicName, 23, batches)
+	require.Equal(t, 0, fetcher.fetchSessions.numSessions())
+}
+
+func TestFetchSessionAuthorization(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+
+	batches, _ := setupForPartition(t, defaultTopicID, defaultTopicName, 23, 1000, 10999, 10999, 10, 2, topicProvider, controlClient, objStore)
+
+	authorized := true
+	authorizer := func(topic *string) bool {
+		require.Equal(t, defaultTopicName, *topic)
+		return authorized
+	}
+
+	req := createSessionFetchRequest(0, 0, map[int32]int64{23: 1000})
+	resp := sendFetchWithAuthorizer(t, req, 12, authorizer, fetcher)
+	sessionID := resp.SessionId
+	require.NotEqual(t, 0, int(sessionID))
+	verifySinglePartitionResponse(t, resp, defaultTopicName, 23, batches)
+
+	// Authorization is revoked - the partition is not listed in the incremental fetch but is held in the session, so
+	// it must still be authorized
+	authorized = false
+	req = createSessionFetchRequest(sessionID, 1, nil)
+	resp = sendFetchWithAuthorizer(t, req, 12, authorizer, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 1, len(resp.Responses))
+	require.Equal(t, defaultTopicName, *resp.Responses[0].Topic)
+	require.Equal(t, 1, len(resp.Responses[0].Partitions))
+	require.Equal(t, 23, int(resp.Responses[0].Partitions[0].PartitionIndex))
+	require.Equal(t, kafkaprotocol.ErrorCodeTopicAuthorizationFailed, int(resp.Responses[0].Partitions[0].ErrorCode))
+	require.Equal(t, 0, len(resp.Responses[0].Partitions[0].Records))
+
+	// The partition has been removed from the session, so isn't fetched even if authorization is granted again
+	authorized = true
+	session, ok := fetcher.fetchSessions.getSession(sessionID)
+	require.True(t, ok)
+	require.Equal(t, 0, session.size)
+	req = createSessionFetchRequest(sessionID, 2, nil)
+	resp = sendFetchWithAuthorizer(t, req, 12, authorizer, fetcher)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 0, len(resp.Responses))
+}
+
+func TestFetchSessionCacheEviction(t *testing.T) {
+	cache := newFetchSessionCache(2, time.Hour)
+
+	session1 := cache.createSession(createSessionFetchRequest(0, 0, map[int32]int64{1: 0, 2: 0}))
+	require.NotNil(t, session1)
+	session2 := cache.createSession(createSessionFetchRequest(0, 0, map[int32]int64{1: 0, 2: 0, 3: 0}))
+	require.NotNil(t, session2)
+	require.NotEqual(t, session1.id, session2.id)
+
+	// Cache is full and new session is not larger than any existing session
+	session3 := cache.createSession(createSessionFetchRequest(0, 0, map[int32]int64{1: 0, 2: 0}))
+	require.Nil(t, session3)
+	require.Equal(t, 2, cache.numSessions())
+
+	// New session is larger than the smallest session, which is evicted
+	session3 = cache.createSession(createSessionFetchRequest(0, 0, map[int32]int64{1: 0, 2: 0, 3: 0, 4: 0}))
+	require.NotNil(t, session3)
+	require.Equal(t, 2, cache.numSessions())
+	_, ok := cache.getSession(session1.id)
+	require.False(t, ok)
+
+	// Stale sessions are evicted regardless of size
+	cache.evictionTimeout = 0
+	session4 := cache.createSession(createSessionFetchRequest(0, 0, map[int32]int64{1: 0}))
+	require.NotNil(t, session4)
+	require.Equal(t, 2, cache.numSessions())
+	_, ok = cache.getSession(session4.id)
+	require.True(t, ok)
+}
+
+func createSessionFetchRequest(sessionID int32, sessionEpoch int32, fetchOffsets map[int32]int64) *kafkaprotocol.FetchRequest {
+	req := &kafkaprotocol.FetchRequest{
+		MaxBytes:     defaultMaxBytes,
+		SessionId:    sessionID,
+		SessionEpoch: sessionEpoch,
+	}
+	if len(fetchOffsets) > 0 {
+		var partitions []kafkaprotocol.FetchRequestFetchPartition
+		for partitionID, fetchOffset := range fetchOffsets {
+			partitions = append(partitions, kafkaprotocol.FetchRequestFetchPartition{
+				Partition:         partitionID,
+				FetchOffset:       fetchOffset,
+				PartitionMaxBytes: defaultMaxBytes,
+			})
+		}
+		req.Topics = []kafkaprotocol.FetchRequestFetchTopic{
+			{
+				Topic:      common.StrPtr(defaultTopicName),
+				Partitions: partitions,
+			},
+		}
+	}
+	return req
+}
+
+func sendFetchWithVersion(t *testing.T, req *kafkaprotocol.FetchRequest, apiVersion int16, fetcher *BatchFetcher) *kafkaprotocol.FetchResponse {
+	return sendFetchWithAuthorizer(t, req, apiVersion, nil, fetcher)
+}
+
+func sendFetchWithAuthorizer(t *testing.T, req *kafkaprotocol.FetchRequest, apiVersion int16,
+	authorizer TopicAuthorizer, fetcher *BatchFetcher) *kafkaprotocol.FetchResponse {
+	ch := make(chan *kafkaprotocol.FetchResponse, 1)
+	err := fetcher.HandleFetchRequest(&kafkaprotocol.RequestHeader{RequestApiVersion: apiVersion}, req, authorizer,
+		func(resp *kafkaprotocol.FetchResponse) error {
+			ch <- resp
+			return nil
+		})
+	require.NoError(t, err)
+	return <-ch
+}
@@ -1,6 +1,7 @@
 package fetcher
 
 import (
+	"fmt"
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/iteration"
@@ -8,6 +9,7 @@ import (
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/queryutils"
 	"github.com/spirit-labs/tektite/sst"
 	"math"
@@ -41,6 +43,7 @@ func newFetchState(batchFetcher *BatchFetcher, req *kafkaprotocol.FetchRequest,
 	}
 	fetchState.resp.Responses = make([]kafkaprotocol.FetchResponseFetchableTopicResponse, len(fetchState.req.Topics))
 	recentTables := &fetchState.bf.recentTables
+	rackAgents := batchFetcher.getAgentsForRack(req.RackId)
 	for i, topicData := range fetchState.req.Topics {
 		fetchState.resp.Responses[i].Topic = topicData.Topic
 		partitionResponses := make([]kafkaprotocol.FetchResponsePartitionData, len(topicData.Partitions))
@@ -62,6 +65,10 @@ func newFetchState(batchFetcher *BatchFetcher, req *kafkaprotocol.FetchRequest,
 		for j, partitionData := range topicData.Partitions {
 			partitionResponses[j].PartitionIndex = partitionData.Partition
 			partitionResponses[j].Records = [][]byte{} // client does not like nil records
+			setPartitionResponseDefaults(&partitionResponses[j])
+			if req.IsolationLevel == isolationLevelReadCommitted {
+				partitionResponses[j].AbortedTransactions = []kafkaprotocol.FetchResponseAbortedTransaction{}
+			}
 			partitionID := int(partitionData.Partition)
 			if !topicExists {
 				partitionResponses[j].ErrorCode = int16(kafkaprotocol.ErrorCodeUnknownTopicOrPartition)
@@ -72,6 +79,14 @@ func newFetchState(batchFetcher *BatchFetcher, req *kafkaprotocol.FetchRequest,
 				if err != nil {
 					return nil, err
 				}
+				if len(rackAgents) > 0 {
+					// The consumer is in a different rack - we don't return any data, and instead direct the consumer
+					// to fetch from the agent for the partition in its own rack
+					partitionResponses[j].PreferredReadReplica = rackAgents[common.CalcMemberForHash(partHash, len(rackAgents))]
+					partitionResponses[j].HighWatermark = -1
+					partitionResponses[j].LastStableOffset = -1
+					continue
+				}
 				topicPartitionFetchStates[partitionID] = &PartitionFetchState{
 					fs:                 fetchState,
 					partitionFetchReq:  &partitionData,
@@ -84,10 +99,28 @@ func newFetchState(batchFetcher *BatchFetcher, req *kafkaprotocol.FetchRequest,
 				}
 			}
 		}
+		if topicExists && len(topicPartitionFetchStates) == 0 {
+			// nothing to fetch for the topic, e.g. the consumer has been directed to another agent
+			delete(fetchState.partitionStates, topicInfo.ID)
+		}
 	}
 	return fetchState, nil
 }
 
+// setPartitionResponseDefaults sets the fields that we do not support to their "unknown" values. A zero value would be
+// misinterpreted by clients, e.g. a PreferredReadReplica of zero would direct the client to fetch from node 0.
+// DivergingEpoch, CurrentLeader and SnapshotId are tagged fields which are always written in flexible versions.
+func setPartitionResponseDefaults(partitionResp *kafkaprotocol.FetchResponsePartitionData) {
+	partitionResp.LogStartOffset = -1
+	partitionResp.PreferredReadReplica = -1
+	partitionResp.DivergingEpoch.Epoch = -1
+	partitionResp.DivergingEpoch.EndOffset = -1
+	partitionResp.CurrentLeader.LeaderId = -1
+	partitionResp.CurrentLeader.LeaderEpoch = -1
+	partitionResp.SnapshotId.EndOffset = -1
+	partitionResp.SnapshotId.Epoch = -1
+}
+
 // We read async on notifications to avoid blocking the transport thread that provides the notification and so we can
 // parallelise sending multiple responses and fetching from distributed cache
 func (f *FetchState) readAsync() {
@@ -110,11 +143,8 @@ outer:
 			wouldExceedRequestMax, wouldExceedPartitionMax, err = partitionFetchState.read()
 			if err != nil {
 				log.Warnf("failed to fetch from partition %v", err)
-				if common.IsUnavailableError(err) {
-					partitionFetchState.partitionFetchResp.ErrorCode = kafkaprotocol.ErrorCodeLeaderNotAvailable
-				} else {
-					partitionFetchState.partitionFetchResp.ErrorCode = kafkaprotocol.ErrorCodeUnknownServerError
-				}
+				partitionFetchState.partitionFetchResp.ErrorCode =
+					int16(kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeLeaderNotAvailable))
 			}
 			if wouldExceedRequestMax {
 				break outer
@@ -163,6 +193,9 @@ func (f *FetchState) clearFetchedRecords() {
 	for i := 0; i < len(f.resp.Responses); i++ {
 		for j := 0; j < len(f.resp.Responses[i].Partitions); j++ {
 			f.resp.Responses[i].Partitions[j].Records = [][]byte{}
+			if f.resp.Responses[i].Partitions[j].AbortedTransactions != nil {
+				f.resp.Responses[i].Partitions[j].AbortedTransactions = []kafkaprotocol.FetchResponseAbortedTransaction{}
+			}
 		}
 	}
 }
@@ -212,6 +245,8 @@ type PartitionFetchState struct {
 	listening          bool
 }
 
+const isolationLevelReadCommitted = 1
+
 func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPartitionMax bool, err error) {
 	memberID := atomic.LoadInt32(&p.fs.bf.memberID)
 	if memberID == -1 {
@@ -219,18 +254,20 @@ func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPar
 			common.NewTektiteErrorf(common.Unavailable, "fetch before fetcher has received cluster state")
 	}
 	var iter iteration.Iterator
+	var highestReadableOffset int64
 	for {
 		if !p.listening {
 			p.partitionTables.addListener(p)
 			p.listening = true
 		}
-		tabIds, lastReadableOffset, initialised, isInCachedRange := p.partitionTables.maybeGetRecentTableIDs(p.fetchOffset)
+		tabIds, lastReadableOffset, lastStableOffset, logStartOffset, initialised, isInCachedRange :=
+			p.partitionTables.maybeGetRecentTableIDs(p.fetchOffset)
 		if !initialised {
 			// initialise it - call initialise passing in function for Fetch to prevent race, as executed under
 			// partition tables lock
 			cl, err := p.fs.bf.getClient()
 			var alreadyInitialised bool
-			lastReadableOffset, alreadyInitialised, err = p.partitionTables.initialise(func() (int64, error) {
+			lastReadableOffset, lastStableOffset, logStartOffset, alreadyInitialised, err = p.partitionTables.initialise(func() (int64, int64, int64, error) {
 				return cl.RegisterTableListener(p.topicID, p.partitionID, p.fs.bf.memberID, atomic.LoadInt64(&p.fs.bf.resetSequence))
 			})
 			if err != nil {
@@ -241,6 +278,28 @@ func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPar
 				continue
 			}
 		}
+		p.partitionFetchResp.HighWatermark = lastReadableOffset + 1
+		p.partitionFetchResp.LastStableOffset = lastStableOffset + 1
+		p.partitionFetchResp.LogStartOffset = logStartOffset
+		if p.fetchOffset < logStartOffset {
+			// The records have been deleted
+			return false, false, &kafkaencoding.KafkaError{ErrorCode: kafkaprotocol.ErrorCodeOffsetOutOfRange,
+				ErrorMsg: fmt.Sprintf("fetch offset %d is before log start offset %d", p.fetchOffset, logStartOffset)}
+		}
+		highestReadableOffset = lastReadableOffset
+		if p.isReadCommitted() {
+			// Consumers with read_committed isolation level can only read up to the last stable offset. This ensures
+			// they never receive data from transactions that have not yet been committed or aborted
+			lastReadableOffset = lastStableOffset
+		}
+		iterStartOffset := p.fetchOffset
+		if p.fetchOffset == logStartOffset && logStartOffset > 0 {
+			// When records are deleted the batch containing the log start offset is retained, but it is keyed by its
+			// base offset, so we must search from the start of the partition. Earlier batches have been deleted or
+			// are skipped below
+			iterStartOffset = 0
+			isInCachedRange = false
+		}
 		if isInCachedRange {
 			iter, err = p.createIteratorFromTabIDs(tabIds, p.fetchOffset, lastReadableOffset)
 			if err != nil {
@@ -252,8 +311,12 @@ func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPar
 			if err != nil {
 				return false, false, err
 			}
-			keyStart, keyEnd := p.createKeyStartAndEnd(p.fetchOffset, lastReadableOffset)
-			iter, err = queryutils.CreateIteratorForKeyRange(keyStart, keyEnd, cl, p.fs.bf.tableGetter)
+			keyStart, keyEnd := p.createKeyStartAndEnd(iterStartOffset, lastReadableOffset)
+			// Older data is unlikely to be shared between consumers, so rather than loading whole tables through the
+			// fetch cache we read just the blocks of the tables that contain the partition's data. Tables without a
+			// block index are still loaded through the fetch cache
+			iter, err = queryutils.CreateRangedIteratorForKeyRange(keyStart, keyEnd, cl, p.fs.bf.getTableIndex,
+				p.fs.bf.getTableRange, p.fs.bf.getTableFromCache)
 			if err != nil {
 				return false, false, err
 			}
@@ -269,6 +332,9 @@ func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPar
 		if !ok {
 			break
 		}
+		if kafkaencoding.BaseOffset(kv.Value)+int64(kafkaencoding.LastOffsetDelta(kv.Value)) < p.fetchOffset {
+			continue
+		}
 		batchSize := len(kv.Value)
 		if !p.fs.first {
 			if p.bytesFetched+batchSize > int(p.partitionFetchReq.PartitionMaxBytes) {
@@ -286,14 +352,81 @@ func (p *PartitionFetchState) read() (wouldExceedRequestMax bool, wouldExceedPar
 		p.fs.first = false
 		p.bytesFetched += batchSize
 		p.fs.bytesFetched += batchSize
-		p.fetchOffset += int64(kafkaencoding.NumRecords(kv.Value))
+		// Batches in compacted topics can have fewer records than offsets, so we use the last offset of the batch
+		p.fetchOffset = kafkaencoding.BaseOffset(kv.Value) + int64(kafkaencoding.LastOffsetDelta(kv.Value)) + 1
 	}
 	if len(batches) > 0 {
 		p.partitionFetchResp.Records = append(p.partitionFetchResp.Records, batches...)
 	}
+	if p.isReadCommitted() && len(batches) > 0 {
+		if err := p.addAbortedTransactions(batches, highestReadableOffset); err != nil {
+			return false, false, err
+		}
+	}
 	return
 }
 
+func (p *PartitionFetchState) isReadCommitted() bool {
+	return p.fs.req.IsolationLevel == isolationLevelReadCommitted
+}
+
+// addAbortedTransactions adds any aborted transactions which overlap the batches being returned to a read_committed
+// consumer, so it can discard their data. The aborted transactions are looked up in the index written along with the
+// abort markers, so they are found even when the abort marker is not returned, as the response is full or the marker is
+// after the last stable offset. The marker can't be after lastReadableOffset, as the transaction would still be open.
+func (p *PartitionFetchState) addAbortedTransactions(batches [][]byte, lastReadableOffset int64) error {
+	hasTransactionalData := false
+	for _, batch := range batches {
+		if kafkaencoding.IsTransactional(batch) && !kafkaencoding.IsControlBatch(batch) {
+			hasTransactionalData = true
+			break
+		}
+	}
+	if !hasTransactionalData {
+		return nil
+	}
+	firstOffset := kafkaencoding.BaseOffset(batches[0])
+	lastOffset := p.fetchOffset - 1
+	cl, err := p.fs.bf.getClient()
+	if err != nil {
+		return err
+	}
+	keyStart, keyEnd := offsets.CreateAbortedTransactionsKeyRange(p.partitionHash, firstOffset, lastReadableOffset)
+	iter, err := queryutils.CreateIteratorForKeyRange(keyStart, keyEnd, cl, p.fs.bf.getTableFromCache)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		abortedTx := offsets.DecodeAbortedTransaction(kv)
+		if abortedTx.FirstOffset > lastOffset || p.hasAbortedTransaction(abortedTx) {
+			continue
+		}
+		p.partitionFetchResp.AbortedTransactions = append(p.partitionFetchResp.AbortedTransactions,
+			kafkaprotocol.FetchResponseAbortedTransaction{
+				ProducerId:  abortedTx.ProducerID,
+				FirstOffset: abortedTx.FirstOffset,
+			})
+	}
+}
+
+// hasAbortedTransaction returns true if the aborted transaction was added to the response by an earlier read
+func (p *PartitionFetchState) hasAbortedTransaction(abortedTx offsets.AbortedTransaction) bool {
+	for _, existing := range p.partitionFetchResp.AbortedTransactions {
+		if existing.ProducerId == abortedTx.ProducerID && existing.FirstOffset == abortedTx.FirstOffset {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *PartitionFetchState) createKeyStartAndEnd(fetchOffset int64, lro int64) ([]byte, []byte) {
 	keyStart := make([]byte, 0, 25)
 	keyStart = append(keyStart, p.partitionHash...)
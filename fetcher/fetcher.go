@@ -2,6 +2,7 @@ package fetcher
 
 import (
 	"github.com/spirit-labs/tektite/cluster"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
@@ -12,6 +13,7 @@ import (
 	"github.com/spirit-labs/tektite/transport"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 /*
@@ -47,9 +49,14 @@ type BatchFetcher struct {
 	dataBucketName     string
 	readExecs          []readExecutor
 	localCache         *LocalSSTCache
+	indexCache         *LocalSSTCache
+	fetchSessions      *fetchSessionCache
 	execAssignPos      int64
 	resetSequence      int64
 	memberID           int32
+	locationLock       sync.RWMutex
+	location           string
+	agentsByLocation   map[string][]int32
 }
 
 func NewBatchFetcher(objStore objstore.Client, topicProvider topicInfoProvider, partitionHashes *parthash.PartitionHashes,
@@ -58,6 +65,10 @@ func NewBatchFetcher(objStore objstore.Client, topicProvider topicInfoProvider,
 	if err != nil {
 		return nil, err
 	}
+	indexCache, err := NewLocalSSTCache(cfg.TableIndexCacheNumEntries, cfg.TableIndexCacheMaxBytes)
+	if err != nil {
+		return nil, err
+	}
 	bf := &BatchFetcher{
 		objStore:           objStore,
 		topicProvider:      topicProvider,
@@ -66,6 +77,8 @@ func NewBatchFetcher(objStore objstore.Client, topicProvider topicInfoProvider,
 		tableGetter:        tableGetter,
 		readExecs:          make([]readExecutor, cfg.NumReadExecutors),
 		localCache:         localCache,
+		indexCache:         indexCache,
+		fetchSessions:      newFetchSessionCache(cfg.MaxFetchSessions, cfg.FetchSessionEvictionTimeout),
 		dataBucketName:     cfg.DataBucketName,
 		memberID:           -1,
 	}
@@ -79,6 +92,10 @@ type Conf struct {
 	NumReadExecutors            int
 	LocalCacheNumEntries        int
 	LocalCacheMaxBytes          int
+	TableIndexCacheNumEntries   int
+	TableIndexCacheMaxBytes     int
+	MaxFetchSessions            int
+	FetchSessionEvictionTimeout time.Duration
 }
 
 func NewConf() Conf {
@@ -88,6 +105,10 @@ func NewConf() Conf {
 		NumReadExecutors:            DefaultNumReadExecutors,
 		LocalCacheNumEntries:        DefaultLocalCacheNumEntries,
 		LocalCacheMaxBytes:          DefaultLocalCacheMaxBytes,
+		TableIndexCacheNumEntries:   DefaultTableIndexCacheNumEntries,
+		TableIndexCacheMaxBytes:     DefaultTableIndexCacheMaxBytes,
+		MaxFetchSessions:            DefaultMaxFetchSessions,
+		FetchSessionEvictionTimeout: DefaultFetchSessionEvictionTimeout,
 	}
 }
 
@@ -101,6 +122,10 @@ const (
 	DefaultNumReadExecutors            = 8
 	DefaultLocalCacheNumEntries        = 10
 	DefaultLocalCacheMaxBytes          = 128 * 1024 * 1024 // 128MiB
+	DefaultTableIndexCacheNumEntries   = 1000
+	DefaultTableIndexCacheMaxBytes     = 32 * 1024 * 1024 // 32MiB
+	DefaultMaxFetchSessions            = 1000
+	DefaultFetchSessionEvictionTimeout = 2 * time.Minute
 	readExecChannelSize                = 10
 )
 
@@ -126,12 +151,52 @@ func (b *BatchFetcher) Stop() error {
 func (b *BatchFetcher) HandleTableRegisteredNotification(_ *transport.ConnectionContext, request []byte,
 	_ []byte, _ transport.ResponseWriter) error {
 	notif := &control.TablesRegisteredNotification{}
-	notif.Deserialize(request, 0)
+	if _, err := notif.Deserialize(request, 0); err != nil {
+		return err
+	}
 	return b.recentTables.handleTableRegisteredNotification(notif)
 }
 
-func (b *BatchFetcher) HandleFetchRequest(req *kafkaprotocol.FetchRequest,
-	completionFunc func(resp *kafkaprotocol.FetchResponse) error) error {
+// TopicAuthorizer returns true if the topic can be read from
+type TopicAuthorizer func(topic *string) bool
+
+// HandleFetchRequest handles a fetch request. The authorizer, if not nil, is applied to every partition that will be
+// fetched, including partitions that are held in an incremental fetch session but not listed in the request.
+func (b *BatchFetcher) HandleFetchRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.FetchRequest,
+	authorizer TopicAuthorizer, completionFunc func(resp *kafkaprotocol.FetchResponse) error) error {
+	var session *fetchSession
+	if hdr.RequestApiVersion >= 7 {
+		// Fetch sessions are supported in version 7 and higher
+		fetchReq, sess, full, errCode := b.fetchSessions.prepareFetch(req)
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			return completionFunc(&kafkaprotocol.FetchResponse{ErrorCode: errCode})
+		}
+		req = fetchReq
+		session = sess
+		if session != nil {
+			sessionCompletionFunc := completionFunc
+			completionFunc = func(resp *kafkaprotocol.FetchResponse) error {
+				session.completeFetch(resp, full)
+				return sessionCompletionFunc(resp)
+			}
+		}
+	}
+	if authorizer != nil {
+		authorizedReq, unauthorized := authorizeFetchRequest(req, authorizer)
+		if len(unauthorized) > 0 {
+			req = authorizedReq
+			if session != nil {
+				// Authorization may have been revoked since the partitions were added to the session, so they are
+				// removed, otherwise they would be served on every subsequent incremental fetch
+				session.removeTopics(unauthorized)
+			}
+			authCompletionFunc := completionFunc
+			completionFunc = func(resp *kafkaprotocol.FetchResponse) error {
+				resp.Responses = append(resp.Responses, authorizationFailedResponses(unauthorized)...)
+				return authCompletionFunc(resp)
+			}
+		}
+	}
 	pos := atomic.AddInt64(&b.execAssignPos, 1)
 	readExec := &b.readExecs[pos%int64(len(b.readExecs))]
 	// No need to shuffle partitions as golang map has non-deterministic iteration order - this ensures we don't have
@@ -147,6 +212,46 @@ func (b *BatchFetcher) HandleFetchRequest(req *kafkaprotocol.FetchRequest,
 	return fetchState.read()
 }
 
+// authorizeFetchRequest returns a copy of the request containing only the authorized topics, along with the topics which
+// are not authorized
+func authorizeFetchRequest(req *kafkaprotocol.FetchRequest,
+	authorizer TopicAuthorizer) (*kafkaprotocol.FetchRequest, []kafkaprotocol.FetchRequestFetchTopic) {
+	var authorized, unauthorized []kafkaprotocol.FetchRequestFetchTopic
+	for _, topic := range req.Topics {
+		if authorizer(topic.Topic) {
+			authorized = append(authorized, topic)
+		} else {
+			unauthorized = append(unauthorized, topic)
+		}
+	}
+	if len(unauthorized) == 0 {
+		return req, nil
+	}
+	authorizedReq := *req
+	authorizedReq.Topics = authorized
+	return &authorizedReq, unauthorized
+}
+
+func authorizationFailedResponses(topics []kafkaprotocol.FetchRequestFetchTopic) []kafkaprotocol.FetchResponseFetchableTopicResponse {
+	responses := make([]kafkaprotocol.FetchResponseFetchableTopicResponse, len(topics))
+	for i, topic := range topics {
+		responses[i].Topic = topic.Topic
+		responses[i].Partitions = make([]kafkaprotocol.FetchResponsePartitionData, len(topic.Partitions))
+		for j, partition := range topic.Partitions {
+			responses[i].Partitions[j] = kafkaprotocol.FetchResponsePartitionData{
+				PartitionIndex:       partition.Partition,
+				ErrorCode:            kafkaprotocol.ErrorCodeTopicAuthorizationFailed,
+				HighWatermark:        -1,
+				LastStableOffset:     -1,
+				LogStartOffset:       -1,
+				PreferredReadReplica: -1,
+				Records:              [][]byte{},
+			}
+		}
+	}
+	return responses
+}
+
 func (b *BatchFetcher) getTableFromCache(tableID sst.SSTableID) (*sst.SSTable, error) {
 	// First look in local cache
 	table, ok := b.localCache.Get(tableID)
@@ -163,6 +268,26 @@ func (b *BatchFetcher) getTableFromCache(tableID sst.SSTableID) (*sst.SSTable, e
 	return table, nil
 }
 
+// getTableIndex gets the index of a table, which is cached, so historic fetches only need to read the blocks they need
+// from the object store
+func (b *BatchFetcher) getTableIndex(tableID sst.SSTableID) (*sst.SSTable, error) {
+	index, ok := b.indexCache.Get(tableID)
+	if ok {
+		return index, nil
+	}
+	index, err := sst.ReadTableIndex(tableID, b.getTableRange)
+	if err != nil {
+		return nil, err
+	}
+	b.indexCache.Put(tableID, index)
+	return index, nil
+}
+
+func (b *BatchFetcher) getTableRange(tableID sst.SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	return objstore.GetRangeWithTimeout(b.objStore, b.dataBucketName, string(tableID), rangeStart, rangeEnd,
+		objstore.DefaultCallTimeout)
+}
+
 func (b *BatchFetcher) getClient() (control.Client, error) {
 	return b.controlClientCache.GetClient()
 }
@@ -170,9 +295,44 @@ func (b *BatchFetcher) getClient() (control.Client, error) {
 func (b *BatchFetcher) MembershipChanged(thisMemberID int32, membership cluster.MembershipState) error {
 	atomic.StoreInt32(&b.memberID, thisMemberID)
 	b.recentTables.membershipChanged(membership)
+	b.updateLocations(thisMemberID, membership)
 	return nil
 }
 
+// updateLocations records the agents in each location (AZ), in membership order. This is the same order that agents
+// are listed in cluster metadata, so we choose the same agent to serve a partition in a location as the metadata does.
+func (b *BatchFetcher) updateLocations(thisMemberID int32, membership cluster.MembershipState) {
+	var location string
+	agentsByLocation := map[string][]int32{}
+	for _, member := range membership.Members {
+		var memberData common.MembershipData
+		memberData.Deserialize(member.Data, 0)
+		agentsByLocation[memberData.Location] = append(agentsByLocation[memberData.Location], member.ID)
+		if member.ID == thisMemberID {
+			location = memberData.Location
+		}
+	}
+	b.locationLock.Lock()
+	defer b.locationLock.Unlock()
+	b.location = location
+	b.agentsByLocation = agentsByLocation
+}
+
+// getAgentsForRack returns the agents that a consumer in the provided rack should fetch from, if the consumer is not in
+// the same location as this agent. The consumer's rack (client.rack) is matched against the agents' locations, so
+// consumers are steered to agents in their own AZ, which read through the fetch cache in that AZ.
+func (b *BatchFetcher) getAgentsForRack(rackID *string) []int32 {
+	if rackID == nil || *rackID == "" {
+		return nil
+	}
+	b.locationLock.RLock()
+	defer b.locationLock.RUnlock()
+	if *rackID == b.location {
+		return nil
+	}
+	return b.agentsByLocation[*rackID]
+}
+
 type readExecutor struct {
 	lock    sync.Mutex
 	stopped bool
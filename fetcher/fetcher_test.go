@@ -3,11 +3,16 @@ package fetcher
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/acls"
 	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
 	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/kafkaencoding"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
@@ -17,6 +22,7 @@ import (
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/testutils"
 	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/spirit-labs/tektite/types"
 	"github.com/stretchr/testify/require"
 	"math"
 	"sort"
@@ -1234,7 +1240,7 @@ func TestFetcherRequestNotEnoughBytesAndNotificationAddsSufficientData(t *testin
 	}
 	var completionCalled atomic.Bool
 	resCh := make(chan *kafkaprotocol.FetchResponse, 1)
-	err := fetcher.HandleFetchRequest(&req, func(resp *kafkaprotocol.FetchResponse) error {
+	err := fetcher.HandleFetchRequest(&kafkaprotocol.RequestHeader{}, &req, nil, func(resp *kafkaprotocol.FetchResponse) error {
 		completionCalled.Store(true)
 		resCh <- resp
 		return nil
@@ -1302,7 +1308,7 @@ func TestFetcherRequestNotEnoughBytesAndNotificationsDontAddSufficientData(t *te
 
 	var completionCalled atomic.Bool
 	resCh := make(chan *kafkaprotocol.FetchResponse, 1)
-	err := fetcher.HandleFetchRequest(&req, func(resp *kafkaprotocol.FetchResponse) error {
+	err := fetcher.HandleFetchRequest(&kafkaprotocol.RequestHeader{}, &req, nil, func(resp *kafkaprotocol.FetchResponse) error {
 		completionCalled.Store(true)
 		resCh <- resp
 		return nil
@@ -1885,6 +1891,117 @@ func TestFetcherControllerUnexpectedErrorSinglePartition(t *testing.T) {
 	require.Equal(t, kafkaprotocol.ErrorCodeUnknownServerError, int(partResp.ErrorCode))
 }
 
+func TestFetcherFetchBeforeLogStartOffset(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+
+	batches, _ := setupDataDefault(t, 0, 9999, 9999, 10, 2, topicProvider, controlClient, objStore)
+	controlClient.setLogStartOffset(defaultTopicID, defaultPartitionID, 3500)
+
+	// The batch containing the log start offset is returned
+	resp := sendFetchDefault(t, 3500, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches[3:])
+	require.Equal(t, 3500, int(resp.Responses[0].Partitions[0].LogStartOffset))
+
+	resp = sendFetchDefault(t, 3499, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	partResp := resp.Responses[0].Partitions[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeOffsetOutOfRange, int(partResp.ErrorCode))
+	require.Equal(t, 0, len(partResp.Records))
+	require.Equal(t, 3500, int(partResp.LogStartOffset))
+	require.Equal(t, 10000, int(partResp.HighWatermark))
+
+	// Records are deleted - a notification with no tables advances the log start offset
+	err := fetcher.recentTables.handleTableRegisteredNotification(&control.TablesRegisteredNotification{
+		LeaderVersion: 1,
+		TableIDs:      []sst.SSTableID{},
+		Sequence:      0,
+		Infos: []offsets.OffsetTopicInfo{
+			{
+				TopicID: defaultTopicID,
+				PartitionInfos: []offsets.OffsetPartitionInfo{
+					{
+						PartitionID:      defaultPartitionID,
+						Offset:           9999,
+						LastStableOffset: 9999,
+						LogStartOffset:   6000,
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	resp = sendFetchDefault(t, 5000, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	partResp = resp.Responses[0].Partitions[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeOffsetOutOfRange, int(partResp.ErrorCode))
+	require.Equal(t, 6000, int(partResp.LogStartOffset))
+	require.Equal(t, 10000, int(partResp.HighWatermark))
+
+	resp = sendFetchDefault(t, 6000, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches[6:])
+}
+
+func TestFetcherPreferredReadReplica(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+	batches, _ := setupDataDefault(t, 100, 10000, 10000, 1, 1, topicProvider, controlClient, objStore)
+
+	// This agent is in az1, and there are two agents in az2
+	var members []cluster.MembershipEntry
+	for i, location := range []string{"az1", "az2", "az1", "az2"} {
+		memberData := common.MembershipData{Location: location}
+		members = append(members, cluster.MembershipEntry{ID: int32(i), Data: memberData.Serialize(nil)})
+	}
+	err := fetcher.MembershipChanged(0, cluster.MembershipState{
+		LeaderVersion:  1,
+		ClusterVersion: 2,
+		Members:        members,
+	})
+	require.NoError(t, err)
+
+	sendFetchWithRack := func(rackID string, minBytes int) *kafkaprotocol.FetchResponse {
+		req := kafkaprotocol.FetchRequest{
+			MaxWaitMs: 10000,
+			MinBytes:  int32(minBytes),
+			MaxBytes:  int32(defaultMaxBytes),
+			Topics: []kafkaprotocol.FetchRequestFetchTopic{
+				{
+					Topic: common.StrPtr(defaultTopicName),
+					Partitions: []kafkaprotocol.FetchRequestFetchPartition{
+						{
+							Partition:         int32(defaultPartitionID),
+							FetchOffset:       0,
+							PartitionMaxBytes: int32(defaultMaxBytes),
+						},
+					},
+				},
+			},
+			RackId: common.StrPtr(rackID),
+		}
+		return sendFetch(t, &req, fetcher)
+	}
+
+	// Consumer in another AZ is directed to the agent for the partition in its AZ, without being sent any data
+	// MinBytes can't be reached, so the fetch would wait if the consumer was not directed elsewhere
+	resp := sendFetchWithRack("az2", math.MaxInt32)
+	require.Equal(t, 1, len(resp.Responses))
+	require.Equal(t, 1, len(resp.Responses[0].Partitions))
+	partResp := resp.Responses[0].Partitions[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(partResp.ErrorCode))
+	partHash, err := parthash.CreatePartitionHash(defaultTopicID, defaultPartitionID)
+	require.NoError(t, err)
+	expectedReplica := []int32{1, 3}[common.CalcMemberForHash(partHash, 2)]
+	require.Equal(t, expectedReplica, partResp.PreferredReadReplica)
+	require.Equal(t, 0, len(partResp.Records))
+
+	// Consumer in the same AZ, or an AZ with no agents, is served by this agent
+	for _, rackID := range []string{"az1", "az3"} {
+		resp = sendFetchWithRack(rackID, 0)
+		partResp = resp.Responses[0].Partitions[0]
+		require.Equal(t, -1, int(partResp.PreferredReadReplica))
+		require.Equal(t, batches, partResp.Records)
+	}
+}
+
 func TestFetcherErrorUnknownTopic(t *testing.T) {
 	fetcher, _, _, _ := setupFetcher(t)
 	defer stopFetcher(t, fetcher)
@@ -1987,6 +2104,271 @@ func TestFetcherTableNotificationWithMultipleTables(t *testing.T) {
 	verifyDefaultResponse(t, resp, batches)
 }
 
+func TestFetcherReadCommittedCappedAtLastStableOffset(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+	batches, _ := setupDataDefault(t, 0, 9999, 9999, 10, 2, topicProvider, controlClient, objStore)
+	controlClient.setLastStableOffset(defaultTopicID, defaultPartitionID, 4999)
+
+	// read_committed should only receive batches up to the last stable offset
+	resp := sendFetchWithIsolationLevel(t, 0, defaultMaxBytes, 1, fetcher)
+	verifyDefaultResponse(t, resp, batches[:5])
+	partitionResp := resp.Responses[0].Partitions[0]
+	require.Equal(t, 10000, int(partitionResp.HighWatermark))
+	require.Equal(t, 5000, int(partitionResp.LastStableOffset))
+	require.NotNil(t, partitionResp.AbortedTransactions)
+	require.Equal(t, 0, len(partitionResp.AbortedTransactions))
+
+	// read_uncommitted should receive everything
+	resp = sendFetchWithIsolationLevel(t, 0, defaultMaxBytes, 0, fetcher)
+	verifyDefaultResponse(t, resp, batches)
+	partitionResp = resp.Responses[0].Partitions[0]
+	require.Equal(t, 10000, int(partitionResp.HighWatermark))
+	require.Equal(t, 5000, int(partitionResp.LastStableOffset))
+	require.Nil(t, partitionResp.AbortedTransactions)
+}
+
+func TestFetcherReadCommittedAbortedTransactions(t *testing.T) {
+	fetcher, batches := setupFetcherWithTransactions(t)
+	defer stopFetcher(t, fetcher)
+
+	resp := sendFetchWithIsolationLevel(t, 0, defaultMaxBytes, 1, fetcher)
+	verifyDefaultResponse(t, resp, batches)
+	require.Equal(t, []kafkaprotocol.FetchResponseAbortedTransaction{
+		{ProducerId: 7, FirstOffset: 10},
+		{ProducerId: 7, FirstOffset: 32},
+	}, resp.Responses[0].Partitions[0].AbortedTransactions)
+
+	// Fetching from after the start of the first transaction should not return it
+	resp = sendFetchWithIsolationLevel(t, 31, defaultMaxBytes, 1, fetcher)
+	verifyDefaultResponse(t, resp, batches[4:])
+	require.Equal(t, []kafkaprotocol.FetchResponseAbortedTransaction{
+		{ProducerId: 7, FirstOffset: 32},
+	}, resp.Responses[0].Partitions[0].AbortedTransactions)
+}
+
+func TestFetcherReadCommittedAbortedTransactionsMarkerNotInResponse(t *testing.T) {
+	fetcher, batches := setupFetcherWithTransactions(t)
+	defer stopFetcher(t, fetcher)
+
+	// Only room for the first two batches, so the abort marker is not returned, but the aborted transaction must still
+	// be in the response
+	maxBytes := len(batches[0]) + len(batches[1])
+	resp := sendFetchWithIsolationLevel(t, 0, maxBytes, 1, fetcher)
+	verifyDefaultResponse(t, resp, batches[:2])
+	require.Equal(t, []kafkaprotocol.FetchResponseAbortedTransaction{
+		{ProducerId: 7, FirstOffset: 10},
+	}, resp.Responses[0].Partitions[0].AbortedTransactions)
+}
+
+func TestFetcherReadCommittedAbortedTransactionsMarkerAfterLastStableOffset(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+	topicProvider.infos[defaultTopicName] = topicmeta.TopicInfo{
+		ID:             defaultTopicID,
+		Name:           defaultTopicName,
+		PartitionCount: defaultNumPartitions,
+	}
+	now := types.Timestamp{Val: time.Now().UnixMilli()}
+	batches := [][]byte{
+		testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10),
+		testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 10, 10),
+		// producer 8's transaction is still open, so the last stable offset is 19
+		testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(8, 20, 10),
+		createControlBatch(30, 7, false, now),
+	}
+	tableID := setupTableWithBatchesAndAbortedTransactions(t, batches, []offsets.AbortedTransaction{
+		{ProducerID: 7, FirstOffset: 10, LastOffset: 30},
+	}, objStore)
+	controlClient.queryRes = lsm.OverlappingTables{lsm.NonOverlappingTables{{ID: tableID}}}
+	controlClient.setLastReadableOffset(defaultTopicID, defaultPartitionID, 30)
+	controlClient.setLastStableOffset(defaultTopicID, defaultPartitionID, 19)
+
+	// The abort marker is after the last stable offset, but the aborted transaction must still be returned
+	resp := sendFetchWithIsolationLevel(t, 0, defaultMaxBytes, 1, fetcher)
+	verifyDefaultResponse(t, resp, batches[:2])
+	require.Equal(t, []kafkaprotocol.FetchResponseAbortedTransaction{
+		{ProducerId: 7, FirstOffset: 10},
+	}, resp.Responses[0].Partitions[0].AbortedTransactions)
+
+	// No transactional data is returned, so no aborted transactions
+	resp = sendFetchWithIsolationLevel(t, 0, len(batches[0]), 1, fetcher)
+	verifyDefaultResponse(t, resp, batches[:1])
+	require.Equal(t, 0, len(resp.Responses[0].Partitions[0].AbortedTransactions))
+}
+
+func TestFetcherHistoricConsumerTableFormatV2(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+	topicProvider.infos[defaultTopicName] = topicmeta.TopicInfo{
+		ID:             defaultTopicID,
+		Name:           defaultTopicName,
+		PartitionCount: defaultNumPartitions,
+	}
+	var batches [][]byte
+	for i := 0; i < 100; i++ {
+		batches = append(batches, testutils.CreateKafkaRecordBatchWithIncrementingKVs(i*10, 10))
+	}
+	tableID := setupTableWithBatchesAndFormat(t, defaultTopicID, defaultPartitionID, batches, common.DataFormatV2,
+		compress.CompressionTypeZstd, objStore)
+	controlClient.queryRes = lsm.OverlappingTables{lsm.NonOverlappingTables{{ID: tableID}}}
+	controlClient.setLastReadableOffset(defaultTopicID, defaultPartitionID, 999)
+
+	// Fetches are not in the range of the recent tables, so they read the table ranges from the object store
+	resp := sendFetchDefault(t, 500, 0, 0, len(batches[50]), defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches[50:51])
+	resp = sendFetchDefault(t, 0, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches)
+
+	// The index of the table is cached, and the table is not loaded through the fetch cache
+	fetcher.indexCache.cache.Wait()
+	index, ok := fetcher.indexCache.Get(tableID)
+	require.True(t, ok)
+	require.True(t, index.HasBlockIndex())
+	fetcher.localCache.cache.Wait()
+	_, ok = fetcher.localCache.Get(tableID)
+	require.False(t, ok)
+}
+
+func TestFetcherHistoricConsumerTableFormatV1(t *testing.T) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	defer stopFetcher(t, fetcher)
+	topicProvider.infos[defaultTopicName] = topicmeta.TopicInfo{
+		ID:             defaultTopicID,
+		Name:           defaultTopicName,
+		PartitionCount: defaultNumPartitions,
+	}
+	var batches [][]byte
+	for i := 0; i < 100; i++ {
+		batches = append(batches, testutils.CreateKafkaRecordBatchWithIncrementingKVs(i*10, 10))
+	}
+	tableID := setupTableWithBatches(t, defaultTopicID, defaultPartitionID, batches, objStore)
+	controlClient.queryRes = lsm.OverlappingTables{lsm.NonOverlappingTables{{ID: tableID}}}
+	controlClient.setLastReadableOffset(defaultTopicID, defaultPartitionID, 999)
+
+	resp := sendFetchDefault(t, 500, 0, 0, len(batches[50]), defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches[50:51])
+
+	// The table has no block index, so it is loaded through the fetch cache
+	fetcher.indexCache.cache.Wait()
+	index, ok := fetcher.indexCache.Get(tableID)
+	require.True(t, ok)
+	require.False(t, index.HasBlockIndex())
+	fetcher.localCache.cache.Wait()
+	_, ok = fetcher.localCache.Get(tableID)
+	require.True(t, ok)
+
+	resp = sendFetchDefault(t, 0, 0, 0, defaultMaxBytes, defaultMaxBytes, fetcher)
+	verifyDefaultResponse(t, resp, batches)
+}
+
+func setupFetcherWithTransactions(t *testing.T) (*BatchFetcher, [][]byte) {
+	fetcher, topicProvider, controlClient, objStore := setupFetcher(t)
+	topicProvider.infos[defaultTopicName] = topicmeta.TopicInfo{
+		ID:             defaultTopicID,
+		Name:           defaultTopicName,
+		PartitionCount: defaultNumPartitions,
+	}
+	now := types.Timestamp{Val: time.Now().UnixMilli()}
+	batches := [][]byte{
+		testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10),
+		testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 10, 10),
+		testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(8, 20, 10),
+		createControlBatch(30, 7, false, now),
+		createControlBatch(31, 8, true, now),
+		testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 32, 10),
+		createControlBatch(42, 7, false, now),
+	}
+	tableID := setupTableWithBatchesAndAbortedTransactions(t, batches, []offsets.AbortedTransaction{
+		{ProducerID: 7, FirstOffset: 10, LastOffset: 30},
+		{ProducerID: 7, FirstOffset: 32, LastOffset: 42},
+	}, objStore)
+	controlClient.queryRes = lsm.OverlappingTables{lsm.NonOverlappingTables{{ID: tableID}}}
+	controlClient.setLastReadableOffset(defaultTopicID, defaultPartitionID, 42)
+	return fetcher, batches
+}
+
+func createControlBatch(offset int64, producerID int64, commit bool, timestamp types.Timestamp) []byte {
+	batch := kafkaencoding.CreateControlBatch(producerID, 0, commit, timestamp)
+	binary.BigEndian.PutUint64(batch, uint64(offset))
+	return batch
+}
+
+func setupTableWithBatches(t *testing.T, topicID int, partitionID int, batches [][]byte, objStore objstore.Client) sst.SSTableID {
+	return setupTableWithBatchesAndFormat(t, topicID, partitionID, batches, common.DataFormatV1,
+		compress.CompressionTypeNone, objStore)
+}
+
+// setupTableWithBatchesAndAbortedTransactions sets up a table for the default partition, containing the batches along
+// with the aborted transaction index entries that the table pusher writes with the abort markers
+func setupTableWithBatchesAndAbortedTransactions(t *testing.T, batches [][]byte,
+	abortedTxs []offsets.AbortedTransaction, objStore objstore.Client) sst.SSTableID {
+	partHashes, err := parthash.NewPartitionHashes(0)
+	require.NoError(t, err)
+	prefix, err := partHashes.GetPartitionHash(defaultTopicID, defaultPartitionID)
+	require.NoError(t, err)
+	var abortedTxKVs []common.KV
+	for _, abortedTx := range abortedTxs {
+		abortedTxKVs = append(abortedTxKVs, offsets.CreateAbortedTransactionKV(prefix, abortedTx))
+	}
+	return setupTableWithBatchesAndKVs(t, defaultTopicID, defaultPartitionID, batches, abortedTxKVs,
+		common.DataFormatV1, compress.CompressionTypeNone, objStore)
+}
+
+func setupTableWithBatchesAndFormat(t *testing.T, topicID int, partitionID int, batches [][]byte,
+	format common.DataFormat, compressionType compress.CompressionType, objStore objstore.Client) sst.SSTableID {
+	return setupTableWithBatchesAndKVs(t, topicID, partitionID, batches, nil, format, compressionType, objStore)
+}
+
+// setupTableWithBatchesAndKVs sets up a table containing the batches followed by extraKVs, which must sort after them
+func setupTableWithBatchesAndKVs(t *testing.T, topicID int, partitionID int, batches [][]byte, extraKVs []common.KV,
+	format common.DataFormat, compressionType compress.CompressionType, objStore objstore.Client) sst.SSTableID {
+	partHashes, err := parthash.NewPartitionHashes(0)
+	require.NoError(t, err)
+	prefix, err := partHashes.GetPartitionHash(topicID, partitionID)
+	require.NoError(t, err)
+	var kvs []common.KV
+	for _, batch := range batches {
+		key := make([]byte, 0, 24)
+		key = append(key, prefix...)
+		key = append(key, common.EntryTypeTopicData)
+		key = encoding.KeyEncodeInt(key, kafkaencoding.BaseOffset(batch))
+		key = encoding.EncodeVersion(key, 0)
+		kvs = append(kvs, common.KV{
+			Key:   key,
+			Value: batch,
+		})
+	}
+	kvs = append(kvs, extraKVs...)
+	iter := common.NewKvSliceIterator(kvs)
+	table, _, _, _, _, err := sst.BuildSSTableWithOptions(format, sst.BuildOptions{Compression: compressionType}, 0, 0, iter)
+	require.NoError(t, err)
+	tableID := sst.CreateSSTableId()
+	err = objStore.Put(context.Background(), databucketName, tableID, table.Serialize())
+	require.NoError(t, err)
+	return []byte(tableID)
+}
+
+func sendFetchWithIsolationLevel(t *testing.T, fetchOffset int, maxBytes int, isolationLevel int8, fetcher *BatchFetcher) *kafkaprotocol.FetchResponse {
+	req := kafkaprotocol.FetchRequest{
+		MaxBytes:       int32(maxBytes),
+		IsolationLevel: isolationLevel,
+		Topics: []kafkaprotocol.FetchRequestFetchTopic{
+			{
+				Topic: common.StrPtr(defaultTopicName),
+				Partitions: []kafkaprotocol.FetchRequestFetchPartition{
+					{
+						Partition:         int32(defaultPartitionID),
+						FetchOffset:       int64(fetchOffset),
+						PartitionMaxBytes: int32(maxBytes),
+					},
+				},
+			},
+		},
+	}
+	return sendFetch(t, &req, fetcher)
+}
+
 func setupFetcher(t *testing.T) (*BatchFetcher, *testTopicProvider, *testControlClient, objstore.Client) {
 	objStore := dev.NewInMemStore(0)
 	infoProvider := &testTopicProvider{infos: map[string]topicmeta.TopicInfo{}}
@@ -2140,7 +2522,7 @@ func sendFetchRequest(t *testing.T, topicName string, partitionID int, fetchOffs
 
 func sendFetch(t *testing.T, req *kafkaprotocol.FetchRequest, fetcher *BatchFetcher) *kafkaprotocol.FetchResponse {
 	ch := make(chan *kafkaprotocol.FetchResponse, 1)
-	err := fetcher.HandleFetchRequest(req, func(resp *kafkaprotocol.FetchResponse) error {
+	err := fetcher.HandleFetchRequest(&kafkaprotocol.RequestHeader{}, req, nil, func(resp *kafkaprotocol.FetchResponse) error {
 		ch <- resp
 		return nil
 	})
@@ -2211,6 +2593,8 @@ func verifyPartitionErrorInResponse(t *testing.T, resp *kafkaprotocol.FetchRespo
 func newTestControlClient() *testControlClient {
 	return &testControlClient{
 		lastReadableOffsets: map[int]map[int]int64{},
+		lastStableOffsets:   map[int]map[int]int64{},
+		logStartOffsets:     map[int]map[int]int64{},
 	}
 }
 
@@ -2218,6 +2602,8 @@ type testControlClient struct {
 	lock                sync.Mutex
 	queryRes            lsm.OverlappingTables
 	lastReadableOffsets map[int]map[int]int64
+	lastStableOffsets   map[int]map[int]int64
+	logStartOffsets     map[int]map[int]int64
 	unavailable         bool
 	unexpectedErr       bool
 	memberID            int32
@@ -2243,26 +2629,34 @@ func (t *testControlClient) setFailWithUnexpectedErr() {
 }
 
 func (t *testControlClient) RegisterTableListener(topicID int, partitionID int, memberID int32,
-	resetSequence int64) (int64, error) {
+	resetSequence int64) (int64, int64, int64, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 	if t.unavailable {
-		return 0, common.NewTektiteErrorf(common.Unavailable, "controller is unavailable")
+		return 0, 0, 0, common.NewTektiteErrorf(common.Unavailable, "controller is unavailable")
 	}
 	if t.unexpectedErr {
-		return 0, errors.New("unexpected error")
+		return 0, 0, 0, errors.New("unexpected error")
 	}
 	partMap, ok := t.lastReadableOffsets[topicID]
 	if !ok {
-		return 0, errors.Errorf("unknown topic: %d", topicID)
+		return 0, 0, 0, errors.Errorf("unknown topic: %d", topicID)
 	}
 	off, ok := partMap[partitionID]
 	if !ok {
-		return 0, errors.Errorf("unknown partition: %d", partitionID)
+		return 0, 0, 0, errors.Errorf("unknown partition: %d", partitionID)
+	}
+	lso := off
+	stablePartMap, ok := t.lastStableOffsets[topicID]
+	if ok {
+		stableOff, ok := stablePartMap[partitionID]
+		if ok {
+			lso = stableOff
+		}
 	}
 	t.memberID = memberID
 	t.resetSequence = resetSequence
-	return off, nil
+	return off, lso, t.logStartOffsets[topicID][partitionID], nil
 }
 
 func (t *testControlClient) QueryTablesInRange(_ []byte, _ []byte) (lsm.OverlappingTables, error) {
@@ -2292,6 +2686,24 @@ func (t *testControlClient) setLastReadableOffset(topicID int, partitionID int,
 	partMap[partitionID] = offset
 }
 
+func (t *testControlClient) setLastStableOffset(topicID int, partitionID int, offset int64) {
+	partMap, ok := t.lastStableOffsets[topicID]
+	if !ok {
+		partMap = map[int]int64{}
+		t.lastStableOffsets[topicID] = partMap
+	}
+	partMap[partitionID] = offset
+}
+
+func (t *testControlClient) setLogStartOffset(topicID int, partitionID int, offset int64) {
+	partMap, ok := t.logStartOffsets[topicID]
+	if !ok {
+		partMap = map[int]int64{}
+		t.logStartOffsets[topicID] = partMap
+	}
+	partMap[partitionID] = offset
+}
+
 func (t *testControlClient) PrePush(infos []offsets.GenerateOffsetTopicInfo, epochInfos []control.EpochInfo) ([]offsets.OffsetTopicInfo, int64, []bool, error) {
 	panic("should not be called")
 }
@@ -2329,6 +2741,14 @@ func (t *testControlClient) DeleteTopic(topicName string) error {
 	panic("should not be called")
 }
 
+func (t *testControlClient) CreatePartitions(topicName string, partitionCount int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
 func (t *testControlClient) GetCoordinatorInfo(key string) (memberID int32, address string, groupEpoch int, err error) {
 	panic("should not be called")
 }
@@ -2337,6 +2757,47 @@ func (t *testControlClient) GenerateSequence(sequenceName string) (int64, error)
 	panic("should not be called")
 }
 
+func (t *testControlClient) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteUserCredentials(username string, mechanism string) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterClientQuotas(alterations []control.ClientQuotaAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetClientQuotas() ([]control.ClientQuotaConfig, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) CreateAcls([]acls.AclEntry) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteAcls([]acls.AclFilter) ([][]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetAcls() ([]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	panic("should not be called")
+}
+
 func (t *testControlClient) Close() error {
 	return nil
 }
@@ -38,6 +38,8 @@ type PartitionTables struct {
 	listeners              []*PartitionFetchState
 	initialised            bool
 	validFromOffset        int64
+	lastStableOffset       int64
+	logStartOffset         int64
 }
 
 func (p *PartitionRecentTables) membershipChanged(membership cluster.MembershipState) {
@@ -76,39 +78,43 @@ func (p *PartitionRecentTables) getPartitionTables(partitionMap map[int]*Partiti
 	return partitionTables
 }
 
-func (p *PartitionTables) initialise(queryFunc func() (int64, error)) (int64, bool, error) {
+func (p *PartitionTables) initialise(queryFunc func() (int64, int64, int64, error)) (int64, int64, int64, bool, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	if p.initialised {
-		return 0, true, nil
+		return 0, 0, 0, true, nil
 	}
-	// We execute the registration and get the initial lastReadableOffset via a function passed in here. The function is
-	// executed with the lock held which handles a race where a new notification comes in very quickly and updates
-	// partitionTables before we have fully initialised.
-	lro, err := queryFunc()
+	// We execute the registration and get the initial lastReadableOffset, lastStableOffset and logStartOffset via a
+	// function passed in here. The function is executed with the lock held which handles a race where a new
+	// notification comes in very quickly and updates partitionTables before we have fully initialised.
+	lro, lso, logStartOffset, err := queryFunc()
 	if err != nil {
-		return 0, false, err
+		return 0, 0, 0, false, err
 	}
 	p.validFromOffset = lro + 1
+	p.lastStableOffset = lso
+	p.logStartOffset = logStartOffset
 	p.initialised = true
-	return lro, false, nil
+	return lro, lso, logStartOffset, false, nil
 }
 
 func (p *PartitionTables) maybeGetRecentTableIDs(fetchOffset int64) (tables []*sst.SSTableID, lastReadableOffset int64,
-	initialised bool, isInCachedRange bool) {
+	lastStableOffset int64, logStartOffset int64, initialised bool, isInCachedRange bool) {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 	if !p.initialised {
-		return nil, 0, false, false
+		return nil, 0, 0, 0, false, false
 	}
 	if len(p.entries) == 0 {
 		lastReadableOffset = p.validFromOffset - 1
 	} else {
 		lastReadableOffset = p.entries[len(p.entries)-1].LastReadableOffset
 	}
+	lastStableOffset = p.lastStableOffset
+	logStartOffset = p.logStartOffset
 	if fetchOffset < p.validFromOffset {
 		// We are trying to fetch from an offset before the first offset we are caching tables from
-		return nil, lastReadableOffset, true, false
+		return nil, lastReadableOffset, lastStableOffset, logStartOffset, true, false
 	}
 	// Screen out any ids which can't contain any data from >= fetchOffset
 	var tabIDs []*sst.SSTableID
@@ -118,7 +124,7 @@ func (p *PartitionTables) maybeGetRecentTableIDs(fetchOffset int64) (tables []*s
 		}
 		tabIDs = append(tabIDs, entry.TableID)
 	}
-	return tabIDs, lastReadableOffset, true, true
+	return tabIDs, lastReadableOffset, lastStableOffset, logStartOffset, true, true
 }
 
 func (p *PartitionTables) isInitialised() bool {
@@ -127,16 +133,25 @@ func (p *PartitionTables) isInitialised() bool {
 	return p.initialised
 }
 
-func (p *PartitionTables) addTableIDs(tableIDs []sst.SSTableID, lastReadableOffset int64,
-	fetchStates map[*FetchState]struct{}) error {
+func (p *PartitionTables) addTableIDs(tableIDs []sst.SSTableID, lastReadableOffset int64, lastStableOffset int64,
+	logStartOffset int64, fetchStates map[*FetchState]struct{}) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	if !p.initialised {
 		panic("partition tables not initialised")
 	}
+	// The log start offset never moves backwards
+	if logStartOffset > p.logStartOffset {
+		p.logStartOffset = logStartOffset
+	}
+	if len(tableIDs) == 0 {
+		// Records have been deleted, no new data has been made readable
+		return nil
+	}
 	for _, tabID := range tableIDs {
 		p.entries = append(p.entries, RecentTableEntry{&tabID, lastReadableOffset})
 	}
+	p.lastStableOffset = lastStableOffset
 	extra := len(p.entries) - p.maxEntriesPerPartition
 	if extra > 0 {
 		p.validFromOffset = p.entries[extra-1].LastReadableOffset + 1
@@ -178,6 +193,8 @@ func (p *PartitionTables) invalidate() {
 	// will cause it to invalidate all listeners for the agent
 	p.initialised = false
 	p.validFromOffset = -1
+	p.lastStableOffset = -1
+	p.logStartOffset = 0
 }
 
 type RecentTableEntry struct {
@@ -253,7 +270,8 @@ func (p *PartitionRecentTables) handleTableRegisteredNotification(notification *
 			if !ok {
 				panic(fmt.Sprintf("cannot find partition tables for partition %d", partitionInfo.PartitionID))
 			}
-			if err := partitionTables.addTableIDs(notification.TableIDs, partitionInfo.Offset, fetchStates); err != nil {
+			if err := partitionTables.addTableIDs(notification.TableIDs, partitionInfo.Offset,
+				partitionInfo.LastStableOffset, partitionInfo.LogStartOffset, fetchStates); err != nil {
 				return err
 			}
 		}
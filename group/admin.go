@@ -0,0 +1,515 @@
+package group
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/queryutils"
+	"math"
+	"sort"
+	"strings"
+)
+
+/*
+Admin operations on consumer groups: ListGroups, DescribeGroups, ConsumerGroupDescribe, DeleteGroups and OffsetDelete.
+
+Groups only exist in memory on their coordinator, so ListGroups lists the groups which this agent coordinates, and
+clients send it to each agent in the cluster. The other operations must be sent to the group's coordinator.
+
+Committed offsets are deleted by writing tombstones for the offset keys through the table pusher, in the same way that
+offsets are committed. A group might not be in memory but still have committed offsets, e.g. if the coordinator has
+moved, so in that case we look in the LSM for the group's offsets to determine whether the group exists.
+*/
+
+const (
+	consumerProtocolType = "consumer"
+	// classicGroupType is the type of groups which use the JoinGroup/SyncGroup protocol
+	classicGroupType = "classic"
+	// consumerGroupType is the type of groups which use the ConsumerGroupHeartbeat protocol
+	consumerGroupType = "consumer"
+)
+
+func groupStateName(state int) string {
+	switch state {
+	case stateEmpty:
+		return "Empty"
+	case statePreReBalance:
+		return "PreparingRebalance"
+	case stateAwaitingReBalance:
+		return "CompletingRebalance"
+	case stateActive:
+		return "Stable"
+	default:
+		return "Dead"
+	}
+}
+
+func (c *Coordinator) HandleListGroupsRequest(req *kafkaprotocol.ListGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.ListGroupsResponse) error) error {
+	var resp kafkaprotocol.ListGroupsResponse
+	groups, errCode := c.listGroups(req.StatesFilter, req.TypesFilter)
+	resp.ErrorCode = errCode
+	resp.Groups = groups
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) listGroups(statesFilter []*string, typesFilter []*string) ([]kafkaprotocol.ListGroupsResponseListedGroup, int16) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		return nil, kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	groups := make([]kafkaprotocol.ListGroupsResponseListedGroup, 0, len(c.groups))
+	for groupID, g := range c.groups {
+		state, stateName, protocolType, groupType := g.listingInfo()
+		if state == stateDead {
+			continue
+		}
+		if !matchesFilter(stateName, statesFilter) || !matchesFilter(groupType, typesFilter) {
+			continue
+		}
+		groups = append(groups, kafkaprotocol.ListGroupsResponseListedGroup{
+			GroupId:      common.StrPtr(groupID),
+			ProtocolType: common.StrPtr(protocolType),
+			GroupState:   common.StrPtr(stateName),
+			GroupType:    common.StrPtr(groupType),
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return *groups[i].GroupId < *groups[j].GroupId
+	})
+	return groups, kafkaprotocol.ErrorCodeNone
+}
+
+// matchesFilter returns true if the filter is empty or contains the value. As with Kafka, matching is case-insensitive
+func matchesFilter(value string, filter []*string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if strings.EqualFold(value, common.SafeDerefStringPtr(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Coordinator) HandleDescribeGroupsRequest(req *kafkaprotocol.DescribeGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.DescribeGroupsResponse) error) error {
+	var resp kafkaprotocol.DescribeGroupsResponse
+	resp.Groups = make([]kafkaprotocol.DescribeGroupsResponseDescribedGroup, len(req.Groups))
+	for i, groupID := range req.Groups {
+		resp.Groups[i] = c.describeGroup(common.SafeDerefStringPtr(groupID))
+	}
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) describeGroup(groupID string) kafkaprotocol.DescribeGroupsResponseDescribedGroup {
+	// State, protocol type and protocol data are not nullable
+	result := kafkaprotocol.DescribeGroupsResponseDescribedGroup{
+		GroupId:              common.StrPtr(groupID),
+		GroupState:           common.StrPtr(""),
+		ProtocolType:         common.StrPtr(""),
+		ProtocolData:         common.StrPtr(""),
+		Members:              []kafkaprotocol.DescribeGroupsResponseDescribedGroupMember{},
+		AuthorizedOperations: math.MinInt32,
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		result.ErrorCode = kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+		return result
+	}
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		result.ErrorCode = errCode
+		return result
+	}
+	if !ok {
+		// As with Kafka, a group which doesn't exist is described as dead
+		result.GroupState = common.StrPtr(groupStateName(stateDead))
+		return result
+	}
+	g.describe(&result)
+	return result
+}
+
+func (c *Coordinator) HandleConsumerGroupDescribeRequest(req *kafkaprotocol.ConsumerGroupDescribeRequest,
+	completionFunc func(resp *kafkaprotocol.ConsumerGroupDescribeResponse) error) error {
+	var resp kafkaprotocol.ConsumerGroupDescribeResponse
+	resp.Groups = make([]kafkaprotocol.ConsumerGroupDescribeResponseDescribedGroup, len(req.GroupIds))
+	for i, groupID := range req.GroupIds {
+		resp.Groups[i] = c.describeConsumerGroup(common.SafeDerefStringPtr(groupID))
+	}
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) describeConsumerGroup(groupID string) kafkaprotocol.ConsumerGroupDescribeResponseDescribedGroup {
+	// State and assignor name are not nullable
+	result := kafkaprotocol.ConsumerGroupDescribeResponseDescribedGroup{
+		GroupId:              common.StrPtr(groupID),
+		GroupState:           common.StrPtr(""),
+		AssignorName:         common.StrPtr(""),
+		Members:              []kafkaprotocol.ConsumerGroupDescribeResponseMember{},
+		AuthorizedOperations: math.MinInt32,
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		result.ErrorCode = kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+		return result
+	}
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		result.ErrorCode = errCode
+		return result
+	}
+	if !ok {
+		result.ErrorCode = kafkaprotocol.ErrorCodeGroupIDNotFound
+		return result
+	}
+	g.describeConsumerGroup(&result)
+	return result
+}
+
+func (c *Coordinator) HandleDeleteGroupsRequest(req *kafkaprotocol.DeleteGroupsRequest,
+	completionFunc func(resp *kafkaprotocol.DeleteGroupsResponse) error) error {
+	var resp kafkaprotocol.DeleteGroupsResponse
+	resp.Results = make([]kafkaprotocol.DeleteGroupsResponseDeletableGroupResult, len(req.GroupsNames))
+	for i, groupID := range req.GroupsNames {
+		resp.Results[i].GroupId = groupID
+		resp.Results[i].ErrorCode = c.deleteGroup(common.SafeDerefStringPtr(groupID))
+	}
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) deleteGroup(groupID string) int16 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		return kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	// If the group doesn't exist, it may still have committed offsets
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return errCode
+	}
+	errCode = g.delete(ok)
+	if errCode == kafkaprotocol.ErrorCodeNone && ok {
+		c.removeGroup(groupID)
+	}
+	return errCode
+}
+
+func (c *Coordinator) HandleOffsetDeleteRequest(req *kafkaprotocol.OffsetDeleteRequest,
+	completionFunc func(resp *kafkaprotocol.OffsetDeleteResponse) error) error {
+	var resp kafkaprotocol.OffsetDeleteResponse
+	resp.Topics = make([]kafkaprotocol.OffsetDeleteResponseOffsetDeleteResponseTopic, len(req.Topics))
+	for i, topicData := range req.Topics {
+		resp.Topics[i].Name = topicData.Name
+		resp.Topics[i].Partitions = make([]kafkaprotocol.OffsetDeleteResponseOffsetDeleteResponsePartition,
+			len(topicData.Partitions))
+		for j, partitionData := range topicData.Partitions {
+			resp.Topics[i].Partitions[j].PartitionIndex = partitionData.PartitionIndex
+		}
+	}
+	resp.ErrorCode = c.offsetDelete(common.SafeDerefStringPtr(req.GroupId), req, &resp)
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) offsetDelete(groupID string, req *kafkaprotocol.OffsetDeleteRequest,
+	resp *kafkaprotocol.OffsetDeleteResponse) int16 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		return kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return errCode
+	}
+	return g.offsetDelete(ok, req, resp)
+}
+
+// listingInfo returns the state, state name, protocol type and group type of the group for ListGroups
+func (g *group) listingInfo() (int, string, string, string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer != nil && g.state != stateDead {
+		return g.state, g.consumer.stateName(), g.protocolType, consumerGroupType
+	}
+	return g.state, groupStateName(g.state), g.protocolType, classicGroupType
+}
+
+func (g *group) describe(result *kafkaprotocol.DescribeGroupsResponseDescribedGroup) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer != nil {
+		// As with Kafka, consumer groups can only be described with ConsumerGroupDescribe
+		result.ErrorCode = kafkaprotocol.ErrorCodeGroupIDNotFound
+		return
+	}
+	result.GroupState = common.StrPtr(groupStateName(g.state))
+	result.ProtocolType = common.StrPtr(g.protocolType)
+	result.ProtocolData = common.StrPtr(g.protocolName)
+	assignments := make(map[string][]byte, len(g.assignments))
+	for _, assignment := range g.assignments {
+		assignments[assignment.MemberID] = assignment.Assignment
+	}
+	memberIDs := make([]string, 0, len(g.members))
+	for memberID := range g.members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+	for _, memberID := range memberIDs {
+		m := g.members[memberID]
+		var metadata []byte
+		for _, protocol := range m.protocols {
+			if protocol.Name == g.protocolName {
+				metadata = protocol.Metadata
+				break
+			}
+		}
+		describedMember := kafkaprotocol.DescribeGroupsResponseDescribedGroupMember{
+			MemberId: common.StrPtr(memberID),
+			ClientId: common.StrPtr(m.clientID),
+			// The client host is not known as it is not passed to the coordinator on join
+			ClientHost:       common.StrPtr(""),
+			MemberMetadata:   metadata,
+			MemberAssignment: assignments[memberID],
+		}
+		if m.groupInstanceID != "" {
+			describedMember.GroupInstanceId = common.StrPtr(m.groupInstanceID)
+		}
+		result.Members = append(result.Members, describedMember)
+	}
+}
+
+func (g *group) describeConsumerGroup(result *kafkaprotocol.ConsumerGroupDescribeResponseDescribedGroup) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	cg := g.consumer
+	if cg == nil || g.state == stateDead {
+		result.ErrorCode = kafkaprotocol.ErrorCodeGroupIDNotFound
+		result.ErrorMessage = common.StrPtr(fmt.Sprintf("group %s is not a consumer group", g.id))
+		return
+	}
+	result.GroupState = common.StrPtr(cg.stateName())
+	result.GroupEpoch = cg.epoch
+	result.AssignmentEpoch = cg.assignmentEpoch
+	result.AssignorName = common.StrPtr(cg.assignorName)
+	memberIDs := make([]string, 0, len(cg.members))
+	for memberID := range cg.members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+	for _, memberID := range memberIDs {
+		m := cg.members[memberID]
+		describedMember := kafkaprotocol.ConsumerGroupDescribeResponseMember{
+			MemberId:    common.StrPtr(memberID),
+			MemberEpoch: m.epoch,
+			ClientId:    common.StrPtr(m.clientID),
+			// The client host is not known as it is not passed to the coordinator
+			ClientHost:           common.StrPtr(""),
+			SubscribedTopicNames: make([]*string, len(m.subscribedTopicNames)),
+			Assignment:           cg.describeAssignment(m.assigned),
+			TargetAssignment:     cg.describeAssignment(cg.targetAssignment[memberID]),
+		}
+		for i, topicName := range m.subscribedTopicNames {
+			describedMember.SubscribedTopicNames[i] = common.StrPtr(topicName)
+		}
+		if m.instanceID != "" {
+			describedMember.InstanceId = common.StrPtr(m.instanceID)
+		}
+		if m.rackID != "" {
+			describedMember.RackId = common.StrPtr(m.rackID)
+		}
+		result.Members = append(result.Members, describedMember)
+	}
+}
+
+func (cg *consumerGroup) describeAssignment(assignment topicPartitions) kafkaprotocol.ConsumerGroupDescribeResponseAssignment {
+	result := kafkaprotocol.ConsumerGroupDescribeResponseAssignment{
+		TopicPartitions: make([]kafkaprotocol.ConsumerGroupDescribeResponseTopicPartitions, 0, len(assignment)),
+	}
+	for _, topicID := range assignment.sortedTopicIDs() {
+		result.TopicPartitions = append(result.TopicPartitions, kafkaprotocol.ConsumerGroupDescribeResponseTopicPartitions{
+			TopicId:    kafkaTopicID(topicID),
+			TopicName:  common.StrPtr(cg.topicNames[topicID]),
+			Partitions: assignment[topicID],
+		})
+	}
+	return result
+}
+
+// delete deletes all the committed offsets of the group, the group must have no members. If the group is not in memory
+// and has no committed offsets then it does not exist.
+func (g *group) delete(inMemory bool) int16 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer != nil && len(g.consumer.members) > 0 {
+		return kafkaprotocol.ErrorCodeNonEmptyGroup
+	}
+	switch g.state {
+	case stateEmpty:
+	case stateDead:
+		return kafkaprotocol.ErrorCodeGroupIDNotFound
+	default:
+		return kafkaprotocol.ErrorCodeNonEmptyGroup
+	}
+	keys, err := g.loadOffsetKeys()
+	if err != nil {
+		return offsetsErrorCode(err)
+	}
+	if !inMemory && len(keys) == 0 {
+		return kafkaprotocol.ErrorCodeGroupIDNotFound
+	}
+	if len(keys) > 0 {
+		kvs := make([]common.KV, len(keys))
+		for i, key := range keys {
+			kvs[i] = common.KV{Key: key}
+		}
+		if errCode := g.writeOffsetKVs(kvs); errCode != kafkaprotocol.ErrorCodeNone {
+			return int16(errCode)
+		}
+	}
+	// Any members attempting to join before the group has been removed will get an error and retry
+	g.state = stateDead
+	log.Debugf("deleted group %s with %d committed offsets", g.id, len(keys))
+	return kafkaprotocol.ErrorCodeNone
+}
+
+func (g *group) offsetDelete(inMemory bool, req *kafkaprotocol.OffsetDeleteRequest,
+	resp *kafkaprotocol.OffsetDeleteResponse) int16 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.state == stateDead {
+		return kafkaprotocol.ErrorCodeGroupIDNotFound
+	}
+	if !inMemory {
+		keys, err := g.loadOffsetKeys()
+		if err != nil {
+			return offsetsErrorCode(err)
+		}
+		if len(keys) == 0 {
+			return kafkaprotocol.ErrorCodeGroupIDNotFound
+		}
+	}
+	var subscribedTopics map[string]struct{}
+	if g.consumer != nil {
+		subscribedTopics = g.consumer.subscribedTopicNames()
+	} else if g.state != stateEmpty {
+		if g.protocolType != consumerProtocolType {
+			// We can only tell which topics are in use for consumer groups
+			return kafkaprotocol.ErrorCodeNonEmptyGroup
+		}
+		subscribedTopics = g.subscribedTopics()
+	}
+	var kvs []common.KV
+	for i, topicData := range req.Topics {
+		topicName := common.SafeDerefStringPtr(topicData.Name)
+		var errCode int16
+		if _, subscribed := subscribedTopics[topicName]; subscribed {
+			errCode = kafkaprotocol.ErrorCodeGroupSubscribedToTopic
+		}
+		info, foundTopic, err := g.gc.topicProvider.GetTopicInfo(topicName)
+		if errCode == kafkaprotocol.ErrorCodeNone {
+			if err != nil {
+				log.Errorf("failed to get topic info %v", err)
+				errCode = kafkaprotocol.ErrorCodeUnknownServerError
+			} else if !foundTopic {
+				errCode = kafkaprotocol.ErrorCodeUnknownTopicOrPartition
+			}
+		}
+		for j, partitionData := range topicData.Partitions {
+			if errCode != kafkaprotocol.ErrorCodeNone {
+				resp.Topics[i].Partitions[j].ErrorCode = errCode
+				continue
+			}
+			partitionID := int(partitionData.PartitionIndex)
+			kvs = append(kvs,
+				common.KV{Key: createOffsetKey(g.partHash, offsetKeyPublic, info.ID, partitionID)},
+				common.KV{Key: createOffsetKey(g.partHash, offsetKeyTransactional, info.ID, partitionID)})
+		}
+	}
+	if len(kvs) > 0 {
+		if errCode := g.writeOffsetKVs(kvs); errCode != kafkaprotocol.ErrorCodeNone {
+			return int16(errCode)
+		}
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
+// subscribedTopics returns the topics which the members of a consumer group are subscribed to, by parsing the
+// ConsumerProtocolSubscription in the members' metadata. All versions start with the version followed by the topics.
+func (g *group) subscribedTopics() map[string]struct{} {
+	topics := map[string]struct{}{}
+	for _, m := range g.members {
+		for _, protocol := range m.protocols {
+			if protocol.Name != g.protocolName {
+				continue
+			}
+			metadata := protocol.Metadata
+			if len(metadata) < 6 {
+				continue
+			}
+			numTopics := int(int32(binary.BigEndian.Uint32(metadata[2:])))
+			offset := 6
+			for i := 0; i < numTopics && offset+2 <= len(metadata); i++ {
+				l := int(binary.BigEndian.Uint16(metadata[offset:]))
+				offset += 2
+				if offset+l > len(metadata) {
+					break
+				}
+				topics[string(metadata[offset:offset+l])] = struct{}{}
+				offset += l
+			}
+		}
+	}
+	return topics
+}
+
+// loadOffsetKeys returns the keys of all the committed offsets for the group which are stored in the LSM
+func (g *group) loadOffsetKeys() ([][]byte, error) {
+	cl, err := g.gc.clientCache.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	keyEnd := common.IncBigEndianBytes(common.ByteSliceCopy(g.partHash))
+	iter, err := queryutils.CreateIteratorForKeyRange(g.partHash, keyEnd, cl, g.gc.tableGetter)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var keys [][]byte
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return keys, nil
+		}
+		if len(kv.Value) == 0 {
+			// tombstone
+			continue
+		}
+		keys = append(keys, common.ByteSliceCopy(kv.Key))
+	}
+}
+
+func offsetsErrorCode(err error) int16 {
+	if common.IsUnavailableError(err) {
+		log.Warnf("failed to load offsets %v", err)
+		return kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	log.Errorf("failed to load offsets %v", err)
+	return kafkaprotocol.ErrorCodeUnknownServerError
+}
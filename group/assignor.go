@@ -0,0 +1,190 @@
+package group
+
+import (
+	"sort"
+)
+
+/*
+Server side assignors for the consumer group protocol. An assignor computes the target assignment for the group from the
+subscriptions of its members and the partition counts of the subscribed topics.
+
+The uniform assignor spreads the partitions of all subscribed topics as evenly as possible across the members and is
+sticky - partitions stay with the member they were previously assigned to where possible, so members are not affected by
+a rebalance unless partitions need to be moved to them or away from them.
+
+The range assignor assigns contiguous ranges of the partitions of each topic to the members subscribed to the topic,
+so members subscribed to co-partitioned topics are assigned the same partitions of each topic.
+*/
+
+const (
+	uniformAssignorName = "uniform"
+	rangeAssignorName   = "range"
+	// defaultAssignorName is the assignor used when no members of the group have specified one
+	defaultAssignorName = uniformAssignorName
+)
+
+// topicPartitions maps topic id to the sorted ids of the partitions of the topic
+type topicPartitions map[int][]int32
+
+type assignmentMember struct {
+	memberID string
+	// topicIDs are the ids of the subscribed topics which exist
+	topicIDs []int
+	// previous is the member's target assignment before this assignment
+	previous topicPartitions
+}
+
+// assignorFunc computes the target assignment for the members, which are sorted by member id. partitionCounts contains
+// the partition count for each subscribed topic.
+type assignorFunc func(members []assignmentMember, partitionCounts map[int]int) map[string]topicPartitions
+
+var assignors = map[string]assignorFunc{
+	uniformAssignorName: assignUniform,
+	rangeAssignorName:   assignRange,
+}
+
+type topicPartition struct {
+	topicID     int
+	partitionID int32
+}
+
+func assignUniform(members []assignmentMember, partitionCounts map[int]int) map[string]topicPartitions {
+	subscribed := make(map[string]map[int]struct{}, len(members))
+	counts := make(map[string]int, len(members))
+	owners := map[topicPartition]string{}
+	for _, m := range members {
+		topics := make(map[int]struct{}, len(m.topicIDs))
+		for _, topicID := range m.topicIDs {
+			topics[topicID] = struct{}{}
+		}
+		subscribed[m.memberID] = topics
+		counts[m.memberID] = 0
+	}
+	// First, keep previous assignments which are still valid
+	for _, m := range members {
+		for topicID, partitionIDs := range m.previous {
+			if _, ok := subscribed[m.memberID][topicID]; !ok {
+				continue
+			}
+			for _, partitionID := range partitionIDs {
+				tp := topicPartition{topicID: topicID, partitionID: partitionID}
+				if int(partitionID) >= partitionCounts[topicID] {
+					continue
+				}
+				if _, owned := owners[tp]; owned {
+					continue
+				}
+				owners[tp] = m.memberID
+				counts[m.memberID]++
+			}
+		}
+	}
+	// leastLoaded returns the subscribed member with the fewest partitions, ties are broken by member id
+	leastLoaded := func(topicID int) (string, bool) {
+		var chosen string
+		found := false
+		for _, m := range members {
+			if _, ok := subscribed[m.memberID][topicID]; !ok {
+				continue
+			}
+			if !found || counts[m.memberID] < counts[chosen] {
+				chosen = m.memberID
+				found = true
+			}
+		}
+		return chosen, found
+	}
+	allPartitions := sortedTopicPartitions(partitionCounts)
+	// Then assign any unassigned partitions to the least loaded members
+	for _, tp := range allPartitions {
+		if _, owned := owners[tp]; owned {
+			continue
+		}
+		memberID, ok := leastLoaded(tp.topicID)
+		if !ok {
+			// No members subscribed to the topic
+			continue
+		}
+		owners[tp] = memberID
+		counts[memberID]++
+	}
+	// Finally, move partitions from the most loaded members until the assignment is balanced. Each move reduces the
+	// imbalance so this terminates.
+	for moved := true; moved; {
+		moved = false
+		for _, tp := range allPartitions {
+			owner, ok := owners[tp]
+			if !ok {
+				continue
+			}
+			memberID, _ := leastLoaded(tp.topicID)
+			if counts[owner]-counts[memberID] >= 2 {
+				owners[tp] = memberID
+				counts[owner]--
+				counts[memberID]++
+				moved = true
+			}
+		}
+	}
+	assignment := make(map[string]topicPartitions, len(members))
+	for _, m := range members {
+		assignment[m.memberID] = topicPartitions{}
+	}
+	// allPartitions is sorted, so the partitions in the assignment are too
+	for _, tp := range allPartitions {
+		if memberID, ok := owners[tp]; ok {
+			assignment[memberID][tp.topicID] = append(assignment[memberID][tp.topicID], tp.partitionID)
+		}
+	}
+	return assignment
+}
+
+func assignRange(members []assignmentMember, partitionCounts map[int]int) map[string]topicPartitions {
+	subscribers := map[int][]string{}
+	assignment := make(map[string]topicPartitions, len(members))
+	for _, m := range members {
+		assignment[m.memberID] = topicPartitions{}
+		for _, topicID := range m.topicIDs {
+			subscribers[topicID] = append(subscribers[topicID], m.memberID)
+		}
+	}
+	for topicID, memberIDs := range subscribers {
+		partitionCount := partitionCounts[topicID]
+		perMember := partitionCount / len(memberIDs)
+		extra := partitionCount % len(memberIDs)
+		start := 0
+		for i, memberID := range memberIDs {
+			count := perMember
+			if i < extra {
+				count++
+			}
+			if count == 0 {
+				continue
+			}
+			partitionIDs := make([]int32, count)
+			for j := range partitionIDs {
+				partitionIDs[j] = int32(start + j)
+			}
+			assignment[memberID][topicID] = partitionIDs
+			start += count
+		}
+	}
+	return assignment
+}
+
+func sortedTopicPartitions(partitionCounts map[int]int) []topicPartition {
+	topicIDs := make([]int, 0, len(partitionCounts))
+	numPartitions := 0
+	for topicID, partitionCount := range partitionCounts {
+		topicIDs = append(topicIDs, topicID)
+		numPartitions += partitionCount
+	}
+	sort.Ints(topicIDs)
+	tps := make([]topicPartition, 0, numPartitions)
+	for _, topicID := range topicIDs {
+		for partitionID := 0; partitionID < partitionCounts[topicID]; partitionID++ {
+			tps = append(tps, topicPartition{topicID: topicID, partitionID: int32(partitionID)})
+		}
+	}
+	return tps
+}
@@ -0,0 +1,122 @@
+package group
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUniformAssignorBalanced(t *testing.T) {
+	members := createAssignmentMembers(3, 1000, 1001)
+	assignment := assignUniform(members, map[int]int{1000: 10, 1001: 5})
+	requireAssignmentComplete(t, assignment, map[int]int{1000: 10, 1001: 5})
+	for _, m := range members {
+		require.Equal(t, 5, assignment[m.memberID].count())
+	}
+}
+
+func TestUniformAssignorSticky(t *testing.T) {
+	partitionCounts := map[int]int{1000: 12}
+	members := createAssignmentMembers(3, 1000)
+	previous := assignUniform(members, partitionCounts)
+	// Add a member - each existing member should keep three of its partitions and give one to the new member
+	members = append(members, assignmentMember{memberID: "member-3", topicIDs: []int{1000}})
+	for i := range members {
+		members[i].previous = previous[members[i].memberID]
+	}
+	assignment := assignUniform(members, partitionCounts)
+	requireAssignmentComplete(t, assignment, partitionCounts)
+	for _, m := range members {
+		require.Equal(t, 3, assignment[m.memberID].count())
+		if m.previous != nil {
+			require.True(t, assignment[m.memberID].isSubsetOf(m.previous))
+		}
+	}
+	// Remove a member - only its partitions should move
+	removed := members[1]
+	members = append(members[:1], members[2:]...)
+	for i := range members {
+		members[i].previous = assignment[members[i].memberID]
+	}
+	next := assignUniform(members, partitionCounts)
+	requireAssignmentComplete(t, next, partitionCounts)
+	for _, m := range members {
+		require.Equal(t, 4, next[m.memberID].count())
+		require.True(t, m.previous.isSubsetOf(next[m.memberID]))
+		require.True(t, next[m.memberID].subtract(m.previous).isSubsetOf(assignment[removed.memberID]))
+	}
+}
+
+func TestUniformAssignorDifferentSubscriptions(t *testing.T) {
+	members := []assignmentMember{
+		{memberID: "member-0", topicIDs: []int{1000}},
+		{memberID: "member-1", topicIDs: []int{1000, 1001}},
+		{memberID: "member-2", topicIDs: []int{1001}},
+	}
+	partitionCounts := map[int]int{1000: 4, 1001: 2}
+	assignment := assignUniform(members, partitionCounts)
+	requireAssignmentComplete(t, assignment, partitionCounts)
+	for _, m := range members {
+		require.Equal(t, 2, assignment[m.memberID].count())
+		for topicID := range assignment[m.memberID] {
+			require.Contains(t, m.topicIDs, topicID)
+		}
+	}
+	// Topic with no subscribers is not assigned
+	assignment = assignUniform(members[:1], partitionCounts)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, assignment["member-0"])
+}
+
+func TestRangeAssignor(t *testing.T) {
+	members := createAssignmentMembers(3, 1000, 1001)
+	partitionCounts := map[int]int{1000: 7, 1001: 3}
+	assignment := assignRange(members, partitionCounts)
+	requireAssignmentComplete(t, assignment, partitionCounts)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2}, 1001: {0}}, assignment["member-0"])
+	require.Equal(t, topicPartitions{1000: {3, 4}, 1001: {1}}, assignment["member-1"])
+	require.Equal(t, topicPartitions{1000: {5, 6}, 1001: {2}}, assignment["member-2"])
+}
+
+func TestRangeAssignorMoreMembersThanPartitions(t *testing.T) {
+	members := createAssignmentMembers(3, 1000)
+	assignment := assignRange(members, map[int]int{1000: 2})
+	require.Equal(t, topicPartitions{1000: {0}}, assignment["member-0"])
+	require.Equal(t, topicPartitions{1000: {1}}, assignment["member-1"])
+	require.Equal(t, topicPartitions{}, assignment["member-2"])
+}
+
+func createAssignmentMembers(numMembers int, topicIDs ...int) []assignmentMember {
+	members := make([]assignmentMember, numMembers)
+	for i := range members {
+		members[i] = assignmentMember{memberID: fmt.Sprintf("member-%d", i), topicIDs: topicIDs}
+	}
+	return members
+}
+
+func requireAssignmentComplete(t *testing.T, assignment map[string]topicPartitions, partitionCounts map[int]int) {
+	assigned := map[topicPartition]string{}
+	for memberID, tps := range assignment {
+		for topicID, partitionIDs := range tps {
+			for _, partitionID := range partitionIDs {
+				tp := topicPartition{topicID: topicID, partitionID: partitionID}
+				owner, exists := assigned[tp]
+				require.False(t, exists, "partition %v assigned to %s and %s", tp, owner, memberID)
+				assigned[tp] = memberID
+			}
+		}
+	}
+	for topicID, partitionCount := range partitionCounts {
+		for partitionID := 0; partitionID < partitionCount; partitionID++ {
+			_, ok := assigned[topicPartition{topicID: topicID, partitionID: int32(partitionID)}]
+			require.True(t, ok)
+		}
+	}
+}
+
+func (t topicPartitions) count() int {
+	count := 0
+	for _, partitionIDs := range t {
+		count += len(partitionIDs)
+	}
+	return count
+}
@@ -0,0 +1,773 @@
+package group
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"sort"
+	"time"
+)
+
+/*
+The consumer group protocol (KIP-848).
+
+With the classic protocol every member must rejoin and sync on every rebalance, and no member can consume while the
+rebalance is in progress. With the consumer protocol members only send ConsumerGroupHeartbeat requests. The coordinator
+computes a target assignment for each member using a server side assignor, and each member is moved towards its target
+assignment incrementally through its heartbeats. A member must first revoke the partitions which are no longer in its
+target assignment, and it is only assigned a partition once the previous owner has revoked it. Members which are not
+affected by a change carry on consuming throughout.
+
+The group epoch is bumped whenever the members, their subscriptions, or the partition counts of subscribed topics change.
+The target assignment is then recomputed on the next heartbeat, and the assignment epoch is set to the group epoch. A
+member's epoch is advanced to the assignment epoch once it no longer owns any partitions which are not in its target
+assignment. Requests from a member with an unexpected epoch are fenced.
+
+A group is either a classic group or a consumer group. Only an empty group can change from one to the other.
+*/
+
+const (
+	// leaveGroupMemberEpoch is sent by a member when it leaves the group
+	leaveGroupMemberEpoch = -1
+	// leaveGroupStaticMemberEpoch is sent by a static member when it leaves the group temporarily, its assignment is
+	// kept until the session timeout expires so it can be given back to the member when it rejoins
+	leaveGroupStaticMemberEpoch = -2
+)
+
+type consumerGroup struct {
+	g               *group
+	epoch           int32
+	assignmentEpoch int32
+	assignorName    string
+	members         map[string]*consumerMember
+	staticMembers   map[string]string
+	// targetAssignment is the assignment computed at assignmentEpoch
+	targetAssignment map[string]topicPartitions
+	// partitionOwners maps each partition which is assigned to, or pending revocation from a member to the member id
+	partitionOwners map[topicPartition]string
+	// subscribedTopics contains the topic info for each subscribed topic which exists
+	subscribedTopics map[string]topicmeta.TopicInfo
+	topicNames       map[int]string
+	// dirty is true if the group has changed since its metadata was last persisted
+	dirty bool
+}
+
+type consumerMember struct {
+	id                   string
+	epoch                int32
+	previousEpoch        int32
+	instanceID           string
+	rackID               string
+	clientID             string
+	subscribedTopicNames []string
+	serverAssignor       string
+	rebalanceTimeout     time.Duration
+	assigned             topicPartitions
+	pendingRevocation    topicPartitions
+	assignmentSent       bool
+	// left is true if the member is a static member which has left the group temporarily
+	left bool
+}
+
+func newConsumerGroup(g *group) *consumerGroup {
+	return &consumerGroup{
+		g:                g,
+		members:          map[string]*consumerMember{},
+		staticMembers:    map[string]string{},
+		targetAssignment: map[string]topicPartitions{},
+		partitionOwners:  map[topicPartition]string{},
+		subscribedTopics: map[string]topicmeta.TopicInfo{},
+		topicNames:       map[int]string{},
+	}
+}
+
+func (c *Coordinator) HandleConsumerGroupHeartbeatRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest,
+	completionFunc func(resp *kafkaprotocol.ConsumerGroupHeartbeatResponse) error) error {
+	return completionFunc(c.consumerGroupHeartbeat(common.SafeDerefStringPtr(hdr.ClientId), req))
+}
+
+func (c *Coordinator) consumerGroupHeartbeat(clientID string,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if err := c.checkStarted(); err != nil {
+		log.Warn("coordinator is not started")
+		return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeCoordinatorNotAvailable, "")
+	}
+	if errCode, errMsg := validateConsumerGroupHeartbeat(req); errCode != kafkaprotocol.ErrorCodeNone {
+		return consumerGroupHeartbeatError(errCode, errMsg)
+	}
+	groupID := common.SafeDerefStringPtr(req.GroupId)
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return consumerGroupHeartbeatError(errCode, "")
+	}
+	if !ok {
+		if req.MemberEpoch != 0 {
+			// The group is not known, e.g. because it has been deleted. The member will rejoin.
+			return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeUnknownMemberID, "")
+		}
+		g = c.addGroup(g)
+	}
+	return g.consumerGroupHeartbeat(clientID, req)
+}
+
+func validateConsumerGroupHeartbeat(req *kafkaprotocol.ConsumerGroupHeartbeatRequest) (int16, string) {
+	if common.SafeDerefStringPtr(req.GroupId) == "" {
+		return kafkaprotocol.ErrorCodeInvalidRequest, "GroupId can't be empty"
+	}
+	if req.InstanceId != nil && *req.InstanceId == "" {
+		return kafkaprotocol.ErrorCodeInvalidRequest, "InstanceId can't be empty"
+	}
+	switch {
+	case req.MemberEpoch == 0:
+		if req.RebalanceTimeoutMs == -1 {
+			return kafkaprotocol.ErrorCodeInvalidRequest, "RebalanceTimeoutMs must be provided when joining"
+		}
+		if req.SubscribedTopicNames == nil {
+			return kafkaprotocol.ErrorCodeInvalidRequest, "SubscribedTopicNames must be provided when joining"
+		}
+		if len(req.TopicPartitions) > 0 {
+			return kafkaprotocol.ErrorCodeInvalidRequest, "TopicPartitions must be empty when joining"
+		}
+	case req.MemberEpoch < leaveGroupStaticMemberEpoch:
+		return kafkaprotocol.ErrorCodeInvalidRequest, fmt.Sprintf("invalid MemberEpoch %d", req.MemberEpoch)
+	case req.MemberEpoch == leaveGroupStaticMemberEpoch && req.InstanceId == nil:
+		return kafkaprotocol.ErrorCodeInvalidRequest, "InstanceId can't be null when leaving temporarily"
+	default:
+		if common.SafeDerefStringPtr(req.MemberId) == "" {
+			return kafkaprotocol.ErrorCodeInvalidRequest, "MemberId can't be empty"
+		}
+	}
+	if req.ServerAssignor != nil {
+		if _, ok := assignors[*req.ServerAssignor]; !ok {
+			return kafkaprotocol.ErrorCodeUnsupportedAssignor,
+				fmt.Sprintf("ServerAssignor %s is not supported", *req.ServerAssignor)
+		}
+	}
+	return kafkaprotocol.ErrorCodeNone, ""
+}
+
+func consumerGroupHeartbeatError(errCode int16, errMsg string) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	resp := &kafkaprotocol.ConsumerGroupHeartbeatResponse{ErrorCode: errCode}
+	if errMsg != "" {
+		resp.ErrorMessage = &errMsg
+	}
+	return resp
+}
+
+func (g *group) consumerGroupHeartbeat(clientID string,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.state == stateDead {
+		return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeCoordinatorNotAvailable, "")
+	}
+	if g.consumer == nil {
+		if g.state != stateEmpty {
+			return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeGroupIDNotFound,
+				fmt.Sprintf("group %s is not a consumer group", g.id))
+		}
+		// An empty classic group becomes a consumer group
+		g.consumer = newConsumerGroup(g)
+		g.protocolType = consumerProtocolType
+		g.protocolName = ""
+	}
+	defer g.consumer.persistIfDirty()
+	if req.MemberEpoch == leaveGroupMemberEpoch || req.MemberEpoch == leaveGroupStaticMemberEpoch {
+		return g.consumer.leave(req)
+	}
+	return g.consumer.heartbeat(clientID, req)
+}
+
+func (cg *consumerGroup) heartbeat(clientID string,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	owned := ownedPartitions(req)
+	var m *consumerMember
+	bumpEpoch := false
+	if req.MemberEpoch == 0 {
+		var errCode int16
+		var errMsg string
+		m, bumpEpoch, errCode, errMsg = cg.joinMember(clientID, req)
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			return consumerGroupHeartbeatError(errCode, errMsg)
+		}
+	} else {
+		memberID := common.SafeDerefStringPtr(req.MemberId)
+		var ok bool
+		m, ok = cg.members[memberID]
+		if !ok || m.left {
+			return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeUnknownMemberID, "")
+		}
+		if req.InstanceId != nil && cg.staticMembers[*req.InstanceId] != memberID {
+			return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeFencedInstanceID, "")
+		}
+		if !m.isValidEpoch(req.MemberEpoch, owned) {
+			return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeFencedMemberEpoch,
+				fmt.Sprintf("member epoch %d does not match expected epoch %d", req.MemberEpoch, m.epoch))
+		}
+	}
+	subscriptionChanged, memberChanged := m.updateSubscription(req)
+	if subscriptionChanged {
+		bumpEpoch = true
+	}
+	if memberChanged {
+		cg.dirty = true
+	}
+	topicsChanged, err := cg.refreshSubscribedTopics()
+	if err != nil {
+		log.Errorf("failed to get topic info %v", err)
+		return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeUnknownServerError, "")
+	}
+	if bumpEpoch || topicsChanged {
+		cg.epoch++
+		cg.dirty = true
+	}
+	if cg.epoch > cg.assignmentEpoch {
+		cg.computeTargetAssignment()
+	}
+	cg.reconcile(m, owned)
+	cg.g.gc.rescheduleTimer(m.id, cg.g.gc.cfg.ConsumerGroupSessionTimeout, cg.sessionTimeoutAction(m.id))
+	resp := &kafkaprotocol.ConsumerGroupHeartbeatResponse{
+		MemberId:            common.StrPtr(m.id),
+		MemberEpoch:         m.epoch,
+		HeartbeatIntervalMs: int32(cg.g.gc.cfg.ConsumerGroupHeartbeatInterval.Milliseconds()),
+	}
+	if req.MemberEpoch == 0 || !m.assignmentSent || (owned != nil && !owned.equals(m.assigned)) {
+		resp.Assignment = &kafkaprotocol.ConsumerGroupHeartbeatResponseAssignment{
+			TopicPartitions: m.assigned.toHeartbeatResponse(),
+		}
+		m.assignmentSent = true
+	}
+	return resp
+}
+
+// joinMember returns the member which is joining, or rejoining, the group, and whether it is a new member
+func (cg *consumerGroup) joinMember(clientID string,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest) (*consumerMember, bool, int16, string) {
+	memberID := common.SafeDerefStringPtr(req.MemberId)
+	instanceID := common.SafeDerefStringPtr(req.InstanceId)
+	if instanceID != "" {
+		if existingID, ok := cg.staticMembers[instanceID]; ok {
+			existing := cg.members[existingID]
+			if existingID == memberID {
+				return existing, false, kafkaprotocol.ErrorCodeNone, ""
+			}
+			if !existing.left {
+				return nil, false, kafkaprotocol.ErrorCodeUnreleasedInstanceID,
+					fmt.Sprintf("instance id %s is in use by another member", instanceID)
+			}
+			// The static member has restarted, it keeps its assignment and epoch
+			if memberID == "" {
+				memberID = generateMemberID(clientID)
+			}
+			cg.replaceMemberID(existing, memberID)
+			return existing, false, kafkaprotocol.ErrorCodeNone, ""
+		}
+	}
+	if memberID != "" {
+		if m, ok := cg.members[memberID]; ok {
+			// The member has been fenced, or has lost its assignment, and is rejoining
+			return m, false, kafkaprotocol.ErrorCodeNone, ""
+		}
+	} else {
+		memberID = generateMemberID(clientID)
+	}
+	m := &consumerMember{
+		id:            memberID,
+		previousEpoch: -1,
+		instanceID:    instanceID,
+		clientID:      clientID,
+		assigned:      topicPartitions{},
+	}
+	cg.members[memberID] = m
+	if instanceID != "" {
+		cg.staticMembers[instanceID] = memberID
+	}
+	return m, true, kafkaprotocol.ErrorCodeNone, ""
+}
+
+func (cg *consumerGroup) replaceMemberID(m *consumerMember, newMemberID string) {
+	cg.g.gc.cancelTimer(m.id)
+	cg.g.gc.cancelTimer(revocationTimerKey(m.id))
+	delete(cg.members, m.id)
+	if target, ok := cg.targetAssignment[m.id]; ok {
+		delete(cg.targetAssignment, m.id)
+		cg.targetAssignment[newMemberID] = target
+	}
+	m.id = newMemberID
+	m.left = false
+	m.assignmentSent = false
+	cg.dirty = true
+	cg.members[newMemberID] = m
+	cg.staticMembers[m.instanceID] = newMemberID
+	for _, tps := range []topicPartitions{m.assigned, m.pendingRevocation} {
+		for topicID, partitionIDs := range tps {
+			for _, partitionID := range partitionIDs {
+				cg.partitionOwners[topicPartition{topicID: topicID, partitionID: partitionID}] = newMemberID
+			}
+		}
+	}
+	if len(m.pendingRevocation) > 0 {
+		cg.scheduleRevocationTimeout(m)
+	}
+}
+
+func (cg *consumerGroup) leave(req *kafkaprotocol.ConsumerGroupHeartbeatRequest) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	memberID := common.SafeDerefStringPtr(req.MemberId)
+	m, ok := cg.members[memberID]
+	if !ok {
+		return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeUnknownMemberID, "")
+	}
+	if req.InstanceId != nil && cg.staticMembers[*req.InstanceId] != memberID {
+		return consumerGroupHeartbeatError(kafkaprotocol.ErrorCodeFencedInstanceID, "")
+	}
+	if req.MemberEpoch == leaveGroupStaticMemberEpoch {
+		// The member keeps its assignment until it rejoins or its session times out
+		m.left = true
+		cg.dirty = true
+	} else {
+		cg.removeMember(m)
+	}
+	return &kafkaprotocol.ConsumerGroupHeartbeatResponse{
+		MemberId:    common.StrPtr(memberID),
+		MemberEpoch: req.MemberEpoch,
+	}
+}
+
+func (cg *consumerGroup) removeMember(m *consumerMember) {
+	cg.setAssigned(m, nil)
+	cg.setPendingRevocation(m, nil)
+	delete(cg.members, m.id)
+	delete(cg.targetAssignment, m.id)
+	if m.instanceID != "" && cg.staticMembers[m.instanceID] == m.id {
+		delete(cg.staticMembers, m.instanceID)
+	}
+	cg.g.gc.cancelTimer(m.id)
+	cg.g.gc.cancelTimer(revocationTimerKey(m.id))
+	cg.epoch++
+	cg.dirty = true
+}
+
+func (cg *consumerGroup) sessionTimeoutAction(memberID string) func() {
+	return func() {
+		cg.g.lock.Lock()
+		defer cg.g.lock.Unlock()
+		if cg.g.stopped {
+			return
+		}
+		m, ok := cg.members[memberID]
+		if !ok {
+			return
+		}
+		log.Debugf("group %s member %s session timed out", cg.g.id, memberID)
+		cg.removeMember(m)
+		cg.persistIfDirty()
+	}
+}
+
+// isValidEpoch returns true if the member can send a heartbeat with the epoch. A member can still send its previous
+// epoch if the response to its last heartbeat was lost, as long as it does not own partitions which it has since been
+// asked to revoke.
+func (m *consumerMember) isValidEpoch(epoch int32, owned topicPartitions) bool {
+	if epoch == m.epoch {
+		return true
+	}
+	return epoch == m.previousEpoch && (owned == nil || owned.isSubsetOf(m.assigned))
+}
+
+// updateSubscription updates the member from the heartbeat. It returns true if the group needs a new target assignment,
+// and true if the member has changed. Fields which have not changed since the last heartbeat are null.
+func (m *consumerMember) updateSubscription(req *kafkaprotocol.ConsumerGroupHeartbeatRequest) (bool, bool) {
+	changed := false
+	memberChanged := false
+	if req.RebalanceTimeoutMs != -1 {
+		rebalanceTimeout := time.Duration(req.RebalanceTimeoutMs) * time.Millisecond
+		memberChanged = memberChanged || rebalanceTimeout != m.rebalanceTimeout
+		m.rebalanceTimeout = rebalanceTimeout
+	}
+	if req.RackId != nil {
+		memberChanged = memberChanged || *req.RackId != m.rackID
+		m.rackID = *req.RackId
+	}
+	if req.SubscribedTopicNames != nil {
+		topicNames := make([]string, len(req.SubscribedTopicNames))
+		for i, topicName := range req.SubscribedTopicNames {
+			topicNames[i] = common.SafeDerefStringPtr(topicName)
+		}
+		sort.Strings(topicNames)
+		if !stringSlicesEqual(topicNames, m.subscribedTopicNames) {
+			m.subscribedTopicNames = topicNames
+			changed = true
+		}
+	}
+	if req.ServerAssignor != nil && *req.ServerAssignor != m.serverAssignor {
+		m.serverAssignor = *req.ServerAssignor
+		changed = true
+	}
+	return changed, memberChanged || changed
+}
+
+// refreshSubscribedTopics looks up the topics which the members are subscribed to and returns true if any have been
+// created, deleted or had partitions added since the last time
+func (cg *consumerGroup) refreshSubscribedTopics() (bool, error) {
+	subscribedTopics := map[string]topicmeta.TopicInfo{}
+	for _, m := range cg.members {
+		for _, topicName := range m.subscribedTopicNames {
+			if _, ok := subscribedTopics[topicName]; ok {
+				continue
+			}
+			info, exists, err := cg.g.gc.topicProvider.GetTopicInfo(topicName)
+			if err != nil {
+				return false, err
+			}
+			if exists {
+				subscribedTopics[topicName] = info
+				cg.topicNames[info.ID] = topicName
+			}
+		}
+	}
+	changed := len(subscribedTopics) != len(cg.subscribedTopics)
+	if !changed {
+		for topicName, info := range subscribedTopics {
+			prev, ok := cg.subscribedTopics[topicName]
+			if !ok || prev.ID != info.ID || prev.PartitionCount != info.PartitionCount {
+				changed = true
+				break
+			}
+		}
+	}
+	cg.subscribedTopics = subscribedTopics
+	return changed, nil
+}
+
+// chooseAssignor chooses the assignor which is specified by the most members
+func (cg *consumerGroup) chooseAssignor() string {
+	votes := map[string]int{}
+	for _, m := range cg.members {
+		if m.serverAssignor != "" {
+			votes[m.serverAssignor]++
+		}
+	}
+	chosen := defaultAssignorName
+	maxVotes := 0
+	for name, count := range votes {
+		if count > maxVotes || (count == maxVotes && name < chosen) {
+			chosen = name
+			maxVotes = count
+		}
+	}
+	return chosen
+}
+
+func (cg *consumerGroup) computeTargetAssignment() {
+	partitionCounts := make(map[int]int, len(cg.subscribedTopics))
+	for _, info := range cg.subscribedTopics {
+		partitionCounts[info.ID] = info.PartitionCount
+	}
+	memberIDs := make([]string, 0, len(cg.members))
+	for memberID := range cg.members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+	members := make([]assignmentMember, len(memberIDs))
+	for i, memberID := range memberIDs {
+		m := cg.members[memberID]
+		members[i] = assignmentMember{memberID: memberID, previous: cg.targetAssignment[memberID]}
+		for _, topicName := range m.subscribedTopicNames {
+			if info, ok := cg.subscribedTopics[topicName]; ok {
+				members[i].topicIDs = append(members[i].topicIDs, info.ID)
+			}
+		}
+	}
+	cg.assignorName = cg.chooseAssignor()
+	cg.targetAssignment = assignors[cg.assignorName](members, partitionCounts)
+	cg.assignmentEpoch = cg.epoch
+	cg.dirty = true
+	log.Debugf("group %s computed target assignment at epoch %d with %s assignor", cg.g.id, cg.epoch,
+		cg.assignorName)
+}
+
+// reconcile moves the member towards its target assignment. Partitions which are not in the target assignment are
+// revoked first, and the member stays at its current epoch until it reports that it no longer owns them. Partitions in
+// the target assignment are then assigned as soon as their previous owners have revoked them.
+func (cg *consumerGroup) reconcile(m *consumerMember, owned topicPartitions) {
+	if len(m.pendingRevocation) > 0 {
+		if owned == nil || owned.containsAny(m.pendingRevocation) {
+			// Still waiting for the member to revoke
+			return
+		}
+		cg.setPendingRevocation(m, nil)
+		cg.g.gc.cancelTimer(revocationTimerKey(m.id))
+	}
+	target := cg.targetAssignment[m.id]
+	revoked := m.assigned.subtract(target)
+	if len(revoked) > 0 {
+		cg.setAssigned(m, m.assigned.subtract(revoked))
+		cg.setPendingRevocation(m, revoked)
+		m.assignmentSent = false
+		cg.scheduleRevocationTimeout(m)
+		return
+	}
+	if m.epoch != cg.assignmentEpoch {
+		m.previousEpoch = m.epoch
+		m.epoch = cg.assignmentEpoch
+		cg.dirty = true
+	}
+	added := topicPartitions{}
+	for topicID, partitionIDs := range target.subtract(m.assigned) {
+		for _, partitionID := range partitionIDs {
+			if _, owned := cg.partitionOwners[topicPartition{topicID: topicID, partitionID: partitionID}]; !owned {
+				added.add(topicID, partitionID)
+			}
+		}
+	}
+	if len(added) > 0 {
+		cg.setAssigned(m, m.assigned.union(added))
+		m.assignmentSent = false
+	}
+}
+
+// scheduleRevocationTimeout fences the member if it does not revoke its partitions within its rebalance timeout
+func (cg *consumerGroup) scheduleRevocationTimeout(m *consumerMember) {
+	memberID := m.id
+	epoch := m.epoch
+	cg.g.gc.rescheduleTimer(revocationTimerKey(memberID), m.rebalanceTimeout, func() {
+		cg.g.lock.Lock()
+		defer cg.g.lock.Unlock()
+		if cg.g.stopped {
+			return
+		}
+		m, ok := cg.members[memberID]
+		if !ok || m.epoch != epoch || len(m.pendingRevocation) == 0 {
+			return
+		}
+		log.Warnf("group %s member %s did not revoke partitions within rebalance timeout and has been fenced",
+			cg.g.id, memberID)
+		cg.removeMember(m)
+		cg.persistIfDirty()
+	})
+}
+
+func revocationTimerKey(memberID string) string {
+	return memberID + ".revocation"
+}
+
+func (cg *consumerGroup) setAssigned(m *consumerMember, assigned topicPartitions) {
+	cg.updatePartitionOwners(m.id, m.assigned, assigned)
+	m.assigned = assigned
+	cg.dirty = true
+}
+
+func (cg *consumerGroup) setPendingRevocation(m *consumerMember, pendingRevocation topicPartitions) {
+	cg.updatePartitionOwners(m.id, m.pendingRevocation, pendingRevocation)
+	m.pendingRevocation = pendingRevocation
+	cg.dirty = true
+}
+
+func (cg *consumerGroup) updatePartitionOwners(memberID string, prev topicPartitions, next topicPartitions) {
+	for topicID, partitionIDs := range prev {
+		for _, partitionID := range partitionIDs {
+			delete(cg.partitionOwners, topicPartition{topicID: topicID, partitionID: partitionID})
+		}
+	}
+	for topicID, partitionIDs := range next {
+		for _, partitionID := range partitionIDs {
+			cg.partitionOwners[topicPartition{topicID: topicID, partitionID: partitionID}] = memberID
+		}
+	}
+}
+
+func (cg *consumerGroup) stateName() string {
+	if len(cg.members) == 0 {
+		return "Empty"
+	}
+	if cg.epoch > cg.assignmentEpoch {
+		return "Assigning"
+	}
+	for memberID, m := range cg.members {
+		if m.epoch != cg.assignmentEpoch || len(m.pendingRevocation) > 0 ||
+			!m.assigned.equals(cg.targetAssignment[memberID]) {
+			return "Reconciling"
+		}
+	}
+	return "Stable"
+}
+
+func (cg *consumerGroup) subscribedTopicNames() map[string]struct{} {
+	topics := map[string]struct{}{}
+	for _, m := range cg.members {
+		for _, topicName := range m.subscribedTopicNames {
+			topics[topicName] = struct{}{}
+		}
+	}
+	return topics
+}
+
+func (g *group) hasConsumerMember(memberID string) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer == nil {
+		return false
+	}
+	_, ok := g.consumer.members[memberID]
+	return ok
+}
+
+func (cg *consumerGroup) stop() {
+	for memberID := range cg.members {
+		cg.g.gc.cancelTimer(memberID)
+		cg.g.gc.cancelTimer(revocationTimerKey(memberID))
+	}
+}
+
+// checkOffsetCommit checks that offsets can be committed by the member at the epoch. Offsets can be committed without
+// a member, e.g. by admin tools, only if the group is empty.
+func (cg *consumerGroup) checkOffsetCommit(memberID string, epoch int32) int {
+	if memberID == "" && epoch < 0 && len(cg.members) == 0 {
+		return kafkaprotocol.ErrorCodeNone
+	}
+	return cg.checkMemberEpoch(memberID, epoch)
+}
+
+func (cg *consumerGroup) checkMemberEpoch(memberID string, epoch int32) int {
+	m, ok := cg.members[memberID]
+	if !ok {
+		return kafkaprotocol.ErrorCodeUnknownMemberID
+	}
+	if epoch != m.epoch {
+		return kafkaprotocol.ErrorCodeStaleMemberEpoch
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
+func ownedPartitions(req *kafkaprotocol.ConsumerGroupHeartbeatRequest) topicPartitions {
+	if req.TopicPartitions == nil {
+		return nil
+	}
+	owned := topicPartitions{}
+	for _, topicData := range req.TopicPartitions {
+		topicID, ok := topicmeta.TopicIDFromKafkaTopicID(topicData.TopicId)
+		if !ok {
+			continue
+		}
+		for _, partitionID := range topicData.Partitions {
+			owned.add(topicID, partitionID)
+		}
+	}
+	return owned
+}
+
+func (t topicPartitions) add(topicID int, partitionID int32) {
+	partitionIDs := t[topicID]
+	index := sort.Search(len(partitionIDs), func(i int) bool {
+		return partitionIDs[i] >= partitionID
+	})
+	if index < len(partitionIDs) && partitionIDs[index] == partitionID {
+		return
+	}
+	partitionIDs = append(partitionIDs, 0)
+	copy(partitionIDs[index+1:], partitionIDs[index:])
+	partitionIDs[index] = partitionID
+	t[topicID] = partitionIDs
+}
+
+func (t topicPartitions) contains(topicID int, partitionID int32) bool {
+	partitionIDs := t[topicID]
+	index := sort.Search(len(partitionIDs), func(i int) bool {
+		return partitionIDs[i] >= partitionID
+	})
+	return index < len(partitionIDs) && partitionIDs[index] == partitionID
+}
+
+func (t topicPartitions) containsAny(other topicPartitions) bool {
+	for topicID, partitionIDs := range other {
+		for _, partitionID := range partitionIDs {
+			if t.contains(topicID, partitionID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t topicPartitions) isSubsetOf(other topicPartitions) bool {
+	for topicID, partitionIDs := range t {
+		for _, partitionID := range partitionIDs {
+			if !other.contains(topicID, partitionID) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (t topicPartitions) equals(other topicPartitions) bool {
+	return t.isSubsetOf(other) && other.isSubsetOf(t)
+}
+
+// subtract returns the partitions in t which are not in other
+func (t topicPartitions) subtract(other topicPartitions) topicPartitions {
+	result := topicPartitions{}
+	for topicID, partitionIDs := range t {
+		for _, partitionID := range partitionIDs {
+			if !other.contains(topicID, partitionID) {
+				result[topicID] = append(result[topicID], partitionID)
+			}
+		}
+	}
+	return result
+}
+
+func (t topicPartitions) union(other topicPartitions) topicPartitions {
+	result := make(topicPartitions, len(t))
+	for topicID, partitionIDs := range t {
+		result[topicID] = append([]int32(nil), partitionIDs...)
+	}
+	for topicID, partitionIDs := range other {
+		for _, partitionID := range partitionIDs {
+			result.add(topicID, partitionID)
+		}
+	}
+	return result
+}
+
+func (t topicPartitions) sortedTopicIDs() []int {
+	topicIDs := make([]int, 0, len(t))
+	for topicID := range t {
+		topicIDs = append(topicIDs, topicID)
+	}
+	sort.Ints(topicIDs)
+	return topicIDs
+}
+
+func (t topicPartitions) toHeartbeatResponse() []kafkaprotocol.ConsumerGroupHeartbeatResponseTopicPartitions {
+	result := make([]kafkaprotocol.ConsumerGroupHeartbeatResponseTopicPartitions, 0, len(t))
+	for _, topicID := range t.sortedTopicIDs() {
+		result = append(result, kafkaprotocol.ConsumerGroupHeartbeatResponseTopicPartitions{
+			TopicId:    kafkaTopicID(topicID),
+			Partitions: t[topicID],
+		})
+	}
+	return result
+}
+
+func kafkaTopicID(topicID int) []byte {
+	info := topicmeta.TopicInfo{ID: topicID}
+	return info.KafkaTopicID()
+}
+
+func stringSlicesEqual(s1 []string, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i, s := range s1 {
+		if s2[i] != s {
+			return false
+		}
+	}
+	return true
+}
@@ -3,11 +3,13 @@ package group
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/metrics"
 	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/topicmeta"
@@ -29,9 +31,14 @@ type Coordinator struct {
 	connCachesLock sync.RWMutex
 	connCaches     map[string]*transport.ConnectionCache
 	tableGetter    sst.TableGetter
+	filterGetter   sst.BloomFilterGetter
 	groups         map[string]*group
 	timers         sync.Map
 	membership     cluster.MembershipState
+	// membershipVersion is incremented when the cluster membership changes, after which coordination of groups may
+	// have moved
+	membershipVersion int64
+	rebalances        prometheus.Counter
 }
 
 type topicInfoProvider interface {
@@ -46,6 +53,8 @@ type Conf struct {
 	InitialJoinDelay               time.Duration
 	NewMemberJoinTimeout           time.Duration
 	MaxPusherConnectionsPerAddress int
+	ConsumerGroupSessionTimeout    time.Duration
+	ConsumerGroupHeartbeatInterval time.Duration
 }
 
 func NewConf() Conf {
@@ -57,10 +66,15 @@ func NewConf() Conf {
 		InitialJoinDelay:               DefaultInitialJoinDelay,
 		NewMemberJoinTimeout:           DefaultNewMemberJoinTimeout,
 		MaxPusherConnectionsPerAddress: DefaultMaxPusherConnectionsPerAddresss,
+		ConsumerGroupSessionTimeout:    DefaultConsumerGroupSessionTimeout,
+		ConsumerGroupHeartbeatInterval: DefaultConsumerGroupHeartbeatInterval,
 	}
 }
 
 func (c *Conf) Validate() error {
+	if c.ConsumerGroupHeartbeatInterval >= c.ConsumerGroupSessionTimeout {
+		return errors.New("consumer group heartbeat interval must be less than consumer group session timeout")
+	}
 	return nil
 }
 
@@ -72,10 +86,13 @@ const (
 	DefaultMaxPusherConnectionsPerAddresss = 10
 	DeafultDefaultRebalanceTimeout         = 5 * time.Minute
 	DefaultDefaultSessionTimeout           = 45 * time.Second
+	DefaultConsumerGroupSessionTimeout     = 45 * time.Second
+	DefaultConsumerGroupHeartbeatInterval  = 5 * time.Second
 )
 
 func NewCoordinator(cfg Conf, topicProvider topicInfoProvider, controlClientCache *control.ClientCache,
-	connFactory transport.ConnectionFactory, tableGetter sst.TableGetter) (*Coordinator, error) {
+	connFactory transport.ConnectionFactory, tableGetter sst.TableGetter,
+	filterGetter sst.BloomFilterGetter) (*Coordinator, error) {
 	return &Coordinator{
 		cfg:           cfg,
 		groups:        map[string]*group{},
@@ -83,10 +100,23 @@ func NewCoordinator(cfg Conf, topicProvider topicInfoProvider, controlClientCach
 		clientCache:   controlClientCache,
 		connFactory:   connFactory,
 		tableGetter:   tableGetter,
+		filterGetter:  filterGetter,
 		connCaches:    map[string]*transport.ConnectionCache{},
+		rebalances: metrics.NewCounter("group_coordinator", "rebalances_total",
+			"number of consumer group rebalances completed"),
 	}, nil
 }
 
+func (c *Coordinator) RegisterMetrics(registry *metrics.Registry) {
+	registry.MustRegister(c.rebalances,
+		metrics.NewGaugeFunc("group_coordinator", "groups", "number of consumer groups coordinated by this agent",
+			func() float64 {
+				c.lock.RLock()
+				defer c.lock.RUnlock()
+				return float64(len(c.groups))
+			}))
+}
+
 func (c *Coordinator) SetKafkaAddress(address string) {
 	c.kafkaAddress = address
 }
@@ -118,6 +148,7 @@ func (c *Coordinator) MembershipChanged(_ int32, memberState cluster.MembershipS
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.membership = memberState
+	c.membershipVersion++
 	return nil
 }
 
@@ -128,55 +159,61 @@ func (c *Coordinator) checkStarted() error {
 	return nil
 }
 
-func (c *Coordinator) HandleFindCoordinatorRequest(req *kafkaprotocol.FindCoordinatorRequest,
+func (c *Coordinator) HandleFindCoordinatorRequest(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.FindCoordinatorRequest,
 	completionFunc func(resp *kafkaprotocol.FindCoordinatorResponse) error) error {
-	log.Debugf("received FindCoordinatorRequest on address: %s for key %s key type: %d", c.kafkaAddress, common.SafeDerefStringPtr(req.Key), req.KeyType)
 	var resp kafkaprotocol.FindCoordinatorResponse
+	if hdr.RequestApiVersion >= 4 {
+		// In version 4 and higher, coordinators can be found for multiple keys in one request
+		resp.Coordinators = make([]kafkaprotocol.FindCoordinatorResponseCoordinator, len(req.CoordinatorKeys))
+		for i, key := range req.CoordinatorKeys {
+			coordinator := &resp.Coordinators[i]
+			coordinator.Key = key
+			coordinator.NodeId, coordinator.Host, coordinator.Port, coordinator.ErrorCode, coordinator.ErrorMessage =
+				c.findCoordinatorForKey(req.KeyType, common.SafeDerefStringPtr(key))
+		}
+	} else {
+		resp.NodeId, resp.Host, resp.Port, resp.ErrorCode, resp.ErrorMessage =
+			c.findCoordinatorForKey(req.KeyType, common.SafeDerefStringPtr(req.Key))
+	}
+	return completionFunc(&resp)
+}
+
+func (c *Coordinator) findCoordinatorForKey(keyType int8, key string) (nodeID int32, host *string, port int32,
+	errCode int16, errMsg *string) {
+	log.Debugf("received FindCoordinatorRequest on address: %s for key %s key type: %d", c.kafkaAddress, key, keyType)
+	// host is not nullable so must be provided in case of error
+	host = common.StrPtr("")
 	var prefix string
-	if req.KeyType == 0 {
+	if keyType == 0 {
 		// group coordinator
 		prefix = "g."
-	} else if req.KeyType == 1 {
+	} else if keyType == 1 {
 		// transaction coordinator
 		prefix = "t."
 	} else {
-		resp.ErrorCode = kafkaprotocol.ErrorCodeInvalidRequest
-		resp.ErrorMessage = common.StrPtr(fmt.Sprintf("invalid key type %d", req.KeyType))
+		return 0, host, 0, kafkaprotocol.ErrorCodeInvalidRequest, common.StrPtr(fmt.Sprintf("invalid key type %d", keyType))
 	}
-	if resp.ErrorCode == kafkaprotocol.ErrorCodeNone {
-		key := common.SafeDerefStringPtr(req.Key)
-		memberID, address, err := c.findCoordinator(prefix + key)
-		if err != nil {
-			if common.IsUnavailableError(err) {
-				log.Warnf("failed to find coordinator: %v", err)
-				resp.ErrorCode = kafkaprotocol.ErrorCodeCoordinatorNotAvailable
-				resp.ErrorMessage = common.StrPtr("no coordinator available")
-			} else {
-				log.Errorf("failed to find coordinator: %v", err)
-				resp.ErrorCode = kafkaprotocol.ErrorCodeUnknownServerError
-			}
-		} else {
-			host, sPort, err := net.SplitHostPort(address)
-			var port int
-			if err == nil {
-				port, err = strconv.Atoi(sPort)
-			}
-			if err != nil {
-				log.Errorf("failed to parse address: %v", err)
-				resp.ErrorCode = kafkaprotocol.ErrorCodeUnknownServerError
-			} else {
-				resp.NodeId = memberID
-				resp.Host = &host
-				resp.Port = int32(port)
-				log.Debugf("coordinator for %s is node %d host:%s port:%d", common.SafeDerefStringPtr(req.Key), memberID, host, port)
-			}
+	memberID, address, err := c.findCoordinator(prefix + key)
+	if err != nil {
+		if common.IsUnavailableError(err) {
+			log.Warnf("failed to find coordinator: %v", err)
+			return 0, host, 0, kafkaprotocol.ErrorCodeCoordinatorNotAvailable, common.StrPtr("no coordinator available")
 		}
+		log.Errorf("failed to find coordinator: %v", err)
+		return 0, host, 0, kafkaprotocol.ErrorCodeUnknownServerError, nil
 	}
-	if resp.ErrorCode != kafkaprotocol.ErrorCodeNone {
-		// host is not nullable
-		resp.Host = common.StrPtr("")
+	sHost, sPort, err := net.SplitHostPort(address)
+	var iPort int
+	if err == nil {
+		iPort, err = strconv.Atoi(sPort)
 	}
-	return completionFunc(&resp)
+	if err != nil {
+		log.Errorf("failed to parse address: %v", err)
+		return 0, host, 0, kafkaprotocol.ErrorCodeUnknownServerError, nil
+	}
+	log.Debugf("coordinator for %s is node %d host:%s port:%d", key, memberID, sHost, iPort)
+	return memberID, &sHost, int32(iPort), kafkaprotocol.ErrorCodeNone, nil
 }
 
 func (c *Coordinator) findCoordinator(key string) (int32, string, error) {
@@ -211,19 +248,21 @@ func (c *Coordinator) HandleJoinGroupRequest(hdr *kafkaprotocol.RequestHeader, r
 		sessionTimeout = time.Duration(req.SessionTimeoutMs) * time.Millisecond
 	}
 	c.joinGroup(hdr.RequestApiVersion, common.SafeDerefStringPtr(req.GroupId),
-		common.SafeDerefStringPtr(hdr.ClientId), common.SafeDerefStringPtr(req.MemberId), common.SafeDerefStringPtr(req.ProtocolType),
-		infos, sessionTimeout, rebalanceTimeout, func(result JoinResult) {
+		common.SafeDerefStringPtr(hdr.ClientId), common.SafeDerefStringPtr(req.MemberId),
+		common.SafeDerefStringPtr(req.GroupInstanceId), common.SafeDerefStringPtr(req.ProtocolType), infos, sessionTimeout, rebalanceTimeout, func(result JoinResult) {
 			var resp kafkaprotocol.JoinGroupResponse
 			resp.ErrorCode = int16(result.ErrorCode)
 			if resp.ErrorCode == kafkaprotocol.ErrorCodeNone {
 				resp.GenerationId = int32(result.GenerationID)
 				resp.Leader = &result.LeaderMemberID
+				resp.ProtocolType = &result.ProtocolType
 				resp.ProtocolName = &result.ProtocolName
 				resp.MemberId = &result.MemberID
 				resp.Members = make([]kafkaprotocol.JoinGroupResponseJoinGroupResponseMember, len(result.Members))
 				for i, m := range result.Members {
 					memberID := m.MemberID
 					resp.Members[i].MemberId = &memberID
+					resp.Members[i].GroupInstanceId = m.GroupInstanceID
 					resp.Members[i].Metadata = m.MetaData
 				}
 			} else {
@@ -239,8 +278,9 @@ func (c *Coordinator) HandleJoinGroupRequest(hdr *kafkaprotocol.RequestHeader, r
 	return nil
 }
 
-func (c *Coordinator) joinGroup(apiVersion int16, groupID string, clientID string, memberID string, protocolType string,
-	protocols []ProtocolInfo, sessionTimeout time.Duration, reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
+func (c *Coordinator) joinGroup(apiVersion int16, groupID string, clientID string, memberID string,
+	groupInstanceID string, protocolType string, protocols []ProtocolInfo, sessionTimeout time.Duration,
+	reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if err := c.checkStarted(); err != nil {
@@ -252,27 +292,16 @@ func (c *Coordinator) joinGroup(apiVersion int16, groupID string, clientID strin
 		c.sendJoinError(completionFunc, kafkaprotocol.ErrorCodeInvalidSessionTimeout)
 		return
 	}
-	g, ok := c.getGroup(groupID)
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		c.sendJoinError(completionFunc, int(errCode))
+		return
+	}
 	if !ok {
-		cl, err := c.clientCache.GetClient()
-		if err != nil {
-			log.Warnf("failed to get controller client to get coordinator info: %v", err)
-			c.sendJoinError(completionFunc, kafkaprotocol.ErrorCodeCoordinatorNotAvailable)
-			return
-		}
-		_, address, groupEpoch, err := cl.GetCoordinatorInfo(createCoordinatorKey(groupID))
-		if err != nil {
-			log.Warnf("failed to get coordinator info: %v", err)
-			c.sendJoinError(completionFunc, kafkaprotocol.ErrorCodeCoordinatorNotAvailable)
-			return
-		}
-		if address != c.kafkaAddress {
-			c.sendJoinError(completionFunc, kafkaprotocol.ErrorCodeNotCoordinator)
-			return
-		}
-		g = c.createGroup(groupID, groupEpoch)
+		g = c.addGroup(g)
 	}
-	g.Join(apiVersion, clientID, memberID, protocolType, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
+	g.Join(apiVersion, clientID, memberID, groupInstanceID, protocolType, protocols, sessionTimeout, reBalanceTimeout,
+		completionFunc)
 }
 
 func (c *Coordinator) HandleSyncGroupRequest(req *kafkaprotocol.SyncGroupRequest,
@@ -285,10 +314,19 @@ func (c *Coordinator) HandleSyncGroupRequest(req *kafkaprotocol.SyncGroupRequest
 			Assignment: assignment.Assignment,
 		}
 	}
-	c.syncGroup(*req.GroupId, *req.MemberId, int(req.GenerationId), assignments, func(errorCode int, assignment []byte) {
+	protocolType, protocolName, ok := c.groupProtocol(*req.GroupId)
+	if ok && ((req.ProtocolType != nil && *req.ProtocolType != protocolType) ||
+		(req.ProtocolName != nil && *req.ProtocolName != protocolName)) {
+		// In version 5 and higher the member provides the protocol type and name, which must match those of the group
+		return completionFunc(&kafkaprotocol.SyncGroupResponse{ErrorCode: kafkaprotocol.ErrorCodeInconsistentGroupProtocol})
+	}
+	groupInstanceID := common.SafeDerefStringPtr(req.GroupInstanceId)
+	c.syncGroup(*req.GroupId, *req.MemberId, groupInstanceID, int(req.GenerationId), assignments, func(errorCode int, assignment []byte) {
 		var resp kafkaprotocol.SyncGroupResponse
 		resp.ErrorCode = int16(errorCode)
 		if resp.ErrorCode == kafkaprotocol.ErrorCodeNone {
+			resp.ProtocolType = &protocolType
+			resp.ProtocolName = &protocolName
 			resp.Assignment = assignment
 		}
 		if err := completionFunc(&resp); err != nil {
@@ -298,8 +336,8 @@ func (c *Coordinator) HandleSyncGroupRequest(req *kafkaprotocol.SyncGroupRequest
 	return nil
 }
 
-func (c *Coordinator) syncGroup(groupID string, memberID string, generationID int, assignments []AssignmentInfo,
-	completionFunc SyncCompletion) {
+func (c *Coordinator) syncGroup(groupID string, memberID string, groupInstanceID string, generationID int,
+	assignments []AssignmentInfo, completionFunc SyncCompletion) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if err := c.checkStarted(); err != nil {
@@ -311,24 +349,39 @@ func (c *Coordinator) syncGroup(groupID string, memberID string, generationID in
 		c.sendSyncError(completionFunc, kafkaprotocol.ErrorCodeUnknownMemberID)
 		return
 	}
-	g, ok := c.getGroup(groupID)
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		c.sendSyncError(completionFunc, int(errCode))
+		return
+	}
 	if !ok {
 		c.sendSyncError(completionFunc, kafkaprotocol.ErrorCodeGroupIDNotFound)
 		return
 	}
-	g.Sync(memberID, generationID, assignments, completionFunc)
+	g.Sync(memberID, groupInstanceID, generationID, assignments, completionFunc)
+}
+
+func (c *Coordinator) groupProtocol(groupID string) (string, string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	g, ok := c.getGroup(groupID)
+	if !ok {
+		return "", "", false
+	}
+	protocolType, protocolName := g.getProtocol()
+	return protocolType, protocolName, true
 }
 
 func (c *Coordinator) HandleHeartbeatRequest(req *kafkaprotocol.HeartbeatRequest,
 	completionFunc func(resp *kafkaprotocol.HeartbeatResponse) error) error {
-	errCode := c.heartbeatGroup(common.SafeDerefStringPtr(req.GroupId),
-		common.SafeDerefStringPtr(req.MemberId), int(req.GenerationId))
+	errCode := c.heartbeatGroup(common.SafeDerefStringPtr(req.GroupId), common.SafeDerefStringPtr(req.MemberId),
+		common.SafeDerefStringPtr(req.GroupInstanceId), int(req.GenerationId))
 	return completionFunc(&kafkaprotocol.HeartbeatResponse{
 		ErrorCode: int16(errCode),
 	})
 }
 
-func (c *Coordinator) heartbeatGroup(groupID string, memberID string, generationID int) int {
+func (c *Coordinator) heartbeatGroup(groupID string, memberID string, groupInstanceID string, generationID int) int {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if err := c.checkStarted(); err != nil {
@@ -338,34 +391,62 @@ func (c *Coordinator) heartbeatGroup(groupID string, memberID string, generation
 	if memberID == "" {
 		return kafkaprotocol.ErrorCodeUnknownMemberID
 	}
-	g, ok := c.getGroup(groupID)
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return int(errCode)
+	}
 	if !ok {
 		return kafkaprotocol.ErrorCodeGroupIDNotFound
 	}
-	return g.Heartbeat(memberID, generationID)
+	return g.Heartbeat(memberID, groupInstanceID, generationID)
 }
 
-func (c *Coordinator) HandleLeaveGroupRequest(req *kafkaprotocol.LeaveGroupRequest,
+func (c *Coordinator) HandleLeaveGroupRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.LeaveGroupRequest,
 	completionFunc func(resp *kafkaprotocol.LeaveGroupResponse) error) error {
-	leaveInfos := []MemberLeaveInfo{{MemberID: *req.MemberId}}
-	errorCode := c.leaveGroup(*req.GroupId, leaveInfos)
 	var resp kafkaprotocol.LeaveGroupResponse
-	resp.ErrorCode = errorCode
+	if hdr.RequestApiVersion >= 3 {
+		// In version 3 and higher, multiple members can leave in one request
+		leaveInfos := make([]MemberLeaveInfo, len(req.Members))
+		resp.Members = make([]kafkaprotocol.LeaveGroupResponseMemberResponse, len(req.Members))
+		for i, member := range req.Members {
+			leaveInfos[i] = MemberLeaveInfo{
+				MemberID:        common.SafeDerefStringPtr(member.MemberId),
+				GroupInstanceID: member.GroupInstanceId,
+			}
+			resp.Members[i].MemberId = member.MemberId
+			resp.Members[i].GroupInstanceId = member.GroupInstanceId
+		}
+		var memberErrorCodes []int16
+		resp.ErrorCode, memberErrorCodes = c.leaveGroup(common.SafeDerefStringPtr(req.GroupId), leaveInfos)
+		for i, errorCode := range memberErrorCodes {
+			resp.Members[i].ErrorCode = errorCode
+		}
+	} else {
+		leaveInfos := []MemberLeaveInfo{{MemberID: common.SafeDerefStringPtr(req.MemberId)}}
+		var memberErrorCodes []int16
+		resp.ErrorCode, memberErrorCodes = c.leaveGroup(common.SafeDerefStringPtr(req.GroupId), leaveInfos)
+		if resp.ErrorCode == kafkaprotocol.ErrorCodeNone {
+			resp.ErrorCode = memberErrorCodes[0]
+		}
+	}
 	return completionFunc(&resp)
 }
 
-func (c *Coordinator) leaveGroup(groupID string, leaveInfos []MemberLeaveInfo) int16 {
+func (c *Coordinator) leaveGroup(groupID string, leaveInfos []MemberLeaveInfo) (int16, []int16) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if err := c.checkStarted(); err != nil {
 		log.Warn("coordinator is not started")
-		return kafkaprotocol.ErrorCodeUnknownServerError
+		return kafkaprotocol.ErrorCodeUnknownServerError, nil
+	}
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return errCode, nil
 	}
-	g, ok := c.getGroup(groupID)
 	if !ok {
-		return kafkaprotocol.ErrorCodeGroupIDNotFound
+		return kafkaprotocol.ErrorCodeGroupIDNotFound, nil
 	}
-	return g.Leave(leaveInfos)
+	return kafkaprotocol.ErrorCodeNone, g.Leave(leaveInfos)
 }
 
 func (c *Coordinator) OffsetCommit(req *kafkaprotocol.OffsetCommitRequest) (*kafkaprotocol.OffsetCommitResponse, error) {
@@ -388,7 +469,10 @@ func (c *Coordinator) offsetCommit(transactional bool, req *kafkaprotocol.Offset
 		}
 	}
 	groupID := *req.GroupId
-	g, ok := c.getGroup(groupID)
+	g, ok, loadErrCode := c.getOrLoadGroup(groupID)
+	if loadErrCode != kafkaprotocol.ErrorCodeNone {
+		return fillAllErrorCodesForOffsetCommit(req, int(loadErrCode)), nil
+	}
 	if !ok {
 		return fillAllErrorCodesForOffsetCommit(req, kafkaprotocol.ErrorCodeGroupIDNotFound), nil
 	}
@@ -450,23 +534,109 @@ func (c *Coordinator) OffsetFetch(req *kafkaprotocol.OffsetFetchRequest) (*kafka
 	if err := c.checkStarted(); err != nil {
 		return nil, err
 	}
+	if len(req.Groups) > 0 {
+		// In version 8 and higher, offsets can be fetched for multiple groups in one request
+		return c.offsetFetchGroups(req), nil
+	}
+	return c.offsetFetch(req), nil
+}
+
+func (c *Coordinator) offsetFetch(req *kafkaprotocol.OffsetFetchRequest) *kafkaprotocol.OffsetFetchResponse {
+	groupID := common.SafeDerefStringPtr(req.GroupId)
+	g, ok, errCode := c.getOrLoadGroup(groupID)
+	topics := req.Topics
 	var resp kafkaprotocol.OffsetFetchResponse
-	resp.Topics = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopic, len(req.Topics))
-	for i, topicData := range req.Topics {
-		resp.Topics[i].Name = req.Topics[i].Name
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		resp.ErrorCode = errCode
+		return &resp
+	}
+	if topics == nil && ok {
+		// In version 2 and higher, null topics means fetch the offsets for all topics
+		var err error
+		topics, err = g.committedOffsetTopics()
+		if err != nil {
+			resp.ErrorCode = offsetsErrorCode(err)
+			return &resp
+		}
+	}
+	resp.Topics = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopic, len(topics))
+	for i, topicData := range topics {
+		resp.Topics[i].Name = topicData.Name
 		resp.Topics[i].Partitions = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponsePartition, len(topicData.PartitionIndexes))
 		for j, index := range topicData.PartitionIndexes {
 			resp.Topics[i].Partitions[j].PartitionIndex = index
+			// We don't have leader epochs
+			resp.Topics[i].Partitions[j].CommittedLeaderEpoch = -1
 		}
 	}
-	groupID := common.SafeDerefStringPtr(req.GroupId)
-	g, ok := c.getGroup(groupID)
 	if !ok {
 		fillAllErrorCodesForOffsetFetch(&resp, kafkaprotocol.ErrorCodeGroupIDNotFound)
-		return &resp, nil
+		return &resp
 	}
-	g.offsetFetch(req, &resp)
-	return &resp, nil
+	g.offsetFetch(topics, &resp)
+	return &resp
+}
+
+func (c *Coordinator) offsetFetchGroups(req *kafkaprotocol.OffsetFetchRequest) *kafkaprotocol.OffsetFetchResponse {
+	var resp kafkaprotocol.OffsetFetchResponse
+	resp.Groups = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponseGroup, len(req.Groups))
+	for i, groupData := range req.Groups {
+		groupReq := kafkaprotocol.OffsetFetchRequest{GroupId: groupData.GroupId}
+		if groupData.Topics != nil {
+			groupReq.Topics = make([]kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic, len(groupData.Topics))
+			for j, topicData := range groupData.Topics {
+				groupReq.Topics[j] = kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic(topicData)
+			}
+		}
+		resp.Groups[i].GroupId = groupData.GroupId
+		if groupData.MemberId != nil {
+			// In version 9 and higher, members of consumer groups provide their member id and epoch
+			errCode := c.checkOffsetFetchMember(common.SafeDerefStringPtr(groupData.GroupId),
+				*groupData.MemberId, groupData.MemberEpoch)
+			if errCode != kafkaprotocol.ErrorCodeNone {
+				resp.Groups[i].ErrorCode = int16(errCode)
+				resp.Groups[i].Topics = []kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopics{}
+				continue
+			}
+		}
+		groupResp := c.offsetFetch(&groupReq)
+		resp.Groups[i].ErrorCode = groupResp.ErrorCode
+		resp.Groups[i].Topics = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponseTopics, len(groupResp.Topics))
+		for j, topicData := range groupResp.Topics {
+			resp.Groups[i].Topics[j].Name = topicData.Name
+			resp.Groups[i].Topics[j].Partitions = make([]kafkaprotocol.OffsetFetchResponseOffsetFetchResponsePartitions, len(topicData.Partitions))
+			for k, partitionData := range topicData.Partitions {
+				resp.Groups[i].Topics[j].Partitions[k] = kafkaprotocol.OffsetFetchResponseOffsetFetchResponsePartitions(partitionData)
+			}
+		}
+	}
+	return &resp
+}
+
+func (c *Coordinator) checkOffsetFetchMember(groupID string, memberID string, memberEpoch int32) int {
+	g, ok := c.getGroup(groupID)
+	if !ok {
+		return kafkaprotocol.ErrorCodeNone
+	}
+	return g.checkOffsetFetchMember(memberID, memberEpoch)
+}
+
+// checkCoordinator checks that this agent is the coordinator for the group and returns the group epoch if it is
+func (c *Coordinator) checkCoordinator(groupID string) (int, int16) {
+	cl, err := c.clientCache.GetClient()
+	if err != nil {
+		log.Warnf("failed to get controller client to get coordinator info: %v", err)
+		return 0, kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	_, address, groupEpoch, err := cl.GetCoordinatorInfo(createCoordinatorKey(groupID))
+	if err != nil {
+		log.Warnf("failed to get coordinator info: %v", err)
+		return 0, kafkaprotocol.ErrorCodeCoordinatorNotAvailable
+	}
+	if address != c.kafkaAddress {
+		return 0, kafkaprotocol.ErrorCodeNotCoordinator
+	}
+	return groupEpoch, kafkaprotocol.ErrorCodeNone
 }
 
 func (c *Coordinator) getGroup(groupID string) (*group, bool) {
@@ -498,23 +668,116 @@ func (c *Coordinator) groupHasMember(groupID string, memberID string) bool {
 	return g.hasMember(memberID)
 }
 
-func (c *Coordinator) createGroup(groupID string, groupEpoch int) *group {
+func (c *Coordinator) groupHasConsumerMember(groupID string, memberID string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	g, ok := c.getGroup(groupID)
+	if !ok {
+		return false
+	}
+	return g.hasConsumerMember(memberID)
+}
+
+// getOrLoadGroup returns the group, loading it from storage if it is not in memory, and returns false if the group does
+// not exist. In that case the returned group is a new group which has not been added to the coordinator.
+//
+// If the cluster membership has changed since the group was last used then coordination of the group may have moved to
+// another agent, and possibly back again, so the coordinator is checked again. If the group epoch has changed then
+// another coordinator may have changed the group, so the in-memory group is discarded and loaded again.
+func (c *Coordinator) getOrLoadGroup(groupID string) (*group, bool, int16) {
+	g, ok := c.getGroup(groupID)
+	if ok && g.membershipVersion.Load() == c.membershipVersion {
+		return g, true, kafkaprotocol.ErrorCodeNone
+	}
+	groupEpoch, errCode := c.checkCoordinator(groupID)
+	if errCode == kafkaprotocol.ErrorCodeNotCoordinator && ok {
+		c.removeStaleGroup(g)
+	}
+	if errCode != kafkaprotocol.ErrorCodeNone {
+		return nil, false, errCode
+	}
+	if ok {
+		if g.groupEpoch == groupEpoch {
+			g.membershipVersion.Store(c.membershipVersion)
+			return g, true, kafkaprotocol.ErrorCodeNone
+		}
+		c.removeStaleGroup(g)
+	}
+	g = c.newGroup(groupID, groupEpoch)
+	loaded, err := g.loadMetadata()
+	if err != nil {
+		log.Warnf("failed to load metadata for group %s: %v", groupID, err)
+		return nil, false, offsetsErrorCode(err)
+	}
+	if !loaded {
+		return g, false, kafkaprotocol.ErrorCodeNone
+	}
+	added := c.addGroup(g)
+	if added == g {
+		g.restoreTimers()
+		// Topics may have been deleted since the group was last coordinated by this agent
+		if err := g.reclaimDeletedTopicOffsets(); err != nil {
+			log.Warnf("failed to remove committed offsets of deleted topics for group %s: %v", groupID, err)
+		}
+	}
+	return added, true, kafkaprotocol.ErrorCodeNone
+}
+
+// ReclaimDeletedTopicOffsets removes the committed offsets of deleted topics from the groups which are in memory. Offsets
+// of other groups are removed when the group is next loaded.
+func (c *Coordinator) ReclaimDeletedTopicOffsets() {
+	c.lock.RLock()
+	groups := make([]*group, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, g)
+	}
+	c.lock.RUnlock()
+	for _, g := range groups {
+		if err := g.reclaimDeletedTopicOffsets(); err != nil {
+			log.Warnf("failed to remove committed offsets of deleted topics for group %s: %v", g.id, err)
+		}
+	}
+}
+
+// addGroup adds the group to the coordinator, unless it has been added concurrently, and returns the group which was
+// added
+func (c *Coordinator) addGroup(g *group) *group {
 	c.lock.RUnlock()
 	c.lock.Lock()
 	defer func() {
 		c.lock.Unlock()
 		c.lock.RLock()
 	}()
-	g, ok := c.groups[groupID]
+	existing, ok := c.groups[g.id]
 	if ok {
-		return g
+		return existing
+	}
+	c.groups[g.id] = g
+	return g
+}
+
+// removeStaleGroup stops and removes a group which this agent no longer coordinates, or which has been coordinated by
+// another agent since it was loaded
+func (c *Coordinator) removeStaleGroup(g *group) {
+	g.stop()
+	c.lock.RUnlock()
+	c.lock.Lock()
+	defer func() {
+		c.lock.Unlock()
+		c.lock.RLock()
+	}()
+	if c.groups[g.id] == g {
+		delete(c.groups, g.id)
 	}
+}
+
+func (c *Coordinator) newGroup(groupID string, groupEpoch int) *group {
 	offsetWriterKey := createCoordinatorKey(groupID)
 	partHash, err := parthash.CreateHash([]byte(offsetWriterKey))
 	if err != nil {
 		panic(err) // doesn't happen
 	}
-	g = &group{
+	g := &group{
 		gc:                      c,
 		id:                      groupID,
 		groupEpoch:              groupEpoch,
@@ -523,13 +786,24 @@ func (c *Coordinator) createGroup(groupID string, groupEpoch int) *group {
 		state:                   stateEmpty,
 		members:                 map[string]*member{},
 		pendingMemberIDs:        map[string]struct{}{},
+		staticMembers:           map[string]string{},
 		supportedProtocolCounts: map[string]int{},
 		committedOffsets:        map[int]map[int32]int64{},
 	}
-	c.groups[groupID] = g
+	g.membershipVersion.Store(c.membershipVersion)
 	return g
 }
 
+func (c *Coordinator) removeGroup(groupID string) {
+	c.lock.RUnlock()
+	c.lock.Lock()
+	defer func() {
+		c.lock.Unlock()
+		c.lock.RLock()
+	}()
+	delete(c.groups, groupID)
+}
+
 func (c *Coordinator) setTimer(timerKey string, delay time.Duration, action func()) {
 	timer := common.ScheduleTimer(delay, false, action)
 	c.timers.Store(timerKey, timer)
@@ -589,8 +863,9 @@ const (
 )
 
 type MemberInfo struct {
-	MemberID string
-	MetaData []byte
+	MemberID        string
+	GroupInstanceID *string
+	MetaData        []byte
 }
 
 type ProtocolInfo struct {
@@ -609,6 +884,7 @@ type JoinResult struct {
 	ErrorCode      int
 	MemberID       string
 	LeaderMemberID string
+	ProtocolType   string
 	ProtocolName   string
 	GenerationID   int
 	Members        []MemberInfo
@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/spirit-labs/tektite/acls"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
@@ -14,9 +16,11 @@ import (
 	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/pusher"
 	"github.com/spirit-labs/tektite/sst"
+	"github.com/spirit-labs/tektite/testutils"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/spirit-labs/tektite/transport"
 	"github.com/stretchr/testify/require"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -45,7 +49,7 @@ func TestFindCoordinator(t *testing.T) {
 		Key: common.StrPtr(groupID),
 	}
 	respCh := make(chan *kafkaprotocol.FindCoordinatorResponse, 1)
-	err := gc.HandleFindCoordinatorRequest(&req, func(resp *kafkaprotocol.FindCoordinatorResponse) error {
+	err := gc.HandleFindCoordinatorRequest(&kafkaprotocol.RequestHeader{}, &req, func(resp *kafkaprotocol.FindCoordinatorResponse) error {
 		respCh <- resp
 		return nil
 	})
@@ -57,6 +61,36 @@ func TestFindCoordinator(t *testing.T) {
 	require.Equal(t, 777, int(resp.Port))
 }
 
+func TestFindCoordinatorMultipleKeys(t *testing.T) {
+	localTransports := transport.NewLocalTransports()
+	gc, controlClient, _, _ := createCoordinatorWithConnFactoryAndCfgSetter(t, localTransports.CreateConnection, nil)
+	defer stopCoordinator(t, gc)
+	memberID := int32(333)
+	controlClient.groupCoordinatorMemberID = memberID
+	controlClient.groupCoordinatorAddress = "someaddress:777"
+	groupID1 := uuid.New().String()
+	groupID2 := uuid.New().String()
+	req := kafkaprotocol.FindCoordinatorRequest{
+		CoordinatorKeys: []*string{common.StrPtr(groupID1), common.StrPtr(groupID2)},
+	}
+	respCh := make(chan *kafkaprotocol.FindCoordinatorResponse, 1)
+	err := gc.HandleFindCoordinatorRequest(&kafkaprotocol.RequestHeader{RequestApiVersion: 4}, &req, func(resp *kafkaprotocol.FindCoordinatorResponse) error {
+		respCh <- resp
+		return nil
+	})
+	require.NoError(t, err)
+	resp := <-respCh
+	require.Equal(t, 2, len(resp.Coordinators))
+	for i, groupID := range []string{groupID1, groupID2} {
+		coordinator := resp.Coordinators[i]
+		require.Equal(t, groupID, *coordinator.Key)
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(coordinator.ErrorCode))
+		require.Equal(t, memberID, coordinator.NodeId)
+		require.Equal(t, "someaddress", *coordinator.Host)
+		require.Equal(t, 777, int(coordinator.Port))
+	}
+}
+
 func TestInitialJoinNoMemberID(t *testing.T) {
 	gc := createCoordinator(t)
 	defer stopCoordinator(t, gc)
@@ -127,7 +161,7 @@ func TestJoinMultipleMembersBeforeInitialDelay(t *testing.T) {
 		protocols := []ProtocolInfo{
 			{defaultProtocolName, protocolMetadata},
 		}
-		gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 			memberMetaDataMap.Store(result.MemberID, protocolMetadata)
 			ch <- result
 			joinWg.Done()
@@ -186,7 +220,7 @@ func TestExtendInitialJoinDelayToRebalanceTimeout(t *testing.T) {
 		// We pause half the initial join delay each time, this should have the effect of extending the delay
 		time.Sleep(initialJoinDelay / 2)
 
-		gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
 			memberMetaDataMap.Store(result.MemberID, protocolMetadata)
 			ch <- result
 			wg.Done()
@@ -257,7 +291,7 @@ func testChooseProtocol(t *testing.T, infos [][]ProtocolInfo, expectedProtocol s
 	for i, protocolInfos := range infos {
 		ch := make(chan JoinResult, 1)
 		thePIs := protocolInfos
-		gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, thePIs, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, thePIs, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
 		chans[i] = ch
@@ -324,7 +358,7 @@ func TestSyncGroup(t *testing.T) {
 		if isLeader {
 			theAssignments = assignments
 		}
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			syncResults.Store(memberID, syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -344,6 +378,95 @@ func TestSyncGroup(t *testing.T) {
 	}
 }
 
+func TestSyncGroupInconsistentProtocol(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
+
+	groupID := uuid.New().String()
+	members, _ := setupJoinedGroup(t, 1, groupID, gc)
+	var memberID string
+	members.Range(func(key, value any) bool {
+		memberID = key.(string)
+		return false
+	})
+
+	syncWithProtocol := func(protocolType string, protocolName string) *kafkaprotocol.SyncGroupResponse {
+		respCh := make(chan *kafkaprotocol.SyncGroupResponse, 1)
+		err := gc.HandleSyncGroupRequest(&kafkaprotocol.SyncGroupRequest{
+			GroupId:      common.StrPtr(groupID),
+			GenerationId: 1,
+			MemberId:     common.StrPtr(memberID),
+			ProtocolType: common.StrPtr(protocolType),
+			ProtocolName: common.StrPtr(protocolName),
+			Assignments: []kafkaprotocol.SyncGroupRequestSyncGroupRequestAssignment{
+				{MemberId: common.StrPtr(memberID), Assignment: []byte("assignment")},
+			},
+		}, func(resp *kafkaprotocol.SyncGroupResponse) error {
+			respCh <- resp
+			return nil
+		})
+		require.NoError(t, err)
+		return <-respCh
+	}
+
+	resp := syncWithProtocol("unknown_protocol_type", defaultProtocolName)
+	require.Equal(t, kafkaprotocol.ErrorCodeInconsistentGroupProtocol, int(resp.ErrorCode))
+
+	resp = syncWithProtocol(defaultProtocolType, "unknown_protocol")
+	require.Equal(t, kafkaprotocol.ErrorCodeInconsistentGroupProtocol, int(resp.ErrorCode))
+
+	resp = syncWithProtocol(defaultProtocolType, defaultProtocolName)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, defaultProtocolType, *resp.ProtocolType)
+	require.Equal(t, defaultProtocolName, *resp.ProtocolName)
+	require.Equal(t, []byte("assignment"), resp.Assignment)
+}
+
+func TestLeaveGroupMultipleMembers(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
+
+	groupID := uuid.New().String()
+	numMembers := 3
+	members, _ := setupJoinedGroup(t, numMembers, groupID, gc)
+	syncGroup(groupID, numMembers, members, gc)
+	var memberIDs []string
+	members.Range(func(key, value any) bool {
+		memberIDs = append(memberIDs, key.(string))
+		return true
+	})
+
+	req := kafkaprotocol.LeaveGroupRequest{
+		GroupId: common.StrPtr(groupID),
+		Members: []kafkaprotocol.LeaveGroupRequestMemberIdentity{
+			{MemberId: common.StrPtr(memberIDs[0])},
+			{MemberId: common.StrPtr("unknown_member")},
+			{MemberId: common.StrPtr(memberIDs[1])},
+		},
+	}
+	respCh := make(chan *kafkaprotocol.LeaveGroupResponse, 1)
+	err := gc.HandleLeaveGroupRequest(&kafkaprotocol.RequestHeader{RequestApiVersion: 3}, &req, func(resp *kafkaprotocol.LeaveGroupResponse) error {
+		respCh <- resp
+		return nil
+	})
+	require.NoError(t, err)
+	resp := <-respCh
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 3, len(resp.Members))
+	require.Equal(t, memberIDs[0], *resp.Members[0].MemberId)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Members[0].ErrorCode))
+	require.Equal(t, "unknown_member", *resp.Members[1].MemberId)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(resp.Members[1].ErrorCode))
+	require.Equal(t, memberIDs[1], *resp.Members[2].MemberId)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Members[2].ErrorCode))
+
+	g, ok := gc.getGroup(groupID)
+	require.True(t, ok)
+	require.False(t, g.hasMember(memberIDs[0]))
+	require.False(t, g.hasMember(memberIDs[1]))
+	require.True(t, g.hasMember(memberIDs[2]))
+}
+
 func TestJoinNewMemberWhileAwaitingRebalance(t *testing.T) {
 	gc := createCoordinator(t)
 	defer stopCoordinator(t, gc)
@@ -382,7 +505,7 @@ func TestJoinNewMemberWhileAwaitingRebalance(t *testing.T) {
 		}
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -398,7 +521,7 @@ func TestJoinNewMemberWhileAwaitingRebalance(t *testing.T) {
 	protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 	ch := make(chan JoinResult, 1)
 	chans2 = append(chans2, ch)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 		func(result JoinResult) {
 			ch <- result
 		})
@@ -418,7 +541,7 @@ func TestJoinNewMemberWhileAwaitingRebalance(t *testing.T) {
 		chans2 = append(chans2, ch)
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 		expectedMeta[memberID] = protocols[0].Metadata
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -488,7 +611,7 @@ func TestExistingMembersRejoinWithDifferentProtocolMetadataWhileAwaitingRebalanc
 		}
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -508,7 +631,7 @@ func TestExistingMembersRejoinWithDifferentProtocolMetadataWhileAwaitingRebalanc
 		chans2 = append(chans2, ch)
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 		expectedMeta[memberID] = protocols[0].Metadata
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -586,7 +709,7 @@ func TestExistingMembersRejoinWithSameProtocolMetadataWhileAwaitingRebalance(t *
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
 		memberIDs = append(memberIDs, memberID)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -604,7 +727,7 @@ func TestExistingMembersRejoinWithSameProtocolMetadataWhileAwaitingRebalance(t *
 		p, ok := memberProtocols.Load(memberID)
 		require.True(t, ok)
 		protocols := p.([]ProtocolInfo)
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -638,7 +761,7 @@ func TestExistingMembersRejoinWithSameProtocolMetadataWhileAwaitingRebalance(t *
 	ch := make(chan syncResult, 1)
 	chans = append(chans, ch)
 	memberIDs = append(memberIDs, skippedMember)
-	gc.syncGroup(groupID, skippedMember, 1, nil, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, skippedMember, "", 1, nil, func(errorCode int, assignment []byte) {
 		ch <- syncResult{
 			errorCode:  errorCode,
 			assignment: assignment,
@@ -689,7 +812,7 @@ func TestJoinNewMemberWhileActive(t *testing.T) {
 		}
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -711,7 +834,7 @@ func TestJoinNewMemberWhileActive(t *testing.T) {
 	protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 	ch := make(chan JoinResult, 1)
 	chans2 = append(chans2, ch)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 		func(result JoinResult) {
 			ch <- result
 		})
@@ -723,7 +846,7 @@ func TestJoinNewMemberWhileActive(t *testing.T) {
 		chans2 = append(chans2, ch)
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 		expectedMeta[memberID] = protocols[0].Metadata
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -786,7 +909,7 @@ func TestRejoinLeaderWhileActive(t *testing.T) {
 		}
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -811,13 +934,13 @@ func TestRejoinLeaderWhileActive(t *testing.T) {
 	ch := make(chan JoinResult, 1)
 	chans2 = append(chans2, ch)
 	expectedMeta[leader] = protocols[0].Metadata
-	gc.joinGroup(0, groupID, defaultClientID, leader, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, leader, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 		func(result JoinResult) {
 			ch <- result
 		})
 
 	// This should trigger a rebalance
-	errorCode := gc.heartbeatGroup(groupID, leader, 1)
+	errorCode := gc.heartbeatGroup(groupID, leader, "", 1)
 	require.Equal(t, kafkaprotocol.ErrorCodeRebalanceInProgress, errorCode)
 
 	// Now we rejoin all the others members
@@ -830,7 +953,7 @@ func TestRejoinLeaderWhileActive(t *testing.T) {
 		chans2 = append(chans2, ch)
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata2-%d", i))}}
 		expectedMeta[memberID] = protocols[0].Metadata
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -891,7 +1014,7 @@ func TestRejoinNonLeaderWhileActive(t *testing.T) {
 		}
 		ch := make(chan syncResult, 1)
 		chans = append(chans, ch)
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -923,7 +1046,7 @@ func TestRejoinNonLeaderWhileActive(t *testing.T) {
 		require.True(t, ok)
 		protocols := p.([]ProtocolInfo)
 		expectedMeta[memberID] = protocols[0].Metadata
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout, defaultRebalanceTimeout,
 			func(result JoinResult) {
 				ch <- result
 			})
@@ -961,14 +1084,14 @@ func TestSyncInJoinPhaseFails(t *testing.T) {
 	protocols := []ProtocolInfo{
 		{defaultProtocolName, []byte("protocol1_bytes")},
 	}
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols,
 		defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 		})
 
 	// The group will now be in state statePreReBalance
 
 	ch := make(chan int, 1)
-	gc.syncGroup(groupID, "some-member-id", 0, nil, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, "some-member-id", "", 0, nil, func(errorCode int, assignment []byte) {
 		ch <- errorCode
 	})
 	errorCode := <-ch
@@ -993,7 +1116,7 @@ func TestSyncWhenActiveReturnsCurrentState(t *testing.T) {
 
 	// Sync with unknown member id
 	ch := make(chan int, 1)
-	gc.syncGroup(groupID, "unknown", 1, nil, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, "unknown", "", 1, nil, func(errorCode int, assignment []byte) {
 		ch <- errorCode
 	})
 	errorCode := <-ch
@@ -1004,7 +1127,7 @@ func TestSyncWhenActiveReturnsCurrentState(t *testing.T) {
 		memberID := key.(string)
 
 		ch := make(chan syncResult, 1)
-		gc.syncGroup(groupID, memberID, 1, nil, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, nil, func(errorCode int, assignment []byte) {
 			ch <- syncResult{
 				errorCode:  errorCode,
 				assignment: assignment,
@@ -1032,10 +1155,10 @@ func setupJoinedGroupWithArgs(t *testing.T, numMembers int, groupID string, gc *
 	memberProtocols := sync.Map{}
 	for i := 0; i < numMembers; i++ {
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata-%d", i))}}
-		gc.joinGroup(4, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(4, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
 			require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, result.ErrorCode)
 			go func() {
-				gc.joinGroup(0, groupID, defaultClientID, result.MemberID, defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
+				gc.joinGroup(0, groupID, defaultClientID, result.MemberID, "", defaultProtocolType, protocols, defaultSessionTimeout, rebalanceTimeout, func(result JoinResult) {
 					if result.ErrorCode != kafkaprotocol.ErrorCodeNone {
 						panic(fmt.Sprintf("join returned error %d", result.ErrorCode))
 					}
@@ -1077,7 +1200,7 @@ func syncGroup(groupID string, numMembers int, members *sync.Map, gc *Coordinato
 		if isLeader {
 			theAssignments = assignments
 		}
-		gc.syncGroup(groupID, memberID, 1, theAssignments, func(errorCode int, assignment []byte) {
+		gc.syncGroup(groupID, memberID, "", 1, theAssignments, func(errorCode int, assignment []byte) {
 			if errorCode != kafkaprotocol.ErrorCodeNone {
 				panic(fmt.Sprintf("sync returned error %d", errorCode))
 			}
@@ -1102,7 +1225,7 @@ func TestSyncWrongGeneration(t *testing.T) {
 	})
 
 	ch := make(chan int, 1)
-	gc.syncGroup(groupID, memberID, 23, []AssignmentInfo{}, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, memberID, "", 23, []AssignmentInfo{}, func(errorCode int, assignment []byte) {
 		ch <- errorCode
 	})
 	err := <-ch
@@ -1128,10 +1251,10 @@ func TestAddNewMembersAfterSync(t *testing.T) {
 		ch := make(chan JoinResult, 1)
 		chans = append(chans, ch)
 		protocols := []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata-%d", i+numInitialMembers))}}
-		gc.joinGroup(0, groupID, defaultClientID, "", "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(0, groupID, defaultClientID, "", "", "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 			go func() {
 				// First should trigger a rebalance
-				gc.joinGroup(0, groupID, defaultClientID, result.MemberID, "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+				gc.joinGroup(0, groupID, defaultClientID, result.MemberID, "", "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 					newMembersMap.Store(result.MemberID, struct{}{})
 					memberProtocols.Store(result.MemberID, protocols)
 					ch <- result
@@ -1155,7 +1278,7 @@ func TestAddNewMembersAfterSync(t *testing.T) {
 		o, ok := memberProtocols.Load(memberID)
 		require.True(t, ok)
 		protocols := o.([]ProtocolInfo)
-		gc.joinGroup(0, groupID, defaultClientID, memberID, "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", "protocoltype1", protocols, defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
 		cnt++
@@ -1196,7 +1319,7 @@ func TestSyncEmptyMemberID(t *testing.T) {
 	groupID := uuid.New().String()
 
 	ch := make(chan int, 1)
-	gc.syncGroup(groupID, "", 1, nil, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, "", "", 1, nil, func(errorCode int, assignment []byte) {
 		ch <- errorCode
 	})
 	errorCode := <-ch
@@ -1210,7 +1333,7 @@ func TestSyncUnknownGroupID(t *testing.T) {
 	groupID := uuid.New().String()
 
 	ch := make(chan int, 1)
-	gc.syncGroup(groupID, "foo", 1, nil, func(errorCode int, assignment []byte) {
+	gc.syncGroup(groupID, "foo", "", 1, nil, func(errorCode int, assignment []byte) {
 		ch <- errorCode
 	})
 	errorCode := <-ch
@@ -1222,7 +1345,7 @@ func TestHeartbeatEmptyMemberID(t *testing.T) {
 	defer stopCoordinator(t, gc)
 
 	groupID := uuid.New().String()
-	errorCode := gc.heartbeatGroup(groupID, "", 1)
+	errorCode := gc.heartbeatGroup(groupID, "", "", 1)
 	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, errorCode)
 }
 
@@ -1231,7 +1354,7 @@ func TestHeartbeatUnknownGroupID(t *testing.T) {
 	defer stopCoordinator(t, gc)
 
 	groupID := uuid.New().String()
-	errorCode := gc.heartbeatGroup(groupID, "foo", 1)
+	errorCode := gc.heartbeatGroup(groupID, "foo", "", 1)
 	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, errorCode)
 }
 
@@ -1245,7 +1368,7 @@ func TestHeartbeatIllegalGeneration(t *testing.T) {
 	}
 	groupID := uuid.New().String()
 	ch := make(chan JoinResult, 1)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout,
 		defaultRebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
@@ -1254,7 +1377,7 @@ func TestHeartbeatIllegalGeneration(t *testing.T) {
 	// Group should now be in state stateAwaitingReBalance - waiting for initial timeout before completing join
 	require.Equal(t, stateAwaitingReBalance, gc.getState(groupID))
 
-	errorCode := gc.heartbeatGroup(groupID, res.MemberID, 100)
+	errorCode := gc.heartbeatGroup(groupID, res.MemberID, "", 100)
 	require.Equal(t, kafkaprotocol.ErrorCodeIllegalGeneration, errorCode)
 }
 
@@ -1273,7 +1396,7 @@ func TestHeartbeatAwaitingRebalance(t *testing.T) {
 	// Group should now be in state stateAwaitingReBalance
 	require.Equal(t, stateAwaitingReBalance, gc.getState(groupID))
 
-	errorCode := gc.heartbeatGroup(groupID, memberID, 1)
+	errorCode := gc.heartbeatGroup(groupID, memberID, "", 1)
 	require.Equal(t, kafkaprotocol.ErrorCodeNone, errorCode)
 }
 
@@ -1297,7 +1420,7 @@ func TestHeartbeatWhileActive(t *testing.T) {
 	// Group should now be in state stateAwaitingReBalance
 	require.Equal(t, stateActive, gc.getState(groupID))
 
-	errorCode := gc.heartbeatGroup(groupID, memberID, 1)
+	errorCode := gc.heartbeatGroup(groupID, memberID, "", 1)
 	require.Equal(t, kafkaprotocol.ErrorCodeNone, errorCode)
 }
 
@@ -1317,7 +1440,7 @@ func TestJoinTimeoutMembersRemovedAndJoinCompletes(t *testing.T) {
 	protocols := []ProtocolInfo{{defaultProtocolName, []byte("metadata-11")}}
 	ch := make(chan JoinResult, 1)
 	chans = append(chans, ch)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout,
 		rebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
@@ -1343,7 +1466,7 @@ func TestJoinTimeoutMembersRemovedAndJoinCompletes(t *testing.T) {
 		protocols := p.([]ProtocolInfo)
 		ch := make(chan JoinResult, 1)
 		chans = append(chans, ch)
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout,
 			rebalanceTimeout, func(result JoinResult) {
 				ch <- result
 			})
@@ -1398,7 +1521,7 @@ func TestJoinTimeoutMembersRemovedIncludingLeaderAndJoinCompletes(t *testing.T)
 	ch := make(chan JoinResult, 1)
 	chans = append(chans, ch)
 
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout,
 		rebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
@@ -1426,7 +1549,7 @@ func TestJoinTimeoutMembersRemovedIncludingLeaderAndJoinCompletes(t *testing.T)
 		protocols := p.([]ProtocolInfo)
 		ch := make(chan JoinResult, 1)
 		chans = append(chans, ch)
-		gc.joinGroup(0, groupID, defaultClientID, memberID, defaultProtocolType, protocols, defaultSessionTimeout,
+		gc.joinGroup(0, groupID, defaultClientID, memberID, "", defaultProtocolType, protocols, defaultSessionTimeout,
 			rebalanceTimeout, func(result JoinResult) {
 				ch <- result
 			})
@@ -1487,7 +1610,7 @@ func TestJoinTimeoutNoMembersRejoinTransitionsToEmpty(t *testing.T) {
 	protocols := []ProtocolInfo{{defaultProtocolName, []byte("metadata-11")}}
 	ch := make(chan JoinResult, 1)
 	chans = append(chans, ch)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, defaultSessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, defaultSessionTimeout,
 		rebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
@@ -1527,7 +1650,7 @@ func TestRejoinLeaderTriggersRebalance(t *testing.T) {
 	})
 	p, ok := memberProts.Load(leader)
 	require.True(t, ok)
-	gc.joinGroup(0, groupID, defaultClientID, leader, defaultProtocolType, p.([]ProtocolInfo), defaultSessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, leader, "", defaultProtocolType, p.([]ProtocolInfo), defaultSessionTimeout,
 		rebalanceTimeout, func(result JoinResult) {})
 	require.Equal(t, statePreReBalance, gc.getState(groupID))
 }
@@ -1535,7 +1658,7 @@ func TestRejoinLeaderTriggersRebalance(t *testing.T) {
 func addMemberWithSessionTimeout(gc *Coordinator, groupID string, sessionTimeout time.Duration) chan JoinResult {
 	protocols := []ProtocolInfo{{defaultProtocolName, []byte("foo")}}
 	ch := make(chan JoinResult, 1)
-	gc.joinGroup(0, groupID, defaultClientID, "", defaultProtocolType, protocols, sessionTimeout,
+	gc.joinGroup(0, groupID, defaultClientID, "", "", defaultProtocolType, protocols, sessionTimeout,
 		defaultRebalanceTimeout, func(result JoinResult) {
 			ch <- result
 		})
@@ -1599,163 +1722,771 @@ func TestSessionTimeoutWhenActive(t *testing.T) {
 	require.Equal(t, stateEmpty, gc.getState(groupID))
 }
 
-func TestOffsetCommit(t *testing.T) {
-	localTransports := transport.NewLocalTransports()
-	gc, controlClient, topicProvider, _ := createCoordinatorWithConnFactoryAndCfgSetter(t, localTransports.CreateConnection, nil)
+func TestStaticMemberRejoinKeepsAssignment(t *testing.T) {
+	gc := createCoordinator(t)
 	defer stopCoordinator(t, gc)
 
-	fp := &fakePusherSink{}
-	transportServer, err := localTransports.NewLocalServer(uuid.New().String())
-	require.NoError(t, err)
-	transportServer.RegisterHandler(transport.HandlerIDTablePusherDirectWrite, fp.HandleDirectWrite)
-	memberData := common.MembershipData{
-		ClusterListenAddress: transportServer.Address(),
+	groupID := uuid.New().String()
+	instanceIDs := []string{"instance-1", "instance-2"}
+	results := joinStaticMembers(t, gc, groupID, instanceIDs)
+	var members sync.Map
+	for _, res := range results {
+		members.Store(res.MemberID, res.LeaderMemberID == res.MemberID)
 	}
-	err = gc.MembershipChanged(0, cluster.MembershipState{
-		LeaderVersion:  1,
-		ClusterVersion: 1,
-		Members: []cluster.MembershipEntry{
-			{
-				ID:   0,
-				Data: memberData.Serialize(nil),
-			},
-		},
-	})
-	require.NoError(t, err)
+	assignments := syncGroup(groupID, len(instanceIDs), &members, gc)
+	require.Equal(t, stateActive, gc.getState(groupID))
 
-	topicName1 := "test-topic1"
-	topicName2 := "test-topic2"
-	topicProvider.infos[topicName1] = topicmeta.TopicInfo{
-		ID:             1234,
-		Name:           topicName1,
-		PartitionCount: 100,
-	}
-	topicProvider.infos[topicName2] = topicmeta.TopicInfo{
-		ID:             2234,
-		Name:           topicName2,
-		PartitionCount: 100,
+	// The member restarts and joins again with an empty member id
+	oldMemberID := results[1].MemberID
+	var expectedAssignment []byte
+	for _, assignment := range assignments {
+		if assignment.MemberID == oldMemberID {
+			expectedAssignment = assignment.Assignment
+		}
 	}
-	controlClient.groupEpoch = 23
-
-	groupID := uuid.New().String()
-	numMembers := 10
-	rebalanceTimeout := 1 * time.Second
-	members, _ := setupJoinedGroupWithArgs(t, numMembers, groupID, gc, rebalanceTimeout)
-	syncGroup(groupID, numMembers, members, gc)
+	require.NotNil(t, expectedAssignment)
+	ch := make(chan JoinResult, 1)
+	gc.joinGroup(4, groupID, defaultClientID, "", instanceIDs[1], defaultProtocolType, staticMemberProtocols(1),
+		defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+			ch <- result
+		})
+	res := <-ch
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, res.ErrorCode)
+	newMemberID := res.MemberID
+	require.NotEqual(t, oldMemberID, newMemberID)
+	// No rebalance
+	require.Equal(t, 1, res.GenerationID)
 	require.Equal(t, stateActive, gc.getState(groupID))
+	require.False(t, gc.groupHasMember(groupID, oldMemberID))
+	require.True(t, gc.groupHasMember(groupID, newMemberID))
 
-	var memberID string
-	members.Range(func(key, value any) bool {
-		memberID = key.(string)
-		return false
+	// The member gets its previous assignment
+	syncCh := make(chan syncResult, 1)
+	gc.syncGroup(groupID, newMemberID, instanceIDs[1], 1, nil, func(errorCode int, assignment []byte) {
+		syncCh <- syncResult{errorCode: errorCode, assignment: assignment}
 	})
+	sr := <-syncCh
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, sr.errorCode)
+	require.Equal(t, expectedAssignment, sr.assignment)
+
+	// The old member id is fenced
+	require.Equal(t, kafkaprotocol.ErrorCodeFencedInstanceID, gc.heartbeatGroup(groupID, oldMemberID, instanceIDs[1], 1))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, gc.heartbeatGroup(groupID, newMemberID, instanceIDs[1], 1))
+	gc.syncGroup(groupID, oldMemberID, instanceIDs[1], 1, nil, func(errorCode int, assignment []byte) {
+		syncCh <- syncResult{errorCode: errorCode, assignment: assignment}
+	})
+	sr = <-syncCh
+	require.Equal(t, kafkaprotocol.ErrorCodeFencedInstanceID, sr.errorCode)
+}
 
-	req := kafkaprotocol.OffsetCommitRequest{
-		GroupId:                   common.StrPtr(groupID),
-		MemberId:                  common.StrPtr(memberID),
-		GenerationIdOrMemberEpoch: int32(1),
-		Topics: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic{
-			{
-				Name: common.StrPtr(topicName1),
-				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
-					{
-						PartitionIndex:  1,
-						CommittedOffset: 12345,
-					},
-					{
-						PartitionIndex:  23,
-						CommittedOffset: 456456,
-					},
-				},
-			},
-			{
-				Name: common.StrPtr(topicName2),
-				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
-					{
-						PartitionIndex:  7,
-						CommittedOffset: 345345,
-					},
-				},
-			},
-		},
-	}
-
-	resp, err := gc.OffsetCommit(&req)
-	require.NoError(t, err)
+func TestStaticMemberJoinFenced(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
 
-	require.Equal(t, 2, len(resp.Topics))
-	require.Equal(t, topicName1, *resp.Topics[0].Name)
+	groupID := uuid.New().String()
+	results := joinStaticMembers(t, gc, groupID, []string{"instance-1"})
+	require.Equal(t, results[0].MemberID, results[0].LeaderMemberID)
+	require.Equal(t, 1, len(results[0].Members))
+	require.Equal(t, "instance-1", *results[0].Members[0].GroupInstanceID)
 
-	require.Equal(t, 2, len(resp.Topics[0].Partitions))
+	// Another member joins with the same instance id but a different member id
+	ch := make(chan JoinResult, 1)
+	gc.joinGroup(4, groupID, defaultClientID, "some-other-member", "instance-1", defaultProtocolType,
+		staticMemberProtocols(0), defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+			ch <- result
+		})
+	res := <-ch
+	require.Equal(t, kafkaprotocol.ErrorCodeFencedInstanceID, res.ErrorCode)
 
-	require.Equal(t, 1, int(resp.Topics[0].Partitions[0].PartitionIndex))
-	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[0].ErrorCode))
-	require.Equal(t, 23, int(resp.Topics[0].Partitions[1].PartitionIndex))
-	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[1].ErrorCode))
+	// Unknown instance id with a member id
+	gc.joinGroup(4, groupID, defaultClientID, "some-other-member", "instance-2", defaultProtocolType,
+		staticMemberProtocols(0), defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+			ch <- result
+		})
+	res = <-ch
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, res.ErrorCode)
+	require.True(t, gc.groupHasMember(groupID, results[0].MemberID))
+}
 
-	require.Equal(t, 1, len(resp.Topics[1].Partitions))
+func TestStaticMemberSessionTimeout(t *testing.T) {
+	gc, _, _, _ := createCoordinatorWithCfgSetter(t, func(config *Conf) {
+		config.InitialJoinDelay = 100 * time.Millisecond
+		config.MinSessionTimeout = 1 * time.Millisecond
+	})
+	defer stopCoordinator(t, gc)
 
-	require.Equal(t, 7, int(resp.Topics[1].Partitions[0].PartitionIndex))
-	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[0].ErrorCode))
+	groupID := uuid.New().String()
+	ch := make(chan JoinResult, 1)
+	gc.joinGroup(4, groupID, defaultClientID, "", "instance-1", defaultProtocolType, staticMemberProtocols(0),
+		200*time.Millisecond, defaultRebalanceTimeout, func(result JoinResult) {
+			ch <- result
+		})
+	res := <-ch
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, res.ErrorCode)
+	var members sync.Map
+	members.Store(res.MemberID, true)
+	syncGroup(groupID, 1, &members, gc)
 
-	received, rcpVer := fp.getReceived()
-	require.NotNil(t, received)
+	// The member is removed after the session timeout, and must then join as a new member
+	testutils.WaitUntil(t, func() (bool, error) {
+		return !gc.groupHasMember(groupID, res.MemberID), nil
+	})
+	require.Equal(t, stateEmpty, gc.getState(groupID))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, gc.heartbeatGroup(groupID, res.MemberID, "instance-1", 1))
+}
 
-	require.Equal(t, 1, int(rcpVer))
+func TestLeaveGroupByInstanceID(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
 
-	require.Equal(t, "g."+groupID, received.WriterKey)
-	require.Equal(t, 23, received.WriterEpoch)
+	groupID := uuid.New().String()
+	instanceIDs := []string{"instance-1", "instance-2", "instance-3"}
+	results := joinStaticMembers(t, gc, groupID, instanceIDs)
+	var members sync.Map
+	for _, res := range results {
+		members.Store(res.MemberID, res.LeaderMemberID == res.MemberID)
+	}
+	syncGroup(groupID, len(instanceIDs), &members, gc)
 
-	partHash, err := parthash.CreateHash([]byte("g." + groupID))
+	req := kafkaprotocol.LeaveGroupRequest{
+		GroupId: common.StrPtr(groupID),
+		Members: []kafkaprotocol.LeaveGroupRequestMemberIdentity{
+			{GroupInstanceId: common.StrPtr(instanceIDs[0])},
+			{GroupInstanceId: common.StrPtr("unknown-instance")},
+			{MemberId: common.StrPtr("wrong-member"), GroupInstanceId: common.StrPtr(instanceIDs[1])},
+			{MemberId: common.StrPtr(results[2].MemberID), GroupInstanceId: common.StrPtr(instanceIDs[2])},
+		},
+	}
+	respCh := make(chan *kafkaprotocol.LeaveGroupResponse, 1)
+	err := gc.HandleLeaveGroupRequest(&kafkaprotocol.RequestHeader{RequestApiVersion: 3}, &req, func(resp *kafkaprotocol.LeaveGroupResponse) error {
+		respCh <- resp
+		return nil
+	})
 	require.NoError(t, err)
+	resp := <-respCh
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 4, len(resp.Members))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Members[0].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(resp.Members[1].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeFencedInstanceID, int(resp.Members[2].ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Members[3].ErrorCode))
 
-	var expectedKVs []common.KV
-	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 1234, 1, 12345))
-	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 1234, 23, 456456))
-	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 2234, 7, 345345))
+	require.False(t, gc.groupHasMember(groupID, results[0].MemberID))
+	require.True(t, gc.groupHasMember(groupID, results[1].MemberID))
+	require.False(t, gc.groupHasMember(groupID, results[2].MemberID))
+}
 
-	require.Equal(t, expectedKVs, received.KVs)
+func staticMemberProtocols(i int) []ProtocolInfo {
+	return []ProtocolInfo{{defaultProtocolName, []byte(fmt.Sprintf("metadata-%d", i))}}
 }
 
-func createExpectedKV(partHash []byte, topicID int, partitionID int, committedOffset int64) common.KV {
-	key := createOffsetKey(partHash, offsetKeyPublic, topicID, partitionID)
-	val := make([]byte, 8)
-	binary.BigEndian.PutUint64(val, uint64(committedOffset))
-	return common.KV{
-		Key:   key,
-		Value: val,
+// joinStaticMembers joins members with the provided group instance ids and returns the join results in the same order
+func joinStaticMembers(t *testing.T, gc *Coordinator, groupID string, instanceIDs []string) []JoinResult {
+	chans := make([]chan JoinResult, len(instanceIDs))
+	for i, instanceID := range instanceIDs {
+		ch := make(chan JoinResult, 1)
+		chans[i] = ch
+		// Static members don't need to call back in with their member id, even with API version >= 4
+		gc.joinGroup(4, groupID, defaultClientID, "", instanceID, defaultProtocolType, staticMemberProtocols(i),
+			defaultSessionTimeout, defaultRebalanceTimeout, func(result JoinResult) {
+				ch <- result
+			})
+	}
+	results := make([]JoinResult, len(instanceIDs))
+	for i, ch := range chans {
+		results[i] = <-ch
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, results[i].ErrorCode)
+		require.Equal(t, 1, results[i].GenerationID)
 	}
+	return results
 }
 
-func TestOffsetFetch(t *testing.T) {
-	gc, controlClient, topicProvider, tableGetter := createCoordinatorWithCfgSetter(t, nil)
+func TestConsumerGroupHeartbeatJoin(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
 	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
 
-	topicName1 := "test-topic1"
-	topicName2 := "test-topic2"
-	topicProvider.infos[topicName1] = topicmeta.TopicInfo{
-		ID:             1234,
-		Name:           topicName1,
-		PartitionCount: 100,
-	}
-	topicProvider.infos[topicName2] = topicmeta.TopicInfo{
-		ID:             2234,
-		Name:           topicName2,
-		PartitionCount: 100,
-	}
-	controlClient.groupEpoch = 23
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	memberID := common.SafeDerefStringPtr(resp.MemberId)
+	require.True(t, strings.HasPrefix(memberID, defaultClientID))
+	require.Equal(t, int32(1), resp.MemberEpoch)
+	require.Equal(t, int32(DefaultConsumerGroupHeartbeatInterval.Milliseconds()), resp.HeartbeatIntervalMs)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, heartbeatAssignment(t, resp))
+
+	// Assignment is not sent again once the member owns it
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", memberID, 1,
+		topicPartitions{1000: {0, 1, 2, 3}}))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(1), resp.MemberEpoch)
+	require.Nil(t, resp.Assignment)
 
-	groupID := uuid.New().String()
-	numMembers := 10
-	rebalanceTimeout := 1 * time.Second
-	members, _ := setupJoinedGroupWithArgs(t, numMembers, groupID, gc, rebalanceTimeout)
-	syncGroup(groupID, numMembers, members, gc)
-	require.Equal(t, stateActive, gc.getState(groupID))
+	// Adding partitions to the topic gives the member a new assignment at a new epoch
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 6}
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", memberID, 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(2), resp.MemberEpoch)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3, 4, 5}}, heartbeatAssignment(t, resp))
+}
 
-	g, ok := gc.getGroup(groupID)
-	require.True(t, ok)
+func TestConsumerGroupIncrementalRebalance(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
 
-	infos := []createOffsetsInfo{
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, heartbeatAssignment(t, resp))
+
+	// The second member joins but cannot be assigned any partitions until the first member has revoked them
+	resp = consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-b", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(2), resp.MemberEpoch)
+	require.Equal(t, topicPartitions{}, heartbeatAssignment(t, resp))
+	require.Equal(t, "Reconciling", consumerGroupState(t, gc, "group1"))
+
+	// The first member is asked to revoke partitions, and stays at its epoch until it has done so
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", 1,
+		topicPartitions{1000: {0, 1, 2, 3}}))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(1), resp.MemberEpoch)
+	retained := heartbeatAssignment(t, resp)
+	require.Equal(t, 2, retained.count())
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-b", 2, topicPartitions{}))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Nil(t, resp.Assignment)
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", 1, retained))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(2), resp.MemberEpoch)
+	require.Nil(t, resp.Assignment)
+
+	// Now the revoked partitions can be assigned to the second member
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-b", 2, topicPartitions{}))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}.subtract(retained), heartbeatAssignment(t, resp))
+	require.Equal(t, "Stable", consumerGroupState(t, gc, "group1"))
+}
+
+func TestConsumerGroupHeartbeatErrors(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", 7, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeFencedMemberEpoch, int(resp.ErrorCode))
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "unknown", 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(resp.ErrorCode))
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "", 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidRequest, int(resp.ErrorCode))
+
+	req := joinConsumerGroupRequest("group1", "member-b", "topic1")
+	req.ServerAssignor = common.StrPtr("unknown")
+	resp = consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnsupportedAssignor, int(resp.ErrorCode))
+
+	// Fenced members rejoin with epoch zero and are given their assignment again
+	resp = consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(1), resp.MemberEpoch)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, heartbeatAssignment(t, resp))
+}
+
+func TestConsumerGroupLeave(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	joinConsumerGroupMembers(t, gc, "group1", "topic1", "member-a", "member-b")
+
+	resp := consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", leaveGroupMemberEpoch, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(leaveGroupMemberEpoch), resp.MemberEpoch)
+
+	// The partitions of the member which left are assigned to the remaining member
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-b", 2, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(3), resp.MemberEpoch)
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, heartbeatAssignment(t, resp))
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-b", leaveGroupMemberEpoch, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, "Empty", consumerGroupState(t, gc, "group1"))
+}
+
+func TestConsumerGroupStaticMember(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	req := joinConsumerGroupRequest("group1", "", "topic1")
+	req.InstanceId = common.StrPtr("instance1")
+	resp := consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	memberID := common.SafeDerefStringPtr(resp.MemberId)
+	assignment := heartbeatAssignment(t, resp)
+
+	// Another member cannot join with the instance id while it is in use
+	resp = consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnreleasedInstanceID, int(resp.ErrorCode))
+
+	leaveReq := consumerGroupHeartbeatRequest("group1", memberID, leaveGroupStaticMemberEpoch, nil)
+	leaveReq.InstanceId = common.StrPtr("instance1")
+	resp = consumerGroupHeartbeat(t, gc, leaveReq)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, int32(leaveGroupStaticMemberEpoch), resp.MemberEpoch)
+
+	// The restarted member gets a new member id and keeps its assignment and epoch
+	resp = consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.NotEqual(t, memberID, common.SafeDerefStringPtr(resp.MemberId))
+	require.Equal(t, int32(1), resp.MemberEpoch)
+	require.Equal(t, assignment, heartbeatAssignment(t, resp))
+
+	// The old member id is no longer known
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", memberID, 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(resp.ErrorCode))
+}
+
+func TestConsumerGroupRevocationTimeout(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	req := joinConsumerGroupRequest("group1", "member-a", "topic1")
+	req.RebalanceTimeoutMs = 100
+	resp := consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	resp = consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-b", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+
+	// The first member is asked to revoke partitions but never does
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 2, heartbeatAssignment(t, resp).count())
+
+	testutils.WaitUntil(t, func() (bool, error) {
+		return !gc.groupHasConsumerMember("group1", "member-a"), nil
+	})
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", 1, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(resp.ErrorCode))
+
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-b", 2, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, topicPartitions{1000: {0, 1, 2, 3}}, heartbeatAssignment(t, resp))
+}
+
+func TestConsumerGroupSessionTimeout(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, func(cfg *Conf) {
+		cfg.ConsumerGroupSessionTimeout = 100 * time.Millisecond
+	})
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	testutils.WaitUntil(t, func() (bool, error) {
+		return !gc.groupHasConsumerMember("group1", "member-a"), nil
+	})
+	require.Equal(t, "Empty", consumerGroupState(t, gc, "group1"))
+}
+
+func TestConsumerGroupRangeAssignor(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 3}
+	topicProvider.infos["topic2"] = topicmeta.TopicInfo{ID: 1001, Name: "topic2", PartitionCount: 3}
+
+	req := joinConsumerGroupRequest("group1", "member-a", "topic1", "topic2")
+	req.ServerAssignor = common.StrPtr(rangeAssignorName)
+	resp := consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	req = joinConsumerGroupRequest("group1", "member-b", "topic1", "topic2")
+	req.ServerAssignor = common.StrPtr(rangeAssignorName)
+	resp = consumerGroupHeartbeat(t, gc, req)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+
+	described := describeConsumerGroup(t, gc, "group1").Groups[0]
+	require.Equal(t, rangeAssignorName, common.SafeDerefStringPtr(described.AssignorName))
+	require.Equal(t, 2, len(described.Members))
+	for i, expected := range []topicPartitions{{1000: {0, 1}, 1001: {0, 1}}, {1000: {2}, 1001: {2}}} {
+		target := described.Members[i].TargetAssignment
+		actual := topicPartitions{}
+		for _, topicData := range target.TopicPartitions {
+			topicID, ok := topicmeta.TopicIDFromKafkaTopicID(topicData.TopicId)
+			require.True(t, ok)
+			require.Equal(t, topicProviderName(topicProvider, topicID), common.SafeDerefStringPtr(topicData.TopicName))
+			actual[topicID] = topicData.Partitions
+		}
+		require.Equal(t, expected, actual)
+	}
+}
+
+func TestConsumerGroupCoexistenceWithClassicGroups(t *testing.T) {
+	gc, topicProvider := createConsumerGroupCoordinator(t, nil)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+
+	// Classic members cannot join a consumer group with members
+	protocols := []ProtocolInfo{{defaultProtocolName, []byte("metadata")}}
+	res := callJoinGroupSync(gc, "group1", defaultClientID, "", defaultProtocolType, protocols,
+		defaultSessionTimeout, defaultRebalanceTimeout)
+	require.Equal(t, kafkaprotocol.ErrorCodeInconsistentGroupProtocol, res.ErrorCode)
+
+	// Consumer group members cannot join a classic group with members
+	setupJoinedGroup(t, 1, "group2", gc)
+	resp = consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group2", "member-b", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, int(resp.ErrorCode))
+
+	groups := listGroups(t, gc, nil, []*string{common.StrPtr("consumer")})
+	require.Equal(t, 1, len(groups.Groups))
+	require.Equal(t, "group1", common.SafeDerefStringPtr(groups.Groups[0].GroupId))
+	require.Equal(t, "Stable", common.SafeDerefStringPtr(groups.Groups[0].GroupState))
+	require.Equal(t, consumerProtocolType, common.SafeDerefStringPtr(groups.Groups[0].ProtocolType))
+	groups = listGroups(t, gc, nil, []*string{common.StrPtr("classic")})
+	require.Equal(t, 1, len(groups.Groups))
+	require.Equal(t, "group2", common.SafeDerefStringPtr(groups.Groups[0].GroupId))
+
+	// Classic groups can't be described as consumer groups and vice versa
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound,
+		int(describeConsumerGroup(t, gc, "group2").Groups[0].ErrorCode))
+	var describeResp *kafkaprotocol.DescribeGroupsResponse
+	err := gc.HandleDescribeGroupsRequest(&kafkaprotocol.DescribeGroupsRequest{
+		Groups: []*string{common.StrPtr("group1")},
+	}, func(r *kafkaprotocol.DescribeGroupsResponse) error {
+		describeResp = r
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, int(describeResp.Groups[0].ErrorCode))
+
+	// Once the consumer group is empty, classic members can join it
+	require.Equal(t, kafkaprotocol.ErrorCodeNonEmptyGroup, int(deleteGroup(t, gc, "group1")))
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", leaveGroupMemberEpoch, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	res = callJoinGroupSyncWithApiVersion(gc, "group1", defaultClientID, "", defaultProtocolType, protocols,
+		defaultSessionTimeout, defaultRebalanceTimeout, 4)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, res.ErrorCode)
+	res = callJoinGroupSync(gc, "group1", defaultClientID, res.MemberID, defaultProtocolType, protocols,
+		defaultSessionTimeout, defaultRebalanceTimeout)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, res.ErrorCode)
+	groups = listGroups(t, gc, nil, []*string{common.StrPtr("classic")})
+	require.Equal(t, 2, len(groups.Groups))
+}
+
+func TestConsumerGroupOffsetCommit(t *testing.T) {
+	gc, _, topicProvider, _, fp := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc)
+	topicProvider.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 4}
+
+	resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest("group1", "member-a", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+
+	commit := func(memberID string, memberEpoch int32) int16 {
+		commitResp, err := gc.OffsetCommit(&kafkaprotocol.OffsetCommitRequest{
+			GroupId:                   common.StrPtr("group1"),
+			MemberId:                  common.StrPtr(memberID),
+			GenerationIdOrMemberEpoch: memberEpoch,
+			Topics: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic{{
+				Name: common.StrPtr("topic1"),
+				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
+					{PartitionIndex: 1, CommittedOffset: 100},
+				},
+			}},
+		})
+		require.NoError(t, err)
+		return commitResp.Topics[0].Partitions[0].ErrorCode
+	}
+	require.Equal(t, kafkaprotocol.ErrorCodeStaleMemberEpoch, int(commit("member-a", 0)))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(commit("unknown", 1)))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, int(commit("", -1)))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(commit("member-a", 1)))
+	received, _ := fp.getReceived()
+	require.NotNil(t, received)
+	require.Equal(t, 1, len(received.KVs))
+
+	// Offsets can be committed without a member once the group is empty
+	resp = consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest("group1", "member-a", leaveGroupMemberEpoch, nil))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(commit("", -1)))
+}
+
+func createConsumerGroupCoordinator(t *testing.T, cfgSetter func(cfg *Conf)) (*Coordinator, *testTopicInfoProvider) {
+	gc, _, topicProvider, _ := createCoordinatorWithCfgSetter(t, func(cfg *Conf) {
+		cfg.InitialJoinDelay = defaultInitialJoinDelay
+		if cfgSetter != nil {
+			cfgSetter(cfg)
+		}
+	})
+	return gc, topicProvider
+}
+
+func joinConsumerGroupMembers(t *testing.T, gc *Coordinator, groupID string, topicName string, memberIDs ...string) {
+	for _, memberID := range memberIDs {
+		resp := consumerGroupHeartbeat(t, gc, joinConsumerGroupRequest(groupID, memberID, topicName))
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	}
+	// Heartbeat until all members have reconciled
+	for i := 0; i < 2; i++ {
+		for _, memberID := range memberIDs {
+			member := gc.groups[groupID].consumer.members[memberID]
+			resp := consumerGroupHeartbeat(t, gc, consumerGroupHeartbeatRequest(groupID, memberID, member.epoch,
+				member.assigned))
+			require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+		}
+	}
+	require.Equal(t, "Stable", consumerGroupState(t, gc, groupID))
+}
+
+func joinConsumerGroupRequest(groupID string, memberID string, topicNames ...string) *kafkaprotocol.ConsumerGroupHeartbeatRequest {
+	req := &kafkaprotocol.ConsumerGroupHeartbeatRequest{
+		GroupId:              common.StrPtr(groupID),
+		MemberId:             common.StrPtr(memberID),
+		RebalanceTimeoutMs:   int32(defaultRebalanceTimeout.Milliseconds()),
+		SubscribedTopicNames: []*string{},
+		TopicPartitions:      []kafkaprotocol.ConsumerGroupHeartbeatRequestTopicPartitions{},
+	}
+	for _, topicName := range topicNames {
+		req.SubscribedTopicNames = append(req.SubscribedTopicNames, common.StrPtr(topicName))
+	}
+	return req
+}
+
+func consumerGroupHeartbeatRequest(groupID string, memberID string, memberEpoch int32,
+	owned topicPartitions) *kafkaprotocol.ConsumerGroupHeartbeatRequest {
+	req := &kafkaprotocol.ConsumerGroupHeartbeatRequest{
+		GroupId:            common.StrPtr(groupID),
+		MemberId:           common.StrPtr(memberID),
+		MemberEpoch:        memberEpoch,
+		RebalanceTimeoutMs: -1,
+	}
+	if owned != nil {
+		req.TopicPartitions = []kafkaprotocol.ConsumerGroupHeartbeatRequestTopicPartitions{}
+		for _, topicID := range owned.sortedTopicIDs() {
+			req.TopicPartitions = append(req.TopicPartitions, kafkaprotocol.ConsumerGroupHeartbeatRequestTopicPartitions{
+				TopicId:    kafkaTopicID(topicID),
+				Partitions: owned[topicID],
+			})
+		}
+	}
+	return req
+}
+
+func consumerGroupHeartbeat(t *testing.T, gc *Coordinator,
+	req *kafkaprotocol.ConsumerGroupHeartbeatRequest) *kafkaprotocol.ConsumerGroupHeartbeatResponse {
+	var resp *kafkaprotocol.ConsumerGroupHeartbeatResponse
+	err := gc.HandleConsumerGroupHeartbeatRequest(&kafkaprotocol.RequestHeader{ClientId: common.StrPtr(defaultClientID)},
+		req, func(r *kafkaprotocol.ConsumerGroupHeartbeatResponse) error {
+			resp = r
+			return nil
+		})
+	require.NoError(t, err)
+	return resp
+}
+
+func heartbeatAssignment(t *testing.T, resp *kafkaprotocol.ConsumerGroupHeartbeatResponse) topicPartitions {
+	require.NotNil(t, resp.Assignment)
+	assignment := topicPartitions{}
+	for _, topicData := range resp.Assignment.TopicPartitions {
+		topicID, ok := topicmeta.TopicIDFromKafkaTopicID(topicData.TopicId)
+		require.True(t, ok)
+		assignment[topicID] = topicData.Partitions
+	}
+	return assignment
+}
+
+func describeConsumerGroup(t *testing.T, gc *Coordinator, groupID string) *kafkaprotocol.ConsumerGroupDescribeResponse {
+	var resp *kafkaprotocol.ConsumerGroupDescribeResponse
+	err := gc.HandleConsumerGroupDescribeRequest(&kafkaprotocol.ConsumerGroupDescribeRequest{
+		GroupIds: []*string{common.StrPtr(groupID)},
+	}, func(r *kafkaprotocol.ConsumerGroupDescribeResponse) error {
+		resp = r
+		return nil
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func consumerGroupState(t *testing.T, gc *Coordinator, groupID string) string {
+	resp := describeConsumerGroup(t, gc, groupID)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Groups[0].ErrorCode))
+	return common.SafeDerefStringPtr(resp.Groups[0].GroupState)
+}
+
+func topicProviderName(topicProvider *testTopicInfoProvider, topicID int) string {
+	for topicName, info := range topicProvider.infos {
+		if info.ID == topicID {
+			return topicName
+		}
+	}
+	return ""
+}
+
+func TestOffsetCommit(t *testing.T) {
+	localTransports := transport.NewLocalTransports()
+	gc, controlClient, topicProvider, _ := createCoordinatorWithConnFactoryAndCfgSetter(t, localTransports.CreateConnection, nil)
+	defer stopCoordinator(t, gc)
+
+	fp := &fakePusherSink{}
+	transportServer, err := localTransports.NewLocalServer(uuid.New().String())
+	require.NoError(t, err)
+	transportServer.RegisterHandler(transport.HandlerIDTablePusherDirectWrite, fp.HandleDirectWrite)
+	memberData := common.MembershipData{
+		ClusterListenAddress: transportServer.Address(),
+	}
+	err = gc.MembershipChanged(0, cluster.MembershipState{
+		LeaderVersion:  1,
+		ClusterVersion: 1,
+		Members: []cluster.MembershipEntry{
+			{
+				ID:   0,
+				Data: memberData.Serialize(nil),
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	topicName1 := "test-topic1"
+	topicName2 := "test-topic2"
+	topicProvider.infos[topicName1] = topicmeta.TopicInfo{
+		ID:             1234,
+		Name:           topicName1,
+		PartitionCount: 100,
+	}
+	topicProvider.infos[topicName2] = topicmeta.TopicInfo{
+		ID:             2234,
+		Name:           topicName2,
+		PartitionCount: 100,
+	}
+	controlClient.groupEpoch = 23
+
+	groupID := uuid.New().String()
+	numMembers := 10
+	rebalanceTimeout := 1 * time.Second
+	members, _ := setupJoinedGroupWithArgs(t, numMembers, groupID, gc, rebalanceTimeout)
+	syncGroup(groupID, numMembers, members, gc)
+	require.Equal(t, stateActive, gc.getState(groupID))
+
+	var memberID string
+	members.Range(func(key, value any) bool {
+		memberID = key.(string)
+		return false
+	})
+
+	req := kafkaprotocol.OffsetCommitRequest{
+		GroupId:                   common.StrPtr(groupID),
+		MemberId:                  common.StrPtr(memberID),
+		GenerationIdOrMemberEpoch: int32(1),
+		Topics: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestTopic{
+			{
+				Name: common.StrPtr(topicName1),
+				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
+					{
+						PartitionIndex:  1,
+						CommittedOffset: 12345,
+					},
+					{
+						PartitionIndex:  23,
+						CommittedOffset: 456456,
+					},
+				},
+			},
+			{
+				Name: common.StrPtr(topicName2),
+				Partitions: []kafkaprotocol.OffsetCommitRequestOffsetCommitRequestPartition{
+					{
+						PartitionIndex:  7,
+						CommittedOffset: 345345,
+					},
+				},
+			},
+		},
+	}
+
+	resp, err := gc.OffsetCommit(&req)
+	require.NoError(t, err)
+
+	require.Equal(t, 2, len(resp.Topics))
+	require.Equal(t, topicName1, *resp.Topics[0].Name)
+
+	require.Equal(t, 2, len(resp.Topics[0].Partitions))
+
+	require.Equal(t, 1, int(resp.Topics[0].Partitions[0].PartitionIndex))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[0].ErrorCode))
+	require.Equal(t, 23, int(resp.Topics[0].Partitions[1].PartitionIndex))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[1].ErrorCode))
+
+	require.Equal(t, 1, len(resp.Topics[1].Partitions))
+
+	require.Equal(t, 7, int(resp.Topics[1].Partitions[0].PartitionIndex))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.Topics[0].Partitions[0].ErrorCode))
+
+	received, rcpVer := fp.getReceived()
+	require.NotNil(t, received)
+
+	require.Equal(t, 1, int(rcpVer))
+
+	require.Equal(t, "g."+groupID, received.WriterKey)
+	require.Equal(t, 23, received.WriterEpoch)
+
+	partHash, err := parthash.CreateHash([]byte("g." + groupID))
+	require.NoError(t, err)
+
+	var expectedKVs []common.KV
+	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 1234, 1, 12345))
+	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 1234, 23, 456456))
+	expectedKVs = append(expectedKVs, createExpectedKV(partHash, 2234, 7, 345345))
+
+	require.Equal(t, expectedKVs, received.KVs)
+}
+
+func createExpectedKV(partHash []byte, topicID int, partitionID int, committedOffset int64) common.KV {
+	key := createOffsetKey(partHash, offsetKeyPublic, topicID, partitionID)
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, uint64(committedOffset))
+	return common.KV{
+		Key:   key,
+		Value: val,
+	}
+}
+
+func TestOffsetFetch(t *testing.T) {
+	gc, controlClient, topicProvider, tableGetter := createCoordinatorWithCfgSetter(t, nil)
+	defer stopCoordinator(t, gc)
+
+	topicName1 := "test-topic1"
+	topicName2 := "test-topic2"
+	topicProvider.infos[topicName1] = topicmeta.TopicInfo{
+		ID:             1234,
+		Name:           topicName1,
+		PartitionCount: 100,
+	}
+	topicProvider.infos[topicName2] = topicmeta.TopicInfo{
+		ID:             2234,
+		Name:           topicName2,
+		PartitionCount: 100,
+	}
+	controlClient.groupEpoch = 23
+
+	groupID := uuid.New().String()
+	numMembers := 10
+	rebalanceTimeout := 1 * time.Second
+	members, _ := setupJoinedGroupWithArgs(t, numMembers, groupID, gc, rebalanceTimeout)
+	syncGroup(groupID, numMembers, members, gc)
+	require.Equal(t, stateActive, gc.getState(groupID))
+
+	g, ok := gc.getGroup(groupID)
+	require.True(t, ok)
+
+	infos := []createOffsetsInfo{
 		{
 			topicID: 1234,
 			partInfos: []createOffsetsPartitionInfo{
@@ -1845,6 +2576,542 @@ func TestOffsetFetch(t *testing.T) {
 	require.Equal(t, -1, int(resp.Topics[1].Partitions[3].CommittedOffset))
 }
 
+func TestListGroups(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
+
+	groupID1 := "group1"
+	members, _ := setupJoinedGroup(t, 3, groupID1, gc)
+	syncGroup(groupID1, 3, members, gc)
+	require.Equal(t, stateActive, gc.getState(groupID1))
+
+	groupID2 := "group2"
+	setupJoinedGroup(t, 2, groupID2, gc)
+	require.Equal(t, stateAwaitingReBalance, gc.getState(groupID2))
+
+	resp := listGroups(t, gc, nil, nil)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 2, len(resp.Groups))
+	require.Equal(t, groupID1, common.SafeDerefStringPtr(resp.Groups[0].GroupId))
+	require.Equal(t, "Stable", common.SafeDerefStringPtr(resp.Groups[0].GroupState))
+	require.Equal(t, defaultProtocolType, common.SafeDerefStringPtr(resp.Groups[0].ProtocolType))
+	require.Equal(t, "classic", common.SafeDerefStringPtr(resp.Groups[0].GroupType))
+	require.Equal(t, groupID2, common.SafeDerefStringPtr(resp.Groups[1].GroupId))
+	require.Equal(t, "CompletingRebalance", common.SafeDerefStringPtr(resp.Groups[1].GroupState))
+
+	resp = listGroups(t, gc, []*string{common.StrPtr("stable")}, nil)
+	require.Equal(t, 1, len(resp.Groups))
+	require.Equal(t, groupID1, common.SafeDerefStringPtr(resp.Groups[0].GroupId))
+
+	resp = listGroups(t, gc, []*string{common.StrPtr("Empty")}, nil)
+	require.Equal(t, 0, len(resp.Groups))
+
+	resp = listGroups(t, gc, nil, []*string{common.StrPtr("consumer")})
+	require.Equal(t, 0, len(resp.Groups))
+}
+
+func listGroups(t *testing.T, gc *Coordinator, statesFilter []*string, typesFilter []*string) *kafkaprotocol.ListGroupsResponse {
+	var resp *kafkaprotocol.ListGroupsResponse
+	err := gc.HandleListGroupsRequest(&kafkaprotocol.ListGroupsRequest{
+		StatesFilter: statesFilter,
+		TypesFilter:  typesFilter,
+	}, func(r *kafkaprotocol.ListGroupsResponse) error {
+		resp = r
+		return nil
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestDescribeGroups(t *testing.T) {
+	gc := createCoordinator(t)
+	defer stopCoordinator(t, gc)
+
+	groupID := "group1"
+	numMembers := 3
+	members, memberProtocols := setupJoinedGroup(t, numMembers, groupID, gc)
+	assignments := syncGroup(groupID, numMembers, members, gc)
+
+	var resp *kafkaprotocol.DescribeGroupsResponse
+	err := gc.HandleDescribeGroupsRequest(&kafkaprotocol.DescribeGroupsRequest{
+		Groups: []*string{common.StrPtr(groupID), common.StrPtr("unknown")},
+	}, func(r *kafkaprotocol.DescribeGroupsResponse) error {
+		resp = r
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(resp.Groups))
+
+	described := resp.Groups[0]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(described.ErrorCode))
+	require.Equal(t, groupID, common.SafeDerefStringPtr(described.GroupId))
+	require.Equal(t, "Stable", common.SafeDerefStringPtr(described.GroupState))
+	require.Equal(t, defaultProtocolType, common.SafeDerefStringPtr(described.ProtocolType))
+	require.Equal(t, defaultProtocolName, common.SafeDerefStringPtr(described.ProtocolData))
+	require.Equal(t, numMembers, len(described.Members))
+	expectedAssignments := map[string][]byte{}
+	for _, assignment := range assignments {
+		expectedAssignments[assignment.MemberID] = assignment.Assignment
+	}
+	for _, m := range described.Members {
+		memberID := common.SafeDerefStringPtr(m.MemberId)
+		require.Equal(t, defaultClientID, common.SafeDerefStringPtr(m.ClientId))
+		protocols, ok := memberProtocols.Load(memberID)
+		require.True(t, ok)
+		require.Equal(t, protocols.([]ProtocolInfo)[0].Metadata, m.MemberMetadata)
+		require.Equal(t, expectedAssignments[memberID], m.MemberAssignment)
+	}
+
+	described = resp.Groups[1]
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(described.ErrorCode))
+	require.Equal(t, "unknown", common.SafeDerefStringPtr(described.GroupId))
+	require.Equal(t, "Dead", common.SafeDerefStringPtr(described.GroupState))
+	require.Equal(t, 0, len(described.Members))
+}
+
+func TestDeleteGroups(t *testing.T) {
+	gc, controlClient, _, tableGetter, fp := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc)
+
+	// Non-empty group cannot be deleted
+	groupID := "group1"
+	members, _ := setupJoinedGroup(t, 2, groupID, gc)
+	require.Equal(t, kafkaprotocol.ErrorCodeNonEmptyGroup, int(deleteGroup(t, gc, groupID)))
+
+	// Unknown group
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, int(deleteGroup(t, gc, "unknown")))
+
+	// Once all members have left the group can be deleted, and its committed offsets are deleted
+	var leaveInfos []MemberLeaveInfo
+	members.Range(func(key, value any) bool {
+		leaveInfos = append(leaveInfos, MemberLeaveInfo{MemberID: key.(string)})
+		return true
+	})
+	errCode, _ := gc.leaveGroup(groupID, leaveInfos)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(errCode))
+	require.Equal(t, stateEmpty, gc.getState(groupID))
+
+	g, ok := gc.getGroup(groupID)
+	require.True(t, ok)
+	infos := []createOffsetsInfo{
+		{topicID: 1234, partInfos: []createOffsetsPartitionInfo{{1, 100}, {3, 200}}},
+	}
+	setOffsetsTable(t, controlClient, tableGetter, infos, g.partHash)
+
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(deleteGroup(t, gc, groupID)))
+	_, ok = gc.getGroup(groupID)
+	require.False(t, ok)
+	received, _ := fp.getReceived()
+	require.NotNil(t, received)
+	require.Equal(t, createCoordinatorKey(groupID), received.WriterKey)
+	expectedKVs := createOffsetsKvs(t, infos, g.partHash)
+	require.Equal(t, len(expectedKVs), len(received.KVs))
+	for i, kv := range received.KVs {
+		require.Equal(t, expectedKVs[i].Key, kv.Key)
+		require.Equal(t, 0, len(kv.Value))
+	}
+
+	// A group which is not in memory but has committed offsets can be deleted
+	groupID = "group2"
+	partHash := gc.newGroup(groupID, 0).partHash
+	setOffsetsTable(t, controlClient, tableGetter, infos, partHash)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(deleteGroup(t, gc, groupID)))
+	received, _ = fp.getReceived()
+	require.Equal(t, createCoordinatorKey(groupID), received.WriterKey)
+	require.Equal(t, len(expectedKVs), len(received.KVs))
+}
+
+func deleteGroup(t *testing.T, gc *Coordinator, groupID string) int16 {
+	var resp *kafkaprotocol.DeleteGroupsResponse
+	err := gc.HandleDeleteGroupsRequest(&kafkaprotocol.DeleteGroupsRequest{
+		GroupsNames: []*string{common.StrPtr(groupID)},
+	}, func(r *kafkaprotocol.DeleteGroupsResponse) error {
+		resp = r
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(resp.Results))
+	require.Equal(t, groupID, common.SafeDerefStringPtr(resp.Results[0].GroupId))
+	return resp.Results[0].ErrorCode
+}
+
+func TestOffsetDelete(t *testing.T) {
+	gc, _, topicProvider, _, fp := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc)
+
+	topicName1 := "test-topic1"
+	topicName2 := "test-topic2"
+	topicProvider.infos[topicName1] = topicmeta.TopicInfo{ID: 1234, Name: topicName1, PartitionCount: 10}
+	topicProvider.infos[topicName2] = topicmeta.TopicInfo{ID: 2234, Name: topicName2, PartitionCount: 10}
+
+	// Consumer group with a member subscribed to topic1
+	groupID := "group1"
+	metadata := createSubscriptionMetadata(topicName1)
+	protocols := []ProtocolInfo{{defaultProtocolName, metadata}}
+	res := callJoinGroupSyncWithApiVersion(gc, groupID, defaultClientID, "", "consumer", protocols,
+		defaultSessionTimeout, defaultRebalanceTimeout, 4)
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownMemberID, res.ErrorCode)
+	res = callJoinGroupSync(gc, groupID, defaultClientID, res.MemberID, "consumer", protocols,
+		defaultSessionTimeout, defaultRebalanceTimeout)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, res.ErrorCode)
+
+	resp := offsetDelete(t, gc, groupID, map[string][]int32{topicName1: {1, 2}, topicName2: {3}, "unknown": {0}})
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, 3, len(resp.Topics))
+	errCodes := map[string]int16{}
+	for _, topic := range resp.Topics {
+		for _, partition := range topic.Partitions {
+			errCodes[fmt.Sprintf("%s-%d", *topic.Name, partition.PartitionIndex)] = partition.ErrorCode
+		}
+	}
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupSubscribedToTopic, int(errCodes[topicName1+"-1"]))
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupSubscribedToTopic, int(errCodes[topicName1+"-2"]))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(errCodes[topicName2+"-3"]))
+	require.Equal(t, kafkaprotocol.ErrorCodeUnknownTopicOrPartition, int(errCodes["unknown-0"]))
+
+	g, ok := gc.getGroup(groupID)
+	require.True(t, ok)
+	received, _ := fp.getReceived()
+	require.NotNil(t, received)
+	require.Equal(t, 2, len(received.KVs))
+	require.Equal(t, createOffsetKey(g.partHash, offsetKeyPublic, 2234, 3), received.KVs[0].Key)
+	require.Equal(t, createOffsetKey(g.partHash, offsetKeyTransactional, 2234, 3), received.KVs[1].Key)
+	require.Equal(t, 0, len(received.KVs[0].Value))
+	require.Equal(t, 0, len(received.KVs[1].Value))
+
+	// Non-consumer groups with members cannot have offsets deleted
+	groupID = "group2"
+	setupJoinedGroup(t, 1, groupID, gc)
+	resp = offsetDelete(t, gc, groupID, map[string][]int32{topicName2: {3}})
+	require.Equal(t, kafkaprotocol.ErrorCodeNonEmptyGroup, int(resp.ErrorCode))
+
+	resp = offsetDelete(t, gc, "unknown", map[string][]int32{topicName2: {3}})
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, int(resp.ErrorCode))
+}
+
+func createSubscriptionMetadata(topics ...string) []byte {
+	var metadata []byte
+	metadata = binary.BigEndian.AppendUint16(metadata, 0)
+	metadata = binary.BigEndian.AppendUint32(metadata, uint32(len(topics)))
+	for _, topic := range topics {
+		metadata = binary.BigEndian.AppendUint16(metadata, uint16(len(topic)))
+		metadata = append(metadata, topic...)
+	}
+	// user data
+	metadata = binary.BigEndian.AppendUint32(metadata, math.MaxUint32)
+	return metadata
+}
+
+func offsetDelete(t *testing.T, gc *Coordinator, groupID string, partitions map[string][]int32) *kafkaprotocol.OffsetDeleteResponse {
+	req := &kafkaprotocol.OffsetDeleteRequest{GroupId: common.StrPtr(groupID)}
+	for topicName, partitionIDs := range partitions {
+		topic := kafkaprotocol.OffsetDeleteRequestOffsetDeleteRequestTopic{Name: common.StrPtr(topicName)}
+		for _, partitionID := range partitionIDs {
+			topic.Partitions = append(topic.Partitions,
+				kafkaprotocol.OffsetDeleteRequestOffsetDeleteRequestPartition{PartitionIndex: partitionID})
+		}
+		req.Topics = append(req.Topics, topic)
+	}
+	var resp *kafkaprotocol.OffsetDeleteResponse
+	err := gc.HandleOffsetDeleteRequest(req, func(r *kafkaprotocol.OffsetDeleteResponse) error {
+		resp = r
+		return nil
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestClassicGroupMetadataLoadedOnFailover(t *testing.T) {
+	gc1, controlClient1, _, _, fp1 := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc1)
+	controlClient1.groupEpoch = 7
+
+	groupID := uuid.New().String()
+	numMembers := 3
+	members, _ := setupJoinedGroup(t, numMembers, groupID, gc1)
+	assignments := syncGroup(groupID, numMembers, members, gc1)
+
+	// Metadata is written with the group epoch when the rebalance completes
+	received, _ := fp1.getReceived()
+	require.NotNil(t, received)
+	require.Equal(t, 7, received.WriterEpoch)
+	require.Equal(t, createCoordinatorKey(groupID), received.WriterKey)
+	require.Equal(t, 1, len(received.KVs))
+	require.Equal(t, createGroupMetadataKey(gc1.groups[groupID].partHash), received.KVs[0].Key)
+
+	// Coordination moves to another agent which loads the group
+	gc2, controlClient2, _, tableGetter2, _ := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc2)
+	setTableFromKVs(t, controlClient2, tableGetter2, received.KVs)
+
+	members.Range(func(key, value any) bool {
+		memberID := key.(string)
+		errCode := gc2.heartbeatGroup(groupID, memberID, "", 1)
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, errCode)
+		return true
+	})
+	require.Equal(t, stateActive, gc2.getState(groupID))
+	g := gc2.groups[groupID]
+	require.Equal(t, 1, g.generationID)
+	require.Equal(t, defaultProtocolType, g.protocolType)
+	require.Equal(t, defaultProtocolName, g.protocolName)
+	require.Equal(t, numMembers, len(g.members))
+	leader, ok := members.Load(g.leader)
+	require.True(t, ok)
+	require.True(t, leader.(bool))
+
+	// Members get their current assignment when they sync
+	for _, assignment := range assignments {
+		ch := make(chan syncResult, 1)
+		gc2.syncGroup(groupID, assignment.MemberID, "", 1, nil, func(errorCode int, assignment []byte) {
+			ch <- syncResult{errorCode: errorCode, assignment: assignment}
+		})
+		res := <-ch
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, res.errorCode)
+		require.Equal(t, assignment.Assignment, res.assignment)
+	}
+
+	// A member leaving causes a rebalance
+	errCode, _ := gc2.leaveGroup(groupID, []MemberLeaveInfo{{MemberID: assignments[0].MemberID}})
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(errCode))
+	require.Equal(t, statePreReBalance, gc2.getState(groupID))
+	errCode = int16(gc2.heartbeatGroup(groupID, assignments[1].MemberID, "", 1))
+	require.Equal(t, kafkaprotocol.ErrorCodeRebalanceInProgress, int(errCode))
+}
+
+func TestConsumerGroupMetadataLoadedOnFailover(t *testing.T) {
+	gc1, _, topicProvider1, _, fp1 := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc1)
+	topicProvider1.infos["topic1"] = topicmeta.TopicInfo{ID: 1000, Name: "topic1", PartitionCount: 6}
+	joinConsumerGroupMembers(t, gc1, "group1", "topic1", "member-0", "member-1", "member-2")
+	cg1 := gc1.groups["group1"].consumer
+
+	received, _ := fp1.getReceived()
+	require.NotNil(t, received)
+	require.Equal(t, 1, len(received.KVs))
+
+	gc2, controlClient2, topicProvider2, tableGetter2, fp2 := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc2)
+	topicProvider2.infos["topic1"] = topicProvider1.infos["topic1"]
+	setTableFromKVs(t, controlClient2, tableGetter2, received.KVs)
+
+	require.Equal(t, "Stable", consumerGroupState(t, gc2, "group1"))
+	cg2 := gc2.groups["group1"].consumer
+	require.Equal(t, cg1.epoch, cg2.epoch)
+	require.Equal(t, cg1.assignmentEpoch, cg2.assignmentEpoch)
+	require.Equal(t, cg1.assignorName, cg2.assignorName)
+	require.Equal(t, cg1.targetAssignment, cg2.targetAssignment)
+	require.Equal(t, cg1.partitionOwners, cg2.partitionOwners)
+
+	// Members carry on at the same epoch with the same assignment
+	for memberID, m := range cg1.members {
+		resp := consumerGroupHeartbeat(t, gc2, consumerGroupHeartbeatRequest("group1", memberID, m.epoch, m.assigned))
+		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+		require.Equal(t, m.epoch, resp.MemberEpoch)
+		if resp.Assignment != nil {
+			require.Equal(t, m.assigned, heartbeatAssignment(t, resp))
+		}
+	}
+	require.Equal(t, "Stable", consumerGroupState(t, gc2, "group1"))
+	// Nothing has changed, so the metadata is not written again
+	received, _ = fp2.getReceived()
+	require.Nil(t, received)
+
+	// A new member causes a new epoch, which is written
+	resp := consumerGroupHeartbeat(t, gc2, joinConsumerGroupRequest("group1", "member-3", "topic1"))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, cg1.epoch+1, gc2.groups["group1"].consumer.epoch)
+	received, _ = fp2.getReceived()
+	require.NotNil(t, received)
+}
+
+func TestGroupRemovedWhenCoordinatorMoves(t *testing.T) {
+	gc, controlClient, _, tableGetter, fp := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc)
+
+	groupID := uuid.New().String()
+	members, _ := setupJoinedGroup(t, 1, groupID, gc)
+	syncGroup(groupID, 1, members, gc)
+	var memberID string
+	members.Range(func(key, value any) bool {
+		memberID = key.(string)
+		return false
+	})
+	received, _ := fp.getReceived()
+	require.NotNil(t, received)
+
+	// Coordination of the group moves to another agent
+	address := controlClient.groupCoordinatorAddress
+	controlClient.groupCoordinatorAddress = uuid.New().String()
+	err := gc.MembershipChanged(0, gc.membership)
+	require.NoError(t, err)
+	errCode := gc.heartbeatGroup(groupID, memberID, "", 1)
+	require.Equal(t, kafkaprotocol.ErrorCodeNotCoordinator, errCode)
+	require.Equal(t, -1, gc.getState(groupID))
+
+	// And back again with a new epoch - the group is loaded again
+	controlClient.groupCoordinatorAddress = address
+	controlClient.groupEpoch++
+	err = gc.MembershipChanged(0, gc.membership)
+	require.NoError(t, err)
+	setTableFromKVs(t, controlClient, tableGetter, received.KVs)
+	errCode = gc.heartbeatGroup(groupID, memberID, "", 1)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, errCode)
+	require.Equal(t, controlClient.groupEpoch, gc.groups[groupID].groupEpoch)
+}
+
+func TestDeletedGroupMetadataNotLoaded(t *testing.T) {
+	gc, controlClient, _, tableGetter, fp := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc)
+
+	groupID := uuid.New().String()
+	members, _ := setupJoinedGroup(t, 1, groupID, gc)
+	syncGroup(groupID, 1, members, gc)
+	var memberID string
+	members.Range(func(key, value any) bool {
+		memberID = key.(string)
+		return false
+	})
+	errCode, memberErrCodes := gc.leaveGroup(groupID, []MemberLeaveInfo{{MemberID: memberID}})
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(errCode))
+	require.Equal(t, []int16{kafkaprotocol.ErrorCodeNone}, memberErrCodes)
+	require.Equal(t, stateEmpty, gc.getState(groupID))
+	setTableFromLastWrite(t, fp, controlClient, tableGetter)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(deleteGroup(t, gc, groupID)))
+
+	// The metadata is deleted along with the offsets
+	received, _ := fp.getReceived()
+	require.Equal(t, 1, len(received.KVs))
+	require.Equal(t, createGroupMetadataKey(gc.newGroup(groupID, 0).partHash), received.KVs[0].Key)
+	require.Equal(t, 0, len(received.KVs[0].Value))
+	setTableFromKVs(t, controlClient, tableGetter, received.KVs)
+	require.Equal(t, kafkaprotocol.ErrorCodeGroupIDNotFound, gc.heartbeatGroup(groupID, memberID, "", 1))
+}
+
+func TestDeletedTopicOffsetsReclaimed(t *testing.T) {
+	gc1, _, _, _, fp1 := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc1)
+	groupID := uuid.New().String()
+	members, _ := setupJoinedGroup(t, 1, groupID, gc1)
+	syncGroup(groupID, 1, members, gc1)
+	var memberID string
+	members.Range(func(key, value any) bool {
+		memberID = key.(string)
+		return false
+	})
+	received, _ := fp1.getReceived()
+	require.NotNil(t, received)
+	metadataKVs := received.KVs
+
+	// Offsets committed for topic 1000, which exists, and topic 2000, which has been deleted
+	partHash := gc1.groups[groupID].partHash
+	createKVs := func(topicIDs ...int) []common.KV {
+		kvs := append([]common.KV{}, metadataKVs...)
+		for _, topicID := range topicIDs {
+			for _, keyType := range []byte{offsetKeyPublic, offsetKeyTransactional} {
+				for partitionID := 0; partitionID < 2; partitionID++ {
+					kvs = append(kvs, common.KV{
+						Key:   createOffsetKey(partHash, keyType, topicID, partitionID),
+						Value: binary.BigEndian.AppendUint64(nil, 100),
+					})
+				}
+			}
+		}
+		sort.Slice(kvs, func(i, j int) bool {
+			return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+		})
+		return kvs
+	}
+	requireTombstones := func(fp *fakePusherSink, topicID int) {
+		received, _ := fp.getReceived()
+		require.NotNil(t, received)
+		require.Equal(t, 4, len(received.KVs))
+		i := 0
+		for _, keyType := range []byte{offsetKeyPublic, offsetKeyTransactional} {
+			for partitionID := 0; partitionID < 2; partitionID++ {
+				require.Equal(t, createOffsetKey(partHash, keyType, topicID, partitionID), received.KVs[i].Key)
+				require.Equal(t, 0, len(received.KVs[i].Value))
+				i++
+			}
+		}
+	}
+
+	// The offsets of the deleted topic are removed when another coordinator loads the group
+	gc2, controlClient2, _, tableGetter2, fp2 := setupCoordinatorWithPusherSink(t)
+	defer stopCoordinator(t, gc2)
+	controlClient2.topicInfos = []topicmeta.TopicInfo{{ID: 1000, Name: "topic1", PartitionCount: 2}}
+	setTableFromKVs(t, controlClient2, tableGetter2, createKVs(1000, 2000))
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, gc2.heartbeatGroup(groupID, memberID, "", 1))
+	requireTombstones(fp2, 2000)
+
+	// The offsets of a topic deleted while the group is loaded are removed when notified
+	setTableFromKVs(t, controlClient2, tableGetter2, createKVs(1000))
+	controlClient2.topicInfos = nil
+	gc2.ReclaimDeletedTopicOffsets()
+	requireTombstones(fp2, 1000)
+}
+
+func setTableFromLastWrite(t *testing.T, fp *fakePusherSink, controlClient *testControlClient,
+	tableGetter *testTableGetter) {
+	received, _ := fp.getReceived()
+	require.NotNil(t, received)
+	setTableFromKVs(t, controlClient, tableGetter, received.KVs)
+}
+
+func setTableFromKVs(t *testing.T, controlClient *testControlClient, tableGetter *testTableGetter,
+	kvs []common.KV) {
+	table, _, _, _, _, err := sst.BuildSSTable(common.DataFormatV1, 0, 0, common.NewKvSliceIterator(kvs))
+	require.NoError(t, err)
+	tableGetter.table = table
+	controlClient.queryRes = []lsm.NonOverlappingTables{
+		[]lsm.QueryTableInfo{
+			{
+				ID: []byte(sst.CreateSSTableId()),
+			},
+		},
+	}
+}
+
+func setupCoordinatorWithPusherSink(t *testing.T) (*Coordinator, *testControlClient, *testTopicInfoProvider,
+	*testTableGetter, *fakePusherSink) {
+	localTransports := transport.NewLocalTransports()
+	gc, controlClient, topicProvider, tableGetter := createCoordinatorWithConnFactoryAndCfgSetter(t,
+		localTransports.CreateConnection, func(cfg *Conf) {
+			cfg.InitialJoinDelay = defaultInitialJoinDelay
+		})
+	fp := &fakePusherSink{}
+	transportServer, err := localTransports.NewLocalServer(uuid.New().String())
+	require.NoError(t, err)
+	transportServer.RegisterHandler(transport.HandlerIDTablePusherDirectWrite, fp.HandleDirectWrite)
+	memberData := common.MembershipData{
+		ClusterListenAddress: transportServer.Address(),
+	}
+	err = gc.MembershipChanged(0, cluster.MembershipState{
+		LeaderVersion:  1,
+		ClusterVersion: 1,
+		Members: []cluster.MembershipEntry{
+			{
+				ID:   0,
+				Data: memberData.Serialize(nil),
+			},
+		},
+	})
+	require.NoError(t, err)
+	return gc, controlClient, topicProvider, tableGetter, fp
+}
+
+func setOffsetsTable(t *testing.T, controlClient *testControlClient, tableGetter *testTableGetter,
+	infos []createOffsetsInfo, partHash []byte) {
+	tableGetter.table = createOffsetsBatch(t, infos, partHash)
+	controlClient.queryRes = []lsm.NonOverlappingTables{
+		[]lsm.QueryTableInfo{
+			{
+				ID: []byte(sst.CreateSSTableId()),
+			},
+		},
+	}
+}
+
 type createOffsetsInfo struct {
 	topicID   int
 	partInfos []createOffsetsPartitionInfo
@@ -1895,7 +3162,7 @@ func callJoinGroupSync(gc *Coordinator, groupID string, clientID string, memberI
 func callJoinGroupSyncWithApiVersion(gc *Coordinator, groupID string, clientID string, memberID string, protocolType string, protocols []ProtocolInfo, sessionTimeout time.Duration,
 	rebalanceTimeout time.Duration, apiVersion int16) JoinResult {
 	ch := make(chan JoinResult, 1)
-	gc.joinGroup(apiVersion, groupID, clientID, memberID, protocolType, protocols, sessionTimeout, rebalanceTimeout, func(result JoinResult) {
+	gc.joinGroup(apiVersion, groupID, clientID, memberID, "", protocolType, protocols, sessionTimeout, rebalanceTimeout, func(result JoinResult) {
 		ch <- result
 	})
 	return <-ch
@@ -1937,7 +3204,7 @@ func createCoordinatorWithConnFactoryAndCfgSetter(t *testing.T, connFactory tran
 		return controlClient, nil
 	}
 	controlClientCache := control.NewClientCache(10, controlFactory)
-	gc, err := NewCoordinator(cfg, topicProvider, controlClientCache, connFactory, tableGetter.getTable)
+	gc, err := NewCoordinator(cfg, topicProvider, controlClientCache, connFactory, tableGetter.getTable, nil)
 	require.NoError(t, err)
 	gc.SetKafkaAddress(address)
 	err = gc.Start()
@@ -1986,6 +3253,7 @@ type testControlClient struct {
 	groupCoordinatorAddress  string
 	groupEpoch               int
 	queryRes                 lsm.OverlappingTables
+	topicInfos               []topicmeta.TopicInfo
 }
 
 func (t *testControlClient) PrePush(infos []offsets.GenerateOffsetTopicInfo, epochInfos []control.EpochInfo) ([]offsets.OffsetTopicInfo, int64,
@@ -2005,7 +3273,7 @@ func (t *testControlClient) QueryTablesInRange(keyStart []byte, keyEnd []byte) (
 	return t.queryRes, nil
 }
 
-func (t *testControlClient) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, error) {
+func (t *testControlClient) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, int64, int64, error) {
 	panic("should not be called")
 }
 
@@ -2022,7 +3290,7 @@ func (t *testControlClient) GetTopicInfo(topicName string) (topicmeta.TopicInfo,
 }
 
 func (t *testControlClient) GetAllTopicInfos() ([]topicmeta.TopicInfo, error) {
-	panic("should not be called")
+	return t.topicInfos, nil
 }
 
 func (t *testControlClient) CreateTopic(topicInfo topicmeta.TopicInfo) error {
@@ -2033,6 +3301,14 @@ func (t *testControlClient) DeleteTopic(topicName string) error {
 	panic("should not be called")
 }
 
+func (t *testControlClient) CreatePartitions(topicName string, partitionCount int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
 func (t *testControlClient) GetCoordinatorInfo(key string) (memberID int32, address string, groupEpoch int, err error) {
 	return t.groupCoordinatorMemberID, t.groupCoordinatorAddress, t.groupEpoch, nil
 }
@@ -2041,6 +3317,47 @@ func (t *testControlClient) GenerateSequence(sequenceName string) (int64, error)
 	panic("should not be called")
 }
 
+func (t *testControlClient) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteUserCredentials(username string, mechanism string) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterClientQuotas(alterations []control.ClientQuotaAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetClientQuotas() ([]control.ClientQuotaConfig, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) CreateAcls([]acls.AclEntry) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteAcls([]acls.AclFilter) ([][]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetAcls() ([]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	panic("should not be called")
+}
+
 func (t *testControlClient) Close() error {
 	panic("should not be called")
 }
@@ -2085,3 +3402,11 @@ func TestCalcMemberForHash(t *testing.T) {
 	h = []byte{255, 255, 255, 254, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}
 	require.Equal(t, 9, common.CalcMemberForHash(h, 10))
 }
+
+func TestGroupMetadataUnknownVersion(t *testing.T) {
+	g := &group{}
+	buff := binary.BigEndian.AppendUint16(nil, groupMetadataVersion+1)
+	buff = append(buff, groupMetadataTypeClassic)
+	err := g.deserializeMetadata(buff)
+	require.Error(t, err)
+}
@@ -5,13 +5,16 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/pusher"
+	"github.com/spirit-labs/tektite/queryutils"
 	"github.com/spirit-labs/tektite/transport"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +27,7 @@ type group struct {
 	state                   int
 	members                 map[string]*member
 	pendingMemberIDs        map[string]struct{}
+	staticMembers           map[string]string
 	leader                  string
 	protocolType            string
 	protocolName            string
@@ -36,9 +40,15 @@ type group struct {
 	newMemberAdded          bool
 	committedOffsets        map[int]map[int32]int64
 	groupEpoch              int
+	// membershipVersion is the coordinator membership version when the group epoch was last checked
+	membershipVersion atomic.Int64
+	// consumer is the state of the group if it uses the consumer group protocol, otherwise nil
+	consumer *consumerGroup
 }
 
 type member struct {
+	clientID         string
+	groupInstanceID  string
 	protocols        []ProtocolInfo
 	joinCompletion   JoinCompletion
 	syncCompletion   SyncCompletion
@@ -47,14 +57,43 @@ type member struct {
 	reBalanceTimeout time.Duration
 }
 
-func (g *group) Join(apiVersion int16, clientID string, memberID string, protocolType string, protocols []ProtocolInfo,
-	sessionTimeout time.Duration, reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
+func (g *group) Join(apiVersion int16, clientID string, memberID string, groupInstanceID string, protocolType string,
+	protocols []ProtocolInfo, sessionTimeout time.Duration, reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
+	if g.consumer != nil {
+		if len(g.consumer.members) > 0 {
+			// The group is in use by members using the consumer group protocol
+			completionFunc(JoinResult{ErrorCode: kafkaprotocol.ErrorCodeInconsistentGroupProtocol, MemberID: ""})
+			return
+		}
+		// An empty consumer group becomes a classic group
+		g.consumer = nil
+	}
 	if g.state != stateEmpty && !g.canSupportProtocols(protocols) {
 		completionFunc(JoinResult{ErrorCode: kafkaprotocol.ErrorCodeInconsistentGroupProtocol, MemberID: ""})
 		return
 	}
+	if groupInstanceID != "" && g.state != stateDead {
+		// Static member (KIP-345)
+		staticMemberID, known := g.staticMembers[groupInstanceID]
+		if memberID == "" {
+			if known {
+				g.rejoinStaticMember(groupInstanceID, staticMemberID, clientID, protocols, completionFunc)
+				return
+			}
+			// Static members are not required to call back in with their member id before joining
+			memberID = generateMemberID(clientID)
+			g.staticMembers[groupInstanceID] = memberID
+		} else if !known {
+			completionFunc(JoinResult{ErrorCode: kafkaprotocol.ErrorCodeUnknownMemberID, MemberID: memberID})
+			return
+		} else if staticMemberID != memberID {
+			// Another member has joined with the same instance id
+			completionFunc(JoinResult{ErrorCode: kafkaprotocol.ErrorCodeFencedInstanceID, MemberID: memberID})
+			return
+		}
+	}
 	if memberID == "" {
 		memberID = generateMemberID(clientID)
 		g.gc.setTimer(memberID, sessionTimeout, func() {
@@ -73,7 +112,7 @@ func (g *group) Join(apiVersion int16, clientID string, memberID string, protoco
 		// The first to join is the leader
 		g.leader = memberID
 		g.protocolType = protocolType
-		g.addMember(memberID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
+		g.addMember(memberID, clientID, groupInstanceID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
 		g.newMemberAdded = false
 		g.state = statePreReBalance
 		// The first time the join stage is attempted we don't try to complete the join until after a delay - this
@@ -87,7 +126,7 @@ func (g *group) Join(apiVersion int16, clientID string, memberID string, protoco
 			g.updateMember(memberID, protocols, completionFunc)
 		} else {
 			// adding new member
-			g.addMember(memberID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
+			g.addMember(memberID, clientID, groupInstanceID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
 		}
 		if g.initialJoinDelayExpired {
 			// If we have gone through join before we can potentially complete the join now, otherwise a timer
@@ -101,7 +140,7 @@ func (g *group) Join(apiVersion int16, clientID string, memberID string, protoco
 			// For any members waiting sync we complete response with reBalance-in-progress and empty assignments
 			// Members will then re-join
 			g.resetSync()
-			g.addMember(memberID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
+			g.addMember(memberID, clientID, groupInstanceID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
 		} else {
 			// existing member
 			if !protocolInfosEqual(member.protocols, protocols) {
@@ -116,7 +155,7 @@ func (g *group) Join(apiVersion int16, clientID string, memberID string, protoco
 	case stateActive:
 		_, ok := g.members[memberID]
 		if !ok {
-			g.addMember(memberID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
+			g.addMember(memberID, clientID, groupInstanceID, protocols, sessionTimeout, reBalanceTimeout, completionFunc)
 			g.triggerReBalance()
 		} else {
 			// existing member
@@ -302,9 +341,11 @@ func (g *group) chooseNewLeader() string {
 	return ""
 }
 
-func (g *group) addMember(memberID string, protocols []ProtocolInfo, sessionTimeout time.Duration,
-	reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
+func (g *group) addMember(memberID string, clientID string, groupInstanceID string, protocols []ProtocolInfo,
+	sessionTimeout time.Duration, reBalanceTimeout time.Duration, completionFunc JoinCompletion) {
 	g.members[memberID] = &member{
+		clientID:         clientID,
+		groupInstanceID:  groupInstanceID,
 		protocols:        protocols,
 		joinCompletion:   completionFunc,
 		sessionTimeout:   sessionTimeout,
@@ -335,6 +376,78 @@ func (g *group) updateMember(memberID string, protocols []ProtocolInfo, completi
 	delete(g.pendingMemberIDs, memberID)
 }
 
+// rejoinStaticMember handles a static member which has restarted and joined again with an empty member id. The member
+// is given a new member id and keeps its assignment, so no rebalance is needed unless its protocols have changed.
+func (g *group) rejoinStaticMember(groupInstanceID string, oldMemberID string, clientID string,
+	protocols []ProtocolInfo, completionFunc JoinCompletion) {
+	memberID := generateMemberID(clientID)
+	g.replaceStaticMember(groupInstanceID, oldMemberID, memberID)
+	switch g.state {
+	case statePreReBalance:
+		g.updateMember(memberID, protocols, completionFunc)
+		if g.initialJoinDelayExpired {
+			g.maybeCompleteJoin()
+		}
+	case stateAwaitingReBalance:
+		// The leader computes assignments using the old member id, so we must rebalance
+		g.resetSync()
+		g.updateMember(memberID, protocols, completionFunc)
+	case stateActive:
+		if protocolInfosEqual(g.members[memberID].protocols, protocols) {
+			// The member will get its current assignment when it syncs
+			g.persistMetadata()
+			g.sendJoinResult(memberID, completionFunc)
+		} else {
+			g.updateMember(memberID, protocols, completionFunc)
+			g.triggerReBalance()
+		}
+	}
+}
+
+// replaceStaticMember replaces the member id of a static member. Any requests from the old member id are fenced.
+func (g *group) replaceStaticMember(groupInstanceID string, oldMemberID string, newMemberID string) {
+	member := g.members[oldMemberID]
+	if member.joinCompletion != nil {
+		member.joinCompletion(JoinResult{ErrorCode: kafkaprotocol.ErrorCodeFencedInstanceID, MemberID: oldMemberID})
+		member.joinCompletion = nil
+	}
+	if member.syncCompletion != nil {
+		member.syncCompletion(kafkaprotocol.ErrorCodeFencedInstanceID, nil)
+		member.syncCompletion = nil
+	}
+	delete(g.members, oldMemberID)
+	g.members[newMemberID] = member
+	g.staticMembers[groupInstanceID] = newMemberID
+	if g.leader == oldMemberID {
+		g.leader = newMemberID
+	}
+	for i, assignment := range g.assignments {
+		if assignment.MemberID == oldMemberID {
+			g.assignments[i].MemberID = newMemberID
+		}
+	}
+	g.gc.cancelTimer(oldMemberID)
+	g.gc.rescheduleTimer(newMemberID, member.sessionTimeout, func() {
+		g.sessionTimeoutExpired(newMemberID)
+	})
+}
+
+// checkStaticMember checks that the member id provided by a static member is the current member id for its group
+// instance id
+func (g *group) checkStaticMember(memberID string, groupInstanceID string) int {
+	if groupInstanceID == "" {
+		return kafkaprotocol.ErrorCodeNone
+	}
+	staticMemberID, ok := g.staticMembers[groupInstanceID]
+	if !ok {
+		return kafkaprotocol.ErrorCodeUnknownMemberID
+	}
+	if staticMemberID != memberID {
+		return kafkaprotocol.ErrorCodeFencedInstanceID
+	}
+	return kafkaprotocol.ErrorCodeNone
+}
+
 func (g *group) removeMember(memberID string) bool {
 	member, ok := g.members[memberID]
 	if !ok {
@@ -342,11 +455,15 @@ func (g *group) removeMember(memberID string) bool {
 	}
 	delete(g.members, memberID)
 	delete(g.pendingMemberIDs, memberID)
+	if member.groupInstanceID != "" {
+		delete(g.staticMembers, member.groupInstanceID)
+	}
 	g.updateSupportedProtocols(member.protocols, false)
 	g.gc.cancelTimer(memberID)
 	if len(g.members) == 0 {
 		g.state = stateEmpty
 		g.assignments = nil
+		g.persistMetadata()
 	}
 	return true
 }
@@ -372,6 +489,7 @@ func (g *group) maybeCompleteJoin() bool {
 }
 
 func (g *group) sendJoinResults() {
+	g.gc.rebalances.Inc()
 	g.protocolName = g.chooseProtocol()
 	memberInfos := g.createMemberInfos()
 	for memberID, member := range g.members {
@@ -434,10 +552,14 @@ func (g *group) createMemberInfos() []MemberInfo {
 		if meta == nil {
 			panic("cannot find protocol")
 		}
-		memberInfos = append(memberInfos, MemberInfo{
+		memberInfo := MemberInfo{
 			MemberID: memberID,
 			MetaData: meta,
-		})
+		}
+		if member.groupInstanceID != "" {
+			memberInfo.GroupInstanceID = &member.groupInstanceID
+		}
+		memberInfos = append(memberInfos, memberInfo)
 	}
 	return memberInfos
 }
@@ -452,6 +574,7 @@ func (g *group) sendJoinResultWithMembers(memberID string, memberInfos []MemberI
 		ErrorCode:      kafkaprotocol.ErrorCodeNone,
 		MemberID:       memberID,
 		LeaderMemberID: g.leader,
+		ProtocolType:   g.protocolType,
 		ProtocolName:   g.protocolName,
 		GenerationID:   g.generationID,
 		Members:        memberInfos,
@@ -462,9 +585,14 @@ func (g *group) sendJoinResultWithMembers(memberID string, memberInfos []MemberI
 	completionFunc(jr)
 }
 
-func (g *group) Sync(memberID string, generationID int, assignments []AssignmentInfo, completionFunc SyncCompletion) {
+func (g *group) Sync(memberID string, groupInstanceID string, generationID int, assignments []AssignmentInfo,
+	completionFunc SyncCompletion) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
+	if errCode := g.checkStaticMember(memberID, groupInstanceID); errCode != kafkaprotocol.ErrorCodeNone {
+		completionFunc(errCode, nil)
+		return
+	}
 	if generationID != g.generationID {
 		completionFunc(kafkaprotocol.ErrorCodeIllegalGeneration, nil)
 		return
@@ -546,11 +674,15 @@ func (g *group) completeSync() {
 	// cancel sync timeout
 	g.gc.cancelTimer(g.id)
 	g.state = stateActive
+	g.persistMetadata()
 }
 
-func (g *group) Heartbeat(memberID string, generationID int) int {
+func (g *group) Heartbeat(memberID string, groupInstanceID string, generationID int) int {
 	g.lock.Lock()
 	defer g.lock.Unlock()
+	if errCode := g.checkStaticMember(memberID, groupInstanceID); errCode != kafkaprotocol.ErrorCodeNone {
+		return errCode
+	}
 	if generationID != g.generationID {
 		return kafkaprotocol.ErrorCodeIllegalGeneration
 	}
@@ -575,18 +707,36 @@ func (g *group) Heartbeat(memberID string, generationID int) int {
 	}
 }
 
-func (g *group) Leave(leaveInfos []MemberLeaveInfo) int16 {
+// Leave removes the members from the group and returns an error code for each member
+func (g *group) Leave(leaveInfos []MemberLeaveInfo) []int16 {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 	changed := false
 	removedLeader := false
-	for _, leaveInfo := range leaveInfos {
-		removed := g.removeMember(leaveInfo.MemberID)
+	errorCodes := make([]int16, len(leaveInfos))
+	for i, leaveInfo := range leaveInfos {
+		memberID := leaveInfo.MemberID
+		if leaveInfo.GroupInstanceID != nil {
+			// Static members can leave by group instance id
+			staticMemberID, ok := g.staticMembers[*leaveInfo.GroupInstanceID]
+			if !ok {
+				errorCodes[i] = kafkaprotocol.ErrorCodeUnknownMemberID
+				continue
+			}
+			if memberID != "" && memberID != staticMemberID {
+				errorCodes[i] = kafkaprotocol.ErrorCodeFencedInstanceID
+				continue
+			}
+			memberID = staticMemberID
+		}
+		removed := g.removeMember(memberID)
 		if removed {
-			if leaveInfo.MemberID == g.leader {
+			if memberID == g.leader {
 				removedLeader = true
 			}
 			changed = true
+		} else {
+			errorCodes[i] = kafkaprotocol.ErrorCodeUnknownMemberID
 		}
 	}
 	if changed {
@@ -602,7 +752,13 @@ func (g *group) Leave(leaveInfos []MemberLeaveInfo) int16 {
 			g.state = stateEmpty
 		}
 	}
-	return kafkaprotocol.ErrorCodeNone
+	return errorCodes
+}
+
+func (g *group) getProtocol() (string, string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.protocolType, g.protocolName
 }
 
 func (g *group) getState() int {
@@ -614,6 +770,9 @@ func (g *group) getState() int {
 func (g *group) sessionTimeoutExpired(memberID string) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
+	if g.stopped {
+		return
+	}
 	_, ok := g.pendingMemberIDs[memberID]
 	if ok {
 		g.removeMember(memberID)
@@ -696,12 +855,23 @@ func fillAllErrorCodesForOffsetFetch(resp *kafkaprotocol.OffsetFetchResponse, er
 func (g *group) offsetCommit(transactional bool, req *kafkaprotocol.OffsetCommitRequest, resp *kafkaprotocol.OffsetCommitResponse) int {
 	g.lock.Lock()
 	defer g.lock.Unlock()
-	if int(req.GenerationIdOrMemberEpoch) != g.generationID {
-		return kafkaprotocol.ErrorCodeIllegalGeneration
-	}
-	_, ok := g.members[common.SafeDerefStringPtr(req.MemberId)]
-	if !ok {
-		return kafkaprotocol.ErrorCodeUnknownMemberID
+	if g.consumer != nil {
+		errCode := g.consumer.checkOffsetCommit(common.SafeDerefStringPtr(req.MemberId), req.GenerationIdOrMemberEpoch)
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			return errCode
+		}
+	} else {
+		errCode := g.checkStaticMember(common.SafeDerefStringPtr(req.MemberId), common.SafeDerefStringPtr(req.GroupInstanceId))
+		if errCode != kafkaprotocol.ErrorCodeNone {
+			return errCode
+		}
+		if int(req.GenerationIdOrMemberEpoch) != g.generationID {
+			return kafkaprotocol.ErrorCodeIllegalGeneration
+		}
+		_, ok := g.members[common.SafeDerefStringPtr(req.MemberId)]
+		if !ok {
+			return kafkaprotocol.ErrorCodeUnknownMemberID
+		}
 	}
 	// Convert to KV pairs
 	var kvs []common.KV
@@ -740,6 +910,12 @@ func (g *group) offsetCommit(transactional bool, req *kafkaprotocol.OffsetCommit
 				partitionData.PartitionIndex, offset)
 		}
 	}
+	return g.writeOffsetKVs(kvs)
+}
+
+// writeOffsetKVs writes the KVs via the table pusher for the group's partition hash. Offsets are written with the
+// group epoch so that writes from a stale coordinator are rejected.
+func (g *group) writeOffsetKVs(kvs []common.KV) int {
 	commitReq := pusher.DirectWriteRequest{
 		WriterKey:   g.offsetWriterKey,
 		WriterEpoch: g.groupEpoch,
@@ -749,7 +925,7 @@ func (g *group) offsetCommit(transactional bool, req *kafkaprotocol.OffsetCommit
 	pusherAddress, ok := pusher.ChooseTablePusherForHash(g.partHash, g.gc.membership.Members)
 	if !ok {
 		// No available pushers
-		log.Warnf("cannot commit offsets as no members in cluster")
+		log.Warnf("cannot write offsets as no members in cluster")
 		return kafkaprotocol.ErrorCodeCoordinatorNotAvailable
 	}
 	conn, err := g.gc.getConnection(pusherAddress)
@@ -760,10 +936,10 @@ func (g *group) offsetCommit(transactional bool, req *kafkaprotocol.OffsetCommit
 	_, err = conn.SendRPC(transport.HandlerIDTablePusherDirectWrite, buff)
 	if err != nil {
 		if common.IsUnavailableError(err) {
-			log.Warnf("failed to handle offset commit: %v", err)
+			log.Warnf("failed to write offsets: %v", err)
 			return kafkaprotocol.ErrorCodeCoordinatorNotAvailable
 		} else {
-			log.Errorf("failed to handle offset commit: %v", err)
+			log.Errorf("failed to write offsets: %v", err)
 			return kafkaprotocol.ErrorCodeUnknownServerError
 		}
 	}
@@ -773,15 +949,20 @@ func (g *group) offsetCommit(transactional bool, req *kafkaprotocol.OffsetCommit
 const (
 	offsetKeyPublic        = byte(1)
 	offsetKeyTransactional = byte(2)
+	// groupMetadataKeyType is the key type of the persisted group metadata, which is stored with the offsets
+	groupMetadataKeyType = byte(3)
 )
 
 func createOffsetKey(partHash []byte, offsetKeyType byte, topicID int, partitionID int) []byte {
+	return encoding.EncodeVersion(createOffsetKeyNoVersion(partHash, offsetKeyType, topicID, partitionID), 0)
+}
+
+func createOffsetKeyNoVersion(partHash []byte, offsetKeyType byte, topicID int, partitionID int) []byte {
 	key := make([]byte, 0, 41)
 	key = append(key, partHash...)
 	key = append(key, offsetKeyType)
 	key = binary.BigEndian.AppendUint64(key, uint64(topicID))
 	key = binary.BigEndian.AppendUint64(key, uint64(partitionID))
-	key = encoding.EncodeVersion(key, 0)
 	return key
 }
 
@@ -792,51 +973,27 @@ func createRequestBuffer() []byte {
 }
 
 func (g *group) loadOffset(topicID int, partitionID int) (int64, error) {
-	key := createOffsetKey(g.partHash, offsetKeyPublic, topicID, partitionID)
+	key := createOffsetKeyNoVersion(g.partHash, offsetKeyPublic, topicID, partitionID)
 	cl, err := g.gc.clientCache.GetClient()
 	if err != nil {
 		return 0, err
 	}
-	keyEnd := common.IncBigEndianBytes(key)
-	queryRes, err := cl.QueryTablesInRange(key, keyEnd)
+	value, err := queryutils.GetLatestValueWithKey(key, cl, g.gc.tableGetter, g.gc.filterGetter)
 	if err != nil {
 		return 0, err
 	}
-	if len(queryRes) == 0 {
+	if value == nil {
 		// no stored offset
 		return -1, nil
 	}
-	// We take the first one as that's most recent
-	nonOverlapping := queryRes[0]
-	res := nonOverlapping[0]
-	tableID := res.ID
-	sstTable, err := g.gc.tableGetter(tableID)
-	if err != nil {
-		return 0, err
-	}
-	iter, err := sstTable.NewIterator(key, keyEnd)
-	if err != nil {
-		return 0, err
-	}
-	ok, kv, err := iter.Next()
-	if err != nil {
-		return 0, err
-	}
-	if !ok {
-		return -1, nil
-	}
-	if len(kv.Value) == 0 {
-		// tombstone
-		return -1, nil
-	}
-	offset := int64(binary.BigEndian.Uint64(kv.Value))
-	return offset, nil
+	return int64(binary.BigEndian.Uint64(value)), nil
 }
 
-func (g *group) offsetFetch(req *kafkaprotocol.OffsetFetchRequest, resp *kafkaprotocol.OffsetFetchResponse) {
+func (g *group) offsetFetch(topics []kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic,
+	resp *kafkaprotocol.OffsetFetchResponse) {
 	g.lock.Lock()
 	defer g.lock.Unlock()
-	for i, topicData := range req.Topics {
+	for i, topicData := range topics {
 		topicName := common.SafeDerefStringPtr(topicData.Name)
 		topicInfo, foundTopic, err := g.gc.topicProvider.GetTopicInfo(topicName)
 		var errCode int16
@@ -870,6 +1027,116 @@ func (g *group) offsetFetch(req *kafkaprotocol.OffsetFetchRequest, resp *kafkapr
 	}
 }
 
+// checkOffsetFetchMember checks the member id and epoch provided when fetching offsets for a consumer group
+func (g *group) checkOffsetFetchMember(memberID string, memberEpoch int32) int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer == nil {
+		return kafkaprotocol.ErrorCodeNone
+	}
+	return g.consumer.checkMemberEpoch(memberID, memberEpoch)
+}
+
+// committedOffsetTopics returns the topics and partitions for which the group has committed offsets. Offsets for topics
+// which no longer exist are ignored.
+func (g *group) committedOffsetTopics() ([]kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	keys, err := g.loadOffsetKeys()
+	if err != nil {
+		return nil, err
+	}
+	cl, err := g.gc.clientCache.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	topicInfos, err := cl.GetAllTopicInfos()
+	if err != nil {
+		return nil, err
+	}
+	topicNames := make(map[int]string, len(topicInfos))
+	for _, info := range topicInfos {
+		topicNames[info.ID] = info.Name
+	}
+	topics := []kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic{}
+	topicIndexes := map[int]int{}
+	for _, key := range keys {
+		// key is [partition_hash, offset_key_type, topic_id, partition_id, version]
+		offset := len(g.partHash)
+		if key[offset] != offsetKeyPublic {
+			continue
+		}
+		topicID := int(binary.BigEndian.Uint64(key[offset+1:]))
+		partitionID := int32(binary.BigEndian.Uint64(key[offset+9:]))
+		topicName, ok := topicNames[topicID]
+		if !ok {
+			continue
+		}
+		index, ok := topicIndexes[topicID]
+		if !ok {
+			index = len(topics)
+			topicIndexes[topicID] = index
+			topics = append(topics, kafkaprotocol.OffsetFetchRequestOffsetFetchRequestTopic{Name: &topicName})
+		}
+		topics[index].PartitionIndexes = append(topics[index].PartitionIndexes, partitionID)
+	}
+	return topics, nil
+}
+
+// reclaimDeletedTopicOffsets writes tombstones for the group's committed offsets, public and transactional, of topics
+// which have been deleted. The keys are loaded before the topics, and an offset can only be committed for a topic which
+// exists, so an offset for a topic which is no longer returned must be for a deleted topic. Topic ids are never reused.
+func (g *group) reclaimDeletedTopicOffsets() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.state == stateDead || g.stopped {
+		return nil
+	}
+	keys, err := g.loadOffsetKeys()
+	if err != nil {
+		return err
+	}
+	offset := len(g.partHash)
+	var offsetKeys [][]byte
+	for _, key := range keys {
+		if key[offset] == offsetKeyPublic || key[offset] == offsetKeyTransactional {
+			offsetKeys = append(offsetKeys, key)
+		}
+	}
+	if len(offsetKeys) == 0 {
+		return nil
+	}
+	cl, err := g.gc.clientCache.GetClient()
+	if err != nil {
+		return err
+	}
+	topicInfos, err := cl.GetAllTopicInfos()
+	if err != nil {
+		return err
+	}
+	liveTopics := make(map[int]struct{}, len(topicInfos))
+	for _, info := range topicInfos {
+		liveTopics[info.ID] = struct{}{}
+	}
+	var kvs []common.KV
+	for _, key := range offsetKeys {
+		// key is [partition_hash, offset_key_type, topic_id, partition_id, version]
+		topicID := int(binary.BigEndian.Uint64(key[offset+1:]))
+		if _, ok := liveTopics[topicID]; ok {
+			continue
+		}
+		kvs = append(kvs, common.KV{Key: encoding.EncodeVersion(key[:offset+17], 0)})
+	}
+	if len(kvs) == 0 {
+		return nil
+	}
+	if errCode := g.writeOffsetKVs(kvs); errCode != kafkaprotocol.ErrorCodeNone {
+		return errors.Errorf("failed to write tombstones for committed offsets, error code %d", errCode)
+	}
+	log.Debugf("group %s removed %d committed offsets of deleted topics", g.id, len(kvs))
+	return nil
+}
+
 func (g *group) stop() {
 	g.lock.Lock()
 	defer g.lock.Unlock()
@@ -878,6 +1145,9 @@ func (g *group) stop() {
 	for memberID := range g.members {
 		g.gc.cancelTimer(memberID)
 	}
+	if g.consumer != nil {
+		g.consumer.stop()
+	}
 }
 
 func generateMemberID(clientID string) string {
@@ -0,0 +1,366 @@
+package group
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaprotocol"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/queryutils"
+	"github.com/spirit-labs/tektite/topicmeta"
+	"time"
+)
+
+/*
+Group metadata is persisted so that when coordination of a group moves to another agent, e.g. because the agent which
+was coordinating it has left the cluster, the new coordinator can carry on where the old one left off. Members of a
+stable group don't notice the move - their heartbeats succeed with the same generation or member epoch, and no rebalance
+is needed.
+
+The metadata is written with a direct write via the table pusher, in the same way as committed offsets, with key
+[partition_hash, groupMetadataKeyType, version]. It is written with the coordinator epoch, so writes from a coordinator
+which no longer coordinates the group are rejected. The value starts with a format version, so that fields can be added
+later and metadata written by earlier versions can still be loaded.
+
+A classic group is written when a rebalance completes, when a static member rejoins with a new member id, and when the
+group becomes empty. A group which is part way through a rebalance when the coordinator moves is loaded as it was when
+the previous rebalance completed, and members which have since joined must join again.
+
+A consumer group is written after any heartbeat, leave or timeout which changes the group epoch, the target assignment,
+or the epoch, assignment or subscription of a member. Heartbeats from members of a stable group change nothing, so
+don't cause a write.
+*/
+
+const (
+	groupMetadataVersion      uint16 = 1
+	groupMetadataTypeClassic         = byte(1)
+	groupMetadataTypeConsumer        = byte(2)
+)
+
+func createGroupMetadataKey(partHash []byte) []byte {
+	key := make([]byte, 0, 25)
+	key = append(key, partHash...)
+	key = append(key, groupMetadataKeyType)
+	key = encoding.EncodeVersion(key, 0)
+	return key
+}
+
+// persistMetadata writes the group metadata. Failure to write is not returned to the member which caused the change -
+// the change has already been made in memory, and it will be written again on the next change.
+func (g *group) persistMetadata() bool {
+	kv := common.KV{
+		Key:   createGroupMetadataKey(g.partHash),
+		Value: g.serializeMetadata(nil),
+	}
+	if errCode := g.writeOffsetKVs([]common.KV{kv}); errCode != kafkaprotocol.ErrorCodeNone {
+		log.Warnf("failed to persist metadata for group %s error code %d", g.id, errCode)
+		return false
+	}
+	return true
+}
+
+// loadMetadata loads the group metadata and returns false if the group has no metadata
+func (g *group) loadMetadata() (bool, error) {
+	key := createGroupMetadataKey(g.partHash)
+	cl, err := g.gc.clientCache.GetClient()
+	if err != nil {
+		return false, err
+	}
+	iter, err := queryutils.CreateIteratorForKeyRange(key, common.IncBigEndianBytes(key), cl, g.gc.tableGetter)
+	if err != nil {
+		return false, err
+	}
+	defer iter.Close()
+	ok, kv, err := iter.Next()
+	if err != nil {
+		return false, err
+	}
+	if !ok || len(kv.Value) == 0 {
+		// not found, or tombstone
+		return false, nil
+	}
+	if err := g.deserializeMetadata(kv.Value); err != nil {
+		return false, err
+	}
+	log.Debugf("loaded metadata for group %s generation %d", g.id, g.generationID)
+	return true, nil
+}
+
+func (g *group) serializeMetadata(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint16(buff, groupMetadataVersion)
+	if g.consumer != nil {
+		buff = append(buff, groupMetadataTypeConsumer)
+		return g.consumer.serialize(buff)
+	}
+	buff = append(buff, groupMetadataTypeClassic)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(g.generationID))
+	buff = appendString(buff, g.protocolType)
+	buff = appendString(buff, g.protocolName)
+	buff = appendString(buff, g.leader)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.members)))
+	for memberID, m := range g.members {
+		buff = appendString(buff, memberID)
+		buff = appendString(buff, m.clientID)
+		buff = appendString(buff, m.groupInstanceID)
+		buff = binary.BigEndian.AppendUint64(buff, uint64(m.sessionTimeout))
+		buff = binary.BigEndian.AppendUint64(buff, uint64(m.reBalanceTimeout))
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.protocols)))
+		for _, protocol := range m.protocols {
+			buff = appendString(buff, protocol.Name)
+			buff = appendBytes(buff, protocol.Metadata)
+		}
+	}
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(g.assignments)))
+	for _, assignment := range g.assignments {
+		buff = appendString(buff, assignment.MemberID)
+		buff = appendBytes(buff, assignment.Assignment)
+	}
+	return buff
+}
+
+func (g *group) deserializeMetadata(buff []byte) error {
+	version := binary.BigEndian.Uint16(buff)
+	if version != groupMetadataVersion {
+		return errors.Errorf("invalid group metadata version %d", version)
+	}
+	groupType := buff[2]
+	offset := 3
+	if groupType == groupMetadataTypeConsumer {
+		g.consumer = newConsumerGroup(g)
+		g.protocolType = consumerProtocolType
+		g.consumer.deserialize(buff, offset)
+		return nil
+	}
+	g.generationID = int(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	g.protocolType, offset = readString(buff, offset)
+	g.protocolName, offset = readString(buff, offset)
+	g.leader, offset = readString(buff, offset)
+	numMembers := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	for i := 0; i < numMembers; i++ {
+		var memberID string
+		m := &member{}
+		memberID, offset = readString(buff, offset)
+		m.clientID, offset = readString(buff, offset)
+		m.groupInstanceID, offset = readString(buff, offset)
+		m.sessionTimeout = time.Duration(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		m.reBalanceTimeout = time.Duration(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		numProtocols := int(binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		m.protocols = make([]ProtocolInfo, numProtocols)
+		for j := range m.protocols {
+			m.protocols[j].Name, offset = readString(buff, offset)
+			m.protocols[j].Metadata, offset = readBytes(buff, offset)
+		}
+		g.members[memberID] = m
+		g.updateSupportedProtocols(m.protocols, true)
+		if m.groupInstanceID != "" {
+			g.staticMembers[m.groupInstanceID] = memberID
+		}
+	}
+	numAssignments := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	if numAssignments > 0 {
+		g.assignments = make([]AssignmentInfo, numAssignments)
+		for i := range g.assignments {
+			g.assignments[i].MemberID, offset = readString(buff, offset)
+			g.assignments[i].Assignment, offset = readBytes(buff, offset)
+		}
+	}
+	if len(g.members) > 0 {
+		g.state = stateActive
+	}
+	// The initial join delay only applies to a new group
+	g.initialJoinDelayExpired = true
+	return nil
+}
+
+// restoreTimers schedules the session timeouts of the members of a loaded group. Members get a full session timeout
+// to heartbeat to the new coordinator.
+func (g *group) restoreTimers() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.consumer != nil {
+		g.consumer.restoreTimers()
+		return
+	}
+	for memberID, m := range g.members {
+		id := memberID
+		g.gc.setTimer(id, m.sessionTimeout, func() {
+			g.sessionTimeoutExpired(id)
+		})
+	}
+}
+
+func (cg *consumerGroup) serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(cg.epoch))
+	buff = binary.BigEndian.AppendUint32(buff, uint32(cg.assignmentEpoch))
+	buff = appendString(buff, cg.assignorName)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(cg.members)))
+	for _, m := range cg.members {
+		buff = appendString(buff, m.id)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(m.epoch))
+		buff = binary.BigEndian.AppendUint32(buff, uint32(m.previousEpoch))
+		buff = appendString(buff, m.instanceID)
+		buff = appendString(buff, m.rackID)
+		buff = appendString(buff, m.clientID)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.subscribedTopicNames)))
+		for _, topicName := range m.subscribedTopicNames {
+			buff = appendString(buff, topicName)
+		}
+		buff = appendString(buff, m.serverAssignor)
+		buff = binary.BigEndian.AppendUint64(buff, uint64(m.rebalanceTimeout))
+		buff = m.assigned.serialize(buff)
+		buff = m.pendingRevocation.serialize(buff)
+		buff = encoding.AppendBoolToBuffer(buff, m.left)
+	}
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(cg.targetAssignment)))
+	for memberID, target := range cg.targetAssignment {
+		buff = appendString(buff, memberID)
+		buff = target.serialize(buff)
+	}
+	// The subscribed topics are persisted so that loading the group isn't seen as a change to the topics, which
+	// would bump the group epoch
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(cg.subscribedTopics)))
+	for topicName, info := range cg.subscribedTopics {
+		buff = appendString(buff, topicName)
+		buff = binary.BigEndian.AppendUint64(buff, uint64(info.ID))
+		buff = binary.BigEndian.AppendUint64(buff, uint64(info.PartitionCount))
+	}
+	return buff
+}
+
+func (cg *consumerGroup) deserialize(buff []byte, offset int) int {
+	cg.epoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	cg.assignmentEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	cg.assignorName, offset = readString(buff, offset)
+	numMembers := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	for i := 0; i < numMembers; i++ {
+		m := &consumerMember{}
+		m.id, offset = readString(buff, offset)
+		m.epoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		m.previousEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		m.instanceID, offset = readString(buff, offset)
+		m.rackID, offset = readString(buff, offset)
+		m.clientID, offset = readString(buff, offset)
+		numTopics := int(binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		m.subscribedTopicNames = make([]string, numTopics)
+		for j := range m.subscribedTopicNames {
+			m.subscribedTopicNames[j], offset = readString(buff, offset)
+		}
+		m.serverAssignor, offset = readString(buff, offset)
+		m.rebalanceTimeout = time.Duration(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		var assigned, pendingRevocation topicPartitions
+		assigned, offset = deserializeTopicPartitions(buff, offset)
+		pendingRevocation, offset = deserializeTopicPartitions(buff, offset)
+		cg.setAssigned(m, assigned)
+		if len(pendingRevocation) > 0 {
+			cg.setPendingRevocation(m, pendingRevocation)
+		}
+		m.left, offset = encoding.ReadBoolFromBuffer(buff, offset)
+		cg.members[m.id] = m
+		if m.instanceID != "" {
+			cg.staticMembers[m.instanceID] = m.id
+		}
+	}
+	numTargets := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	for i := 0; i < numTargets; i++ {
+		var memberID string
+		memberID, offset = readString(buff, offset)
+		cg.targetAssignment[memberID], offset = deserializeTopicPartitions(buff, offset)
+	}
+	numTopics := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	for i := 0; i < numTopics; i++ {
+		var info topicmeta.TopicInfo
+		info.Name, offset = readString(buff, offset)
+		info.ID = int(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		info.PartitionCount = int(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		cg.subscribedTopics[info.Name] = info
+		cg.topicNames[info.ID] = info.Name
+	}
+	cg.dirty = false
+	return offset
+}
+
+func (cg *consumerGroup) restoreTimers() {
+	for _, m := range cg.members {
+		cg.g.gc.setTimer(m.id, cg.g.gc.cfg.ConsumerGroupSessionTimeout, cg.sessionTimeoutAction(m.id))
+		if len(m.pendingRevocation) > 0 {
+			cg.scheduleRevocationTimeout(m)
+		}
+	}
+}
+
+// persistIfDirty writes the group metadata if it has changed since it was last written
+func (cg *consumerGroup) persistIfDirty() {
+	if cg.dirty && cg.g.persistMetadata() {
+		cg.dirty = false
+	}
+}
+
+func (t topicPartitions) serialize(buff []byte) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(t)))
+	for topicID, partitionIDs := range t {
+		buff = binary.BigEndian.AppendUint64(buff, uint64(topicID))
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(partitionIDs)))
+		for _, partitionID := range partitionIDs {
+			buff = binary.BigEndian.AppendUint32(buff, uint32(partitionID))
+		}
+	}
+	return buff
+}
+
+func deserializeTopicPartitions(buff []byte, offset int) (topicPartitions, int) {
+	numTopics := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	t := make(topicPartitions, numTopics)
+	for i := 0; i < numTopics; i++ {
+		topicID := int(binary.BigEndian.Uint64(buff[offset:]))
+		offset += 8
+		partitionIDs := make([]int32, binary.BigEndian.Uint32(buff[offset:]))
+		offset += 4
+		for j := range partitionIDs {
+			partitionIDs[j] = int32(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+		}
+		t[topicID] = partitionIDs
+	}
+	return t, offset
+}
+
+func appendString(buff []byte, s string) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(s)))
+	return append(buff, s...)
+}
+
+func readString(buff []byte, offset int) (string, int) {
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	return string(buff[offset : offset+ln]), offset + ln
+}
+
+func appendBytes(buff []byte, b []byte) []byte {
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(b)))
+	return append(buff, b...)
+}
+
+func readBytes(buff []byte, offset int) ([]byte, int) {
+	ln := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	return common.ByteSliceCopy(buff[offset : offset+ln]), offset + ln
+}
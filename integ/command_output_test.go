@@ -7,12 +7,14 @@ import (
 )
 
 func TestAgentCommandMissingRequiredArgs(t *testing.T) {
-	testAgentMissingRequiredArgs(t, "", "missing flags: --obj-store-username=STRING, --obj-store-password=STRING, --obj-store-url=STRING, --cluster-name=STRING, --location=STRING")
-	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user", "missing flags: --obj-store-password=STRING, --obj-store-url=STRING, --cluster-name=STRING, --location=STRING")
-	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --obj-store-password=some-pwd", "missing flags: --obj-store-url=STRING, --cluster-name=STRING, --location=STRING")
-	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --obj-store-password=some-pwd --obj-store-url=some-url", "missing flags: --cluster-name=STRING, --location=STRING")
+	testAgentMissingRequiredArgs(t, "", "missing flags: --cluster-name=STRING, --location=STRING")
+	testAgentMissingRequiredArgs(t, "--cluster-name=some-cluster --location=some-az", "missing flags for minio object store: --obj-store-username, --obj-store-password, --obj-store-url")
+	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --cluster-name=some-cluster --location=some-az", "missing flags for minio object store: --obj-store-password, --obj-store-url")
+	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --obj-store-password=some-pwd --cluster-name=some-cluster --location=some-az", "missing flags for minio object store: --obj-store-url")
 	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --obj-store-password=some-pwd --obj-store-url=some-url --cluster-name=some-cluster", "missing flags: --location=STRING")
 	testAgentMissingRequiredArgs(t, "--obj-store-username=some-user --obj-store-password=some-pwd --obj-store-url=some-url --location=some-az", "missing flags: --cluster-name=STRING")
+	testAgentMissingRequiredArgs(t, "--obj-store-type=local --cluster-name=some-cluster --location=some-az", "missing flags for local object store: --obj-store-dir")
+	testAgentMissingRequiredArgs(t, "--obj-store-type=s3 --cluster-name=some-cluster --location=some-az", "invalid value for obj-store-type must be one of minio or local")
 }
 
 func testAgentMissingRequiredArgs(t *testing.T, args string, expectedMsg string) {
@@ -25,13 +27,31 @@ func testAgentMissingRequiredArgs(t *testing.T, args string, expectedMsg string)
 
 func TestAgentCommandHelp(t *testing.T) {
 	expected :=
-		`Usage: tekagent --obj-store-username=STRING --obj-store-password=STRING --obj-store-url=STRING --cluster-name=STRING --location=STRING
+		`Usage: tekagent --cluster-name=STRING --location=STRING
 
 Flags:
   -h, --help                                         Show context-sensitive help.
-      --obj-store-username=STRING                    username for the object store
-      --obj-store-password=STRING                    password for the object store
-      --obj-store-url=STRING                         url of the object store
+      --obj-store-type="minio"                       type of the object store - one of minio or local. A local object store keeps objects in a directory on the
+                                                     local file system
+      --obj-store-username=STRING                    username for the object store. Required for minio with static credentials
+      --obj-store-password=STRING                    password for the object store. Required for minio with static credentials
+      --obj-store-url=STRING                         url of the object store. Required for minio
+      --obj-store-dir=STRING                         directory to keep objects in for a local object store. Required for local
+      --obj-store-secure                             whether to connect to the object store with TLS
+      --obj-store-tls-trusted-certs-path=STRING      path to a PEM encoded file containing certificates of trusted object store servers and/or certificate
+                                                     authorities. If not set, the system certificates are used
+      --obj-store-tls-no-verify                      set to true to disable object store certificate verification. WARNING use only for testing
+      --obj-store-region=STRING                      region of the object store. If not set, it is looked up from the object store
+      --obj-store-bucket-lookup="auto"               how object store buckets are addressed - one of auto, dns (virtual-hosted style) or path
+      --obj-store-credentials="static"               where object store credentials come from - one of static or chain. static uses obj-store-username and
+                                                     obj-store-password, chain uses AWS or minio environment variables, credentials files or the IAM role of the
+                                                     instance
+      --obj-store-server-side-encryption="none"      server side encryption of objects - one of none, sse-s3 or sse-kms
+      --obj-store-sse-kms-key-id=STRING              KMS key id for sse-kms server side encryption
+      --obj-store-max-retries=3                      max number of times an object store operation is retried after a transient error
+      --obj-store-retry-initial-backoff-ms=100       max delay before the first retry of an object store operation in ms. Each retry is delayed by a random time
+                                                     up to a max which doubles with each retry
+      --obj-store-retry-max-backoff-ms=5000          max delay before retrying an object store operation in ms
       --cluster-name=STRING                          name of the agent cluster
       --location=STRING                              location (e.g. availability zone) that the agent runs in
       --kafka-listen-address=STRING                  address to listen on for kafka connections
@@ -40,6 +60,17 @@ Flags:
       --membership-eviction-interval-ms=20000        interval after which member will be evicted from the cluster
       --consumer-group-initial-join-delay-ms=3000    initial delay to wait for more consumers to join a new consumer group before performing the first
                                                      rebalance, in ms
+      --kafka-authentication-type=STRING             authentication required for kafka connections - one of SCRAM-SHA-256 or SCRAM-SHA-512. If not set,
+                                                     no authentication is required
+      --metrics-listen-address=STRING                address to serve prometheus metrics on at /metrics. If not set, metrics are not served
+      --kafka-authorization-enabled                  whether kafka requests are authorized using ACLs. If not set, all requests are allowed
+      --kafka-super-users=KAFKA-SUPER-USERS,...      principals, in the form User:<name>, which are allowed to perform any operation when kafka authorization is
+                                                     enabled
+      --table-format=1                               format of the SSTables written by the cluster - 1 or 2. Format 2 is required for table compression and
+                                                     bloom filters
+      --table-compression="none"                     compression for SSTable data blocks - one of none, snappy, lz4 or zstd
+      --table-bloom-filter-bits-per-key=0            bits per key of the bloom filter in each SSTable, used to skip tables in point lookups. If 0, no bloom
+                                                     filters are built
       --topic-name=STRING                            name of the topic
       --log-format="console"                         format to write log lines in - one of: console, json
       --log-level="info"                             lowest log level that will be emitted - one of: debug, info, warn, error`
@@ -80,3 +111,17 @@ tektite agent has stopped`
 	require.True(t, strings.Contains(allOut[0], "and internal listener"))
 	require.Equal(t, expected, outputToString(allOut[1:]))
 }
+
+func TestAgentCommandOutputSuccessfulStartStopLocalObjStore(t *testing.T) {
+	mgr := NewManager()
+	agent, err := mgr.StartAgent("--obj-store-type=local --obj-store-dir="+t.TempDir()+" --cluster-name=test-cluster --location=az1", true)
+	require.NoError(t, err)
+
+	err = agent.Stop()
+	require.NoError(t, err)
+
+	allOut := agent.Output()
+	require.Equal(t, 3, len(allOut))
+	require.True(t, strings.HasPrefix(allOut[0], "started tektite agent with kafka listener"))
+	require.Equal(t, "tektite agent has stopped", allOut[2])
+}
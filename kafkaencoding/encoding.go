@@ -1,14 +1,18 @@
 package kafkaencoding
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	kafkacompress "github.com/segmentio/kafka-go/compress"
 	"github.com/spirit-labs/tektite/asl/errwrap"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/types"
 	"hash"
+	"hash/crc32"
+	"math"
 )
 
 func SetBatchHeader(batchBytes []byte, firstOffset int64, lastOffset int64, firstTimestamp types.Timestamp,
@@ -129,6 +133,78 @@ func LastOffsetDelta(records []byte) int32 {
 	return int32(binary.BigEndian.Uint32(records[23:]))
 }
 
+func BaseTimestamp(records []byte) int64 {
+	return int64(binary.BigEndian.Uint64(records[27:]))
+}
+
+func MaxTimestamp(records []byte) int64 {
+	return int64(binary.BigEndian.Uint64(records[35:]))
+}
+
+// FindOffsetForTimestamp returns the offset and timestamp of the first record in the batch with a timestamp >= the
+// provided timestamp, or false if there is no such record.
+func FindOffsetForTimestamp(records []byte, timestamp int64) (int64, int64, bool) {
+	maxTimestamp := MaxTimestamp(records)
+	if maxTimestamp < timestamp {
+		return 0, 0, false
+	}
+	baseOffset := BaseOffset(records)
+	attributes := Attributes(records)
+	if attributes&attributeTimestampTypeLogAppend != 0 {
+		// All records in the batch have the max timestamp
+		return baseOffset, maxTimestamp, true
+	}
+	baseTimestamp := BaseTimestamp(records)
+	if attributes&attributeCompressionMask != 0 {
+		// We can't inspect the records without decompressing them, so we return the start of the batch
+		return baseOffset, baseTimestamp, true
+	}
+	off := 61
+	numRecords := NumRecords(records)
+	for i := 0; i < numRecords; i++ {
+		recordLen, n := binary.Varint(records[off:])
+		off += n
+		recordStart := off
+		off++ // attributes
+		timestampDelta, n := binary.Varint(records[off:])
+		off += n
+		offsetDelta, _ := binary.Varint(records[off:])
+		if baseTimestamp+timestampDelta >= timestamp {
+			return baseOffset + offsetDelta, baseTimestamp + timestampDelta, true
+		}
+		off = recordStart + int(recordLen)
+	}
+	return 0, 0, false
+}
+
+// FindOffsetForMaxTimestamp returns the offset of the first record in the batch with the batch's max timestamp, along
+// with the max timestamp. If the records can't be inspected the offset of the last record in the batch is returned.
+func FindOffsetForMaxTimestamp(records []byte) (int64, int64) {
+	maxTimestamp := MaxTimestamp(records)
+	baseOffset := BaseOffset(records)
+	attributes := Attributes(records)
+	if attributes&(attributeTimestampTypeLogAppend|attributeCompressionMask) != 0 {
+		return baseOffset + int64(LastOffsetDelta(records)), maxTimestamp
+	}
+	baseTimestamp := BaseTimestamp(records)
+	off := 61
+	numRecords := NumRecords(records)
+	for i := 0; i < numRecords; i++ {
+		recordLen, n := binary.Varint(records[off:])
+		off += n
+		recordStart := off
+		off++ // attributes
+		timestampDelta, n := binary.Varint(records[off:])
+		off += n
+		offsetDelta, _ := binary.Varint(records[off:])
+		if baseTimestamp+timestampDelta == maxTimestamp {
+			return baseOffset + offsetDelta, maxTimestamp
+		}
+		off = recordStart + int(recordLen)
+	}
+	return baseOffset + int64(LastOffsetDelta(records)), maxTimestamp
+}
+
 type KafkaError struct {
 	ErrorCode int
 	ErrorMsg  string
@@ -143,9 +219,13 @@ func ErrorCodeForError(err error, unavailableErrorCode int16) int16 {
 		return int16(kafkaprotocol.ErrorCodeNone)
 	}
 	var kerr KafkaError
+	var pkerr *KafkaError
 	if errwrap.As(err, &kerr) {
 		log.Warn(err)
 		return int16(kerr.ErrorCode)
+	} else if errwrap.As(err, &pkerr) {
+		log.Warn(err)
+		return int16(pkerr.ErrorCode)
 	} else if common.IsUnavailableError(err) {
 		log.Warn(err)
 		return unavailableErrorCode
@@ -153,4 +233,205 @@ func ErrorCodeForError(err error, unavailableErrorCode int16) int16 {
 		log.Error(err)
 		return int16(kafkaprotocol.ErrorCodeUnknownServerError)
 	}
-}
\ No newline at end of file
+}
+func ProducerEpoch(records []byte) int16 {
+	return int16(binary.BigEndian.Uint16(records[51:]))
+}
+
+func Attributes(records []byte) int16 {
+	return int16(binary.BigEndian.Uint16(records[21:]))
+}
+
+func IsTransactional(records []byte) bool {
+	return Attributes(records)&attributeTransactional != 0
+}
+
+func IsControlBatch(records []byte) bool {
+	return Attributes(records)&attributeControlBatch != 0
+}
+
+const (
+	attributeCompressionMask        = int16(0x07)
+	attributeTimestampTypeLogAppend = int16(1 << 3)
+	attributeTransactional          = int16(1 << 4)
+	attributeControlBatch           = int16(1 << 5)
+
+	ControlRecordTypeAbort  = int16(0)
+	ControlRecordTypeCommit = int16(1)
+)
+
+// CreateControlBatch creates a batch containing a single transaction marker control record for the producer
+func CreateControlBatch(producerID int64, producerEpoch int16, commit bool, timestamp types.Timestamp) []byte {
+	/*
+		Control record key:
+			version: int16 (current version is 0)
+			type: int16 (0 indicates an abort marker, 1 indicates a commit)
+		Control record value:
+			version: int16 (current version is 0)
+			coordinatorEpoch: int32
+	*/
+	recordType := ControlRecordTypeAbort
+	if commit {
+		recordType = ControlRecordTypeCommit
+	}
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint16(key[2:], uint16(recordType))
+	val := make([]byte, 6)
+	batchBytes := make([]byte, 61)
+	// Headers are encoded as a count followed by the headers, and control records have no headers
+	batchBytes, _ = AppendToBatch(batchBytes, 0, key, []byte{0}, val, timestamp, timestamp, math.MaxInt, true)
+	binary.BigEndian.PutUint64(batchBytes, 0)
+	binary.BigEndian.PutUint32(batchBytes[8:], uint32(len(batchBytes)-12))
+	batchBytes[16] = 2 // Magic
+	binary.BigEndian.PutUint16(batchBytes[21:], uint16(attributeTransactional|attributeControlBatch))
+	binary.BigEndian.PutUint32(batchBytes[23:], 0)
+	binary.BigEndian.PutUint64(batchBytes[27:], uint64(timestamp.Val))
+	binary.BigEndian.PutUint64(batchBytes[35:], uint64(timestamp.Val))
+	binary.BigEndian.PutUint64(batchBytes[43:], uint64(producerID))
+	binary.BigEndian.PutUint16(batchBytes[51:], uint16(producerEpoch))
+	minusOne := int32(-1)
+	binary.BigEndian.PutUint32(batchBytes[53:], uint32(minusOne))
+	binary.BigEndian.PutUint32(batchBytes[57:], 1)
+	// The checksum covers everything from attributes onwards, so must be calculated last
+	binary.BigEndian.PutUint32(batchBytes[17:], crc32.Checksum(batchBytes[21:], crc32.MakeTable(crc32.Castagnoli)))
+	return batchBytes
+}
+
+// IsAbortMarker returns true if the control batch contains a transaction abort marker
+func IsAbortMarker(records []byte) bool {
+	off := 61
+	_, n := binary.Varint(records[off:]) // length
+	off += n
+	off++                               // attributes
+	_, n = binary.Varint(records[off:]) // timestampDelta
+	off += n
+	_, n = binary.Varint(records[off:]) // offsetDelta
+	off += n
+	keyLen, n := binary.Varint(records[off:])
+	off += n
+	if keyLen < 4 {
+		return false
+	}
+	return int16(binary.BigEndian.Uint16(records[off+2:])) == ControlRecordTypeAbort
+}
+
+func IsCompressed(records []byte) bool {
+	return Attributes(records)&attributeCompressionMask != 0
+}
+
+// DecompressBatch returns a copy of the compressed record batch with its records decompressed, so they can be read with
+// ReadBatchRecords. The compression codec of the batch is also returned, so the batch can be compressed again with
+// CompressBatch.
+func DecompressBatch(records []byte) ([]byte, int16, error) {
+	attributes := Attributes(records)
+	codec := attributes & attributeCompressionMask
+	kafkaCodec := kafkacompress.Compression(codec).Codec()
+	if kafkaCodec == nil {
+		return nil, 0, errwrap.Errorf("unsupported compression codec %d", codec)
+	}
+	reader := kafkaCodec.NewReader(bytes.NewReader(records[61:]))
+	defer reader.Close()
+	buff := bytes.NewBuffer(make([]byte, 0, 2*len(records)))
+	buff.Write(records[:61])
+	if _, err := buff.ReadFrom(reader); err != nil {
+		return nil, 0, errwrap.WithStack(err)
+	}
+	batch := buff.Bytes()
+	binary.BigEndian.PutUint16(batch[21:], uint16(attributes&^attributeCompressionMask))
+	setBatchLengthAndChecksum(batch)
+	return batch, codec, nil
+}
+
+// CompressBatch returns a copy of the uncompressed record batch with its records compressed with the compression codec
+func CompressBatch(records []byte, codec int16) ([]byte, error) {
+	kafkaCodec := kafkacompress.Compression(codec).Codec()
+	if kafkaCodec == nil {
+		return nil, errwrap.Errorf("unsupported compression codec %d", codec)
+	}
+	buff := bytes.NewBuffer(make([]byte, 0, len(records)))
+	buff.Write(records[:61])
+	writer := kafkaCodec.NewWriter(buff)
+	if _, err := writer.Write(records[61:]); err != nil {
+		writer.Close()
+		return nil, errwrap.WithStack(err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errwrap.WithStack(err)
+	}
+	batch := buff.Bytes()
+	binary.BigEndian.PutUint16(batch[21:], uint16(Attributes(batch)|codec))
+	setBatchLengthAndChecksum(batch)
+	return batch, nil
+}
+
+// BatchRecord is a record from an uncompressed record batch. Bytes contains the whole encoded record, including its
+// length, so the record can be copied to another batch unchanged. Value is nil if the record is a tombstone.
+type BatchRecord struct {
+	Bytes       []byte
+	OffsetDelta int64
+	Key         []byte
+	Value       []byte
+}
+
+// ReadBatchRecords returns the records in an uncompressed record batch
+func ReadBatchRecords(records []byte) []BatchRecord {
+	numRecords := NumRecords(records)
+	batchRecords := make([]BatchRecord, 0, numRecords)
+	off := 61
+	for i := 0; i < numRecords; i++ {
+		recordStart := off
+		recordLen, n := binary.Varint(records[off:])
+		off += n
+		recordEnd := off + int(recordLen)
+		off++                               // attributes
+		_, n = binary.Varint(records[off:]) // timestampDelta
+		off += n
+		offsetDelta, n := binary.Varint(records[off:])
+		off += n
+		keyLen, n := binary.Varint(records[off:])
+		off += n
+		var key []byte
+		if keyLen >= 0 {
+			key = records[off : off+int(keyLen)]
+			off += int(keyLen)
+		}
+		valueLen, n := binary.Varint(records[off:])
+		off += n
+		var value []byte
+		if valueLen >= 0 {
+			value = records[off : off+int(valueLen)]
+		}
+		batchRecords = append(batchRecords, BatchRecord{
+			Bytes:       records[recordStart:recordEnd],
+			OffsetDelta: offsetDelta,
+			Key:         key,
+			Value:       value,
+		})
+		off = recordEnd
+	}
+	return batchRecords
+}
+
+// RewriteBatch creates a new batch containing only the provided records, which must have been read from the batch. The
+// batch header is preserved, including the base offset, last offset delta and timestamps, so the offsets of the
+// retained records do not change, and the number of records, length and checksum are updated.
+func RewriteBatch(records []byte, retained []BatchRecord) []byte {
+	size := 61
+	for _, record := range retained {
+		size += len(record.Bytes)
+	}
+	batch := make([]byte, 61, size)
+	copy(batch, records[:61])
+	for _, record := range retained {
+		batch = append(batch, record.Bytes...)
+	}
+	binary.BigEndian.PutUint32(batch[57:], uint32(len(retained)))
+	setBatchLengthAndChecksum(batch)
+	return batch
+}
+
+func setBatchLengthAndChecksum(batch []byte) {
+	binary.BigEndian.PutUint32(batch[8:], uint32(len(batch)-12))
+	// The checksum covers everything from attributes onwards, so must be calculated last
+	binary.BigEndian.PutUint32(batch[17:], crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)))
+}
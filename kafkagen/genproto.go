@@ -53,6 +53,40 @@ var included = []string{
 	"TxnOffsetCommitResponse",
 	"EndTxnRequest",
 	"EndTxnResponse",
+	"CreateTopicsRequest",
+	"CreateTopicsResponse",
+	"DeleteTopicsRequest",
+	"DeleteTopicsResponse",
+	"CreatePartitionsRequest",
+	"CreatePartitionsResponse",
+	"DescribeConfigsRequest",
+	"DescribeConfigsResponse",
+	"IncrementalAlterConfigsRequest",
+	"IncrementalAlterConfigsResponse",
+	"ListGroupsRequest",
+	"ListGroupsResponse",
+	"DescribeGroupsRequest",
+	"DescribeGroupsResponse",
+	"DeleteGroupsRequest",
+	"DeleteGroupsResponse",
+	"OffsetDeleteRequest",
+	"OffsetDeleteResponse",
+	"DescribeClientQuotasRequest",
+	"DescribeClientQuotasResponse",
+	"AlterClientQuotasRequest",
+	"AlterClientQuotasResponse",
+	"DescribeAclsRequest",
+	"DescribeAclsResponse",
+	"CreateAclsRequest",
+	"CreateAclsResponse",
+	"DeleteAclsRequest",
+	"DeleteAclsResponse",
+	"DeleteRecordsRequest",
+	"DeleteRecordsResponse",
+	"ConsumerGroupHeartbeatRequest",
+	"ConsumerGroupHeartbeatResponse",
+	"ConsumerGroupDescribeRequest",
+	"ConsumerGroupDescribeResponse",
 }
 
 func Generate(specDir string, outDir string) error {
@@ -181,9 +215,20 @@ func loadMessageSpec(fileName string) (MessageSpec, error) {
 	if err := json5.Unmarshal(bytes, &ms); err != nil {
 		return MessageSpec{}, err
 	}
+	exportFieldNames(ms.Fields)
+	exportFieldNames(ms.CommonStructs)
 	return ms, nil
 }
 
+// exportFieldNames upper-cases the first letter of field names, as a few specs (e.g. CreateTopicsRequest) have fields
+// starting with a lower case letter, and these must be exported
+func exportFieldNames(fields []MessageField) {
+	for i := range fields {
+		fields[i].Name = strings.ToUpper(fields[i].Name[:1]) + fields[i].Name[1:]
+		exportFieldNames(fields[i].Fields)
+	}
+}
+
 func generateMessage(ms MessageSpec) (string, error) {
 	var flexibleRange *versionRange
 	if ms.FlexibleVersions != "" && ms.FlexibleVersions != "none" {
@@ -287,6 +332,11 @@ func generateStructs(name string, fields []MessageField, gc *genContext) error {
 		var fieldType string
 		if field.isArray() {
 			fieldType = "[]" + parseType(field.componentType())
+		} else if field.isNullableStruct() {
+			if field.NullableVersions != field.Versions {
+				return errors.Errorf("nullable struct field %s must be nullable in all versions", field.Name)
+			}
+			fieldType = "*" + parseType(field.FieldType)
 		} else {
 			fieldType = parseType(field.FieldType)
 		}
@@ -546,7 +596,19 @@ func genReadField(structName string, field MessageField, gc *genContext) error {
 			gc.write("}\n")
 		}
 	} else {
-		if fields != nil {
+		if fields != nil && field.isNullableStruct() {
+			// Non array nullable nested struct
+			gc.write("isNull := int8(buff[offset]) == -1\n")
+			gc.write("offset++\n")
+			gc.write("if !isNull {\n")
+			gc.incIndent()
+			gc.writeF("%s.%s = &%s{}\n", structName, field.Name, parseType(field.FieldType))
+			if err := genReadForFields(fmt.Sprintf("%s.%s", structName, field.Name), fields, gc); err != nil {
+				return err
+			}
+			gc.decIndent()
+			gc.write("}\n")
+		} else if fields != nil {
 			// Non array nested struct
 			gc.write("{\n")
 			gc.incIndent()
@@ -943,7 +1005,20 @@ func genWriteFields(writeTagHeader bool, structName string, fields []MessageFiel
 			}
 		} else {
 			// Not an array
-			if fields != nil {
+			if fields != nil && field.isNullableStruct() {
+				// Non array nullable nested struct
+				varName := fmt.Sprintf("%s.%s", structName, field.Name)
+				gc.writeF("if %s == nil {\n", varName)
+				gc.write("    buff = append(buff, 0xff)\n")
+				gc.write("} else {\n")
+				gc.incIndent()
+				gc.write("buff = append(buff, 1)\n")
+				if err := genWriteForFields(varName, fields, gc); err != nil {
+					return err
+				}
+				gc.decIndent()
+				gc.write("}\n")
+			} else if fields != nil {
 				// Non array nested struct
 				gc.write("{\n")
 				gc.incIndent()
@@ -1004,8 +1079,11 @@ func genWriteSimpleField(receiverName string, field MessageField, gc *genContext
 		gc.writeF("    buff = append(buff, *%s...)\n", receiverName)
 		gc.write("}\n")
 	case "uuid":
+		// a nil uuid is written as the zero uuid, as the size is always calculated as 16 bytes
 		gc.writeF("if %s != nil {\n", receiverName)
 		gc.writeF("    buff = append(buff, %s...)\n", receiverName)
+		gc.write("} else {\n")
+		gc.write("    buff = append(buff, zeroUUID[:]...)\n")
 		gc.write("}\n")
 	case "bytes":
 		if err := genWriteFlexField(gc, &field, receiverName); err != nil {
@@ -1259,7 +1337,19 @@ func genCalcSize(writingTagged bool, structName string, fields []MessageField, g
 				gc.write("}\n")
 			}
 		} else {
-			if fields != nil {
+			if fields != nil && field.isNullableStruct() {
+				// Non array nullable nested struct
+				varName := fmt.Sprintf("%s.%s", structName, field.Name)
+				gc.write("// null marker\n")
+				gc.write("size += 1\n")
+				gc.writeF("if %s != nil {\n", varName)
+				gc.incIndent()
+				if err := genCalcForFields(varName, fields, gc); err != nil {
+					return err
+				}
+				gc.decIndent()
+				gc.write("}\n")
+			} else if fields != nil {
 				// Non array nested struct
 				varName := fmt.Sprintf("%s.%s", structName, field.Name)
 				gc.write("{\n")
@@ -1600,6 +1690,12 @@ func (f *MessageField) supportsNullable() bool {
 	return f.FieldType == "string" || f.FieldType == "uuid" || f.FieldType == "bytes" || f.FieldType == "records"
 }
 
+// isNullableStruct returns true if the field is a nested struct which can be null. A nullable struct is written with a
+// preceding int8 which is -1 if the struct is null, and 1 otherwise.
+func (f *MessageField) isNullableStruct() bool {
+	return !f.isArray() && f.NullableVersions != "" && !f.supportsNullable()
+}
+
 func (f *MessageField) isArray() bool {
 	return f.FieldType[:2] == "[]"
 }
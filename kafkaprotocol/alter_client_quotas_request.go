@@ -0,0 +1,502 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "math"
+import "unsafe"
+
+type AlterClientQuotasRequestEntityData struct {
+    // The entity type.
+    EntityType *string
+    // The name of the entity, or null if the default.
+    EntityName *string
+}
+
+type AlterClientQuotasRequestOpData struct {
+    // The quota configuration key.
+    Key *string
+    // The value to set, otherwise ignored if the value is to be removed.
+    Value float64
+    // Whether the quota configuration value should be removed, otherwise set.
+    Remove bool
+}
+
+type AlterClientQuotasRequestEntryData struct {
+    // The quota entity to alter.
+    Entity []AlterClientQuotasRequestEntityData
+    // An individual quota configuration entry to alter.
+    Ops []AlterClientQuotasRequestOpData
+}
+
+type AlterClientQuotasRequest struct {
+    // The quota configuration entries to alter.
+    Entries []AlterClientQuotasRequestEntryData
+    // Whether the alteration should be validated, but not performed.
+    ValidateOnly bool
+}
+
+func (m *AlterClientQuotasRequest) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.Entries: The quota configuration entries to alter.
+        var l0 int
+        if version >= 1 {
+            // flexible and not nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l0 = int(u - 1)
+        } else {
+            // non flexible and non nullable
+            l0 = int(binary.BigEndian.Uint32(buff[offset:]))
+            offset += 4
+        }
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            entries := make([]AlterClientQuotasRequestEntryData, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // reading non tagged fields
+                {
+                    // reading entries[i0].Entity: The quota entity to alter.
+                    var l1 int
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l1 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l1 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l1 >= 0 {
+                        // length will be -1 if field is null
+                        entity := make([]AlterClientQuotasRequestEntityData, l1)
+                        for i1 := 0; i1 < l1; i1++ {
+                            // reading non tagged fields
+                            {
+                                // reading entity[i1].EntityType: The entity type.
+                                if version >= 1 {
+                                    // flexible and not nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l2 := int(u - 1)
+                                    s := string(buff[offset: offset + l2])
+                                    entity[i1].EntityType = &s
+                                    offset += l2
+                                } else {
+                                    // non flexible and non nullable
+                                    var l2 int
+                                    l2 = int(binary.BigEndian.Uint16(buff[offset:]))
+                                    offset += 2
+                                    s := string(buff[offset: offset + l2])
+                                    entity[i1].EntityType = &s
+                                    offset += l2
+                                }
+                            }
+                            {
+                                // reading entity[i1].EntityName: The name of the entity, or null if the default.
+                                if version >= 1 {
+                                    // flexible and nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l3 := int(u - 1)
+                                    if l3 > 0 {
+                                        s := string(buff[offset: offset + l3])
+                                        entity[i1].EntityName = &s
+                                        offset += l3
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                } else {
+                                    // non flexible and nullable
+                                    var l3 int
+                                    l3 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+                                    offset += 2
+                                    if l3 > 0 {
+                                        s := string(buff[offset: offset + l3])
+                                        entity[i1].EntityName = &s
+                                        offset += l3
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                }
+                            }
+                            if version >= 1 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    entries[i0].Entity = entity
+                    }
+                }
+                {
+                    // reading entries[i0].Ops: An individual quota configuration entry to alter.
+                    var l4 int
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l4 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l4 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l4 >= 0 {
+                        // length will be -1 if field is null
+                        ops := make([]AlterClientQuotasRequestOpData, l4)
+                        for i2 := 0; i2 < l4; i2++ {
+                            // reading non tagged fields
+                            {
+                                // reading ops[i2].Key: The quota configuration key.
+                                if version >= 1 {
+                                    // flexible and not nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l5 := int(u - 1)
+                                    s := string(buff[offset: offset + l5])
+                                    ops[i2].Key = &s
+                                    offset += l5
+                                } else {
+                                    // non flexible and non nullable
+                                    var l5 int
+                                    l5 = int(binary.BigEndian.Uint16(buff[offset:]))
+                                    offset += 2
+                                    s := string(buff[offset: offset + l5])
+                                    ops[i2].Key = &s
+                                    offset += l5
+                                }
+                            }
+                            {
+                                // reading ops[i2].Value: The value to set, otherwise ignored if the value is to be removed.
+                                ops[i2].Value = math.Float64frombits(binary.BigEndian.Uint64(buff[offset:]))
+                                offset += 8
+                            }
+                            {
+                                // reading ops[i2].Remove: Whether the quota configuration value should be removed, otherwise set.
+                                ops[i2].Remove = buff[offset] == 1
+                                offset++
+                            }
+                            if version >= 1 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    entries[i0].Ops = ops
+                    }
+                }
+                if version >= 1 {
+                    // reading tagged fields
+                    nt, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    for i := 0; i < int(nt); i++ {
+                        t, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        ts, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        switch t {
+                            default:
+                                offset += int(ts)
+                        }
+                    }
+                }
+            }
+        m.Entries = entries
+        }
+    }
+    {
+        // reading m.ValidateOnly: Whether the alteration should be validated, but not performed.
+        m.ValidateOnly = buff[offset] == 1
+        offset++
+    }
+    if version >= 1 {
+        // reading tagged fields
+        nt, n := binary.Uvarint(buff[offset:])
+        offset += n
+        for i := 0; i < int(nt); i++ {
+            t, n := binary.Uvarint(buff[offset:])
+            offset += n
+            ts, n := binary.Uvarint(buff[offset:])
+            offset += n
+            switch t {
+                default:
+                    offset += int(ts)
+            }
+        }
+    }
+    return offset, nil
+}
+
+func (m *AlterClientQuotasRequest) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.Entries: The quota configuration entries to alter.
+    if version >= 1 {
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(m.Entries) + 1))
+    } else {
+        // non flexible and non nullable
+        buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.Entries)))
+    }
+    for _, entries := range m.Entries {
+        // writing non tagged fields
+        // writing entries.Entity: The quota entity to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(entries.Entity) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(entries.Entity)))
+        }
+        for _, entity := range entries.Entity {
+            // writing non tagged fields
+            // writing entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityType) + 1))
+            } else {
+                // non flexible and non nullable
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityType)))
+            }
+            if entity.EntityType != nil {
+                buff = append(buff, *entity.EntityType...)
+            }
+            // writing entity.EntityName: The name of the entity, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = append(buff, 0)
+                } else {
+                    // not null
+                    buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityName) + 1))
+                }
+            } else {
+                // non flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = binary.BigEndian.AppendUint16(buff, 65535)
+                } else {
+                    // not null
+                    buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityName)))
+                }
+            }
+            if entity.EntityName != nil {
+                buff = append(buff, *entity.EntityName...)
+            }
+            if version >= 1 {
+                numTaggedFields4 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields4))
+            }
+        }
+        // writing entries.Ops: An individual quota configuration entry to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(entries.Ops) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(entries.Ops)))
+        }
+        for _, ops := range entries.Ops {
+            // writing non tagged fields
+            // writing ops.Key: The quota configuration key.
+            if version >= 1 {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*ops.Key) + 1))
+            } else {
+                // non flexible and non nullable
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*ops.Key)))
+            }
+            if ops.Key != nil {
+                buff = append(buff, *ops.Key...)
+            }
+            // writing ops.Value: The value to set, otherwise ignored if the value is to be removed.
+            buff = binary.BigEndian.AppendUint64(buff, math.Float64bits(ops.Value))
+            // writing ops.Remove: Whether the quota configuration value should be removed, otherwise set.
+            if ops.Remove {
+                buff = append(buff, 1)
+            } else {
+                buff = append(buff, 0)
+            }
+            if version >= 1 {
+                numTaggedFields9 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields9))
+            }
+        }
+        if version >= 1 {
+            numTaggedFields10 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields10))
+        }
+    }
+    // writing m.ValidateOnly: Whether the alteration should be validated, but not performed.
+    if m.ValidateOnly {
+        buff = append(buff, 1)
+    } else {
+        buff = append(buff, 0)
+    }
+    if version >= 1 {
+        numTaggedFields12 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields12))
+    }
+    return buff
+}
+
+func (m *AlterClientQuotasRequest) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.Entries: The quota configuration entries to alter.
+    if version >= 1 {
+        // flexible and not nullable
+        size += sizeofUvarint(len(m.Entries) + 1)
+    } else {
+        // non flexible and non nullable
+        size += 4
+    }
+    for _, entries := range m.Entries {
+        size += 0 * int(unsafe.Sizeof(entries)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for entries.Entity: The quota entity to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(entries.Entity) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, entity := range entries.Entity {
+            size += 0 * int(unsafe.Sizeof(entity)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                size += sizeofUvarint(len(*entity.EntityType) + 1)
+            } else {
+                // non flexible and non nullable
+                size += 2
+            }
+            if entity.EntityType != nil {
+                size += len(*entity.EntityType)
+            }
+            // size for entity.EntityName: The name of the entity, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    size += 1
+                } else {
+                    // not null
+                    size += sizeofUvarint(len(*entity.EntityName) + 1)
+                }
+            } else {
+                // non flexible and nullable
+                size += 2
+            }
+            if entity.EntityName != nil {
+                size += len(*entity.EntityName)
+            }
+            numTaggedFields3:= 0
+            numTaggedFields3 += 0
+            if version >= 1 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields3)
+            }
+        }
+        // size for entries.Ops: An individual quota configuration entry to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(entries.Ops) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, ops := range entries.Ops {
+            size += 0 * int(unsafe.Sizeof(ops)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields4:= 0
+            numTaggedFields4 += 0
+            // size for ops.Key: The quota configuration key.
+            if version >= 1 {
+                // flexible and not nullable
+                size += sizeofUvarint(len(*ops.Key) + 1)
+            } else {
+                // non flexible and non nullable
+                size += 2
+            }
+            if ops.Key != nil {
+                size += len(*ops.Key)
+            }
+            // size for ops.Value: The value to set, otherwise ignored if the value is to be removed.
+            size += 8
+            // size for ops.Remove: Whether the quota configuration value should be removed, otherwise set.
+            size += 1
+            numTaggedFields5:= 0
+            numTaggedFields5 += 0
+            if version >= 1 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields5)
+            }
+        }
+        numTaggedFields6:= 0
+        numTaggedFields6 += 0
+        if version >= 1 {
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields6)
+        }
+    }
+    // size for m.ValidateOnly: Whether the alteration should be validated, but not performed.
+    size += 1
+    numTaggedFields7:= 0
+    numTaggedFields7 += 0
+    if version >= 1 {
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields7)
+    }
+    return size, tagSizes
+}
+
+func (m *AlterClientQuotasRequest) HeaderVersions(version int16) (int16, int16) {
+    if version >= 1 {
+        return 2, 1
+    } else {
+        return 1, 0
+    }
+}
+
+func (m *AlterClientQuotasRequest) SupportedApiVersions() (int16, int16) {
+    return 0, 1
+}
@@ -0,0 +1,420 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "unsafe"
+
+type AlterClientQuotasResponseEntityData struct {
+    // The entity type.
+    EntityType *string
+    // The name of the entity, or null if the default.
+    EntityName *string
+}
+
+type AlterClientQuotasResponseEntryData struct {
+    // The error code, or `0` if the quota alteration succeeded.
+    ErrorCode int16
+    // The error message, or `null` if the quota alteration succeeded.
+    ErrorMessage *string
+    // The quota entity to alter.
+    Entity []AlterClientQuotasResponseEntityData
+}
+
+type AlterClientQuotasResponse struct {
+    // The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    ThrottleTimeMs int32
+    // The quota configuration entries to alter.
+    Entries []AlterClientQuotasResponseEntryData
+}
+
+func (m *AlterClientQuotasResponse) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+        m.ThrottleTimeMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.Entries: The quota configuration entries to alter.
+        var l0 int
+        if version >= 1 {
+            // flexible and not nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l0 = int(u - 1)
+        } else {
+            // non flexible and non nullable
+            l0 = int(binary.BigEndian.Uint32(buff[offset:]))
+            offset += 4
+        }
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            entries := make([]AlterClientQuotasResponseEntryData, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // reading non tagged fields
+                {
+                    // reading entries[i0].ErrorCode: The error code, or `0` if the quota alteration succeeded.
+                    entries[i0].ErrorCode = int16(binary.BigEndian.Uint16(buff[offset:]))
+                    offset += 2
+                }
+                {
+                    // reading entries[i0].ErrorMessage: The error message, or `null` if the quota alteration succeeded.
+                    if version >= 1 {
+                        // flexible and nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l1 := int(u - 1)
+                        if l1 > 0 {
+                            s := string(buff[offset: offset + l1])
+                            entries[i0].ErrorMessage = &s
+                            offset += l1
+                        } else {
+                            entries[i0].ErrorMessage = nil
+                        }
+                    } else {
+                        // non flexible and nullable
+                        var l1 int
+                        l1 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+                        offset += 2
+                        if l1 > 0 {
+                            s := string(buff[offset: offset + l1])
+                            entries[i0].ErrorMessage = &s
+                            offset += l1
+                        } else {
+                            entries[i0].ErrorMessage = nil
+                        }
+                    }
+                }
+                {
+                    // reading entries[i0].Entity: The quota entity to alter.
+                    var l2 int
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l2 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l2 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l2 >= 0 {
+                        // length will be -1 if field is null
+                        entity := make([]AlterClientQuotasResponseEntityData, l2)
+                        for i1 := 0; i1 < l2; i1++ {
+                            // reading non tagged fields
+                            {
+                                // reading entity[i1].EntityType: The entity type.
+                                if version >= 1 {
+                                    // flexible and not nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l3 := int(u - 1)
+                                    s := string(buff[offset: offset + l3])
+                                    entity[i1].EntityType = &s
+                                    offset += l3
+                                } else {
+                                    // non flexible and non nullable
+                                    var l3 int
+                                    l3 = int(binary.BigEndian.Uint16(buff[offset:]))
+                                    offset += 2
+                                    s := string(buff[offset: offset + l3])
+                                    entity[i1].EntityType = &s
+                                    offset += l3
+                                }
+                            }
+                            {
+                                // reading entity[i1].EntityName: The name of the entity, or null if the default.
+                                if version >= 1 {
+                                    // flexible and nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l4 := int(u - 1)
+                                    if l4 > 0 {
+                                        s := string(buff[offset: offset + l4])
+                                        entity[i1].EntityName = &s
+                                        offset += l4
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                } else {
+                                    // non flexible and nullable
+                                    var l4 int
+                                    l4 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+                                    offset += 2
+                                    if l4 > 0 {
+                                        s := string(buff[offset: offset + l4])
+                                        entity[i1].EntityName = &s
+                                        offset += l4
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                }
+                            }
+                            if version >= 1 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    entries[i0].Entity = entity
+                    }
+                }
+                if version >= 1 {
+                    // reading tagged fields
+                    nt, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    for i := 0; i < int(nt); i++ {
+                        t, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        ts, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        switch t {
+                            default:
+                                offset += int(ts)
+                        }
+                    }
+                }
+            }
+        m.Entries = entries
+        }
+    }
+    if version >= 1 {
+        // reading tagged fields
+        nt, n := binary.Uvarint(buff[offset:])
+        offset += n
+        for i := 0; i < int(nt); i++ {
+            t, n := binary.Uvarint(buff[offset:])
+            offset += n
+            ts, n := binary.Uvarint(buff[offset:])
+            offset += n
+            switch t {
+                default:
+                    offset += int(ts)
+            }
+        }
+    }
+    return offset, nil
+}
+
+func (m *AlterClientQuotasResponse) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.ThrottleTimeMs))
+    // writing m.Entries: The quota configuration entries to alter.
+    if version >= 1 {
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(m.Entries) + 1))
+    } else {
+        // non flexible and non nullable
+        buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.Entries)))
+    }
+    for _, entries := range m.Entries {
+        // writing non tagged fields
+        // writing entries.ErrorCode: The error code, or `0` if the quota alteration succeeded.
+        buff = binary.BigEndian.AppendUint16(buff, uint16(entries.ErrorCode))
+        // writing entries.ErrorMessage: The error message, or `null` if the quota alteration succeeded.
+        if version >= 1 {
+            // flexible and nullable
+            if entries.ErrorMessage == nil {
+                // null
+                buff = append(buff, 0)
+            } else {
+                // not null
+                buff = binary.AppendUvarint(buff, uint64(len(*entries.ErrorMessage) + 1))
+            }
+        } else {
+            // non flexible and nullable
+            if entries.ErrorMessage == nil {
+                // null
+                buff = binary.BigEndian.AppendUint16(buff, 65535)
+            } else {
+                // not null
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entries.ErrorMessage)))
+            }
+        }
+        if entries.ErrorMessage != nil {
+            buff = append(buff, *entries.ErrorMessage...)
+        }
+        // writing entries.Entity: The quota entity to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(entries.Entity) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(entries.Entity)))
+        }
+        for _, entity := range entries.Entity {
+            // writing non tagged fields
+            // writing entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityType) + 1))
+            } else {
+                // non flexible and non nullable
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityType)))
+            }
+            if entity.EntityType != nil {
+                buff = append(buff, *entity.EntityType...)
+            }
+            // writing entity.EntityName: The name of the entity, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = append(buff, 0)
+                } else {
+                    // not null
+                    buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityName) + 1))
+                }
+            } else {
+                // non flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = binary.BigEndian.AppendUint16(buff, 65535)
+                } else {
+                    // not null
+                    buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityName)))
+                }
+            }
+            if entity.EntityName != nil {
+                buff = append(buff, *entity.EntityName...)
+            }
+            if version >= 1 {
+                numTaggedFields7 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields7))
+            }
+        }
+        if version >= 1 {
+            numTaggedFields8 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields8))
+        }
+    }
+    if version >= 1 {
+        numTaggedFields9 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields9))
+    }
+    return buff
+}
+
+func (m *AlterClientQuotasResponse) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    size += 4
+    // size for m.Entries: The quota configuration entries to alter.
+    if version >= 1 {
+        // flexible and not nullable
+        size += sizeofUvarint(len(m.Entries) + 1)
+    } else {
+        // non flexible and non nullable
+        size += 4
+    }
+    for _, entries := range m.Entries {
+        size += 0 * int(unsafe.Sizeof(entries)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for entries.ErrorCode: The error code, or `0` if the quota alteration succeeded.
+        size += 2
+        // size for entries.ErrorMessage: The error message, or `null` if the quota alteration succeeded.
+        if version >= 1 {
+            // flexible and nullable
+            if entries.ErrorMessage == nil {
+                // null
+                size += 1
+            } else {
+                // not null
+                size += sizeofUvarint(len(*entries.ErrorMessage) + 1)
+            }
+        } else {
+            // non flexible and nullable
+            size += 2
+        }
+        if entries.ErrorMessage != nil {
+            size += len(*entries.ErrorMessage)
+        }
+        // size for entries.Entity: The quota entity to alter.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(entries.Entity) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, entity := range entries.Entity {
+            size += 0 * int(unsafe.Sizeof(entity)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                size += sizeofUvarint(len(*entity.EntityType) + 1)
+            } else {
+                // non flexible and non nullable
+                size += 2
+            }
+            if entity.EntityType != nil {
+                size += len(*entity.EntityType)
+            }
+            // size for entity.EntityName: The name of the entity, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    size += 1
+                } else {
+                    // not null
+                    size += sizeofUvarint(len(*entity.EntityName) + 1)
+                }
+            } else {
+                // non flexible and nullable
+                size += 2
+            }
+            if entity.EntityName != nil {
+                size += len(*entity.EntityName)
+            }
+            numTaggedFields3:= 0
+            numTaggedFields3 += 0
+            if version >= 1 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields3)
+            }
+        }
+        numTaggedFields4:= 0
+        numTaggedFields4 += 0
+        if version >= 1 {
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields4)
+        }
+    }
+    numTaggedFields5:= 0
+    numTaggedFields5 += 0
+    if version >= 1 {
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields5)
+    }
+    return size, tagSizes
+}
+
+
@@ -0,0 +1,118 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "unsafe"
+
+type ConsumerGroupDescribeRequest struct {
+    // The ids of the groups to describe
+    GroupIds []*string
+    // Whether to include authorized operations.
+    IncludeAuthorizedOperations bool
+}
+
+func (m *ConsumerGroupDescribeRequest) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.GroupIds: The ids of the groups to describe
+        var l0 int
+        // flexible and not nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l0 = int(u - 1)
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            groupIds := make([]*string, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // flexible and not nullable
+                u, n := binary.Uvarint(buff[offset:])
+                offset += n
+                l1 := int(u - 1)
+                s := string(buff[offset: offset + l1])
+                groupIds[i0] = &s
+                offset += l1
+            }
+            m.GroupIds = groupIds
+        }
+    }
+    {
+        // reading m.IncludeAuthorizedOperations: Whether to include authorized operations.
+        m.IncludeAuthorizedOperations = buff[offset] == 1
+        offset++
+    }
+    // reading tagged fields
+    nt, n := binary.Uvarint(buff[offset:])
+    offset += n
+    for i := 0; i < int(nt); i++ {
+        t, n := binary.Uvarint(buff[offset:])
+        offset += n
+        ts, n := binary.Uvarint(buff[offset:])
+        offset += n
+        switch t {
+            default:
+                offset += int(ts)
+        }
+    }
+    return offset, nil
+}
+
+func (m *ConsumerGroupDescribeRequest) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.GroupIds: The ids of the groups to describe
+    // flexible and not nullable
+    buff = binary.AppendUvarint(buff, uint64(len(m.GroupIds) + 1))
+    for _, groupIds := range m.GroupIds {
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(*groupIds) + 1))
+        if groupIds != nil {
+            buff = append(buff, *groupIds...)
+        }
+    }
+    // writing m.IncludeAuthorizedOperations: Whether to include authorized operations.
+    if m.IncludeAuthorizedOperations {
+        buff = append(buff, 1)
+    } else {
+        buff = append(buff, 0)
+    }
+    numTaggedFields2 := 0
+    // write number of tagged fields
+    buff = binary.AppendUvarint(buff, uint64(numTaggedFields2))
+    return buff
+}
+
+func (m *ConsumerGroupDescribeRequest) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.GroupIds: The ids of the groups to describe
+    // flexible and not nullable
+    size += sizeofUvarint(len(m.GroupIds) + 1)
+    for _, groupIds := range m.GroupIds {
+        size += 0 * int(unsafe.Sizeof(groupIds)) // hack to make sure loop variable is always used
+        // flexible and not nullable
+        size += sizeofUvarint(len(*groupIds) + 1)
+        if groupIds != nil {
+            size += len(*groupIds)
+        }
+    }
+    // size for m.IncludeAuthorizedOperations: Whether to include authorized operations.
+    size += 1
+    numTaggedFields1:= 0
+    numTaggedFields1 += 0
+    // writing size of num tagged fields field
+    size += sizeofUvarint(numTaggedFields1)
+    return size, tagSizes
+}
+
+func (m *ConsumerGroupDescribeRequest) HeaderVersions(version int16) (int16, int16) {
+    return 2, 1
+}
+
+func (m *ConsumerGroupDescribeRequest) SupportedApiVersions() (int16, int16) {
+    return 0, 0
+}
@@ -0,0 +1,902 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "github.com/spirit-labs/tektite/common"
+import "unsafe"
+
+type ConsumerGroupDescribeResponseTopicPartitions struct {
+    // The topic ID.
+    TopicId []byte
+    // The topic name.
+    TopicName *string
+    // The partitions.
+    Partitions []int32
+}
+
+type ConsumerGroupDescribeResponseAssignment struct {
+    // The assigned topic-partitions to the member.
+    TopicPartitions []ConsumerGroupDescribeResponseTopicPartitions
+}
+
+type ConsumerGroupDescribeResponseMember struct {
+    // The member ID.
+    MemberId *string
+    // The member instance ID.
+    InstanceId *string
+    // The member rack ID.
+    RackId *string
+    // The current member epoch.
+    MemberEpoch int32
+    // The client ID.
+    ClientId *string
+    // The client host.
+    ClientHost *string
+    // The subscribed topic names.
+    SubscribedTopicNames []*string
+    // the subscribed topic regex otherwise or null of not provided.
+    SubscribedTopicRegex *string
+    // The current assignment.
+    Assignment ConsumerGroupDescribeResponseAssignment
+    // The target assignment.
+    TargetAssignment ConsumerGroupDescribeResponseAssignment
+}
+
+type ConsumerGroupDescribeResponseDescribedGroup struct {
+    // The describe error, or 0 if there was no error.
+    ErrorCode int16
+    // The top-level error message, or null if there was no error.
+    ErrorMessage *string
+    // The group ID string.
+    GroupId *string
+    // The group state string, or the empty string.
+    GroupState *string
+    // The group epoch.
+    GroupEpoch int32
+    // The assignment epoch.
+    AssignmentEpoch int32
+    // The selected assignor.
+    AssignorName *string
+    // The members.
+    Members []ConsumerGroupDescribeResponseMember
+    // 32-bit bitfield to represent authorized operations for this group.
+    AuthorizedOperations int32
+}
+
+type ConsumerGroupDescribeResponse struct {
+    // The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    ThrottleTimeMs int32
+    // Each described group.
+    Groups []ConsumerGroupDescribeResponseDescribedGroup
+}
+
+func (m *ConsumerGroupDescribeResponse) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+        m.ThrottleTimeMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.Groups: Each described group.
+        var l0 int
+        // flexible and not nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l0 = int(u - 1)
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            groups := make([]ConsumerGroupDescribeResponseDescribedGroup, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // reading non tagged fields
+                {
+                    // reading groups[i0].ErrorCode: The describe error, or 0 if there was no error.
+                    groups[i0].ErrorCode = int16(binary.BigEndian.Uint16(buff[offset:]))
+                    offset += 2
+                }
+                {
+                    // reading groups[i0].ErrorMessage: The top-level error message, or null if there was no error.
+                    // flexible and nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l1 := int(u - 1)
+                    if l1 > 0 {
+                        s := string(buff[offset: offset + l1])
+                        groups[i0].ErrorMessage = &s
+                        offset += l1
+                    } else {
+                        groups[i0].ErrorMessage = nil
+                    }
+                }
+                {
+                    // reading groups[i0].GroupId: The group ID string.
+                    // flexible and not nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l2 := int(u - 1)
+                    s := string(buff[offset: offset + l2])
+                    groups[i0].GroupId = &s
+                    offset += l2
+                }
+                {
+                    // reading groups[i0].GroupState: The group state string, or the empty string.
+                    // flexible and not nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l3 := int(u - 1)
+                    s := string(buff[offset: offset + l3])
+                    groups[i0].GroupState = &s
+                    offset += l3
+                }
+                {
+                    // reading groups[i0].GroupEpoch: The group epoch.
+                    groups[i0].GroupEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+                    offset += 4
+                }
+                {
+                    // reading groups[i0].AssignmentEpoch: The assignment epoch.
+                    groups[i0].AssignmentEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+                    offset += 4
+                }
+                {
+                    // reading groups[i0].AssignorName: The selected assignor.
+                    // flexible and not nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l4 := int(u - 1)
+                    s := string(buff[offset: offset + l4])
+                    groups[i0].AssignorName = &s
+                    offset += l4
+                }
+                {
+                    // reading groups[i0].Members: The members.
+                    var l5 int
+                    // flexible and not nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l5 = int(u - 1)
+                    if l5 >= 0 {
+                        // length will be -1 if field is null
+                        members := make([]ConsumerGroupDescribeResponseMember, l5)
+                        for i1 := 0; i1 < l5; i1++ {
+                            // reading non tagged fields
+                            {
+                                // reading members[i1].MemberId: The member ID.
+                                // flexible and not nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l6 := int(u - 1)
+                                s := string(buff[offset: offset + l6])
+                                members[i1].MemberId = &s
+                                offset += l6
+                            }
+                            {
+                                // reading members[i1].InstanceId: The member instance ID.
+                                // flexible and nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l7 := int(u - 1)
+                                if l7 > 0 {
+                                    s := string(buff[offset: offset + l7])
+                                    members[i1].InstanceId = &s
+                                    offset += l7
+                                } else {
+                                    members[i1].InstanceId = nil
+                                }
+                            }
+                            {
+                                // reading members[i1].RackId: The member rack ID.
+                                // flexible and nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l8 := int(u - 1)
+                                if l8 > 0 {
+                                    s := string(buff[offset: offset + l8])
+                                    members[i1].RackId = &s
+                                    offset += l8
+                                } else {
+                                    members[i1].RackId = nil
+                                }
+                            }
+                            {
+                                // reading members[i1].MemberEpoch: The current member epoch.
+                                members[i1].MemberEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+                                offset += 4
+                            }
+                            {
+                                // reading members[i1].ClientId: The client ID.
+                                // flexible and not nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l9 := int(u - 1)
+                                s := string(buff[offset: offset + l9])
+                                members[i1].ClientId = &s
+                                offset += l9
+                            }
+                            {
+                                // reading members[i1].ClientHost: The client host.
+                                // flexible and not nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l10 := int(u - 1)
+                                s := string(buff[offset: offset + l10])
+                                members[i1].ClientHost = &s
+                                offset += l10
+                            }
+                            {
+                                // reading members[i1].SubscribedTopicNames: The subscribed topic names.
+                                var l11 int
+                                // flexible and not nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l11 = int(u - 1)
+                                if l11 >= 0 {
+                                    // length will be -1 if field is null
+                                    subscribedTopicNames := make([]*string, l11)
+                                    for i2 := 0; i2 < l11; i2++ {
+                                        // flexible and not nullable
+                                        u, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        l12 := int(u - 1)
+                                        s := string(buff[offset: offset + l12])
+                                        subscribedTopicNames[i2] = &s
+                                        offset += l12
+                                    }
+                                    members[i1].SubscribedTopicNames = subscribedTopicNames
+                                }
+                            }
+                            {
+                                // reading members[i1].SubscribedTopicRegex: the subscribed topic regex otherwise or null of not provided.
+                                // flexible and nullable
+                                u, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                l13 := int(u - 1)
+                                if l13 > 0 {
+                                    s := string(buff[offset: offset + l13])
+                                    members[i1].SubscribedTopicRegex = &s
+                                    offset += l13
+                                } else {
+                                    members[i1].SubscribedTopicRegex = nil
+                                }
+                            }
+                            {
+                                // reading members[i1].Assignment: The current assignment.
+                                {
+                                    // reading non tagged fields
+                                    {
+                                        // reading members[i1].Assignment.TopicPartitions: The assigned topic-partitions to the member.
+                                        var l14 int
+                                        // flexible and not nullable
+                                        u, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        l14 = int(u - 1)
+                                        if l14 >= 0 {
+                                            // length will be -1 if field is null
+                                            topicPartitions := make([]ConsumerGroupDescribeResponseTopicPartitions, l14)
+                                            for i3 := 0; i3 < l14; i3++ {
+                                                // reading non tagged fields
+                                                {
+                                                    // reading topicPartitions[i3].TopicId: The topic ID.
+                                                    topicPartitions[i3].TopicId = common.ByteSliceCopy(buff[offset: offset + 16])
+                                                    offset += 16
+                                                }
+                                                {
+                                                    // reading topicPartitions[i3].TopicName: The topic name.
+                                                    // flexible and not nullable
+                                                    u, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    l15 := int(u - 1)
+                                                    s := string(buff[offset: offset + l15])
+                                                    topicPartitions[i3].TopicName = &s
+                                                    offset += l15
+                                                }
+                                                {
+                                                    // reading topicPartitions[i3].Partitions: The partitions.
+                                                    var l16 int
+                                                    // flexible and not nullable
+                                                    u, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    l16 = int(u - 1)
+                                                    if l16 >= 0 {
+                                                        // length will be -1 if field is null
+                                                        partitions := make([]int32, l16)
+                                                        for i4 := 0; i4 < l16; i4++ {
+                                                            partitions[i4] = int32(binary.BigEndian.Uint32(buff[offset:]))
+                                                            offset += 4
+                                                        }
+                                                        topicPartitions[i3].Partitions = partitions
+                                                    }
+                                                }
+                                                // reading tagged fields
+                                                nt, n := binary.Uvarint(buff[offset:])
+                                                offset += n
+                                                for i := 0; i < int(nt); i++ {
+                                                    t, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    ts, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    switch t {
+                                                        default:
+                                                            offset += int(ts)
+                                                    }
+                                                }
+                                            }
+                                        members[i1].Assignment.TopicPartitions = topicPartitions
+                                        }
+                                    }
+                                    // reading tagged fields
+                                    nt, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    for i := 0; i < int(nt); i++ {
+                                        t, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        ts, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        switch t {
+                                            default:
+                                                offset += int(ts)
+                                        }
+                                    }
+                                }
+                            }
+                            {
+                                // reading members[i1].TargetAssignment: The target assignment.
+                                {
+                                    // reading non tagged fields
+                                    {
+                                        // reading members[i1].TargetAssignment.TopicPartitions: The assigned topic-partitions to the member.
+                                        var l17 int
+                                        // flexible and not nullable
+                                        u, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        l17 = int(u - 1)
+                                        if l17 >= 0 {
+                                            // length will be -1 if field is null
+                                            topicPartitions := make([]ConsumerGroupDescribeResponseTopicPartitions, l17)
+                                            for i5 := 0; i5 < l17; i5++ {
+                                                // reading non tagged fields
+                                                {
+                                                    // reading topicPartitions[i5].TopicId: The topic ID.
+                                                    topicPartitions[i5].TopicId = common.ByteSliceCopy(buff[offset: offset + 16])
+                                                    offset += 16
+                                                }
+                                                {
+                                                    // reading topicPartitions[i5].TopicName: The topic name.
+                                                    // flexible and not nullable
+                                                    u, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    l18 := int(u - 1)
+                                                    s := string(buff[offset: offset + l18])
+                                                    topicPartitions[i5].TopicName = &s
+                                                    offset += l18
+                                                }
+                                                {
+                                                    // reading topicPartitions[i5].Partitions: The partitions.
+                                                    var l19 int
+                                                    // flexible and not nullable
+                                                    u, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    l19 = int(u - 1)
+                                                    if l19 >= 0 {
+                                                        // length will be -1 if field is null
+                                                        partitions := make([]int32, l19)
+                                                        for i6 := 0; i6 < l19; i6++ {
+                                                            partitions[i6] = int32(binary.BigEndian.Uint32(buff[offset:]))
+                                                            offset += 4
+                                                        }
+                                                        topicPartitions[i5].Partitions = partitions
+                                                    }
+                                                }
+                                                // reading tagged fields
+                                                nt, n := binary.Uvarint(buff[offset:])
+                                                offset += n
+                                                for i := 0; i < int(nt); i++ {
+                                                    t, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    ts, n := binary.Uvarint(buff[offset:])
+                                                    offset += n
+                                                    switch t {
+                                                        default:
+                                                            offset += int(ts)
+                                                    }
+                                                }
+                                            }
+                                        members[i1].TargetAssignment.TopicPartitions = topicPartitions
+                                        }
+                                    }
+                                    // reading tagged fields
+                                    nt, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    for i := 0; i < int(nt); i++ {
+                                        t, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        ts, n := binary.Uvarint(buff[offset:])
+                                        offset += n
+                                        switch t {
+                                            default:
+                                                offset += int(ts)
+                                        }
+                                    }
+                                }
+                            }
+                            // reading tagged fields
+                            nt, n := binary.Uvarint(buff[offset:])
+                            offset += n
+                            for i := 0; i < int(nt); i++ {
+                                t, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                ts, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                switch t {
+                                    default:
+                                        offset += int(ts)
+                                }
+                            }
+                        }
+                    groups[i0].Members = members
+                    }
+                }
+                {
+                    // reading groups[i0].AuthorizedOperations: 32-bit bitfield to represent authorized operations for this group.
+                    groups[i0].AuthorizedOperations = int32(binary.BigEndian.Uint32(buff[offset:]))
+                    offset += 4
+                }
+                // reading tagged fields
+                nt, n := binary.Uvarint(buff[offset:])
+                offset += n
+                for i := 0; i < int(nt); i++ {
+                    t, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    ts, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    switch t {
+                        default:
+                            offset += int(ts)
+                    }
+                }
+            }
+        m.Groups = groups
+        }
+    }
+    // reading tagged fields
+    nt, n := binary.Uvarint(buff[offset:])
+    offset += n
+    for i := 0; i < int(nt); i++ {
+        t, n := binary.Uvarint(buff[offset:])
+        offset += n
+        ts, n := binary.Uvarint(buff[offset:])
+        offset += n
+        switch t {
+            default:
+                offset += int(ts)
+        }
+    }
+    return offset, nil
+}
+
+func (m *ConsumerGroupDescribeResponse) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.ThrottleTimeMs))
+    // writing m.Groups: Each described group.
+    // flexible and not nullable
+    buff = binary.AppendUvarint(buff, uint64(len(m.Groups) + 1))
+    for _, groups := range m.Groups {
+        // writing non tagged fields
+        // writing groups.ErrorCode: The describe error, or 0 if there was no error.
+        buff = binary.BigEndian.AppendUint16(buff, uint16(groups.ErrorCode))
+        // writing groups.ErrorMessage: The top-level error message, or null if there was no error.
+        // flexible and nullable
+        if groups.ErrorMessage == nil {
+            // null
+            buff = append(buff, 0)
+        } else {
+            // not null
+            buff = binary.AppendUvarint(buff, uint64(len(*groups.ErrorMessage) + 1))
+        }
+        if groups.ErrorMessage != nil {
+            buff = append(buff, *groups.ErrorMessage...)
+        }
+        // writing groups.GroupId: The group ID string.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(*groups.GroupId) + 1))
+        if groups.GroupId != nil {
+            buff = append(buff, *groups.GroupId...)
+        }
+        // writing groups.GroupState: The group state string, or the empty string.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(*groups.GroupState) + 1))
+        if groups.GroupState != nil {
+            buff = append(buff, *groups.GroupState...)
+        }
+        // writing groups.GroupEpoch: The group epoch.
+        buff = binary.BigEndian.AppendUint32(buff, uint32(groups.GroupEpoch))
+        // writing groups.AssignmentEpoch: The assignment epoch.
+        buff = binary.BigEndian.AppendUint32(buff, uint32(groups.AssignmentEpoch))
+        // writing groups.AssignorName: The selected assignor.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(*groups.AssignorName) + 1))
+        if groups.AssignorName != nil {
+            buff = append(buff, *groups.AssignorName...)
+        }
+        // writing groups.Members: The members.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(groups.Members) + 1))
+        for _, members := range groups.Members {
+            // writing non tagged fields
+            // writing members.MemberId: The member ID.
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(*members.MemberId) + 1))
+            if members.MemberId != nil {
+                buff = append(buff, *members.MemberId...)
+            }
+            // writing members.InstanceId: The member instance ID.
+            // flexible and nullable
+            if members.InstanceId == nil {
+                // null
+                buff = append(buff, 0)
+            } else {
+                // not null
+                buff = binary.AppendUvarint(buff, uint64(len(*members.InstanceId) + 1))
+            }
+            if members.InstanceId != nil {
+                buff = append(buff, *members.InstanceId...)
+            }
+            // writing members.RackId: The member rack ID.
+            // flexible and nullable
+            if members.RackId == nil {
+                // null
+                buff = append(buff, 0)
+            } else {
+                // not null
+                buff = binary.AppendUvarint(buff, uint64(len(*members.RackId) + 1))
+            }
+            if members.RackId != nil {
+                buff = append(buff, *members.RackId...)
+            }
+            // writing members.MemberEpoch: The current member epoch.
+            buff = binary.BigEndian.AppendUint32(buff, uint32(members.MemberEpoch))
+            // writing members.ClientId: The client ID.
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(*members.ClientId) + 1))
+            if members.ClientId != nil {
+                buff = append(buff, *members.ClientId...)
+            }
+            // writing members.ClientHost: The client host.
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(*members.ClientHost) + 1))
+            if members.ClientHost != nil {
+                buff = append(buff, *members.ClientHost...)
+            }
+            // writing members.SubscribedTopicNames: The subscribed topic names.
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(members.SubscribedTopicNames) + 1))
+            for _, subscribedTopicNames := range members.SubscribedTopicNames {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*subscribedTopicNames) + 1))
+                if subscribedTopicNames != nil {
+                    buff = append(buff, *subscribedTopicNames...)
+                }
+            }
+            // writing members.SubscribedTopicRegex: the subscribed topic regex otherwise or null of not provided.
+            // flexible and nullable
+            if members.SubscribedTopicRegex == nil {
+                // null
+                buff = append(buff, 0)
+            } else {
+                // not null
+                buff = binary.AppendUvarint(buff, uint64(len(*members.SubscribedTopicRegex) + 1))
+            }
+            if members.SubscribedTopicRegex != nil {
+                buff = append(buff, *members.SubscribedTopicRegex...)
+            }
+            // writing members.Assignment: The current assignment.
+            {
+                // writing non tagged fields
+                // writing members.Assignment.TopicPartitions: The assigned topic-partitions to the member.
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(members.Assignment.TopicPartitions) + 1))
+                for _, topicPartitions := range members.Assignment.TopicPartitions {
+                    // writing non tagged fields
+                    // writing topicPartitions.TopicId: The topic ID.
+                    if topicPartitions.TopicId != nil {
+                        buff = append(buff, topicPartitions.TopicId...)
+                    } else {
+                        buff = append(buff, zeroUUID[:]...)
+                    }
+                    // writing topicPartitions.TopicName: The topic name.
+                    // flexible and not nullable
+                    buff = binary.AppendUvarint(buff, uint64(len(*topicPartitions.TopicName) + 1))
+                    if topicPartitions.TopicName != nil {
+                        buff = append(buff, *topicPartitions.TopicName...)
+                    }
+                    // writing topicPartitions.Partitions: The partitions.
+                    // flexible and not nullable
+                    buff = binary.AppendUvarint(buff, uint64(len(topicPartitions.Partitions) + 1))
+                    for _, partitions := range topicPartitions.Partitions {
+                        buff = binary.BigEndian.AppendUint32(buff, uint32(partitions))
+                    }
+                    numTaggedFields23 := 0
+                    // write number of tagged fields
+                    buff = binary.AppendUvarint(buff, uint64(numTaggedFields23))
+                }
+                numTaggedFields24 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields24))
+            }
+            // writing members.TargetAssignment: The target assignment.
+            {
+                // writing non tagged fields
+                // writing members.TargetAssignment.TopicPartitions: The assigned topic-partitions to the member.
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(members.TargetAssignment.TopicPartitions) + 1))
+                for _, topicPartitions := range members.TargetAssignment.TopicPartitions {
+                    // writing non tagged fields
+                    // writing topicPartitions.TopicId: The topic ID.
+                    if topicPartitions.TopicId != nil {
+                        buff = append(buff, topicPartitions.TopicId...)
+                    } else {
+                        buff = append(buff, zeroUUID[:]...)
+                    }
+                    // writing topicPartitions.TopicName: The topic name.
+                    // flexible and not nullable
+                    buff = binary.AppendUvarint(buff, uint64(len(*topicPartitions.TopicName) + 1))
+                    if topicPartitions.TopicName != nil {
+                        buff = append(buff, *topicPartitions.TopicName...)
+                    }
+                    // writing topicPartitions.Partitions: The partitions.
+                    // flexible and not nullable
+                    buff = binary.AppendUvarint(buff, uint64(len(topicPartitions.Partitions) + 1))
+                    for _, partitions := range topicPartitions.Partitions {
+                        buff = binary.BigEndian.AppendUint32(buff, uint32(partitions))
+                    }
+                    numTaggedFields30 := 0
+                    // write number of tagged fields
+                    buff = binary.AppendUvarint(buff, uint64(numTaggedFields30))
+                }
+                numTaggedFields31 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields31))
+            }
+            numTaggedFields32 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields32))
+        }
+        // writing groups.AuthorizedOperations: 32-bit bitfield to represent authorized operations for this group.
+        buff = binary.BigEndian.AppendUint32(buff, uint32(groups.AuthorizedOperations))
+        numTaggedFields34 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields34))
+    }
+    numTaggedFields35 := 0
+    // write number of tagged fields
+    buff = binary.AppendUvarint(buff, uint64(numTaggedFields35))
+    return buff
+}
+
+func (m *ConsumerGroupDescribeResponse) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    size += 4
+    // size for m.Groups: Each described group.
+    // flexible and not nullable
+    size += sizeofUvarint(len(m.Groups) + 1)
+    for _, groups := range m.Groups {
+        size += 0 * int(unsafe.Sizeof(groups)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for groups.ErrorCode: The describe error, or 0 if there was no error.
+        size += 2
+        // size for groups.ErrorMessage: The top-level error message, or null if there was no error.
+        // flexible and nullable
+        if groups.ErrorMessage == nil {
+            // null
+            size += 1
+        } else {
+            // not null
+            size += sizeofUvarint(len(*groups.ErrorMessage) + 1)
+        }
+        if groups.ErrorMessage != nil {
+            size += len(*groups.ErrorMessage)
+        }
+        // size for groups.GroupId: The group ID string.
+        // flexible and not nullable
+        size += sizeofUvarint(len(*groups.GroupId) + 1)
+        if groups.GroupId != nil {
+            size += len(*groups.GroupId)
+        }
+        // size for groups.GroupState: The group state string, or the empty string.
+        // flexible and not nullable
+        size += sizeofUvarint(len(*groups.GroupState) + 1)
+        if groups.GroupState != nil {
+            size += len(*groups.GroupState)
+        }
+        // size for groups.GroupEpoch: The group epoch.
+        size += 4
+        // size for groups.AssignmentEpoch: The assignment epoch.
+        size += 4
+        // size for groups.AssignorName: The selected assignor.
+        // flexible and not nullable
+        size += sizeofUvarint(len(*groups.AssignorName) + 1)
+        if groups.AssignorName != nil {
+            size += len(*groups.AssignorName)
+        }
+        // size for groups.Members: The members.
+        // flexible and not nullable
+        size += sizeofUvarint(len(groups.Members) + 1)
+        for _, members := range groups.Members {
+            size += 0 * int(unsafe.Sizeof(members)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for members.MemberId: The member ID.
+            // flexible and not nullable
+            size += sizeofUvarint(len(*members.MemberId) + 1)
+            if members.MemberId != nil {
+                size += len(*members.MemberId)
+            }
+            // size for members.InstanceId: The member instance ID.
+            // flexible and nullable
+            if members.InstanceId == nil {
+                // null
+                size += 1
+            } else {
+                // not null
+                size += sizeofUvarint(len(*members.InstanceId) + 1)
+            }
+            if members.InstanceId != nil {
+                size += len(*members.InstanceId)
+            }
+            // size for members.RackId: The member rack ID.
+            // flexible and nullable
+            if members.RackId == nil {
+                // null
+                size += 1
+            } else {
+                // not null
+                size += sizeofUvarint(len(*members.RackId) + 1)
+            }
+            if members.RackId != nil {
+                size += len(*members.RackId)
+            }
+            // size for members.MemberEpoch: The current member epoch.
+            size += 4
+            // size for members.ClientId: The client ID.
+            // flexible and not nullable
+            size += sizeofUvarint(len(*members.ClientId) + 1)
+            if members.ClientId != nil {
+                size += len(*members.ClientId)
+            }
+            // size for members.ClientHost: The client host.
+            // flexible and not nullable
+            size += sizeofUvarint(len(*members.ClientHost) + 1)
+            if members.ClientHost != nil {
+                size += len(*members.ClientHost)
+            }
+            // size for members.SubscribedTopicNames: The subscribed topic names.
+            // flexible and not nullable
+            size += sizeofUvarint(len(members.SubscribedTopicNames) + 1)
+            for _, subscribedTopicNames := range members.SubscribedTopicNames {
+                size += 0 * int(unsafe.Sizeof(subscribedTopicNames)) // hack to make sure loop variable is always used
+                // flexible and not nullable
+                size += sizeofUvarint(len(*subscribedTopicNames) + 1)
+                if subscribedTopicNames != nil {
+                    size += len(*subscribedTopicNames)
+                }
+            }
+            // size for members.SubscribedTopicRegex: the subscribed topic regex otherwise or null of not provided.
+            // flexible and nullable
+            if members.SubscribedTopicRegex == nil {
+                // null
+                size += 1
+            } else {
+                // not null
+                size += sizeofUvarint(len(*members.SubscribedTopicRegex) + 1)
+            }
+            if members.SubscribedTopicRegex != nil {
+                size += len(*members.SubscribedTopicRegex)
+            }
+            // size for members.Assignment: The current assignment.
+            {
+                // calculating size for non tagged fields
+                numTaggedFields3:= 0
+                numTaggedFields3 += 0
+                // size for members.Assignment.TopicPartitions: The assigned topic-partitions to the member.
+                // flexible and not nullable
+                size += sizeofUvarint(len(members.Assignment.TopicPartitions) + 1)
+                for _, topicPartitions := range members.Assignment.TopicPartitions {
+                    size += 0 * int(unsafe.Sizeof(topicPartitions)) // hack to make sure loop variable is always used
+                    // calculating size for non tagged fields
+                    numTaggedFields4:= 0
+                    numTaggedFields4 += 0
+                    // size for topicPartitions.TopicId: The topic ID.
+                    size += 16
+                    // size for topicPartitions.TopicName: The topic name.
+                    // flexible and not nullable
+                    size += sizeofUvarint(len(*topicPartitions.TopicName) + 1)
+                    if topicPartitions.TopicName != nil {
+                        size += len(*topicPartitions.TopicName)
+                    }
+                    // size for topicPartitions.Partitions: The partitions.
+                    // flexible and not nullable
+                    size += sizeofUvarint(len(topicPartitions.Partitions) + 1)
+                    for _, partitions := range topicPartitions.Partitions {
+                        size += 0 * int(unsafe.Sizeof(partitions)) // hack to make sure loop variable is always used
+                        size += 4
+                    }
+                    numTaggedFields5:= 0
+                    numTaggedFields5 += 0
+                    // writing size of num tagged fields field
+                    size += sizeofUvarint(numTaggedFields5)
+                }
+                numTaggedFields6:= 0
+                numTaggedFields6 += 0
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields6)
+            }
+            // size for members.TargetAssignment: The target assignment.
+            {
+                // calculating size for non tagged fields
+                numTaggedFields7:= 0
+                numTaggedFields7 += 0
+                // size for members.TargetAssignment.TopicPartitions: The assigned topic-partitions to the member.
+                // flexible and not nullable
+                size += sizeofUvarint(len(members.TargetAssignment.TopicPartitions) + 1)
+                for _, topicPartitions := range members.TargetAssignment.TopicPartitions {
+                    size += 0 * int(unsafe.Sizeof(topicPartitions)) // hack to make sure loop variable is always used
+                    // calculating size for non tagged fields
+                    numTaggedFields8:= 0
+                    numTaggedFields8 += 0
+                    // size for topicPartitions.TopicId: The topic ID.
+                    size += 16
+                    // size for topicPartitions.TopicName: The topic name.
+                    // flexible and not nullable
+                    size += sizeofUvarint(len(*topicPartitions.TopicName) + 1)
+                    if topicPartitions.TopicName != nil {
+                        size += len(*topicPartitions.TopicName)
+                    }
+                    // size for topicPartitions.Partitions: The partitions.
+                    // flexible and not nullable
+                    size += sizeofUvarint(len(topicPartitions.Partitions) + 1)
+                    for _, partitions := range topicPartitions.Partitions {
+                        size += 0 * int(unsafe.Sizeof(partitions)) // hack to make sure loop variable is always used
+                        size += 4
+                    }
+                    numTaggedFields9:= 0
+                    numTaggedFields9 += 0
+                    // writing size of num tagged fields field
+                    size += sizeofUvarint(numTaggedFields9)
+                }
+                numTaggedFields10:= 0
+                numTaggedFields10 += 0
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields10)
+            }
+            numTaggedFields11:= 0
+            numTaggedFields11 += 0
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields11)
+        }
+        // size for groups.AuthorizedOperations: 32-bit bitfield to represent authorized operations for this group.
+        size += 4
+        numTaggedFields12:= 0
+        numTaggedFields12 += 0
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields12)
+    }
+    numTaggedFields13:= 0
+    numTaggedFields13 += 0
+    // writing size of num tagged fields field
+    size += sizeofUvarint(numTaggedFields13)
+    return size, tagSizes
+}
+
+
@@ -0,0 +1,437 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "github.com/spirit-labs/tektite/common"
+import "unsafe"
+
+type ConsumerGroupHeartbeatRequestTopicPartitions struct {
+    // The topic ID.
+    TopicId []byte
+    // The partitions.
+    Partitions []int32
+}
+
+type ConsumerGroupHeartbeatRequest struct {
+    // The group identifier.
+    GroupId *string
+    // The member id generated by the coordinator. The member id must be kept during the entire lifetime of the member.
+    MemberId *string
+    // The current member epoch; 0 to join the group; -1 to leave the group; -2 to indicate that the static member will rejoin.
+    MemberEpoch int32
+    // null if not provided or if it didn't change since the last heartbeat; the instance Id otherwise.
+    InstanceId *string
+    // null if not provided or if it didn't change since the last heartbeat; the rack ID of consumer otherwise.
+    RackId *string
+    // -1 if it didn't change since the last heartbeat; the maximum time in milliseconds that the coordinator will wait on the member to revoke its partitions otherwise.
+    RebalanceTimeoutMs int32
+    // null if it didn't change since the last heartbeat; the subscribed topic names otherwise.
+    SubscribedTopicNames []*string
+    // null if not used or if it didn't change since the last heartbeat; the server side assignor to use otherwise.
+    ServerAssignor *string
+    // null if it didn't change since the last heartbeat; the partitions owned by the member.
+    TopicPartitions []ConsumerGroupHeartbeatRequestTopicPartitions
+}
+
+func (m *ConsumerGroupHeartbeatRequest) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.GroupId: The group identifier.
+        // flexible and not nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l0 := int(u - 1)
+        s := string(buff[offset: offset + l0])
+        m.GroupId = &s
+        offset += l0
+    }
+    {
+        // reading m.MemberId: The member id generated by the coordinator. The member id must be kept during the entire lifetime of the member.
+        // flexible and not nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l1 := int(u - 1)
+        s := string(buff[offset: offset + l1])
+        m.MemberId = &s
+        offset += l1
+    }
+    {
+        // reading m.MemberEpoch: The current member epoch; 0 to join the group; -1 to leave the group; -2 to indicate that the static member will rejoin.
+        m.MemberEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.InstanceId: null if not provided or if it didn't change since the last heartbeat; the instance Id otherwise.
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l2 := int(u - 1)
+        if l2 > 0 {
+            s := string(buff[offset: offset + l2])
+            m.InstanceId = &s
+            offset += l2
+        } else {
+            m.InstanceId = nil
+        }
+    }
+    {
+        // reading m.RackId: null if not provided or if it didn't change since the last heartbeat; the rack ID of consumer otherwise.
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l3 := int(u - 1)
+        if l3 > 0 {
+            s := string(buff[offset: offset + l3])
+            m.RackId = &s
+            offset += l3
+        } else {
+            m.RackId = nil
+        }
+    }
+    {
+        // reading m.RebalanceTimeoutMs: -1 if it didn't change since the last heartbeat; the maximum time in milliseconds that the coordinator will wait on the member to revoke its partitions otherwise.
+        m.RebalanceTimeoutMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.SubscribedTopicNames: null if it didn't change since the last heartbeat; the subscribed topic names otherwise.
+        var l4 int
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l4 = int(u - 1)
+        if l4 >= 0 {
+            // length will be -1 if field is null
+            subscribedTopicNames := make([]*string, l4)
+            for i0 := 0; i0 < l4; i0++ {
+                // flexible and nullable
+                u, n := binary.Uvarint(buff[offset:])
+                offset += n
+                l5 := int(u - 1)
+                if l5 > 0 {
+                    s := string(buff[offset: offset + l5])
+                    subscribedTopicNames[i0] = &s
+                    offset += l5
+                } else {
+                    subscribedTopicNames[i0] = nil
+                }
+            }
+            m.SubscribedTopicNames = subscribedTopicNames
+        }
+    }
+    {
+        // reading m.ServerAssignor: null if not used or if it didn't change since the last heartbeat; the server side assignor to use otherwise.
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l6 := int(u - 1)
+        if l6 > 0 {
+            s := string(buff[offset: offset + l6])
+            m.ServerAssignor = &s
+            offset += l6
+        } else {
+            m.ServerAssignor = nil
+        }
+    }
+    {
+        // reading m.TopicPartitions: null if it didn't change since the last heartbeat; the partitions owned by the member.
+        var l7 int
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l7 = int(u - 1)
+        if l7 >= 0 {
+            // length will be -1 if field is null
+            topicPartitions := make([]ConsumerGroupHeartbeatRequestTopicPartitions, l7)
+            for i1 := 0; i1 < l7; i1++ {
+                // reading non tagged fields
+                {
+                    // reading topicPartitions[i1].TopicId: The topic ID.
+                    topicPartitions[i1].TopicId = common.ByteSliceCopy(buff[offset: offset + 16])
+                    offset += 16
+                }
+                {
+                    // reading topicPartitions[i1].Partitions: The partitions.
+                    var l8 int
+                    // flexible and not nullable
+                    u, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    l8 = int(u - 1)
+                    if l8 >= 0 {
+                        // length will be -1 if field is null
+                        partitions := make([]int32, l8)
+                        for i2 := 0; i2 < l8; i2++ {
+                            partitions[i2] = int32(binary.BigEndian.Uint32(buff[offset:]))
+                            offset += 4
+                        }
+                        topicPartitions[i1].Partitions = partitions
+                    }
+                }
+                // reading tagged fields
+                nt, n := binary.Uvarint(buff[offset:])
+                offset += n
+                for i := 0; i < int(nt); i++ {
+                    t, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    ts, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    switch t {
+                        default:
+                            offset += int(ts)
+                    }
+                }
+            }
+        m.TopicPartitions = topicPartitions
+        }
+    }
+    // reading tagged fields
+    nt, n := binary.Uvarint(buff[offset:])
+    offset += n
+    for i := 0; i < int(nt); i++ {
+        t, n := binary.Uvarint(buff[offset:])
+        offset += n
+        ts, n := binary.Uvarint(buff[offset:])
+        offset += n
+        switch t {
+            default:
+                offset += int(ts)
+        }
+    }
+    return offset, nil
+}
+
+func (m *ConsumerGroupHeartbeatRequest) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.GroupId: The group identifier.
+    // flexible and not nullable
+    buff = binary.AppendUvarint(buff, uint64(len(*m.GroupId) + 1))
+    if m.GroupId != nil {
+        buff = append(buff, *m.GroupId...)
+    }
+    // writing m.MemberId: The member id generated by the coordinator. The member id must be kept during the entire lifetime of the member.
+    // flexible and not nullable
+    buff = binary.AppendUvarint(buff, uint64(len(*m.MemberId) + 1))
+    if m.MemberId != nil {
+        buff = append(buff, *m.MemberId...)
+    }
+    // writing m.MemberEpoch: The current member epoch; 0 to join the group; -1 to leave the group; -2 to indicate that the static member will rejoin.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.MemberEpoch))
+    // writing m.InstanceId: null if not provided or if it didn't change since the last heartbeat; the instance Id otherwise.
+    // flexible and nullable
+    if m.InstanceId == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(*m.InstanceId) + 1))
+    }
+    if m.InstanceId != nil {
+        buff = append(buff, *m.InstanceId...)
+    }
+    // writing m.RackId: null if not provided or if it didn't change since the last heartbeat; the rack ID of consumer otherwise.
+    // flexible and nullable
+    if m.RackId == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(*m.RackId) + 1))
+    }
+    if m.RackId != nil {
+        buff = append(buff, *m.RackId...)
+    }
+    // writing m.RebalanceTimeoutMs: -1 if it didn't change since the last heartbeat; the maximum time in milliseconds that the coordinator will wait on the member to revoke its partitions otherwise.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.RebalanceTimeoutMs))
+    // writing m.SubscribedTopicNames: null if it didn't change since the last heartbeat; the subscribed topic names otherwise.
+    // flexible and nullable
+    if m.SubscribedTopicNames == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(m.SubscribedTopicNames) + 1))
+    }
+    for _, subscribedTopicNames := range m.SubscribedTopicNames {
+        // flexible and nullable
+        if subscribedTopicNames == nil {
+            // null
+            buff = append(buff, 0)
+        } else {
+            // not null
+            buff = binary.AppendUvarint(buff, uint64(len(*subscribedTopicNames) + 1))
+        }
+        if subscribedTopicNames != nil {
+            buff = append(buff, *subscribedTopicNames...)
+        }
+    }
+    // writing m.ServerAssignor: null if not used or if it didn't change since the last heartbeat; the server side assignor to use otherwise.
+    // flexible and nullable
+    if m.ServerAssignor == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(*m.ServerAssignor) + 1))
+    }
+    if m.ServerAssignor != nil {
+        buff = append(buff, *m.ServerAssignor...)
+    }
+    // writing m.TopicPartitions: null if it didn't change since the last heartbeat; the partitions owned by the member.
+    // flexible and nullable
+    if m.TopicPartitions == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(m.TopicPartitions) + 1))
+    }
+    for _, topicPartitions := range m.TopicPartitions {
+        // writing non tagged fields
+        // writing topicPartitions.TopicId: The topic ID.
+        if topicPartitions.TopicId != nil {
+            buff = append(buff, topicPartitions.TopicId...)
+        } else {
+            buff = append(buff, zeroUUID[:]...)
+        }
+        // writing topicPartitions.Partitions: The partitions.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(topicPartitions.Partitions) + 1))
+        for _, partitions := range topicPartitions.Partitions {
+            buff = binary.BigEndian.AppendUint32(buff, uint32(partitions))
+        }
+        numTaggedFields11 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields11))
+    }
+    numTaggedFields12 := 0
+    // write number of tagged fields
+    buff = binary.AppendUvarint(buff, uint64(numTaggedFields12))
+    return buff
+}
+
+func (m *ConsumerGroupHeartbeatRequest) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.GroupId: The group identifier.
+    // flexible and not nullable
+    size += sizeofUvarint(len(*m.GroupId) + 1)
+    if m.GroupId != nil {
+        size += len(*m.GroupId)
+    }
+    // size for m.MemberId: The member id generated by the coordinator. The member id must be kept during the entire lifetime of the member.
+    // flexible and not nullable
+    size += sizeofUvarint(len(*m.MemberId) + 1)
+    if m.MemberId != nil {
+        size += len(*m.MemberId)
+    }
+    // size for m.MemberEpoch: The current member epoch; 0 to join the group; -1 to leave the group; -2 to indicate that the static member will rejoin.
+    size += 4
+    // size for m.InstanceId: null if not provided or if it didn't change since the last heartbeat; the instance Id otherwise.
+    // flexible and nullable
+    if m.InstanceId == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(*m.InstanceId) + 1)
+    }
+    if m.InstanceId != nil {
+        size += len(*m.InstanceId)
+    }
+    // size for m.RackId: null if not provided or if it didn't change since the last heartbeat; the rack ID of consumer otherwise.
+    // flexible and nullable
+    if m.RackId == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(*m.RackId) + 1)
+    }
+    if m.RackId != nil {
+        size += len(*m.RackId)
+    }
+    // size for m.RebalanceTimeoutMs: -1 if it didn't change since the last heartbeat; the maximum time in milliseconds that the coordinator will wait on the member to revoke its partitions otherwise.
+    size += 4
+    // size for m.SubscribedTopicNames: null if it didn't change since the last heartbeat; the subscribed topic names otherwise.
+    // flexible and nullable
+    if m.SubscribedTopicNames == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(m.SubscribedTopicNames) + 1)
+    }
+    for _, subscribedTopicNames := range m.SubscribedTopicNames {
+        size += 0 * int(unsafe.Sizeof(subscribedTopicNames)) // hack to make sure loop variable is always used
+        // flexible and nullable
+        if subscribedTopicNames == nil {
+            // null
+            size += 1
+        } else {
+            // not null
+            size += sizeofUvarint(len(*subscribedTopicNames) + 1)
+        }
+        if subscribedTopicNames != nil {
+            size += len(*subscribedTopicNames)
+        }
+    }
+    // size for m.ServerAssignor: null if not used or if it didn't change since the last heartbeat; the server side assignor to use otherwise.
+    // flexible and nullable
+    if m.ServerAssignor == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(*m.ServerAssignor) + 1)
+    }
+    if m.ServerAssignor != nil {
+        size += len(*m.ServerAssignor)
+    }
+    // size for m.TopicPartitions: null if it didn't change since the last heartbeat; the partitions owned by the member.
+    // flexible and nullable
+    if m.TopicPartitions == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(m.TopicPartitions) + 1)
+    }
+    for _, topicPartitions := range m.TopicPartitions {
+        size += 0 * int(unsafe.Sizeof(topicPartitions)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for topicPartitions.TopicId: The topic ID.
+        size += 16
+        // size for topicPartitions.Partitions: The partitions.
+        // flexible and not nullable
+        size += sizeofUvarint(len(topicPartitions.Partitions) + 1)
+        for _, partitions := range topicPartitions.Partitions {
+            size += 0 * int(unsafe.Sizeof(partitions)) // hack to make sure loop variable is always used
+            size += 4
+        }
+        numTaggedFields2:= 0
+        numTaggedFields2 += 0
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields2)
+    }
+    numTaggedFields3:= 0
+    numTaggedFields3 += 0
+    // writing size of num tagged fields field
+    size += sizeofUvarint(numTaggedFields3)
+    return size, tagSizes
+}
+
+func (m *ConsumerGroupHeartbeatRequest) HeaderVersions(version int16) (int16, int16) {
+    return 2, 1
+}
+
+func (m *ConsumerGroupHeartbeatRequest) SupportedApiVersions() (int16, int16) {
+    return 0, 0
+}
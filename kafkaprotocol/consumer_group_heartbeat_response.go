@@ -0,0 +1,329 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "github.com/spirit-labs/tektite/common"
+import "unsafe"
+
+type ConsumerGroupHeartbeatResponseTopicPartitions struct {
+    // The topic ID.
+    TopicId []byte
+    // The partitions.
+    Partitions []int32
+}
+
+type ConsumerGroupHeartbeatResponseAssignment struct {
+    // The partitions assigned to the member that can be used immediately.
+    TopicPartitions []ConsumerGroupHeartbeatResponseTopicPartitions
+}
+
+type ConsumerGroupHeartbeatResponse struct {
+    // The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    ThrottleTimeMs int32
+    // The top-level error code, or 0 if there was no error
+    ErrorCode int16
+    // The top-level error message, or null if there was no error.
+    ErrorMessage *string
+    // The member id generated by the coordinator. Only provided when the member joins with MemberEpoch == 0.
+    MemberId *string
+    // The member epoch.
+    MemberEpoch int32
+    // The heartbeat interval in milliseconds.
+    HeartbeatIntervalMs int32
+    // null if not provided; the assignment otherwise.
+    Assignment *ConsumerGroupHeartbeatResponseAssignment
+}
+
+func (m *ConsumerGroupHeartbeatResponse) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+        m.ThrottleTimeMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.ErrorCode: The top-level error code, or 0 if there was no error
+        m.ErrorCode = int16(binary.BigEndian.Uint16(buff[offset:]))
+        offset += 2
+    }
+    {
+        // reading m.ErrorMessage: The top-level error message, or null if there was no error.
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l0 := int(u - 1)
+        if l0 > 0 {
+            s := string(buff[offset: offset + l0])
+            m.ErrorMessage = &s
+            offset += l0
+        } else {
+            m.ErrorMessage = nil
+        }
+    }
+    {
+        // reading m.MemberId: The member id generated by the coordinator. Only provided when the member joins with MemberEpoch == 0.
+        // flexible and nullable
+        u, n := binary.Uvarint(buff[offset:])
+        offset += n
+        l1 := int(u - 1)
+        if l1 > 0 {
+            s := string(buff[offset: offset + l1])
+            m.MemberId = &s
+            offset += l1
+        } else {
+            m.MemberId = nil
+        }
+    }
+    {
+        // reading m.MemberEpoch: The member epoch.
+        m.MemberEpoch = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.HeartbeatIntervalMs: The heartbeat interval in milliseconds.
+        m.HeartbeatIntervalMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.Assignment: null if not provided; the assignment otherwise.
+        isNull := int8(buff[offset]) == -1
+        offset++
+        if !isNull {
+            m.Assignment = &ConsumerGroupHeartbeatResponseAssignment{}
+            // reading non tagged fields
+            {
+                // reading m.Assignment.TopicPartitions: The partitions assigned to the member that can be used immediately.
+                var l2 int
+                // flexible and not nullable
+                u, n := binary.Uvarint(buff[offset:])
+                offset += n
+                l2 = int(u - 1)
+                if l2 >= 0 {
+                    // length will be -1 if field is null
+                    topicPartitions := make([]ConsumerGroupHeartbeatResponseTopicPartitions, l2)
+                    for i0 := 0; i0 < l2; i0++ {
+                        // reading non tagged fields
+                        {
+                            // reading topicPartitions[i0].TopicId: The topic ID.
+                            topicPartitions[i0].TopicId = common.ByteSliceCopy(buff[offset: offset + 16])
+                            offset += 16
+                        }
+                        {
+                            // reading topicPartitions[i0].Partitions: The partitions.
+                            var l3 int
+                            // flexible and not nullable
+                            u, n := binary.Uvarint(buff[offset:])
+                            offset += n
+                            l3 = int(u - 1)
+                            if l3 >= 0 {
+                                // length will be -1 if field is null
+                                partitions := make([]int32, l3)
+                                for i1 := 0; i1 < l3; i1++ {
+                                    partitions[i1] = int32(binary.BigEndian.Uint32(buff[offset:]))
+                                    offset += 4
+                                }
+                                topicPartitions[i0].Partitions = partitions
+                            }
+                        }
+                        // reading tagged fields
+                        nt, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        for i := 0; i < int(nt); i++ {
+                            t, n := binary.Uvarint(buff[offset:])
+                            offset += n
+                            ts, n := binary.Uvarint(buff[offset:])
+                            offset += n
+                            switch t {
+                                default:
+                                    offset += int(ts)
+                            }
+                        }
+                    }
+                m.Assignment.TopicPartitions = topicPartitions
+                }
+            }
+            // reading tagged fields
+            nt, n := binary.Uvarint(buff[offset:])
+            offset += n
+            for i := 0; i < int(nt); i++ {
+                t, n := binary.Uvarint(buff[offset:])
+                offset += n
+                ts, n := binary.Uvarint(buff[offset:])
+                offset += n
+                switch t {
+                    default:
+                        offset += int(ts)
+                }
+            }
+        }
+    }
+    // reading tagged fields
+    nt, n := binary.Uvarint(buff[offset:])
+    offset += n
+    for i := 0; i < int(nt); i++ {
+        t, n := binary.Uvarint(buff[offset:])
+        offset += n
+        ts, n := binary.Uvarint(buff[offset:])
+        offset += n
+        switch t {
+            default:
+                offset += int(ts)
+        }
+    }
+    return offset, nil
+}
+
+func (m *ConsumerGroupHeartbeatResponse) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.ThrottleTimeMs))
+    // writing m.ErrorCode: The top-level error code, or 0 if there was no error
+    buff = binary.BigEndian.AppendUint16(buff, uint16(m.ErrorCode))
+    // writing m.ErrorMessage: The top-level error message, or null if there was no error.
+    // flexible and nullable
+    if m.ErrorMessage == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(*m.ErrorMessage) + 1))
+    }
+    if m.ErrorMessage != nil {
+        buff = append(buff, *m.ErrorMessage...)
+    }
+    // writing m.MemberId: The member id generated by the coordinator. Only provided when the member joins with MemberEpoch == 0.
+    // flexible and nullable
+    if m.MemberId == nil {
+        // null
+        buff = append(buff, 0)
+    } else {
+        // not null
+        buff = binary.AppendUvarint(buff, uint64(len(*m.MemberId) + 1))
+    }
+    if m.MemberId != nil {
+        buff = append(buff, *m.MemberId...)
+    }
+    // writing m.MemberEpoch: The member epoch.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.MemberEpoch))
+    // writing m.HeartbeatIntervalMs: The heartbeat interval in milliseconds.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.HeartbeatIntervalMs))
+    // writing m.Assignment: null if not provided; the assignment otherwise.
+    if m.Assignment == nil {
+        buff = append(buff, 0xff)
+    } else {
+        buff = append(buff, 1)
+        // writing non tagged fields
+        // writing m.Assignment.TopicPartitions: The partitions assigned to the member that can be used immediately.
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(m.Assignment.TopicPartitions) + 1))
+        for _, topicPartitions := range m.Assignment.TopicPartitions {
+            // writing non tagged fields
+            // writing topicPartitions.TopicId: The topic ID.
+            if topicPartitions.TopicId != nil {
+                buff = append(buff, topicPartitions.TopicId...)
+            } else {
+                buff = append(buff, zeroUUID[:]...)
+            }
+            // writing topicPartitions.Partitions: The partitions.
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(topicPartitions.Partitions) + 1))
+            for _, partitions := range topicPartitions.Partitions {
+                buff = binary.BigEndian.AppendUint32(buff, uint32(partitions))
+            }
+            numTaggedFields10 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields10))
+        }
+        numTaggedFields11 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields11))
+    }
+    numTaggedFields12 := 0
+    // write number of tagged fields
+    buff = binary.AppendUvarint(buff, uint64(numTaggedFields12))
+    return buff
+}
+
+func (m *ConsumerGroupHeartbeatResponse) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    size += 4
+    // size for m.ErrorCode: The top-level error code, or 0 if there was no error
+    size += 2
+    // size for m.ErrorMessage: The top-level error message, or null if there was no error.
+    // flexible and nullable
+    if m.ErrorMessage == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(*m.ErrorMessage) + 1)
+    }
+    if m.ErrorMessage != nil {
+        size += len(*m.ErrorMessage)
+    }
+    // size for m.MemberId: The member id generated by the coordinator. Only provided when the member joins with MemberEpoch == 0.
+    // flexible and nullable
+    if m.MemberId == nil {
+        // null
+        size += 1
+    } else {
+        // not null
+        size += sizeofUvarint(len(*m.MemberId) + 1)
+    }
+    if m.MemberId != nil {
+        size += len(*m.MemberId)
+    }
+    // size for m.MemberEpoch: The member epoch.
+    size += 4
+    // size for m.HeartbeatIntervalMs: The heartbeat interval in milliseconds.
+    size += 4
+    // size for m.Assignment: null if not provided; the assignment otherwise.
+    // null marker
+    size += 1
+    if m.Assignment != nil {
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for m.Assignment.TopicPartitions: The partitions assigned to the member that can be used immediately.
+        // flexible and not nullable
+        size += sizeofUvarint(len(m.Assignment.TopicPartitions) + 1)
+        for _, topicPartitions := range m.Assignment.TopicPartitions {
+            size += 0 * int(unsafe.Sizeof(topicPartitions)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for topicPartitions.TopicId: The topic ID.
+            size += 16
+            // size for topicPartitions.Partitions: The partitions.
+            // flexible and not nullable
+            size += sizeofUvarint(len(topicPartitions.Partitions) + 1)
+            for _, partitions := range topicPartitions.Partitions {
+                size += 0 * int(unsafe.Sizeof(partitions)) // hack to make sure loop variable is always used
+                size += 4
+            }
+            numTaggedFields3:= 0
+            numTaggedFields3 += 0
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields3)
+        }
+        numTaggedFields4:= 0
+        numTaggedFields4 += 0
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields4)
+    }
+    numTaggedFields5:= 0
+    numTaggedFields5 += 0
+    // writing size of num tagged fields field
+    size += sizeofUvarint(numTaggedFields5)
+    return size, tagSizes
+}
+
+
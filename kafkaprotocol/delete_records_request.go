@@ -0,0 +1,301 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "unsafe"
+
+type DeleteRecordsRequestDeleteRecordsPartition struct {
+    // The partition index.
+    PartitionIndex int32
+    // The deletion offset.
+    Offset int64
+}
+
+type DeleteRecordsRequestDeleteRecordsTopic struct {
+    // The topic name.
+    Name *string
+    // Each partition that we want to delete records from.
+    Partitions []DeleteRecordsRequestDeleteRecordsPartition
+}
+
+type DeleteRecordsRequest struct {
+    // Each topic that we want to delete records from.
+    Topics []DeleteRecordsRequestDeleteRecordsTopic
+    // How long to wait for the deletion to complete, in milliseconds.
+    TimeoutMs int32
+}
+
+func (m *DeleteRecordsRequest) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.Topics: Each topic that we want to delete records from.
+        var l0 int
+        if version >= 2 {
+            // flexible and not nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l0 = int(u - 1)
+        } else {
+            // non flexible and non nullable
+            l0 = int(binary.BigEndian.Uint32(buff[offset:]))
+            offset += 4
+        }
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            topics := make([]DeleteRecordsRequestDeleteRecordsTopic, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // reading non tagged fields
+                {
+                    // reading topics[i0].Name: The topic name.
+                    if version >= 2 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l1 := int(u - 1)
+                        s := string(buff[offset: offset + l1])
+                        topics[i0].Name = &s
+                        offset += l1
+                    } else {
+                        // non flexible and non nullable
+                        var l1 int
+                        l1 = int(binary.BigEndian.Uint16(buff[offset:]))
+                        offset += 2
+                        s := string(buff[offset: offset + l1])
+                        topics[i0].Name = &s
+                        offset += l1
+                    }
+                }
+                {
+                    // reading topics[i0].Partitions: Each partition that we want to delete records from.
+                    var l2 int
+                    if version >= 2 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l2 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l2 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l2 >= 0 {
+                        // length will be -1 if field is null
+                        partitions := make([]DeleteRecordsRequestDeleteRecordsPartition, l2)
+                        for i1 := 0; i1 < l2; i1++ {
+                            // reading non tagged fields
+                            {
+                                // reading partitions[i1].PartitionIndex: The partition index.
+                                partitions[i1].PartitionIndex = int32(binary.BigEndian.Uint32(buff[offset:]))
+                                offset += 4
+                            }
+                            {
+                                // reading partitions[i1].Offset: The deletion offset.
+                                partitions[i1].Offset = int64(binary.BigEndian.Uint64(buff[offset:]))
+                                offset += 8
+                            }
+                            if version >= 2 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    topics[i0].Partitions = partitions
+                    }
+                }
+                if version >= 2 {
+                    // reading tagged fields
+                    nt, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    for i := 0; i < int(nt); i++ {
+                        t, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        ts, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        switch t {
+                            default:
+                                offset += int(ts)
+                        }
+                    }
+                }
+            }
+        m.Topics = topics
+        }
+    }
+    {
+        // reading m.TimeoutMs: How long to wait for the deletion to complete, in milliseconds.
+        m.TimeoutMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    if version >= 2 {
+        // reading tagged fields
+        nt, n := binary.Uvarint(buff[offset:])
+        offset += n
+        for i := 0; i < int(nt); i++ {
+            t, n := binary.Uvarint(buff[offset:])
+            offset += n
+            ts, n := binary.Uvarint(buff[offset:])
+            offset += n
+            switch t {
+                default:
+                    offset += int(ts)
+            }
+        }
+    }
+    return offset, nil
+}
+
+func (m *DeleteRecordsRequest) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.Topics: Each topic that we want to delete records from.
+    if version >= 2 {
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(m.Topics) + 1))
+    } else {
+        // non flexible and non nullable
+        buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.Topics)))
+    }
+    for _, topics := range m.Topics {
+        // writing non tagged fields
+        // writing topics.Name: The topic name.
+        if version >= 2 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(*topics.Name) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint16(buff, uint16(len(*topics.Name)))
+        }
+        if topics.Name != nil {
+            buff = append(buff, *topics.Name...)
+        }
+        // writing topics.Partitions: Each partition that we want to delete records from.
+        if version >= 2 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(topics.Partitions) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(topics.Partitions)))
+        }
+        for _, partitions := range topics.Partitions {
+            // writing non tagged fields
+            // writing partitions.PartitionIndex: The partition index.
+            buff = binary.BigEndian.AppendUint32(buff, uint32(partitions.PartitionIndex))
+            // writing partitions.Offset: The deletion offset.
+            buff = binary.BigEndian.AppendUint64(buff, uint64(partitions.Offset))
+            if version >= 2 {
+                numTaggedFields5 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields5))
+            }
+        }
+        if version >= 2 {
+            numTaggedFields6 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields6))
+        }
+    }
+    // writing m.TimeoutMs: How long to wait for the deletion to complete, in milliseconds.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.TimeoutMs))
+    if version >= 2 {
+        numTaggedFields8 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields8))
+    }
+    return buff
+}
+
+func (m *DeleteRecordsRequest) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.Topics: Each topic that we want to delete records from.
+    if version >= 2 {
+        // flexible and not nullable
+        size += sizeofUvarint(len(m.Topics) + 1)
+    } else {
+        // non flexible and non nullable
+        size += 4
+    }
+    for _, topics := range m.Topics {
+        size += 0 * int(unsafe.Sizeof(topics)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for topics.Name: The topic name.
+        if version >= 2 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(*topics.Name) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 2
+        }
+        if topics.Name != nil {
+            size += len(*topics.Name)
+        }
+        // size for topics.Partitions: Each partition that we want to delete records from.
+        if version >= 2 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(topics.Partitions) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, partitions := range topics.Partitions {
+            size += 0 * int(unsafe.Sizeof(partitions)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for partitions.PartitionIndex: The partition index.
+            size += 4
+            // size for partitions.Offset: The deletion offset.
+            size += 8
+            numTaggedFields3:= 0
+            numTaggedFields3 += 0
+            if version >= 2 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields3)
+            }
+        }
+        numTaggedFields4:= 0
+        numTaggedFields4 += 0
+        if version >= 2 {
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields4)
+        }
+    }
+    // size for m.TimeoutMs: How long to wait for the deletion to complete, in milliseconds.
+    size += 4
+    numTaggedFields5:= 0
+    numTaggedFields5 += 0
+    if version >= 2 {
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields5)
+    }
+    return size, tagSizes
+}
+
+func (m *DeleteRecordsRequest) HeaderVersions(version int16) (int16, int16) {
+    if version >= 2 {
+        return 2, 1
+    } else {
+        return 1, 0
+    }
+}
+
+func (m *DeleteRecordsRequest) SupportedApiVersions() (int16, int16) {
+    return 0, 2
+}
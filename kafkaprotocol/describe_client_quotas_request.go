@@ -0,0 +1,286 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "unsafe"
+
+type DescribeClientQuotasRequestComponentData struct {
+    // The entity type that the filter component applies to.
+    EntityType *string
+    // How to match the entity {0 = exact name, 1 = default name, 2 = any specified name}.
+    MatchType int8
+    // The string to match against, or null if unused for the match type.
+    Match *string
+}
+
+type DescribeClientQuotasRequest struct {
+    // Filter components to apply to quota entities.
+    Components []DescribeClientQuotasRequestComponentData
+    // Whether the match is strict, i.e. should exclude entities with unspecified entity types.
+    Strict bool
+}
+
+func (m *DescribeClientQuotasRequest) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.Components: Filter components to apply to quota entities.
+        var l0 int
+        if version >= 1 {
+            // flexible and not nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l0 = int(u - 1)
+        } else {
+            // non flexible and non nullable
+            l0 = int(binary.BigEndian.Uint32(buff[offset:]))
+            offset += 4
+        }
+        if l0 >= 0 {
+            // length will be -1 if field is null
+            components := make([]DescribeClientQuotasRequestComponentData, l0)
+            for i0 := 0; i0 < l0; i0++ {
+                // reading non tagged fields
+                {
+                    // reading components[i0].EntityType: The entity type that the filter component applies to.
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l1 := int(u - 1)
+                        s := string(buff[offset: offset + l1])
+                        components[i0].EntityType = &s
+                        offset += l1
+                    } else {
+                        // non flexible and non nullable
+                        var l1 int
+                        l1 = int(binary.BigEndian.Uint16(buff[offset:]))
+                        offset += 2
+                        s := string(buff[offset: offset + l1])
+                        components[i0].EntityType = &s
+                        offset += l1
+                    }
+                }
+                {
+                    // reading components[i0].MatchType: How to match the entity {0 = exact name, 1 = default name, 2 = any specified name}.
+                    components[i0].MatchType = int8(buff[offset])
+                    offset++
+                }
+                {
+                    // reading components[i0].Match: The string to match against, or null if unused for the match type.
+                    if version >= 1 {
+                        // flexible and nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l2 := int(u - 1)
+                        if l2 > 0 {
+                            s := string(buff[offset: offset + l2])
+                            components[i0].Match = &s
+                            offset += l2
+                        } else {
+                            components[i0].Match = nil
+                        }
+                    } else {
+                        // non flexible and nullable
+                        var l2 int
+                        l2 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+                        offset += 2
+                        if l2 > 0 {
+                            s := string(buff[offset: offset + l2])
+                            components[i0].Match = &s
+                            offset += l2
+                        } else {
+                            components[i0].Match = nil
+                        }
+                    }
+                }
+                if version >= 1 {
+                    // reading tagged fields
+                    nt, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    for i := 0; i < int(nt); i++ {
+                        t, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        ts, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        switch t {
+                            default:
+                                offset += int(ts)
+                        }
+                    }
+                }
+            }
+        m.Components = components
+        }
+    }
+    {
+        // reading m.Strict: Whether the match is strict, i.e. should exclude entities with unspecified entity types.
+        m.Strict = buff[offset] == 1
+        offset++
+    }
+    if version >= 1 {
+        // reading tagged fields
+        nt, n := binary.Uvarint(buff[offset:])
+        offset += n
+        for i := 0; i < int(nt); i++ {
+            t, n := binary.Uvarint(buff[offset:])
+            offset += n
+            ts, n := binary.Uvarint(buff[offset:])
+            offset += n
+            switch t {
+                default:
+                    offset += int(ts)
+            }
+        }
+    }
+    return offset, nil
+}
+
+func (m *DescribeClientQuotasRequest) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.Components: Filter components to apply to quota entities.
+    if version >= 1 {
+        // flexible and not nullable
+        buff = binary.AppendUvarint(buff, uint64(len(m.Components) + 1))
+    } else {
+        // non flexible and non nullable
+        buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.Components)))
+    }
+    for _, components := range m.Components {
+        // writing non tagged fields
+        // writing components.EntityType: The entity type that the filter component applies to.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(*components.EntityType) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint16(buff, uint16(len(*components.EntityType)))
+        }
+        if components.EntityType != nil {
+            buff = append(buff, *components.EntityType...)
+        }
+        // writing components.MatchType: How to match the entity {0 = exact name, 1 = default name, 2 = any specified name}.
+        buff = append(buff, byte(components.MatchType))
+        // writing components.Match: The string to match against, or null if unused for the match type.
+        if version >= 1 {
+            // flexible and nullable
+            if components.Match == nil {
+                // null
+                buff = append(buff, 0)
+            } else {
+                // not null
+                buff = binary.AppendUvarint(buff, uint64(len(*components.Match) + 1))
+            }
+        } else {
+            // non flexible and nullable
+            if components.Match == nil {
+                // null
+                buff = binary.BigEndian.AppendUint16(buff, 65535)
+            } else {
+                // not null
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*components.Match)))
+            }
+        }
+        if components.Match != nil {
+            buff = append(buff, *components.Match...)
+        }
+        if version >= 1 {
+            numTaggedFields4 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields4))
+        }
+    }
+    // writing m.Strict: Whether the match is strict, i.e. should exclude entities with unspecified entity types.
+    if m.Strict {
+        buff = append(buff, 1)
+    } else {
+        buff = append(buff, 0)
+    }
+    if version >= 1 {
+        numTaggedFields6 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields6))
+    }
+    return buff
+}
+
+func (m *DescribeClientQuotasRequest) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.Components: Filter components to apply to quota entities.
+    if version >= 1 {
+        // flexible and not nullable
+        size += sizeofUvarint(len(m.Components) + 1)
+    } else {
+        // non flexible and non nullable
+        size += 4
+    }
+    for _, components := range m.Components {
+        size += 0 * int(unsafe.Sizeof(components)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for components.EntityType: The entity type that the filter component applies to.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(*components.EntityType) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 2
+        }
+        if components.EntityType != nil {
+            size += len(*components.EntityType)
+        }
+        // size for components.MatchType: How to match the entity {0 = exact name, 1 = default name, 2 = any specified name}.
+        size += 1
+        // size for components.Match: The string to match against, or null if unused for the match type.
+        if version >= 1 {
+            // flexible and nullable
+            if components.Match == nil {
+                // null
+                size += 1
+            } else {
+                // not null
+                size += sizeofUvarint(len(*components.Match) + 1)
+            }
+        } else {
+            // non flexible and nullable
+            size += 2
+        }
+        if components.Match != nil {
+            size += len(*components.Match)
+        }
+        numTaggedFields2:= 0
+        numTaggedFields2 += 0
+        if version >= 1 {
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields2)
+        }
+    }
+    // size for m.Strict: Whether the match is strict, i.e. should exclude entities with unspecified entity types.
+    size += 1
+    numTaggedFields3:= 0
+    numTaggedFields3 += 0
+    if version >= 1 {
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields3)
+    }
+    return size, tagSizes
+}
+
+func (m *DescribeClientQuotasRequest) HeaderVersions(version int16) (int16, int16) {
+    if version >= 1 {
+        return 2, 1
+    } else {
+        return 1, 0
+    }
+}
+
+func (m *DescribeClientQuotasRequest) SupportedApiVersions() (int16, int16) {
+    return 0, 1
+}
@@ -0,0 +1,572 @@
+// Package kafkaprotocol - This is a generated file, please do not edit
+
+package kafkaprotocol
+
+import "encoding/binary"
+import "math"
+import "unsafe"
+
+type DescribeClientQuotasResponseEntityData struct {
+    // The entity type.
+    EntityType *string
+    // The entity name, or null if the default.
+    EntityName *string
+}
+
+type DescribeClientQuotasResponseValueData struct {
+    // The quota configuration key.
+    Key *string
+    // The quota configuration value.
+    Value float64
+}
+
+type DescribeClientQuotasResponseEntryData struct {
+    // The quota entity description.
+    Entity []DescribeClientQuotasResponseEntityData
+    // The quota values for the entity.
+    Values []DescribeClientQuotasResponseValueData
+}
+
+type DescribeClientQuotasResponse struct {
+    // The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    ThrottleTimeMs int32
+    // The error code, or `0` if the quota description succeeded.
+    ErrorCode int16
+    // The error message, or `null` if the quota description succeeded.
+    ErrorMessage *string
+    // A result entry.
+    Entries []DescribeClientQuotasResponseEntryData
+}
+
+func (m *DescribeClientQuotasResponse) Read(version int16, buff []byte) (int, error) {
+    offset := 0
+    // reading non tagged fields
+    {
+        // reading m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+        m.ThrottleTimeMs = int32(binary.BigEndian.Uint32(buff[offset:]))
+        offset += 4
+    }
+    {
+        // reading m.ErrorCode: The error code, or `0` if the quota description succeeded.
+        m.ErrorCode = int16(binary.BigEndian.Uint16(buff[offset:]))
+        offset += 2
+    }
+    {
+        // reading m.ErrorMessage: The error message, or `null` if the quota description succeeded.
+        if version >= 1 {
+            // flexible and nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l0 := int(u - 1)
+            if l0 > 0 {
+                s := string(buff[offset: offset + l0])
+                m.ErrorMessage = &s
+                offset += l0
+            } else {
+                m.ErrorMessage = nil
+            }
+        } else {
+            // non flexible and nullable
+            var l0 int
+            l0 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+            offset += 2
+            if l0 > 0 {
+                s := string(buff[offset: offset + l0])
+                m.ErrorMessage = &s
+                offset += l0
+            } else {
+                m.ErrorMessage = nil
+            }
+        }
+    }
+    {
+        // reading m.Entries: A result entry.
+        var l1 int
+        if version >= 1 {
+            // flexible and nullable
+            u, n := binary.Uvarint(buff[offset:])
+            offset += n
+            l1 = int(u - 1)
+        } else {
+            // non flexible and nullable
+            l1 = int(int32(binary.BigEndian.Uint32(buff[offset:])))
+            offset += 4
+        }
+        if l1 >= 0 {
+            // length will be -1 if field is null
+            entries := make([]DescribeClientQuotasResponseEntryData, l1)
+            for i0 := 0; i0 < l1; i0++ {
+                // reading non tagged fields
+                {
+                    // reading entries[i0].Entity: The quota entity description.
+                    var l2 int
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l2 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l2 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l2 >= 0 {
+                        // length will be -1 if field is null
+                        entity := make([]DescribeClientQuotasResponseEntityData, l2)
+                        for i1 := 0; i1 < l2; i1++ {
+                            // reading non tagged fields
+                            {
+                                // reading entity[i1].EntityType: The entity type.
+                                if version >= 1 {
+                                    // flexible and not nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l3 := int(u - 1)
+                                    s := string(buff[offset: offset + l3])
+                                    entity[i1].EntityType = &s
+                                    offset += l3
+                                } else {
+                                    // non flexible and non nullable
+                                    var l3 int
+                                    l3 = int(binary.BigEndian.Uint16(buff[offset:]))
+                                    offset += 2
+                                    s := string(buff[offset: offset + l3])
+                                    entity[i1].EntityType = &s
+                                    offset += l3
+                                }
+                            }
+                            {
+                                // reading entity[i1].EntityName: The entity name, or null if the default.
+                                if version >= 1 {
+                                    // flexible and nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l4 := int(u - 1)
+                                    if l4 > 0 {
+                                        s := string(buff[offset: offset + l4])
+                                        entity[i1].EntityName = &s
+                                        offset += l4
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                } else {
+                                    // non flexible and nullable
+                                    var l4 int
+                                    l4 = int(int16(binary.BigEndian.Uint16(buff[offset:])))
+                                    offset += 2
+                                    if l4 > 0 {
+                                        s := string(buff[offset: offset + l4])
+                                        entity[i1].EntityName = &s
+                                        offset += l4
+                                    } else {
+                                        entity[i1].EntityName = nil
+                                    }
+                                }
+                            }
+                            if version >= 1 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    entries[i0].Entity = entity
+                    }
+                }
+                {
+                    // reading entries[i0].Values: The quota values for the entity.
+                    var l5 int
+                    if version >= 1 {
+                        // flexible and not nullable
+                        u, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        l5 = int(u - 1)
+                    } else {
+                        // non flexible and non nullable
+                        l5 = int(binary.BigEndian.Uint32(buff[offset:]))
+                        offset += 4
+                    }
+                    if l5 >= 0 {
+                        // length will be -1 if field is null
+                        values := make([]DescribeClientQuotasResponseValueData, l5)
+                        for i2 := 0; i2 < l5; i2++ {
+                            // reading non tagged fields
+                            {
+                                // reading values[i2].Key: The quota configuration key.
+                                if version >= 1 {
+                                    // flexible and not nullable
+                                    u, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    l6 := int(u - 1)
+                                    s := string(buff[offset: offset + l6])
+                                    values[i2].Key = &s
+                                    offset += l6
+                                } else {
+                                    // non flexible and non nullable
+                                    var l6 int
+                                    l6 = int(binary.BigEndian.Uint16(buff[offset:]))
+                                    offset += 2
+                                    s := string(buff[offset: offset + l6])
+                                    values[i2].Key = &s
+                                    offset += l6
+                                }
+                            }
+                            {
+                                // reading values[i2].Value: The quota configuration value.
+                                values[i2].Value = math.Float64frombits(binary.BigEndian.Uint64(buff[offset:]))
+                                offset += 8
+                            }
+                            if version >= 1 {
+                                // reading tagged fields
+                                nt, n := binary.Uvarint(buff[offset:])
+                                offset += n
+                                for i := 0; i < int(nt); i++ {
+                                    t, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    ts, n := binary.Uvarint(buff[offset:])
+                                    offset += n
+                                    switch t {
+                                        default:
+                                            offset += int(ts)
+                                    }
+                                }
+                            }
+                        }
+                    entries[i0].Values = values
+                    }
+                }
+                if version >= 1 {
+                    // reading tagged fields
+                    nt, n := binary.Uvarint(buff[offset:])
+                    offset += n
+                    for i := 0; i < int(nt); i++ {
+                        t, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        ts, n := binary.Uvarint(buff[offset:])
+                        offset += n
+                        switch t {
+                            default:
+                                offset += int(ts)
+                        }
+                    }
+                }
+            }
+        m.Entries = entries
+        }
+    }
+    if version >= 1 {
+        // reading tagged fields
+        nt, n := binary.Uvarint(buff[offset:])
+        offset += n
+        for i := 0; i < int(nt); i++ {
+            t, n := binary.Uvarint(buff[offset:])
+            offset += n
+            ts, n := binary.Uvarint(buff[offset:])
+            offset += n
+            switch t {
+                default:
+                    offset += int(ts)
+            }
+        }
+    }
+    return offset, nil
+}
+
+func (m *DescribeClientQuotasResponse) Write(version int16, buff []byte, tagSizes []int) []byte {
+    var tagPos int
+    tagPos += 0 // make sure variable is used
+    // writing non tagged fields
+    // writing m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    buff = binary.BigEndian.AppendUint32(buff, uint32(m.ThrottleTimeMs))
+    // writing m.ErrorCode: The error code, or `0` if the quota description succeeded.
+    buff = binary.BigEndian.AppendUint16(buff, uint16(m.ErrorCode))
+    // writing m.ErrorMessage: The error message, or `null` if the quota description succeeded.
+    if version >= 1 {
+        // flexible and nullable
+        if m.ErrorMessage == nil {
+            // null
+            buff = append(buff, 0)
+        } else {
+            // not null
+            buff = binary.AppendUvarint(buff, uint64(len(*m.ErrorMessage) + 1))
+        }
+    } else {
+        // non flexible and nullable
+        if m.ErrorMessage == nil {
+            // null
+            buff = binary.BigEndian.AppendUint16(buff, 65535)
+        } else {
+            // not null
+            buff = binary.BigEndian.AppendUint16(buff, uint16(len(*m.ErrorMessage)))
+        }
+    }
+    if m.ErrorMessage != nil {
+        buff = append(buff, *m.ErrorMessage...)
+    }
+    // writing m.Entries: A result entry.
+    if version >= 1 {
+        // flexible and nullable
+        if m.Entries == nil {
+            // null
+            buff = append(buff, 0)
+        } else {
+            // not null
+            buff = binary.AppendUvarint(buff, uint64(len(m.Entries) + 1))
+        }
+    } else {
+        // non flexible and nullable
+        if m.Entries == nil {
+            // null
+            buff = binary.BigEndian.AppendUint32(buff, 4294967295)
+        } else {
+            // not null
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(m.Entries)))
+        }
+    }
+    for _, entries := range m.Entries {
+        // writing non tagged fields
+        // writing entries.Entity: The quota entity description.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(entries.Entity) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(entries.Entity)))
+        }
+        for _, entity := range entries.Entity {
+            // writing non tagged fields
+            // writing entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityType) + 1))
+            } else {
+                // non flexible and non nullable
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityType)))
+            }
+            if entity.EntityType != nil {
+                buff = append(buff, *entity.EntityType...)
+            }
+            // writing entity.EntityName: The entity name, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = append(buff, 0)
+                } else {
+                    // not null
+                    buff = binary.AppendUvarint(buff, uint64(len(*entity.EntityName) + 1))
+                }
+            } else {
+                // non flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    buff = binary.BigEndian.AppendUint16(buff, 65535)
+                } else {
+                    // not null
+                    buff = binary.BigEndian.AppendUint16(buff, uint16(len(*entity.EntityName)))
+                }
+            }
+            if entity.EntityName != nil {
+                buff = append(buff, *entity.EntityName...)
+            }
+            if version >= 1 {
+                numTaggedFields7 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields7))
+            }
+        }
+        // writing entries.Values: The quota values for the entity.
+        if version >= 1 {
+            // flexible and not nullable
+            buff = binary.AppendUvarint(buff, uint64(len(entries.Values) + 1))
+        } else {
+            // non flexible and non nullable
+            buff = binary.BigEndian.AppendUint32(buff, uint32(len(entries.Values)))
+        }
+        for _, values := range entries.Values {
+            // writing non tagged fields
+            // writing values.Key: The quota configuration key.
+            if version >= 1 {
+                // flexible and not nullable
+                buff = binary.AppendUvarint(buff, uint64(len(*values.Key) + 1))
+            } else {
+                // non flexible and non nullable
+                buff = binary.BigEndian.AppendUint16(buff, uint16(len(*values.Key)))
+            }
+            if values.Key != nil {
+                buff = append(buff, *values.Key...)
+            }
+            // writing values.Value: The quota configuration value.
+            buff = binary.BigEndian.AppendUint64(buff, math.Float64bits(values.Value))
+            if version >= 1 {
+                numTaggedFields11 := 0
+                // write number of tagged fields
+                buff = binary.AppendUvarint(buff, uint64(numTaggedFields11))
+            }
+        }
+        if version >= 1 {
+            numTaggedFields12 := 0
+            // write number of tagged fields
+            buff = binary.AppendUvarint(buff, uint64(numTaggedFields12))
+        }
+    }
+    if version >= 1 {
+        numTaggedFields13 := 0
+        // write number of tagged fields
+        buff = binary.AppendUvarint(buff, uint64(numTaggedFields13))
+    }
+    return buff
+}
+
+func (m *DescribeClientQuotasResponse) CalcSize(version int16, tagSizes []int) (int, []int) {
+    size := 0
+    // calculating size for non tagged fields
+    numTaggedFields0:= 0
+    numTaggedFields0 += 0
+    // size for m.ThrottleTimeMs: The duration in milliseconds for which the request was throttled due to a quota violation, or zero if the request did not violate any quota.
+    size += 4
+    // size for m.ErrorCode: The error code, or `0` if the quota description succeeded.
+    size += 2
+    // size for m.ErrorMessage: The error message, or `null` if the quota description succeeded.
+    if version >= 1 {
+        // flexible and nullable
+        if m.ErrorMessage == nil {
+            // null
+            size += 1
+        } else {
+            // not null
+            size += sizeofUvarint(len(*m.ErrorMessage) + 1)
+        }
+    } else {
+        // non flexible and nullable
+        size += 2
+    }
+    if m.ErrorMessage != nil {
+        size += len(*m.ErrorMessage)
+    }
+    // size for m.Entries: A result entry.
+    if version >= 1 {
+        // flexible and nullable
+        if m.Entries == nil {
+            // null
+            size += 1
+        } else {
+            // not null
+            size += sizeofUvarint(len(m.Entries) + 1)
+        }
+    } else {
+        // non flexible and nullable
+        size += 4
+    }
+    for _, entries := range m.Entries {
+        size += 0 * int(unsafe.Sizeof(entries)) // hack to make sure loop variable is always used
+        // calculating size for non tagged fields
+        numTaggedFields1:= 0
+        numTaggedFields1 += 0
+        // size for entries.Entity: The quota entity description.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(entries.Entity) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, entity := range entries.Entity {
+            size += 0 * int(unsafe.Sizeof(entity)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields2:= 0
+            numTaggedFields2 += 0
+            // size for entity.EntityType: The entity type.
+            if version >= 1 {
+                // flexible and not nullable
+                size += sizeofUvarint(len(*entity.EntityType) + 1)
+            } else {
+                // non flexible and non nullable
+                size += 2
+            }
+            if entity.EntityType != nil {
+                size += len(*entity.EntityType)
+            }
+            // size for entity.EntityName: The entity name, or null if the default.
+            if version >= 1 {
+                // flexible and nullable
+                if entity.EntityName == nil {
+                    // null
+                    size += 1
+                } else {
+                    // not null
+                    size += sizeofUvarint(len(*entity.EntityName) + 1)
+                }
+            } else {
+                // non flexible and nullable
+                size += 2
+            }
+            if entity.EntityName != nil {
+                size += len(*entity.EntityName)
+            }
+            numTaggedFields3:= 0
+            numTaggedFields3 += 0
+            if version >= 1 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields3)
+            }
+        }
+        // size for entries.Values: The quota values for the entity.
+        if version >= 1 {
+            // flexible and not nullable
+            size += sizeofUvarint(len(entries.Values) + 1)
+        } else {
+            // non flexible and non nullable
+            size += 4
+        }
+        for _, values := range entries.Values {
+            size += 0 * int(unsafe.Sizeof(values)) // hack to make sure loop variable is always used
+            // calculating size for non tagged fields
+            numTaggedFields4:= 0
+            numTaggedFields4 += 0
+            // size for values.Key: The quota configuration key.
+            if version >= 1 {
+                // flexible and not nullable
+                size += sizeofUvarint(len(*values.Key) + 1)
+            } else {
+                // non flexible and non nullable
+                size += 2
+            }
+            if values.Key != nil {
+                size += len(*values.Key)
+            }
+            // size for values.Value: The quota configuration value.
+            size += 8
+            numTaggedFields5:= 0
+            numTaggedFields5 += 0
+            if version >= 1 {
+                // writing size of num tagged fields field
+                size += sizeofUvarint(numTaggedFields5)
+            }
+        }
+        numTaggedFields6:= 0
+        numTaggedFields6 += 0
+        if version >= 1 {
+            // writing size of num tagged fields field
+            size += sizeofUvarint(numTaggedFields6)
+        }
+    }
+    numTaggedFields7:= 0
+    numTaggedFields7 += 0
+    if version >= 1 {
+        // writing size of num tagged fields field
+        size += sizeofUvarint(numTaggedFields7)
+    }
+    return size, tagSizes
+}
+
+
@@ -505,6 +505,8 @@ func (m *FetchRequest) Write(version int16, buff []byte, tagSizes []int) []byte
             // writing topics.TopicId: The unique topic ID
             if topics.TopicId != nil {
                 buff = append(buff, topics.TopicId...)
+            } else {
+                buff = append(buff, zeroUUID[:]...)
             }
         }
         // writing topics.Partitions: The partitions to fetch.
@@ -557,6 +559,8 @@ func (m *FetchRequest) Write(version int16, buff []byte, tagSizes []int) []byte
                     // writing partitions.ReplicaDirectoryId: The directory id of the follower fetching
                     if partitions.ReplicaDirectoryId != nil {
                         buff = append(buff, partitions.ReplicaDirectoryId...)
+                    } else {
+                        buff = append(buff, zeroUUID[:]...)
                     }
                     if debug.SanityChecks && len(buff) - tagSizeStart18 != tagSizes[tagPos - 1] {
                         panic(fmt.Sprintf("incorrect calculated tag size for tag %d", 0))
@@ -598,6 +602,8 @@ func (m *FetchRequest) Write(version int16, buff []byte, tagSizes []int) []byte
                 // writing forgottenTopicsData.TopicId: The unique topic ID
                 if forgottenTopicsData.TopicId != nil {
                     buff = append(buff, forgottenTopicsData.TopicId...)
+                } else {
+                    buff = append(buff, zeroUUID[:]...)
                 }
             }
             // writing forgottenTopicsData.Partitions: The partitions indexes to forget.
@@ -934,5 +940,5 @@ func (m *FetchRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *FetchRequest) SupportedApiVersions() (int16, int16) {
-    return 4, 4
+    return 4, 12
 }
@@ -543,6 +543,8 @@ func (m *FetchResponse) Write(version int16, buff []byte, tagSizes []int) []byte
             // writing responses.TopicId: The unique topic ID
             if responses.TopicId != nil {
                 buff = append(buff, responses.TopicId...)
+            } else {
+                buff = append(buff, zeroUUID[:]...)
             }
         }
         // writing responses.Partitions: The topic partitions.
@@ -182,5 +182,5 @@ func (m *FindCoordinatorRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *FindCoordinatorRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 4
 }
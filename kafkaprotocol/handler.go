@@ -533,6 +533,465 @@ func HandleRequestBuffer(apiKey int16, buff []byte, handler RequestHandler, conn
 			_, err := conn.Write(respBuff)
 			return err
 		})
+    case 19:
+		var req CreateTopicsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleCreateTopicsRequest(&requestHeader, &req, func(resp *CreateTopicsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 20:
+		var req DeleteTopicsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDeleteTopicsRequest(&requestHeader, &req, func(resp *DeleteTopicsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 37:
+		var req CreatePartitionsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleCreatePartitionsRequest(&requestHeader, &req, func(resp *CreatePartitionsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 32:
+		var req DescribeConfigsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDescribeConfigsRequest(&requestHeader, &req, func(resp *DescribeConfigsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 44:
+		var req IncrementalAlterConfigsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleIncrementalAlterConfigsRequest(&requestHeader, &req, func(resp *IncrementalAlterConfigsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 16:
+		var req ListGroupsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleListGroupsRequest(&requestHeader, &req, func(resp *ListGroupsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 15:
+		var req DescribeGroupsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDescribeGroupsRequest(&requestHeader, &req, func(resp *DescribeGroupsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 42:
+		var req DeleteGroupsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDeleteGroupsRequest(&requestHeader, &req, func(resp *DeleteGroupsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 47:
+		var req OffsetDeleteRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleOffsetDeleteRequest(&requestHeader, &req, func(resp *OffsetDeleteResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 48:
+		var req DescribeClientQuotasRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDescribeClientQuotasRequest(&requestHeader, &req, func(resp *DescribeClientQuotasResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 49:
+		var req AlterClientQuotasRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleAlterClientQuotasRequest(&requestHeader, &req, func(resp *AlterClientQuotasResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 29:
+		var req DescribeAclsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDescribeAclsRequest(&requestHeader, &req, func(resp *DescribeAclsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 30:
+		var req CreateAclsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleCreateAclsRequest(&requestHeader, &req, func(resp *CreateAclsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 31:
+		var req DeleteAclsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDeleteAclsRequest(&requestHeader, &req, func(resp *DeleteAclsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 21:
+		var req DeleteRecordsRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleDeleteRecordsRequest(&requestHeader, &req, func(resp *DeleteRecordsResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 68:
+		var req ConsumerGroupHeartbeatRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleConsumerGroupHeartbeatRequest(&requestHeader, &req, func(resp *ConsumerGroupHeartbeatResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
+    case 69:
+		var req ConsumerGroupDescribeRequest
+		requestHeaderVersion, responseHeaderVersion := req.HeaderVersions(apiVersion)
+		var requestHeader RequestHeader
+		var offset int
+		if offset, err = requestHeader.Read(requestHeaderVersion, buff); err != nil {
+			return err
+		}
+		minVer, maxVer := req.SupportedApiVersions()
+		if err := checkSupportedVersion(apiKey, apiVersion, minVer, maxVer); err != nil {
+			return err
+		}
+		if _, err := req.Read(apiVersion, buff[offset:]); err != nil {
+			return err
+		}
+		responseHeader.CorrelationId = requestHeader.CorrelationId
+		err = handler.HandleConsumerGroupDescribeRequest(&requestHeader, &req, func(resp *ConsumerGroupDescribeResponse) error {
+			respHeaderSize, hdrTagSizes := responseHeader.CalcSize(responseHeaderVersion, nil)
+			respSize, tagSizes := resp.CalcSize(apiVersion, nil)
+			totRespSize := respHeaderSize + respSize
+			respBuff := make([]byte, 0, 4+totRespSize)
+			respBuff = binary.BigEndian.AppendUint32(respBuff, uint32(totRespSize))
+			respBuff = responseHeader.Write(responseHeaderVersion, respBuff, hdrTagSizes)
+			respBuff = resp.Write(apiVersion, respBuff, tagSizes)
+			_, err := conn.Write(respBuff)
+			return err
+		})
     default: return errors.Errorf("Unsupported ApiKey: %d", apiKey)
     }
     return err
@@ -558,4 +1017,21 @@ type RequestHandler interface {
     HandleAddPartitionsToTxnRequest(hdr *RequestHeader, req *AddPartitionsToTxnRequest, completionFunc func(resp *AddPartitionsToTxnResponse) error) error
     HandleTxnOffsetCommitRequest(hdr *RequestHeader, req *TxnOffsetCommitRequest, completionFunc func(resp *TxnOffsetCommitResponse) error) error
     HandleEndTxnRequest(hdr *RequestHeader, req *EndTxnRequest, completionFunc func(resp *EndTxnResponse) error) error
+    HandleCreateTopicsRequest(hdr *RequestHeader, req *CreateTopicsRequest, completionFunc func(resp *CreateTopicsResponse) error) error
+    HandleDeleteTopicsRequest(hdr *RequestHeader, req *DeleteTopicsRequest, completionFunc func(resp *DeleteTopicsResponse) error) error
+    HandleCreatePartitionsRequest(hdr *RequestHeader, req *CreatePartitionsRequest, completionFunc func(resp *CreatePartitionsResponse) error) error
+    HandleDescribeConfigsRequest(hdr *RequestHeader, req *DescribeConfigsRequest, completionFunc func(resp *DescribeConfigsResponse) error) error
+    HandleIncrementalAlterConfigsRequest(hdr *RequestHeader, req *IncrementalAlterConfigsRequest, completionFunc func(resp *IncrementalAlterConfigsResponse) error) error
+    HandleListGroupsRequest(hdr *RequestHeader, req *ListGroupsRequest, completionFunc func(resp *ListGroupsResponse) error) error
+    HandleDescribeGroupsRequest(hdr *RequestHeader, req *DescribeGroupsRequest, completionFunc func(resp *DescribeGroupsResponse) error) error
+    HandleDeleteGroupsRequest(hdr *RequestHeader, req *DeleteGroupsRequest, completionFunc func(resp *DeleteGroupsResponse) error) error
+    HandleOffsetDeleteRequest(hdr *RequestHeader, req *OffsetDeleteRequest, completionFunc func(resp *OffsetDeleteResponse) error) error
+    HandleDescribeClientQuotasRequest(hdr *RequestHeader, req *DescribeClientQuotasRequest, completionFunc func(resp *DescribeClientQuotasResponse) error) error
+    HandleAlterClientQuotasRequest(hdr *RequestHeader, req *AlterClientQuotasRequest, completionFunc func(resp *AlterClientQuotasResponse) error) error
+    HandleDescribeAclsRequest(hdr *RequestHeader, req *DescribeAclsRequest, completionFunc func(resp *DescribeAclsResponse) error) error
+    HandleCreateAclsRequest(hdr *RequestHeader, req *CreateAclsRequest, completionFunc func(resp *CreateAclsResponse) error) error
+    HandleDeleteAclsRequest(hdr *RequestHeader, req *DeleteAclsRequest, completionFunc func(resp *DeleteAclsResponse) error) error
+    HandleDeleteRecordsRequest(hdr *RequestHeader, req *DeleteRecordsRequest, completionFunc func(resp *DeleteRecordsResponse) error) error
+    HandleConsumerGroupHeartbeatRequest(hdr *RequestHeader, req *ConsumerGroupHeartbeatRequest, completionFunc func(resp *ConsumerGroupHeartbeatResponse) error) error
+    HandleConsumerGroupDescribeRequest(hdr *RequestHeader, req *ConsumerGroupDescribeRequest, completionFunc func(resp *ConsumerGroupDescribeResponse) error) error
 }
@@ -238,5 +238,5 @@ func (m *HeartbeatRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *HeartbeatRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 4
 }
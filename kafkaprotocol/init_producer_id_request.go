@@ -172,5 +172,5 @@ func (m *InitProducerIdRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *InitProducerIdRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 4
 }
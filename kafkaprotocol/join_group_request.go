@@ -511,5 +511,5 @@ func (m *JoinGroupRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *JoinGroupRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 9
 }
@@ -382,5 +382,5 @@ func (m *LeaveGroupRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *LeaveGroupRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 5
 }
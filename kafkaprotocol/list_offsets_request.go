@@ -348,5 +348,5 @@ func (m *ListOffsetsRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *ListOffsetsRequest) SupportedApiVersions() (int16, int16) {
-    return 1, 1
+    return 1, 8
 }
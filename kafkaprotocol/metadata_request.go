@@ -188,6 +188,8 @@ func (m *MetadataRequest) Write(version int16, buff []byte, tagSizes []int) []by
             // writing topics.TopicId: The topic id.
             if topics.TopicId != nil {
                 buff = append(buff, topics.TopicId...)
+            } else {
+                buff = append(buff, zeroUUID[:]...)
             }
         }
         // writing topics.Name: The topic name.
@@ -353,5 +355,5 @@ func (m *MetadataRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *MetadataRequest) SupportedApiVersions() (int16, int16) {
-    return 1, 1
+    return 1, 12
 }
@@ -616,6 +616,8 @@ func (m *MetadataResponse) Write(version int16, buff []byte, tagSizes []int) []b
             // writing topics.TopicId: The topic id. Zero for non-existing topics queried by name. This is never zero when ErrorCode is zero. One of Name and TopicId is always populated.
             if topics.TopicId != nil {
                 buff = append(buff, topics.TopicId...)
+            } else {
+                buff = append(buff, zeroUUID[:]...)
             }
         }
         if version >= 1 {
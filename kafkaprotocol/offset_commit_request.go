@@ -588,5 +588,5 @@ func (m *OffsetCommitRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *OffsetCommitRequest) SupportedApiVersions() (int16, int16) {
-    return 2, 2
+    return 2, 9
 }
@@ -623,5 +623,5 @@ func (m *OffsetFetchRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *OffsetFetchRequest) SupportedApiVersions() (int16, int16) {
-    return 1, 1
+    return 1, 9
 }
@@ -454,5 +454,5 @@ func (m *ProduceRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *ProduceRequest) SupportedApiVersions() (int16, int16) {
-    return 3, 3
+    return 3, 9
 }
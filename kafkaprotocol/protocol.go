@@ -1,25 +1,42 @@
 package kafkaprotocol
 
 const (
-	APIKeyProduce            = 0
-	APIKeyFetch              = 1
-	APIKeyListOffsets        = 2
-	APIKeyMetadata           = 3
-	APIKeyOffsetCommit       = 8
-	APIKeyOffsetFetch        = 9
-	APIKeyFindCoordinator    = 10
-	ApiKeyJoinGroup          = 11
-	ApiKeyHeartbeat          = 12
-	ApiKeyLeaveGroup         = 13
-	ApiKeySyncGroup          = 14
-	APIKeySaslHandshake      = 17
-	APIKeyAPIVersions        = 18
-	APIKeyInitProducerId     = 22
-	APIKeyAddPartitionsToTxn = 24
-	APIKeyAddOffsetsToTxn    = 25
-	APIKeyEndTxn             = 26
-	APIKeyTxnOffsetCommit    = 28
-	APIKeySaslAuthenticate   = 36
+	APIKeyProduce                 = 0
+	APIKeyFetch                   = 1
+	APIKeyListOffsets             = 2
+	APIKeyMetadata                = 3
+	APIKeyOffsetCommit            = 8
+	APIKeyOffsetFetch             = 9
+	APIKeyFindCoordinator         = 10
+	ApiKeyJoinGroup               = 11
+	ApiKeyHeartbeat               = 12
+	ApiKeyLeaveGroup              = 13
+	ApiKeySyncGroup               = 14
+	APIKeyDescribeGroups          = 15
+	APIKeyListGroups              = 16
+	APIKeySaslHandshake           = 17
+	APIKeyAPIVersions             = 18
+	APIKeyCreateTopics            = 19
+	APIKeyDeleteTopics            = 20
+	APIKeyDeleteRecords           = 21
+	APIKeyInitProducerId          = 22
+	APIKeyAddPartitionsToTxn      = 24
+	APIKeyAddOffsetsToTxn         = 25
+	APIKeyEndTxn                  = 26
+	APIKeyTxnOffsetCommit         = 28
+	APIKeyDescribeAcls            = 29
+	APIKeyCreateAcls              = 30
+	APIKeyDeleteAcls              = 31
+	APIKeyDescribeConfigs         = 32
+	APIKeySaslAuthenticate        = 36
+	APIKeyCreatePartitions        = 37
+	APIKeyDeleteGroups            = 42
+	APIKeyIncrementalAlterConfigs = 44
+	APIKeyOffsetDelete            = 47
+	APIKeyDescribeClientQuotas    = 48
+	APIKeyAlterClientQuotas       = 49
+	APIKeyConsumerGroupHeartbeat  = 68
+	APIKeyConsumerGroupDescribe   = 69
 )
 
 const (
@@ -96,24 +113,48 @@ const (
 	ErrorCodeGroupIDNotFound                    = 69
 	ErrorCodeFetchSessionIDNotFound             = 70
 	ErrorCodeInvalidFetchSessionEpoch           = 71
+	ErrorCodeFencedInstanceID                   = 82
+	ErrorCodeGroupSubscribedToTopic             = 86
+	ErrorCodeUnknownTopicID                     = 100
+	ErrorCodeFencedMemberEpoch                  = 110
+	ErrorCodeUnreleasedInstanceID               = 111
+	ErrorCodeUnsupportedAssignor                = 112
+	ErrorCodeStaleMemberEpoch                   = 113
 )
 
 var SupportedAPIVersions = []ApiVersionsResponseApiVersion{
-	{ApiKey: APIKeyProduce, MinVersion: 3, MaxVersion: 3},
-	{ApiKey: APIKeyFetch, MinVersion: 4, MaxVersion: 4},
-	{ApiKey: APIKeyAPIVersions, MinVersion: 0, MaxVersion: 3},
-	{ApiKey: APIKeyMetadata, MinVersion: 1, MaxVersion: 1},
-	{ApiKey: APIKeyFindCoordinator, MinVersion: 0, MaxVersion: 0},
-	{ApiKey: ApiKeyJoinGroup, MinVersion: 0, MaxVersion: 0},
-	{ApiKey: ApiKeySyncGroup, MinVersion: 0, MaxVersion: 0},
-	{ApiKey: ApiKeyHeartbeat, MinVersion: 0, MaxVersion: 0},
-	{ApiKey: APIKeyListOffsets, MinVersion: 1, MaxVersion: 1},
-	{ApiKey: APIKeyOffsetCommit, MinVersion: 2, MaxVersion: 2},
-	{ApiKey: APIKeyOffsetFetch, MinVersion: 1, MaxVersion: 1},
-	{ApiKey: ApiKeyLeaveGroup, MinVersion: 0, MaxVersion: 0},
+	{ApiKey: APIKeyProduce, MinVersion: 3, MaxVersion: 9},
+	{ApiKey: APIKeyFetch, MinVersion: 4, MaxVersion: 12},
+	{ApiKey: APIKeyAPIVersions, MinVersion: 0, MaxVersion: 4},
+	{ApiKey: APIKeyMetadata, MinVersion: 1, MaxVersion: 12},
+	{ApiKey: APIKeyFindCoordinator, MinVersion: 0, MaxVersion: 4},
+	{ApiKey: ApiKeyJoinGroup, MinVersion: 0, MaxVersion: 9},
+	{ApiKey: ApiKeySyncGroup, MinVersion: 0, MaxVersion: 5},
+	{ApiKey: ApiKeyHeartbeat, MinVersion: 0, MaxVersion: 4},
+	{ApiKey: APIKeyListOffsets, MinVersion: 1, MaxVersion: 8},
+	{ApiKey: APIKeyOffsetCommit, MinVersion: 2, MaxVersion: 9},
+	{ApiKey: APIKeyOffsetFetch, MinVersion: 1, MaxVersion: 9},
+	{ApiKey: ApiKeyLeaveGroup, MinVersion: 0, MaxVersion: 5},
 	{ApiKey: APIKeySaslHandshake, MinVersion: 0, MaxVersion: 1},
-	{ApiKey: APIKeyInitProducerId, MinVersion: 0, MaxVersion: 0},
-	{ApiKey: APIKeySaslAuthenticate, MinVersion: 0, MaxVersion: 1},
+	{ApiKey: APIKeyInitProducerId, MinVersion: 0, MaxVersion: 4},
+	{ApiKey: APIKeySaslAuthenticate, MinVersion: 0, MaxVersion: 2},
+	{ApiKey: APIKeyCreateTopics, MinVersion: 0, MaxVersion: 6},
+	{ApiKey: APIKeyDeleteTopics, MinVersion: 0, MaxVersion: 5},
+	{ApiKey: APIKeyCreatePartitions, MinVersion: 0, MaxVersion: 3},
+	{ApiKey: APIKeyDescribeConfigs, MinVersion: 0, MaxVersion: 4},
+	{ApiKey: APIKeyIncrementalAlterConfigs, MinVersion: 0, MaxVersion: 1},
+	{ApiKey: APIKeyListGroups, MinVersion: 0, MaxVersion: 5},
+	{ApiKey: APIKeyDescribeGroups, MinVersion: 0, MaxVersion: 5},
+	{ApiKey: APIKeyDeleteGroups, MinVersion: 0, MaxVersion: 2},
+	{ApiKey: APIKeyOffsetDelete, MinVersion: 0, MaxVersion: 0},
+	{ApiKey: APIKeyDescribeClientQuotas, MinVersion: 0, MaxVersion: 1},
+	{ApiKey: APIKeyAlterClientQuotas, MinVersion: 0, MaxVersion: 1},
+	{ApiKey: APIKeyDescribeAcls, MinVersion: 0, MaxVersion: 3},
+	{ApiKey: APIKeyCreateAcls, MinVersion: 0, MaxVersion: 3},
+	{ApiKey: APIKeyDeleteAcls, MinVersion: 0, MaxVersion: 3},
+	{ApiKey: APIKeyDeleteRecords, MinVersion: 0, MaxVersion: 2},
+	{ApiKey: APIKeyConsumerGroupHeartbeat, MinVersion: 0, MaxVersion: 0},
+	{ApiKey: APIKeyConsumerGroupDescribe, MinVersion: 0, MaxVersion: 0},
 	/*
 		Transactions are currently incomplete
 		{ApiKey: APIKeyAddPartitionsToTxn, MinVersion: 3, MaxVersion: 3},
@@ -123,6 +164,9 @@ var SupportedAPIVersions = []ApiVersionsResponseApiVersion{
 	*/
 }
 
+// zeroUUID is written for uuid fields which have not been set
+var zeroUUID [16]byte
+
 type Records struct {
 	Data [][]byte
 }
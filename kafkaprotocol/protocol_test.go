@@ -3889,6 +3889,64 @@ func TestSyncGroupResponse(t *testing.T) {
 	testReadWriteCases(t, testCases)
 }
 
+func TestConsumerGroupHeartbeatRequest(t *testing.T) {
+	testCases := []readWriteCase{
+		{version: 0, obj: &ConsumerGroupHeartbeatRequest{
+			GroupId:              stringPtr("group1"),
+			MemberId:             stringPtr(""),
+			MemberEpoch:          0,
+			InstanceId:           stringPtr("instance1"),
+			RackId:               stringPtr("rack1"),
+			RebalanceTimeoutMs:   30000,
+			SubscribedTopicNames: []*string{stringPtr("topic1"), stringPtr("topic2")},
+			ServerAssignor:       stringPtr("uniform"),
+			TopicPartitions: []ConsumerGroupHeartbeatRequestTopicPartitions{
+				{TopicId: randomUUID(), Partitions: []int32{1, 3, 5}},
+			},
+		}},
+		{version: 0, obj: &ConsumerGroupHeartbeatRequest{
+			GroupId:            stringPtr("group1"),
+			MemberId:           stringPtr("member1"),
+			MemberEpoch:        23,
+			RebalanceTimeoutMs: -1,
+		}},
+	}
+	testReadWriteCases(t, testCases)
+}
+
+func TestConsumerGroupHeartbeatResponse(t *testing.T) {
+	testCases := []readWriteCase{
+		{version: 0, obj: &ConsumerGroupHeartbeatResponse{
+			ErrorCode:    ErrorCodeFencedMemberEpoch,
+			ErrorMessage: stringPtr("fenced"),
+		}},
+		{version: 0, obj: &ConsumerGroupHeartbeatResponse{
+			MemberId:            stringPtr("member1"),
+			MemberEpoch:         23,
+			HeartbeatIntervalMs: 5000,
+		}},
+		{version: 0, obj: &ConsumerGroupHeartbeatResponse{
+			MemberId:            stringPtr("member1"),
+			MemberEpoch:         23,
+			HeartbeatIntervalMs: 5000,
+			Assignment: &ConsumerGroupHeartbeatResponseAssignment{
+				TopicPartitions: []ConsumerGroupHeartbeatResponseTopicPartitions{
+					{TopicId: randomUUID(), Partitions: []int32{1, 3, 5}},
+					{TopicId: randomUUID(), Partitions: []int32{0}},
+				},
+			},
+		}},
+		{version: 0, obj: &ConsumerGroupHeartbeatResponse{
+			MemberId:    stringPtr("member1"),
+			MemberEpoch: 23,
+			Assignment: &ConsumerGroupHeartbeatResponseAssignment{
+				TopicPartitions: []ConsumerGroupHeartbeatResponseTopicPartitions{},
+			},
+		}},
+	}
+	testReadWriteCases(t, testCases)
+}
+
 func randomUUID() []byte {
 	u, err := uuid.New().MarshalBinary()
 	if err != nil {
@@ -106,5 +106,5 @@ func (m *SaslAuthenticateRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *SaslAuthenticateRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 1
+    return 0, 2
 }
@@ -490,5 +490,5 @@ func (m *SyncGroupRequest) HeaderVersions(version int16) (int16, int16) {
 }
 
 func (m *SyncGroupRequest) SupportedApiVersions() (int16, int16) {
-    return 0, 0
+    return 0, 5
 }
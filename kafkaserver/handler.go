@@ -631,8 +631,10 @@ func (c *connection) HandleSaslHandshakeRequest(_ *kafkaprotocol.RequestHeader,
 	} else {
 		c.saslConversation = conversation
 	}
-	authType := c.s.saslAuthManager.ScramAuthType()
-	resp.Mechanisms = []*string{&authType}
+	for _, mechanism := range c.s.saslAuthManager.EnabledMechanisms() {
+		mech := mechanism
+		resp.Mechanisms = append(resp.Mechanisms, &mech)
+	}
 	return completionFunc(&resp)
 }
 
@@ -684,3 +686,88 @@ func (c *connection) HandleEndTxnRequest(hdr *kafkaprotocol.RequestHeader, req *
 	//TODO implement me
 	panic("implement me")
 }
+
+func (c *connection) HandleCreateTopicsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.CreateTopicsRequest, completionFunc func(resp *kafkaprotocol.CreateTopicsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDeleteTopicsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteTopicsRequest, completionFunc func(resp *kafkaprotocol.DeleteTopicsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleCreatePartitionsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.CreatePartitionsRequest, completionFunc func(resp *kafkaprotocol.CreatePartitionsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDescribeConfigsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeConfigsRequest, completionFunc func(resp *kafkaprotocol.DescribeConfigsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleIncrementalAlterConfigsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.IncrementalAlterConfigsRequest, completionFunc func(resp *kafkaprotocol.IncrementalAlterConfigsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleListGroupsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.ListGroupsRequest, completionFunc func(resp *kafkaprotocol.ListGroupsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDescribeGroupsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeGroupsRequest, completionFunc func(resp *kafkaprotocol.DescribeGroupsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDeleteGroupsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteGroupsRequest, completionFunc func(resp *kafkaprotocol.DeleteGroupsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleOffsetDeleteRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.OffsetDeleteRequest, completionFunc func(resp *kafkaprotocol.OffsetDeleteResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDescribeClientQuotasRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeClientQuotasRequest, completionFunc func(resp *kafkaprotocol.DescribeClientQuotasResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleAlterClientQuotasRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.AlterClientQuotasRequest, completionFunc func(resp *kafkaprotocol.AlterClientQuotasResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDescribeAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DescribeAclsRequest, completionFunc func(resp *kafkaprotocol.DescribeAclsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleCreateAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.CreateAclsRequest, completionFunc func(resp *kafkaprotocol.CreateAclsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDeleteAclsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteAclsRequest, completionFunc func(resp *kafkaprotocol.DeleteAclsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleDeleteRecordsRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.DeleteRecordsRequest, completionFunc func(resp *kafkaprotocol.DeleteRecordsResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleConsumerGroupHeartbeatRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.ConsumerGroupHeartbeatRequest, completionFunc func(resp *kafkaprotocol.ConsumerGroupHeartbeatResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (c *connection) HandleConsumerGroupDescribeRequest(hdr *kafkaprotocol.RequestHeader, req *kafkaprotocol.ConsumerGroupDescribeRequest, completionFunc func(resp *kafkaprotocol.ConsumerGroupDescribeResponse) error) error {
+	//TODO implement me
+	panic("implement me")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/asl/conf"
 	"github.com/spirit-labs/tektite/auth"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	"github.com/spirit-labs/tektite/sockserver"
 	"net"
@@ -27,13 +28,21 @@ type HandlerFactory func(ctx ConnectionContext) kafkaprotocol.RequestHandler
 
 type ConnectionContext interface {
 	AuthContext() auth.Context
+	// ClientHost returns the address of the client, without the port
+	ClientHost() string
+	HandleSaslHandshakeRequest(req *kafkaprotocol.SaslHandshakeRequest,
+		completionFunc func(resp *kafkaprotocol.SaslHandshakeResponse) error) error
+	HandleSaslAuthenticateRequest(req *kafkaprotocol.SaslAuthenticateRequest,
+		completionFunc func(resp *kafkaprotocol.SaslAuthenticateResponse) error) error
 }
 
-func NewKafkaServer(address string, tlsConf conf.TLSConfig, authenticationType string, handlerFactory HandlerFactory) *KafkaServer {
+func NewKafkaServer(address string, tlsConf conf.TLSConfig, authenticationType string,
+	saslAuthManager *auth.SaslAuthManager, handlerFactory HandlerFactory) *KafkaServer {
 	return &KafkaServer{
 		address:            address,
 		tlsConf:            tlsConf,
 		authenticationType: authenticationType,
+		saslAuthManager:    saslAuthManager,
 		handlerFactory:     handlerFactory,
 	}
 }
@@ -78,28 +87,96 @@ func (k *KafkaServer) createConnection(conn net.Conn) sockserver.ServerConnectio
 }
 
 type kafkaConnection struct {
-	s           *KafkaServer
-	lock        sync.Mutex
-	conn        net.Conn
-	authContext auth.Context
-	handler     kafkaprotocol.RequestHandler
+	s                *KafkaServer
+	lock             sync.Mutex
+	conn             net.Conn
+	authContext      auth.Context
+	handler          kafkaprotocol.RequestHandler
+	saslConversation auth.SaslConversation
 }
 
 func (c *kafkaConnection) AuthContext() auth.Context {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 	return c.authContext
 }
 
+func (c *kafkaConnection) ClientHost() string {
+	addr := c.conn.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+func (c *kafkaConnection) HandleSaslHandshakeRequest(req *kafkaprotocol.SaslHandshakeRequest,
+	completionFunc func(resp *kafkaprotocol.SaslHandshakeResponse) error) error {
+	var resp kafkaprotocol.SaslHandshakeResponse
+	if c.s.saslAuthManager == nil {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeUnsupportedSaslMechanism
+		return completionFunc(&resp)
+	}
+	conversation, ok, err := c.s.saslAuthManager.CreateConversation(common.SafeDerefStringPtr(req.Mechanism))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeUnsupportedSaslMechanism
+	} else {
+		c.lock.Lock()
+		c.saslConversation = conversation
+		c.lock.Unlock()
+	}
+	for _, mechanism := range c.s.saslAuthManager.EnabledMechanisms() {
+		mech := mechanism
+		resp.Mechanisms = append(resp.Mechanisms, &mech)
+	}
+	return completionFunc(&resp)
+}
+
+func (c *kafkaConnection) HandleSaslAuthenticateRequest(req *kafkaprotocol.SaslAuthenticateRequest,
+	completionFunc func(resp *kafkaprotocol.SaslAuthenticateResponse) error) error {
+	var resp kafkaprotocol.SaslAuthenticateResponse
+	c.lock.Lock()
+	conv := c.saslConversation
+	c.lock.Unlock()
+	if conv == nil {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeIllegalSaslState
+		resp.ErrorMessage = common.StrPtr("SaslAuthenticateRequest without a preceding SaslHandshakeRequest")
+		return completionFunc(&resp)
+	}
+	saslRespBytes, complete, failed := conv.Process(req.AuthBytes)
+	if failed {
+		resp.ErrorCode = kafkaprotocol.ErrorCodeSaslAuthenticationFailed
+		resp.ErrorMessage = common.StrPtr("SASL authentication failed")
+	} else {
+		resp.AuthBytes = saslRespBytes
+		if complete {
+			principal := conv.Principal()
+			c.lock.Lock()
+			c.authContext.Principal = &principal
+			c.authContext.Authenticated = true
+			c.saslConversation = nil
+			c.lock.Unlock()
+		}
+	}
+	return completionFunc(&resp)
+}
+
 func (c *kafkaConnection) HandleMessage(message []byte) error {
-	if !c.authContext.Authenticated && c.s.authenticationType == auth.AuthenticationTLS {
+	authContext := c.AuthContext()
+	if !authContext.Authenticated && c.s.authenticationType == auth.AuthenticationTLS {
 		if err := c.authoriseWithClientCert(); err != nil {
 			return err
 		}
+		authContext = c.AuthContext()
 	}
 	apiKey := int16(binary.BigEndian.Uint16(message))
 	authType := c.s.authenticationType
 	authenticated := authType == "" || apiKey == kafkaprotocol.APIKeyAPIVersions ||
 		apiKey == kafkaprotocol.APIKeySaslHandshake || apiKey == kafkaprotocol.APIKeySaslAuthenticate ||
-		c.authContext.Authenticated
+		authContext.Authenticated
 	if !authenticated {
 		return errors.Errorf("cannot handle Kafka apiKey: %d as authentication type is %s but connection has not been authenticated", apiKey, authType)
 	}
@@ -17,14 +17,15 @@ import (
 )
 
 type compactionState struct {
-	tableDeleteTimer   *time.Timer
-	tablesToDelete     []deleteTableEntry
-	jobQueue           []jobHolder
-	inProgress         map[string]inProgressCompaction
-	pendingCompactions map[int]int
-	lockedRanges       map[int][]lockedRange
-	pollers            *pollerQueue
-	stats              CompactionStats
+	tableDeleteTimer    *time.Timer
+	retentionCheckTimer *time.Timer
+	tablesToDelete      []deleteTableEntry
+	jobQueue            []jobHolder
+	inProgress          map[string]inProgressCompaction
+	pendingCompactions  map[int]int
+	lockedRanges        map[int][]lockedRange
+	pollers             *pollerQueue
+	stats               CompactionStats
 }
 
 func newCompactionState() compactionState {
@@ -202,17 +203,27 @@ outer:
 		// We preserve tombstones if we're not compacting into the last level or there are entries in any table
 		// in the compaction with a non compactable version (> last flushed version)
 		preserveTombstones := !canCompact || m.getLastLevel() > destLevel
+		var hasAllPartitionData bool
+		if !move && m.retentionProvider != nil {
+			// Tombstones in compacted topics can only be removed if there's no older data they could be hiding
+			var err error
+			hasAllPartitionData, err = m.hasAllPartitionData(tablesToCompact, destRangeStart, destRangeEnd)
+			if err != nil {
+				return 0, false, err
+			}
+		}
 		job := CompactionJob{
-			id:                 id,
-			levelFrom:          level,
-			tables:             tablesToCompact,
-			isMove:             move,
-			preserveTombstones: preserveTombstones,
-			scheduleTime:       arista.NanoTime(),
-			serverTime:         uint64(time.Now().UTC().UnixMilli()),
-			lastFlushedVersion: m.masterRecord.lastFlushedVersion,
-			sourceRange:        sourceRange,
-			destRange:          destRange,
+			id:                  id,
+			levelFrom:           level,
+			tables:              tablesToCompact,
+			isMove:              move,
+			preserveTombstones:  preserveTombstones,
+			hasAllPartitionData: hasAllPartitionData,
+			scheduleTime:        arista.NanoTime(),
+			serverTime:          uint64(time.Now().UTC().UnixMilli()),
+			lastFlushedVersion:  m.masterRecord.lastFlushedVersion,
+			sourceRange:         sourceRange,
+			destRange:           destRange,
 		}
 		jobs = append(jobs, job)
 		m.lockTablesForJob(job)
@@ -237,6 +248,37 @@ outer:
 	return len(jobs), hasLocked, nil
 }
 
+// hasAllPartitionData returns true if there are no tables outside the job, in any level, containing data for the
+// partitions in the range of the job
+func (m *Manager) hasAllPartitionData(tables [][]tableToCompact, rangeStart []byte, rangeEnd []byte) (bool, error) {
+	if len(rangeStart) < 16 || len(rangeEnd) < 16 {
+		return false, nil
+	}
+	// Widen the range to cover all the topic data for the partitions at either end of the range
+	keyStart := rangeStart[:16]
+	keyEnd := make([]byte, 0, 17)
+	keyEnd = append(keyEnd, rangeEnd[:16]...)
+	keyEnd = append(keyEnd, common.EntryTypeTopicData+1)
+	jobTables := map[string]struct{}{}
+	for _, overlapping := range tables {
+		for _, t := range overlapping {
+			jobTables[string(t.table.SSTableID)] = struct{}{}
+		}
+	}
+	for level := 0; level <= m.getLastLevel(); level++ {
+		overlapping, err := m.getOverlappingTables(keyStart, keyEnd, level, m.levelEntry(level))
+		if err != nil {
+			return false, err
+		}
+		for _, te := range overlapping {
+			if _, ok := jobTables[string(te.SSTableID)]; !ok {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
 func (m *Manager) isRangeLocked(rng lockedRange) bool {
 	rngs, ok := m.lockedRanges[rng.level]
 	if !ok {
@@ -251,6 +293,27 @@ func (m *Manager) isRangeLocked(rng lockedRange) bool {
 }
 
 func (m *Manager) hasPotentialExpiredEntries(te *TableEntry, now uint64) bool {
+	if m.retentionProvider != nil {
+		expired, err := isTableExpired(te, now, m.retentionProvider)
+		if err != nil {
+			log.Warnf("failed to get retention for table %v: %v", te.SSTableID, err)
+			// err on the side of caution
+			return true
+		}
+		if expired {
+			// Prevent a move, so the table is dropped by the compaction worker instead
+			return true
+		}
+		compacted, err := isTableCompacted(te, m.retentionProvider)
+		if err != nil {
+			log.Warnf("failed to get retention for table %v: %v", te.SSTableID, err)
+			return true
+		}
+		if compacted {
+			// Prevent a move, so the records in the table are compacted by the compaction worker
+			return true
+		}
+	}
 	if len(m.masterRecord.slabRetentions) == 0 {
 		return false
 	}
@@ -277,6 +340,66 @@ func (m *Manager) hasPotentialExpiredEntries(te *TableEntry, now uint64) bool {
 	return false
 }
 
+// isTableExpired returns true if every entry in the table is topic data that is past retention. The age of the data is
+// judged by the table MaxTimestamp, the latest timestamp of any batch in the table, or by the time the table was added if
+// that isn't known. If all the entries in the table are for the same partition we can also tell whether they are below
+// the minimum retained offset, as the RangeEnd has the highest offset in the table. A table containing more than one
+// partition can only be dropped if it's known to contain nothing but topic data, and its data is past the retention
+// of every partition in its range.
+func isTableExpired(te *TableEntry, now uint64, retentionProvider RetentionProvider) (bool, error) {
+	if len(te.RangeStart) <= 16 || len(te.RangeEnd) <= 16 || te.NumPrefixDeletes > 0 {
+		return false, nil
+	}
+	if te.RangeStart[16] != common.EntryTypeTopicData || te.RangeEnd[16] != common.EntryTypeTopicData {
+		return false, nil
+	}
+	dataTime := te.AddedTime
+	if te.MaxTimestamp > 0 {
+		dataTime = uint64(te.MaxTimestamp)
+	}
+	partitionHash := te.RangeStart[:16]
+	if bytes.Equal(partitionHash, te.RangeEnd[:16]) {
+		retention, err := retentionProvider.GetPartitionRetention(partitionHash)
+		if err != nil {
+			return false, err
+		}
+		if isRetentionExpired(dataTime, retention.Retention, now) {
+			return true, nil
+		}
+		return isOffsetExpired(te.RangeEnd, retention.MinOffset), nil
+	}
+	rangeProvider, ok := retentionProvider.(RangeRetentionProvider)
+	if !ok || !te.TopicDataOnly {
+		return false, nil
+	}
+	retention, err := rangeProvider.GetMaxRetentionInRange(partitionHash, te.RangeEnd[:16])
+	if err != nil {
+		return false, err
+	}
+	return isRetentionExpired(dataTime, retention, now), nil
+}
+
+// isTableCompacted returns true if all the entries in the table are topic data for the same partition, and the topic is
+// compacted. Tables containing data for more than one partition are compacted when they are next merged with other
+// tables.
+func isTableCompacted(te *TableEntry, retentionProvider RetentionProvider) (bool, error) {
+	if len(te.RangeStart) <= 16 || len(te.RangeEnd) <= 16 {
+		return false, nil
+	}
+	if te.RangeStart[16] != common.EntryTypeTopicData || te.RangeEnd[16] != common.EntryTypeTopicData {
+		return false, nil
+	}
+	partitionHash := te.RangeStart[:16]
+	if !bytes.Equal(partitionHash, te.RangeEnd[:16]) {
+		return false, nil
+	}
+	retention, err := retentionProvider.GetPartitionRetention(partitionHash)
+	if err != nil {
+		return false, err
+	}
+	return retention.Compacted, nil
+}
+
 func (m *Manager) queueOrDespatchJob(job CompactionJob, complFunc func(error)) {
 	if m.pollers.Len() > 0 {
 		// We have a waiting poller - hand the job to the poller straightaway
@@ -690,14 +813,16 @@ type CompactionJob struct {
 	tables             [][]tableToCompact
 	isMove             bool
 	preserveTombstones bool
-	scheduleTime       uint64 // Used for timing jobs - we use nanoTime to avoid errors if clocks change
-	serverTime         uint64 // Unix millis past epoch - Used on compaction workers to determine if entries are expired
-	lastFlushedVersion int64
-	sourceRange        lockedRange // Not used on compaction worker so doesn't need to be serialized
-	destRange          lockedRange // Not used on compaction worker so doesn't need to be serialized
+	// hasAllPartitionData is true if there is no data outside the job for any partition in the range of the job
+	hasAllPartitionData bool
+	scheduleTime        uint64 // Used for timing jobs - we use nanoTime to avoid errors if clocks change
+	serverTime          uint64 // Unix millis past epoch - Used on compaction workers to determine if entries are expired
+	lastFlushedVersion  int64
+	sourceRange         lockedRange // Not used on compaction worker so doesn't need to be serialized
+	destRange           lockedRange // Not used on compaction worker so doesn't need to be serialized
 }
 
-func (c *CompactionJob) Serialize(buff []byte) []byte {
+func (c *CompactionJob) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = encoding.AppendStringToBufferLE(buff, c.id)
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(c.levelFrom))
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(c.tables)))
@@ -705,18 +830,19 @@ func (c *CompactionJob) Serialize(buff []byte) []byte {
 		buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(tablesToCompact)))
 		for _, tableToCompact := range tablesToCompact {
 			buff = encoding.AppendUint32ToBufferLE(buff, uint32(tableToCompact.level))
-			buff = tableToCompact.table.serialize(buff)
+			buff = tableToCompact.table.serialize(buff, format)
 		}
 	}
 	buff = encoding.AppendBoolToBuffer(buff, c.isMove)
 	buff = encoding.AppendBoolToBuffer(buff, c.preserveTombstones)
+	buff = encoding.AppendBoolToBuffer(buff, c.hasAllPartitionData)
 	buff = encoding.AppendUint64ToBufferLE(buff, c.scheduleTime)
 	buff = encoding.AppendUint64ToBufferLE(buff, c.serverTime)
 	buff = encoding.AppendUint64ToBufferLE(buff, uint64(c.lastFlushedVersion))
 	return buff
 }
 
-func (c *CompactionJob) Deserialize(buff []byte, offset int) int {
+func (c *CompactionJob) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	c.id, offset = encoding.ReadStringFromBufferLE(buff, offset)
 	var lf uint32
 	lf, offset = encoding.ReadUint32FromBufferLE(buff, offset)
@@ -732,7 +858,7 @@ func (c *CompactionJob) Deserialize(buff []byte, offset int) int {
 			var l uint32
 			l, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 			te := &TableEntry{}
-			offset = te.deserialize(buff, offset)
+			offset = te.deserialize(buff, offset, format)
 			tables2[j] = tableToCompact{
 				level: int(l),
 				table: te,
@@ -742,6 +868,7 @@ func (c *CompactionJob) Deserialize(buff []byte, offset int) int {
 	}
 	c.isMove, offset = encoding.ReadBoolFromBuffer(buff, offset)
 	c.preserveTombstones, offset = encoding.ReadBoolFromBuffer(buff, offset)
+	c.hasAllPartitionData, offset = encoding.ReadBoolFromBuffer(buff, offset)
 	c.scheduleTime, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	c.serverTime, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	var lfv uint64
@@ -755,22 +882,22 @@ type CompactionResult struct {
 	newTables []TableEntry
 }
 
-func (c *CompactionResult) Serialize(buff []byte) []byte {
+func (c *CompactionResult) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = encoding.AppendStringToBufferLE(buff, c.id)
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(c.newTables)))
 	for _, nt := range c.newTables {
-		buff = nt.serialize(buff)
+		buff = nt.serialize(buff, format)
 	}
 	return buff
 }
 
-func (c *CompactionResult) Deserialize(buff []byte, offset int) int {
+func (c *CompactionResult) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	c.id, offset = encoding.ReadStringFromBufferLE(buff, offset)
 	var nt uint32
 	nt, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	c.newTables = make([]TableEntry, int(nt))
 	for i := 0; i < int(nt); i++ {
-		offset = c.newTables[i].deserialize(buff, offset)
+		offset = c.newTables[i].deserialize(buff, offset, format)
 	}
 	return offset
 }
@@ -786,6 +913,8 @@ type ssTableInfo struct {
 	maxVersion       uint64
 	deleteRatio      float64
 	numPrefixDeletes uint32
+	maxTimestamp     int64
+	topicDataOnly    bool
 }
 
 type poller struct {
@@ -963,9 +963,9 @@ func TestMergeInterleaved(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1,
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{},
 		[][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}}, true,
-		1300, math.MaxInt64, "", nil, 0)
+		1300, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 4, len(res))
 	for i := 0; i < 4; i++ {
@@ -1007,9 +1007,9 @@ func TestMergeNoOverlap(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1,
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{},
 		[][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}}, true,
-		1300, math.MaxInt64, "", nil, 0)
+		1300, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 4, len(res))
 	for i := 0; i < 4; i++ {
@@ -1051,9 +1051,9 @@ func TestOverwriteEntriesWithLaterVersionFirst(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1,
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{},
 		[][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}}, true,
-		maxTableSize, math.MaxInt64, "", nil, 0)
+		maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 3, len(res))
 	for i := 0; i < 3; i++ {
@@ -1094,8 +1094,8 @@ func TestOverwriteEntriesWithLaterVersionLast(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
-		true, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
+		true, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 3, len(res))
 	for i := 0; i < 3; i++ {
@@ -1132,9 +1132,9 @@ func TestMergePreserveTombstones(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1,
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{},
 		[][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}}, true, maxTableSize,
-		math.MaxInt64, "", nil, 0)
+		math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(res))
 	checkKVs(t, res[0].sst, "val", 0, 0, 1, -1, 2, 2, 3, -1)
@@ -1165,8 +1165,8 @@ func TestMergePreserveTombstonesAllEntriesRemoved(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
-		true, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
+		true, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(res))
 
@@ -1199,8 +1199,8 @@ func TestMergePreserveTombstonesNotAllEntriesRemoved(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
-		true, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
+		true, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(res))
 
@@ -1233,7 +1233,7 @@ func TestMergeIntoMultipleTables(t *testing.T) {
 		tablesToMerge = append(tablesToMerge, tableToMerge{sst: ssTable})
 	}
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{tablesToMerge}, true, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{tablesToMerge}, true, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, numTables, len(res))
 
@@ -1284,7 +1284,7 @@ func TestMergeSameKeysDifferentVersions(t *testing.T) {
 		tablesToMerge = append(tablesToMerge, tableToMerge{sst: ssTable})
 	}
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{tablesToMerge}, true, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{tablesToMerge}, true, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	// We never split different versions of same key across tables, so one table should be produced.
 	require.Equal(t, 1, len(res))
@@ -1331,8 +1331,8 @@ func TestMergeNotPreserveTombstonesAllEntriesRemoved(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
-		false, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
+		false, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 0, len(res))
 }
@@ -1362,8 +1362,8 @@ func TestMergeNotPreserveTombstonesNotAllEntriesRemoved(t *testing.T) {
 	sst4, err := builder4.build()
 	require.NoError(t, err)
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
-		false, maxTableSize, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}, {sst: sst2}}, {{sst: sst3}, {sst: sst4}}},
+		false, maxTableSize, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(res))
 
@@ -1405,8 +1405,8 @@ func TestMergeDeadVersions(t *testing.T) {
 		sst:               sst2,
 	}
 
-	res, err := mergeSSTables(common.DataFormatV1, [][]tableToMerge{{tableToMerge1}, {tableToMerge2}},
-		false, 3500, math.MaxInt64, "", nil, 0)
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{tableToMerge1}, {tableToMerge2}},
+		false, 3500, math.MaxInt64, "", nil, 0, false)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(res))
 
@@ -1509,91 +1509,250 @@ func TestRemoveDeadVersionsIterator(t *testing.T) {
 	}
 }
 
-type testSlabRetentions struct {
+type testPartitionRetentions struct {
 	lock       sync.Mutex
-	retentions map[int]time.Duration
+	retentions map[string]PartitionRetention
 }
 
-func (t *testSlabRetentions) GetSlabRetention(slabID int) (time.Duration, error) {
+func (t *testPartitionRetentions) GetPartitionRetention(partitionHash []byte) (PartitionRetention, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	return t.retentions[slabID], nil
+	return t.retentions[string(partitionHash)], nil
 }
 
-func createExpiredEntryKey(slabID int, i int) []byte {
-	key := []byte("xxxxxxxxxxxxxxxx")                           // partition hash
-	key = encoding2.AppendUint64ToBufferBE(key, uint64(slabID)) // slab id
+func (t *testPartitionRetentions) GetMaxRetentionInRange(partitionHashStart []byte, partitionHashEnd []byte) (time.Duration, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var maxRetention time.Duration
+	for hash, retention := range t.retentions {
+		if hash < string(partitionHashStart) || hash > string(partitionHashEnd) {
+			continue
+		}
+		if retention.Retention <= 0 {
+			return 0, nil
+		}
+		maxRetention = max(maxRetention, retention.Retention)
+	}
+	return maxRetention, nil
+}
+
+func createTestPartitionHash(partition int) []byte {
+	return []byte(fmt.Sprintf("partition%07d", partition))
+}
+
+func createExpiredEntryKey(partition int, entryType byte, i int) []byte {
+	key := createTestPartitionHash(partition)
+	key = append(key, entryType)
 	return append(key, []byte(fmt.Sprintf("key-%04d", i))...)
 }
 
-func createExpiredValue(slabID int, i int) []byte {
-	return []byte(fmt.Sprintf("val-%d-%d", slabID, i))
+func createExpiredValue(partition int, i int) []byte {
+	return []byte(fmt.Sprintf("val-%d-%d", partition, i))
 }
 
 func TestRemoveExpiredEntriesIterator(t *testing.T) {
 	si := &iteration.StaticIterator{}
 
-	retentions := &testSlabRetentions{retentions: map[int]time.Duration{}}
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{}}
 
-	numSlabs := 10
-	entriesPerSlab := 10
-	for i := 0; i < numSlabs; i++ {
-		for j := 0; j < entriesPerSlab; j++ {
-			key := createExpiredEntryKey(i, j)
+	numPartitions := 10
+	entriesPerPartition := 10
+	for i := 0; i < numPartitions; i++ {
+		for j := 0; j < entriesPerPartition; j++ {
+			key := createExpiredEntryKey(i, common.EntryTypeTopicData, j)
 			value := createExpiredValue(i, j)
 			si.AddKV(key, value)
 		}
-		// even numbered slabs have short retention, odd ones have a long retention
+		// Offset snapshots must never be expired
+		si.AddKV(createExpiredEntryKey(i, common.EntryTypeOffsetSnapshot, 0), createExpiredValue(i, 0))
+		// even numbered partitions have short retention, odd ones have a long retention
 		if i%2 == 0 {
-			retentions.retentions[i] = 1 * time.Hour
+			retentions.retentions[string(createTestPartitionHash(i))] = PartitionRetention{Retention: 1 * time.Hour}
 		} else {
-			retentions.retentions[i] = 5 * time.Hour
+			retentions.retentions[string(createTestPartitionHash(i))] = PartitionRetention{Retention: 5 * time.Hour}
 		}
 	}
-
-	// No entries expired
-	creationTime := time.Now().UTC().UnixMilli()
-	now := creationTime + 30*time.Minute.Milliseconds()
-	iter := NewRemoveExpiredEntriesIterator(si, uint64(creationTime), uint64(now), retentions)
-	for i := 0; i < numSlabs; i++ {
-		for j := 0; j < entriesPerSlab; j++ {
+	// Partition with no retention
+	si.AddKV(createExpiredEntryKey(numPartitions, common.EntryTypeTopicData, 0), createExpiredValue(numPartitions, 0))
+
+	requireEntries := func(iter *RemoveExpiredEntriesIterator, expired func(partition int) bool) {
+		for i := 0; i < numPartitions; i++ {
+			if !expired(i) {
+				for j := 0; j < entriesPerPartition; j++ {
+					valid, curr, err := iter.Next()
+					require.NoError(t, err)
+					require.True(t, valid)
+					require.Equal(t, createExpiredEntryKey(i, common.EntryTypeTopicData, j), curr.Key)
+					require.Equal(t, createExpiredValue(i, j), curr.Value)
+				}
+			}
 			valid, curr, err := iter.Next()
 			require.NoError(t, err)
 			require.True(t, valid)
-			expectedKey := createExpiredEntryKey(i, j)
-			expectedVal := createExpiredValue(i, j)
-			require.Equal(t, expectedKey, curr.Key)
-			require.Equal(t, expectedVal, curr.Value)
+			require.Equal(t, createExpiredEntryKey(i, common.EntryTypeOffsetSnapshot, 0), curr.Key)
 		}
+		valid, curr, err := iter.Next()
+		require.NoError(t, err)
+		require.True(t, valid)
+		require.Equal(t, createExpiredEntryKey(numPartitions, common.EntryTypeTopicData, 0), curr.Key)
+		valid, _, err = iter.Next()
+		require.NoError(t, err)
+		require.False(t, valid)
 	}
 
-	// Even slabs expired
+	// No entries expired
+	addedTime := time.Now().UTC().UnixMilli()
+	now := addedTime + 30*time.Minute.Milliseconds()
+	iter := NewRemoveExpiredEntriesIterator(si, uint64(addedTime), uint64(now), retentions)
+	requireEntries(iter, func(partition int) bool {
+		return false
+	})
+
+	// Even partitions expired
 	si.Reset()
-	now = creationTime + 1*time.Hour.Milliseconds()
-	iter = NewRemoveExpiredEntriesIterator(si, uint64(creationTime), uint64(now), retentions)
-	for i := 0; i < numSlabs; i++ {
-		if i%2 == 0 {
-			continue
+	now = addedTime + 1*time.Hour.Milliseconds()
+	iter = NewRemoveExpiredEntriesIterator(si, uint64(addedTime), uint64(now), retentions)
+	requireEntries(iter, func(partition int) bool {
+		return partition%2 == 0
+	})
+
+	// All partitions with retention expired
+	si.Reset()
+	now = addedTime + 5*time.Hour.Milliseconds()
+	iter = NewRemoveExpiredEntriesIterator(si, uint64(addedTime), uint64(now), retentions)
+	requireEntries(iter, func(partition int) bool {
+		return true
+	})
+}
+
+func TestRemoveExpiredEntriesIteratorBatchTimestamp(t *testing.T) {
+	si := &iteration.StaticIterator{}
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Retention: 1 * time.Hour},
+	}}
+	now := time.Now().UTC().UnixMilli()
+	// The table was added recently, e.g. by a compaction, but the first two batches are older than the retention
+	addedTime := now - 1
+	expiredTimestamp := now - time.Hour.Milliseconds()
+	si.AddKV(createOffsetEntryKey(0, 0), createTestBatch(0, 1, expiredTimestamp-1000))
+	si.AddKV(createOffsetEntryKey(0, 1), createTestBatch(1, 1, expiredTimestamp))
+	si.AddKV(createOffsetEntryKey(0, 2), createTestBatch(2, 1, expiredTimestamp+1))
+	// Batches with no timestamp are judged by the time the table was added
+	si.AddKV(createOffsetEntryKey(0, 3), createTestBatch(3, 1, -1))
+	iter := NewRemoveExpiredEntriesIterator(si, uint64(addedTime), uint64(now), retentions)
+	for i := 2; i < 4; i++ {
+		valid, curr, err := iter.Next()
+		require.NoError(t, err)
+		require.True(t, valid)
+		require.Equal(t, createOffsetEntryKey(0, int64(i)), curr.Key)
+	}
+	valid, _, err := iter.Next()
+	require.NoError(t, err)
+	require.False(t, valid)
+}
+
+func createOffsetEntryKey(partition int, offset int64) []byte {
+	key := createTestPartitionHash(partition)
+	key = append(key, common.EntryTypeTopicData)
+	key = encoding2.KeyEncodeInt(key, offset)
+	return encoding2.EncodeVersion(key, 0)
+}
+
+func TestRemoveExpiredEntriesIteratorMinOffset(t *testing.T) {
+	si := &iteration.StaticIterator{}
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {MinOffset: 5},
+		string(createTestPartitionHash(1)): {MinOffset: 0},
+	}}
+	numOffsets := 10
+	for partition := 0; partition < 3; partition++ {
+		for i := 0; i < numOffsets; i++ {
+			si.AddKV(createOffsetEntryKey(partition, int64(i)), createExpiredValue(partition, i))
+		}
+	}
+	now := uint64(time.Now().UTC().UnixMilli())
+	iter := NewRemoveExpiredEntriesIterator(si, now, now, retentions)
+	for partition := 0; partition < 3; partition++ {
+		start := 0
+		if partition == 0 {
+			// offsets below the min offset are removed
+			start = 5
 		}
-		for j := 0; j < entriesPerSlab; j++ {
+		for i := start; i < numOffsets; i++ {
 			valid, curr, err := iter.Next()
 			require.NoError(t, err)
 			require.True(t, valid)
-			expectedKey := createExpiredEntryKey(i, j)
-			expectedVal := createExpiredValue(i, j)
-			require.Equal(t, expectedKey, curr.Key)
-			require.Equal(t, expectedVal, curr.Value)
+			require.Equal(t, createOffsetEntryKey(partition, int64(i)), curr.Key)
 		}
 	}
-
-	// All slabs expired
-	si.Reset()
-	now = creationTime + 5*time.Hour.Milliseconds()
-	iter = NewRemoveExpiredEntriesIterator(si, uint64(creationTime), uint64(now), retentions)
-	valid, _, _ := iter.Next()
+	valid, _, err := iter.Next()
+	require.NoError(t, err)
 	require.False(t, valid)
 }
 
+func TestIsTableExpired(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Retention: 1 * time.Hour},
+		string(createTestPartitionHash(1)): {Retention: 2 * time.Hour},
+		string(createTestPartitionHash(2)): {},
+		string(createTestPartitionHash(3)): {MinOffset: 100},
+	}}
+	addedTime := uint64(time.Now().UTC().UnixMilli())
+	expiredTime := addedTime + uint64(time.Hour.Milliseconds())
+
+	testCases := []struct {
+		name          string
+		rangeStart    []byte
+		rangeEnd      []byte
+		maxTimestamp  int64
+		topicDataOnly bool
+		now           uint64
+		expired       bool
+	}{
+		{name: "not past retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(0, common.EntryTypeTopicData, 10), now: expiredTime - 1, expired: false},
+		{name: "past retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(0, common.EntryTypeTopicData, 10), now: expiredTime, expired: true},
+		{name: "max timestamp past retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(0, common.EntryTypeTopicData, 10), maxTimestamp: int64(addedTime) - 1,
+			now: expiredTime - 1, expired: true},
+		{name: "max timestamp not past retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(0, common.EntryTypeTopicData, 10), maxTimestamp: int64(expiredTime),
+			now: expiredTime, expired: false},
+		{name: "no retention", rangeStart: createExpiredEntryKey(2, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(2, common.EntryTypeTopicData, 10), now: expiredTime, expired: false},
+		{name: "multiple partitions", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(1, common.EntryTypeTopicData, 10), now: expiredTime * 2, expired: false},
+		{name: "multiple partitions topic data only", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(1, common.EntryTypeTopicData, 10), topicDataOnly: true,
+			now: addedTime + uint64(2*time.Hour.Milliseconds()), expired: true},
+		{name: "multiple partitions not past longest retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(1, common.EntryTypeTopicData, 10), topicDataOnly: true, now: expiredTime,
+			expired: false},
+		{name: "multiple partitions one with no retention", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(2, common.EntryTypeTopicData, 10), topicDataOnly: true, now: expiredTime * 2,
+			expired: false},
+		{name: "offset snapshot", rangeStart: createExpiredEntryKey(0, common.EntryTypeTopicData, 0),
+			rangeEnd: createExpiredEntryKey(0, common.EntryTypeOffsetSnapshot, 0), now: expiredTime, expired: false},
+		{name: "below min offset", rangeStart: createOffsetEntryKey(3, 0),
+			rangeEnd: createOffsetEntryKey(3, 99), now: addedTime, expired: true},
+		{name: "spans min offset", rangeStart: createOffsetEntryKey(3, 0),
+			rangeEnd: createOffsetEntryKey(3, 100), now: addedTime, expired: false},
+	}
+	for _, tc := range testCases {
+		te := &TableEntry{
+			RangeStart:    tc.rangeStart,
+			RangeEnd:      tc.rangeEnd,
+			AddedTime:     addedTime,
+			MaxTimestamp:  tc.maxTimestamp,
+			TopicDataOnly: tc.topicDataOnly,
+		}
+		expired, err := isTableExpired(te, tc.now, retentions)
+		require.NoError(t, err)
+		require.Equal(t, tc.expired, expired, tc.name)
+	}
+}
+
 func TestSerializeDeserializeCompactionJob(t *testing.T) {
 	job1 := CompactionJob{
 		id:        uuid.New().String(),
@@ -1629,21 +1788,22 @@ func TestSerializeDeserializeCompactionJob(t *testing.T) {
 				}},
 			},
 		},
-		isMove:             true,
-		preserveTombstones: true,
-		scheduleTime:       123456,
-		serverTime:         32476374634,
+		isMove:              true,
+		preserveTombstones:  true,
+		hasAllPartitionData: true,
+		scheduleTime:        123456,
+		serverTime:          32476374634,
 	}
 
-	buff := job1.Serialize(nil)
+	buff := job1.Serialize(nil, common.MetadataFormatV2)
 	var job2 CompactionJob
-	job2.Deserialize(buff, 0)
+	job2.Deserialize(buff, 0, common.MetadataFormatV2)
 	require.Equal(t, job1, job2)
 
 	job1.isMove = false
-	buff = job1.Serialize(nil)
+	buff = job1.Serialize(nil, common.MetadataFormatV2)
 	var job3 CompactionJob
-	job3.Deserialize(buff, 0)
+	job3.Deserialize(buff, 0, common.MetadataFormatV2)
 	require.Equal(t, job1, job3)
 }
 
@@ -1666,10 +1826,10 @@ func TestSerializeDeserializeCompactionResult(t *testing.T) {
 		},
 	}
 	var buff []byte
-	buff = res.Serialize(buff)
+	buff = res.Serialize(buff, common.MetadataFormatV2)
 
 	var res2 CompactionResult
-	res2.Deserialize(buff, 0)
+	res2.Deserialize(buff, 0, common.MetadataFormatV2)
 
 	require.Equal(t, res, res2)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/asl/errwrap"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
 	"github.com/spirit-labs/tektite/iteration"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/objstore"
@@ -42,18 +43,40 @@ type CompactionWorkerServiceConf struct {
 	WorkerCount           int
 	SSTableBucketName     string
 	SSTablePushRetryDelay time.Duration
+	DataFormat            common.DataFormat
+	TableCompression      compress.CompressionType
+	// TableBloomFilterBitsPerKey is the size of each table's bloom filter per key. Zero means no bloom filters.
+	TableBloomFilterBitsPerKey int
 }
 
 func (c *CompactionWorkerServiceConf) Validate() error {
+	if c.TableCompression != compress.CompressionTypeNone && c.DataFormat == common.DataFormatV1 {
+		return errors.Errorf("table compression %s requires data format %d", c.TableCompression, common.DataFormatV2)
+	}
+	if c.TableBloomFilterBitsPerKey < 0 {
+		return errors.Errorf("invalid table bloom filter bits per key %d", c.TableBloomFilterBitsPerKey)
+	}
+	if c.TableBloomFilterBitsPerKey > 0 && c.DataFormat == common.DataFormatV1 {
+		return errors.Errorf("table bloom filters require data format %d", common.DataFormatV2)
+	}
 	return nil
 }
 
+func (c *CompactionWorkerServiceConf) tableBuildOptions() sst.BuildOptions {
+	return sst.BuildOptions{
+		Compression:           c.TableCompression,
+		BloomFilterBitsPerKey: c.TableBloomFilterBitsPerKey,
+	}
+}
+
 func NewCompactionWorkerServiceConf() CompactionWorkerServiceConf {
 	return CompactionWorkerServiceConf{
 		WorkerCount:           4,
 		SSTableBucketName:     "tektite-data",
 		SSTablePushRetryDelay: 1 * time.Second,
 		MaxSSTableSize:        16 * 1024 * 1024,
+		DataFormat:            common.DataFormatV1,
+		TableCompression:      compress.CompressionTypeNone,
 	}
 }
 
@@ -62,6 +85,7 @@ type ControllerClientFactory func() (ControllerClient, error)
 type ControllerClient interface {
 	ApplyLsmChanges(regBatch RegistrationBatch) error
 	PollForJob() (CompactionJob, error)
+	GetPartitionRetention(partitionHash []byte) (PartitionRetention, error)
 	Close() error
 }
 
@@ -75,8 +99,8 @@ func (c *CompactionWorkerService) Start() error {
 	}
 	for i := 0; i < c.cfg.WorkerCount; i++ {
 		worker := &compactionWorker{
-			cws:            c,
-			slabRetentions: map[int]time.Duration{},
+			cws:                 c,
+			partitionRetentions: map[string]PartitionRetention{},
 		}
 		c.workers = append(c.workers, worker)
 		worker.start()
@@ -110,13 +134,13 @@ func (c *CompactionWorkerService) Stop() error {
 }
 
 type compactionWorker struct {
-	cws            *CompactionWorkerService
-	started        atomic.Bool
-	stopWg         sync.WaitGroup
-	slabRetentions map[int]time.Duration
-	controlClient  ControllerClient
-	ccLock         sync.Mutex
-	stopped        bool
+	cws                 *CompactionWorkerService
+	started             atomic.Bool
+	stopWg              sync.WaitGroup
+	partitionRetentions map[string]PartitionRetention
+	controlClient       ControllerClient
+	ccLock              sync.Mutex
+	stopped             bool
 }
 
 func (c *compactionWorker) controllerClient() (ControllerClient, error) {
@@ -150,25 +174,23 @@ func (c *compactionWorker) closeControllerClient(stop bool) {
 	}
 }
 
-func (c *compactionWorker) GetSlabRetention(slabID int) (time.Duration, error) {
-	// we cache the slab retentions
-	ret, ok := c.slabRetentions[slabID]
+func (c *compactionWorker) GetPartitionRetention(partitionHash []byte) (PartitionRetention, error) {
+	// we cache the partition retentions for the duration of a job
+	ret, ok := c.partitionRetentions[string(partitionHash)]
 	if ok {
 		return ret, nil
 	}
-	// TODO - slab retentions will come from the metaservice, exposed over the controller
-	//cl, err := c.controllerClient()
-	//if err != nil {
-	//	return 0, err
-	//}
-	//ret, err = cl.GetSlabRetention(slabID)
-	//if err != nil {
-	//	c.closeControllerClient()
-	//	return 0, err
-	//}
-	//c.slabRetentions[slabID] = ret
-	//return ret, nil
-	return 0, nil
+	cl, err := c.controllerClient()
+	if err != nil {
+		return PartitionRetention{}, err
+	}
+	ret, err = cl.GetPartitionRetention(partitionHash)
+	if err != nil {
+		c.closeControllerClient(false)
+		return PartitionRetention{}, err
+	}
+	c.partitionRetentions[string(partitionHash)] = ret
+	return ret, nil
 }
 
 func (c *compactionWorker) start() {
@@ -262,15 +284,29 @@ func (c *compactionWorker) processJob(job *CompactionJob) ([]RegistrationEntry,
 		return registrations, deRegistrations, nil
 	}
 	start := time.Now()
-	tablesInMerge := 0
-	for _, overlapping := range job.tables {
-		tablesInMerge += len(overlapping)
+	// Retentions can change between jobs so we don't cache them across jobs
+	clear(c.partitionRetentions)
+	var retProvider RetentionProvider
+	if c.cws.retentions {
+		retProvider = c
 	}
 	var maxAddedTime uint64
-	tablesToMerge := make([][]tableToMerge, len(job.tables))
-	for i, overlapping := range job.tables {
-		tables := make([]tableToMerge, len(overlapping))
-		for j, t := range overlapping {
+	tablesToMerge := make([][]tableToMerge, 0, len(job.tables))
+	for _, overlapping := range job.tables {
+		tables := make([]tableToMerge, 0, len(overlapping))
+		for _, t := range overlapping {
+			if retProvider != nil {
+				expired, err := isTableExpired(t.table, job.serverTime, retProvider)
+				if err != nil {
+					return nil, nil, err
+				}
+				if expired {
+					// Every entry in the table is past retention, so there's no need to fetch or merge it. It will
+					// still be deregistered when the job completes
+					log.Debugf("compaction job %s dropping expired table %v", job.id, t.table.SSTableID)
+					continue
+				}
+			}
 			ssTable, err := c.getSSTable(t.table.SSTableID)
 			if err != nil {
 				return nil, nil, err
@@ -279,25 +315,24 @@ func (c *compactionWorker) processJob(job *CompactionJob) ([]RegistrationEntry,
 				return nil, nil, errwrap.Errorf("cannot process compaction job as cannot find sstable: %v (%s)", t.table.SSTableID,
 					string(t.table.SSTableID))
 			}
-			tables[j] = tableToMerge{
+			tables = append(tables, tableToMerge{
 				deadVersionRanges: t.table.DeadVersionRanges,
 				sst:               ssTable,
 				id:                t.table.SSTableID,
-			}
+				addedTime:         t.table.AddedTime,
+			})
 			if t.table.AddedTime > maxAddedTime {
 				// We compute the maxAddedTime time - this is used for the AddedTime of any new sstables created.
 				maxAddedTime = t.table.AddedTime
 			}
 		}
-		tablesToMerge[i] = tables
+		if len(tables) > 0 {
+			tablesToMerge = append(tablesToMerge, tables)
+		}
 	}
 	mergeStart := time.Now()
-	var retProvider RetentionProvider
-	if c.cws.retentions {
-		retProvider = c
-	}
-	infos, err := mergeSSTables(common.DataFormatV1, tablesToMerge, job.preserveTombstones,
-		c.cws.cfg.MaxSSTableSize, job.lastFlushedVersion, job.id, retProvider, job.serverTime)
+	infos, err := mergeSSTables(c.cws.cfg.DataFormat, c.cws.cfg.tableBuildOptions(), tablesToMerge,
+		job.preserveTombstones, c.cws.cfg.MaxSSTableSize, job.lastFlushedVersion, job.id, retProvider, job.serverTime, job.hasAllPartitionData)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -337,6 +372,8 @@ func (c *compactionWorker) processJob(job *CompactionJob) ([]RegistrationEntry,
 			Size:             uint64(info.sst.SizeBytes()),
 			AddedTime:        maxAddedTime,
 			NumPrefixDeletes: info.numPrefixDeletes,
+			MaxTimestamp:     info.maxTimestamp,
+			TopicDataOnly:    info.topicDataOnly,
 		})
 		log.Debugf("compaction %s created table %v delete ratio %f preserve tombstones %t", job.id, ids[i], info.deleteRatio,
 			job.preserveTombstones)
@@ -376,6 +413,8 @@ func changesToApply(newTables []TableEntry, job *CompactionJob) ([]RegistrationE
 			TableSize:        newTable.Size,
 			AddedTime:        newTable.AddedTime,
 			NumPrefixDeletes: newTable.NumPrefixDeletes,
+			MaxTimestamp:     newTable.MaxTimestamp,
+			TopicDataOnly:    newTable.TopicDataOnly,
 		})
 	}
 	var deRegistrations []RegistrationEntry
@@ -406,16 +445,18 @@ func (c *compactionWorker) moveTables(tables []tableToCompact, fromLevel int, pr
 			// The table is just deletes, and we're moving it into the last level - we can just drop it
 		} else {
 			registrations = append(registrations, RegistrationEntry{
-				Level:       fromLevel + 1,
-				TableID:     tableToCompact.table.SSTableID,
-				KeyStart:    tableToCompact.table.RangeStart,
-				KeyEnd:      tableToCompact.table.RangeEnd,
-				MinVersion:  tableToCompact.table.MinVersion,
-				MaxVersion:  tableToCompact.table.MaxVersion,
-				DeleteRatio: tableToCompact.table.DeleteRatio,
-				NumEntries:  tableToCompact.table.NumEntries,
-				TableSize:   tableToCompact.table.Size,
-				AddedTime:   tableToCompact.table.AddedTime,
+				Level:         fromLevel + 1,
+				TableID:       tableToCompact.table.SSTableID,
+				KeyStart:      tableToCompact.table.RangeStart,
+				KeyEnd:        tableToCompact.table.RangeEnd,
+				MinVersion:    tableToCompact.table.MinVersion,
+				MaxVersion:    tableToCompact.table.MaxVersion,
+				DeleteRatio:   tableToCompact.table.DeleteRatio,
+				NumEntries:    tableToCompact.table.NumEntries,
+				TableSize:     tableToCompact.table.Size,
+				AddedTime:     tableToCompact.table.AddedTime,
+				MaxTimestamp:  tableToCompact.table.MaxTimestamp,
+				TopicDataOnly: tableToCompact.table.TopicDataOnly,
 			})
 		}
 	}
@@ -438,10 +479,12 @@ type tableToMerge struct {
 	deadVersionRanges []VersionRange
 	sst               *sst.SSTable
 	id                sst.SSTableID
+	addedTime         uint64
 }
 
-func mergeSSTables(format common.DataFormat, tables [][]tableToMerge, preserveTombstones bool, maxTableSize int,
-	lastFlushedVersion int64, jobID string, retentionProvider RetentionProvider, serverTime uint64) ([]ssTableInfo, error) {
+func mergeSSTables(format common.DataFormat, buildOpts sst.BuildOptions, tables [][]tableToMerge,
+	preserveTombstones bool, maxTableSize int, lastFlushedVersion int64, jobID string, retentionProvider RetentionProvider, serverTime uint64,
+	hasAllPartitionData bool) ([]ssTableInfo, error) {
 
 	totEntries := 0
 	chainIters := make([]iteration.Iterator, len(tables))
@@ -453,7 +496,9 @@ func mergeSSTables(format common.DataFormat, tables [][]tableToMerge, preserveTo
 				sstIter = NewRemoveDeadVersionsIterator(sstIter, table.deadVersionRanges)
 			}
 			if retentionProvider != nil {
-				sstIter = NewRemoveExpiredEntriesIterator(sstIter, table.sst.CreationTime(), serverTime, retentionProvider)
+				// Batches without a timestamp are aged from the time the table was added to the LSM, not the sstable
+				// creation time, as compaction creates new sstables containing old data
+				sstIter = NewRemoveExpiredEntriesIterator(sstIter, table.addedTime, serverTime, retentionProvider)
 			}
 			sourceIters[j] = sstIter
 
@@ -493,15 +538,32 @@ func mergeSSTables(format common.DataFormat, tables [][]tableToMerge, preserveTo
 		}
 		mergeResults = append(mergeResults, curr)
 	}
+	if retentionProvider != nil {
+		mergeResults, err = compactTopicData(mergeResults, retentionProvider, serverTime, hasAllPartitionData)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	size := 0
 	iLast := 0
 	var outTables []ssTableInfo
 	var lastKeyNoVersion []byte
+	// The max timestamp of each new sstable is computed from the batches it contains, rather than taken from the merged
+	// tables, as merged tables can contain data which has since expired
+	var maxTimestamp int64
+	topicDataOnly := true
 	for i, curr := range mergeResults {
 
 		k := curr.Key
 		size += 12 + 2*len(k) + len(curr.Value)
+		if len(k) > 16 && k[16] == common.EntryTypeTopicData {
+			if timestamp, ok := batchMaxTimestamp(curr.Value); ok && timestamp > maxTimestamp {
+				maxTimestamp = timestamp
+			}
+		} else {
+			topicDataOnly = false
+		}
 
 		isLast := i == len(mergeResults)-1
 
@@ -516,8 +578,8 @@ func mergeSSTables(format common.DataFormat, tables [][]tableToMerge, preserveTo
 
 		if size >= maxTableSize || isLast {
 			iter := common.NewKvSliceIterator(mergeResults[iLast : i+1])
-			ssTable, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTable(format, size, i+1-iLast,
-				iter)
+			ssTable, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTableWithOptions(format,
+				buildOpts, size, i+1-iLast, iter)
 			if err != nil {
 				return nil, err
 			}
@@ -529,9 +591,13 @@ func mergeSSTables(format common.DataFormat, tables [][]tableToMerge, preserveTo
 				maxVersion:       maxVersion,
 				deleteRatio:      ssTable.DeleteRatio(),
 				numPrefixDeletes: uint32(ssTable.NumPrefixDeletes()),
+				maxTimestamp:     maxTimestamp,
+				topicDataOnly:    topicDataOnly,
 			})
 			iLast = i + 1
 			size = 0
+			maxTimestamp = UnknownMaxTimestamp
+			topicDataOnly = true
 		}
 	}
 
@@ -578,6 +578,83 @@ func TestCompactionExpiredPrefix(t *testing.T) {
 	}
 }
 
+func TestCompactionPartitionRetention(t *testing.T) {
+	l0CompactionTrigger := 4
+	l1CompactionTrigger := 4
+	levelMultiplier := 10
+	lm, tearDown := setup(t, func(cfg *Conf) {
+		cfg.L0CompactionTrigger = l0CompactionTrigger
+		cfg.L1CompactionTrigger = l1CompactionTrigger
+		cfg.L0MaxTablesBeforeBlocking = 2 * l0CompactionTrigger
+		cfg.LevelMultiplier = levelMultiplier
+		cfg.RetentionCheckInterval = 100 * time.Millisecond
+	})
+	defer tearDown(t)
+
+	// Make sure all entries get compacted
+	err := lm.StoreLastFlushedVersion(math.MaxInt64)
+	require.NoError(t, err)
+
+	retention := 2 * time.Second
+	prefix1 := createTestPartitionHash(1)
+	prefix1 = append(prefix1, common.EntryTypeTopicData)
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(1)): {Retention: retention},
+	}}
+	lm.SetRetentionProvider(retentions)
+
+	numEntriesPerTable := 10
+	numLevels := 4
+	// we will generate sufficient tables to fill approx numLevels levels
+	numTables := getNumTablesToFill(numLevels, l0CompactionTrigger, l1CompactionTrigger, levelMultiplier)
+	numTables-- // subtract one as we don't want l0 to be completely full and thus trigger a compaction at the end
+	addTables := func(prefix []byte) {
+		rangeStart := 0
+		for tableCount := 0; tableCount < numTables; tableCount++ {
+			tableName := uuid.New().String()
+			rangeEnd := rangeStart + numEntriesPerTable - 1
+			smallestKey, largestKey := buildAndRegisterTableWithKeyRangeWithPrefix(t, tableName, rangeStart, rangeEnd,
+				lm.GetObjectStore(), prefix)
+			addTable(t, lm, tableName, smallestKey, largestKey)
+			rangeStart += numEntriesPerTable
+		}
+		ok, err := testutils.WaitUntilWithError(func() (bool, error) {
+			stats := lm.GetCompactionStats()
+			return stats.QueuedJobs == 0 && stats.InProgressJobs == 0, nil
+		}, 30*time.Second, 1*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	endRange := common.IncBigEndianBytes(createTestPartitionHash(1))
+	hasPrefix1Tables := func(fromLevel int) bool {
+		for level := fromLevel; level <= lm.getLastLevel(); level++ {
+			levEntry := lm.getLevelEntry(level)
+			for _, lte := range levEntry.tableEntries {
+				te := getTableEntry(lm, lte, levEntry)
+				if HasOverlap(prefix1, endRange, te.RangeStart, te.RangeEnd) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	addTables(prefix1)
+	require.True(t, hasPrefix1Tables(0))
+
+	// Wait for retention to expire - the tables in levels > 0 will be dropped by the retention check
+	testutils.WaitUntil(t, func() (bool, error) {
+		return !hasPrefix1Tables(1), nil
+	})
+
+	// Add more tables with a different prefix - this will cause L0 to be compacted, and the expired tables in L0 will be
+	// dropped by the compaction worker
+	prefix2 := createTestPartitionHash(2)
+	prefix2 = append(prefix2, common.EntryTypeTopicData)
+	addTables(prefix2)
+	require.False(t, hasPrefix1Tables(0))
+}
+
 func TestCompactionDeadVersions(t *testing.T) {
 	l0CompactionTrigger := 2
 	l1CompactionTrigger := 20
@@ -923,6 +1000,16 @@ func (c *directControllerClient) PollForJob() (CompactionJob, error) {
 	return res.job, res.err
 }
 
+func (c *directControllerClient) GetPartitionRetention(partitionHash []byte) (PartitionRetention, error) {
+	c.mgr.lock.RLock()
+	provider := c.mgr.retentionProvider
+	c.mgr.lock.RUnlock()
+	if provider == nil {
+		return PartitionRetention{}, nil
+	}
+	return provider.GetPartitionRetention(partitionHash)
+}
+
 func (c *directControllerClient) Close() error {
 	return nil
 }
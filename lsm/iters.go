@@ -1,31 +1,59 @@
 package lsm
 
 import (
+	"bytes"
 	"encoding/binary"
+	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	iteration2 "github.com/spirit-labs/tektite/iteration"
+	"github.com/spirit-labs/tektite/kafkaencoding"
 	log "github.com/spirit-labs/tektite/logger"
 	"math"
 	"time"
 )
 
-// RemoveExpiredEntriesIterator filters out any keys which have expired due to retention time being exceeded
+// RemoveExpiredEntriesIterator filters out any topic data entries which have expired due to the retention time of the
+// partition being exceeded, or which have an offset below the minimum retained offset of the partition. The age of a
+// batch is judged by its max timestamp, so it isn't reset when the table containing it is compacted. The time the table
+// was added is used for batches with no timestamp.
 type RemoveExpiredEntriesIterator struct {
 	iter              iteration2.Iterator
-	sstCreationTime   uint64
+	addedTime         uint64
 	now               uint64
 	retentionProvider RetentionProvider
+	lastPartitionHash []byte
+	lastRetention     PartitionRetention
 }
 
 type RetentionProvider interface {
-	GetSlabRetention(slabID int) (time.Duration, error)
+	GetPartitionRetention(partitionHash []byte) (PartitionRetention, error)
 }
 
-func NewRemoveExpiredEntriesIterator(iter iteration2.Iterator, sstCreationTime uint64, now uint64,
+// RangeRetentionProvider can optionally be implemented by a RetentionProvider, to allow tables containing topic data
+// for more than one partition to be dropped once all of their data is past retention.
+type RangeRetentionProvider interface {
+	// GetMaxRetentionInRange returns the longest retention of any partition with a partition hash between
+	// partitionHashStart and partitionHashEnd inclusive. A retention <= 0 means some partition in the range retains data
+	// forever.
+	GetMaxRetentionInRange(partitionHashStart []byte, partitionHashEnd []byte) (time.Duration, error)
+}
+
+// PartitionRetention describes which topic data for a partition is retained. Data older than Retention is expired, as
+// is data with an offset less than MinOffset. A Retention <= 0 means data is retained forever, and a MinOffset <= 0
+// means no data is removed based on its offset. If Compacted is true, only the latest record for each key is retained,
+// and tombstones are removed once they are older than DeleteRetention.
+type PartitionRetention struct {
+	Retention       time.Duration
+	MinOffset       int64
+	Compacted       bool
+	DeleteRetention time.Duration
+}
+
+func NewRemoveExpiredEntriesIterator(iter iteration2.Iterator, addedTime uint64, now uint64,
 	retentionProvider RetentionProvider) *RemoveExpiredEntriesIterator {
 	return &RemoveExpiredEntriesIterator{
 		iter:              iter,
-		sstCreationTime:   sstCreationTime,
+		addedTime:         addedTime,
 		now:               now,
 		retentionProvider: retentionProvider,
 	}
@@ -37,7 +65,7 @@ func (r *RemoveExpiredEntriesIterator) Next() (bool, common.KV, error) {
 		if err != nil || !valid {
 			return false, curr, err
 		}
-		expired, err := r.isExpired(curr.Key)
+		expired, err := r.isExpired(curr)
 		if err != nil {
 			return false, common.KV{}, err
 		}
@@ -59,20 +87,61 @@ func (r *RemoveExpiredEntriesIterator) Close() {
 	r.iter.Close()
 }
 
-func (r *RemoveExpiredEntriesIterator) isExpired(key []byte) (bool, error) {
-	if len(key) <= 16 {
-		log.Warnf("Got small key %v", key)
+func (r *RemoveExpiredEntriesIterator) isExpired(kv common.KV) (bool, error) {
+	key := kv.Key
+	if len(key) <= 16 || key[16] != common.EntryTypeTopicData {
+		// Only topic data is subject to retention
+		return false, nil
 	}
-	slabID := int(binary.BigEndian.Uint64(key[16:]))
-	retention, err := r.retentionProvider.GetSlabRetention(slabID)
-	if err != nil {
-		return false, err
+	partitionHash := key[:16]
+	// Keys are ordered so consecutive keys are usually for the same partition - no need to look up the retention again
+	if !bytes.Equal(partitionHash, r.lastPartitionHash) {
+		retention, err := r.retentionProvider.GetPartitionRetention(partitionHash)
+		if err != nil {
+			return false, err
+		}
+		r.lastPartitionHash = partitionHash
+		r.lastRetention = retention
 	}
-	if retention == 0 {
-		return false, nil
+	dataTime := r.addedTime
+	if timestamp, ok := batchMaxTimestamp(kv.Value); ok {
+		dataTime = uint64(timestamp)
+	}
+	if isRetentionExpired(dataTime, r.lastRetention.Retention, r.now) {
+		return true, nil
+	}
+	return isOffsetExpired(key, r.lastRetention.MinOffset), nil
+}
+
+// recordBatchHeaderSize is the size of the header of a Kafka record batch, which contains the max timestamp of the batch
+const recordBatchHeaderSize = 61
+
+// batchMaxTimestamp returns the max timestamp of the record batch in the value of a topic data entry, or false if the
+// value is not a record batch or the batch has no timestamp
+func batchMaxTimestamp(value []byte) (int64, bool) {
+	if len(value) < recordBatchHeaderSize {
+		return 0, false
+	}
+	timestamp := kafkaencoding.MaxTimestamp(value)
+	return timestamp, timestamp > 0
+}
+
+// isRetentionExpired returns true if data with a time of addedTime is past the retention at now. A retention <= 0 means
+// data is retained forever. Times are in milliseconds.
+func isRetentionExpired(addedTime uint64, retention time.Duration, now uint64) bool {
+	if retention <= 0 {
+		return false
+	}
+	return addedTime+uint64(retention.Milliseconds()) <= now
+}
+
+// isOffsetExpired returns true if the topic data key has an offset less than minOffset
+func isOffsetExpired(key []byte, minOffset int64) bool {
+	if minOffset <= 0 || len(key) < 25 {
+		return false
 	}
-	expired := r.sstCreationTime+uint64(retention.Milliseconds()) <= r.now
-	return expired, nil
+	offset, _ := encoding.KeyDecodeInt(key, 17)
+	return offset < minOffset
 }
 
 // RemoveDeadVersionsIterator filters out any dead version ranges
@@ -29,6 +29,7 @@ type Manager struct {
 	hasChanges                bool
 	enableCompaction          bool
 	validateOnEachStateChange bool
+	retentionProvider         RetentionProvider
 }
 
 type Conf struct {
@@ -42,12 +43,13 @@ type Conf struct {
 	SSTableDeleteCheckInterval time.Duration
 	CompactionPollerTimeout    time.Duration
 	CompactionJobTimeout       time.Duration
+	RetentionCheckInterval     time.Duration
 	ClusteredDataConf          cluster.ClusteredDataConf
 }
 
 func NewConf() Conf {
 	return Conf{
-		RegistryFormat:             common.MetadataFormatV1,
+		RegistryFormat:             common.MetadataFormatV2,
 		SSTableBucketName:          "tektite-data",
 		L0CompactionTrigger:        4,
 		L1CompactionTrigger:        4,
@@ -57,6 +59,7 @@ func NewConf() Conf {
 		SSTableDeleteCheckInterval: 2 * time.Second,
 		CompactionPollerTimeout:    1 * time.Second,
 		CompactionJobTimeout:       30 * time.Second,
+		RetentionCheckInterval:     1 * time.Minute,
 		ClusteredDataConf:          cluster.NewClusteredDataConf(),
 	}
 }
@@ -88,10 +91,16 @@ func (m *Manager) Start(masterRecordBytes []byte) error {
 	if len(masterRecordBytes) > 0 {
 		m.masterRecord = &MasterRecord{}
 		m.masterRecord.Deserialize(masterRecordBytes, 0)
+		if m.masterRecord.format < m.cfg.RegistryFormat {
+			log.Infof("upgrading lsm master record from format %d to format %d", m.masterRecord.format,
+				m.cfg.RegistryFormat)
+			m.masterRecord.upgradeFormat(m.cfg.RegistryFormat)
+		}
 	} else {
 		m.masterRecord = NewMasterRecord(m.cfg.RegistryFormat)
 	}
 	m.scheduleTableDeleteTimer()
+	m.scheduleRetentionCheckTimer()
 	// Maybe trigger a compaction as levels could be full
 	if err := m.maybeScheduleCompaction(); err != nil {
 		return err
@@ -107,6 +116,7 @@ func (m *Manager) Stop() error {
 		return nil
 	}
 	m.tableDeleteTimer.Stop()
+	m.retentionCheckTimer.Stop()
 	for _, inProg := range m.inProgress {
 		if inProg.timer != nil {
 			inProg.timer.Stop()
@@ -131,7 +141,8 @@ func (m *Manager) QueryTablesInRange(keyStart []byte, keyEnd []byte) (Overlappin
 		if level == 0 {
 			// Level 0 is overlapping
 			for _, table := range tables {
-				overlapping = append(overlapping, []QueryTableInfo{{ID: table.SSTableID, DeadVersions: table.DeadVersionRanges}})
+				overlapping = append(overlapping, []QueryTableInfo{{ID: table.SSTableID, DeadVersions: table.DeadVersionRanges,
+					MaxTimestamp: table.queryMaxTimestamp()}})
 			}
 		} else if tables != nil {
 			// Other levels are non overlapping
@@ -140,6 +151,7 @@ func (m *Manager) QueryTablesInRange(keyStart []byte, keyEnd []byte) (Overlappin
 				tableInfos[i] = QueryTableInfo{
 					ID:           table.SSTableID,
 					DeadVersions: table.DeadVersionRanges,
+					MaxTimestamp: table.queryMaxTimestamp(),
 				}
 			}
 			overlapping = append(overlapping, tableInfos)
@@ -148,6 +160,28 @@ func (m *Manager) QueryTablesInRange(keyStart []byte, keyEnd []byte) (Overlappin
 	return overlapping, nil
 }
 
+// HasPrefixDeletesInRange returns true if any table overlapping the range contains prefix deletes. Prefix deletes are only
+// removed when they are compacted into the last level, at which point the data they delete has been removed too.
+func (m *Manager) HasPrefixDeletesInRange(keyStart []byte, keyEnd []byte) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if !m.started {
+		return false, errors.New("not started")
+	}
+	for level, entry := range m.masterRecord.levelEntries {
+		tables, err := m.getOverlappingTables(keyStart, keyEnd, level, entry)
+		if err != nil {
+			return false, err
+		}
+		for _, table := range tables {
+			if table.NumPrefixDeletes > 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 func (m *Manager) GetTablesForHighestKeyWithPrefix(prefix []byte) ([]sst.SSTableID, error) {
 	m.lock.RLock()
 	defer m.lock.RUnlock()
@@ -546,7 +580,7 @@ func (m *Manager) maybeResizeLevelEntries(level int) {
 		newEntries := make([]*levelEntry, level+1)
 		copy(newEntries, m.masterRecord.levelEntries)
 		for j := len(m.masterRecord.levelEntries); j < level+1; j++ {
-			newEntries[j] = &levelEntry{}
+			newEntries[j] = &levelEntry{format: m.masterRecord.format}
 		}
 		m.masterRecord.levelEntries = newEntries
 	}
@@ -572,6 +606,8 @@ func (m *Manager) applyRegistrations(registrations []RegistrationEntry) error {
 			NumEntries:       registration.NumEntries,
 			Size:             registration.TableSize,
 			NumPrefixDeletes: registration.NumPrefixDeletes,
+			MaxTimestamp:     registration.MaxTimestamp,
+			TopicDataOnly:    registration.TopicDataOnly,
 		}
 		entry := m.levelEntry(registration.Level)
 		if registration.MaxVersion > entry.maxVersion {
@@ -685,6 +721,81 @@ func (m *Manager) maybeDeleteTables() {
 	m.scheduleTableDeleteTimer()
 }
 
+// SetRetentionProvider sets the provider used to look up the retention of topic partitions. When set, tables which only
+// contain expired topic data are periodically dropped from the LSM.
+func (m *Manager) SetRetentionProvider(provider RetentionProvider) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.retentionProvider = provider
+}
+
+func (m *Manager) scheduleRetentionCheckTimer() {
+	m.retentionCheckTimer = time.AfterFunc(m.cfg.RetentionCheckInterval, func() {
+		m.maybeDropExpiredTables()
+	})
+}
+
+func (m *Manager) maybeDropExpiredTables() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.started {
+		return
+	}
+	if m.retentionProvider != nil {
+		if err := m.dropExpiredTables(uint64(time.Now().UTC().UnixMilli())); err != nil {
+			log.Errorf("failed to drop expired tables: %v", err)
+		}
+	}
+	m.scheduleRetentionCheckTimer()
+}
+
+// dropExpiredTables removes any tables where every entry is past retention. Tables in the last level are only merged
+// when the level overflows, so without this, expired data there would never be removed.
+func (m *Manager) dropExpiredTables(now uint64) error {
+	var deRegistrations []RegistrationEntry
+	// We don't look at L0 - tables there are compacted frequently and usually contain data for many partitions
+	for level := 1; level < len(m.masterRecord.levelEntries); level++ {
+		levEntry := m.masterRecord.levelEntries[level]
+		for _, lte := range levEntry.tableEntries {
+			te := lte.Get(levEntry)
+			if m.isRangeLocked(lockedRange{level: level, start: te.RangeStart, end: te.RangeEnd}) {
+				// The table might be part of an in-progress compaction, which will remove the expired entries
+				continue
+			}
+			expired, err := isTableExpired(te, now, m.retentionProvider)
+			if err != nil {
+				return err
+			}
+			if expired {
+				deRegistrations = append(deRegistrations, RegistrationEntry{
+					Level:       level,
+					TableID:     te.SSTableID,
+					KeyStart:    te.RangeStart,
+					KeyEnd:      te.RangeEnd,
+					DeleteRatio: te.DeleteRatio,
+					NumEntries:  te.NumEntries,
+					TableSize:   te.Size,
+				})
+			}
+		}
+	}
+	if len(deRegistrations) == 0 {
+		return nil
+	}
+	if err := m.doApplyChanges(RegistrationBatch{DeRegistrations: deRegistrations}); err != nil {
+		return err
+	}
+	addedTime := arista.NanoTime()
+	for _, deRegistration := range deRegistrations {
+		log.Debugf("dropping expired sstable %v", deRegistration.TableID)
+		m.tablesToDelete = append(m.tablesToDelete, deleteTableEntry{
+			tableID:   deRegistration.TableID,
+			addedTime: addedTime,
+		})
+	}
+	return nil
+}
+
 func HasOverlap(keyStart []byte, keyEnd []byte, blockKeyStart []byte, blockKeyEnd []byte) bool {
 	// Note! keyStart is inclusive, keyEnd is exclusive
 	// LevelManager keyStart and keyEnd are inclusive!
@@ -8,6 +8,7 @@ import (
 	"github.com/spirit-labs/tektite/objstore/dev"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/stretchr/testify/require"
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -344,7 +345,7 @@ func TestAddRemove_L0(t *testing.T) {
 	defer tearDown(t)
 
 	mr := levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(0), mr.version)
 	require.Equal(t, 0, len(mr.levelEntries))
 
@@ -354,7 +355,7 @@ func TestAddRemove_L0(t *testing.T) {
 		12, 17, 3, 9, 1, 2, 10, 15, 4, 20, 7, 30)
 
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(1), mr.version)
 	require.Equal(t, 1, len(mr.levelEntries))
 
@@ -370,7 +371,7 @@ func TestAddRemove_L0(t *testing.T) {
 		11, 13, 3, 9, 0, 35, 7, 12)
 
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(2), mr.version)
 	require.Equal(t, 1, len(mr.levelEntries))
 
@@ -386,7 +387,7 @@ func TestAddRemove_L0(t *testing.T) {
 		15, 19, 45, 47, 12, 13, 88, 89, 45, 40)
 
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(3), mr.version)
 
 	levEntry = mr.levelEntries[0]
@@ -399,7 +400,7 @@ func TestAddRemove_L0(t *testing.T) {
 	removeTables(t, levelManager, 0, tableIDs3, 15, 19, 45, 47, 12, 13, 88, 89, 45, 40)
 
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(4), mr.version)
 
 	levEntry = mr.levelEntries[0]
@@ -411,7 +412,7 @@ func TestAddRemove_L0(t *testing.T) {
 	removeTables(t, levelManager, 0, tableIDs2, 11, 13, 3, 9, 0, 35, 7, 12)
 
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(5), mr.version)
 
 	levEntry = mr.levelEntries[0]
@@ -424,7 +425,7 @@ func TestAddRemove_L0(t *testing.T) {
 
 	// Should be all gone
 	mr = levelManager.getMasterRecord()
-	require.Equal(t, common.MetadataFormatV1, mr.format)
+	require.Equal(t, common.MetadataFormatV2, mr.format)
 	require.Equal(t, uint64(6), mr.version)
 	require.Equal(t, 0, len(mr.levelEntries[0].tableEntries))
 
@@ -479,6 +480,153 @@ func TestAddAndRemoveSameBatch(t *testing.T) {
 	afterTest(t, levelManager)
 }
 
+func TestQueryTablesReturnsMaxTimestamp(t *testing.T) {
+	levelManager, tearDown := setupLevelManager(t)
+	defer tearDown(t)
+
+	tableID1, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	tableID2, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	regBatch := RegistrationBatch{
+		Registrations: []RegistrationEntry{{
+			Level:        0,
+			TableID:      tableID1,
+			KeyStart:     createKey(1),
+			KeyEnd:       createKey(5),
+			MaxTimestamp: 12345,
+		}, {
+			Level:            0,
+			TableID:          tableID2,
+			KeyStart:         createKey(3),
+			KeyEnd:           createKey(7),
+			MaxTimestamp:     23456,
+			NumPrefixDeletes: 1,
+		}},
+	}
+	ok, err := levelManager.ApplyChanges(regBatch, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	oTabIDs, err := levelManager.QueryTablesInRange(createKey(0), createKey(1000))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(oTabIDs))
+	// Most recent first
+	require.Equal(t, sst.SSTableID(tableID2), oTabIDs[0][0].ID)
+	// Tables with prefix deletes must never be skipped when querying by timestamp
+	require.Equal(t, int64(math.MaxInt64), oTabIDs[0][0].MaxTimestamp)
+	require.Equal(t, sst.SSTableID(tableID1), oTabIDs[1][0].ID)
+	require.Equal(t, int64(12345), oTabIDs[1][0].MaxTimestamp)
+
+	afterTest(t, levelManager)
+}
+
+func TestHasPrefixDeletesInRange(t *testing.T) {
+	levelManager, tearDown := setupLevelManager(t)
+	defer tearDown(t)
+
+	tableID1, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	tableID2, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	regBatch := RegistrationBatch{
+		Registrations: []RegistrationEntry{{
+			Level:    0,
+			TableID:  tableID1,
+			KeyStart: createKey(1),
+			KeyEnd:   createKey(5),
+		}, {
+			Level:            0,
+			TableID:          tableID2,
+			KeyStart:         createKey(10),
+			KeyEnd:           createKey(15),
+			NumPrefixDeletes: 1,
+		}},
+	}
+	ok, err := levelManager.ApplyChanges(regBatch, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	has, err := levelManager.HasPrefixDeletesInRange(createKey(0), createKey(8))
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = levelManager.HasPrefixDeletesInRange(createKey(4), createKey(11))
+	require.NoError(t, err)
+	require.True(t, has)
+
+	// Once the table has been removed, there are no prefix deletes
+	ok, err = levelManager.ApplyChanges(RegistrationBatch{DeRegistrations: regBatch.Registrations[1:]}, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+	has, err = levelManager.HasPrefixDeletesInRange(createKey(4), createKey(11))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	afterTest(t, levelManager)
+}
+
+func TestUpgradeMasterRecordFormat(t *testing.T) {
+	levelManager, tearDown := setupLevelManagerWithConfigSetter(t, false, true, func(cfg *Conf) {
+		cfg.RegistryFormat = common.MetadataFormatV1
+	})
+	defer tearDown(t)
+
+	tableID1, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	regBatch := RegistrationBatch{
+		Registrations: []RegistrationEntry{{
+			Level:        0,
+			TableID:      tableID1,
+			KeyStart:     createKey(1),
+			KeyEnd:       createKey(5),
+			MaxTimestamp: 12345,
+		}},
+	}
+	ok, err := levelManager.ApplyChanges(regBatch, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+	// The max timestamp can't be stored in the old format
+	oTabIDs, err := levelManager.QueryTablesInRange(createKey(0), createKey(1000))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(oTabIDs))
+	require.Equal(t, int64(UnknownMaxTimestamp), oTabIDs[0][0].MaxTimestamp)
+
+	// Start a manager with the current format from the old master record - the master record is upgraded
+	levelManager2 := NewManager(&dev.InMemStore{}, func() {}, false, true, NewConf())
+	err = levelManager2.Start(levelManager.GetMasterRecordBytes())
+	require.NoError(t, err)
+	defer func() {
+		err := levelManager2.Stop()
+		require.NoError(t, err)
+	}()
+	require.Equal(t, common.MetadataFormatV2, levelManager2.getMasterRecord().format)
+
+	tableID2, err := uuid.New().MarshalBinary()
+	require.NoError(t, err)
+	regBatch = RegistrationBatch{
+		Registrations: []RegistrationEntry{{
+			Level:        0,
+			TableID:      tableID2,
+			KeyStart:     createKey(3),
+			KeyEnd:       createKey(7),
+			MaxTimestamp: 23456,
+		}},
+	}
+	ok, err = levelManager2.ApplyChanges(regBatch, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	oTabIDs, err = levelManager2.QueryTablesInRange(createKey(0), createKey(1000))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(oTabIDs))
+	require.Equal(t, sst.SSTableID(tableID2), oTabIDs[0][0].ID)
+	require.Equal(t, int64(23456), oTabIDs[0][0].MaxTimestamp)
+	// The table registered with the old format has an unknown max timestamp, so isn't filtered out by timestamp
+	require.Equal(t, sst.SSTableID(tableID1), oTabIDs[1][0].ID)
+	require.Equal(t, int64(UnknownMaxTimestamp), oTabIDs[1][0].MaxTimestamp)
+	require.Equal(t, 2, len(oTabIDs.FilterByMaxTimestamp(20000)))
+}
+
 func TestNilRangeStartAndEnd(t *testing.T) {
 	// Range start of nil means start at the beginning
 	testNilRangeStartAndEnd(t, nil, createKey(1000))
@@ -671,7 +819,7 @@ func setupLevelManagerWithConfigSetter(t *testing.T, enableCompaction bool, vali
 	configSetter(&cfg)
 	cloudStore := &dev.InMemStore{}
 	lm := NewManager(cloudStore, func() {}, enableCompaction, validate, cfg)
-	mr := NewMasterRecord(common.MetadataFormatV1)
+	mr := NewMasterRecord(cfg.RegistryFormat)
 	err := lm.Start(mr.Serialize(nil))
 	require.NoError(t, err)
 	return lm, func(t *testing.T) {
@@ -780,6 +928,70 @@ func TestRegisterAndGetSlabRetentions(t *testing.T) {
 	require.Equal(t, time.Duration(0), ret)
 }
 
+func TestDropExpiredTables(t *testing.T) {
+	lm, tearDown := setupLevelManager(t)
+	defer tearDown(t)
+
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(1)): {Retention: 1 * time.Hour},
+		string(createTestPartitionHash(3)): {Retention: 1 * time.Hour},
+		string(createTestPartitionHash(4)): {Retention: 1 * time.Hour},
+	}}
+	lm.SetRetentionProvider(retentions)
+
+	createRetentionKey := func(partition int, i int) []byte {
+		return encoding.EncodeVersion(createExpiredEntryKey(partition, common.EntryTypeTopicData, i), 0)
+	}
+	now := uint64(time.Now().UTC().UnixMilli())
+	expiredAddedTime := now - uint64(time.Hour.Milliseconds())
+	regEntries := []RegistrationEntry{
+		// expired
+		{Level: 1, TableID: []byte("sst1"), KeyStart: createRetentionKey(1, 0), KeyEnd: createRetentionKey(1, 9),
+			AddedTime: expiredAddedTime},
+		// not expired
+		{Level: 1, TableID: []byte("sst2"), KeyStart: createRetentionKey(1, 10), KeyEnd: createRetentionKey(1, 19),
+			AddedTime: now},
+		// spans partitions, so we can't tell whether all entries are expired
+		{Level: 1, TableID: []byte("sst3"), KeyStart: createRetentionKey(1, 20), KeyEnd: createRetentionKey(2, 0),
+			AddedTime: expiredAddedTime},
+		// partition has no retention
+		{Level: 1, TableID: []byte("sst4"), KeyStart: createRetentionKey(2, 10), KeyEnd: createRetentionKey(2, 19),
+			AddedTime: expiredAddedTime},
+		// expired, in a different level
+		{Level: 2, TableID: []byte("sst5"), KeyStart: createRetentionKey(1, 30), KeyEnd: createRetentionKey(1, 39),
+			AddedTime: expiredAddedTime},
+		// recently added, e.g. by a compaction, but the data in it is expired
+		{Level: 2, TableID: []byte("sst6"), KeyStart: createRetentionKey(1, 40), KeyEnd: createRetentionKey(1, 49),
+			AddedTime: now, MaxTimestamp: int64(expiredAddedTime)},
+		// spans partitions, but only contains topic data which is expired for all of them
+		{Level: 2, TableID: []byte("sst7"), KeyStart: createRetentionKey(3, 0), KeyEnd: createRetentionKey(4, 9),
+			AddedTime: expiredAddedTime, TopicDataOnly: true},
+	}
+	ok, err := lm.ApplyChanges(RegistrationBatch{Registrations: regEntries}, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	lm.lock.Lock()
+	err = lm.dropExpiredTables(now)
+	lm.lock.Unlock()
+	require.NoError(t, err)
+
+	var remaining []string
+	for level := 1; level <= 2; level++ {
+		levEntry := lm.getLevelEntry(level)
+		for _, lte := range levEntry.tableEntries {
+			remaining = append(remaining, string(getTableEntry(lm, lte, levEntry).SSTableID))
+		}
+	}
+	require.Equal(t, []string{"sst2", "sst3", "sst4"}, remaining)
+	var deleted []string
+	for _, entry := range lm.tablesToDelete {
+		deleted = append(deleted, string(entry.tableID))
+	}
+	require.Equal(t, []string{"sst1", "sst5", "sst6", "sst7"}, deleted)
+	require.Equal(t, 3, lm.GetStats().TotTables)
+}
+
 func TestStats(t *testing.T) {
 	lm, tearDown := setupLevelManager(t)
 	defer tearDown(t)
@@ -6,18 +6,24 @@ import (
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/sst"
+	"math"
 )
 
+// UnknownMaxTimestamp is the MaxTimestamp of tables whose max timestamp is not known, such as tables registered before
+// max timestamps were recorded. These tables must never be skipped by a query by timestamp.
+const UnknownMaxTimestamp = 0
+
 type QueryTableInfo struct {
 	ID           sst.SSTableID
 	DeadVersions []VersionRange
+	MaxTimestamp int64
 }
 
 type NonOverlappingTables []QueryTableInfo
 
 type OverlappingTables []NonOverlappingTables
 
-func (ot OverlappingTables) Serialize(bytes []byte) []byte {
+func (ot OverlappingTables) Serialize(bytes []byte, format common.MetadataFormat) []byte {
 	bytes = encoding.AppendUint32ToBufferLE(bytes, uint32(len(ot)))
 	for _, tableInfos := range ot {
 		bytes = encoding.AppendUint32ToBufferLE(bytes, uint32(len(tableInfos)))
@@ -28,12 +34,33 @@ func (ot OverlappingTables) Serialize(bytes []byte) []byte {
 			for _, dv := range tableInfo.DeadVersions {
 				bytes = dv.Serialize(bytes)
 			}
+			if format >= common.MetadataFormatV2 {
+				bytes = encoding.AppendUint64ToBufferLE(bytes, uint64(tableInfo.MaxTimestamp))
+			}
 		}
 	}
 	return bytes
 }
 
-func DeserializeOverlappingTables(bytes []byte, offset int) (OverlappingTables, int) {
+// FilterByMaxTimestamp returns only the tables which could contain data with a timestamp >= the provided timestamp.
+// Tables whose max timestamp is unknown are always returned.
+func (ot OverlappingTables) FilterByMaxTimestamp(timestamp int64) OverlappingTables {
+	var res OverlappingTables
+	for _, tableInfos := range ot {
+		var filtered NonOverlappingTables
+		for _, tableInfo := range tableInfos {
+			if tableInfo.MaxTimestamp == UnknownMaxTimestamp || tableInfo.MaxTimestamp >= timestamp {
+				filtered = append(filtered, tableInfo)
+			}
+		}
+		if len(filtered) > 0 {
+			res = append(res, filtered)
+		}
+	}
+	return res
+}
+
+func DeserializeOverlappingTables(bytes []byte, offset int, format common.MetadataFormat) (OverlappingTables, int) {
 	nn, offset := encoding.ReadUint32FromBufferLE(bytes, offset)
 	otids := make([]NonOverlappingTables, nn)
 	for i := 0; i < int(nn); i++ {
@@ -53,6 +80,11 @@ func DeserializeOverlappingTables(bytes []byte, offset int) (OverlappingTables,
 				offset = deadVersions[i].Deserialize(bytes, offset)
 			}
 			tableInfos[j].DeadVersions = deadVersions
+			if format >= common.MetadataFormatV2 {
+				var maxTimestamp uint64
+				maxTimestamp, offset = encoding.ReadUint64FromBufferLE(bytes, offset)
+				tableInfos[j].MaxTimestamp = int64(maxTimestamp)
+			}
 		}
 	}
 	return otids, offset
@@ -69,9 +101,11 @@ type RegistrationEntry struct {
 	NumEntries       uint64
 	TableSize        uint64
 	NumPrefixDeletes uint32
+	MaxTimestamp     int64
+	TopicDataOnly    bool
 }
 
-func (re *RegistrationEntry) Serialize(buff []byte) []byte {
+func (re *RegistrationEntry) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(re.Level))
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(re.TableID)))
 	buff = append(buff, re.TableID...)
@@ -86,10 +120,14 @@ func (re *RegistrationEntry) Serialize(buff []byte) []byte {
 	buff = encoding.AppendUint64ToBufferLE(buff, re.NumEntries)
 	buff = encoding.AppendUint64ToBufferLE(buff, re.TableSize)
 	buff = encoding.AppendUint32ToBufferLE(buff, re.NumPrefixDeletes)
+	if format >= common.MetadataFormatV2 {
+		buff = encoding.AppendUint64ToBufferLE(buff, uint64(re.MaxTimestamp))
+		buff = encoding.AppendBoolToBuffer(buff, re.TopicDataOnly)
+	}
 	return buff
 }
 
-func (re *RegistrationEntry) Deserialize(buff []byte, offset int) int {
+func (re *RegistrationEntry) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	var lev uint32
 	lev, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	re.Level = int(lev)
@@ -110,6 +148,12 @@ func (re *RegistrationEntry) Deserialize(buff []byte, offset int) int {
 	re.NumEntries, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	re.TableSize, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	re.NumPrefixDeletes, offset = encoding.ReadUint32FromBufferLE(buff, offset)
+	if format >= common.MetadataFormatV2 {
+		var maxTimestamp uint64
+		maxTimestamp, offset = encoding.ReadUint64FromBufferLE(buff, offset)
+		re.MaxTimestamp = int64(maxTimestamp)
+		re.TopicDataOnly, offset = encoding.ReadBoolFromBuffer(buff, offset)
+	}
 	return offset
 }
 
@@ -120,33 +164,33 @@ type RegistrationBatch struct {
 	DeRegistrations []RegistrationEntry
 }
 
-func (rb *RegistrationBatch) Serialize(buff []byte) []byte {
+func (rb *RegistrationBatch) Serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = encoding.AppendBoolToBuffer(buff, rb.Compaction)
 	buff = encoding.AppendStringToBufferLE(buff, rb.JobID)
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(rb.Registrations)))
 	for _, reg := range rb.Registrations {
-		buff = reg.Serialize(buff)
+		buff = reg.Serialize(buff, format)
 	}
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(rb.DeRegistrations)))
 	for _, dereg := range rb.DeRegistrations {
-		buff = dereg.Serialize(buff)
+		buff = dereg.Serialize(buff, format)
 	}
 	return buff
 }
 
-func (rb *RegistrationBatch) Deserialize(buff []byte, offset int) int {
+func (rb *RegistrationBatch) Deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	rb.Compaction, offset = encoding.ReadBoolFromBuffer(buff, offset)
 	rb.JobID, offset = encoding.ReadStringFromBufferLE(buff, offset)
 	var l uint32
 	l, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	rb.Registrations = make([]RegistrationEntry, l)
 	for i := 0; i < int(l); i++ {
-		offset = rb.Registrations[i].Deserialize(buff, offset)
+		offset = rb.Registrations[i].Deserialize(buff, offset, format)
 	}
 	l, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	rb.DeRegistrations = make([]RegistrationEntry, l)
 	for i := 0; i < int(l); i++ {
-		offset = rb.DeRegistrations[i].Deserialize(buff, offset)
+		offset = rb.DeRegistrations[i].Deserialize(buff, offset, format)
 	}
 	return offset
 }
@@ -162,6 +206,8 @@ type TableEntry struct {
 	NumEntries        uint64
 	Size              uint64
 	NumPrefixDeletes  uint32
+	MaxTimestamp      int64 // The maximum Kafka record timestamp of any topic data in the table, or UnknownMaxTimestamp - used for ListOffsets by timestamp and retention
+	TopicDataOnly     bool  // True if every entry in the table is topic data - used to drop tables containing more than one partition once they are past retention
 	DeadVersionRanges []VersionRange
 }
 
@@ -172,7 +218,16 @@ func (te *TableEntry) copy() *TableEntry {
 	return &cp
 }
 
-func (te *TableEntry) serialize(buff []byte) []byte {
+// queryMaxTimestamp returns the max timestamp reported for the table in query results. A table containing prefix
+// deletes must never be skipped by a query by timestamp as that could resurrect deleted data, so we report it as unbounded.
+func (te *TableEntry) queryMaxTimestamp() int64 {
+	if te.NumPrefixDeletes > 0 {
+		return math.MaxInt64
+	}
+	return te.MaxTimestamp
+}
+
+func (te *TableEntry) serialize(buff []byte, format common.MetadataFormat) []byte {
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(te.SSTableID)))
 	buff = append(buff, te.SSTableID...)
 	buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(te.RangeStart)))
@@ -186,6 +241,10 @@ func (te *TableEntry) serialize(buff []byte) []byte {
 	buff = encoding.AppendUint64ToBufferLE(buff, te.NumEntries)
 	buff = encoding.AppendUint64ToBufferLE(buff, te.Size)
 	buff = encoding.AppendUint32ToBufferLE(buff, te.NumPrefixDeletes)
+	if format >= common.MetadataFormatV2 {
+		buff = encoding.AppendUint64ToBufferLE(buff, uint64(te.MaxTimestamp))
+		buff = encoding.AppendBoolToBuffer(buff, te.TopicDataOnly)
+	}
 	// We encode DeadVersionRanges as varint to save space as most TableEntry instances won't have any DeadVersionRanges
 	ldvps := len(te.DeadVersionRanges)
 	buff = binary.AppendUvarint(buff, uint64(ldvps))
@@ -195,7 +254,7 @@ func (te *TableEntry) serialize(buff []byte) []byte {
 	return buff
 }
 
-func (te *TableEntry) deserialize(buff []byte, offset int) int {
+func (te *TableEntry) deserialize(buff []byte, offset int, format common.MetadataFormat) int {
 	var l uint32
 	l, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	te.SSTableID = buff[offset : offset+int(l)]
@@ -213,6 +272,12 @@ func (te *TableEntry) deserialize(buff []byte, offset int) int {
 	te.NumEntries, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	te.Size, offset = encoding.ReadUint64FromBufferLE(buff, offset)
 	te.NumPrefixDeletes, offset = encoding.ReadUint32FromBufferLE(buff, offset)
+	if format >= common.MetadataFormatV2 {
+		var maxTimestamp uint64
+		maxTimestamp, offset = encoding.ReadUint64FromBufferLE(buff, offset)
+		te.MaxTimestamp = int64(maxTimestamp)
+		te.TopicDataOnly, offset = encoding.ReadBoolFromBuffer(buff, offset)
+	}
 	ldvps, bytesRead := binary.Uvarint(buff[offset:])
 	offset += bytesRead
 	if ldvps > 0 {
@@ -401,7 +466,7 @@ func (mr *MasterRecord) Deserialize(buff []byte, offset int) int {
 	nl, offset = encoding.ReadUint32FromBufferLE(buff, offset)
 	mr.levelEntries = make([]*levelEntry, nl)
 	for level := 0; level < int(nl); level++ {
-		mr.levelEntries[level] = &levelEntry{}
+		mr.levelEntries[level] = &levelEntry{format: mr.format}
 		offset = mr.levelEntries[level].Deserialize(buff, offset)
 	}
 	var ncnts uint32
@@ -431,8 +496,17 @@ func (mr *MasterRecord) Deserialize(buff []byte, offset int) int {
 	return mr.stats.Deserialize(buff, offset)
 }
 
+// upgradeFormat converts the master record to a newer format, re-encoding all the table entries
+func (mr *MasterRecord) upgradeFormat(format common.MetadataFormat) {
+	for _, levEntry := range mr.levelEntries {
+		levEntry.upgradeFormat(format)
+	}
+	mr.format = format
+}
+
 type levelEntry struct {
-	maxVersion       uint64 // Note this is the max version ever stored in the level, not necessarily the current max version in the level
+	format           common.MetadataFormat // The format the table entries are encoded in
+	maxVersion       uint64                // Note this is the max version ever stored in the level, not necessarily the current max version in the level
 	rangeStart       []byte
 	rangeEnd         []byte
 	tableEntries     []levelTableEntry
@@ -444,7 +518,7 @@ type levelEntry struct {
 func (le *levelEntry) SetAt(index int, te *TableEntry) {
 	prevLength := le.tableEntries[index].length
 	pos := len(le.addedTablesBuff)
-	le.addedTablesBuff = te.serialize(le.addedTablesBuff)
+	le.addedTablesBuff = te.serialize(le.addedTablesBuff, le.format)
 	length := uint32(len(le.addedTablesBuff) - pos)
 	lte := levelTableEntry{
 		pos:    pos + len(le.tableEntriesBuff),
@@ -456,7 +530,7 @@ func (le *levelEntry) SetAt(index int, te *TableEntry) {
 
 func (le *levelEntry) InsertAt(index int, te *TableEntry) {
 	pos := len(le.addedTablesBuff)
-	le.addedTablesBuff = te.serialize(le.addedTablesBuff)
+	le.addedTablesBuff = te.serialize(le.addedTablesBuff, le.format)
 	length := uint32(len(le.addedTablesBuff) - pos)
 	// When adding new entries we set pos to be pos in the addedTablesBuff + length of the existing tableEntriesBuff
 	// this allows us to distinguish between newly inserted/set entries and ones that are backed by the existing buffer
@@ -469,6 +543,21 @@ func (le *levelEntry) InsertAt(index int, te *TableEntry) {
 	le.totEntrySizes += int(length)
 }
 
+func (le *levelEntry) upgradeFormat(format common.MetadataFormat) {
+	tableEntries := make([]*TableEntry, len(le.tableEntries))
+	for i, lte := range le.tableEntries {
+		tableEntries[i] = lte.Get(le)
+	}
+	le.format = format
+	le.tableEntries = make([]levelTableEntry, 0, len(tableEntries))
+	le.tableEntriesBuff = nil
+	le.addedTablesBuff = nil
+	le.totEntrySizes = 0
+	for i, te := range tableEntries {
+		le.InsertAt(i, te)
+	}
+}
+
 func (le *levelEntry) RemoveAt(index int) {
 	prevLength := le.tableEntries[index].length
 	le.tableEntries = append(le.tableEntries[:index], le.tableEntries[index+1:]...)
@@ -656,7 +745,7 @@ func (lte *levelTableEntry) Get(levEntry *levelEntry) *TableEntry {
 		offset = lte.pos
 	}
 	te := &TableEntry{}
-	te.deserialize(buff, offset)
+	te.deserialize(buff, offset, levEntry.format)
 	return te
 }
 
@@ -3,6 +3,7 @@ package lsm
 import (
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/stretchr/testify/require"
 	"math/rand"
@@ -35,6 +36,7 @@ func TestSerializeDeserializeOverlappingTableIDs(t *testing.T) {
 			notOverlapping = append(notOverlapping, QueryTableInfo{
 				ID:           bytes,
 				DeadVersions: dvs,
+				MaxTimestamp: int64(1000*i + j),
 			})
 		}
 		overlapping = append(overlapping, notOverlapping)
@@ -43,30 +45,94 @@ func TestSerializeDeserializeOverlappingTableIDs(t *testing.T) {
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
 	require.NotNil(t, overlapping)
-	buff = overlapping.Serialize(buff)
-	overlappingAfter, _ := DeserializeOverlappingTables(buff, 3)
+	buff = overlapping.Serialize(buff, common.MetadataFormatV2)
+	overlappingAfter, _ := DeserializeOverlappingTables(buff, 3, common.MetadataFormatV2)
 	require.Equal(t, overlapping, overlappingAfter)
 }
 
+func TestFilterOverlappingTablesByMaxTimestamp(t *testing.T) {
+	overlapping := OverlappingTables{
+		{{ID: sst.SSTableID("t1"), MaxTimestamp: 1000}},
+		{{ID: sst.SSTableID("t2"), MaxTimestamp: 3000}},
+		{{ID: sst.SSTableID("t3"), MaxTimestamp: 1000}, {ID: sst.SSTableID("t4"), MaxTimestamp: 2000},
+			{ID: sst.SSTableID("t5"), MaxTimestamp: 1500}},
+		{{ID: sst.SSTableID("t6"), MaxTimestamp: 500}, {ID: sst.SSTableID("t7"), MaxTimestamp: 999}},
+	}
+	filtered := overlapping.FilterByMaxTimestamp(1500)
+	require.Equal(t, OverlappingTables{
+		{{ID: sst.SSTableID("t2"), MaxTimestamp: 3000}},
+		{{ID: sst.SSTableID("t4"), MaxTimestamp: 2000}, {ID: sst.SSTableID("t5"), MaxTimestamp: 1500}},
+	}, filtered)
+	require.Equal(t, 0, len(overlapping.FilterByMaxTimestamp(3001)))
+
+	// Tables whose max timestamp is unknown are never filtered out
+	overlapping = append(overlapping, NonOverlappingTables{{ID: sst.SSTableID("t8"),
+		MaxTimestamp: UnknownMaxTimestamp}})
+	require.Equal(t, OverlappingTables{{{ID: sst.SSTableID("t8")}}}, overlapping.FilterByMaxTimestamp(3001))
+}
+
 func TestSerializeDeserializeRegistrationEntry(t *testing.T) {
 	regEntry := &RegistrationEntry{
-		Level:       23,
-		TableID:     sst.SSTableID("sometableid"),
-		KeyStart:    []byte("keystart"),
-		KeyEnd:      []byte("keyend"),
-		MinVersion:  2536353,
-		MaxVersion:  2353653,
-		DeleteRatio: 0.25,
-		AddedTime:   12345,
+		Level:         23,
+		TableID:       sst.SSTableID("sometableid"),
+		KeyStart:      []byte("keystart"),
+		KeyEnd:        []byte("keyend"),
+		MinVersion:    2536353,
+		MaxVersion:    2353653,
+		DeleteRatio:   0.25,
+		AddedTime:     12345,
+		MaxTimestamp:  1724237292000,
+		TopicDataOnly: true,
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = regEntry.Serialize(buff)
+	buff = regEntry.Serialize(buff, common.MetadataFormatV2)
 
 	regEntryAfter := &RegistrationEntry{}
-	regEntryAfter.Deserialize(buff, 3)
+	regEntryAfter.Deserialize(buff, 3, common.MetadataFormatV2)
+
+	require.Equal(t, regEntry, regEntryAfter)
+}
 
+func TestSerializeDeserializeMetadataFormatV1(t *testing.T) {
+	// The max timestamp is not encoded in MetadataFormatV1, so it is unknown after deserializing
+	regEntry := &RegistrationEntry{
+		Level:        23,
+		TableID:      sst.SSTableID("sometableid"),
+		KeyStart:     []byte("keystart"),
+		KeyEnd:       []byte("keyend"),
+		MaxVersion:   2353653,
+		MaxTimestamp: 1724237292000,
+	}
+	buff := regEntry.Serialize(nil, common.MetadataFormatV1)
+	regEntryAfter := &RegistrationEntry{}
+	off := regEntryAfter.Deserialize(buff, 0, common.MetadataFormatV1)
+	require.Equal(t, len(buff), off)
+	regEntry.MaxTimestamp = UnknownMaxTimestamp
 	require.Equal(t, regEntry, regEntryAfter)
+
+	te := &TableEntry{
+		SSTableID:         []byte("sstableid1"),
+		RangeStart:        []byte("rangestart1"),
+		RangeEnd:          []byte("rangeend1"),
+		MaxVersion:        7654321,
+		MaxTimestamp:      1724237292000,
+		DeadVersionRanges: []VersionRange{{VersionStart: 111, VersionEnd: 222}},
+	}
+	buff = te.serialize(nil, common.MetadataFormatV1)
+	teAfter := &TableEntry{}
+	off = teAfter.deserialize(buff, 0, common.MetadataFormatV1)
+	require.Equal(t, len(buff), off)
+	te.MaxTimestamp = UnknownMaxTimestamp
+	require.Equal(t, te, teAfter)
+
+	overlapping := OverlappingTables{{{ID: sst.SSTableID("t1"), DeadVersions: []VersionRange{},
+		MaxTimestamp: 1724237292000}}}
+	buff = overlapping.Serialize(nil, common.MetadataFormatV1)
+	overlappingAfter, off := DeserializeOverlappingTables(buff, 0, common.MetadataFormatV1)
+	require.Equal(t, len(buff), off)
+	overlapping[0][0].MaxTimestamp = UnknownMaxTimestamp
+	require.Equal(t, overlapping, overlappingAfter)
 }
 
 func TestSerializeDeserializeRegistrationBatch(t *testing.T) {
@@ -100,10 +166,10 @@ func TestSerializeDeserializeRegistrationBatch(t *testing.T) {
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = regBatch.Serialize(buff)
+	buff = regBatch.Serialize(buff, common.MetadataFormatV2)
 
 	regBatchAfter := &RegistrationBatch{}
-	regBatchAfter.Deserialize(buff, 3)
+	regBatchAfter.Deserialize(buff, 3, common.MetadataFormatV2)
 
 	require.Equal(t, regBatch, regBatchAfter)
 }
@@ -120,13 +186,14 @@ func TestSerializeDeserializeTableEntry(t *testing.T) {
 		NumEntries:       47464,
 		Size:             696686,
 		NumPrefixDeletes: 36363,
+		MaxTimestamp:     1724237292000,
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = te.serialize(buff)
+	buff = te.serialize(buff, common.MetadataFormatV2)
 
 	teAfter := &TableEntry{}
-	teAfter.deserialize(buff, 3)
+	teAfter.deserialize(buff, 3, common.MetadataFormatV2)
 
 	require.Equal(t, te, teAfter)
 }
@@ -147,10 +214,10 @@ func TestSerializeDeserializeTableEntryWithDeadVersionRanges(t *testing.T) {
 	}
 	var buff []byte
 	buff = append(buff, 1, 2, 3)
-	buff = te.serialize(buff)
+	buff = te.serialize(buff, common.MetadataFormatV2)
 
 	teAfter := &TableEntry{}
-	teAfter.deserialize(buff, 3)
+	teAfter.deserialize(buff, 3, common.MetadataFormatV2)
 
 	require.Equal(t, te, teAfter)
 }
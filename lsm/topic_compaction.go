@@ -0,0 +1,169 @@
+package lsm
+
+import (
+	"bytes"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	log "github.com/spirit-labs/tektite/logger"
+	"time"
+)
+
+/*
+compactTopicData implements key based compaction for compacted topics. Topic data is stored as one entry per record
+batch, keyed by partition hash and offset, so the LSM cannot remove superseded records itself. Instead, for each
+compacted partition in the merge results, we find the latest offset of each record key and rewrite the batches so that
+only the latest record for each key is retained.
+
+Batches are rewritten in place - the base offset, last offset delta and timestamps of the batch are not changed, so the
+retained records keep their offsets and consumers move past records which have been removed. Batches which no longer
+contain any records are removed, unless they were written by an idempotent producer, in which case they are kept empty
+so the producer's sequence can still be recovered from the last batch it wrote.
+
+Tombstones (records with a null value) are removed once they are older than the partition's delete retention, but only
+if hasAllPartitionData is true, i.e. the job contains all the data for the partitions in its range. Otherwise, there could
+be an older record for the key outside the job which would be exposed by removing the tombstone.
+
+Compressed batches are decompressed to read their records, and are compressed again with the same codec if they are
+rewritten. Transactional and control batches are not rewritten and are not used to determine the latest records.
+*/
+func compactTopicData(kvs []common.KV, retentionProvider RetentionProvider, serverTime uint64,
+	hasAllPartitionData bool) ([]common.KV, error) {
+	var result []common.KV
+	start := 0
+	for start < len(kvs) {
+		partitionHash, ok := topicDataPartitionHash(kvs[start].Key)
+		if !ok {
+			if result != nil {
+				result = append(result, kvs[start])
+			}
+			start++
+			continue
+		}
+		// Find the end of the data for the partition
+		end := start + 1
+		for end < len(kvs) {
+			hash, ok := topicDataPartitionHash(kvs[end].Key)
+			if !ok || !bytes.Equal(hash, partitionHash) {
+				break
+			}
+			end++
+		}
+		retention, err := retentionProvider.GetPartitionRetention(partitionHash)
+		if err != nil {
+			return nil, err
+		}
+		if !retention.Compacted {
+			if result != nil {
+				result = append(result, kvs[start:end]...)
+			}
+			start = end
+			continue
+		}
+		if result == nil {
+			// Only copy the results once we know there's a compacted partition
+			result = make([]common.KV, 0, len(kvs))
+			result = append(result, kvs[:start]...)
+		}
+		result = compactPartitionBatches(result, kvs[start:end], retention.DeleteRetention, serverTime,
+			hasAllPartitionData)
+		start = end
+	}
+	if result == nil {
+		return kvs, nil
+	}
+	return result, nil
+}
+
+func compactPartitionBatches(result []common.KV, kvs []common.KV, deleteRetention time.Duration, serverTime uint64,
+	removeTombstones bool) []common.KV {
+	// First find the latest offset of each key
+	batches := make([][]byte, len(kvs))
+	codecs := make([]int16, len(kvs))
+	latestOffsets := map[string]int64{}
+	for i, kv := range kvs {
+		batch, codec, ok := compactableBatch(kv.Value)
+		if !ok {
+			continue
+		}
+		batches[i] = batch
+		codecs[i] = codec
+		baseOffset := kafkaencoding.BaseOffset(batch)
+		for _, record := range kafkaencoding.ReadBatchRecords(batch) {
+			if record.Key != nil {
+				latestOffsets[string(record.Key)] = baseOffset + record.OffsetDelta
+			}
+		}
+	}
+	// Then rewrite each batch retaining only the latest records
+	for i, kv := range kvs {
+		batch := batches[i]
+		if batch == nil {
+			result = append(result, kv)
+			continue
+		}
+		baseOffset := kafkaencoding.BaseOffset(batch)
+		tombstoneExpired := removeTombstones &&
+			int64(serverTime)-kafkaencoding.MaxTimestamp(batch) >= deleteRetention.Milliseconds()
+		records := kafkaencoding.ReadBatchRecords(batch)
+		retained := make([]kafkaencoding.BatchRecord, 0, len(records))
+		for _, record := range records {
+			if record.Key != nil {
+				if latestOffsets[string(record.Key)] != baseOffset+record.OffsetDelta {
+					// Superseded by a later record with the same key
+					continue
+				}
+				if record.Value == nil && tombstoneExpired {
+					continue
+				}
+			}
+			retained = append(retained, record)
+		}
+		if len(retained) == len(records) {
+			result = append(result, kv)
+			continue
+		}
+		if len(retained) == 0 && kafkaencoding.ProducerID(batch) == -1 {
+			continue
+		}
+		value := kafkaencoding.RewriteBatch(batch, retained)
+		if codecs[i] != 0 {
+			var err error
+			value, err = kafkaencoding.CompressBatch(value, codecs[i])
+			if err != nil {
+				log.Warnf("failed to compress compacted record batch, it will not be compacted: %v", err)
+				result = append(result, kv)
+				continue
+			}
+		}
+		result = append(result, common.KV{
+			Key:   kv.Key,
+			Value: value,
+		})
+	}
+	return result
+}
+
+// compactableBatch returns the batch with its records uncompressed, and the compression codec of the batch, or false if
+// the batch cannot be compacted
+func compactableBatch(batch []byte) ([]byte, int16, bool) {
+	// An empty value is a tombstone in the LSM
+	if len(batch) == 0 || kafkaencoding.IsTransactional(batch) || kafkaencoding.IsControlBatch(batch) {
+		return nil, 0, false
+	}
+	if !kafkaencoding.IsCompressed(batch) {
+		return batch, 0, true
+	}
+	decompressed, codec, err := kafkaencoding.DecompressBatch(batch)
+	if err != nil {
+		log.Warnf("failed to decompress record batch, it will not be compacted: %v", err)
+		return nil, 0, false
+	}
+	return decompressed, codec, true
+}
+
+func topicDataPartitionHash(key []byte) ([]byte, bool) {
+	if len(key) <= 16 || key[16] != common.EntryTypeTopicData {
+		return nil, false
+	}
+	return key[:16], true
+}
@@ -0,0 +1,275 @@
+package lsm
+
+import (
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/sst"
+	"github.com/stretchr/testify/require"
+	"hash/crc32"
+	"math"
+	"testing"
+	"time"
+)
+
+type testRecord struct {
+	key   []byte
+	value []byte
+}
+
+func record(key string, value string) testRecord {
+	return testRecord{key: []byte(key), value: []byte(value)}
+}
+
+func tombstone(key string) testRecord {
+	return testRecord{key: []byte(key)}
+}
+
+func createTestBatch(baseOffset int64, producerID int64, timestamp int64, records ...testRecord) []byte {
+	batch := make([]byte, 61)
+	for i, rec := range records {
+		var body []byte
+		body = append(body, 0)                     // attributes
+		body = binary.AppendVarint(body, 0)        // timestampDelta
+		body = binary.AppendVarint(body, int64(i)) // offsetDelta
+		body = binary.AppendVarint(body, int64(len(rec.key)))
+		body = append(body, rec.key...)
+		if rec.value == nil {
+			body = binary.AppendVarint(body, -1)
+		} else {
+			body = binary.AppendVarint(body, int64(len(rec.value)))
+			body = append(body, rec.value...)
+		}
+		body = binary.AppendVarint(body, 0) // headers
+		batch = binary.AppendVarint(batch, int64(len(body)))
+		batch = append(batch, body...)
+	}
+	binary.BigEndian.PutUint64(batch, uint64(baseOffset))
+	binary.BigEndian.PutUint32(batch[8:], uint32(len(batch)-12))
+	batch[16] = 2
+	binary.BigEndian.PutUint32(batch[23:], uint32(len(records)-1))
+	binary.BigEndian.PutUint64(batch[27:], uint64(timestamp))
+	binary.BigEndian.PutUint64(batch[35:], uint64(timestamp))
+	binary.BigEndian.PutUint64(batch[43:], uint64(producerID))
+	binary.BigEndian.PutUint32(batch[57:], uint32(len(records)))
+	binary.BigEndian.PutUint32(batch[17:], crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)))
+	return batch
+}
+
+func createBatchKV(partition int, batch []byte) common.KV {
+	return common.KV{
+		Key:   createOffsetEntryKey(partition, kafkaencoding.BaseOffset(batch)),
+		Value: batch,
+	}
+}
+
+type retainedRecord struct {
+	offset int64
+	key    string
+	value  []byte
+}
+
+func requireBatch(t *testing.T, kv common.KV, baseOffset int64, lastOffsetDelta int32, expected ...retainedRecord) {
+	require.Equal(t, baseOffset, kafkaencoding.BaseOffset(kv.Value))
+	require.Equal(t, lastOffsetDelta, kafkaencoding.LastOffsetDelta(kv.Value))
+	require.Equal(t, len(expected), kafkaencoding.NumRecords(kv.Value))
+	require.Equal(t, len(kv.Value)-12, int(binary.BigEndian.Uint32(kv.Value[8:])))
+	require.Equal(t, crc32.Checksum(kv.Value[21:], crc32.MakeTable(crc32.Castagnoli)),
+		binary.BigEndian.Uint32(kv.Value[17:]))
+	records := kafkaencoding.ReadBatchRecords(kv.Value)
+	require.Equal(t, len(expected), len(records))
+	for i, rec := range records {
+		require.Equal(t, expected[i].offset, baseOffset+rec.OffsetDelta)
+		require.Equal(t, expected[i].key, string(rec.Key))
+		require.Equal(t, expected[i].value, rec.Value)
+	}
+}
+
+func TestCompactTopicData(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Compacted: true, DeleteRetention: 1 * time.Hour},
+	}}
+	now := time.Now().UTC().UnixMilli()
+	kvs := []common.KV{
+		createBatchKV(0, createTestBatch(0, -1, now, record("k1", "v1"), record("k2", "v2"), record("k3", "v3"))),
+		// Superseded entirely, so removed
+		createBatchKV(0, createTestBatch(3, -1, now, record("k1", "v4"), record("k1", "v5"))),
+		// Superseded entirely, but kept empty as written by an idempotent producer
+		createBatchKV(0, createTestBatch(5, 1000, now, record("k2", "v6"))),
+		createBatchKV(0, createTestBatch(6, -1, now, record("k1", "v7"), tombstone("k2"), record("k4", "v8"))),
+		// Transactional batches are not compacted
+		createBatchKV(0, createTransactionalBatch(createTestBatch(9, 1000, now, record("k4", "v9"), record("k4", "v10")))),
+		// Partition 1 is not compacted
+		createBatchKV(1, createTestBatch(0, -1, now, record("k1", "v1"), record("k1", "v2"))),
+	}
+	compacted, err := compactTopicData(kvs, retentions, uint64(now), true)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(compacted))
+	requireBatch(t, compacted[0], 0, 2, retainedRecord{offset: 2, key: "k3", value: []byte("v3")})
+	requireBatch(t, compacted[1], 5, 0)
+	requireBatch(t, compacted[2], 6, 2,
+		retainedRecord{offset: 6, key: "k1", value: []byte("v7")},
+		retainedRecord{offset: 7, key: "k2"},
+		retainedRecord{offset: 8, key: "k4", value: []byte("v8")})
+	require.Equal(t, kvs[4], compacted[3])
+	require.Equal(t, kvs[5], compacted[4])
+
+	// Tombstones are removed once past delete retention
+	compacted, err = compactTopicData(compacted, retentions, uint64(now+time.Hour.Milliseconds()), true)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(compacted))
+	requireBatch(t, compacted[2], 6, 2,
+		retainedRecord{offset: 6, key: "k1", value: []byte("v7")},
+		retainedRecord{offset: 8, key: "k4", value: []byte("v8")})
+}
+
+func TestCompactTopicDataCompressed(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Compacted: true, DeleteRetention: 1 * time.Hour},
+	}}
+	now := time.Now().UTC().UnixMilli()
+	// gzip, snappy, lz4 and zstd
+	for codec := int16(1); codec <= 4; codec++ {
+		compressed, err := kafkaencoding.CompressBatch(
+			createTestBatch(0, -1, now, record("k1", "v1"), record("k2", "v2"), record("k3", "v3")), codec)
+		require.NoError(t, err)
+		require.True(t, kafkaencoding.IsCompressed(compressed))
+		// Not compressed correctly, so can't be compacted
+		corrupt := createTestBatch(5, -1, now, record("k3", "v6"))
+		binary.BigEndian.PutUint16(corrupt[21:], uint16(codec))
+		kvs := []common.KV{
+			createBatchKV(0, compressed),
+			// Supersedes records in the compressed batch
+			createBatchKV(0, createTestBatch(3, -1, now, record("k1", "v4"), record("k2", "v5"))),
+			createBatchKV(0, corrupt),
+		}
+		compacted, err := compactTopicData(kvs, retentions, uint64(now), true)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(compacted))
+		// The rewritten batch is compressed with the same codec
+		require.Equal(t, codec, kafkaencoding.Attributes(compacted[0].Value)&0x07)
+		require.Equal(t, len(compacted[0].Value)-12, int(binary.BigEndian.Uint32(compacted[0].Value[8:])))
+		require.Equal(t, crc32.Checksum(compacted[0].Value[21:], crc32.MakeTable(crc32.Castagnoli)),
+			binary.BigEndian.Uint32(compacted[0].Value[17:]))
+		decompressed, decompressedCodec, err := kafkaencoding.DecompressBatch(compacted[0].Value)
+		require.NoError(t, err)
+		require.Equal(t, codec, decompressedCodec)
+		requireBatch(t, common.KV{Key: compacted[0].Key, Value: decompressed}, 0, 2,
+			retainedRecord{offset: 2, key: "k3", value: []byte("v3")})
+		require.Equal(t, kvs[1], compacted[1])
+		require.Equal(t, kvs[2], compacted[2])
+	}
+}
+
+func TestCompactTopicDataTombstonesRetained(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Compacted: true, DeleteRetention: 1 * time.Hour},
+	}}
+	now := time.Now().UTC().UnixMilli()
+	kvs := []common.KV{
+		createBatchKV(0, createTestBatch(0, -1, now, record("k1", "v1"), tombstone("k2"))),
+	}
+	// Past delete retention, but the job does not contain all the data for the partition, so the tombstone could be
+	// hiding older data for the key
+	compacted, err := compactTopicData(kvs, retentions, uint64(now+time.Hour.Milliseconds()), false)
+	require.NoError(t, err)
+	require.Equal(t, kvs, compacted)
+}
+
+func TestMergeSSTablesCompactsTopicData(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Compacted: true, DeleteRetention: 1 * time.Hour},
+	}}
+	now := time.Now().UTC().UnixMilli()
+	// Newer data in the first table
+	sst1 := buildTopicDataSSTable(t, createBatchKV(0, createTestBatch(2, -1, now, record("k1", "v3"))))
+	sst2 := buildTopicDataSSTable(t, createBatchKV(0, createTestBatch(0, -1, now, record("k1", "v1"),
+		record("k2", "v2"))))
+	res, err := mergeSSTables(common.DataFormatV1, sst.BuildOptions{}, [][]tableToMerge{{{sst: sst1}}, {{sst: sst2}}}, true,
+		math.MaxInt, -1, "", retentions, uint64(now), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(res))
+	iter, err := res[0].sst.NewIterator(nil, nil)
+	require.NoError(t, err)
+	ok, kv, err := iter.Next()
+	require.NoError(t, err)
+	require.True(t, ok)
+	requireBatch(t, kv, 0, 1, retainedRecord{offset: 1, key: "k2", value: []byte("v2")})
+	ok, kv, err = iter.Next()
+	require.NoError(t, err)
+	require.True(t, ok)
+	requireBatch(t, kv, 2, 0, retainedRecord{offset: 2, key: "k1", value: []byte("v3")})
+	ok, _, err = iter.Next()
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIsTableCompacted(t *testing.T) {
+	retentions := &testPartitionRetentions{retentions: map[string]PartitionRetention{
+		string(createTestPartitionHash(0)): {Compacted: true},
+		string(createTestPartitionHash(1)): {Compacted: true},
+	}}
+	testCases := []struct {
+		name       string
+		rangeStart []byte
+		rangeEnd   []byte
+		compacted  bool
+	}{
+		{name: "compacted", rangeStart: createOffsetEntryKey(0, 0), rangeEnd: createOffsetEntryKey(0, 100),
+			compacted: true},
+		{name: "not compacted", rangeStart: createOffsetEntryKey(2, 0), rangeEnd: createOffsetEntryKey(2, 100),
+			compacted: false},
+		{name: "multiple partitions", rangeStart: createOffsetEntryKey(0, 0), rangeEnd: createOffsetEntryKey(1, 100),
+			compacted: false},
+	}
+	for _, tc := range testCases {
+		te := &TableEntry{
+			RangeStart: tc.rangeStart,
+			RangeEnd:   tc.rangeEnd,
+		}
+		compacted, err := isTableCompacted(te, retentions)
+		require.NoError(t, err)
+		require.Equal(t, tc.compacted, compacted, tc.name)
+	}
+}
+
+func createTransactionalBatch(batch []byte) []byte {
+	binary.BigEndian.PutUint16(batch[21:], 1<<4)
+	binary.BigEndian.PutUint32(batch[17:], crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)))
+	return batch
+}
+
+func buildTopicDataSSTable(t *testing.T, kvs ...common.KV) *sst.SSTable {
+	table, _, _, _, _, err := sst.BuildSSTable(common.DataFormatV1, 0, len(kvs), common.NewKvSliceIterator(kvs))
+	require.NoError(t, err)
+	return table
+}
+
+func TestHasAllPartitionData(t *testing.T) {
+	lm, tearDown := setupLevelManager(t)
+	defer tearDown(t)
+	tableA := TableEntry{SSTableID: []byte("sst-a"), RangeStart: createOffsetEntryKey(0, 0),
+		RangeEnd: createOffsetEntryKey(0, 10)}
+	tableB := TableEntry{SSTableID: []byte("sst-b"), RangeStart: createOffsetEntryKey(0, 20),
+		RangeEnd: createOffsetEntryKey(0, 30)}
+	tableC := TableEntry{SSTableID: []byte("sst-c"), RangeStart: createOffsetEntryKey(1, 0),
+		RangeEnd: createOffsetEntryKey(1, 10)}
+	populateLevel(t, lm, 1, tableA, tableB)
+	populateLevel(t, lm, 2, tableC)
+
+	hasAll := func(tables ...TableEntry) bool {
+		var toCompact []tableToCompact
+		for i := range tables {
+			toCompact = append(toCompact, tableToCompact{table: &tables[i]})
+		}
+		rangeStart, rangeEnd := lm.calculateOverallRange([]*TableEntry{toCompact[0].table,
+			toCompact[len(toCompact)-1].table})
+		res, err := lm.hasAllPartitionData([][]tableToCompact{toCompact}, rangeStart, rangeEnd)
+		require.NoError(t, err)
+		return res
+	}
+	// Table B has later data for the partition
+	require.False(t, hasAll(tableA))
+	require.True(t, hasAll(tableA, tableB))
+	require.True(t, hasAll(tableC))
+}
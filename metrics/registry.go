@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+)
+
+const namespace = "tektite"
+
+var (
+	// LatencyBuckets are histogram buckets in seconds from 0.5ms to ~16s
+	LatencyBuckets = prometheus.ExponentialBuckets(0.0005, 2, 16)
+	// SizeBuckets are histogram buckets in bytes from 1KiB to 256MiB
+	SizeBuckets = prometheus.ExponentialBuckets(1024, 4, 10)
+)
+
+/*
+Registry holds the metrics for an agent. Components create their metrics when they are constructed, using the functions
+in this package, and register them with the agent's registry. Metrics are exposed in the Prometheus text format by the
+metrics Server.
+*/
+type Registry struct {
+	registry *prometheus.Registry
+}
+
+func NewRegistry() *Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return &Registry{registry: registry}
+}
+
+// MustRegister registers the collectors, panicking if any have already been registered
+func (r *Registry) MustRegister(cs ...prometheus.Collector) {
+	r.registry.MustRegister(cs...)
+}
+
+func (r *Registry) Gather() ([]*dto.MetricFamily, error) {
+	return r.registry.Gather()
+}
+
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
+
+func NewCounter(subsystem string, name string, help string) prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	})
+}
+
+func NewCounterVec(subsystem string, name string, help string, labelNames ...string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, labelNames)
+}
+
+// NewCounterFunc creates a counter whose value is obtained by calling f when metrics are gathered
+func NewCounterFunc(subsystem string, name string, help string, f func() float64) prometheus.CounterFunc {
+	return prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, f)
+}
+
+// NewGaugeFunc creates a gauge whose value is obtained by calling f when metrics are gathered
+func NewGaugeFunc(subsystem string, name string, help string, f func() float64) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, f)
+}
+
+func NewHistogram(subsystem string, name string, help string, buckets []float64) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	})
+}
+
+func NewHistogramVec(subsystem string, name string, help string, buckets []float64,
+	labelNames ...string) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+		Buckets:   buckets,
+	}, labelNames)
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/common"
+	log "github.com/spirit-labs/tektite/logger"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Conf struct {
+	Enabled       bool
+	ListenAddress string
+	Path          string
+}
+
+func NewConf() Conf {
+	return Conf{
+		Path: DefaultPath,
+	}
+}
+
+func (c *Conf) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.ListenAddress == "" {
+		return errors.New("metrics listen address must be specified when metrics are enabled")
+	}
+	if len(c.Path) == 0 || c.Path[0] != '/' {
+		return errors.Errorf("invalid metrics path %s, must start with '/'", c.Path)
+	}
+	return nil
+}
+
+const DefaultPath = "/metrics"
+
+// Server serves the metrics in a Registry over HTTP so they can be scraped by Prometheus
+type Server struct {
+	lock       sync.Mutex
+	cfg        Conf
+	registry   *Registry
+	httpServer *http.Server
+	listener   net.Listener
+	closeWg    sync.WaitGroup
+	started    bool
+}
+
+func NewServer(cfg Conf, registry *Registry) *Server {
+	return &Server{
+		cfg:      cfg,
+		registry: registry,
+	}
+}
+
+func (s *Server) Start() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle(s.cfg.Path, s.registry.Handler())
+	s.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	listener, err := common.Listen("tcp", s.cfg.ListenAddress)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.closeWg = sync.WaitGroup{}
+	s.closeWg.Add(1)
+	common.Go(func() {
+		defer s.closeWg.Done()
+		if err := s.httpServer.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("metrics server failed: %v", err)
+		}
+	})
+	s.started = true
+	return nil
+}
+
+func (s *Server) Stop() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.started {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return err
+	}
+	s.closeWg.Wait()
+	s.started = false
+	return nil
+}
+
+// ListenAddress returns the address the server is listening on - this differs from the configured address if an
+// ephemeral port was requested
+func (s *Server) ListenAddress() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
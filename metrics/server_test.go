@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func init() {
+	common.EnableTestPorts()
+}
+
+func TestServeMetrics(t *testing.T) {
+	registry := NewRegistry()
+	counter := NewCounterVec("test", "requests_total", "number of requests", "type")
+	histogram := NewHistogram("test", "request_bytes", "size of requests", SizeBuckets)
+	registry.MustRegister(counter, histogram,
+		NewGaugeFunc("test", "queue_size", "size of the queue", func() float64 {
+			return 23
+		}))
+	counter.WithLabelValues("foo").Add(3)
+	histogram.Observe(2000)
+
+	address, err := common.AddressWithPort("localhost")
+	require.NoError(t, err)
+	cfg := NewConf()
+	cfg.Enabled = true
+	cfg.ListenAddress = address
+	server := NewServer(cfg, registry)
+	err = server.Start()
+	require.NoError(t, err)
+	defer func() {
+		err := server.Stop()
+		require.NoError(t, err)
+	}()
+	require.Equal(t, address, server.ListenAddress())
+
+	body := getMetrics(t, fmt.Sprintf("http://%s/metrics", address))
+	require.Contains(t, body, `tektite_test_requests_total{type="foo"} 3`)
+	require.Contains(t, body, `tektite_test_request_bytes_bucket{le="4096"} 1`)
+	require.Contains(t, body, "tektite_test_request_bytes_count 1")
+	require.Contains(t, body, "tektite_test_queue_size 23")
+	require.Contains(t, body, "go_goroutines")
+	require.Contains(t, body, "process_cpu_seconds_total")
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/foo", address))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+}
+
+func getMetrics(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() {
+		err := resp.Body.Close()
+		require.NoError(t, err)
+	}()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	bytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(bytes)
+}
+
+func TestValidateConf(t *testing.T) {
+	cfg := NewConf()
+	require.NoError(t, cfg.Validate())
+
+	cfg.Enabled = true
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Equal(t, "metrics listen address must be specified when metrics are enabled", err.Error())
+
+	cfg.ListenAddress = "localhost:9102"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Path = "metrics"
+	err = cfg.Validate()
+	require.Error(t, err)
+	require.Equal(t, "invalid metrics path metrics, must start with '/'", err.Error())
+}
@@ -7,6 +7,9 @@ import (
 
 type Client interface {
 	Get(ctx context.Context, bucket string, key string) ([]byte, error)
+	// GetRange gets the bytes of the object in the range [rangeStart, rangeEnd). If the range extends past the end of
+	// the object then the bytes up to the end of the object are returned. Returns nil if the object does not exist.
+	GetRange(ctx context.Context, bucket string, key string, rangeStart int64, rangeEnd int64) ([]byte, error)
 	Put(ctx context.Context, bucket string, key string, value []byte) error
 	PutIfNotExists(ctx context.Context, bucket string, key string, value []byte) (bool, error)
 	Delete(ctx context.Context, bucket string, key string) error
@@ -23,6 +26,17 @@ type ObjectInfo struct {
 
 const DefaultCallTimeout = 5 * time.Second
 
+// SliceRange returns the part of value in the range [rangeStart, rangeEnd), truncated to the length of value
+func SliceRange(value []byte, rangeStart int64, rangeEnd int64) []byte {
+	if rangeEnd > int64(len(value)) {
+		rangeEnd = int64(len(value))
+	}
+	if rangeStart >= rangeEnd {
+		return []byte{}
+	}
+	return value[rangeStart:rangeEnd]
+}
+
 // Convenience methods that apply a timeout to the Client operations
 
 func GetWithTimeout(client Client, bucket string, key string, timeout time.Duration) ([]byte, error) {
@@ -31,6 +45,13 @@ func GetWithTimeout(client Client, bucket string, key string, timeout time.Durat
 	return client.Get(ctx, bucket, key)
 }
 
+func GetRangeWithTimeout(client Client, bucket string, key string, rangeStart int64, rangeEnd int64,
+	timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return client.GetRange(ctx, bucket, key, rangeStart, rangeEnd)
+}
+
 func PutWithTimeout(client Client, bucket string, key string, value []byte, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -13,6 +13,7 @@ func TestApi(t *testing.T, client Client) {
 
 	testCases := []testCase{
 		{testName: "testPutGet", test: testPutGet},
+		{testName: "testGetRange", test: testGetRange},
 		{testName: "testPutOverwrite", test: testPutOverwrite},
 		{testName: "testDelete", test: testDelete},
 		{testName: "testDeleteAll", test: testDeleteAll},
@@ -97,6 +98,39 @@ func testPutGet(t *testing.T, client Client) {
 	require.Nil(t, vb)
 }
 
+func testGetRange(t *testing.T, client Client) {
+	ctx := context.Background()
+
+	vb, err := client.GetRange(ctx, DefaultBucket, "key1", 0, 10)
+	require.NoError(t, err)
+	require.Nil(t, vb)
+
+	err = client.Put(ctx, DefaultBucket, "key1", []byte("0123456789"))
+	require.NoError(t, err)
+
+	vb, err = client.GetRange(ctx, DefaultBucket, "key1", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(vb))
+
+	vb, err = client.GetRange(ctx, DefaultBucket, "key1", 3, 7)
+	require.NoError(t, err)
+	require.Equal(t, "3456", string(vb))
+
+	vb, err = client.GetRange(ctx, DefaultBucket, "key1", 9, 10)
+	require.NoError(t, err)
+	require.Equal(t, "9", string(vb))
+
+	// range extends past the end of the object
+	vb, err = client.GetRange(ctx, DefaultBucket, "key1", 5, 100)
+	require.NoError(t, err)
+	require.Equal(t, "56789", string(vb))
+
+	// empty range
+	vb, err = client.GetRange(ctx, DefaultBucket, "key1", 5, 5)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(vb))
+}
+
 func testPutOverwrite(t *testing.T, client Client) {
 	ctx := context.Background()
 
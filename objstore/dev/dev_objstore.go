@@ -41,7 +41,13 @@ type getMessageHandler struct {
 
 func (g *getMessageHandler) HandleMessage(messageHolder remoting.MessageHolder) (remoting.ClusterMessage, error) {
 	gm := messageHolder.Message.(*clustermsgs.LocalObjStoreGetRequest)
-	value, err := g.store.Get(context.Background(), gm.Bucket, gm.Key)
+	var value []byte
+	var err error
+	if gm.Ranged {
+		value, err = g.store.GetRange(context.Background(), gm.Bucket, gm.Key, gm.RangeStart, gm.RangeEnd)
+	} else {
+		value, err = g.store.Get(context.Background(), gm.Bucket, gm.Key)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +140,18 @@ func (c *Client) Get(_ context.Context, bucket string, key string) ([]byte, erro
 	return vResp.Value, nil
 }
 
+func (c *Client) GetRange(_ context.Context, bucket string, key string, rangeStart int64,
+	rangeEnd int64) ([]byte, error) {
+	req := &clustermsgs.LocalObjStoreGetRequest{Bucket: bucket, Key: key, Ranged: true, RangeStart: rangeStart,
+		RangeEnd: rangeEnd}
+	resp, err := c.rClient.SendRPC(req, c.address)
+	if err != nil {
+		return nil, remoting.MaybeConvertError(err)
+	}
+	vResp := resp.(*clustermsgs.LocalObjStoreGetResponse)
+	return vResp.Value, nil
+}
+
 func (c *Client) Put(_ context.Context, bucket string, key string, value []byte) error {
 	req := &clustermsgs.LocalObjStorePutRequest{Bucket: bucket, Key: key, Value: value}
 	_, err := c.rClient.SendRPC(req, c.address)
@@ -53,6 +53,15 @@ func (im *InMemStore) Get(_ context.Context, bucket string, key string) ([]byte,
 	return holder.value, nil //nolint:forcetypeassert
 }
 
+func (im *InMemStore) GetRange(ctx context.Context, bucket string, key string, rangeStart int64,
+	rangeEnd int64) ([]byte, error) {
+	value, err := im.Get(ctx, bucket, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return objstore.SliceRange(value, rangeStart, rangeEnd), nil
+}
+
 func (im *InMemStore) Put(_ context.Context, bucket string, key string, value []byte) error {
 	if err := im.checkUnavailable(); err != nil {
 		return err
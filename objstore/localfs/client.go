@@ -0,0 +1,253 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"github.com/spirit-labs/tektite/asl/errwrap"
+	"github.com/spirit-labs/tektite/objstore"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+Client is an object store which keeps objects as files on the local file system, so an agent can run without any
+external object store. Each bucket is a directory under Conf.Dir and each object is a file in its bucket directory.
+Objects are written to a temporary file which is then renamed, or hard linked for PutIfNotExists, into place, so readers
+never see a partially written object and PutIfNotExists is atomic.
+*/
+type Client struct {
+	cfg Conf
+}
+
+type Conf struct {
+	// Dir is the directory that the buckets are stored in
+	Dir string
+}
+
+var _ objstore.Client = &Client{}
+
+// temporary files start with a '.' which escaped keys never do
+const tempFilePattern = ".tmp-*"
+
+func NewLocalFsClient(cfg Conf) *Client {
+	return &Client{
+		cfg: cfg,
+	}
+}
+
+func (c *Client) Get(_ context.Context, bucket string, key string) ([]byte, error) {
+	buff, err := os.ReadFile(c.objectPath(bucket, key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errwrap.WithStack(err)
+	}
+	return buff, nil
+}
+
+func (c *Client) GetRange(_ context.Context, bucket string, key string, rangeStart int64,
+	rangeEnd int64) ([]byte, error) {
+	f, err := os.Open(c.objectPath(bucket, key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errwrap.WithStack(err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errwrap.WithStack(err)
+	}
+	if rangeEnd > info.Size() {
+		rangeEnd = info.Size()
+	}
+	if rangeStart >= rangeEnd {
+		return []byte{}, nil
+	}
+	buff := make([]byte, rangeEnd-rangeStart)
+	if _, err := f.ReadAt(buff, rangeStart); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errwrap.WithStack(err)
+	}
+	return buff, nil
+}
+
+func (c *Client) Put(_ context.Context, bucket string, key string, value []byte) error {
+	tempPath, err := c.writeTempFile(bucket, value)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, c.objectPath(bucket, key)); err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		os.Remove(tempPath)
+		return errwrap.WithStack(err)
+	}
+	return c.syncBucketDir(bucket)
+}
+
+func (c *Client) PutIfNotExists(_ context.Context, bucket string, key string, value []byte) (bool, error) {
+	tempPath, err := c.writeTempFile(bucket, value)
+	if err != nil {
+		return false, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer os.Remove(tempPath)
+	// Creating a hard link fails if the object already exists, unlike rename which would replace it
+	if err := os.Link(tempPath, c.objectPath(bucket, key)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, errwrap.WithStack(err)
+	}
+	if err := c.syncBucketDir(bucket); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Client) Delete(_ context.Context, bucket string, key string) error {
+	if err := os.Remove(c.objectPath(bucket, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errwrap.WithStack(err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteAll(ctx context.Context, bucket string, keys []string) error {
+	for _, key := range keys {
+		if err := c.Delete(ctx, bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) ListObjectsWithPrefix(_ context.Context, bucket string, prefix string,
+	maxKeys int) ([]objstore.ObjectInfo, error) {
+	entries, err := os.ReadDir(c.bucketDir(bucket))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errwrap.WithStack(err)
+	}
+	if maxKeys == -1 {
+		maxKeys = math.MaxInt
+	}
+	var infos []objstore.ObjectInfo
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		key, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			return nil, errwrap.WithStack(err)
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// deleted since we read the directory
+				continue
+			}
+			return nil, errwrap.WithStack(err)
+		}
+		infos = append(infos, objstore.ObjectInfo{
+			Key:          key,
+			LastModified: info.ModTime().UTC(),
+		})
+	}
+	// Escaping doesn't preserve the order of keys, so we sort after unescaping
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+	if len(infos) > maxKeys {
+		infos = infos[:maxKeys]
+	}
+	return infos, nil
+}
+
+func (c *Client) Start() error {
+	return errwrap.WithStack(os.MkdirAll(c.cfg.Dir, 0755))
+}
+
+func (c *Client) Stop() error {
+	return nil
+}
+
+func (c *Client) writeTempFile(bucket string, value []byte) (string, error) {
+	dir := c.bucketDir(bucket)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errwrap.WithStack(err)
+	}
+	f, err := os.CreateTemp(dir, tempFilePattern)
+	if err != nil {
+		return "", errwrap.WithStack(err)
+	}
+	_, err = f.Write(value)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// The file system may use a coarser clock for modification times, so we set it from the wall clock to give
+		// objects an accurate LastModified
+		now := time.Now()
+		err = os.Chtimes(f.Name(), now, now)
+	}
+	if err != nil {
+		//goland:noinspection GoUnhandledErrorResult
+		os.Remove(f.Name())
+		return "", errwrap.WithStack(err)
+	}
+	return f.Name(), nil
+}
+
+// syncBucketDir makes a new or replaced object durable
+func (c *Client) syncBucketDir(bucket string) error {
+	dir, err := os.Open(c.bucketDir(bucket))
+	if err != nil {
+		return errwrap.WithStack(err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer dir.Close()
+	return errwrap.WithStack(dir.Sync())
+}
+
+func (c *Client) bucketDir(bucket string) string {
+	return filepath.Join(c.cfg.Dir, escapeName(bucket))
+}
+
+func (c *Client) objectPath(bucket string, key string) string {
+	return filepath.Join(c.bucketDir(bucket), escapeName(key))
+}
+
+// escapeName converts a bucket or key into a file name. Any byte other than an ASCII letter, digit, '-' or '_' is
+// percent encoded, so keys containing path separators can't escape the bucket directory, and escaped names never start
+// with '.'
+func escapeName(name string) string {
+	var sb strings.Builder
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(name); i++ {
+		b := name[i]
+		if (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') || b == '-' || b == '_' {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[b>>4])
+			sb.WriteByte(hex[b&15])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package localfs
+
+import (
+	"context"
+	"github.com/spirit-labs/tektite/objstore"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestLocalFsObjStore(t *testing.T) {
+	client := NewLocalFsClient(Conf{Dir: t.TempDir()})
+	require.NoError(t, client.Start())
+	objstore.TestApi(t, client)
+}
+
+func TestLocalFsObjStoreDurable(t *testing.T) {
+	dir := t.TempDir()
+	client := NewLocalFsClient(Conf{Dir: dir})
+	require.NoError(t, client.Start())
+	ctx := context.Background()
+	keys := []string{"some/key", "../../escaped", ".", "key with spaces"}
+	for _, key := range keys {
+		require.NoError(t, client.Put(ctx, "bucket1", key, []byte("value-"+key)))
+	}
+	require.NoError(t, client.Stop())
+
+	// Objects are kept when the client is restarted, and are all inside the bucket directory
+	client = NewLocalFsClient(Conf{Dir: dir})
+	require.NoError(t, client.Start())
+	for _, key := range keys {
+		value, err := client.Get(ctx, "bucket1", key)
+		require.NoError(t, err)
+		require.Equal(t, "value-"+key, string(value))
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	infos, err := client.ListObjectsWithPrefix(ctx, "bucket1", "", -1)
+	require.NoError(t, err)
+	require.Equal(t, len(keys), len(infos))
+}
+
+func TestLocalFsObjStoreNoTempFilesLeft(t *testing.T) {
+	dir := t.TempDir()
+	client := NewLocalFsClient(Conf{Dir: dir})
+	require.NoError(t, client.Start())
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "bucket1", "key1", []byte("value1")))
+	require.NoError(t, client.Put(ctx, "bucket1", "key1", []byte("value2")))
+	ok, err := client.PutIfNotExists(ctx, "bucket1", "key1", []byte("value3"))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ok, err = client.PutIfNotExists(ctx, "bucket1", "key2", []byte("value4"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	entries, err := os.ReadDir(client.bucketDir("bucket1"))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(entries))
+	value, err := client.Get(ctx, "bucket1", "key1")
+	require.NoError(t, err)
+	require.Equal(t, "value2", string(value))
+}
@@ -3,14 +3,20 @@ package minio
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
 	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/asl/errwrap"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/objstore"
 	"io"
 	"math"
+	"net"
+	"net/http"
+	"os"
 )
 
 func NewMinioClient(cfg Conf) *Client {
@@ -22,6 +28,7 @@ func NewMinioClient(cfg Conf) *Client {
 type Client struct {
 	cfg    Conf
 	client *minio.Client
+	sse    encrypt.ServerSide
 }
 
 func (m *Client) Get(ctx context.Context, bucket string, key string) ([]byte, error) {
@@ -45,16 +52,50 @@ func (m *Client) Get(ctx context.Context, bucket string, key string) ([]byte, er
 	return buff, nil
 }
 
+func (m *Client) GetRange(ctx context.Context, bucket string, key string, rangeStart int64,
+	rangeEnd int64) ([]byte, error) {
+	if rangeStart >= rangeEnd {
+		return []byte{}, nil
+	}
+	opts := minio.GetObjectOptions{}
+	// The end of the range is inclusive in the object store API
+	if err := opts.SetRange(rangeStart, rangeEnd-1); err != nil {
+		return nil, err
+	}
+	obj, err := m.client.GetObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, maybeConvertError(err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer obj.Close()
+	buff, err := io.ReadAll(obj)
+	if err != nil {
+		var merr minio.ErrorResponse
+		if errwrap.As(err, &merr) {
+			if merr.StatusCode == 404 {
+				// does not exist
+				return nil, nil
+			}
+			if merr.StatusCode == 416 {
+				// range starts after the end of the object
+				return []byte{}, nil
+			}
+		}
+		return nil, maybeConvertError(err)
+	}
+	return buff, nil
+}
+
 func (m *Client) Put(ctx context.Context, bucket string, key string, value []byte) error {
 	buff := bytes.NewBuffer(value)
 	_, err := m.client.PutObject(ctx, bucket, key, buff, int64(len(value)),
-		minio.PutObjectOptions{})
+		minio.PutObjectOptions{ServerSideEncryption: m.sse})
 	return maybeConvertError(err)
 }
 
 func (m *Client) PutIfNotExists(ctx context.Context, bucket string, key string, value []byte) (bool, error) {
 	buff := bytes.NewBuffer(value)
-	opts := minio.PutObjectOptions{}
+	opts := minio.PutObjectOptions{ServerSideEncryption: m.sse}
 	opts.SetMatchETagExcept("*")
 	_, err := m.client.PutObject(ctx, bucket, key, buff, int64(len(value)), opts)
 	if err != nil {
@@ -140,15 +181,24 @@ func (m *Client) MakeBucket(ctx context.Context, bucket string) error {
 }
 
 func (m *Client) Start() error {
-	client, err := minio.New(m.cfg.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(m.cfg.Username, m.cfg.Password, ""),
-		Secure: m.cfg.Secure,
-	})
-
+	opts, err := m.cfg.createOptions()
 	if err != nil {
 		return err
 	}
+	client, err := minio.New(m.cfg.Endpoint, opts)
+	if err != nil {
+		return err
+	}
+	sse, err := m.cfg.createServerSideEncryption()
+	if err != nil {
+		return err
+	}
+	if m.cfg.MaxAttempts > 0 {
+		// The minio client only supports setting this for the whole process
+		minio.MaxRetry = m.cfg.MaxAttempts
+	}
 	m.client = client
+	m.sse = sse
 	return nil
 }
 
@@ -157,16 +207,186 @@ func (m *Client) Stop() error {
 	return nil
 }
 
+// maybeConvertError converts transient errors to unavailable errors, so they are retried. Other errors, such as access
+// denied or a missing bucket, won't go away if the request is retried so are returned as they are.
 func maybeConvertError(err error) error {
 	if err == nil {
 		return err
 	}
-	return common.NewTektiteErrorf(common.Unavailable, err.Error())
+	if isTransientError(err) {
+		return common.NewTektiteErrorf(common.Unavailable, err.Error())
+	}
+	return err
+}
+
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	errResponse := minio.ToErrorResponse(err)
+	if errResponse.StatusCode != 0 || errResponse.Code != "" {
+		_, transientCode := transientErrorCodes[errResponse.Code]
+		return transientCode || errResponse.StatusCode >= 500 || errResponse.StatusCode == http.StatusTooManyRequests
+	}
+	// The request did not get a response, e.g. the connection was refused or reset
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// transientErrorCodes are the S3 error codes which mean the request may succeed if retried
+var transientErrorCodes = map[string]struct{}{
+	"InternalError":        {},
+	"RequestTimeout":       {},
+	"ServiceUnavailable":   {},
+	"SlowDown":             {},
+	"Throttling":           {},
+	"ThrottlingException":  {},
+	"RequestLimitExceeded": {},
+	"RequestThrottled":     {},
 }
 
 type Conf struct {
 	Endpoint string
 	Username string
 	Password string
-	Secure   bool
+	// Secure enables TLS
+	Secure bool
+	// TLSTrustedCertsPath is an optional path to a PEM encoded file containing certificates of trusted servers and/or
+	// certificate authorities, used instead of the system certificates
+	TLSTrustedCertsPath string
+	// TLSNoVerify disables server certificate verification. Use only for testing.
+	TLSNoVerify bool
+	// Region is the region of the object store. If not set, it is looked up from the object store
+	Region string
+	// BucketLookup is how buckets are addressed - virtual-hosted (dns) or path-style
+	BucketLookup BucketLookup
+	// Credentials is where credentials come from
+	Credentials CredentialsSource
+	// ServerSideEncryption is the encryption that the object store applies to the objects that we put
+	ServerSideEncryption ServerSideEncryption
+	// SSEKMSKeyID is the key used when ServerSideEncryption is ServerSideEncryptionKMS
+	SSEKMSKeyID string
+	// MaxAttempts is the maximum number of times the minio client attempts a request before returning an error,
+	// including its own retries. Zero means the minio client default is used. Set it to 1 when the client is wrapped
+	// in an objstore.RetryingClient, so that requests are not retried by both.
+	MaxAttempts int
+}
+
+type BucketLookup string
+
+const (
+	// BucketLookupAuto uses virtual-hosted style if the endpoint supports it, otherwise path-style
+	BucketLookupAuto BucketLookup = "auto"
+	BucketLookupDNS  BucketLookup = "dns"
+	BucketLookupPath BucketLookup = "path"
+)
+
+type CredentialsSource string
+
+const (
+	// CredentialsStatic uses Conf.Username and Conf.Password
+	CredentialsStatic CredentialsSource = "static"
+	// CredentialsChain uses the first credentials found in the AWS or minio environment variables, the AWS or minio
+	// credentials files, or the IAM role of the instance
+	CredentialsChain CredentialsSource = "chain"
+)
+
+type ServerSideEncryption string
+
+const (
+	ServerSideEncryptionNone ServerSideEncryption = "none"
+	// ServerSideEncryptionS3 uses keys managed by the object store
+	ServerSideEncryptionS3 ServerSideEncryption = "sse-s3"
+	// ServerSideEncryptionKMS uses the KMS key Conf.SSEKMSKeyID
+	ServerSideEncryptionKMS ServerSideEncryption = "sse-kms"
+)
+
+func (c *Conf) Validate() error {
+	switch c.BucketLookup {
+	case "", BucketLookupAuto, BucketLookupDNS, BucketLookupPath:
+	default:
+		return errors.Errorf("invalid bucket lookup %s must be one of %s, %s or %s", c.BucketLookup, BucketLookupAuto,
+			BucketLookupDNS, BucketLookupPath)
+	}
+	switch c.Credentials {
+	case "", CredentialsStatic, CredentialsChain:
+	default:
+		return errors.Errorf("invalid credentials %s must be one of %s or %s", c.Credentials, CredentialsStatic,
+			CredentialsChain)
+	}
+	switch c.ServerSideEncryption {
+	case "", ServerSideEncryptionNone, ServerSideEncryptionS3:
+	case ServerSideEncryptionKMS:
+		if c.SSEKMSKeyID == "" {
+			return errors.Errorf("a KMS key id is required for server side encryption %s", ServerSideEncryptionKMS)
+		}
+	default:
+		return errors.Errorf("invalid server side encryption %s must be one of %s, %s or %s", c.ServerSideEncryption,
+			ServerSideEncryptionNone, ServerSideEncryptionS3, ServerSideEncryptionKMS)
+	}
+	if c.MaxAttempts < 0 {
+		return errors.New("invalid max attempts must be >= 0")
+	}
+	return nil
+}
+
+func (c *Conf) createOptions() (*minio.Options, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	opts := &minio.Options{
+		Secure: c.Secure,
+		Region: c.Region,
+	}
+	switch c.BucketLookup {
+	case BucketLookupDNS:
+		opts.BucketLookup = minio.BucketLookupDNS
+	case BucketLookupPath:
+		opts.BucketLookup = minio.BucketLookupPath
+	default:
+		opts.BucketLookup = minio.BucketLookupAuto
+	}
+	if c.Credentials == CredentialsChain {
+		opts.Creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{},
+			&credentials.FileMinioClient{},
+			&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+		})
+	} else {
+		opts.Creds = credentials.NewStaticV4(c.Username, c.Password, "")
+	}
+	if c.Secure && (c.TLSTrustedCertsPath != "" || c.TLSNoVerify) {
+		tlsConfig := &tls.Config{ // nolint: gosec
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: c.TLSNoVerify,
+		}
+		if c.TLSTrustedCertsPath != "" {
+			rootCerts, err := os.ReadFile(c.TLSTrustedCertsPath)
+			if err != nil {
+				return nil, err
+			}
+			rootCertPool := x509.NewCertPool()
+			if ok := rootCertPool.AppendCertsFromPEM(rootCerts); !ok {
+				return nil, errwrap.Errorf("failed to append root certs PEM (invalid PEM block?)")
+			}
+			tlsConfig.RootCAs = rootCertPool
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		opts.Transport = transport
+	}
+	return opts, nil
+}
+
+func (c *Conf) createServerSideEncryption() (encrypt.ServerSide, error) {
+	switch c.ServerSideEncryption {
+	case ServerSideEncryptionS3:
+		return encrypt.NewSSE(), nil
+	case ServerSideEncryptionKMS:
+		return encrypt.NewSSEKMS(c.SSEKMSKeyID, nil)
+	default:
+		return nil, nil
+	}
 }
@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	miniolib "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/modules/minio"
+	"net"
+	"net/http"
+	"net/url"
 	"testing"
 )
 
@@ -49,3 +55,81 @@ func TestMinioObjStore(t *testing.T) {
 
 	objstore.TestApi(t, client)
 }
+
+func TestCreateOptions(t *testing.T) {
+	cfg := Conf{Endpoint: "127.0.0.1:9000", Username: "some-user", Password: "some-pwd"}
+	opts, err := cfg.createOptions()
+	require.NoError(t, err)
+	require.False(t, opts.Secure)
+	require.Equal(t, miniolib.BucketLookupAuto, opts.BucketLookup)
+	require.Nil(t, opts.Transport)
+	creds, err := opts.Creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "some-user", creds.AccessKeyID)
+	sse, err := cfg.createServerSideEncryption()
+	require.NoError(t, err)
+	require.Nil(t, sse)
+
+	cfg.Secure = true
+	cfg.TLSNoVerify = true
+	cfg.Region = "eu-west-2"
+	cfg.BucketLookup = BucketLookupPath
+	cfg.Credentials = CredentialsChain
+	opts, err = cfg.createOptions()
+	require.NoError(t, err)
+	require.True(t, opts.Secure)
+	require.Equal(t, "eu-west-2", opts.Region)
+	require.Equal(t, miniolib.BucketLookupPath, opts.BucketLookup)
+	require.True(t, opts.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+	cfg.ServerSideEncryption = ServerSideEncryptionS3
+	sse, err = cfg.createServerSideEncryption()
+	require.NoError(t, err)
+	require.Equal(t, encrypt.S3, sse.Type())
+	cfg.ServerSideEncryption = ServerSideEncryptionKMS
+	cfg.SSEKMSKeyID = "some-key"
+	sse, err = cfg.createServerSideEncryption()
+	require.NoError(t, err)
+	require.Equal(t, encrypt.KMS, sse.Type())
+}
+
+func TestConfValidate(t *testing.T) {
+	cfg := Conf{BucketLookup: "virtual"}
+	require.Error(t, cfg.Validate())
+	cfg = Conf{Credentials: "iam"}
+	require.Error(t, cfg.Validate())
+	cfg = Conf{ServerSideEncryption: "sse-c"}
+	require.Error(t, cfg.Validate())
+	cfg = Conf{ServerSideEncryption: ServerSideEncryptionKMS}
+	require.Error(t, cfg.Validate())
+	cfg = Conf{MaxAttempts: -1}
+	require.Error(t, cfg.Validate())
+	cfg = Conf{Secure: true, TLSTrustedCertsPath: "does/not/exist.pem"}
+	_, err := cfg.createOptions()
+	require.Error(t, err)
+}
+
+func TestMaybeConvertError(t *testing.T) {
+	transientErrs := []error{
+		miniolib.ErrorResponse{Code: "InternalError", StatusCode: http.StatusInternalServerError},
+		miniolib.ErrorResponse{Code: "ServiceUnavailable", StatusCode: http.StatusServiceUnavailable},
+		miniolib.ErrorResponse{Code: "SlowDown", StatusCode: http.StatusServiceUnavailable},
+		miniolib.ErrorResponse{StatusCode: http.StatusTooManyRequests},
+		miniolib.ErrorResponse{Code: "RequestTimeout", StatusCode: http.StatusBadRequest},
+		&url.Error{Op: "Get", URL: "http://127.0.0.1:9000", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}},
+	}
+	for _, err := range transientErrs {
+		require.True(t, common.IsUnavailableError(maybeConvertError(err)), err.Error())
+	}
+	permanentErrs := []error{
+		miniolib.ErrorResponse{Code: "AccessDenied", StatusCode: http.StatusForbidden},
+		miniolib.ErrorResponse{Code: "NoSuchBucket", StatusCode: http.StatusNotFound},
+		context.DeadlineExceeded,
+		errors.New("some error"),
+	}
+	for _, err := range permanentErrs {
+		converted := maybeConvertError(err)
+		require.False(t, common.IsUnavailableError(converted), err.Error())
+		require.Equal(t, err, converted)
+	}
+}
@@ -0,0 +1,143 @@
+package objstore
+
+import (
+	"context"
+	"github.com/spirit-labs/tektite/common"
+	log "github.com/spirit-labs/tektite/logger"
+	"math/rand"
+	"time"
+)
+
+type RetryConf struct {
+	// MaxRetries is the maximum number of times an operation is retried after failing with a transient error. Zero
+	// means operations are not retried.
+	MaxRetries int
+	// InitialBackoff is the maximum delay before the first retry. The maximum delay doubles with each retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the maximum delay before a retry
+	MaxBackoff time.Duration
+}
+
+func NewRetryConf() RetryConf {
+	return RetryConf{
+		MaxRetries:     DefaultMaxRetries,
+		InitialBackoff: DefaultRetryInitialBackoff,
+		MaxBackoff:     DefaultRetryMaxBackoff,
+	}
+}
+
+const (
+	DefaultMaxRetries          = 3
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+)
+
+/*
+RetryingClient retries the operations of a Client which fail with a transient (unavailable) error. The delay before each
+retry is chosen at random between zero and an exponentially increasing backoff ("full jitter") so that many agents
+retrying at the same time don't all hit the object store together. Retries stop when the context is done, so a retried
+operation never takes longer than its timeout.
+*/
+type RetryingClient struct {
+	client Client
+	cfg    RetryConf
+}
+
+var _ Client = &RetryingClient{}
+
+func NewRetryingClient(client Client, cfg RetryConf) *RetryingClient {
+	return &RetryingClient{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+func (r *RetryingClient) Get(ctx context.Context, bucket string, key string) ([]byte, error) {
+	var value []byte
+	err := r.withRetry(ctx, "get", func() error {
+		var err error
+		value, err = r.client.Get(ctx, bucket, key)
+		return err
+	})
+	return value, err
+}
+
+func (r *RetryingClient) GetRange(ctx context.Context, bucket string, key string, rangeStart int64,
+	rangeEnd int64) ([]byte, error) {
+	var value []byte
+	err := r.withRetry(ctx, "get range", func() error {
+		var err error
+		value, err = r.client.GetRange(ctx, bucket, key, rangeStart, rangeEnd)
+		return err
+	})
+	return value, err
+}
+
+func (r *RetryingClient) Put(ctx context.Context, bucket string, key string, value []byte) error {
+	return r.withRetry(ctx, "put", func() error {
+		return r.client.Put(ctx, bucket, key, value)
+	})
+}
+
+// PutIfNotExists is not retried, as if a put succeeds but the response is lost, a retry would find the key exists and
+// wrongly report that it had not been put
+func (r *RetryingClient) PutIfNotExists(ctx context.Context, bucket string, key string, value []byte) (bool, error) {
+	return r.client.PutIfNotExists(ctx, bucket, key, value)
+}
+
+func (r *RetryingClient) Delete(ctx context.Context, bucket string, key string) error {
+	return r.withRetry(ctx, "delete", func() error {
+		return r.client.Delete(ctx, bucket, key)
+	})
+}
+
+func (r *RetryingClient) DeleteAll(ctx context.Context, bucket string, keys []string) error {
+	return r.withRetry(ctx, "delete all", func() error {
+		return r.client.DeleteAll(ctx, bucket, keys)
+	})
+}
+
+func (r *RetryingClient) ListObjectsWithPrefix(ctx context.Context, bucket string, prefix string,
+	maxKeys int) ([]ObjectInfo, error) {
+	var infos []ObjectInfo
+	err := r.withRetry(ctx, "list objects", func() error {
+		var err error
+		infos, err = r.client.ListObjectsWithPrefix(ctx, bucket, prefix, maxKeys)
+		return err
+	})
+	return infos, err
+}
+
+func (r *RetryingClient) Start() error {
+	return r.client.Start()
+}
+
+func (r *RetryingClient) Stop() error {
+	return r.client.Stop()
+}
+
+func (r *RetryingClient) withRetry(ctx context.Context, operation string, action func() error) error {
+	backoff := r.cfg.InitialBackoff
+	for retries := 0; ; retries++ {
+		err := action()
+		if err == nil || retries >= r.cfg.MaxRetries || !common.IsUnavailableError(err) {
+			return err
+		}
+		delay := time.Duration(0)
+		if backoff > 0 {
+			delay = time.Duration(rand.Int63n(int64(backoff)))
+		}
+		log.Debugf("object store %s failed, will retry after %d ms: %v", operation, delay.Milliseconds(), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > r.cfg.MaxBackoff {
+			backoff = r.cfg.MaxBackoff
+		}
+	}
+}
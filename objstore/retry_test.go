@@ -0,0 +1,93 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// failingClient fails the first numFailures calls with err
+type failingClient struct {
+	Client
+	numFailures int
+	err         error
+	calls       int
+}
+
+func (f *failingClient) call() error {
+	f.calls++
+	if f.calls <= f.numFailures {
+		return f.err
+	}
+	return nil
+}
+
+func (f *failingClient) Get(context.Context, string, string) ([]byte, error) {
+	if err := f.call(); err != nil {
+		return nil, err
+	}
+	return []byte("value"), nil
+}
+
+func (f *failingClient) PutIfNotExists(context.Context, string, string, []byte) (bool, error) {
+	if err := f.call(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func testRetryConf() RetryConf {
+	return RetryConf{
+		MaxRetries:     3,
+		InitialBackoff: 1 * time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	fc := &failingClient{numFailures: 3, err: common.NewTektiteErrorf(common.Unavailable, "unavailable")}
+	client := NewRetryingClient(fc, testRetryConf())
+	value, err := client.Get(context.Background(), "bucket1", "key1")
+	require.NoError(t, err)
+	require.Equal(t, "value", string(value))
+	require.Equal(t, 4, fc.calls)
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	fc := &failingClient{numFailures: 4, err: common.NewTektiteErrorf(common.Unavailable, "unavailable")}
+	client := NewRetryingClient(fc, testRetryConf())
+	_, err := client.Get(context.Background(), "bucket1", "key1")
+	require.Error(t, err)
+	require.True(t, common.IsUnavailableError(err))
+	require.Equal(t, 4, fc.calls)
+}
+
+func TestRetryNotTransientError(t *testing.T) {
+	fc := &failingClient{numFailures: 1, err: errors.New("forbidden")}
+	client := NewRetryingClient(fc, testRetryConf())
+	_, err := client.Get(context.Background(), "bucket1", "key1")
+	require.Error(t, err)
+	require.Equal(t, 1, fc.calls)
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	fc := &failingClient{numFailures: 100, err: common.NewTektiteErrorf(common.Unavailable, "unavailable")}
+	client := NewRetryingClient(fc, RetryConf{MaxRetries: 100, InitialBackoff: time.Hour, MaxBackoff: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.Get(ctx, "bucket1", "key1")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), time.Minute)
+}
+
+func TestRetryPutIfNotExistsNotRetried(t *testing.T) {
+	fc := &failingClient{numFailures: 1, err: common.NewTektiteErrorf(common.Unavailable, "unavailable")}
+	client := NewRetryingClient(fc, testRetryConf())
+	_, err := client.PutIfNotExists(context.Background(), "bucket1", "key1", []byte("value"))
+	require.Error(t, err)
+	require.Equal(t, 1, fc.calls)
+}
@@ -0,0 +1,166 @@
+package offsets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/sst"
+)
+
+const logStartFormatVersion uint16 = 1
+
+// LogStart describes the start of the log of a partition. Offset is the log start offset - the lowest offset that can be
+// fetched. As data is stored in record batches, a batch can contain offsets both before and after the log start offset.
+// TruncatedOffset is the base offset of the first batch that is retained, all batches before this have been deleted.
+type LogStart struct {
+	Offset          int64
+	TruncatedOffset int64
+}
+
+// GetLogStart returns the log start of the partition
+func (c *Cache) GetLogStart(topicID int, partitionID int) (LogStart, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.started {
+		return LogStart{}, false, errors.New("offsets cache not started")
+	}
+	partOffs, exists, err := c.getPartitionOffsets(topicID, partitionID)
+	if err != nil || !exists {
+		return LogStart{}, exists, err
+	}
+	logStart, err := partOffs.getLogStart(topicID, partitionID, c)
+	if err != nil {
+		return LogStart{}, false, err
+	}
+	return logStart, true, nil
+}
+
+// AdvanceLogStart advances the log start of the partition. The log start never moves backwards, so the resulting log
+// start is returned, which can be later than the one provided.
+func (c *Cache) AdvanceLogStart(topicID int, partitionID int, logStart LogStart) (LogStart, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.started {
+		return LogStart{}, false, errors.New("offsets cache not started")
+	}
+	partOffs, exists, err := c.getPartitionOffsets(topicID, partitionID)
+	if err != nil || !exists {
+		return LogStart{}, exists, err
+	}
+	partOffs.lock.Lock()
+	defer partOffs.lock.Unlock()
+	if !partOffs.loaded {
+		if err := partOffs.load(topicID, partitionID, c); err != nil {
+			return LogStart{}, false, err
+		}
+	}
+	partOffs.advanceLogStart(logStart)
+	c.setTruncatedOffset(topicID, partitionID, partOffs.logStart.TruncatedOffset)
+	return partOffs.logStart, true, nil
+}
+
+// GetTruncatedOffset returns the offset before which all data of the partition can be removed - the truncated offset
+// of its log start. This is called by the LSM during compaction with the LSM lock held, so it must not load the
+// partition, as that queries the LSM. Instead, it reads a snapshot which is updated whenever the log start of a loaded
+// partition changes. Zero is returned if the partition has not been loaded - records deleted before then are still
+// removed by the range deletes written when they were deleted.
+func (c *Cache) GetTruncatedOffset(topicID int, partitionID int) int64 {
+	c.truncatedOffsetsLock.RLock()
+	defer c.truncatedOffsetsLock.RUnlock()
+	return c.truncatedOffsets[partitionKey{topicID: topicID, partitionID: partitionID}]
+}
+
+func (c *Cache) setTruncatedOffset(topicID int, partitionID int, truncatedOffset int64) {
+	c.truncatedOffsetsLock.Lock()
+	defer c.truncatedOffsetsLock.Unlock()
+	c.truncatedOffsets[partitionKey{topicID: topicID, partitionID: partitionID}] = truncatedOffset
+}
+
+// LoadLogStartForPartition loads the persisted log start of the partition. If none has been persisted then the log
+// start is zero.
+func (c *Cache) LoadLogStartForPartition(topicID int, partitionID int) (LogStart, error) {
+	partHash, err := c.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return LogStart{}, err
+	}
+	prefix := createLogStartPrefix(partHash)
+	tables, err := c.querier.GetTablesForHighestKeyWithPrefix(prefix)
+	if err != nil {
+		return LogStart{}, err
+	}
+	// Tables are returned newest first, but they might overlap the prefix without containing the log start
+	for _, tableID := range tables {
+		buff, err := c.getWithRetry(tableID)
+		if err != nil {
+			return LogStart{}, err
+		}
+		if len(buff) == 0 {
+			return LogStart{}, errors.Errorf("ssttable %s not found", tableID)
+		}
+		var table sst.SSTable
+		table.Deserialize(buff, 0)
+		iter, err := table.NewIterator(prefix, common.IncBigEndianBytes(prefix))
+		if err != nil {
+			return LogStart{}, err
+		}
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return LogStart{}, err
+		}
+		if !ok || !bytes.HasPrefix(kv.Key, prefix) {
+			continue
+		}
+		if len(kv.Value) == 0 {
+			// tombstone
+			return LogStart{}, nil
+		}
+		return decodeLogStart(kv.Value), nil
+	}
+	return LogStart{}, nil
+}
+
+// CreateLogStartKV creates the KV used to persist the log start of the partition with the provided partition hash
+func CreateLogStartKV(partitionHash []byte, logStart LogStart) common.KV {
+	key := encoding.EncodeVersion(createLogStartPrefix(partitionHash), 0)
+	value := make([]byte, 0, 18)
+	value = binary.BigEndian.AppendUint16(value, logStartFormatVersion)
+	value = binary.BigEndian.AppendUint64(value, uint64(logStart.Offset))
+	value = binary.BigEndian.AppendUint64(value, uint64(logStart.TruncatedOffset))
+	return common.KV{Key: key, Value: value}
+}
+
+func createLogStartPrefix(partitionHash []byte) []byte {
+	prefix := make([]byte, 0, 25)
+	prefix = append(prefix, partitionHash...)
+	return append(prefix, common.EntryTypeLogStart)
+}
+
+func decodeLogStart(value []byte) LogStart {
+	// First two bytes are the format version
+	return LogStart{
+		Offset:          int64(binary.BigEndian.Uint64(value[2:])),
+		TruncatedOffset: int64(binary.BigEndian.Uint64(value[10:])),
+	}
+}
+
+func (p *partitionOffsets) getLogStart(topicID int, partitionID int, o *Cache) (LogStart, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.loaded {
+		if err := p.load(topicID, partitionID, o); err != nil {
+			return LogStart{}, err
+		}
+	}
+	return p.logStart, nil
+}
+
+func (p *partitionOffsets) advanceLogStart(logStart LogStart) {
+	if logStart.Offset > p.logStart.Offset {
+		p.logStart.Offset = logStart.Offset
+	}
+	if logStart.TruncatedOffset > p.logStart.TruncatedOffset {
+		p.logStart.TruncatedOffset = logStart.TruncatedOffset
+	}
+}
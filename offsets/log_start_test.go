@@ -0,0 +1,126 @@
+package offsets
+
+import (
+	"bytes"
+	"context"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/objstore/dev"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/sst"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+func TestLogStartNotPersisted(t *testing.T) {
+	oc := setupAndStartCache(t)
+	logStart, exists, err := oc.GetLogStart(7, 1)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, LogStart{}, logStart)
+
+	_, exists, err = oc.GetLogStart(23, 1)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestAdvanceLogStart(t *testing.T) {
+	oc := setupAndStartCache(t)
+	logStart, exists, err := oc.AdvanceLogStart(7, 1, LogStart{Offset: 100, TruncatedOffset: 90})
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, LogStart{Offset: 100, TruncatedOffset: 90}, logStart)
+
+	// Log start never moves backwards
+	logStart, _, err = oc.AdvanceLogStart(7, 1, LogStart{Offset: 50, TruncatedOffset: 50})
+	require.NoError(t, err)
+	require.Equal(t, LogStart{Offset: 100, TruncatedOffset: 90}, logStart)
+
+	logStart, _, err = oc.AdvanceLogStart(7, 1, LogStart{Offset: 150, TruncatedOffset: 150})
+	require.NoError(t, err)
+	require.Equal(t, LogStart{Offset: 150, TruncatedOffset: 150}, logStart)
+
+	logStart, _, err = oc.GetLogStart(7, 1)
+	require.NoError(t, err)
+	require.Equal(t, LogStart{Offset: 150, TruncatedOffset: 150}, logStart)
+}
+
+func TestGetTruncatedOffset(t *testing.T) {
+	oc := setupAndStartCache(t)
+	// Partition not loaded
+	require.Equal(t, 0, int(oc.GetTruncatedOffset(7, 1)))
+
+	_, _, err := oc.AdvanceLogStart(7, 1, LogStart{Offset: 200, TruncatedOffset: 180})
+	require.NoError(t, err)
+	require.Equal(t, 180, int(oc.GetTruncatedOffset(7, 1)))
+
+	// Log start never moves backwards
+	_, _, err = oc.AdvanceLogStart(7, 1, LogStart{Offset: 100, TruncatedOffset: 100})
+	require.NoError(t, err)
+	require.Equal(t, 180, int(oc.GetTruncatedOffset(7, 1)))
+
+	_, _, err = oc.AdvanceLogStart(7, 1, LogStart{Offset: 300, TruncatedOffset: 300})
+	require.NoError(t, err)
+	require.Equal(t, 300, int(oc.GetTruncatedOffset(7, 1)))
+
+	// Unknown partition
+	require.Equal(t, 0, int(oc.GetTruncatedOffset(23, 1)))
+}
+
+func TestLoadPersistedLogStart(t *testing.T) {
+	partHashes, err := parthash.NewPartitionHashes(0)
+	require.NoError(t, err)
+	hash1, err := partHashes.GetPartitionHash(7, 1)
+	require.NoError(t, err)
+	hash3, err := partHashes.GetPartitionHash(7, 3)
+	require.NoError(t, err)
+	kvs := []common.KV{
+		createDataEntry(t, 7, 1, 3456),
+		CreateLogStartKV(hash1, LogStart{Offset: 3000, TruncatedOffset: 2900}),
+		// All the records in the partition have been deleted
+		CreateLogStartKV(hash3, LogStart{Offset: 5000, TruncatedOffset: 5000}),
+	}
+	oc := setupAndStartCacheWithKVs(t, kvs)
+
+	logStart, _, err := oc.GetLogStart(7, 1)
+	require.NoError(t, err)
+	require.Equal(t, LogStart{Offset: 3000, TruncatedOffset: 2900}, logStart)
+	// Loading the log start updates the truncated offset snapshot
+	require.Equal(t, 2900, int(oc.GetTruncatedOffset(7, 1)))
+	lro, _, err := oc.GetLastReadableOffset(7, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3456, int(lro))
+
+	logStart, _, err = oc.GetLogStart(7, 3)
+	require.NoError(t, err)
+	require.Equal(t, LogStart{Offset: 5000, TruncatedOffset: 5000}, logStart)
+	// Offsets must continue from the log start, even though there is no data
+	lro, _, err = oc.GetLastReadableOffset(7, 3)
+	require.NoError(t, err)
+	require.Equal(t, 4999, int(lro))
+	offs, _, err := oc.GenerateOffsets([]GenerateOffsetTopicInfo{
+		{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 3, NumOffsets: 10}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5009, int(offs[0].PartitionInfos[0].Offset))
+}
+
+func setupAndStartCacheWithKVs(t *testing.T, kvs []common.KV) *Cache {
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+	})
+	table, _, _, _, _, err := sst.BuildSSTable(common.DataFormatV1, 0, 0, common.NewKvSliceIterator(kvs))
+	require.NoError(t, err)
+	objStore := dev.NewInMemStore(0)
+	bucketName := "test-bucket"
+	tableID := sst.CreateSSTableId()
+	err = objStore.Put(context.Background(), bucketName, tableID, table.Serialize())
+	require.NoError(t, err)
+	oc, err := NewOffsetsCache(testTopicProvider, &testLsmHolder{
+		tableID: []byte(tableID),
+	}, objStore, bucketName)
+	require.NoError(t, err)
+	err = oc.Start()
+	require.NoError(t, err)
+	return oc
+}
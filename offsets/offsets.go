@@ -9,6 +9,7 @@ import (
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/sst"
@@ -46,11 +47,32 @@ with gaps in the offset sequence. However, it cannot be allowed for registration
 after this change has occurred otherwise that data would be skipped past by consumers. Therefore we maintain a field
 lowestAcceptableSequence which is updated to be current sequence at the point of cluster membership change.
 When MaybeReleaseOffsets is called we reject any attempts where the offset is less than this value.
+
+The Cache also maintains lastStableOffset for each partition, which is used by consumers with read_committed
+isolation level. Transactions that are not yet committed or aborted must not be visible to these consumers. When offsets
+are generated the caller also provides the positions of any transactional data and transaction markers in the data it is
+writing. These are applied in offset order when the offsets are released, and the cache tracks the first offset of
+each open transaction. lastStableOffset is then one less than the lowest first offset of any open transaction, or
+lastReadableOffset if there are no open transactions. The open transactions are persisted along with the data, so they
+are restored when a partition is loaded - see transactions.go.
+
+The Cache also tracks the approximate size of the data stored for each partition, so that topics with a retention size
+can have their oldest data removed. When a table is registered its size is attributed to the partitions it contains in
+proportion to the number of offsets written for each, and a checkpoint of cumulative size is kept against the last
+offset. GetMinRetainedOffset uses these checkpoints to find the lowest offset that must be retained. Sizes are not
+persisted - when a partition of a topic with a retention size is loaded, its size is rebuilt from the data for the
+partition in the LSM. If a topic is altered to add a retention size, data written before then is not counted until the
+controller next fails over.
+
+The Cache also maintains the log start of each partition - the lowest offset that consumers can fetch. It is advanced,
+and persisted, when records are deleted with DeleteRecords, which is also how the controller applies size based
+retention. Note that data removed by time based retention is not tracked, so the earliest data for a partition can be
+after the log start offset.
 */
 type Cache struct {
 	lock                     sync.RWMutex
 	started                  bool
-	topicOffsets             map[int][]partitionOffsets
+	topicOffsets             map[int][]*partitionOffsets
 	topicMetaProvider        topicMetaProvider
 	querier                  querier
 	partitionHashes          *parthash.PartitionHashes
@@ -61,8 +83,14 @@ type Cache struct {
 	reorderLock              sync.Mutex
 	offsHeap                 seqHeap
 	offsetsMap               map[int64][]OffsetTopicInfo
+	txEventsMap              map[int64][]partitionTxEvents
+	writesMap                map[int64][]partitionWrite
 	lastReleasedSequence     int64
 	lowestAcceptableSequence int64
+	sizesLock                sync.Mutex
+	partitionSizes           map[partitionKey]*partitionSize
+	truncatedOffsetsLock     sync.RWMutex
+	truncatedOffsets         map[partitionKey]int64
 }
 
 type topicMetaProvider interface {
@@ -71,6 +99,7 @@ type topicMetaProvider interface {
 
 type querier interface {
 	GetTablesForHighestKeyWithPrefix(prefix []byte) ([]sst.SSTableID, error)
+	QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.OverlappingTables, error)
 }
 
 const (
@@ -86,12 +115,16 @@ func NewOffsetsCache(topicProvider topicMetaProvider, lsm querier, objStore objs
 	}
 	return &Cache{
 		topicMetaProvider: topicProvider,
-		topicOffsets:      make(map[int][]partitionOffsets),
+		topicOffsets:      make(map[int][]*partitionOffsets),
 		querier:           lsm,
 		objStore:          objStore,
 		dataBucketName:    dataBucketName,
 		partitionHashes:   partHashes,
 		offsetsMap:        make(map[int64][]OffsetTopicInfo),
+		txEventsMap:       make(map[int64][]partitionTxEvents),
+		writesMap:         make(map[int64][]partitionWrite),
+		partitionSizes:    make(map[partitionKey]*partitionSize),
+		truncatedOffsets:  make(map[partitionKey]int64),
 	}, nil
 }
 
@@ -103,6 +136,24 @@ type GenerateOffsetTopicInfo struct {
 type GenerateOffsetPartitionInfo struct {
 	PartitionID int
 	NumOffsets  int
+	// TxEvents are the positions of any transactional data and transaction markers in the data being written, in offset
+	// order
+	TxEvents []TxEvent
+}
+
+// TxEvent describes a transactional batch in the data being written. Offset is relative to the first offset generated
+// for the partition.
+type TxEvent struct {
+	ProducerID int64
+	Offset     int64
+	// End is true if the batch is a transaction marker, otherwise the batch contains transactional data
+	End bool
+}
+
+type partitionTxEvents struct {
+	topicID     int
+	partitionID int
+	events      []TxEvent
 }
 
 type GetOffsetTopicInfo struct {
@@ -128,6 +179,13 @@ type OffsetTopicInfo struct {
 type OffsetPartitionInfo struct {
 	PartitionID int
 	Offset      int64
+	// LastStableOffset and LogStartOffset are only set when offsets are released, and when offsets are requested with
+	// GetOffsetInfo
+	LastStableOffset int64
+	LogStartOffset   int64
+	// TxFirstOffsets holds the first offset of the transaction of each TxEvent provided, or -1 if the start of the
+	// transaction is not known. It is only set when offsets are generated.
+	TxFirstOffsets []int64
 }
 
 func (c *Cache) Start() error {
@@ -157,13 +215,47 @@ func (c *Cache) GenerateOffsets(infos []GenerateOffsetTopicInfo) ([]OffsetTopicI
 	if err != nil {
 		return nil, 0, err
 	}
+	txEvents := extractTxEvents(infos, res)
+	writes := extractWrites(infos, res)
 	// reorderLock must be taken after partition locks have been unlocked, to avoid deadlock
 	c.reorderLock.Lock()
 	defer c.reorderLock.Unlock()
 	c.offsetsMap[seq] = res
+	if len(txEvents) > 0 {
+		c.txEventsMap[seq] = txEvents
+	}
+	c.writesMap[seq] = writes
 	return res, seq, nil
 }
 
+// extractTxEvents converts any relative offsets of transaction events to absolute offsets, now we know the offsets
+// that were generated
+func extractTxEvents(infos []GenerateOffsetTopicInfo, res []OffsetTopicInfo) []partitionTxEvents {
+	var txEvents []partitionTxEvents
+	for i, topicInfo := range infos {
+		for j, partitionInfo := range topicInfo.PartitionInfos {
+			if len(partitionInfo.TxEvents) == 0 {
+				continue
+			}
+			firstOffset := res[i].PartitionInfos[j].Offset - int64(partitionInfo.NumOffsets) + 1
+			events := make([]TxEvent, len(partitionInfo.TxEvents))
+			for k, event := range partitionInfo.TxEvents {
+				events[k] = TxEvent{
+					ProducerID: event.ProducerID,
+					Offset:     firstOffset + event.Offset,
+					End:        event.End,
+				}
+			}
+			txEvents = append(txEvents, partitionTxEvents{
+				topicID:     topicInfo.TopicID,
+				partitionID: partitionInfo.PartitionID,
+				events:      events,
+			})
+		}
+	}
+	return txEvents
+}
+
 func (c *Cache) generateOffsets0(infos []GenerateOffsetTopicInfo) ([]OffsetTopicInfo, int64, error) {
 	// First we gather all the partition offsets, and obtain all the locks before we get any offsets. This is
 	// essential to ensure that all offsets got for a particular sequence are higher than offsets got for a lower
@@ -178,22 +270,18 @@ func (c *Cache) generateOffsets0(infos []GenerateOffsetTopicInfo) ([]OffsetTopic
 		}
 	}()
 	for _, topicInfo := range infos {
-		topicOffsets, exists, err := c.getTopicOffsets(topicInfo.TopicID)
-		if err != nil {
-			return nil, 0, err
-		}
-		if !exists {
-			return nil, 0, common.NewTektiteErrorf(common.TopicDoesNotExist, "generate offsets: unknown topic: %d", topicInfo.TopicID)
-		}
 		for _, partitionInfo := range topicInfo.PartitionInfos {
 			if partitionInfo.NumOffsets < 1 {
 				// OK to panic as would be programming error
 				panic(fmt.Sprintf("invalid value for NumOffsets: %d", partitionInfo.NumOffsets))
 			}
-			if err := checkPartitionOffsetInRange(partitionInfo.PartitionID, len(topicOffsets)); err != nil {
+			partitionOff, exists, err := c.getPartitionOffsets(topicInfo.TopicID, partitionInfo.PartitionID)
+			if err != nil {
 				return nil, 0, err
 			}
-			partitionOff := &topicOffsets[partitionInfo.PartitionID]
+			if !exists {
+				return nil, 0, common.NewTektiteErrorf(common.TopicDoesNotExist, "generate offsets: unknown topic: %d", topicInfo.TopicID)
+			}
 			partitionOff.lock.Lock()
 			partOffs = append(partOffs, partitionOff)
 		}
@@ -216,8 +304,9 @@ func (c *Cache) generateOffsets0(infos []GenerateOffsetTopicInfo) ([]OffsetTopic
 				return nil, 0, err
 			}
 			topicOffInfo.PartitionInfos[j] = OffsetPartitionInfo{
-				PartitionID: partitionInfo.PartitionID,
-				Offset:      offset + int64(partitionInfo.NumOffsets) - 1, // The last offset given out
+				PartitionID:    partitionInfo.PartitionID,
+				Offset:         offset + int64(partitionInfo.NumOffsets) - 1, // The last offset given out
+				TxFirstOffsets: partOff.generateTxEvents(partitionInfo.TxEvents, offset),
 			}
 		}
 		offInfos[i] = topicOffInfo
@@ -231,17 +320,35 @@ func (c *Cache) GetLastReadableOffset(topicID int, partitionID int) (int64, bool
 	if !c.started {
 		return 0, false, errors.New("offsets cache not started")
 	}
-	offs, exists, err := c.getTopicOffsets(topicID)
+	partOffs, exists, err := c.getPartitionOffsets(topicID, partitionID)
 	if err != nil {
 		return 0, false, err
 	}
 	if !exists {
 		return 0, false, nil
 	}
-	if err := checkPartitionOffsetInRange(partitionID, len(offs)); err != nil {
+	off, err := partOffs.getLastReadableOffset(topicID, partitionID, c)
+	if err != nil {
 		return 0, false, err
 	}
-	off, err := offs[partitionID].getLastReadableOffset(topicID, partitionID, c)
+	return off, true, nil
+}
+
+// GetLastStableOffset returns the offset of the last message that is not part of an open transaction, and is readable
+func (c *Cache) GetLastStableOffset(topicID int, partitionID int) (int64, bool, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.started {
+		return 0, false, errors.New("offsets cache not started")
+	}
+	partOffs, exists, err := c.getPartitionOffsets(topicID, partitionID)
+	if err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+	off, err := partOffs.getLastStableOffset(topicID, partitionID, c)
 	if err != nil {
 		return 0, false, err
 	}
@@ -269,11 +376,13 @@ func (c *Cache) MembershipChanged() {
 	defer c.reorderLock.Unlock()
 	// reset any unordered tables waiting to be released
 	c.offsetsMap = map[int64][]OffsetTopicInfo{}
+	c.txEventsMap = map[int64][]partitionTxEvents{}
+	c.writesMap = map[int64][]partitionWrite{}
 	c.offsHeap = nil
 	c.lastReleasedSequence = seq
 }
 
-func (c *Cache) loadTopicInfo(topicID int) ([]partitionOffsets, bool, error) {
+func (c *Cache) loadTopicInfo(topicID int) ([]*partitionOffsets, bool, error) {
 	// Upgrade the lock
 	c.lock.RUnlock()
 	c.lock.Lock()
@@ -292,7 +401,35 @@ func (c *Cache) loadTopicInfo(topicID int) ([]partitionOffsets, bool, error) {
 	if !exists {
 		return nil, false, nil
 	}
-	offsets = make([]partitionOffsets, info.PartitionCount)
+	offsets = make([]*partitionOffsets, info.PartitionCount)
+	for i := range offsets {
+		offsets[i] = &partitionOffsets{}
+	}
+	c.topicOffsets[topicID] = offsets
+	return offsets, true, nil
+}
+
+// reloadPartitionCount is called when a partition is out of range of the cached partitions for a topic, as partitions
+// can be added to a topic after it was loaded. Any new partitions are appended to the cached partitions.
+func (c *Cache) reloadPartitionCount(topicID int) ([]*partitionOffsets, bool, error) {
+	// Upgrade the lock
+	c.lock.RUnlock()
+	c.lock.Lock()
+	defer func() {
+		c.lock.Unlock()
+		c.lock.RLock()
+	}()
+	info, exists, err := c.topicMetaProvider.GetTopicInfoByID(topicID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		return nil, false, nil
+	}
+	offsets := c.topicOffsets[topicID]
+	for len(offsets) < info.PartitionCount {
+		offsets = append(offsets, &partitionOffsets{})
+	}
 	c.topicOffsets[topicID] = offsets
 	return offsets, true, nil
 }
@@ -304,7 +441,7 @@ func checkPartitionOffsetInRange(partitionID int, numPartitions int) error {
 	return nil
 }
 
-func (c *Cache) getTopicOffsets(topicID int) ([]partitionOffsets, bool, error) {
+func (c *Cache) getTopicOffsets(topicID int) ([]*partitionOffsets, bool, error) {
 	offsets, ok := c.topicOffsets[topicID]
 	if !ok {
 		var err error
@@ -320,7 +457,26 @@ func (c *Cache) getTopicOffsets(topicID int) ([]partitionOffsets, bool, error) {
 	return offsets, true, nil
 }
 
-func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID) ([]OffsetTopicInfo, []sst.SSTableID, error) {
+func (c *Cache) getPartitionOffsets(topicID int, partitionID int) (*partitionOffsets, bool, error) {
+	offsets, exists, err := c.getTopicOffsets(topicID)
+	if err != nil || !exists {
+		return nil, exists, err
+	}
+	if partitionID >= len(offsets) {
+		offsets, exists, err = c.reloadPartitionCount(topicID)
+		if err != nil || !exists {
+			return nil, exists, err
+		}
+	}
+	if err := checkPartitionOffsetInRange(partitionID, len(offsets)); err != nil {
+		return nil, false, err
+	}
+	return offsets[partitionID], true, nil
+}
+
+// MaybeReleaseOffsets releases the offsets for the sequence, along with any earlier sequences that were waiting for it,
+// as long as sequences are contiguous. tableSize is the size of the table registered for the sequence.
+func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID, tableSize int64) ([]OffsetTopicInfo, []sst.SSTableID, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if !c.started {
@@ -334,6 +490,7 @@ func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID) ([]
 	c.reorderLock.Lock()
 	defer c.reorderLock.Unlock()
 	var infos []OffsetTopicInfo
+	var txEvents []partitionTxEvents
 	var tableIDs []sst.SSTableID
 	if sequence == c.lastReleasedSequence+1 && len(c.offsHeap) == 0 {
 		// happy path - avoid heap
@@ -343,12 +500,17 @@ func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID) ([]
 			panic("cannot find offsets in map")
 		}
 		delete(c.offsetsMap, sequence)
+		txEvents = c.txEventsMap[sequence]
+		delete(c.txEventsMap, sequence)
+		c.recordSizes(c.writesMap[sequence], tableSize)
+		delete(c.writesMap, sequence)
 		c.lastReleasedSequence = sequence
 		tableIDs = []sst.SSTableID{sstableID}
 	} else {
 		heap.Push(&c.offsHeap, seqHolder{
-			seq:     sequence,
-			tableID: sstableID,
+			seq:       sequence,
+			tableID:   sstableID,
+			tableSize: tableSize,
 		})
 		// We pop sequences as long as sequence is contiguous and ascending
 		for len(c.offsHeap) > 0 {
@@ -360,6 +522,11 @@ func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID) ([]
 					panic("cannot find offsets in map")
 				}
 				delete(c.offsetsMap, top.seq)
+				// Events are appended in sequence order, so they are applied in offset order
+				txEvents = append(txEvents, c.txEventsMap[top.seq]...)
+				delete(c.txEventsMap, top.seq)
+				c.recordSizes(c.writesMap[top.seq], top.tableSize)
+				delete(c.writesMap, top.seq)
 				c.lastReleasedSequence = top.seq
 				if infos == nil {
 					infos = infs
@@ -372,14 +539,33 @@ func (c *Cache) MaybeReleaseOffsets(sequence int64, sstableID sst.SSTableID) ([]
 			}
 		}
 	}
+	if err := c.applyTxEvents(txEvents); err != nil {
+		return nil, nil, err
+	}
 	if err := c.updateLastReadable(infos); err != nil {
 		return nil, nil, err
 	}
 	return infos, tableIDs, nil
 }
 
+func (c *Cache) applyTxEvents(txEvents []partitionTxEvents) error {
+	for _, partEvents := range txEvents {
+		offs, exists, err := c.getTopicOffsets(partEvents.topicID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Warnf("applyTxEvents - unknown topic id %d", partEvents.topicID)
+			continue
+		}
+		offs[partEvents.partitionID].applyTxEvents(partEvents.events)
+	}
+	return nil
+}
+
 func (c *Cache) updateLastReadable(infos []OffsetTopicInfo) error {
-	for _, topicInfo := range infos {
+	for i := range infos {
+		topicInfo := &infos[i]
 		offs, exists, err := c.getTopicOffsets(topicInfo.TopicID)
 		if err != nil {
 			return err
@@ -387,8 +573,10 @@ func (c *Cache) updateLastReadable(infos []OffsetTopicInfo) error {
 		if !exists {
 			log.Warnf("updateLastReadable - unknown topic id %d", topicInfo.TopicID)
 		} else {
-			for _, partInfo := range topicInfo.PartitionInfos {
-				offs[partInfo.PartitionID].setLastReadableOffset(partInfo.Offset)
+			for j := range topicInfo.PartitionInfos {
+				partInfo := &topicInfo.PartitionInfos[j]
+				partInfo.LastStableOffset, partInfo.LogStartOffset =
+					offs[partInfo.PartitionID].setLastReadableOffset(partInfo.Offset)
 				log.Debugf("setting lro for topic %d partition %d to %d", topicInfo.TopicID, partInfo.PartitionID, partInfo.Offset)
 			}
 		}
@@ -509,9 +697,15 @@ func (c *Cache) LoadHighestOffsetForPartition(topicID int, partitionID int) (int
 				break
 			}
 			if bytes.Equal(prefix, kv.Key[:len(prefix)]) {
+				if len(kv.Value) == 0 {
+					// A prefix delete from records being deleted
+					continue
+				}
 				baseOffset, _ := encoding.KeyDecodeInt(kv.Key, 17)
-				numRecords := binary.BigEndian.Uint32(kv.Value[57:])
-				offset = baseOffset + int64(numRecords) - 1
+				// Use the last offset delta rather than the number of records, as batches in compacted topics can
+				// have records removed
+				lastOffsetDelta := int32(binary.BigEndian.Uint32(kv.Value[23:]))
+				offset = baseOffset + int64(lastOffsetDelta)
 			} else {
 				break
 			}
@@ -522,18 +716,32 @@ func (c *Cache) LoadHighestOffsetForPartition(topicID int, partitionID int) (int
 }
 
 func (c *Cache) getWithRetry(tableID sst.SSTableID) ([]byte, error) {
+	return c.withRetry(func() ([]byte, error) {
+		return objstore.GetWithTimeout(c.objStore, c.dataBucketName, string(tableID), objectStoreCallTimeout)
+	})
+}
+
+func (c *Cache) getRangeWithRetry(tableID sst.SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	return c.withRetry(func() ([]byte, error) {
+		return objstore.GetRangeWithTimeout(c.objStore, c.dataBucketName, string(tableID), rangeStart, rangeEnd,
+			objectStoreCallTimeout)
+	})
+}
+
+func (c *Cache) withRetry(get func() ([]byte, error)) ([]byte, error) {
 	for {
-		buff, err := objstore.GetWithTimeout(c.objStore, c.dataBucketName, string(tableID), objectStoreCallTimeout)
+		buff, err := get()
 		if err == nil {
 			return buff, nil
 		}
 		if c.stopping.Load() {
 			return nil, errors.New("offset loader is stopping")
 		}
-		if common.IsUnavailableError(err) {
-			log.Warnf("Unable to load offset from object storage due to unavailability, will retry after delay: %v", err)
-			time.Sleep(unavailabilityRetryDelay)
+		if !common.IsUnavailableError(err) {
+			return nil, err
 		}
+		log.Warnf("Unable to load offset from object storage due to unavailability, will retry after delay: %v", err)
+		time.Sleep(unavailabilityRetryDelay)
 	}
 }
 
@@ -556,6 +764,11 @@ type partitionOffsets struct {
 	nextWriteOffset    int64
 	lastReadableOffset int64
 	loaded             bool
+	// map of producer id to first offset of open transaction
+	openTxs map[int64]int64
+	// as openTxs, but as of the offsets generated rather than the offsets released
+	generatedOpenTxs map[int64]int64
+	logStart         LogStart
 }
 
 func (p *partitionOffsets) clusterVersionChanged() {
@@ -586,21 +799,76 @@ func (p *partitionOffsets) getLastReadableOffset(topicID int, partitionID int, o
 	return p.lastReadableOffset, nil
 }
 
+func (p *partitionOffsets) getLastStableOffset(topicID int, partitionID int, o *Cache) (int64, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.loaded {
+		if err := p.load(topicID, partitionID, o); err != nil {
+			return 0, err
+		}
+	}
+	return p.lastStableOffset(), nil
+}
+
+func (p *partitionOffsets) lastStableOffset() int64 {
+	lso := p.lastReadableOffset
+	for _, firstOffset := range p.openTxs {
+		if firstOffset-1 < lso {
+			lso = firstOffset - 1
+		}
+	}
+	return lso
+}
+
+func (p *partitionOffsets) applyTxEvents(events []TxEvent) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, event := range events {
+		if event.End {
+			delete(p.openTxs, event.ProducerID)
+			continue
+		}
+		if _, ok := p.openTxs[event.ProducerID]; !ok {
+			if p.openTxs == nil {
+				p.openTxs = map[int64]int64{}
+			}
+			p.openTxs[event.ProducerID] = event.Offset
+		}
+	}
+}
+
 func (p *partitionOffsets) load(topicID int, partitionID int, o *Cache) error {
 	off, err := o.LoadHighestOffsetForPartition(topicID, partitionID)
 	if err != nil {
 		return err
 	}
+	logStart, err := o.LoadLogStartForPartition(topicID, partitionID)
+	if err != nil {
+		return err
+	}
+	if logStart.Offset > off+1 {
+		// All the data for the partition has been deleted, so the offset must continue from the log start offset
+		off = logStart.Offset - 1
+	}
 	p.nextWriteOffset = off + 1
 	p.lastReadableOffset = off
+	if err := o.loadPartitionSize(topicID, partitionID, logStart.TruncatedOffset); err != nil {
+		return err
+	}
+	if err := p.loadOpenTransactions(topicID, partitionID, o); err != nil {
+		return err
+	}
+	p.logStart = logStart
 	p.loaded = true
+	o.setTruncatedOffset(topicID, partitionID, logStart.TruncatedOffset)
 	return nil
 }
 
-func (p *partitionOffsets) setLastReadableOffset(offset int64) {
+func (p *partitionOffsets) setLastReadableOffset(offset int64) (int64, int64) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	p.lastReadableOffset = offset
+	return p.lastStableOffset(), p.logStart.Offset
 }
 
 func (p *partitionOffsets) forceSetLastReadableOffset(offset int64) {
@@ -611,8 +879,9 @@ func (p *partitionOffsets) forceSetLastReadableOffset(offset int64) {
 }
 
 type seqHolder struct {
-	seq     int64
-	tableID sst.SSTableID
+	seq       int64
+	tableID   sst.SSTableID
+	tableSize int64
 }
 
 type seqHeap []seqHolder
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/objstore/dev"
 	"github.com/spirit-labs/tektite/parthash"
@@ -15,6 +16,7 @@ import (
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/stretchr/testify/require"
 	"math/rand"
+	"slices"
 	"sort"
 	"testing"
 )
@@ -338,6 +340,67 @@ func TestOffsetsCacheUnknownTopicID(t *testing.T) {
 	require.Equal(t, "generate offsets: unknown topic: 2323", err.Error())
 }
 
+func TestOffsetsCachePartitionsAdded(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	bucketName := "test-bucket"
+	tableID := setupInitialOffsets(t, objStore, bucketName)
+	topicProvider := &testTopicMetaProvider{
+		infos: map[int]topicmeta.TopicInfo{
+			7: {
+				Name:           "topic1",
+				ID:             7,
+				PartitionCount: 4,
+			},
+		},
+	}
+	oc, err := NewOffsetsCache(topicProvider, &testLsmHolder{
+		tableID: tableID,
+	}, objStore, bucketName)
+	require.NoError(t, err)
+	err = oc.Start()
+	require.NoError(t, err)
+
+	generate := func(partitionID int) ([]OffsetTopicInfo, error) {
+		offs, _, err := oc.GenerateOffsets([]GenerateOffsetTopicInfo{
+			{
+				TopicID: 7,
+				PartitionInfos: []GenerateOffsetPartitionInfo{
+					{
+						PartitionID: partitionID,
+						NumOffsets:  10,
+					},
+				},
+			},
+		})
+		return offs, err
+	}
+
+	offs, err := generate(0)
+	require.NoError(t, err)
+	require.Equal(t, 1234+10, int(offs[0].PartitionInfos[0].Offset))
+	_, err = generate(5)
+	require.Error(t, err)
+	require.Equal(t, "partition offset out of range: 5", err.Error())
+
+	// Add partitions to the topic
+	topicProvider.infos[7] = topicmeta.TopicInfo{
+		Name:           "topic1",
+		ID:             7,
+		PartitionCount: 6,
+	}
+	offs, err = generate(5)
+	require.NoError(t, err)
+	require.Equal(t, 9, int(offs[0].PartitionInfos[0].Offset))
+	// Existing partitions must be unaffected
+	offs, err = generate(0)
+	require.NoError(t, err)
+	require.Equal(t, 1234+20, int(offs[0].PartitionInfos[0].Offset))
+	lro, exists, err := oc.GetLastReadableOffset(7, 5)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, -1, int(lro))
+}
+
 func TestMembershipChanged(t *testing.T) {
 	oc := setupAndStartCache(t)
 
@@ -406,7 +469,7 @@ func TestMembershipChanged(t *testing.T) {
 	// Now any attempt to register with this sequence should not release anything
 
 	tableID := sst.SSTableID(sst.CreateSSTableId())
-	offs, tabID, err := oc.MaybeReleaseOffsets(seq, tableID)
+	offs, tabID, err := oc.MaybeReleaseOffsets(seq, tableID, 0)
 	require.Error(t, err)
 	require.Nil(t, offs)
 	require.Nil(t, tabID)
@@ -419,7 +482,7 @@ func TestMembershipChanged(t *testing.T) {
 
 	// Register should now work
 	tableID = sst.SSTableID(sst.CreateSSTableId())
-	releasedOffs, tabIDs, err := oc.MaybeReleaseOffsets(seq, tableID)
+	releasedOffs, tabIDs, err := oc.MaybeReleaseOffsets(seq, tableID, 0)
 	require.NoError(t, err)
 	require.Equal(t, []sst.SSTableID{tableID}, tabIDs)
 	require.Equal(t, offs, releasedOffs)
@@ -476,13 +539,25 @@ var testTopicProvider = &testTopicMetaProvider{
 }
 
 type testLsmHolder struct {
-	tableID sst.SSTableID
+	tableID     sst.SSTableID
+	rangeTables []sst.SSTableID
 }
 
 func (t *testLsmHolder) GetTablesForHighestKeyWithPrefix(_ []byte) ([]sst.SSTableID, error) {
 	return []sst.SSTableID{t.tableID}, nil
 }
 
+func (t *testLsmHolder) QueryTablesInRange(_ []byte, _ []byte) (lsm.OverlappingTables, error) {
+	if t.rangeTables == nil {
+		return lsm.OverlappingTables{{{ID: t.tableID}}}, nil
+	}
+	var tables lsm.OverlappingTables
+	for _, tableID := range t.rangeTables {
+		tables = append(tables, []lsm.QueryTableInfo{{ID: tableID}})
+	}
+	return tables, nil
+}
+
 func createDataEntry(t *testing.T, topicID int, partitionID int, offset int) common.KV {
 	partHashes, err := parthash.NewPartitionHashes(0)
 	require.NoError(t, err)
@@ -1056,7 +1131,7 @@ func testMaybeReleaseOffsets(t *testing.T, shuffle bool) {
 
 	var receivedTables []sst.SSTableID
 	for _, entry := range toSend {
-		_, tables, err := oc.MaybeReleaseOffsets(entry.sequence, sst.SSTableID(entry.tableID))
+		_, tables, err := oc.MaybeReleaseOffsets(entry.sequence, sst.SSTableID(entry.tableID), 0)
 		require.NoError(t, err)
 		receivedTables = append(receivedTables, tables...)
 	}
@@ -1067,3 +1142,409 @@ func testMaybeReleaseOffsets(t *testing.T, shuffle bool) {
 		require.Equal(t, entry.tableID, string(receivedTables[i]))
 	}
 }
+
+func TestLastStableOffsetNoTransactions(t *testing.T) {
+	oc := setupAndStartCache(t)
+	offs, seq := generateOffsetsWithTxEvents(t, oc, 7, 1, 100, nil)
+	releaseOffsets(t, oc, seq, 7, 1, offs[0].PartitionInfos[0].Offset)
+	verifyLastStableOffset(t, oc, 7, 1, 3456+100)
+}
+
+func TestLastStableOffsetOpenTransaction(t *testing.T) {
+	oc := setupAndStartCache(t)
+	// First offset is 3457
+	_, seq := generateOffsetsWithTxEvents(t, oc, 7, 1, 100, nil)
+	releaseOffsets(t, oc, seq, 7, 1, 3456+100)
+
+	// Transactional data starts at offset 3456 + 100 + 11
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 100, []TxEvent{
+		{ProducerID: 23, Offset: 10},
+		{ProducerID: 23, Offset: 20},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+100+10)
+
+	// More data, but transaction still open so LSO doesn't move
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 100, nil)
+	releaseOffsets(t, oc, seq, 7, 1, 3456+100+10)
+
+	// Now write the marker
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 23, Offset: 0, End: true},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+301)
+	verifyLastStableOffset(t, oc, 7, 1, 3456+301)
+}
+
+func TestLastStableOffsetMultipleOpenTransactions(t *testing.T) {
+	oc := setupAndStartCache(t)
+	_, seq := generateOffsetsWithTxEvents(t, oc, 7, 1, 100, []TxEvent{
+		{ProducerID: 23, Offset: 10},
+		{ProducerID: 24, Offset: 20},
+		{ProducerID: 25, Offset: 30},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+10)
+
+	// End the transaction for producer 24 - should not change LSO
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 24, Offset: 0, End: true},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+10)
+
+	// End the transaction for producer 23 - LSO should now be just before first offset of producer 25 transaction
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 23, Offset: 0, End: true},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+30)
+
+	_, seq = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 25, Offset: 0, End: true},
+	})
+	releaseOffsets(t, oc, seq, 7, 1, 3456+103)
+}
+
+func TestLastStableOffsetTxEventsAppliedOnRelease(t *testing.T) {
+	oc := setupAndStartCache(t)
+	_, seq1 := generateOffsetsWithTxEvents(t, oc, 7, 1, 100, []TxEvent{
+		{ProducerID: 23, Offset: 50},
+	})
+	_, seq2 := generateOffsetsWithTxEvents(t, oc, 7, 1, 10, []TxEvent{
+		{ProducerID: 23, Offset: 0, End: true},
+	})
+	// Release out of order, nothing released until seq1 released
+	_, tableIDs, err := oc.MaybeReleaseOffsets(seq2, []byte(sst.CreateSSTableId()), 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(tableIDs))
+	verifyLastStableOffset(t, oc, 7, 1, 3456)
+
+	// Releasing seq1 releases both, so the transaction is complete
+	infos, tableIDs, err := oc.MaybeReleaseOffsets(seq1, []byte(sst.CreateSSTableId()), 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(tableIDs))
+	require.Equal(t, 3456+110, int(infos[0].PartitionInfos[0].LastStableOffset))
+	verifyLastStableOffset(t, oc, 7, 1, 3456+110)
+}
+
+func TestGenerateOffsetsTxFirstOffsets(t *testing.T) {
+	oc := setupAndStartCache(t)
+	// First offset is 3457
+	offs, _ := generateOffsetsWithTxEvents(t, oc, 7, 1, 100, []TxEvent{
+		{ProducerID: 23, Offset: 50},
+	})
+	require.Equal(t, []int64{3457 + 50}, offs[0].PartitionInfos[0].TxFirstOffsets)
+	// The first offsets are as of the offsets generated, not released
+	offs, _ = generateOffsetsWithTxEvents(t, oc, 7, 1, 10, []TxEvent{
+		{ProducerID: 24, Offset: 0},
+		{ProducerID: 23, Offset: 5},
+		{ProducerID: 23, Offset: 6, End: true},
+		{ProducerID: 23, Offset: 7},
+	})
+	require.Equal(t, []int64{3557, 3507, 3507, 3557 + 7}, offs[0].PartitionInfos[0].TxFirstOffsets)
+	// Start of the transaction not known
+	offs, _ = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 25, Offset: 0, End: true},
+	})
+	require.Equal(t, []int64{-1}, offs[0].PartitionInfos[0].TxFirstOffsets)
+	offs, _ = generateOffsetsWithTxEvents(t, oc, 7, 1, 1, nil)
+	require.Nil(t, offs[0].PartitionInfos[0].TxFirstOffsets)
+}
+
+func TestLastStableOffsetOpenTransactionsLoaded(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	bucketName := "test-bucket"
+	partHashes, err := parthash.NewPartitionHashes(0)
+	require.NoError(t, err)
+	partHash, err := partHashes.GetPartitionHash(7, 1)
+	require.NoError(t, err)
+	putTable := func(kvs []common.KV) sst.SSTableID {
+		slices.SortFunc(kvs, func(a, b common.KV) int {
+			return bytes.Compare(a.Key, b.Key)
+		})
+		table, _, _, _, _, err := sst.BuildSSTable(common.DataFormatV1, 0, 0, common.NewKvSliceIterator(kvs))
+		require.NoError(t, err)
+		tableID := sst.CreateSSTableId()
+		err = objStore.Put(context.Background(), bucketName, tableID, table.Serialize())
+		require.NoError(t, err)
+		return []byte(tableID)
+	}
+	tableID1 := putTable([]common.KV{
+		createDataEntry(t, 7, 1, 3456),
+		CreateOpenTransactionKV(partHash, 23, 3000, 3000),
+		CreateOpenTransactionKV(partHash, 24, 2000, 3100),
+	})
+	// The transaction for producer 24 ended in a later table
+	tableID2 := putTable([]common.KV{CreateOpenTransactionTombstone(partHash, 24, 3200)})
+	oc, err := NewOffsetsCache(testTopicProvider, &testLsmHolder{
+		tableID:     tableID1,
+		rangeTables: []sst.SSTableID{tableID2, tableID1},
+	}, objStore, bucketName)
+	require.NoError(t, err)
+	err = oc.Start()
+	require.NoError(t, err)
+
+	// The transaction for producer 23 is still open after the partition is loaded
+	verifyLastStableOffset(t, oc, 7, 1, 2999)
+	offs, seq := generateOffsetsWithTxEvents(t, oc, 7, 1, 1, []TxEvent{
+		{ProducerID: 23, Offset: 0, End: true},
+	})
+	require.Equal(t, []int64{3000}, offs[0].PartitionInfos[0].TxFirstOffsets)
+	releaseOffsets(t, oc, seq, 7, 1, 3457)
+	verifyLastStableOffset(t, oc, 7, 1, 3457)
+}
+
+func TestLastStableOffsetTopicDoesNotExist(t *testing.T) {
+	oc := setupAndStartCache(t)
+	lso, exists, err := oc.GetLastStableOffset(23, 1)
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Equal(t, 0, int(lso))
+}
+
+func generateOffsetsWithTxEvents(t *testing.T, oc *Cache, topicID int, partitionID int, numOffsets int,
+	events []TxEvent) ([]OffsetTopicInfo, int64) {
+	offs, seq, err := oc.GenerateOffsets([]GenerateOffsetTopicInfo{
+		{
+			TopicID: topicID,
+			PartitionInfos: []GenerateOffsetPartitionInfo{
+				{
+					PartitionID: partitionID,
+					NumOffsets:  numOffsets,
+					TxEvents:    events,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return offs, seq
+}
+
+func releaseOffsets(t *testing.T, oc *Cache, seq int64, topicID int, partitionID int, expectedLSO int64) {
+	infos, tableIDs, err := oc.MaybeReleaseOffsets(seq, []byte(sst.CreateSSTableId()), 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tableIDs))
+	require.Equal(t, 1, len(infos))
+	require.Equal(t, topicID, infos[0].TopicID)
+	require.Equal(t, partitionID, infos[0].PartitionInfos[0].PartitionID)
+	require.Equal(t, expectedLSO, infos[0].PartitionInfos[0].LastStableOffset)
+	verifyLastStableOffset(t, oc, topicID, partitionID, expectedLSO)
+}
+
+func verifyLastStableOffset(t *testing.T, oc *Cache, topicID int, partitionID int, expectedLSO int64) {
+	lso, exists, err := oc.GetLastStableOffset(topicID, partitionID)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expectedLSO, lso)
+}
+
+// generateAndReleaseOffsets returns the last offset generated for the first partition
+func generateAndReleaseOffsets(t *testing.T, oc *Cache, infos []GenerateOffsetTopicInfo, tableSize int64) int64 {
+	offs, seq, err := oc.GenerateOffsets(infos)
+	require.NoError(t, err)
+	_, _, err = oc.MaybeReleaseOffsets(seq, []byte(sst.CreateSSTableId()), tableSize)
+	require.NoError(t, err)
+	return offs[0].PartitionInfos[0].Offset
+}
+
+func TestGetMinRetainedOffset(t *testing.T) {
+	oc := setupAndStartCache(t)
+	// 10 tables, each with 10 offsets and 1000 bytes
+	var firstOffset int64
+	for i := 0; i < 10; i++ {
+		lastOffset := generateAndReleaseOffsets(t, oc, []GenerateOffsetTopicInfo{
+			{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 1, NumOffsets: 10}}},
+		}, 1000)
+		if i == 0 {
+			firstOffset = lastOffset - 9
+		}
+	}
+	// Unknown partition, and partition with no limit
+	require.Equal(t, 0, int(getMinRetainedOffset(t, oc, 7, 2, 3500)))
+	require.Equal(t, 0, int(getMinRetainedOffset(t, oc, 7, 1, 0)))
+	// Limit not exceeded
+	require.Equal(t, 0, int(getMinRetainedOffset(t, oc, 7, 1, 10000)))
+	// The oldest data is removed as long as the remaining data is still at least the limit
+	require.Equal(t, firstOffset+60, getMinRetainedOffset(t, oc, 7, 1, 3500))
+	require.Equal(t, firstOffset+60, getMinRetainedOffset(t, oc, 7, 1, 3500))
+	require.Equal(t, firstOffset+70, getMinRetainedOffset(t, oc, 7, 1, 3000))
+
+	// Add more data
+	generateAndReleaseOffsets(t, oc, []GenerateOffsetTopicInfo{
+		{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 1, NumOffsets: 10}}},
+	}, 1000)
+	require.Equal(t, firstOffset+80, getMinRetainedOffset(t, oc, 7, 1, 3000))
+}
+
+func getMinRetainedOffset(t *testing.T, oc *Cache, topicID int, partitionID int, retentionBytes int64) int64 {
+	minOffset, err := oc.GetMinRetainedOffset(topicID, partitionID, retentionBytes)
+	require.NoError(t, err)
+	return minOffset
+}
+
+func TestGetMinRetainedOffsetSizesLoadedFromLsm(t *testing.T) {
+	// Tables with a block index only have the partition's blocks read
+	for _, format := range []common.DataFormat{common.DataFormatV1, common.DataFormatV2} {
+		t.Run(fmt.Sprintf("format-%d", format), func(t *testing.T) {
+			testGetMinRetainedOffsetSizesLoadedFromLsm(t, format)
+		})
+	}
+}
+
+func testGetMinRetainedOffsetSizesLoadedFromLsm(t *testing.T, format common.DataFormat) {
+	objStore := dev.NewInMemStore(0)
+	bucketName := "test-bucket"
+	// Two tables, each containing 5 batches for the partition
+	var tableIDs []sst.SSTableID
+	var batchSize int64
+	for i := 0; i < 2; i++ {
+		var kvs []common.KV
+		for j := 0; j < 5; j++ {
+			kv := createDataEntry(t, 9, 0, 5*i+j)
+			batchSize = int64(len(kv.Value))
+			kvs = append(kvs, kv)
+		}
+		table, _, _, _, _, err := sst.BuildSSTable(format, 0, 0, common.NewKvSliceIterator(kvs))
+		require.NoError(t, err)
+		tableID := sst.CreateSSTableId()
+		err = objStore.Put(context.Background(), bucketName, tableID, table.Serialize())
+		require.NoError(t, err)
+		tableIDs = append(tableIDs, []byte(tableID))
+	}
+	topicProvider := &testTopicMetaProvider{
+		infos: map[int]topicmeta.TopicInfo{
+			9: {
+				Name:           "topic3",
+				ID:             9,
+				PartitionCount: 1,
+				RetentionBytes: 5 * batchSize,
+			},
+		},
+	}
+	// Return the tables most recent first, as the LSM does
+	oc, err := NewOffsetsCache(topicProvider, &testLsmHolder{
+		tableID:     tableIDs[1],
+		rangeTables: []sst.SSTableID{tableIDs[1], tableIDs[0]},
+	}, objStore, bucketName)
+	require.NoError(t, err)
+	err = oc.Start()
+	require.NoError(t, err)
+
+	// The existing data is counted without anything being written since the cache started
+	minOffset, err := oc.GetMinRetainedOffset(9, 0, 6*batchSize)
+	require.NoError(t, err)
+	require.Equal(t, 0, int(minOffset))
+	minOffset, err = oc.GetMinRetainedOffset(9, 0, 5*batchSize)
+	require.NoError(t, err)
+	require.Equal(t, 5, int(minOffset))
+}
+
+func TestGetMinRetainedOffsetForTime(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	bucketName := "test-bucket"
+	// Two tables, each containing 5 batches for the partition, with timestamps 1000-1004 and 2000-2004
+	var tableIDs []sst.SSTableID
+	for i := 0; i < 2; i++ {
+		var kvs []common.KV
+		for j := 0; j < 5; j++ {
+			offset := 5*i + j
+			kv := createDataEntry(t, 9, 0, offset)
+			kv.Value = testutils.CreateKafkaRecordBatch([]testutils.RawKafkaMessage{
+				{Key: []byte("key"), Value: []byte("val"), Timestamp: int64(1000*(i+1) + j)},
+			}, int64(offset))
+			kvs = append(kvs, kv)
+		}
+		table, _, _, _, _, err := sst.BuildSSTable(common.DataFormatV2, 0, 0, common.NewKvSliceIterator(kvs))
+		require.NoError(t, err)
+		tableID := sst.CreateSSTableId()
+		err = objStore.Put(context.Background(), bucketName, tableID, table.Serialize())
+		require.NoError(t, err)
+		tableIDs = append(tableIDs, []byte(tableID))
+	}
+	topicProvider := &testTopicMetaProvider{
+		infos: map[int]topicmeta.TopicInfo{
+			9: {Name: "topic3", ID: 9, PartitionCount: 1},
+		},
+	}
+	oc, err := NewOffsetsCache(topicProvider, &testLsmHolder{
+		tableID:     tableIDs[1],
+		rangeTables: []sst.SSTableID{tableIDs[1], tableIDs[0]},
+	}, objStore, bucketName)
+	require.NoError(t, err)
+	err = oc.Start()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		minTimestamp      int64
+		expectedMinOffset int64
+	}{
+		{minTimestamp: 0, expectedMinOffset: 0},
+		{minTimestamp: 1000, expectedMinOffset: 0},
+		{minTimestamp: 1003, expectedMinOffset: 3},
+		{minTimestamp: 1500, expectedMinOffset: 5},
+		{minTimestamp: 2004, expectedMinOffset: 9},
+		// All the data has expired
+		{minTimestamp: 3000, expectedMinOffset: 10},
+	}
+	for _, tc := range testCases {
+		minOffset, err := oc.GetMinRetainedOffsetForTime(9, 0, tc.minTimestamp)
+		require.NoError(t, err)
+		require.Equal(t, tc.expectedMinOffset, minOffset, "min timestamp %d", tc.minTimestamp)
+	}
+}
+
+func TestPartitionSizesAttributedByNumOffsets(t *testing.T) {
+	oc := setupAndStartCache(t)
+	offs, seq, err := oc.GenerateOffsets([]GenerateOffsetTopicInfo{
+		{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 0, NumOffsets: 30}}},
+		{TopicID: 8, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 1, NumOffsets: 10}}},
+	})
+	require.NoError(t, err)
+	_, _, err = oc.MaybeReleaseOffsets(seq, []byte(sst.CreateSSTableId()), 4000)
+	require.NoError(t, err)
+	ps := oc.partitionSizes[partitionKey{topicID: 7, partitionID: 0}]
+	require.Equal(t, []sizeCheckpoint{{lastOffset: offs[0].PartitionInfos[0].Offset, cumulativeSize: 3000}}, ps.checkpoints)
+	ps = oc.partitionSizes[partitionKey{topicID: 8, partitionID: 1}]
+	require.Equal(t, []sizeCheckpoint{{lastOffset: offs[1].PartitionInfos[0].Offset, cumulativeSize: 1000}}, ps.checkpoints)
+}
+
+func TestPartitionSizesUnorderedRelease(t *testing.T) {
+	oc := setupAndStartCache(t)
+	var seqs []int64
+	var lastOffsets []int64
+	for i := 0; i < 10; i++ {
+		offs, seq, err := oc.GenerateOffsets([]GenerateOffsetTopicInfo{
+			{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 1, NumOffsets: 10}}},
+		})
+		require.NoError(t, err)
+		seqs = append(seqs, seq)
+		lastOffsets = append(lastOffsets, offs[0].PartitionInfos[0].Offset)
+	}
+	// Release in reverse order, sizes must still be recorded in offset order
+	for i := len(seqs) - 1; i >= 0; i-- {
+		_, _, err := oc.MaybeReleaseOffsets(seqs[i], []byte(sst.CreateSSTableId()), int64(100*(i+1)))
+		require.NoError(t, err)
+	}
+	ps := oc.partitionSizes[partitionKey{topicID: 7, partitionID: 1}]
+	require.Equal(t, 10, len(ps.checkpoints))
+	var cumulativeSize int64
+	for i, checkpoint := range ps.checkpoints {
+		cumulativeSize += int64(100 * (i + 1))
+		require.Equal(t, sizeCheckpoint{lastOffset: lastOffsets[i], cumulativeSize: cumulativeSize}, checkpoint)
+	}
+}
+
+func TestPartitionSizeCheckpointsThinned(t *testing.T) {
+	oc := setupAndStartCache(t)
+	numTables := 10 * maxSizeCheckpoints
+	var lastOffset int64
+	for i := 0; i < numTables; i++ {
+		lastOffset = generateAndReleaseOffsets(t, oc, []GenerateOffsetTopicInfo{
+			{TopicID: 7, PartitionInfos: []GenerateOffsetPartitionInfo{{PartitionID: 1, NumOffsets: 1}}},
+		}, 100)
+	}
+	ps := oc.partitionSizes[partitionKey{topicID: 7, partitionID: 1}]
+	require.LessOrEqual(t, len(ps.checkpoints), maxSizeCheckpoints)
+	last := ps.checkpoints[len(ps.checkpoints)-1]
+	require.Equal(t, sizeCheckpoint{lastOffset: lastOffset, cumulativeSize: int64(100 * numTables)}, last)
+	for i := 1; i < len(ps.checkpoints); i++ {
+		require.Greater(t, ps.checkpoints[i].lastOffset, ps.checkpoints[i-1].lastOffset)
+	}
+}
@@ -0,0 +1,210 @@
+package offsets
+
+import (
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/sst"
+	"sort"
+)
+
+// maxSizeCheckpoints is the maximum number of size checkpoints we keep for a partition. When exceeded we discard every
+// other checkpoint, so the granularity at which data can be deleted gets coarser as the partition grows.
+const maxSizeCheckpoints = 64
+
+type partitionKey struct {
+	topicID     int
+	partitionID int
+}
+
+// partitionWrite records how many offsets were written for a partition in a particular sequence
+type partitionWrite struct {
+	topicID     int
+	partitionID int
+	lastOffset  int64
+	numOffsets  int
+}
+
+// sizeCheckpoint records the cumulative number of bytes written to a partition up to and including lastOffset
+type sizeCheckpoint struct {
+	lastOffset     int64
+	cumulativeSize int64
+}
+
+type partitionSize struct {
+	checkpoints []sizeCheckpoint
+	// deletedSize is the cumulative size of data before minOffset
+	deletedSize int64
+	minOffset   int64
+}
+
+func extractWrites(infos []GenerateOffsetTopicInfo, res []OffsetTopicInfo) []partitionWrite {
+	var writes []partitionWrite
+	for i, topicInfo := range infos {
+		for j, partitionInfo := range topicInfo.PartitionInfos {
+			writes = append(writes, partitionWrite{
+				topicID:     topicInfo.TopicID,
+				partitionID: partitionInfo.PartitionID,
+				lastOffset:  res[i].PartitionInfos[j].Offset,
+				numOffsets:  partitionInfo.NumOffsets,
+			})
+		}
+	}
+	return writes
+}
+
+// recordSizes attributes the size of a table to the partitions written in it, in proportion to the number of offsets
+// written for each partition. Must be called in sequence order.
+func (c *Cache) recordSizes(writes []partitionWrite, tableSize int64) {
+	totalOffsets := 0
+	for _, write := range writes {
+		totalOffsets += write.numOffsets
+	}
+	if totalOffsets == 0 || tableSize <= 0 {
+		return
+	}
+	c.sizesLock.Lock()
+	defer c.sizesLock.Unlock()
+	for _, write := range writes {
+		key := partitionKey{topicID: write.topicID, partitionID: write.partitionID}
+		ps, ok := c.partitionSizes[key]
+		if !ok {
+			ps = &partitionSize{}
+			c.partitionSizes[key] = ps
+		}
+		ps.addCheckpoint(write.lastOffset, tableSize*int64(write.numOffsets)/int64(totalOffsets))
+	}
+}
+
+func (p *partitionSize) addCheckpoint(lastOffset int64, size int64) {
+	cumulativeSize := p.deletedSize
+	if len(p.checkpoints) > 0 {
+		cumulativeSize = p.checkpoints[len(p.checkpoints)-1].cumulativeSize
+	}
+	p.checkpoints = append(p.checkpoints, sizeCheckpoint{
+		lastOffset:     lastOffset,
+		cumulativeSize: cumulativeSize + size,
+	})
+	if len(p.checkpoints) > maxSizeCheckpoints {
+		// Keep every other checkpoint, always including the latest
+		last := len(p.checkpoints) - 1
+		j := 0
+		for i := last % 2; i <= last; i += 2 {
+			p.checkpoints[j] = p.checkpoints[i]
+			j++
+		}
+		p.checkpoints = p.checkpoints[:j]
+	}
+}
+
+// loadPartitionSize rebuilds the size checkpoints of a partition from the tables in the LSM which contain its data, so
+// that data written before the cache was started is counted. A checkpoint is added for each table, at the highest offset
+// of the partition in the table. Batches before the truncated offset have already been deleted so are not counted. Only
+// the blocks of each table which contain the partition's data are read, but that is still all the partition's data, so
+// this is only done for topics with a retention size.
+func (c *Cache) loadPartitionSize(topicID int, partitionID int, truncatedOffset int64) error {
+	info, exists, err := c.topicMetaProvider.GetTopicInfoByID(topicID)
+	if err != nil {
+		return err
+	}
+	if !exists || !info.IsDeleteEnabled() || info.RetentionBytes <= 0 {
+		return nil
+	}
+	partHash, err := c.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return err
+	}
+	prefix := append(common.ByteSliceCopy(partHash), common.EntryTypeTopicData)
+	keyEnd := common.IncBigEndianBytes(common.ByteSliceCopy(prefix))
+	tables, err := c.querier.QueryTablesInRange(prefix, keyEnd)
+	if err != nil {
+		return err
+	}
+	// cumulativeSize holds the size of the data in each table until the checkpoints are added
+	var tableSizes []sizeCheckpoint
+	for _, nonOverlapping := range tables {
+		for _, tableInfo := range nonOverlapping {
+			tableSize, err := c.loadPartitionSizeInTable(tableInfo.ID, prefix, keyEnd, truncatedOffset)
+			if err != nil {
+				return err
+			}
+			if tableSize.cumulativeSize > 0 {
+				tableSizes = append(tableSizes, tableSize)
+			}
+		}
+	}
+	sort.Slice(tableSizes, func(i, j int) bool {
+		return tableSizes[i].lastOffset < tableSizes[j].lastOffset
+	})
+	ps := &partitionSize{}
+	for _, tableSize := range tableSizes {
+		ps.addCheckpoint(tableSize.lastOffset, tableSize.cumulativeSize)
+	}
+	c.sizesLock.Lock()
+	defer c.sizesLock.Unlock()
+	c.partitionSizes[partitionKey{topicID: topicID, partitionID: partitionID}] = ps
+	return nil
+}
+
+// loadPartitionSizeInTable returns the highest offset and the total size of the partition's batches in the table
+func (c *Cache) loadPartitionSizeInTable(tableID sst.SSTableID, prefix []byte, keyEnd []byte,
+	truncatedOffset int64) (sizeCheckpoint, error) {
+	iter, err := sst.NewRangedSSTableIterator(tableID, c.getTableIndex, c.getRangeWithRetry, c.getTable, prefix, keyEnd)
+	if err != nil {
+		return sizeCheckpoint{}, err
+	}
+	defer iter.Close()
+	res := sizeCheckpoint{lastOffset: -1}
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return sizeCheckpoint{}, err
+		}
+		if !ok {
+			return res, nil
+		}
+		if len(kv.Value) == 0 {
+			// A prefix delete from records being deleted
+			continue
+		}
+		baseOffset, _ := encoding.KeyDecodeInt(kv.Key, 17)
+		if baseOffset < truncatedOffset {
+			continue
+		}
+		lastOffsetDelta := int32(binary.BigEndian.Uint32(kv.Value[23:]))
+		res.lastOffset = max(res.lastOffset, baseOffset+int64(lastOffsetDelta))
+		res.cumulativeSize += int64(len(kv.Value))
+	}
+}
+
+// GetMinRetainedOffset returns the lowest offset of the partition that must be retained so that the partition size
+// does not exceed retentionBytes. As with Kafka, the oldest data is only removed while the remaining data is still at
+// least retentionBytes in size. Returns 0 if no data needs to be removed.
+func (c *Cache) GetMinRetainedOffset(topicID int, partitionID int, retentionBytes int64) (int64, error) {
+	// Loading the partition's offsets rebuilds its size from the LSM
+	if _, _, err := c.GetLastReadableOffset(topicID, partitionID); err != nil {
+		return 0, err
+	}
+	c.sizesLock.Lock()
+	defer c.sizesLock.Unlock()
+	ps, ok := c.partitionSizes[partitionKey{topicID: topicID, partitionID: partitionID}]
+	if !ok {
+		return 0, nil
+	}
+	if retentionBytes > 0 && len(ps.checkpoints) > 0 {
+		totalSize := ps.checkpoints[len(ps.checkpoints)-1].cumulativeSize
+		cut := -1
+		for i, checkpoint := range ps.checkpoints {
+			if totalSize-checkpoint.cumulativeSize < retentionBytes {
+				break
+			}
+			cut = i
+		}
+		if cut >= 0 {
+			ps.minOffset = ps.checkpoints[cut].lastOffset + 1
+			ps.deletedSize = ps.checkpoints[cut].cumulativeSize
+			ps.checkpoints = ps.checkpoints[cut+1:]
+		}
+	}
+	return ps.minOffset, nil
+}
@@ -0,0 +1,54 @@
+package offsets
+
+import (
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/kafkaencoding"
+	"github.com/spirit-labs/tektite/queryutils"
+)
+
+// GetMinRetainedOffsetForTime returns the lowest offset of the partition that must be retained so that no data with a
+// timestamp >= minTimestamp is removed. As with Kafka, data is removed in offset order up to the first batch whose max
+// timestamp is not before minTimestamp, so a batch with a later timestamp holds back the removal of those after it.
+// Only data after the truncated offset is read, and tables whose data is all before minTimestamp are skipped without
+// being read. Returns 0 if no data needs to be removed.
+func (c *Cache) GetMinRetainedOffsetForTime(topicID int, partitionID int, minTimestamp int64) (int64, error) {
+	lastReadableOffset, exists, err := c.GetLastReadableOffset(topicID, partitionID)
+	if err != nil || !exists || lastReadableOffset < 0 {
+		return 0, err
+	}
+	partHash, err := c.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return 0, err
+	}
+	prefix := append(common.ByteSliceCopy(partHash), common.EntryTypeTopicData)
+	keyStart := encoding.KeyEncodeInt(common.ByteSliceCopy(prefix), c.GetTruncatedOffset(topicID, partitionID))
+	keyEnd := encoding.KeyEncodeInt(prefix, lastReadableOffset+1)
+	tables, err := c.querier.QueryTablesInRange(keyStart, keyEnd)
+	if err != nil {
+		return 0, err
+	}
+	iter, err := queryutils.CreateRangedIteratorForTables(tables.FilterByMaxTimestamp(minTimestamp), keyStart, keyEnd,
+		c.getTableIndex, c.getRangeWithRetry, c.getTable)
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			// All the data is before minTimestamp
+			return lastReadableOffset + 1, nil
+		}
+		if len(kv.Value) == 0 {
+			// A prefix delete from records being deleted
+			continue
+		}
+		if kafkaencoding.MaxTimestamp(kv.Value) >= minTimestamp {
+			return kafkaencoding.BaseOffset(kv.Value), nil
+		}
+	}
+}
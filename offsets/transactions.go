@@ -0,0 +1,185 @@
+package offsets
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/queryutils"
+	"github.com/spirit-labs/tektite/sst"
+	"maps"
+)
+
+/*
+The state of transactions is persisted in the same tables as the transactional data, so it is always consistent with
+the data. When offsets are generated, the cache applies the transaction events to the open transactions as of the
+offsets generated, and returns the first offset of the transaction of each event. The caller then adds to the table:
+
+* For the first data of a transaction, an open transaction entry keyed by producer id, holding the first offset of the
+transaction. This is used to rebuild the open transactions, and so the last stable offset, when a partition is loaded.
+* For a transaction marker, a tombstone for the open transaction entry, and for an abort marker, an aborted transaction
+entry keyed by the offset of the marker, holding the producer id and first offset of the transaction. Fetches with
+read_committed isolation level return the aborted transactions which overlap the fetched data, so the consumer can
+discard their data, wherever the abort marker is.
+
+Tables can be registered out of offset order, so the entries are versioned with the offset of the batch they were
+written for - the most recent state of a transaction always has the highest version.
+*/
+
+const abortedTransactionFormatVersion uint16 = 1
+
+// AbortedTransaction is a transaction which was aborted. LastOffset is the offset of the abort marker.
+type AbortedTransaction struct {
+	ProducerID  int64
+	FirstOffset int64
+	LastOffset  int64
+}
+
+// CreateOpenTransactionKV creates the KV used to persist the first offset of an open transaction of the partition with
+// the provided partition hash. offset is the offset of the batch it is written for.
+func CreateOpenTransactionKV(partitionHash []byte, producerID int64, firstOffset int64, offset int64) common.KV {
+	key := encoding.EncodeVersion(createOpenTransactionKey(partitionHash, producerID), uint64(offset))
+	return common.KV{Key: key, Value: binary.BigEndian.AppendUint64(nil, uint64(firstOffset))}
+}
+
+// CreateOpenTransactionTombstone creates the KV used to remove an open transaction when it ends. offset is the offset of
+// the transaction marker.
+func CreateOpenTransactionTombstone(partitionHash []byte, producerID int64, offset int64) common.KV {
+	return common.KV{Key: encoding.EncodeVersion(createOpenTransactionKey(partitionHash, producerID), uint64(offset))}
+}
+
+// CreateAbortedTransactionKV creates the KV used to persist an aborted transaction of the partition with the provided
+// partition hash
+func CreateAbortedTransactionKV(partitionHash []byte, abortedTx AbortedTransaction) common.KV {
+	key := encoding.KeyEncodeInt(createAbortedTransactionPrefix(partitionHash), abortedTx.LastOffset)
+	key = encoding.EncodeVersion(key, uint64(abortedTx.LastOffset))
+	value := make([]byte, 0, 18)
+	value = binary.BigEndian.AppendUint16(value, abortedTransactionFormatVersion)
+	value = binary.BigEndian.AppendUint64(value, uint64(abortedTx.ProducerID))
+	value = binary.BigEndian.AppendUint64(value, uint64(abortedTx.FirstOffset))
+	return common.KV{Key: key, Value: value}
+}
+
+// CreateAbortedTransactionsKeyRange returns the key range of the aborted transactions of the partition with the provided
+// partition hash whose abort markers are between fromOffset and toOffset inclusive
+func CreateAbortedTransactionsKeyRange(partitionHash []byte, fromOffset int64, toOffset int64) ([]byte, []byte) {
+	prefix := createAbortedTransactionPrefix(partitionHash)
+	keyStart := encoding.KeyEncodeInt(common.ByteSliceCopy(prefix), fromOffset)
+	keyEnd := encoding.KeyEncodeInt(prefix, toOffset+1)
+	return keyStart, keyEnd
+}
+
+// DecodeAbortedTransaction decodes an aborted transaction KV created with CreateAbortedTransactionKV
+func DecodeAbortedTransaction(kv common.KV) AbortedTransaction {
+	lastOffset, _ := encoding.KeyDecodeInt(kv.Key, 17)
+	// First two bytes of the value are the format version
+	return AbortedTransaction{
+		ProducerID:  int64(binary.BigEndian.Uint64(kv.Value[2:])),
+		FirstOffset: int64(binary.BigEndian.Uint64(kv.Value[10:])),
+		LastOffset:  lastOffset,
+	}
+}
+
+func createOpenTransactionPrefix(partitionHash []byte) []byte {
+	prefix := make([]byte, 0, 33)
+	prefix = append(prefix, partitionHash...)
+	return append(prefix, common.EntryTypeOpenTransaction)
+}
+
+func createOpenTransactionKey(partitionHash []byte, producerID int64) []byte {
+	return encoding.KeyEncodeInt(createOpenTransactionPrefix(partitionHash), producerID)
+}
+
+func createAbortedTransactionPrefix(partitionHash []byte) []byte {
+	prefix := make([]byte, 0, 41)
+	prefix = append(prefix, partitionHash...)
+	return append(prefix, common.EntryTypeAbortedTransaction)
+}
+
+// loadOpenTransactions loads the persisted open transactions of the partition, as a map of producer id to first offset
+func (c *Cache) loadOpenTransactions(topicID int, partitionID int) (map[int64]int64, error) {
+	partHash, err := c.partitionHashes.GetPartitionHash(topicID, partitionID)
+	if err != nil {
+		return nil, err
+	}
+	prefix := createOpenTransactionPrefix(partHash)
+	iter, err := queryutils.CreateIteratorForKeyRange(prefix, common.IncBigEndianBytes(common.ByteSliceCopy(prefix)),
+		c.querier, c.getTable)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	var openTxs map[int64]int64
+	for {
+		ok, kv, err := iter.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return openTxs, nil
+		}
+		if len(kv.Value) == 0 {
+			// The transaction has ended
+			continue
+		}
+		producerID, _ := encoding.KeyDecodeInt(kv.Key, 17)
+		if openTxs == nil {
+			openTxs = map[int64]int64{}
+		}
+		openTxs[producerID] = int64(binary.BigEndian.Uint64(kv.Value))
+	}
+}
+
+func (c *Cache) getTable(tableID sst.SSTableID) (*sst.SSTable, error) {
+	buff, err := c.getWithRetry(tableID)
+	if err != nil {
+		return nil, err
+	}
+	if len(buff) == 0 {
+		return nil, errors.Errorf("ssttable %s not found", tableID)
+	}
+	var table sst.SSTable
+	table.Deserialize(buff, 0)
+	return &table, nil
+}
+
+func (c *Cache) getTableIndex(tableID sst.SSTableID) (*sst.SSTable, error) {
+	return sst.ReadTableIndex(tableID, c.getRangeWithRetry)
+}
+
+// generateTxEvents applies the transaction events to the open transactions as of the offsets generated, and returns the
+// first offset of the transaction of each event, or -1 if the start of the transaction is not known. firstOffset is the
+// first offset generated for the partition. Must be called with the partition lock held.
+func (p *partitionOffsets) generateTxEvents(events []TxEvent, firstOffset int64) []int64 {
+	if len(events) == 0 {
+		return nil
+	}
+	txFirstOffsets := make([]int64, len(events))
+	for i, event := range events {
+		txFirstOffset, ok := p.generatedOpenTxs[event.ProducerID]
+		if event.End {
+			if !ok {
+				txFirstOffset = -1
+			}
+			delete(p.generatedOpenTxs, event.ProducerID)
+		} else if !ok {
+			txFirstOffset = firstOffset + event.Offset
+			if p.generatedOpenTxs == nil {
+				p.generatedOpenTxs = map[int64]int64{}
+			}
+			p.generatedOpenTxs[event.ProducerID] = txFirstOffset
+		}
+		txFirstOffsets[i] = txFirstOffset
+	}
+	return txFirstOffsets
+}
+
+func (p *partitionOffsets) loadOpenTransactions(topicID int, partitionID int, o *Cache) error {
+	openTxs, err := o.loadOpenTransactions(topicID, partitionID)
+	if err != nil {
+		return err
+	}
+	p.openTxs = openTxs
+	p.generatedOpenTxs = maps.Clone(openTxs)
+	return nil
+}
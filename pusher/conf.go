@@ -1,7 +1,10 @@
 package pusher
 
 import (
+	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	"github.com/spirit-labs/tektite/sst"
 	"time"
 )
 
@@ -10,9 +13,12 @@ type Conf struct {
 	AvailabilityRetryInterval time.Duration
 	BufferMaxSizeBytes        int
 	DataFormat                common.DataFormat
-	DataBucketName            string
-	OffsetSnapshotInterval    time.Duration
-	EnforceProduceOnLeader    bool
+	TableCompression          compress.CompressionType
+	// TableBloomFilterBitsPerKey is the size of each table's bloom filter per key. Zero means no bloom filters.
+	TableBloomFilterBitsPerKey int
+	DataBucketName             string
+	OffsetSnapshotInterval     time.Duration
+	EnforceProduceOnLeader     bool
 }
 
 func NewConf() Conf {
@@ -21,20 +27,38 @@ func NewConf() Conf {
 		WriteTimeout:              DefaultWriteTimeout,
 		AvailabilityRetryInterval: DefaultAvailabilityRetryInterval,
 		DataFormat:                DefaultDataFormat,
+		TableCompression:          DefaultTableCompression,
 		DataBucketName:            DefaultDataBucketName,
 		OffsetSnapshotInterval:    DefaultOffsetSnapshotInterval,
 	}
 }
 
 func (c *Conf) Validate() error {
+	if c.TableCompression != compress.CompressionTypeNone && c.DataFormat == common.DataFormatV1 {
+		return errors.Errorf("table compression %s requires data format %d", c.TableCompression, common.DataFormatV2)
+	}
+	if c.TableBloomFilterBitsPerKey < 0 {
+		return errors.Errorf("invalid table bloom filter bits per key %d", c.TableBloomFilterBitsPerKey)
+	}
+	if c.TableBloomFilterBitsPerKey > 0 && c.DataFormat == common.DataFormatV1 {
+		return errors.Errorf("table bloom filters require data format %d", common.DataFormatV2)
+	}
 	return nil
 }
 
+func (c *Conf) tableBuildOptions() sst.BuildOptions {
+	return sst.BuildOptions{
+		Compression:           c.TableCompression,
+		BloomFilterBitsPerKey: c.TableBloomFilterBitsPerKey,
+	}
+}
+
 const (
 	DefaultWriteTimeout              = 200 * time.Millisecond
 	DefaultAvailabilityRetryInterval = 1 * time.Second
 	DefaultBufferSizeMaxBytes        = 4 * 1024 * 1024
 	DefaultDataFormat                = common.DataFormatV1
+	DefaultTableCompression          = compress.CompressionTypeNone
 	DefaultDataBucketName            = "tektite-data"
 	DefaultOffsetSnapshotInterval    = 5 * time.Second
 )
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
@@ -13,6 +14,7 @@ import (
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/metrics"
 	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/offsets"
 	"github.com/spirit-labs/tektite/parthash"
@@ -53,6 +55,7 @@ type TablePusher struct {
 	stopping             atomic.Bool
 	controllerClient     ControlClient
 	tableGetter          sst.TableGetter
+	filterGetter         sst.BloomFilterGetter
 	leaderChecker        LeaderChecker
 	partitionRecords     map[int]map[int][]bufferedRecords
 	produceCompletions   []func(error)
@@ -67,6 +70,7 @@ type TablePusher struct {
 	sizeBytes            int
 	producerSeqs         map[int]map[int]map[int]*sequenceInfo
 	stats                Stats
+	metrics              pusherMetrics
 }
 
 type bufferedRecords [][]byte
@@ -93,20 +97,26 @@ type Stats struct {
 	ProducedBatchCount int64
 }
 
+type pusherMetrics struct {
+	tablePushBytes    prometheus.Histogram
+	tablePushDuration prometheus.Histogram
+}
+
 const (
 	objStoreAvailabilityTimeout = 5 * time.Second
 	offsetSnapshotFormatVersion = 1
 )
 
 func NewTablePusher(cfg Conf, topicProvider topicInfoProvider, objStore objstore.Client,
-	clientFactory controllerClientFactory, tableGetter sst.TableGetter, partitionHashes *parthash.PartitionHashes,
-	leaderChecker LeaderChecker) (*TablePusher, error) {
+	clientFactory controllerClientFactory, tableGetter sst.TableGetter, filterGetter sst.BloomFilterGetter,
+	partitionHashes *parthash.PartitionHashes, leaderChecker LeaderChecker) (*TablePusher, error) {
 	return &TablePusher{
 		cfg:                cfg,
 		topicProvider:      topicProvider,
 		objStore:           objStore,
 		clientFactory:      clientFactory,
 		tableGetter:        tableGetter,
+		filterGetter:       filterGetter,
 		partitionHashes:    partitionHashes,
 		leaderChecker:      leaderChecker,
 		partitionRecords:   map[int]map[int][]bufferedRecords{},
@@ -114,6 +124,12 @@ func NewTablePusher(cfg Conf, topicProvider topicInfoProvider, objStore objstore
 		directKVs:          map[string][]common.KV{},
 		directCompletions:  map[string][]func(error){},
 		producerSeqs:       map[int]map[int]map[int]*sequenceInfo{},
+		metrics: pusherMetrics{
+			tablePushBytes: metrics.NewHistogram("table_pusher", "table_push_bytes",
+				"size of tables pushed to object storage in bytes", metrics.SizeBuckets),
+			tablePushDuration: metrics.NewHistogram("table_pusher", "table_push_duration_seconds",
+				"time taken to build, push and register a table", metrics.LatencyBuckets),
+		},
 	}, nil
 }
 
@@ -148,6 +164,14 @@ func (t *TablePusher) GetStats() Stats {
 	}
 }
 
+func (t *TablePusher) RegisterMetrics(registry *metrics.Registry) {
+	registry.MustRegister(t.metrics.tablePushBytes, t.metrics.tablePushDuration,
+		metrics.NewCounterFunc("table_pusher", "produced_batches_total", "number of produced batches written",
+			func() float64 {
+				return float64(t.GetStats().ProducedBatchCount)
+			}))
+}
+
 func (t *TablePusher) scheduleWriteTimer(timeout time.Duration) {
 	t.writeTimer = time.AfterFunc(timeout, func() {
 		t.lock.Lock()
@@ -251,6 +275,9 @@ func (t *TablePusher) HandleProduceRequest(req *kafkaprotocol.ProduceRequest,
 	partitions:
 		for j, partitionData := range topicData.PartitionData {
 			partitionResponses[j].Index = partitionData.Index
+			// We don't use log append time or track the log start offset, so these are always -1
+			partitionResponses[j].LogAppendTimeMs = -1
+			partitionResponses[j].LogStartOffset = -1
 			partitionID := int(partitionData.Index)
 			if errCode != kafkaprotocol.ErrorCodeNone {
 				setPartitionError(errCode, errMsg, &partitionResponses[j])
@@ -294,6 +321,15 @@ func (t *TablePusher) HandleProduceRequest(req *kafkaprotocol.ProduceRequest,
 				panic("too many records")
 			}
 			recordBatches := extractBatches(partitionData.Records[0])
+			maxMessageBytes := topicInfo.MaxMessageBytes()
+			for _, records := range recordBatches {
+				if len(records) > maxMessageBytes {
+					setPartitionError(kafkaprotocol.ErrorCodeMessageTooLarge,
+						fmt.Sprintf("record batch size %d exceeds max.message.bytes %d for topic: %s", len(records),
+							maxMessageBytes, *topicData.Name), &partitionResponses[j])
+					continue partitions
+				}
+			}
 			for _, records := range recordBatches {
 				dupRes, err := t.checkDuplicates(records, topicInfo.ID, partitionID)
 				if err != nil {
@@ -531,6 +567,7 @@ func (t *TablePusher) write() error {
 		// Nothing to do
 		return nil
 	}
+	start := time.Now()
 	client, err := t.getClient()
 	if err != nil {
 		return err
@@ -543,14 +580,17 @@ func (t *TablePusher) write() error {
 		offsetInfo.PartitionInfos = make([]offsets.GenerateOffsetPartitionInfo, 0, len(partitions))
 		for partitionID, entries := range partitions {
 			totRecords := 0
+			var txEvents []offsets.TxEvent
 			for _, entry := range entries {
 				for _, batch := range entry {
+					txEvents = appendTxEvent(txEvents, batch, totRecords)
 					totRecords += kafkaencoding.NumRecords(batch)
 				}
 			}
 			offsetInfo.PartitionInfos = append(offsetInfo.PartitionInfos, offsets.GenerateOffsetPartitionInfo{
 				PartitionID: partitionID,
 				NumOffsets:  totRecords,
+				TxEvents:    txEvents,
 			})
 		}
 		getOffSetInfos = append(getOffSetInfos, offsetInfo)
@@ -609,6 +649,8 @@ func (t *TablePusher) write() error {
 	// Add any offset snapshots
 	kvs = append(kvs, t.offsetSnapshotKvs...)
 	// Prepare the data KVs
+	var maxTimestamp int64
+	numDataKVs := 0
 	for i, topOffset := range offs {
 		partitionRecs := t.partitionRecords[topOffset.TopicID]
 		for j, partInfo := range topOffset.PartitionInfos {
@@ -619,9 +661,12 @@ func (t *TablePusher) write() error {
 			// The returned offset is the last offset
 			lastOffset := partInfo.Offset
 			offset := lastOffset - int64(getOffSetInfos[i].PartitionInfos[j].NumOffsets) + 1
+			txState := newTxStateKVs(partitionHash, offset, getOffSetInfos[i].PartitionInfos[j].TxEvents,
+				partInfo.TxFirstOffsets)
 			batches := partitionRecs[partInfo.PartitionID]
 			for _, entry := range batches {
 				for _, records := range entry {
+					txState.addBatch(records, offset)
 					/*
 							For each batch there will be one entry in the database.
 							The key is: [partition_hash, entry_type, offset, version]
@@ -651,9 +696,14 @@ func (t *TablePusher) write() error {
 						Key:   key,
 						Value: records,
 					})
+					numDataKVs++
 					offset += int64(kafkaencoding.NumRecords(records))
+					if ts := kafkaencoding.MaxTimestamp(records); ts > maxTimestamp {
+						maxTimestamp = ts
+					}
 				}
 			}
+			kvs = txState.appendKVs(kvs)
 		}
 	}
 	// Sort by key - ssTables are always in key order
@@ -662,8 +712,8 @@ func (t *TablePusher) write() error {
 	})
 	iter := common.NewKvSliceIterator(kvs)
 	// Build ssTable
-	table, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTable(t.cfg.DataFormat,
-		int(1.1*float64(t.sizeBytes)), len(kvs), iter)
+	table, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTableWithOptions(t.cfg.DataFormat,
+		t.cfg.tableBuildOptions(), int(1.1*float64(t.sizeBytes)), len(kvs), iter)
 	if err != nil {
 		return err
 	}
@@ -687,11 +737,15 @@ func (t *TablePusher) write() error {
 		NumEntries:       uint64(table.NumEntries()),
 		TableSize:        uint64(table.SizeBytes()),
 		NumPrefixDeletes: uint32(table.NumPrefixDeletes()),
+		MaxTimestamp:     maxTimestamp,
+		TopicDataOnly:    numDataKVs == len(kvs),
 	}
 	if err := client.RegisterL0Table(seq, regEntry); err != nil {
 		return err
 	}
 	log.Debugf("table pusher successfully pushed and registered table with id %s", tableID)
+	t.metrics.tablePushBytes.Observe(float64(len(tableData)))
+	t.metrics.tablePushDuration.Observe(time.Since(start).Seconds())
 	// Send back completions
 	t.callCompletions(nil)
 	// reset - the state
@@ -699,6 +753,91 @@ func (t *TablePusher) write() error {
 	return nil
 }
 
+// appendTxEvent appends a TxEvent if the batch is transactional. The controller uses these to track open transactions
+// and maintain the last stable offset. Consecutive data batches from the same producer only need a single event, as
+// only the first offset of a transaction is of interest.
+func appendTxEvent(txEvents []offsets.TxEvent, batch []byte, relativeOffset int) []offsets.TxEvent {
+	if !kafkaencoding.IsTransactional(batch) {
+		return txEvents
+	}
+	producerID := kafkaencoding.ProducerID(batch)
+	end := kafkaencoding.IsControlBatch(batch)
+	if !end {
+		for i := len(txEvents) - 1; i >= 0; i-- {
+			if txEvents[i].ProducerID == producerID {
+				if !txEvents[i].End {
+					// transaction already started
+					return txEvents
+				}
+				break
+			}
+		}
+	}
+	return append(txEvents, offsets.TxEvent{
+		ProducerID: producerID,
+		Offset:     int64(relativeOffset),
+		End:        end,
+	})
+}
+
+// txStateKVs creates the KVs which persist the state of the transactions in the data written for a partition - see
+// offsets/transactions.go
+type txStateKVs struct {
+	partitionHash  []byte
+	firstOffset    int64
+	txEvents       []offsets.TxEvent
+	txFirstOffsets []int64
+	eventIndex     int
+	// The last state of each transaction - a table can't contain more than one version of the same key
+	openTxKVs    map[int64]common.KV
+	abortedTxKVs []common.KV
+}
+
+func newTxStateKVs(partitionHash []byte, firstOffset int64, txEvents []offsets.TxEvent,
+	txFirstOffsets []int64) *txStateKVs {
+	return &txStateKVs{
+		partitionHash:  partitionHash,
+		firstOffset:    firstOffset,
+		txEvents:       txEvents,
+		txFirstOffsets: txFirstOffsets,
+	}
+}
+
+// addBatch must be called for each batch written for the partition, in offset order
+func (s *txStateKVs) addBatch(batch []byte, offset int64) {
+	if s.eventIndex >= len(s.txEvents) || s.firstOffset+s.txEvents[s.eventIndex].Offset != offset {
+		// Not the start or end of a transaction
+		return
+	}
+	event := s.txEvents[s.eventIndex]
+	txFirstOffset := s.txFirstOffsets[s.eventIndex]
+	s.eventIndex++
+	if s.openTxKVs == nil {
+		s.openTxKVs = map[int64]common.KV{}
+	}
+	if !event.End {
+		s.openTxKVs[event.ProducerID] = offsets.CreateOpenTransactionKV(s.partitionHash, event.ProducerID,
+			txFirstOffset, offset)
+		return
+	}
+	s.openTxKVs[event.ProducerID] = offsets.CreateOpenTransactionTombstone(s.partitionHash, event.ProducerID, offset)
+	if kafkaencoding.IsAbortMarker(batch) && txFirstOffset != -1 {
+		s.abortedTxKVs = append(s.abortedTxKVs, offsets.CreateAbortedTransactionKV(s.partitionHash,
+			offsets.AbortedTransaction{
+				ProducerID:  event.ProducerID,
+				FirstOffset: txFirstOffset,
+				LastOffset:  offset,
+			}))
+	}
+}
+
+func (s *txStateKVs) appendKVs(kvs []common.KV) []common.KV {
+	for _, kv := range s.openTxKVs {
+		kvs = append(kvs, kv)
+	}
+	return append(kvs, s.abortedTxKVs...)
+}
+
 func (t *TablePusher) reset() {
 	t.partitionRecords = make(map[int]map[int][]bufferedRecords)
 	t.produceCompletions = t.produceCompletions[:0]
@@ -821,7 +960,8 @@ func (t *TablePusher) loadExpectedSequence(producerID int, topicID int, partitio
 		}
 		if bytes.Equal(prefix, kv.Key[:len(prefix)]) {
 			recordProducerID := int(kafkaencoding.ProducerID(kv.Value))
-			if producerID == recordProducerID {
+			// Transaction markers do not have a sequence
+			if producerID == recordProducerID && !kafkaencoding.IsControlBatch(kv.Value) {
 				baseSequence := kafkaencoding.BaseSequence(kv.Value)
 				lastOffsetDelta := kafkaencoding.LastOffsetDelta(kv.Value)
 				seq := int64(baseSequence) + int64(lastOffsetDelta) + 1
@@ -851,7 +991,7 @@ func (t *TablePusher) maybeSnapshotSequences() error {
 					// load latest sequence after the snapshot
 					value = binary.BigEndian.AppendUint64(value, uint64(seqInfo.offset))
 					kvs = append(kvs, common.KV{
-						Key:   key,
+						Key:   encoding.EncodeVersion(key, 0),
 						Value: value,
 					})
 					seqInfo.dirty = false
@@ -863,6 +1003,7 @@ func (t *TablePusher) maybeSnapshotSequences() error {
 	return nil
 }
 
+// createOffsetSnapshotKey creates the key of an offset snapshot, without a version
 func (t *TablePusher) createOffsetSnapshotKey(producerID int, topicID int, partitionID int) ([]byte, error) {
 	partHash, err := t.partitionHashes.GetPartitionHash(topicID, partitionID)
 	if err != nil {
@@ -872,48 +1013,15 @@ func (t *TablePusher) createOffsetSnapshotKey(producerID int, topicID int, parti
 	key = append(key, partHash...)
 	key = append(key, common.EntryTypeOffsetSnapshot)
 	key = binary.BigEndian.AppendUint64(key, uint64(producerID))
-	key = encoding.EncodeVersion(key, 0)
 	return key, nil
 }
 
 func (t *TablePusher) getLatestValueWithKey(key []byte) ([]byte, error) {
-	keyEnd := common.IncBigEndianBytes(key)
 	controlClient, err := t.getClient()
 	if err != nil {
 		return nil, err
 	}
-	queryRes, err := controlClient.QueryTablesInRange(key, keyEnd)
-	if err != nil {
-		return nil, err
-	}
-	if len(queryRes) == 0 {
-		// no stored txinfo
-		return nil, nil
-	}
-	// We take the first one as that's the most recent
-	nonOverlapping := queryRes[0]
-	res := nonOverlapping[0]
-	tableID := res.ID
-	sstTable, err := t.tableGetter(tableID)
-	if err != nil {
-		return nil, err
-	}
-	iter, err := sstTable.NewIterator(key, keyEnd)
-	if err != nil {
-		return nil, err
-	}
-	ok, kv, err := iter.Next()
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		return nil, nil
-	}
-	if len(kv.Value) == 0 {
-		// tombstone
-		return nil, nil
-	}
-	return kv.Value, nil
+	return queryutils.GetLatestValueWithKey(key, controlClient, t.tableGetter, t.filterGetter)
 }
 
 func ChooseTablePusherForHash(partHash []byte, members []cluster.MembershipEntry) (string, bool) {
@@ -9,6 +9,7 @@ import (
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/kafkaencoding"
 	"github.com/spirit-labs/tektite/kafkaprotocol"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
@@ -18,10 +19,12 @@ import (
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/testutils"
 	"github.com/spirit-labs/tektite/topicmeta"
+	"github.com/spirit-labs/tektite/types"
 	"github.com/stretchr/testify/require"
 	"math"
 	"math/rand"
 	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -68,7 +71,7 @@ func TestTablePusherWriteDirectSingleWriter(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -161,7 +164,7 @@ func TestTablePusherDirectWriteMultipleWritersOK(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -270,7 +273,7 @@ func TestTablePusherDirectWriteMultipleGroupsInvalidEpochs(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -407,7 +410,7 @@ func TestTablePusherHandleDirectProduce(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -525,6 +528,11 @@ func TestTablePusherHandleDirectProduce(t *testing.T) {
 	reg := receivedRegs[0].regEntry
 	require.Equal(t, []byte(objects[0].Key), []byte(reg.TableID))
 	require.Equal(t, seq, receivedRegs[0].seq)
+	var expectedMaxTimestamp int64
+	for _, batch := range [][]byte{recordBatch1, recordBatch2, recordBatch3, recordBatch4} {
+		expectedMaxTimestamp = max(expectedMaxTimestamp, kafkaencoding.MaxTimestamp(batch))
+	}
+	require.Equal(t, expectedMaxTimestamp, reg.MaxTimestamp)
 }
 
 func checkBatchInBatches(t *testing.T, batch []byte, batches [][]byte) {
@@ -569,7 +577,7 @@ func TestTablePusherHandleProduceBatchSimple(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable,
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil,
 		partHashes, &testLeaderChecker{leader: true})
 	require.NoError(t, err)
 	err = pusher.Start()
@@ -670,7 +678,7 @@ func TestTablePusherHandleProduceBatchNotLeader(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes,
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes,
 		&testLeaderChecker{leader: false})
 	require.NoError(t, err)
 	err = pusher.Start()
@@ -709,6 +717,64 @@ func TestTablePusherHandleProduceBatchNotLeader(t *testing.T) {
 	require.Equal(t, kafkaprotocol.ErrorCodeNotLeaderOrFollower, int(resp.Responses[0].PartitionResponses[0].ErrorCode))
 }
 
+func TestTablePusherHandleProduceBatchTooLarge(t *testing.T) {
+	cfg := NewConf()
+	cfg.DataBucketName = "test-data-bucket"
+	cfg.WriteTimeout = 1 * time.Millisecond // So it pushes straightaway
+	objStore := dev.NewInMemStore(0)
+	topicID := 1234
+	numRecordsInBatch := 10
+	recordBatch := testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, numRecordsInBatch)
+
+	controllerClient := &testControllerClient{}
+	clientFactory := func() (ControlClient, error) {
+		return controllerClient, nil
+	}
+	topicProvider := &simpleTopicInfoProvider{infos: map[string]topicmeta.TopicInfo{
+		"topic1": {ID: topicID, PartitionCount: 20, Configs: map[string]string{
+			topicmeta.ConfigMaxMessageBytes: strconv.Itoa(len(recordBatch) - 1),
+		}},
+	}}
+	partHashes, err := parthash.NewPartitionHashes(100)
+	require.NoError(t, err)
+	tableGetter := &testTableGetter{}
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
+	require.NoError(t, err)
+	err = pusher.Start()
+	require.NoError(t, err)
+	defer func() {
+		err := pusher.Stop()
+		require.NoError(t, err)
+	}()
+
+	req := kafkaprotocol.ProduceRequest{
+		Acks:      -1,
+		TimeoutMs: 1234,
+		TopicData: []kafkaprotocol.ProduceRequestTopicProduceData{
+			{
+				Name: common.StrPtr("topic1"),
+				PartitionData: []kafkaprotocol.ProduceRequestPartitionProduceData{
+					{
+						Index: 12,
+						Records: [][]byte{
+							recordBatch,
+						},
+					},
+				},
+			},
+		},
+	}
+	respCh := make(chan *kafkaprotocol.ProduceResponse, 1)
+	err = pusher.HandleProduceRequest(&req, func(resp *kafkaprotocol.ProduceResponse) error {
+		respCh <- resp
+		return nil
+	})
+	require.NoError(t, err)
+	resp := <-respCh
+	require.Equal(t, kafkaprotocol.ErrorCodeMessageTooLarge, int(resp.Responses[0].PartitionResponses[0].ErrorCode))
+	require.Equal(t, 0, len(controllerClient.getRegistrations()))
+}
+
 type testLeaderChecker struct {
 	leader bool
 }
@@ -763,7 +829,7 @@ func TestTablePusherHandleProduceBatchMultipleTopicsAndPartitions(t *testing.T)
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 
 	err = pusher.Start()
@@ -967,7 +1033,7 @@ func TestTablePusherPushWhenBufferIsFull(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 
 	err = pusher.Start()
@@ -1087,7 +1153,7 @@ func TestTablePusherPushWhenTimeoutIsExceeded(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 
 	start := time.Now()
@@ -1195,7 +1261,7 @@ func TestTablePusherHandleProduceBatchMixtureErrorsAndSuccesses(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 
 	err = pusher.Start()
@@ -1418,7 +1484,7 @@ func TestTablePusherUnexpectedError(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(100)
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -1506,7 +1572,7 @@ func TestTablePusherTemporaryUnavailability(t *testing.T) {
 	require.NoError(t, err)
 	tableGetter := &testTableGetter{}
 
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -1840,7 +1906,7 @@ func setupTablePusherForIdempotentProducerWithConfigSetter(t *testing.T, cfgSett
 		tables: map[string]*sst.SSTable{},
 	}
 
-	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, partHashes, nil)
+	pusher, err := NewTablePusher(cfg, topicProvider, objStore, clientFactory, tableGetter.getTable, nil, partHashes, nil)
 	require.NoError(t, err)
 	err = pusher.Start()
 	require.NoError(t, err)
@@ -2072,6 +2138,10 @@ func (f *failingObjectStoreClient) Get(_ context.Context, _ string, _ string) ([
 	panic("should not be called")
 }
 
+func (f *failingObjectStoreClient) GetRange(_ context.Context, _ string, _ string, _ int64, _ int64) ([]byte, error) {
+	panic("should not be called")
+}
+
 func (f *failingObjectStoreClient) Put(_ context.Context, _ string, _ string, _ []byte) error {
 	return errors.New("some random error")
 }
@@ -2112,6 +2182,13 @@ func (u *unavailableObjStoreClient) Get(ctx context.Context, bucket string, key
 	return u.cl.Get(ctx, bucket, key)
 }
 
+func (u *unavailableObjStoreClient) GetRange(ctx context.Context, bucket string, key string, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	if !u.available.Load() {
+		return nil, common.NewTektiteErrorf(common.Unavailable, "object store is unavailable")
+	}
+	return u.cl.GetRange(ctx, bucket, key, rangeStart, rangeEnd)
+}
+
 func (u *unavailableObjStoreClient) Put(ctx context.Context, bucket string, key string, value []byte) error {
 	if !u.available.Load() {
 		return common.NewTektiteErrorf(common.Unavailable, "object store is unavailable")
@@ -2193,3 +2270,79 @@ func (t *mapTableGetter) getTable(tableID sst.SSTableID) (*sst.SSTable, error) {
 	}
 	return table, nil
 }
+
+func TestAppendTxEvents(t *testing.T) {
+	var batches [][]byte
+	// not transactional
+	batches = append(batches, testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10))
+	// start of transaction for producer 7
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 10, 10))
+	// more data in same transaction, no event expected
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 20, 5))
+	// start of transaction for producer 8
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(8, 25, 10))
+	// end of transaction for producer 7
+	batches = append(batches, kafkaencoding.CreateControlBatch(7, 0, true, types.Timestamp{Val: time.Now().UnixMilli()}))
+	// new transaction for producer 7
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 36, 10))
+	// end of transaction for producer 8
+	batches = append(batches, kafkaencoding.CreateControlBatch(8, 0, false, types.Timestamp{Val: time.Now().UnixMilli()}))
+
+	var txEvents []offsets.TxEvent
+	relOffset := 0
+	for _, batch := range batches {
+		txEvents = appendTxEvent(txEvents, batch, relOffset)
+		relOffset += kafkaencoding.NumRecords(batch)
+	}
+	require.Equal(t, []offsets.TxEvent{
+		{ProducerID: 7, Offset: 10},
+		{ProducerID: 8, Offset: 25},
+		{ProducerID: 7, Offset: 35, End: true},
+		{ProducerID: 7, Offset: 36},
+		{ProducerID: 8, Offset: 46, End: true},
+	}, txEvents)
+}
+
+func TestTxStateKVs(t *testing.T) {
+	partHash := []byte("someparthash1234")
+	var batches [][]byte
+	batches = append(batches, testutils.CreateKafkaRecordBatchWithIncrementingKVs(0, 10))
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 10, 10))
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 20, 5))
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(8, 25, 10))
+	// commit for producer 7
+	batches = append(batches, kafkaencoding.CreateControlBatch(7, 0, true, types.Timestamp{Val: time.Now().UnixMilli()}))
+	batches = append(batches, testutils.CreateTransactionalKafkaRecordBatchWithIncrementingKVs(7, 36, 10))
+	// abort for producer 8
+	batches = append(batches, kafkaencoding.CreateControlBatch(8, 0, false, types.Timestamp{Val: time.Now().UnixMilli()}))
+	// abort for producer 9, whose transaction started in an earlier table
+	batches = append(batches, kafkaencoding.CreateControlBatch(9, 0, false, types.Timestamp{Val: time.Now().UnixMilli()}))
+
+	var txEvents []offsets.TxEvent
+	relOffset := 0
+	for _, batch := range batches {
+		txEvents = appendTxEvent(txEvents, batch, relOffset)
+		relOffset += kafkaencoding.NumRecords(batch)
+	}
+	// As returned by the controller
+	txFirstOffsets := []int64{110, 125, 110, 136, 125, 50}
+	require.Equal(t, len(txFirstOffsets), len(txEvents))
+
+	txState := newTxStateKVs(partHash, 100, txEvents, txFirstOffsets)
+	offset := int64(100)
+	for _, batch := range batches {
+		txState.addBatch(batch, offset)
+		offset += int64(kafkaencoding.NumRecords(batch))
+	}
+	kvs := txState.appendKVs(nil)
+	slices.SortFunc(kvs, func(a, b common.KV) int {
+		return bytes.Compare(a.Key, b.Key)
+	})
+	require.Equal(t, []common.KV{
+		offsets.CreateOpenTransactionKV(partHash, 7, 136, 136),
+		offsets.CreateOpenTransactionTombstone(partHash, 8, 146),
+		offsets.CreateOpenTransactionTombstone(partHash, 9, 147),
+		offsets.CreateAbortedTransactionKV(partHash, offsets.AbortedTransaction{ProducerID: 8, FirstOffset: 125, LastOffset: 146}),
+		offsets.CreateAbortedTransactionKV(partHash, offsets.AbortedTransaction{ProducerID: 9, FirstOffset: 50, LastOffset: 147}),
+	}, kvs)
+}
@@ -1,6 +1,7 @@
 package queryutils
 
 import (
+	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/iteration"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/sst"
@@ -16,6 +17,35 @@ func CreateIteratorForKeyRange(keyStart []byte, keyEnd []byte, querier Querier,
 	if err != nil {
 		return nil, err
 	}
+	return CreateIteratorForTables(ids, keyStart, keyEnd, tableGetter)
+}
+
+func CreateIteratorForTables(ids lsm.OverlappingTables, keyStart []byte, keyEnd []byte, tableGetter sst.TableGetter) (iteration.Iterator, error) {
+	return createIteratorForTables(ids, func(tableID sst.SSTableID) (iteration.Iterator, error) {
+		return sst.NewLazySSTableIterator(tableID, tableGetter, keyStart, keyEnd)
+	})
+}
+
+// CreateRangedIteratorForKeyRange creates an iterator which, for tables with a block index, only reads the blocks of
+// each table that contain the key range, rather than the whole tables. Tables without a block index are got in full
+// with the table getter.
+func CreateRangedIteratorForKeyRange(keyStart []byte, keyEnd []byte, querier Querier, indexGetter sst.TableIndexGetter,
+	rangeGetter sst.RangeGetter, tableGetter sst.TableGetter) (iteration.Iterator, error) {
+	ids, err := querier.QueryTablesInRange(keyStart, keyEnd)
+	if err != nil {
+		return nil, err
+	}
+	return CreateRangedIteratorForTables(ids, keyStart, keyEnd, indexGetter, rangeGetter, tableGetter)
+}
+
+func CreateRangedIteratorForTables(ids lsm.OverlappingTables, keyStart []byte, keyEnd []byte,
+	indexGetter sst.TableIndexGetter, rangeGetter sst.RangeGetter, tableGetter sst.TableGetter) (iteration.Iterator, error) {
+	return createIteratorForTables(ids, func(tableID sst.SSTableID) (iteration.Iterator, error) {
+		return sst.NewRangedSSTableIterator(tableID, indexGetter, rangeGetter, tableGetter, keyStart, keyEnd)
+	})
+}
+
+func createIteratorForTables(ids lsm.OverlappingTables, newIter func(tableID sst.SSTableID) (iteration.Iterator, error)) (iteration.Iterator, error) {
 	if len(ids) == 0 {
 		return iteration.EmptyIterator{}, nil
 	}
@@ -23,7 +53,7 @@ func CreateIteratorForKeyRange(keyStart []byte, keyEnd []byte, querier Querier,
 	for _, nonOverLapIDs := range ids {
 		if len(nonOverLapIDs) == 1 {
 			info := nonOverLapIDs[0]
-			iter, err := sst.NewLazySSTableIterator(info.ID, tableGetter, keyStart, keyEnd)
+			iter, err := newIter(info.ID)
 			if err != nil {
 				return nil, err
 			}
@@ -31,7 +61,7 @@ func CreateIteratorForKeyRange(keyStart []byte, keyEnd []byte, querier Querier,
 		} else {
 			itersInChain := make([]iteration.Iterator, len(nonOverLapIDs))
 			for j, nonOverlapID := range nonOverLapIDs {
-				iter, err := sst.NewLazySSTableIterator(nonOverlapID.ID, tableGetter, keyStart, keyEnd)
+				iter, err := newIter(nonOverlapID.ID)
 				if err != nil {
 					return nil, err
 				}
@@ -42,3 +72,49 @@ func CreateIteratorForKeyRange(keyStart []byte, keyEnd []byte, querier Querier,
 	}
 	return iteration.NewMergingIterator(iters, false, math.MaxUint64)
 }
+
+// GetLatestValueWithKey returns the value of the most recent version of the key, or nil if the key does not exist or
+// has been deleted. The key must not include a version. Tables are checked from most recent to least recent, and if
+// filterGetter is not nil, tables whose bloom filter doesn't contain the key are skipped without being fetched.
+func GetLatestValueWithKey(keyNoVersion []byte, querier Querier, tableGetter sst.TableGetter,
+	filterGetter sst.BloomFilterGetter) ([]byte, error) {
+	keyEnd := common.IncBigEndianBytes(keyNoVersion)
+	ids, err := querier.QueryTablesInRange(keyNoVersion, keyEnd)
+	if err != nil {
+		return nil, err
+	}
+	for _, nonOverlapIDs := range ids {
+		for _, info := range nonOverlapIDs {
+			if filterGetter != nil {
+				filter, err := filterGetter(info.ID)
+				if err != nil {
+					return nil, err
+				}
+				if filter != nil && !filter.MayContainUnversioned(keyNoVersion) {
+					continue
+				}
+			}
+			table, err := tableGetter(info.ID)
+			if err != nil {
+				return nil, err
+			}
+			iter, err := table.NewIterator(keyNoVersion, keyEnd)
+			if err != nil {
+				return nil, err
+			}
+			ok, kv, err := iter.Next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if len(kv.Value) == 0 {
+				// tombstone
+				return nil, nil
+			}
+			return kv.Value, nil
+		}
+	}
+	return nil, nil
+}
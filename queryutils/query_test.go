@@ -0,0 +1,101 @@
+package queryutils
+
+import (
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/sst"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testQuerier struct {
+	tables lsm.OverlappingTables
+}
+
+func (t *testQuerier) QueryTablesInRange([]byte, []byte) (lsm.OverlappingTables, error) {
+	return t.tables, nil
+}
+
+type testTables struct {
+	tables     map[string]*sst.SSTable
+	tablesRead int
+}
+
+func (t *testTables) getTable(tableID sst.SSTableID) (*sst.SSTable, error) {
+	t.tablesRead++
+	return t.tables[string(tableID)], nil
+}
+
+func (t *testTables) getRange(tableID sst.SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	buff := t.tables[string(tableID)].Serialize()
+	if rangeEnd > int64(len(buff)) {
+		rangeEnd = int64(len(buff))
+	}
+	return buff[rangeStart:rangeEnd], nil
+}
+
+func (t *testTables) addTable(tt *testing.T, tableID string, bloomFilterBitsPerKey int, kvs ...common.KV) {
+	table, _, _, _, _, err := sst.BuildSSTableWithOptions(common.DataFormatV2,
+		sst.BuildOptions{BloomFilterBitsPerKey: bloomFilterBitsPerKey}, 0, 0, common.NewKvSliceIterator(kvs))
+	require.NoError(tt, err)
+	t.tables[tableID] = table
+}
+
+func testKV(key string, value string) common.KV {
+	var val []byte
+	if value != "" {
+		val = []byte(value)
+	}
+	return common.KV{Key: encoding.EncodeVersion([]byte(key), 0), Value: val}
+}
+
+// lookupKey returns the key to look up, which, unlike the keys in the tables, does not have a version
+func lookupKey(key string) []byte {
+	return []byte(key)
+}
+
+func TestGetLatestValueWithKey(t *testing.T) {
+	tables := &testTables{tables: map[string]*sst.SSTable{}}
+	// The keys are written with version 0 so newer tables overwrite older ones
+	tables.addTable(t, "table1", 10, testKV("key1", "val1-1"), testKV("key3", "val3-1"), testKV("key6", ""))
+	tables.addTable(t, "table2", 10, testKV("key0", "val0-2"), testKV("key2", "val2-2"),
+		testKV("key3", "val3-2"))
+	tables.addTable(t, "table3", 0, testKV("key1", "val1-3"), testKV("key2", ""),
+		testKV("key4", "val4-3"), testKV("key6", "val6-3"))
+	querier := &testQuerier{tables: lsm.OverlappingTables{
+		{{ID: []byte("table1")}},
+		{{ID: []byte("table2")}},
+		{{ID: []byte("table3")}},
+	}}
+	cache, err := sst.NewBloomFilterCache(tables.getRange, sst.DefaultBloomFilterCacheMaxSizeBytes)
+	require.NoError(t, err)
+
+	for _, filterGetter := range []sst.BloomFilterGetter{nil, cache.GetBloomFilter} {
+		testGet := func(key string, expectedValue string, expectedTablesRead int) {
+			tables.tablesRead = 0
+			value, err := GetLatestValueWithKey(lookupKey(key), querier, tables.getTable, filterGetter)
+			require.NoError(t, err)
+			if expectedValue == "" {
+				require.Nil(t, value)
+			} else {
+				require.Equal(t, expectedValue, string(value))
+			}
+			if filterGetter != nil {
+				require.Equal(t, expectedTablesRead, tables.tablesRead)
+			}
+		}
+		// Most recent table contains the key
+		testGet("key1", "val1-1", 1)
+		testGet("key3", "val3-1", 1)
+		// Only older tables contain the key
+		testGet("key0", "val0-2", 1)
+		testGet("key4", "val4-3", 1)
+		// Most recent value is a tombstone
+		testGet("key6", "", 1)
+		// Older tombstone doesn't hide a newer value
+		testGet("key2", "val2-2", 1)
+		// Table without a bloom filter must always be read
+		testGet("key5", "", 1)
+	}
+}
@@ -0,0 +1,246 @@
+package quota
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	log "github.com/spirit-labs/tektite/logger"
+	"sync"
+	"time"
+)
+
+/*
+Manager enforces client quotas on an agent. Quotas are stored on the controller and the manager holds a copy of them,
+which is refreshed periodically, so quotas altered via another agent take effect within the refresh interval.
+For each quota in use the manager maintains a sensor which measures the byte rate of the clients it applies to. When
+bytes are recorded the manager returns how long the client should be throttled for so that its rate falls back within
+its quota - the client is sent the throttle time and the connection is muted until it has passed.
+As with Kafka, the quota that applies to a connection is the most specific one matching its user and client id, in
+the following order:
+ 1. user and client id
+ 2. user and default client id
+ 3. user
+ 4. default user and client id
+ 5. default user and default client id
+ 6. default user
+ 7. client id
+ 8. default client id
+
+Quotas with a user only apply to authenticated connections.
+*/
+type Manager struct {
+	cfg           Conf
+	clientFactory ControlClientFactory
+	lock          sync.RWMutex
+	started       bool
+	quotas        map[control.ClientQuotaEntity]map[string]float64
+	refreshTimer  *common.TimerHandle
+	sensorsLock   sync.Mutex
+	sensors       map[sensorKey]*rateSensor
+}
+
+type ControlClient interface {
+	GetClientQuotas() ([]control.ClientQuotaConfig, error)
+}
+
+type ControlClientFactory func() (ControlClient, error)
+
+type sensorKey struct {
+	quotaKey string
+	entity   control.ClientQuotaEntity
+}
+
+type Conf struct {
+	RefreshInterval time.Duration
+	NumSamples      int
+	SampleWindow    time.Duration
+	SensorExpiry    time.Duration
+}
+
+func NewConf() Conf {
+	return Conf{
+		RefreshInterval: DefaultRefreshInterval,
+		NumSamples:      DefaultNumSamples,
+		SampleWindow:    DefaultSampleWindow,
+		SensorExpiry:    DefaultSensorExpiry,
+	}
+}
+
+func (c *Conf) Validate() error {
+	return nil
+}
+
+const (
+	DefaultRefreshInterval = 5 * time.Second
+	DefaultNumSamples      = 11
+	DefaultSampleWindow    = 1 * time.Second
+	DefaultSensorExpiry    = 1 * time.Hour
+)
+
+func NewManager(cfg Conf, clientFactory ControlClientFactory) *Manager {
+	return &Manager{
+		cfg:           cfg,
+		clientFactory: clientFactory,
+		quotas:        map[control.ClientQuotaEntity]map[string]float64{},
+		sensors:       map[sensorKey]*rateSensor{},
+	}
+}
+
+func (m *Manager) Start() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.started {
+		return nil
+	}
+	m.started = true
+	// The controller may not be available yet, so quotas are loaded on the first refresh
+	m.scheduleRefresh(0)
+	return nil
+}
+
+func (m *Manager) Stop() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.started {
+		return nil
+	}
+	if m.refreshTimer != nil {
+		m.refreshTimer.Stop()
+		m.refreshTimer = nil
+	}
+	m.started = false
+	return nil
+}
+
+// must be called with the lock held
+func (m *Manager) scheduleRefresh(delay time.Duration) {
+	m.refreshTimer = common.ScheduleTimer(delay, false, func() {
+		if err := m.Refresh(); err != nil {
+			log.Debugf("failed to refresh client quotas: %v", err)
+		}
+		m.expireSensors()
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if m.started {
+			m.scheduleRefresh(m.cfg.RefreshInterval)
+		}
+	})
+}
+
+// Refresh reloads the quotas from the controller
+func (m *Manager) Refresh() error {
+	cl, err := m.clientFactory()
+	if err != nil {
+		return err
+	}
+	configs, err := cl.GetClientQuotas()
+	if err != nil {
+		return err
+	}
+	m.SetQuotas(configs)
+	return nil
+}
+
+func (m *Manager) SetQuotas(configs []control.ClientQuotaConfig) {
+	quotas := make(map[control.ClientQuotaEntity]map[string]float64, len(configs))
+	for _, config := range configs {
+		quotas[config.Entity] = config.Quotas
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.quotas = quotas
+}
+
+// RecordAndGetThrottleTime records bytes produced or fetched by a client against the quota with the provided key. It
+// returns how long the client must be throttled for, or zero if the client is within its quota. user is nil for
+// unauthenticated connections.
+func (m *Manager) RecordAndGetThrottleTime(quotaKey string, user *string, clientID string, bytes int) time.Duration {
+	entity, quota, ok := m.resolveQuota(quotaKey, user, clientID)
+	if !ok {
+		return 0
+	}
+	now := time.Now()
+	sensor := m.getSensor(sensorKey{quotaKey: quotaKey, entity: entity}, now)
+	rate, window := sensor.record(bytes, now)
+	if rate <= quota {
+		return 0
+	}
+	// This is the time after which the rate measured over the window will have fallen back to the quota
+	throttleTime := time.Duration((rate - quota) / quota * float64(window))
+	maxThrottleTime := time.Duration(m.cfg.NumSamples) * m.cfg.SampleWindow
+	if throttleTime > maxThrottleTime {
+		throttleTime = maxThrottleTime
+	}
+	return throttleTime
+}
+
+// resolveQuota finds the most specific quota for the user and client id. The returned entity identifies the sensor
+// which measures the rate - for a default user or client id quota each user or client id has its own sensor.
+func (m *Manager) resolveQuota(quotaKey string, user *string, clientID string) (control.ClientQuotaEntity, float64, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	if len(m.quotas) == 0 {
+		return control.ClientQuotaEntity{}, 0, false
+	}
+	none := control.QuotaEntityName{}
+	defaultName := control.QuotaEntityName{Type: control.QuotaEntityNameDefault}
+	specificClientID := control.QuotaEntityName{Type: control.QuotaEntityNameSpecific, Name: clientID}
+	var candidates []control.ClientQuotaEntity
+	if user != nil {
+		specificUser := control.QuotaEntityName{Type: control.QuotaEntityNameSpecific, Name: *user}
+		candidates = append(candidates,
+			control.ClientQuotaEntity{User: specificUser, ClientID: specificClientID},
+			control.ClientQuotaEntity{User: specificUser, ClientID: defaultName},
+			control.ClientQuotaEntity{User: specificUser, ClientID: none},
+			control.ClientQuotaEntity{User: defaultName, ClientID: specificClientID},
+			control.ClientQuotaEntity{User: defaultName, ClientID: defaultName},
+			control.ClientQuotaEntity{User: defaultName, ClientID: none})
+	}
+	candidates = append(candidates,
+		control.ClientQuotaEntity{User: none, ClientID: specificClientID},
+		control.ClientQuotaEntity{User: none, ClientID: defaultName})
+	for _, candidate := range candidates {
+		quota, ok := m.quotas[candidate][quotaKey]
+		if !ok {
+			continue
+		}
+		sensorEntity := control.ClientQuotaEntity{}
+		if candidate.User.Type != control.QuotaEntityNameNone {
+			sensorEntity.User = control.QuotaEntityName{Type: control.QuotaEntityNameSpecific, Name: *user}
+		}
+		if candidate.ClientID.Type != control.QuotaEntityNameNone {
+			sensorEntity.ClientID = specificClientID
+		}
+		return sensorEntity, quota, true
+	}
+	return control.ClientQuotaEntity{}, 0, false
+}
+
+func (m *Manager) getSensor(key sensorKey, now time.Time) *rateSensor {
+	m.sensorsLock.Lock()
+	defer m.sensorsLock.Unlock()
+	sensor, ok := m.sensors[key]
+	if !ok {
+		sensor = newRateSensor(m.cfg.NumSamples, m.cfg.SampleWindow, now)
+		m.sensors[key] = sensor
+	}
+	return sensor
+}
+
+// expireSensors removes sensors which have not been used recently, so sensors for clients which have gone away do not
+// build up
+func (m *Manager) expireSensors() {
+	m.sensorsLock.Lock()
+	defer m.sensorsLock.Unlock()
+	now := time.Now()
+	for key, sensor := range m.sensors {
+		if now.Sub(sensor.idleSince()) >= m.cfg.SensorExpiry {
+			delete(m.sensors, key)
+		}
+	}
+}
+
+func (m *Manager) numSensors() int {
+	m.sensorsLock.Lock()
+	defer m.sensorsLock.Unlock()
+	return len(m.sensors)
+}
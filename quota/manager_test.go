@@ -0,0 +1,150 @@
+package quota
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/control"
+	"github.com/spirit-labs/tektite/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestQuotaPrecedence(t *testing.T) {
+	specific := func(name string) control.QuotaEntityName {
+		return control.QuotaEntityName{Type: control.QuotaEntityNameSpecific, Name: name}
+	}
+	defaultName := control.QuotaEntityName{Type: control.QuotaEntityNameDefault}
+	// In order of precedence, lowest first
+	entities := []control.ClientQuotaEntity{
+		{ClientID: defaultName},
+		{ClientID: specific("client1")},
+		{User: defaultName},
+		{User: defaultName, ClientID: defaultName},
+		{User: defaultName, ClientID: specific("client1")},
+		{User: specific("user1")},
+		{User: specific("user1"), ClientID: defaultName},
+		{User: specific("user1"), ClientID: specific("client1")},
+	}
+	mgr := NewManager(NewConf(), nil)
+	var configs []control.ClientQuotaConfig
+	for i, entity := range entities {
+		configs = append(configs, control.ClientQuotaConfig{
+			Entity: entity,
+			Quotas: map[string]float64{control.QuotaKeyProducerByteRate: float64(i + 1)},
+		})
+		mgr.SetQuotas(configs)
+		_, quota, ok := mgr.resolveQuota(control.QuotaKeyProducerByteRate, common.StrPtr("user1"), "client1")
+		require.True(t, ok)
+		// The most recently added entity is the most specific, so it applies
+		require.Equal(t, float64(i+1), quota)
+	}
+
+	// Only the client id quotas apply to unauthenticated connections
+	sensorEntity, quota, ok := mgr.resolveQuota(control.QuotaKeyProducerByteRate, nil, "client1")
+	require.True(t, ok)
+	require.Equal(t, float64(2), quota)
+	require.Equal(t, control.ClientQuotaEntity{ClientID: specific("client1")}, sensorEntity)
+
+	// Each user has their own sensor for a default user quota
+	sensorEntity, quota, ok = mgr.resolveQuota(control.QuotaKeyProducerByteRate, common.StrPtr("user2"), "client2")
+	require.True(t, ok)
+	require.Equal(t, float64(4), quota)
+	require.Equal(t, control.ClientQuotaEntity{User: specific("user2"), ClientID: specific("client2")}, sensorEntity)
+
+	_, _, ok = mgr.resolveQuota(control.QuotaKeyConsumerByteRate, common.StrPtr("user1"), "client1")
+	require.False(t, ok)
+}
+
+func TestThrottleTime(t *testing.T) {
+	cfg := NewConf()
+	cfg.NumSamples = 2
+	cfg.SampleWindow = 1 * time.Second
+	mgr := NewManager(cfg, nil)
+	mgr.SetQuotas([]control.ClientQuotaConfig{
+		{
+			Entity: control.ClientQuotaEntity{ClientID: control.QuotaEntityName{Type: control.QuotaEntityNameDefault}},
+			Quotas: map[string]float64{control.QuotaKeyProducerByteRate: 1000},
+		},
+	})
+
+	// Within quota
+	throttleTime := mgr.RecordAndGetThrottleTime(control.QuotaKeyProducerByteRate, nil, "client1", 500)
+	require.Equal(t, time.Duration(0), throttleTime)
+
+	// Over quota - rate is 2000 bytes/sec measured over a 1 sec window, so must wait 1 sec for it to fall to 1000
+	throttleTime = mgr.RecordAndGetThrottleTime(control.QuotaKeyProducerByteRate, nil, "client1", 1500)
+	require.Equal(t, 1*time.Second, throttleTime.Round(100*time.Millisecond))
+
+	// Throttle time is capped to the full window
+	throttleTime = mgr.RecordAndGetThrottleTime(control.QuotaKeyProducerByteRate, nil, "client1", 1000000)
+	require.Equal(t, 2*time.Second, throttleTime)
+
+	// Other clients have their own sensors
+	throttleTime = mgr.RecordAndGetThrottleTime(control.QuotaKeyProducerByteRate, nil, "client2", 500)
+	require.Equal(t, time.Duration(0), throttleTime)
+	require.Equal(t, 2, mgr.numSensors())
+
+	// No quota for fetches
+	throttleTime = mgr.RecordAndGetThrottleTime(control.QuotaKeyConsumerByteRate, nil, "client1", 1000000)
+	require.Equal(t, time.Duration(0), throttleTime)
+
+	cfg.SensorExpiry = 0
+	mgr.cfg = cfg
+	mgr.expireSensors()
+	require.Equal(t, 0, mgr.numSensors())
+}
+
+func TestRateSensor(t *testing.T) {
+	now := time.Now()
+	sensor := newRateSensor(3, 1*time.Second, now)
+
+	// The window is never less than all but one of the samples
+	rate, window := sensor.record(1000, now)
+	require.Equal(t, 2*time.Second, window)
+	require.Equal(t, float64(500), rate)
+
+	rate, _ = sensor.record(1000, now.Add(1500*time.Millisecond))
+	require.Equal(t, float64(1000), rate)
+
+	rate, window = sensor.record(1000, now.Add(2500*time.Millisecond))
+	require.Equal(t, 2500*time.Millisecond, window)
+	require.Equal(t, float64(1200), rate)
+
+	// The first sample is now older than the full window so is no longer counted
+	rate, window = sensor.record(0, now.Add(3500*time.Millisecond))
+	require.Equal(t, 2*time.Second, window)
+	require.Equal(t, float64(1000), rate)
+}
+
+func TestRefresh(t *testing.T) {
+	configs := []control.ClientQuotaConfig{
+		{
+			Entity: control.ClientQuotaEntity{User: control.QuotaEntityName{Type: control.QuotaEntityNameDefault}},
+			Quotas: map[string]float64{control.QuotaKeyConsumerByteRate: 1000},
+		},
+	}
+	cl := &testControlClient{configs: configs}
+	cfg := NewConf()
+	cfg.RefreshInterval = 10 * time.Millisecond
+	mgr := NewManager(cfg, func() (ControlClient, error) {
+		return cl, nil
+	})
+	err := mgr.Start()
+	require.NoError(t, err)
+	defer func() {
+		err := mgr.Stop()
+		require.NoError(t, err)
+	}()
+	testutils.WaitUntil(t, func() (bool, error) {
+		_, _, ok := mgr.resolveQuota(control.QuotaKeyConsumerByteRate, common.StrPtr("user1"), "client1")
+		return ok, nil
+	})
+}
+
+type testControlClient struct {
+	configs []control.ClientQuotaConfig
+}
+
+func (t *testControlClient) GetClientQuotas() ([]control.ClientQuotaConfig, error) {
+	return t.configs, nil
+}
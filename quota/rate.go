@@ -0,0 +1,91 @@
+package quota
+
+import (
+	"sync"
+	"time"
+)
+
+/*
+rateSensor measures a byte rate over a sliding window made up of a fixed number of samples, in the same way as Kafka
+quota sensors. Bytes are recorded in the current sample, and when a sample's window has passed recording moves on to
+the next sample, overwriting the oldest one. The rate is the total bytes in all samples divided by the time they span.
+*/
+type rateSensor struct {
+	lock         sync.Mutex
+	sampleWindow time.Duration
+	samples      []rateSample
+	current      int
+	lastRecorded time.Time
+}
+
+type rateSample struct {
+	start time.Time
+	bytes float64
+}
+
+func newRateSensor(numSamples int, sampleWindow time.Duration, now time.Time) *rateSensor {
+	samples := make([]rateSample, numSamples)
+	samples[0].start = now
+	return &rateSensor{
+		sampleWindow: sampleWindow,
+		samples:      samples,
+		lastRecorded: now,
+	}
+}
+
+// record adds the bytes to the sensor and returns the resulting rate in bytes per second, along with the window it was
+// measured over
+func (r *rateSensor) record(bytes int, now time.Time) (float64, time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	sample := &r.samples[r.current]
+	if now.Sub(sample.start) >= r.sampleWindow {
+		r.current = (r.current + 1) % len(r.samples)
+		sample = &r.samples[r.current]
+		sample.start = now
+		sample.bytes = 0
+	}
+	sample.bytes += float64(bytes)
+	r.lastRecorded = now
+	return r.rate(now)
+}
+
+// rate must be called with the lock held
+func (r *rateSensor) rate(now time.Time) (float64, time.Duration) {
+	fullWindow := time.Duration(len(r.samples)) * r.sampleWindow
+	var total float64
+	oldest := now
+	for i := range r.samples {
+		sample := &r.samples[i]
+		if sample.start.IsZero() || now.Sub(sample.start) >= fullWindow {
+			// Not used yet, or too old to count
+			continue
+		}
+		total += sample.bytes
+		if sample.start.Before(oldest) {
+			oldest = sample.start
+		}
+	}
+	window := r.windowSize(oldest, now)
+	return total / window.Seconds(), window
+}
+
+// windowSize is the time spanned by the samples. As with Kafka, it is never less than all but one of the sample
+// windows, so that a burst of bytes when the sensor is new doesn't result in a huge rate.
+func (r *rateSensor) windowSize(oldest time.Time, now time.Time) time.Duration {
+	elapsed := now.Sub(oldest)
+	minWindow := time.Duration(len(r.samples)-1) * r.sampleWindow
+	if elapsed < minWindow {
+		elapsed = minWindow
+	}
+	if elapsed <= 0 {
+		elapsed = r.sampleWindow
+	}
+	return elapsed
+}
+
+func (r *rateSensor) idleSince() time.Time {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.lastRecorded
+}
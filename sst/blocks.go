@@ -0,0 +1,339 @@
+package sst
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/asl/errwrap"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	"github.com/spirit-labs/tektite/iteration"
+)
+
+/*
+DataFormatV2 stores the key-value pairs in data blocks, each of which is compressed separately, followed by a block index.
+Only the block containing the start of the range needs to be decompressed to seek to a key, and blocks are decompressed
+one at a time as the table is iterated.
+
+	Initial 14 bytes contain format, metadataOffset, compression type, indexOffset and filterOffset. The indexOffset is
+	duplicated from the metadata so a reader can read the header, then the index and metadata, then just the blocks that
+	it needs.
+	╭──────┬──────────────┬───────────┬───────────┬────────────╮
+	│format│metadataOffset│compression│indexOffset│filterOffset│
+	├──────┼──────────────┼───────────┼───────────┼────────────┤
+	│1 byte│ 4 bytes      │ 1 byte    │ 4 bytes   │ 4 bytes    │
+	╰──────┴──────────────┴───────────┴───────────┴────────────╯
+	Then we have the data blocks. Before compression, a block contains key-value pairs in the same format as
+	DataFormatV1. Entries are added to a block until it reaches the block size, so blocks are all roughly the same size
+	before compression. A block is stored uncompressed if it doesn't get smaller when compressed.
+	╭────────────────────────┬────────────────────────┬─────╮
+	│block 0                 │block 1                 │ ... │
+	├────────────────────────┼────────────────────────┼─────┤
+	│storedLength bytes      │storedLength bytes      │     │
+	╰────────────────────────┴────────────────────────┴─────╯
+	Then we have the optional bloom filter, which runs from filterOffset to indexOffset. If the table has no bloom
+	filter then filterOffset is equal to indexOffset.
+	╭─────────┬─────────────────────────────────╮
+	│numHashes│bits                             │
+	├─────────┼─────────────────────────────────┤
+	│1 byte   │indexOffset-filterOffset-1 bytes │
+	╰─────────┴─────────────────────────────────╯
+	Then we have the block index, with an entry for each block containing the last key in the block.
+	SSTable.indexOffset refers to this point where the block index begins
+	╭─────────────┬───────────────────────────────────────────────┬───────────┬────────────┬──────────────────╮
+	│lastKeyLength│lastKey + (padding if keyLength < maxKeyLength)│blockOffset│storedLength│uncompressedLength│
+	├─────────────┼───────────────────────────────────────────────┼───────────┼────────────┼──────────────────┤ ...
+	│4 bytes      │maxKeyLength bytes                             │4 bytes    │4 bytes     │4 bytes           │
+	╰─────────────┴───────────────────────────────────────────────┴───────────┴────────────┴──────────────────╯
+	The metadata is the same as DataFormatV1 followed by the number of blocks.
+*/
+
+const (
+	// DefaultBlockSize is the uncompressed size at which a data block is completed
+	DefaultBlockSize           = 64 * 1024
+	formatV2HeaderSize         = 14
+	formatV2CompressionOffset  = 5
+	formatV2IndexOffsetOffset  = 6
+	formatV2FilterOffsetOffset = 10
+)
+
+type blockIndexEntry struct {
+	lastKey            []byte
+	offset             uint32
+	storedLength       uint32
+	uncompressedLength uint32
+}
+
+func buildSSTableV2(compressionType compress.CompressionType, bloomFilterBitsPerKey int, blockSize int,
+	buffSizeEstimate int, iter iteration.Iterator) (ssTable *SSTable, smallestKey []byte, largestKey []byte, minVersion uint64,
+	maxVersion uint64, err error) {
+	buff := make([]byte, 0, buffSizeEstimate+maxMetadataSize)
+	buff = append(buff, byte(common.DataFormatV2), 0, 0, 0, 0, byte(compressionType), 0, 0, 0, 0, 0, 0, 0, 0)
+
+	var blockIndex []blockIndexEntry
+	block := make([]byte, 0, blockSize+blockSize/8)
+	var lastKey []byte
+	var keyHashes []uint64
+	completeBlock := func() error {
+		entry := blockIndexEntry{
+			lastKey:            lastKey,
+			offset:             uint32(len(buff)),
+			uncompressedLength: uint32(len(block)),
+		}
+		compressedBuff, compressed, err := compress.Compress(compressionType, buff, block)
+		if err != nil {
+			return err
+		}
+		buff = compressedBuff
+		if !compressed {
+			buff = append(buff, block...)
+		}
+		entry.storedLength = uint32(len(buff)) - entry.offset
+		blockIndex = append(blockIndex, entry)
+		block = block[:0]
+		return nil
+	}
+
+	stats := newEntryStats()
+	for {
+		v, kv, err := iter.Next()
+		if err != nil {
+			return nil, nil, nil, 0, 0, err
+		}
+		if !v {
+			break
+		}
+		stats.add(kv)
+		block = appendBytesWithLengthPrefix(block, kv.Key)
+		block = appendBytesWithLengthPrefix(block, kv.Value)
+		lastKey = kv.Key
+		if bloomFilterBitsPerKey > 0 {
+			// Versions of the same key are adjacent so we only need to compare with the previous hash
+			hash := bloomFilterHash(kv.Key[:len(kv.Key)-8])
+			if len(keyHashes) == 0 || keyHashes[len(keyHashes)-1] != hash {
+				keyHashes = append(keyHashes, hash)
+			}
+		}
+		if len(block) >= blockSize {
+			if err := completeBlock(); err != nil {
+				return nil, nil, nil, 0, 0, err
+			}
+		}
+	}
+	if len(block) > 0 {
+		if err := completeBlock(); err != nil {
+			return nil, nil, nil, 0, 0, err
+		}
+	}
+
+	binary.LittleEndian.PutUint32(buff[formatV2FilterOffsetOffset:], uint32(len(buff)))
+	if bloomFilterBitsPerKey > 0 && len(keyHashes) > 0 {
+		buff = newBloomFilter(keyHashes, bloomFilterBitsPerKey).Serialize(buff)
+	}
+
+	indexOffset := len(buff)
+	binary.LittleEndian.PutUint32(buff[formatV2IndexOffsetOffset:], uint32(indexOffset))
+	maxKeyLength := stats.maxKeyLength
+	for _, entry := range blockIndex {
+		buff = encoding.AppendUint32ToBufferLE(buff, uint32(len(entry.lastKey)))
+		buff = append(buff, entry.lastKey...)
+		if paddingBytes := maxKeyLength - len(entry.lastKey); paddingBytes > 0 {
+			buff = append(buff, make([]byte, paddingBytes)...)
+		}
+		buff = encoding.AppendUint32ToBufferLE(buff, entry.offset)
+		buff = encoding.AppendUint32ToBufferLE(buff, entry.storedLength)
+		buff = encoding.AppendUint32ToBufferLE(buff, entry.uncompressedLength)
+	}
+
+	if err := fillInMetadataOffset(buff); err != nil {
+		return nil, nil, nil, 0, 0, err
+	}
+
+	selfTable := stats.newSSTable(common.DataFormatV2, indexOffset)
+	selfTable.numBlocks = uint32(len(blockIndex))
+	selfTable.compression = compressionType
+	buff = selfTable.appendMetadata(buff)
+	selfTable.data = buff
+
+	return selfTable, stats.smallestKey, stats.largestKey, stats.minVersion, stats.maxVersion, nil
+}
+
+func (s *SSTable) blockIndexRecordStart(blockNum int) int {
+	return int(s.indexOffset-s.dataOffset) + blockNum*(16+int(s.maxKeyLength))
+}
+
+func (s *SSTable) blockLastKey(blockNum int) []byte {
+	keyLen, keyStart := encoding.ReadUint32FromBufferLE(s.data, s.blockIndexRecordStart(blockNum))
+	return s.data[keyStart : keyStart+int(keyLen)]
+}
+
+// blockLocation returns the offset in the table and the stored and uncompressed lengths of the block
+func (s *SSTable) blockLocation(blockNum int) (uint32, uint32, uint32) {
+	offset := s.blockIndexRecordStart(blockNum) + 4 + int(s.maxKeyLength)
+	blockOffset, offset := encoding.ReadUint32FromBufferLE(s.data, offset)
+	storedLength, offset := encoding.ReadUint32FromBufferLE(s.data, offset)
+	uncompressedLength, _ := encoding.ReadUint32FromBufferLE(s.data, offset)
+	return blockOffset, storedLength, uncompressedLength
+}
+
+// findBlock returns the first block which may contain keys >= key, or -1 if all keys are less than key
+func (s *SSTable) findBlock(key []byte) int {
+	numBlocks := int(s.numBlocks)
+	// We do a binary search for the first block whose last key is >= key
+	low, high := 0, numBlocks
+	for low < high {
+		middle := low + (high-low)/2
+		if bytes.Compare(s.blockLastKey(middle), key) < 0 {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+	if low == numBlocks {
+		return -1
+	}
+	return low
+}
+
+// findBlockRange returns the first and last blocks which may contain keys in the range [keyStart, keyEnd), or -1 if
+// there are none
+func (s *SSTable) findBlockRange(keyStart []byte, keyEnd []byte) (int, int) {
+	firstBlock := s.findBlock(keyStart)
+	if firstBlock == -1 {
+		return -1, -1
+	}
+	lastBlock := int(s.numBlocks) - 1
+	if keyEnd != nil {
+		if endBlock := s.findBlock(keyEnd); endBlock != -1 {
+			lastBlock = endBlock
+		}
+	}
+	return firstBlock, lastBlock
+}
+
+// readBlock returns the uncompressed data of the block. blocks contains the stored blocks, starting at blocksOffset
+// in the table
+func (s *SSTable) readBlock(blockNum int, blocks []byte, blocksOffset uint32) ([]byte, error) {
+	blockOffset, storedLength, uncompressedLength := s.blockLocation(blockNum)
+	blockOffset -= blocksOffset
+	stored := blocks[blockOffset : blockOffset+storedLength]
+	if storedLength == uncompressedLength {
+		// Stored uncompressed
+		return stored, nil
+	}
+	block, err := compress.Decompress(s.compression, stored, int(uncompressedLength))
+	if err != nil {
+		return nil, errwrap.WithStack(err)
+	}
+	return block, nil
+}
+
+func (s *SSTable) newBlockIterator(keyStart []byte, keyEnd []byte) (iteration.Iterator, error) {
+	if s.dataOffset != 0 {
+		return nil, errwrap.New("cannot iterate SSTable without its data blocks")
+	}
+	firstBlock, lastBlock := s.findBlockRange(keyStart, keyEnd)
+	return s.newBlockIteratorForBlocks(keyStart, keyEnd, firstBlock, lastBlock, s.data, 0)
+}
+
+func (s *SSTable) newBlockIteratorForBlocks(keyStart []byte, keyEnd []byte, firstBlock int, lastBlock int,
+	blocks []byte, blocksOffset uint32) (iteration.Iterator, error) {
+	bi := &blockIterator{
+		ss:           s,
+		keyEnd:       keyEnd,
+		blockNum:     firstBlock,
+		lastBlock:    lastBlock,
+		blocks:       blocks,
+		blocksOffset: blocksOffset,
+	}
+	if bi.blockNum == -1 {
+		return bi, nil
+	}
+	if err := bi.loadBlock(); err != nil {
+		return nil, err
+	}
+	// Skip any entries in the block before keyStart
+	for bi.nextOffset < len(bi.block) {
+		kl, _ := encoding.ReadUint32FromBufferLE(bi.block, bi.nextOffset)
+		k := bi.block[bi.nextOffset+4 : bi.nextOffset+4+int(kl)]
+		if bytes.Compare(k, keyStart) >= 0 {
+			break
+		}
+		bi.skipEntry()
+	}
+	return bi, nil
+}
+
+// blockIterator iterates over the blocks of a DataFormatV2 SSTable
+type blockIterator struct {
+	ss           *SSTable
+	keyEnd       []byte
+	blockNum     int
+	lastBlock    int
+	blocks       []byte
+	blocksOffset uint32
+	block        []byte
+	nextOffset   int
+	currKV       common.KV
+}
+
+func (bi *blockIterator) loadBlock() error {
+	block, err := bi.ss.readBlock(bi.blockNum, bi.blocks, bi.blocksOffset)
+	if err != nil {
+		return err
+	}
+	bi.block = block
+	bi.nextOffset = 0
+	return nil
+}
+
+func (bi *blockIterator) skipEntry() {
+	kl, offset := encoding.ReadUint32FromBufferLE(bi.block, bi.nextOffset)
+	offset += int(kl)
+	vl, offset := encoding.ReadUint32FromBufferLE(bi.block, offset)
+	bi.nextOffset = offset + int(vl)
+}
+
+func (bi *blockIterator) Next() (bool, common.KV, error) {
+	if bi.blockNum == -1 {
+		bi.currKV = common.KV{}
+		return false, common.KV{}, nil
+	}
+	for bi.nextOffset >= len(bi.block) {
+		if bi.blockNum >= bi.lastBlock {
+			// Reached end of blocks
+			bi.blockNum = -1
+			bi.currKV = common.KV{}
+			return false, common.KV{}, nil
+		}
+		bi.blockNum++
+		if err := bi.loadBlock(); err != nil {
+			return false, common.KV{}, err
+		}
+	}
+	kl, offset := encoding.ReadUint32FromBufferLE(bi.block, bi.nextOffset)
+	k := bi.block[offset : offset+int(kl)]
+	if bi.keyEnd != nil && bytes.Compare(k, bi.keyEnd) >= 0 {
+		// End of range
+		bi.blockNum = -1
+		bi.currKV = common.KV{}
+		return false, common.KV{}, nil
+	}
+	offset += int(kl)
+	vl, offset := encoding.ReadUint32FromBufferLE(bi.block, offset)
+	bi.currKV.Key = k
+	if vl == 0 {
+		bi.currKV.Value = nil
+	} else {
+		bi.currKV.Value = bi.block[offset : offset+int(vl)]
+	}
+	bi.nextOffset = offset + int(vl)
+	return true, bi.currKV, nil
+}
+
+func (bi *blockIterator) Current() common.KV {
+	return bi.currKV
+}
+
+func (bi *blockIterator) Close() {
+}
@@ -0,0 +1,148 @@
+package sst
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	iteration2 "github.com/spirit-labs/tektite/iteration"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var compressionTypes = []compress.CompressionType{compress.CompressionTypeNone, compress.CompressionTypeSnappy,
+	compress.CompressionTypeLz4, compress.CompressionTypeZstd}
+
+func TestBuildTableV2(t *testing.T) {
+	for _, compressionType := range compressionTypes {
+		t.Run(compressionType.String(), func(t *testing.T) {
+			numEntries := 1000
+			it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), numEntries)
+			sstable, smallestKey, largestKey, _, _, err := buildSSTableV2(compressionType, 0, 512, 0, it)
+			require.NoError(t, err)
+			require.Equal(t, common.DataFormatV2, sstable.format)
+			require.Equal(t, compressionType, sstable.compression)
+			require.Equal(t, numEntries, sstable.NumEntries())
+			require.Greater(t, int(sstable.numBlocks), 1)
+			require.Equal(t, "keyprefix/somekey-0000000000", string(smallestKey))
+			require.Equal(t, "keyprefix/somekey-0000000999", string(largestKey))
+
+			iter, err := sstable.NewIterator(nil, nil)
+			require.NoError(t, err)
+			for i := 0; i < numEntries; i++ {
+				kv := requireIterNextValid(t, iter, true)
+				require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", i), string(kv.Key))
+				require.Equal(t, fmt.Sprintf("valueprefix/somevalue-%010d", i), string(kv.Value))
+				require.Equal(t, kv, iter.Current())
+			}
+			requireIterNextValid(t, iter, false)
+			require.Equal(t, common.KV{}, iter.Current())
+		})
+	}
+}
+
+func TestBuildTableV2Compresses(t *testing.T) {
+	numEntries := 1000
+	uncompressed, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV2, BuildOptions{}, 0, 0,
+		prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), numEntries))
+	require.NoError(t, err)
+	for _, compressionType := range compressionTypes[1:] {
+		compressed, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV2,
+			BuildOptions{Compression: compressionType}, 0, 0,
+			prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), numEntries))
+		require.NoError(t, err)
+		require.Less(t, compressed.SizeBytes(), uncompressed.SizeBytes())
+	}
+}
+
+func TestBuildTableV2WithTombstones(t *testing.T) {
+	gi := &iteration2.StaticIterator{}
+	gi.AddKV([]byte("keyPrefix/key0"), nil)
+	gi.AddKV([]byte("keyPrefix/key1"), []byte("val1"))
+	gi.AddKV([]byte("keyPrefix/key2"), nil)
+	sstable, _, _, _, _, err := buildSSTableV2(compress.CompressionTypeLz4, 0, 16, 0, gi)
+	require.NoError(t, err)
+	require.Equal(t, 2, sstable.NumDeletes())
+
+	iter, err := sstable.NewIterator(nil, nil)
+	require.NoError(t, err)
+	kv := requireIterNextValid(t, iter, true)
+	require.Equal(t, "keyPrefix/key0", string(kv.Key))
+	require.Nil(t, kv.Value)
+	kv = requireIterNextValid(t, iter, true)
+	require.Equal(t, "keyPrefix/key1", string(kv.Key))
+	require.Equal(t, "val1", string(kv.Value))
+	kv = requireIterNextValid(t, iter, true)
+	require.Equal(t, "keyPrefix/key2", string(kv.Key))
+	require.Nil(t, kv.Value)
+	requireIterNextValid(t, iter, false)
+}
+
+func TestBuildTableV2Empty(t *testing.T) {
+	sstable, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV2,
+		BuildOptions{Compression: compress.CompressionTypeSnappy}, 0, 0, &iteration2.StaticIterator{})
+	require.NoError(t, err)
+	require.Equal(t, 0, int(sstable.numBlocks))
+	iter, err := sstable.NewIterator(nil, nil)
+	require.NoError(t, err)
+	requireIterNextValid(t, iter, false)
+}
+
+func TestIterateV2(t *testing.T) {
+	for _, compressionType := range compressionTypes {
+		t.Run(compressionType.String(), func(t *testing.T) {
+			it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 1000)
+			sstable, _, _, _, _, err := buildSSTableV2(compressionType, 0, 512, 0, it)
+			require.NoError(t, err)
+			testIterateV2(t, sstable, []byte("keyprefix/"), nil, 0, 999)
+			testIterateV2(t, sstable, []byte("keyprefix/"), []byte("keyprefix/somekey-0000000450"), 0, 449)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000000300"), nil, 300, 999)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000000300999"), nil, 301, 999)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000000300"), []byte("keyprefix/somekey-0000000900"), 300, 899)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000000700"), []byte("keyprefix/somekey-0000000701"), 700, 700)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000000700"), []byte("keyprefix/somekey-0000000700"), -1, -1)
+			testIterateV2(t, sstable, []byte("keyprefix/somekey-0000001000"), nil, -1, -1)
+			testIterateV2(t, sstable, []byte("keyprefix/t"), []byte("keyprefix/u"), -1, -1)
+		})
+	}
+}
+
+func testIterateV2(t *testing.T, sstable *SSTable, startKey []byte, endKey []byte, firstExpected int, lastExpected int) {
+	t.Helper()
+	iter, err := sstable.NewIterator(startKey, endKey)
+	require.NoError(t, err)
+	if firstExpected != -1 {
+		for i := firstExpected; i <= lastExpected; i++ {
+			kv := requireIterNextValid(t, iter, true)
+			require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", i), string(kv.Key))
+			require.Equal(t, fmt.Sprintf("valueprefix/somevalue-%010d", i), string(kv.Value))
+		}
+	}
+	requireIterNextValid(t, iter, false)
+}
+
+func TestSerializeDeserializeV2(t *testing.T) {
+	it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 1000)
+	sstable, _, _, _, _, err := buildSSTableV2(compress.CompressionTypeZstd, 0, 512, 0, it)
+	require.NoError(t, err)
+	buff := sstable.Serialize()
+
+	sstable2 := &SSTable{}
+	sstable2.Deserialize(buff, 0)
+
+	require.Equal(t, sstable.format, sstable2.format)
+	require.Equal(t, sstable.indexOffset, sstable2.indexOffset)
+	require.Equal(t, sstable.numEntries, sstable2.numEntries)
+	require.Equal(t, sstable.maxKeyLength, sstable2.maxKeyLength)
+	require.Equal(t, sstable.creationTime, sstable2.creationTime)
+	require.Equal(t, sstable.numBlocks, sstable2.numBlocks)
+	require.Equal(t, sstable.compression, sstable2.compression)
+	require.Equal(t, sstable.data, sstable2.data)
+
+	testIterateV2(t, sstable2, []byte("keyprefix/somekey-0000000500"), nil, 500, 999)
+}
+
+func TestCompressionNotSupportedV1(t *testing.T) {
+	_, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV1,
+		BuildOptions{Compression: compress.CompressionTypeSnappy}, 0, 0, prepareInput(nil, nil, 10))
+	require.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package sst
+
+import (
+	"github.com/dgraph-io/ristretto"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/asl/errwrap"
+	"github.com/spirit-labs/tektite/common"
+	"math"
+)
+
+const (
+	// DefaultBloomFilterCacheMaxSizeBytes is the default max size of a BloomFilterCache
+	DefaultBloomFilterCacheMaxSizeBytes = 16 * 1024 * 1024
+	maxBloomFilterHashes                = 30
+	minBloomFilterBits                  = 64
+)
+
+// BloomFilter is a bloom filter over the keys of an SSTable, used to avoid fetching tables which can't contain a key
+// when doing point lookups. Keys are added without their version, so all versions of a key map to the same bits.
+type BloomFilter struct {
+	numHashes uint32
+	bits      []byte
+}
+
+// BloomFilterGetter gets the bloom filter of an SSTable. Returns nil if the table does not have a bloom filter.
+type BloomFilterGetter func(tableID SSTableID) (*BloomFilter, error)
+
+// MayContainUnversioned returns false if the table definitely does not contain any version of the key. The key must
+// not include a version.
+func (b *BloomFilter) MayContainUnversioned(keyNoVersion []byte) bool {
+	return b.mayContainHash(bloomFilterHash(keyNoVersion))
+}
+
+func (b *BloomFilter) mayContainHash(hash uint64) bool {
+	numBits := uint32(len(b.bits) * 8)
+	h1, h2 := uint32(hash), uint32(hash>>32)
+	for i := uint32(0); i < b.numHashes; i++ {
+		bit := (h1 + i*h2) % numBits
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *BloomFilter) SizeBytes() int {
+	return 1 + len(b.bits)
+}
+
+func (b *BloomFilter) Serialize(buff []byte) []byte {
+	buff = append(buff, byte(b.numHashes))
+	return append(buff, b.bits...)
+}
+
+func (b *BloomFilter) Deserialize(buff []byte, offset int) int {
+	b.numHashes = uint32(buff[offset])
+	b.bits = buff[offset+1:]
+	return len(buff)
+}
+
+// newBloomFilter creates a bloom filter from the hashes of the keys, with the number of hashes chosen to minimise the
+// false positive rate for bitsPerKey
+func newBloomFilter(hashes []uint64, bitsPerKey int) *BloomFilter {
+	numHashes := uint32(math.Round(float64(bitsPerKey) * math.Ln2))
+	if numHashes < 1 {
+		numHashes = 1
+	} else if numHashes > maxBloomFilterHashes {
+		numHashes = maxBloomFilterHashes
+	}
+	numBits := len(hashes) * bitsPerKey
+	if numBits < minBloomFilterBits {
+		numBits = minBloomFilterBits
+	}
+	b := &BloomFilter{
+		numHashes: numHashes,
+		bits:      make([]byte, (numBits+7)/8),
+	}
+	actualBits := uint32(len(b.bits) * 8)
+	for _, hash := range hashes {
+		h1, h2 := uint32(hash), uint32(hash>>32)
+		for i := uint32(0); i < numHashes; i++ {
+			bit := (h1 + i*h2) % actualBits
+			b.bits[bit/8] |= 1 << (bit % 8)
+		}
+	}
+	return b
+}
+
+// bloomFilterHash is FNV-1a over a key without its version
+func bloomFilterHash(keyNoVersion []byte) uint64 {
+	hash := uint64(14695981039346656037)
+	for _, b := range keyNoVersion {
+		hash ^= uint64(b)
+		hash *= 1099511628211
+	}
+	return hash
+}
+
+// ReadBloomFilter reads just the bloom filter of an SSTable using the range getter. Returns nil if the table does not
+// have a bloom filter.
+func ReadBloomFilter(tableID SSTableID, rangeGetter RangeGetter) (*BloomFilter, error) {
+	header, err := rangeGetter(tableID, 0, formatV2HeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(header) == 0 {
+		return nil, errwrap.Errorf("cannot find sstable %s", string(tableID))
+	}
+	if common.DataFormat(header[0]) != common.DataFormatV2 {
+		return nil, nil
+	}
+	indexOffset, _ := encoding.ReadUint32FromBufferLE(header, formatV2IndexOffsetOffset)
+	filterOffset, _ := encoding.ReadUint32FromBufferLE(header, formatV2FilterOffsetOffset)
+	if filterOffset == indexOffset {
+		return nil, nil
+	}
+	buff, err := rangeGetter(tableID, int64(filterOffset), int64(indexOffset))
+	if err != nil {
+		return nil, err
+	}
+	if len(buff) != int(indexOffset-filterOffset) {
+		return nil, errwrap.Errorf("sstable %s truncated - read %d bytes of bloom filter, expected %d",
+			string(tableID), len(buff), indexOffset-filterOffset)
+	}
+	filter := &BloomFilter{}
+	filter.Deserialize(buff, 0)
+	return filter, nil
+}
+
+/*
+BloomFilterCache caches the bloom filters of SSTables. SSTables are immutable so a cached filter never needs to be
+invalidated. Tables without a bloom filter are cached too, so we don't keep reading their headers.
+*/
+type BloomFilterCache struct {
+	cache       *ristretto.Cache
+	rangeGetter RangeGetter
+}
+
+func NewBloomFilterCache(rangeGetter RangeGetter, maxSizeBytes int) (*BloomFilterCache, error) {
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		// Assume filters of around 1KB
+		NumCounters: int64(10 * (maxSizeBytes / 1024)),
+		MaxCost:     int64(maxSizeBytes),
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BloomFilterCache{
+		cache:       cache,
+		rangeGetter: rangeGetter,
+	}, nil
+}
+
+func (b *BloomFilterCache) GetBloomFilter(tableID SSTableID) (*BloomFilter, error) {
+	sKey := common.ByteSliceToStringZeroCopy(tableID)
+	v, ok := b.cache.Get(sKey)
+	if ok {
+		return v.(*BloomFilter), nil
+	}
+	filter, err := ReadBloomFilter(tableID, b.rangeGetter)
+	if err != nil {
+		return nil, err
+	}
+	cost := int64(1)
+	if filter != nil {
+		cost = int64(filter.SizeBytes())
+	}
+	b.cache.Set(string(tableID), filter, cost)
+	return filter, nil
+}
@@ -0,0 +1,118 @@
+package sst
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	iteration2 "github.com/spirit-labs/tektite/iteration"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func unversionedKey(i int) []byte {
+	return []byte(fmt.Sprintf("keyprefix/somekey-%010d", i))
+}
+
+func versionedKey(i int, version uint64) []byte {
+	return encoding.EncodeVersion(unversionedKey(i), version)
+}
+
+// prepareVersionedInput creates entries with two versions of each even numbered key
+func prepareVersionedInput(numKeys int) *iteration2.StaticIterator {
+	gi := &iteration2.StaticIterator{}
+	for i := 0; i < numKeys; i++ {
+		value := []byte(fmt.Sprintf("somevalue-%010d", i))
+		gi.AddKV(versionedKey(2*i, 2), value)
+		gi.AddKV(versionedKey(2*i, 1), value)
+	}
+	return gi
+}
+
+func buildTableWithBloomFilter(t *testing.T, numKeys int, bitsPerKey int) *SSTable {
+	table, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV2, BuildOptions{BloomFilterBitsPerKey: bitsPerKey},
+		0, 0, prepareVersionedInput(numKeys))
+	require.NoError(t, err)
+	return table
+}
+
+func TestBloomFilter(t *testing.T) {
+	numKeys := 10000
+	table := buildTableWithBloomFilter(t, numKeys, 10)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+
+	filter, err := ReadBloomFilter([]byte("table1"), getter.getRange)
+	require.NoError(t, err)
+	require.NotNil(t, filter)
+	// header and filter
+	require.Equal(t, 2, getter.numReads)
+	// Versions of the same key are only added once
+	require.Equal(t, 1+numKeys*10/8, filter.SizeBytes())
+
+	for i := 0; i < numKeys; i++ {
+		// No false negatives - all versions of the key are added without their version
+		require.True(t, filter.MayContainUnversioned(unversionedKey(2*i)))
+	}
+	falsePositives := 0
+	for i := 0; i < numKeys; i++ {
+		if filter.MayContainUnversioned(unversionedKey(2*i + 1)) {
+			falsePositives++
+		}
+	}
+	// With 10 bits per key the false positive rate should be around 1%
+	require.Less(t, falsePositives, numKeys/50)
+
+	// The table can still be iterated
+	iter, err := getter.newIterator([]byte("table1"), versionedKey(200, 2), nil)
+	require.NoError(t, err)
+	kv := requireIterNextValid(t, iter, true)
+	require.Equal(t, versionedKey(200, 2), kv.Key)
+	require.Equal(t, "somevalue-0000000100", string(kv.Value))
+}
+
+func TestNoBloomFilter(t *testing.T) {
+	table := buildTableWithBloomFilter(t, 100, 0)
+	tableV1, _, _, _, _, err := BuildSSTable(common.DataFormatV1, 0, 0, prepareVersionedInput(100))
+	require.NoError(t, err)
+	tableEmpty := buildTableWithBloomFilter(t, 0, 10)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize(), "table2": tableV1.Serialize(),
+		"table3": tableEmpty.Serialize()}}
+	for _, tableID := range []string{"table1", "table2", "table3"} {
+		filter, err := ReadBloomFilter([]byte(tableID), getter.getRange)
+		require.NoError(t, err)
+		require.Nil(t, filter)
+	}
+	_, err = ReadBloomFilter([]byte("table4"), getter.getRange)
+	require.Error(t, err)
+}
+
+func TestBloomFilterNotSupportedV1(t *testing.T) {
+	_, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV1, BuildOptions{BloomFilterBitsPerKey: 10}, 0, 0,
+		prepareVersionedInput(10))
+	require.Error(t, err)
+}
+
+func TestBloomFilterCache(t *testing.T) {
+	table := buildTableWithBloomFilter(t, 100, 10)
+	tableNoFilter := buildTableWithBloomFilter(t, 100, 0)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize(),
+		"table2": tableNoFilter.Serialize()}}
+	cache, err := NewBloomFilterCache(getter.getRange, DefaultBloomFilterCacheMaxSizeBytes)
+	require.NoError(t, err)
+
+	filter, err := cache.GetBloomFilter([]byte("table1"))
+	require.NoError(t, err)
+	require.True(t, filter.MayContainUnversioned(unversionedKey(0)))
+	filter, err = cache.GetBloomFilter([]byte("table2"))
+	require.NoError(t, err)
+	require.Nil(t, filter)
+	numReads := getter.numReads
+	cache.cache.Wait()
+
+	filter, err = cache.GetBloomFilter([]byte("table1"))
+	require.NoError(t, err)
+	require.True(t, filter.MayContainUnversioned(unversionedKey(0)))
+	filter, err = cache.GetBloomFilter([]byte("table2"))
+	require.NoError(t, err)
+	require.Nil(t, filter)
+	require.Equal(t, numReads, getter.numReads)
+}
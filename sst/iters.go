@@ -9,6 +9,9 @@ import (
 )
 
 func (s *SSTable) NewIterator(keyStart []byte, keyEnd []byte) (iteration.Iterator, error) {
+	if s.format == common.DataFormatV2 {
+		return s.newBlockIterator(keyStart, keyEnd)
+	}
 	offset := s.findOffset(keyStart)
 	si := &SSTableIterator{
 		ss:         s,
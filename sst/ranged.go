@@ -0,0 +1,152 @@
+package sst
+
+import (
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/asl/errwrap"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	"github.com/spirit-labs/tektite/iteration"
+	"math"
+)
+
+// RangeGetter gets the bytes of an SSTable in the range [rangeStart, rangeEnd). If the range extends past the end of
+// the table then the bytes up to the end of the table are returned. Returns nil if the table does not exist.
+type RangeGetter func(tableID SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error)
+
+// TableIndexGetter gets the index of an SSTable, as read by ReadTableIndex. As tables are immutable, indexes can be
+// cached.
+type TableIndexGetter func(tableID SSTableID) (*SSTable, error)
+
+// RangedSSTableIterator iterates over a key range of an SSTable without reading the whole table. For DataFormatV2 it
+// gets the index of the table, then reads only the blocks which contain the key range. Tables in older formats don't
+// have a block index so are got in full with the table getter. Like LazySSTableIterator, nothing is read until Next is
+// called.
+type RangedSSTableIterator struct {
+	tableID     SSTableID
+	indexGetter TableIndexGetter
+	rangeGetter RangeGetter
+	tableGetter TableGetter
+	keyStart    []byte
+	keyEnd      []byte
+	iter        iteration.Iterator
+}
+
+func NewRangedSSTableIterator(tableID SSTableID, indexGetter TableIndexGetter, rangeGetter RangeGetter,
+	tableGetter TableGetter, keyStart []byte, keyEnd []byte) (iteration.Iterator, error) {
+	return &RangedSSTableIterator{
+		tableID:     tableID,
+		indexGetter: indexGetter,
+		rangeGetter: rangeGetter,
+		tableGetter: tableGetter,
+		keyStart:    keyStart,
+		keyEnd:      keyEnd,
+	}, nil
+}
+
+func (r *RangedSSTableIterator) Next() (bool, common.KV, error) {
+	if r.iter == nil {
+		iter, err := r.createIter()
+		if err != nil {
+			return false, common.KV{}, err
+		}
+		r.iter = iter
+	}
+	return r.iter.Next()
+}
+
+func (r *RangedSSTableIterator) createIter() (iteration.Iterator, error) {
+	index, err := r.indexGetter(r.tableID)
+	if err != nil {
+		return nil, err
+	}
+	if !index.HasBlockIndex() {
+		table, err := r.tableGetter(r.tableID)
+		if err != nil {
+			return nil, err
+		}
+		if table == nil {
+			return nil, errwrap.Errorf("cannot find sstable %s", string(r.tableID))
+		}
+		return table.NewIterator(r.keyStart, r.keyEnd)
+	}
+	firstBlock, lastBlock := index.findBlockRange(r.keyStart, r.keyEnd)
+	if firstBlock == -1 {
+		return index.newBlockIteratorForBlocks(r.keyStart, r.keyEnd, -1, -1, nil, 0)
+	}
+	// The blocks are contiguous so we can read them all with a single range
+	blocksStart, _, _ := index.blockLocation(firstBlock)
+	lastBlockOffset, lastBlockLength, _ := index.blockLocation(lastBlock)
+	blocksEnd := lastBlockOffset + lastBlockLength
+	blocks, err := getTableRange(r.tableID, r.rangeGetter, int64(blocksStart), int64(blocksEnd))
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) != int(blocksEnd-blocksStart) {
+		return nil, errwrap.Errorf("sstable %s truncated - read %d bytes of blocks, expected %d", string(r.tableID),
+			len(blocks), blocksEnd-blocksStart)
+	}
+	return index.newBlockIteratorForBlocks(r.keyStart, r.keyEnd, firstBlock, lastBlock, blocks, blocksStart)
+}
+
+func (r *RangedSSTableIterator) Current() common.KV {
+	if r.iter == nil {
+		return common.KV{}
+	}
+	return r.iter.Current()
+}
+
+func (r *RangedSSTableIterator) Close() {
+	if r.iter != nil {
+		r.iter.Close()
+	}
+}
+
+// ReadTableIndex reads the index of an SSTable. For DataFormatV2 this reads the header, then the block index and
+// metadata, which can be used to find the blocks containing a key range. Tables in older formats don't have a block
+// index, so only the header is read and the returned table only knows its format.
+func ReadTableIndex(tableID SSTableID, rangeGetter RangeGetter) (*SSTable, error) {
+	header, err := getTableRange(tableID, rangeGetter, 0, formatV2HeaderSize)
+	if err != nil {
+		return nil, err
+	}
+	if common.DataFormat(header[0]) != common.DataFormatV2 {
+		return &SSTable{format: common.DataFormat(header[0])}, nil
+	}
+	indexOffset, _ := encoding.ReadUint32FromBufferLE(header, formatV2IndexOffsetOffset)
+	indexAndMetadata, err := getTableRange(tableID, rangeGetter, int64(indexOffset), math.MaxInt64)
+	if err != nil {
+		return nil, err
+	}
+	return deserializeIndex(header, indexAndMetadata), nil
+}
+
+// HasBlockIndex returns true if the table has a block index, so that ranges of it can be read
+func (s *SSTable) HasBlockIndex() bool {
+	return s.format == common.DataFormatV2
+}
+
+func getTableRange(tableID SSTableID, rangeGetter RangeGetter, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	buff, err := rangeGetter(tableID, rangeStart, rangeEnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(buff) == 0 {
+		return nil, errwrap.Errorf("cannot find sstable %s", string(tableID))
+	}
+	return buff, nil
+}
+
+// deserializeIndex creates a DataFormatV2 SSTable from its header and the bytes from the start of its index to the
+// end of the table. The table can find blocks but does not contain them.
+func deserializeIndex(header []byte, indexAndMetadata []byte) *SSTable {
+	metadataOffset, _ := encoding.ReadUint32FromBufferLE(header, 1)
+	indexOffset, _ := encoding.ReadUint32FromBufferLE(header, formatV2IndexOffsetOffset)
+	s := &SSTable{
+		format:      common.DataFormatV2,
+		compression: compress.CompressionType(header[formatV2CompressionOffset]),
+		dataOffset:  indexOffset,
+		data:        indexAndMetadata,
+	}
+	s.deserializeMetadata(indexAndMetadata, int(metadataOffset-indexOffset))
+	return s
+}
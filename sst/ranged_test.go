@@ -0,0 +1,166 @@
+package sst
+
+import (
+	"fmt"
+	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
+	"github.com/spirit-labs/tektite/iteration"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testRangeGetter struct {
+	tables       map[string][]byte
+	bytesRead    int
+	numReads     int
+	numTableGets int
+}
+
+func (t *testRangeGetter) getRange(tableID SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+	buff, ok := t.tables[string(tableID)]
+	if !ok {
+		return nil, nil
+	}
+	if rangeEnd > int64(len(buff)) {
+		rangeEnd = int64(len(buff))
+	}
+	t.numReads++
+	t.bytesRead += int(rangeEnd - rangeStart)
+	return buff[rangeStart:rangeEnd], nil
+}
+
+func (t *testRangeGetter) getTableIndex(tableID SSTableID) (*SSTable, error) {
+	return ReadTableIndex(tableID, t.getRange)
+}
+
+func (t *testRangeGetter) getTable(tableID SSTableID) (*SSTable, error) {
+	t.numTableGets++
+	buff, ok := t.tables[string(tableID)]
+	if !ok {
+		return nil, nil
+	}
+	table := &SSTable{}
+	table.Deserialize(buff, 0)
+	return table, nil
+}
+
+func (t *testRangeGetter) newIterator(tableID SSTableID, keyStart []byte, keyEnd []byte) (iteration.Iterator, error) {
+	return NewRangedSSTableIterator(tableID, t.getTableIndex, t.getRange, t.getTable, keyStart, keyEnd)
+}
+
+func TestRangedIteratorReadsOnlyNeededBlocks(t *testing.T) {
+	for _, compressionType := range compressionTypes {
+		t.Run(compressionType.String(), func(t *testing.T) {
+			it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 10000)
+			table, _, _, _, _, err := buildSSTableV2(compressionType, 0, 4096, 0, it)
+			require.NoError(t, err)
+			getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+
+			iter, err := getter.newIterator([]byte("table1"), []byte("keyprefix/somekey-0000000300"), []byte("keyprefix/somekey-0000000310"))
+			require.NoError(t, err)
+			// Nothing is read until Next is called
+			require.Equal(t, 0, getter.numReads)
+			for i := 300; i < 310; i++ {
+				kv := requireIterNextValid(t, iter, true)
+				require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", i), string(kv.Key))
+				require.Equal(t, fmt.Sprintf("valueprefix/somevalue-%010d", i), string(kv.Value))
+			}
+			requireIterNextValid(t, iter, false)
+			// header, index and blocks
+			require.Equal(t, 3, getter.numReads)
+			require.Less(t, getter.bytesRead, table.SizeBytes()/4)
+			require.Equal(t, 0, getter.numTableGets)
+		})
+	}
+}
+
+func TestRangedIteratorCachedIndex(t *testing.T) {
+	it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 10000)
+	table, _, _, _, _, err := buildSSTableV2(compress.CompressionTypeLz4, 0, 4096, 0, it)
+	require.NoError(t, err)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+	index, err := ReadTableIndex([]byte("table1"), getter.getRange)
+	require.NoError(t, err)
+	require.True(t, index.HasBlockIndex())
+	getter.numReads = 0
+
+	indexGetter := func(tableID SSTableID) (*SSTable, error) {
+		return index, nil
+	}
+	for i := 0; i < 3; i++ {
+		iter, err := NewRangedSSTableIterator([]byte("table1"), indexGetter, getter.getRange, getter.getTable,
+			[]byte("keyprefix/somekey-0000005000"), []byte("keyprefix/somekey-0000005010"))
+		require.NoError(t, err)
+		for j := 5000; j < 5010; j++ {
+			kv := requireIterNextValid(t, iter, true)
+			require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", j), string(kv.Key))
+		}
+		requireIterNextValid(t, iter, false)
+	}
+	// Only the blocks are read
+	require.Equal(t, 3, getter.numReads)
+}
+
+func TestRangedIterator(t *testing.T) {
+	it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 1000)
+	table, _, _, _, _, err := buildSSTableV2(compress.CompressionTypeLz4, 0, 512, 0, it)
+	require.NoError(t, err)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+	testRanged := func(startKey []byte, endKey []byte, firstExpected int, lastExpected int) {
+		iter, err := getter.newIterator([]byte("table1"), startKey, endKey)
+		require.NoError(t, err)
+		if firstExpected != -1 {
+			for i := firstExpected; i <= lastExpected; i++ {
+				kv := requireIterNextValid(t, iter, true)
+				require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", i), string(kv.Key))
+				require.Equal(t, kv, iter.Current())
+			}
+		}
+		requireIterNextValid(t, iter, false)
+	}
+	testRanged(nil, nil, 0, 999)
+	testRanged([]byte("keyprefix/"), []byte("keyprefix/somekey-0000000450"), 0, 449)
+	testRanged([]byte("keyprefix/somekey-0000000300999"), nil, 301, 999)
+	testRanged([]byte("keyprefix/somekey-0000000700"), []byte("keyprefix/somekey-0000000701"), 700, 700)
+	testRanged([]byte("keyprefix/somekey-0000000700"), []byte("keyprefix/somekey-0000000700"), -1, -1)
+	testRanged([]byte("keyprefix/t"), []byte("keyprefix/u"), -1, -1)
+}
+
+func TestRangedIteratorV1(t *testing.T) {
+	it := prepareInput([]byte("keyprefix/"), []byte("valueprefix/"), 100)
+	table, _, _, _, _, err := BuildSSTable(common.DataFormatV1, 0, 0, it)
+	require.NoError(t, err)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+	iter, err := getter.newIterator([]byte("table1"), []byte("keyprefix/somekey-0000000050"), nil)
+	require.NoError(t, err)
+	for i := 50; i < 100; i++ {
+		kv := requireIterNextValid(t, iter, true)
+		require.Equal(t, fmt.Sprintf("keyprefix/somekey-%010d", i), string(kv.Key))
+	}
+	requireIterNextValid(t, iter, false)
+	// Only the header is read, then the whole table is got with the table getter
+	require.Equal(t, 1, getter.numReads)
+	require.Equal(t, formatV2HeaderSize, getter.bytesRead)
+	require.Equal(t, 1, getter.numTableGets)
+	index, err := getter.getTableIndex([]byte("table1"))
+	require.NoError(t, err)
+	require.False(t, index.HasBlockIndex())
+}
+
+func TestRangedIteratorEmptyTable(t *testing.T) {
+	table, _, _, _, _, err := BuildSSTableWithOptions(common.DataFormatV2, BuildOptions{}, 0, 0,
+		&iteration.StaticIterator{})
+	require.NoError(t, err)
+	getter := &testRangeGetter{tables: map[string][]byte{"table1": table.Serialize()}}
+	iter, err := getter.newIterator([]byte("table1"), nil, nil)
+	require.NoError(t, err)
+	requireIterNextValid(t, iter, false)
+}
+
+func TestRangedIteratorTableNotFound(t *testing.T) {
+	getter := &testRangeGetter{tables: map[string][]byte{}}
+	iter, err := getter.newIterator([]byte("table1"), nil, nil)
+	require.NoError(t, err)
+	_, _, err = iter.Next()
+	require.Error(t, err)
+}
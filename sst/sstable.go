@@ -11,6 +11,7 @@ import (
 	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/asl/errwrap"
 	"github.com/spirit-labs/tektite/common"
+	"github.com/spirit-labs/tektite/compress"
 	"github.com/spirit-labs/tektite/iteration"
 )
 
@@ -24,6 +25,12 @@ type SSTable struct {
 	numPrefixDeletes uint32
 	indexOffset      uint32
 	creationTime     uint64
+	// numBlocks and compression are only used by DataFormatV2
+	numBlocks   uint32
+	compression compress.CompressionType
+	// dataOffset is the offset in the table of the start of data. It is non-zero when only the index and metadata of
+	// the table have been read
+	dataOffset uint32
 
 	//  data
 	//  Initial 5 bytes contain format and metadataOffset
@@ -60,6 +67,41 @@ const maxMetadataSize = 28
 func BuildSSTable(format common.DataFormat, buffSizeEstimate int, entriesEstimate int,
 	iter iteration.Iterator) (ssTable *SSTable, smallestKey []byte, largestKey []byte, minVersion uint64,
 	maxVersion uint64, err error) {
+	return BuildSSTableWithOptions(format, BuildOptions{}, buffSizeEstimate, entriesEstimate, iter)
+}
+
+// BuildOptions are the optional features of an SSTable. They are only supported by DataFormatV2.
+type BuildOptions struct {
+	// Compression is the compression type used for each data block
+	Compression compress.CompressionType
+	// BloomFilterBitsPerKey is the size of the table's bloom filter per key. Zero means no bloom filter is built.
+	BloomFilterBitsPerKey int
+}
+
+// BuildSSTableWithOptions builds an SSTable in the format. Compression and bloom filters are only supported by
+// DataFormatV2.
+func BuildSSTableWithOptions(format common.DataFormat, opts BuildOptions, buffSizeEstimate int, entriesEstimate int,
+	iter iteration.Iterator) (ssTable *SSTable, smallestKey []byte, largestKey []byte, minVersion uint64,
+	maxVersion uint64, err error) {
+	switch format {
+	case common.DataFormatV1:
+		if opts.Compression != compress.CompressionTypeNone {
+			return nil, nil, nil, 0, 0, errwrap.Errorf("compression is not supported by SSTable format %d", format)
+		}
+		if opts.BloomFilterBitsPerKey != 0 {
+			return nil, nil, nil, 0, 0, errwrap.Errorf("bloom filters are not supported by SSTable format %d", format)
+		}
+		return buildSSTableV1(buffSizeEstimate, entriesEstimate, iter)
+	case common.DataFormatV2:
+		return buildSSTableV2(opts.Compression, opts.BloomFilterBitsPerKey, DefaultBlockSize, buffSizeEstimate, iter)
+	default:
+		return nil, nil, nil, 0, 0, errwrap.Errorf("unsupported SSTable format %d", format)
+	}
+}
+
+func buildSSTableV1(buffSizeEstimate int, entriesEstimate int,
+	iter iteration.Iterator) (ssTable *SSTable, smallestKey []byte, largestKey []byte, minVersion uint64,
+	maxVersion uint64, err error) {
 
 	type indexEntry struct {
 		key    []byte
@@ -71,15 +113,9 @@ func BuildSSTable(format common.DataFormat, buffSizeEstimate int, entriesEstimat
 
 	// First byte is the format, then 4 bytes (uint32) which is an offset to the metadata section that we will fill in
 	// later
-	buff = append(buff, byte(format), 0, 0, 0, 0)
-
-	minVersion = math.MaxUint64
-	maxKeyLength := 0
-	numEntries := 0
-	numDeletes := 0
-	numPrefixDeletes := 0
-	first := true
-	var prevKey []byte
+	buff = append(buff, byte(common.DataFormatV1), 0, 0, 0, 0)
+
+	stats := newEntryStats()
 	for {
 		v, kv, err := iter.Next()
 		if err != nil {
@@ -88,47 +124,18 @@ func BuildSSTable(format common.DataFormat, buffSizeEstimate int, entriesEstimat
 		if !v {
 			break
 		}
-		// Sanity checks - can maybe remove them or activate them only with a flag for performance
-		if prevKey != nil && bytes.Compare(prevKey, kv.Key) >= 0 {
-			panic("keys not in order / contains duplicates")
-		}
-		prevKey = kv.Key
-		if first {
-			smallestKey = kv.Key
-			first = false
-		}
+		stats.add(kv)
 		offset := uint32(len(buff))
-		lk := len(kv.Key)
-		if lk > maxKeyLength {
-			maxKeyLength = lk
-		}
 		buff = appendBytesWithLengthPrefix(buff, kv.Key)
 		buff = appendBytesWithLengthPrefix(buff, kv.Value)
 		indexEntries = append(indexEntries, indexEntry{
 			key:    kv.Key,
 			offset: offset,
 		})
-		numEntries++
-		if len(kv.Value) == 0 {
-			if len(kv.Key) == 32 { // [partition_hash, slab_id, version]
-				numPrefixDeletes++
-			}
-			numDeletes++
-		}
-		largestKey = kv.Key
-		version := math.MaxUint64 - binary.BigEndian.Uint64(kv.Key[len(kv.Key)-8:]) // last 8 bytes is version
-
-		if version != math.MaxUint64 && version > maxVersion {
-			// prefix delete tombstones have a special version = math.MaxUint64 which identifies them in merging_iterator
-			// we don't consider this a real version and don't take it into account here
-			maxVersion = version
-		}
-		if version < minVersion {
-			minVersion = version
-		}
 	}
 
 	indexOffset := len(buff)
+	maxKeyLength := stats.maxKeyLength
 
 	for _, entry := range indexEntries {
 		buff = append(buff, entry.key...)
@@ -145,36 +152,100 @@ func BuildSSTable(format common.DataFormat, buffSizeEstimate int, entriesEstimat
 	}
 
 	// Now fill in metadata offset
+	if err := fillInMetadataOffset(buff); err != nil {
+		return nil, nil, nil, 0, 0, err
+	}
+
+	selfTable := stats.newSSTable(common.DataFormatV1, indexOffset)
+	buff = selfTable.appendMetadata(buff)
+	selfTable.data = buff
+
+	return selfTable, stats.smallestKey, stats.largestKey, stats.minVersion, stats.maxVersion, nil
+}
+
+func fillInMetadataOffset(buff []byte) error {
 	metadataOffset := len(buff)
 	if metadataOffset > math.MaxUint32 {
-		return nil, nil, nil, 0, 0, errwrap.New("SSTable too big")
+		return errwrap.New("SSTable too big")
 	}
-
 	buff[1] = byte(metadataOffset)
 	buff[2] = byte(metadataOffset >> 8)
 	buff[3] = byte(metadataOffset >> 16)
 	buff[4] = byte(metadataOffset >> 24)
+	return nil
+}
 
-	selfTable := &SSTable{
+// entryStats tracks the entries added to an SSTable while it is built
+type entryStats struct {
+	smallestKey      []byte
+	largestKey       []byte
+	minVersion       uint64
+	maxVersion       uint64
+	maxKeyLength     int
+	numEntries       int
+	numDeletes       int
+	numPrefixDeletes int
+}
+
+func newEntryStats() *entryStats {
+	return &entryStats{minVersion: math.MaxUint64}
+}
+
+func (e *entryStats) add(kv common.KV) {
+	// Sanity checks - can maybe remove them or activate them only with a flag for performance
+	if e.largestKey != nil && bytes.Compare(e.largestKey, kv.Key) >= 0 {
+		panic("keys not in order / contains duplicates")
+	}
+	if e.smallestKey == nil {
+		e.smallestKey = kv.Key
+	}
+	e.largestKey = kv.Key
+	if len(kv.Key) > e.maxKeyLength {
+		e.maxKeyLength = len(kv.Key)
+	}
+	e.numEntries++
+	version := math.MaxUint64 - binary.BigEndian.Uint64(kv.Key[len(kv.Key)-8:]) // last 8 bytes is version
+	if len(kv.Value) == 0 {
+		// [partition_hash, slab_id, version], or a prefix tombstone for a partition hash which has version
+		// math.MaxUint64
+		if len(kv.Key) == 32 || version == math.MaxUint64 {
+			e.numPrefixDeletes++
+		}
+		e.numDeletes++
+	}
+	if version != math.MaxUint64 && version > e.maxVersion {
+		// prefix delete tombstones have a special version = math.MaxUint64 which identifies them in merging_iterator
+		// we don't consider this a real version and don't take it into account here
+		e.maxVersion = version
+	}
+	if version < e.minVersion {
+		e.minVersion = version
+	}
+}
+
+func (e *entryStats) newSSTable(format common.DataFormat, indexOffset int) *SSTable {
+	return &SSTable{
 		format:           format,
-		maxKeyLength:     uint32(maxKeyLength),
-		numEntries:       uint32(numEntries),
-		numDeletes:       uint32(numDeletes),
-		numPrefixDeletes: uint32(numPrefixDeletes),
+		maxKeyLength:     uint32(e.maxKeyLength),
+		numEntries:       uint32(e.numEntries),
+		numDeletes:       uint32(e.numDeletes),
+		numPrefixDeletes: uint32(e.numPrefixDeletes),
 		indexOffset:      uint32(indexOffset),
 		creationTime:     uint64(time.Now().UTC().UnixMilli()),
 	}
+}
 
-	buff = binary.AppendUvarint(buff, uint64(maxKeyLength))
-	buff = binary.AppendUvarint(buff, uint64(numEntries))
-	buff = binary.AppendUvarint(buff, uint64(numDeletes))
-	buff = binary.AppendUvarint(buff, uint64(numPrefixDeletes))
-	buff = binary.AppendUvarint(buff, uint64(indexOffset))
-	buff = binary.AppendUvarint(buff, selfTable.creationTime)
-
-	selfTable.data = buff
-
-	return selfTable, smallestKey, largestKey, minVersion, maxVersion, nil
+func (s *SSTable) appendMetadata(buff []byte) []byte {
+	buff = binary.AppendUvarint(buff, uint64(s.maxKeyLength))
+	buff = binary.AppendUvarint(buff, uint64(s.numEntries))
+	buff = binary.AppendUvarint(buff, uint64(s.numDeletes))
+	buff = binary.AppendUvarint(buff, uint64(s.numPrefixDeletes))
+	buff = binary.AppendUvarint(buff, uint64(s.indexOffset))
+	buff = binary.AppendUvarint(buff, s.creationTime)
+	if s.format == common.DataFormatV2 {
+		buff = binary.AppendUvarint(buff, uint64(s.numBlocks))
+	}
+	return buff
 }
 
 func (s *SSTable) Serialize() []byte {
@@ -182,12 +253,22 @@ func (s *SSTable) Serialize() []byte {
 }
 
 func (s *SSTable) Deserialize(buff []byte, offset int) int {
+	start := offset
 	s.format = common.DataFormat(buff[offset])
 	offset++
 	var metadataOffset uint32
 	metadataOffset, _ = encoding.ReadUint32FromBufferLE(buff, offset)
-	offset = int(metadataOffset)
+	if s.format == common.DataFormatV2 {
+		s.compression = compress.CompressionType(buff[start+formatV2CompressionOffset])
+	}
+	offset = s.deserializeMetadata(buff, int(metadataOffset))
+
+	s.data = buff
 
+	return offset
+}
+
+func (s *SSTable) deserializeMetadata(buff []byte, offset int) int {
 	var n int
 	var value uint64
 	value, n = binary.Uvarint(buff[offset:])
@@ -208,9 +289,11 @@ func (s *SSTable) Deserialize(buff []byte, offset int) int {
 	value, n = binary.Uvarint(buff[offset:])
 	offset += n
 	s.creationTime = value
-
-	s.data = buff
-
+	if s.format == common.DataFormatV2 {
+		value, n = binary.Uvarint(buff[offset:])
+		offset += n
+		s.numBlocks = uint32(value)
+	}
 	return offset
 }
 
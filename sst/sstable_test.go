@@ -83,6 +83,19 @@ func TestBuildWithPrefixTombstones(t *testing.T) {
 	require.Equal(t, 2, sstable.NumPrefixDeletes())
 }
 
+func TestBuildWithPartitionPrefixTombstones(t *testing.T) {
+	gi := &iteration2.StaticIterator{}
+	gi.AddKV(encoding.EncodeVersion([]byte("partition1......"), math.MaxUint64), nil)
+	gi.AddKV(encoding.EncodeVersion([]byte("partition1.....0"), math.MaxUint64), []byte("x"))
+	gi.AddKV(encoding.EncodeVersion([]byte("partition2......"), math.MaxUint64), nil)
+	gi.AddKV(encoding.EncodeVersion([]byte("partition2.....0"), math.MaxUint64), []byte("x"))
+	gi.AddKV(encoding.EncodeVersion([]byte("partition3......"), 0), nil)
+	sstable, _, _, _, _, err := BuildSSTable(common.DataFormatV1, 0, 0, gi)
+	require.NoError(t, err)
+	require.Equal(t, 2, sstable.NumPrefixDeletes())
+	require.Equal(t, 3, sstable.NumDeletes())
+}
+
 func TestSeek(t *testing.T) {
 	commonPrefix := []byte("keyprefix/")
 	numEntries := 1000
@@ -17,7 +17,7 @@ func CreateKafkaRecordBatch(messages []RawKafkaMessage, offsetStart int64) []byt
 	first := true
 	var firstTimestamp types.Timestamp
 	var timestamp types.Timestamp
-	offset := offsetStart
+	lastOffset := offsetStart + int64(len(messages)) - 1
 	for i, msg := range messages {
 		var ok bool
 		timestamp = types.Timestamp{Val: msg.Timestamp}
@@ -31,7 +31,7 @@ func CreateKafkaRecordBatch(messages []RawKafkaMessage, offsetStart int64) []byt
 		}
 		first = false
 	}
-	kafkaencoding.SetBatchHeader(batchBytes, offsetStart, offset, firstTimestamp, timestamp, len(messages), crc32.NewIEEE())
+	kafkaencoding.SetBatchHeader(batchBytes, offsetStart, lastOffset, firstTimestamp, timestamp, len(messages), crc32.NewIEEE())
 	// Set producer id to -1 (no idempotent producer)
 	minusOne := int64(-1)
 	binary.BigEndian.PutUint64(batchBytes[43:], uint64(minusOne))
@@ -50,6 +50,14 @@ func CreateKafkaRecordBatchWithIncrementingKVs(offsetStart int, numMessages int)
 	return CreateKafkaRecordBatch(msgs, int64(offsetStart))
 }
 
+// CreateTransactionalKafkaRecordBatchWithIncrementingKVs creates a batch which is part of a transaction for the producer
+func CreateTransactionalKafkaRecordBatchWithIncrementingKVs(producerID int64, offsetStart int, numMessages int) []byte {
+	batch := CreateKafkaRecordBatchWithIncrementingKVs(offsetStart, numMessages)
+	binary.BigEndian.PutUint16(batch[21:], binary.BigEndian.Uint16(batch[21:])|1<<4) // set as transactional
+	binary.BigEndian.PutUint64(batch[43:], uint64(producerID))
+	return batch
+}
+
 type RawKafkaMessage struct {
 	Key       []byte
 	Value     []byte
@@ -119,6 +119,17 @@ func TestNotifications(t *testing.T) {
 		}
 	}
 
+	// Alter configs of a topic - cached infos should be updated
+	err = mgr.AlterTopicConfigs(info.Name, []ConfigAlteration{
+		{Name: ConfigMaxMessageBytes, Operation: ConfigOperationSet, Value: "1000"},
+	}, false)
+	require.NoError(t, err)
+	for _, localCache := range localCaches {
+		received, ok := localCache.getTopicInfos()[info.Name]
+		require.True(t, ok)
+		require.Equal(t, 1000, received.MaxMessageBytes())
+	}
+
 	// Send notifications with invalid sequence
 	var notif TopicNotification
 	notif.Sequence = 1023
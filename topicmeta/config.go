@@ -0,0 +1,303 @@
+package topicmeta
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Topics have configs, as in Kafka. The retention configs are stored in the RetentionTime and RetentionBytes fields of
+TopicInfo as they are used by the controller when removing data. Any other configs which have been set are stored in the
+Configs map of TopicInfo. Configs which are not in ConfigDefs are stored but have no effect, this allows topics to be
+created and altered by tools which set configs we don't currently support.
+
+Topics with a cleanup policy including "compact" are compacted by the LSM compaction workers, which retain only the latest
+record for each key. Time and size based retention only apply if the cleanup policy also includes "delete".
+*/
+
+const (
+	ConfigRetentionMs       = "retention.ms"
+	ConfigRetentionBytes    = "retention.bytes"
+	ConfigMaxMessageBytes   = "max.message.bytes"
+	ConfigCleanupPolicy     = "cleanup.policy"
+	ConfigDeleteRetentionMs = "delete.retention.ms"
+
+	CleanupPolicyDelete  = "delete"
+	CleanupPolicyCompact = "compact"
+)
+
+type ConfigType int8
+
+const (
+	ConfigTypeString ConfigType = iota
+	ConfigTypeInt
+	ConfigTypeLong
+	ConfigTypeList
+)
+
+// ConfigDef describes a supported topic config
+type ConfigDef struct {
+	Name          string
+	Type          ConfigType
+	DefaultValue  string
+	Documentation string
+}
+
+var ConfigDefs = []ConfigDef{
+	{
+		Name:         ConfigCleanupPolicy,
+		Type:         ConfigTypeList,
+		DefaultValue: CleanupPolicyDelete,
+		Documentation: "The retention policy to use on old data. \"delete\" removes data once it is past retention, " +
+			"\"compact\" retains only the latest record for each key. Both may be specified.",
+	},
+	{
+		Name:         ConfigDeleteRetentionMs,
+		Type:         ConfigTypeLong,
+		DefaultValue: "86400000",
+		Documentation: "The time to retain delete tombstone markers for compacted topics, after which they are " +
+			"removed by compaction.",
+	},
+	{
+		Name:          ConfigMaxMessageBytes,
+		Type:          ConfigTypeInt,
+		DefaultValue:  strconv.Itoa(math.MaxInt32),
+		Documentation: "The largest record batch size allowed to be produced to the topic.",
+	},
+	{
+		Name:          ConfigRetentionBytes,
+		Type:          ConfigTypeLong,
+		DefaultValue:  "-1",
+		Documentation: "The maximum size a partition can grow to before old data is deleted. -1 means no limit.",
+	},
+	{
+		Name:          ConfigRetentionMs,
+		Type:          ConfigTypeLong,
+		DefaultValue:  "-1",
+		Documentation: "The maximum time data is retained before it is deleted. -1 means no time limit.",
+	},
+}
+
+func GetConfigDef(name string) (ConfigDef, bool) {
+	for _, def := range ConfigDefs {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return ConfigDef{}, false
+}
+
+// ConfigOperation is the type of alteration to make to a config, values are the same as for Kafka
+// IncrementalAlterConfigs
+type ConfigOperation int8
+
+const (
+	ConfigOperationSet ConfigOperation = iota
+	ConfigOperationDelete
+	ConfigOperationAppend
+	ConfigOperationSubtract
+)
+
+type ConfigAlteration struct {
+	Name      string
+	Operation ConfigOperation
+	Value     string
+}
+
+// GetConfig returns the value of the config if it has been set on the topic
+func (t *TopicInfo) GetConfig(name string) (string, bool) {
+	switch name {
+	case ConfigRetentionMs:
+		if t.RetentionTime <= 0 {
+			return "", false
+		}
+		return strconv.FormatInt(t.RetentionTime.Milliseconds(), 10), true
+	case ConfigRetentionBytes:
+		if t.RetentionBytes <= 0 {
+			return "", false
+		}
+		return strconv.FormatInt(t.RetentionBytes, 10), true
+	default:
+		value, ok := t.Configs[name]
+		return value, ok
+	}
+}
+
+// GetConfigOrDefault returns the value of the config if set, otherwise the default value if it is a supported config
+func (t *TopicInfo) GetConfigOrDefault(name string) (string, bool) {
+	value, ok := t.GetConfig(name)
+	if ok {
+		return value, true
+	}
+	def, ok := GetConfigDef(name)
+	if !ok {
+		return "", false
+	}
+	return def.DefaultValue, true
+}
+
+// SetConfig validates and sets the value of the config
+func (t *TopicInfo) SetConfig(name string, value string) error {
+	switch name {
+	case ConfigRetentionMs:
+		retention, err := parseRetention(name, value)
+		if err != nil {
+			return err
+		}
+		t.RetentionTime = time.Duration(retention) * time.Millisecond
+		return nil
+	case ConfigRetentionBytes:
+		retention, err := parseRetention(name, value)
+		if err != nil {
+			return err
+		}
+		t.RetentionBytes = retention
+		return nil
+	case ConfigMaxMessageBytes:
+		maxBytes, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || maxBytes < 0 {
+			return invalidConfigValue(name, value)
+		}
+	case ConfigCleanupPolicy:
+		policies := splitList(value)
+		if len(policies) == 0 {
+			return invalidConfigValue(name, value)
+		}
+		for _, policy := range policies {
+			if policy != CleanupPolicyDelete && policy != CleanupPolicyCompact {
+				return invalidConfigValue(name, value)
+			}
+		}
+	case ConfigDeleteRetentionMs:
+		retention, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || retention < 0 {
+			return invalidConfigValue(name, value)
+		}
+	}
+	if t.Configs == nil {
+		t.Configs = map[string]string{}
+	}
+	t.Configs[name] = value
+	return nil
+}
+
+// DeleteConfig removes the config from the topic, so it reverts to the default
+func (t *TopicInfo) DeleteConfig(name string) {
+	switch name {
+	case ConfigRetentionMs:
+		t.RetentionTime = 0
+	case ConfigRetentionBytes:
+		t.RetentionBytes = 0
+	default:
+		delete(t.Configs, name)
+		if len(t.Configs) == 0 {
+			t.Configs = nil
+		}
+	}
+}
+
+// AlterConfig applies the alteration to the topic. Append and subtract are only valid for list configs.
+func (t *TopicInfo) AlterConfig(alteration ConfigAlteration) error {
+	switch alteration.Operation {
+	case ConfigOperationSet:
+		return t.SetConfig(alteration.Name, alteration.Value)
+	case ConfigOperationDelete:
+		t.DeleteConfig(alteration.Name)
+		return nil
+	case ConfigOperationAppend, ConfigOperationSubtract:
+		def, ok := GetConfigDef(alteration.Name)
+		if !ok || def.Type != ConfigTypeList {
+			return common.NewTektiteErrorf(common.InvalidConfiguration,
+				"config %s is not a list, so cannot be appended to or subtracted from", alteration.Name)
+		}
+		current, _ := t.GetConfigOrDefault(alteration.Name)
+		values := splitList(current)
+		for _, value := range splitList(alteration.Value) {
+			pos := -1
+			for i, existing := range values {
+				if existing == value {
+					pos = i
+					break
+				}
+			}
+			if alteration.Operation == ConfigOperationAppend && pos == -1 {
+				values = append(values, value)
+			} else if alteration.Operation == ConfigOperationSubtract && pos != -1 {
+				values = append(values[:pos], values[pos+1:]...)
+			}
+		}
+		return t.SetConfig(alteration.Name, strings.Join(values, ","))
+	default:
+		return common.NewTektiteErrorf(common.InvalidConfiguration, "invalid config operation %d",
+			alteration.Operation)
+	}
+}
+
+// MaxMessageBytes returns the maximum size of a record batch which can be produced to the topic
+func (t *TopicInfo) MaxMessageBytes() int {
+	value, ok := t.Configs[ConfigMaxMessageBytes]
+	if !ok {
+		return math.MaxInt32
+	}
+	// Already validated
+	maxBytes, _ := strconv.Atoi(value)
+	return maxBytes
+}
+
+// IsCompacted returns true if the topic's cleanup policy includes compaction
+func (t *TopicInfo) IsCompacted() bool {
+	return t.hasCleanupPolicy(CleanupPolicyCompact)
+}
+
+// IsDeleteEnabled returns true if the topic's cleanup policy includes deletion of data which is past retention
+func (t *TopicInfo) IsDeleteEnabled() bool {
+	return t.hasCleanupPolicy(CleanupPolicyDelete)
+}
+
+func (t *TopicInfo) hasCleanupPolicy(policy string) bool {
+	value, _ := t.GetConfigOrDefault(ConfigCleanupPolicy)
+	for _, p := range splitList(value) {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteRetention returns how long tombstones are retained for if the topic is compacted
+func (t *TopicInfo) DeleteRetention() time.Duration {
+	value, _ := t.GetConfigOrDefault(ConfigDeleteRetentionMs)
+	// Already validated
+	retention, _ := strconv.ParseInt(value, 10, 64)
+	return time.Duration(retention) * time.Millisecond
+}
+
+func parseRetention(name string, value string) (int64, error) {
+	retention, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || retention == 0 || retention < -1 {
+		return 0, invalidConfigValue(name, value)
+	}
+	if retention == -1 {
+		// no limit
+		return 0, nil
+	}
+	return retention, nil
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func invalidConfigValue(name string, value string) error {
+	return common.NewTektiteErrorf(common.InvalidConfiguration, "invalid value %q for config %s", value, name)
+}
@@ -0,0 +1,110 @@
+package topicmeta
+
+import (
+	"github.com/spirit-labs/tektite/common"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSetConfig(t *testing.T) {
+	var info TopicInfo
+	require.NoError(t, info.SetConfig(ConfigRetentionMs, "10000"))
+	require.Equal(t, 10*time.Second, info.RetentionTime)
+	require.NoError(t, info.SetConfig(ConfigRetentionBytes, "12345"))
+	require.Equal(t, int64(12345), info.RetentionBytes)
+	require.NoError(t, info.SetConfig(ConfigMaxMessageBytes, "1000"))
+	require.Equal(t, 1000, info.MaxMessageBytes())
+	require.NoError(t, info.SetConfig("unknown.config", "foo"))
+	// Retention configs are not stored in the map
+	require.Equal(t, map[string]string{ConfigMaxMessageBytes: "1000", "unknown.config": "foo"}, info.Configs)
+
+	value, ok := info.GetConfig(ConfigRetentionMs)
+	require.True(t, ok)
+	require.Equal(t, "10000", value)
+	value, ok = info.GetConfig(ConfigRetentionBytes)
+	require.True(t, ok)
+	require.Equal(t, "12345", value)
+
+	// -1 means no limit
+	require.NoError(t, info.SetConfig(ConfigRetentionMs, "-1"))
+	require.Equal(t, time.Duration(0), info.RetentionTime)
+	_, ok = info.GetConfig(ConfigRetentionMs)
+	require.False(t, ok)
+	value, ok = info.GetConfigOrDefault(ConfigRetentionMs)
+	require.True(t, ok)
+	require.Equal(t, "-1", value)
+
+	invalid := []ConfigAlteration{
+		{Name: ConfigRetentionMs, Value: "foo"},
+		{Name: ConfigRetentionMs, Value: "0"},
+		{Name: ConfigRetentionMs, Value: "-2"},
+		{Name: ConfigRetentionBytes, Value: "-2"},
+		{Name: ConfigMaxMessageBytes, Value: "-1"},
+		{Name: ConfigMaxMessageBytes, Value: "10000000000"},
+		{Name: ConfigCleanupPolicy, Value: ""},
+		{Name: ConfigCleanupPolicy, Value: "foo"},
+		{Name: ConfigCleanupPolicy, Value: "compact,foo"},
+		{Name: ConfigDeleteRetentionMs, Value: "-1"},
+	}
+	for _, alteration := range invalid {
+		err := info.SetConfig(alteration.Name, alteration.Value)
+		require.Error(t, err)
+		require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+	}
+}
+
+func TestCleanupPolicy(t *testing.T) {
+	var info TopicInfo
+	require.True(t, info.IsDeleteEnabled())
+	require.False(t, info.IsCompacted())
+	require.Equal(t, 24*time.Hour, info.DeleteRetention())
+
+	require.NoError(t, info.SetConfig(ConfigCleanupPolicy, CleanupPolicyCompact))
+	require.False(t, info.IsDeleteEnabled())
+	require.True(t, info.IsCompacted())
+
+	require.NoError(t, info.SetConfig(ConfigCleanupPolicy, "compact, delete"))
+	require.True(t, info.IsDeleteEnabled())
+	require.True(t, info.IsCompacted())
+
+	require.NoError(t, info.SetConfig(ConfigDeleteRetentionMs, "1000"))
+	require.Equal(t, 1*time.Second, info.DeleteRetention())
+}
+
+func TestDeleteConfig(t *testing.T) {
+	info := TopicInfo{
+		RetentionTime:  1 * time.Hour,
+		RetentionBytes: 1000,
+		Configs:        map[string]string{ConfigMaxMessageBytes: "1000"},
+	}
+	info.DeleteConfig(ConfigRetentionMs)
+	info.DeleteConfig(ConfigRetentionBytes)
+	info.DeleteConfig(ConfigMaxMessageBytes)
+	require.Equal(t, TopicInfo{}, info)
+	require.Equal(t, math.MaxInt32, info.MaxMessageBytes())
+}
+
+func TestAlterListConfig(t *testing.T) {
+	var info TopicInfo
+	// Appending a value which is already present in the default is a no-op
+	err := info.AlterConfig(ConfigAlteration{Name: ConfigCleanupPolicy, Operation: ConfigOperationAppend,
+		Value: CleanupPolicyDelete})
+	require.NoError(t, err)
+	value, ok := info.GetConfig(ConfigCleanupPolicy)
+	require.True(t, ok)
+	require.Equal(t, CleanupPolicyDelete, value)
+
+	// Subtracting all values leaves an empty list which is invalid
+	err = info.AlterConfig(ConfigAlteration{Name: ConfigCleanupPolicy, Operation: ConfigOperationSubtract,
+		Value: CleanupPolicyDelete})
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+
+	// Only list configs can be appended to
+	err = info.AlterConfig(ConfigAlteration{Name: ConfigMaxMessageBytes, Operation: ConfigOperationAppend,
+		Value: "1000"})
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+}
@@ -0,0 +1,257 @@
+package topicmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/common"
+	log "github.com/spirit-labs/tektite/logger"
+	"github.com/spirit-labs/tektite/queryutils"
+	"math"
+	"sort"
+	"time"
+)
+
+/*
+When a topic is deleted its metadata is removed straight away, but the topic's data (record batches and offset
+snapshots) is stored in the LSM keyed by the topic's partition hashes. To reclaim it we write a table containing a prefix
+tombstone for each partition hash, each followed by an end marker. The tombstones and end markers overlap all the data
+for the partition, and iterators skip any data they cover. As they are compacted down through the levels of the LSM the
+data is removed, and at the last level the tombstones themselves are removed.
+
+The topic is pending deletion until its data has been removed. A pending deletion record is persisted along with the
+metadata tombstone, so the topic id cannot be reused after restart, and a topic with the same name cannot be created.
+The pending deletions are checked periodically and once the tombstones have been written, there are no live keys for
+any of the topic's partitions, and no tables containing prefix deletes overlap the partitions, the pending deletion is
+removed. Prefix deletes are only removed when compacted into the last level, so by then the data has been physically
+removed too. The highest id of any deleted topic is also persisted so that ids are never reused.
+
+Committed consumer offsets are stored with the consumer group, keyed by the partition hash of the group, so they are
+not covered by the prefix deletes. Instead, the group coordinator writes tombstones for any committed offsets of topics
+which no longer exist, when it is notified that a topic has been deleted and whenever it loads a group.
+*/
+
+const pendingDeletionsCheckInterval = 10 * time.Second
+
+type pendingDeletion struct {
+	info                 TopicInfo
+	prefixDeletesWritten bool
+}
+
+// IsPendingDeletion returns true if a topic with the name has been deleted, but its data has not yet been removed
+func (m *Manager) IsPendingDeletion(topicName string) bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.isPendingDeletion(topicName)
+}
+
+func (m *Manager) isPendingDeletion(topicName string) bool {
+	for _, pending := range m.pendingDeletions {
+		if pending.info.Name == topicName {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) loadPendingDeletions() error {
+	prefix := createPendingDeletionPrefix()
+	keyEnd := common.IncBigEndianBytes(prefix)
+	mi, err := queryutils.CreateIteratorForKeyRange(prefix, keyEnd, m.lsm, m.tableGetter().GetSSTable)
+	if err != nil {
+		return err
+	}
+	if mi == nil {
+		return nil
+	}
+	defer mi.Close()
+	for {
+		ok, kv, err := mi.Next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		info, err := deserializeTopicInfo(kv.Value)
+		if err != nil {
+			return err
+		}
+		m.pendingDeletions[info.ID] = &pendingDeletion{info: info}
+		if int64(info.ID) >= m.topicIDSequence {
+			// Topic ids must not be reused until the data has been removed
+			m.topicIDSequence = int64(info.ID + 1)
+		}
+	}
+}
+
+func (m *Manager) schedulePendingDeletionsCheck() {
+	m.pendingDeletionsTimer = time.AfterFunc(pendingDeletionsCheckInterval, func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if !m.started {
+			return
+		}
+		if err := m.checkPendingDeletions(); err != nil {
+			log.Warnf("failed to check pending topic deletions: %v", err)
+		}
+		m.schedulePendingDeletionsCheck()
+	})
+}
+
+// checkPendingDeletions writes the prefix deletes for any pending deletion which doesn't yet have them, and removes any
+// pending deletions whose data has all been removed from the LSM
+func (m *Manager) checkPendingDeletions() error {
+	for topicID, pending := range m.pendingDeletions {
+		if !pending.prefixDeletesWritten {
+			if err := m.writePrefixDeletes(&pending.info); err != nil {
+				return err
+			}
+			pending.prefixDeletesWritten = true
+			continue
+		}
+		complete, err := m.isDataRemoved(&pending.info)
+		if err != nil {
+			return err
+		}
+		if !complete {
+			continue
+		}
+		kvs := []common.KV{{Key: createPendingDeletionKey(topicID)}}
+		if topicID > m.maxDeletedTopicID {
+			kvs = append(kvs, createMaxDeletedTopicIDKV(topicID))
+		}
+		if err := m.writeKVs(kvs); err != nil {
+			return err
+		}
+		m.maxDeletedTopicID = max(m.maxDeletedTopicID, topicID)
+		delete(m.pendingDeletions, topicID)
+		log.Debugf("topic %s with id %d has been deleted", pending.info.Name, topicID)
+	}
+	return nil
+}
+
+// isDataRemoved returns true if there are no live keys for any of the topic's partitions, and no tables containing prefix
+// deletes overlap them. Until the prefix deletes have been compacted away the data they cover can physically remain.
+// Note that we can't wait for there to be no tables overlapping the partition hashes, as tables containing data for
+// other partitions can span them.
+func (m *Manager) isDataRemoved(info *TopicInfo) (bool, error) {
+	hashes, err := createPartitionHashes(info)
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		hasPrefixDeletes, err := m.lsm.HasPrefixDeletesInRange(hash, common.IncBigEndianBytes(hash))
+		if err != nil {
+			return false, err
+		}
+		if hasPrefixDeletes {
+			return false, nil
+		}
+		iter, err := queryutils.CreateIteratorForKeyRange(hash, common.IncBigEndianBytes(hash), m.lsm,
+			m.tableGetter().GetSSTable)
+		if err != nil {
+			return false, err
+		}
+		ok, _, err := iter.Next()
+		iter.Close()
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// loadMaxDeletedTopicID loads the highest id of any topic whose deletion has completed, making sure it isn't reused
+func (m *Manager) loadMaxDeletedTopicID() error {
+	value, err := queryutils.GetLatestValueWithKey(createMaxDeletedTopicIDKeyNoVersion(), m.lsm,
+		m.tableGetter().GetSSTable, nil)
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return nil
+	}
+	m.maxDeletedTopicID = int(binary.BigEndian.Uint64(value))
+	if int64(m.maxDeletedTopicID) >= m.topicIDSequence {
+		m.topicIDSequence = int64(m.maxDeletedTopicID + 1)
+	}
+	return nil
+}
+
+// writePrefixDeletes writes a table containing a prefix tombstone and end marker for each partition of the topic
+func (m *Manager) writePrefixDeletes(info *TopicInfo) error {
+	hashes, err := createPartitionHashes(info)
+	if err != nil {
+		return err
+	}
+	// Keys in the table must be in order
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i], hashes[j]) < 0
+	})
+	kvs := make([]common.KV, 0, 2*len(hashes))
+	for _, hash := range hashes {
+		kvs = append(kvs, createPrefixDeleteKVs(hash)...)
+	}
+	return m.writeKVs(kvs)
+}
+
+func createPrefixDeleteKVs(partitionHash []byte) []common.KV {
+	// prefix delete tombstones and end markers have special version math.MaxUint64 which identifies them in
+	// MergingIterator
+	tombstone := encoding.EncodeVersion(common.ByteSliceCopy(partitionHash), math.MaxUint64)
+	// The end marker must come after all keys for the partition, so the table overlaps all the partition's data. We
+	// add a zero so as not to conflict with any tombstone for the next partition hash
+	endMarker := append(common.IncBigEndianBytes(partitionHash), 0)
+	endMarker = encoding.EncodeVersion(endMarker, math.MaxUint64)
+	return []common.KV{
+		{Key: tombstone},
+		{Key: endMarker, Value: []byte{'x'}}, // value doesn't matter but can't be empty
+	}
+}
+
+func createPendingDeletionPrefix() []byte {
+	// Pending deletions are stored with the topic metadata, but under a different prefix to the topics themselves
+	prefix := createPrefix()
+	prefix[8] = 1
+	return prefix
+}
+
+func createPendingDeletionKey(topicID int) []byte {
+	key := encoding.KeyEncodeInt(createPendingDeletionPrefix(), int64(topicID))
+	return encoding.EncodeVersion(key, 0)
+}
+
+func createMaxDeletedTopicIDKeyNoVersion() []byte {
+	// Stored with the topic metadata, under a different prefix again
+	prefix := createPrefix()
+	prefix[8] = 2
+	return prefix
+}
+
+func createMaxDeletedTopicIDKV(topicID int) common.KV {
+	return common.KV{
+		Key:   encoding.EncodeVersion(createMaxDeletedTopicIDKeyNoVersion(), 0),
+		Value: binary.BigEndian.AppendUint64(nil, uint64(topicID)),
+	}
+}
+
+func deserializeTopicInfo(value []byte) (TopicInfo, error) {
+	var info TopicInfo
+	topicMetaVersion := binary.BigEndian.Uint16(value)
+	switch topicMetaVersion {
+	case topicMetadataVersion:
+		info.Deserialize(value, 2)
+	case topicMetadataVersionV2:
+		info.deserializeV2(value, 2)
+	case topicMetadataVersionV1:
+		info.deserializeV1(value, 2)
+	default:
+		return TopicInfo{}, errors.Errorf("invalid topic metadata version %d", topicMetaVersion)
+	}
+	return info, nil
+}
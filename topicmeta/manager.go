@@ -1,6 +1,7 @@
 package topicmeta
 
 import (
+	"bytes"
 	"encoding/binary"
 	"github.com/pkg/errors"
 	"github.com/spirit-labs/tektite/asl/encoding"
@@ -9,9 +10,12 @@ import (
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/lsm"
 	"github.com/spirit-labs/tektite/objstore"
+	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/queryutils"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/transport"
+	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,9 +24,11 @@ import (
 /*
 Manager lives on the controller and manages topic metadata persistently. Topic metadata includes the topic name, the
 topic id and number of partitions. Methods exist to create and delete topics which don't return until the topic
-metadata has been written to object storage.
-When a topic is created or deleted a notification is sent to the local cache instances which live on all the non leader
-agents, so the topic can be added or removed from the cache.
+metadata has been written to object storage. The number of partitions of an existing topic can also be increased, and
+its configs altered.
+When a topic is created, deleted, has partitions added or configs altered, a notification is sent to the local cache
+instances which live on all the non leader agents, so the topic can be added, updated or removed in the cache.
+When a topic is deleted it is pending deletion until its data has been removed from the LSM, see deletion.go.
 */
 type Manager struct {
 	lock             sync.RWMutex
@@ -38,31 +44,55 @@ type Manager struct {
 	membership       cluster.MembershipState
 	connFactory      transport.ConnectionFactory
 	connections      map[string]transport.Connection
+	// retentions are protected by a separate lock as they are looked up from the LSM manager while it holds its own
+	// lock, and the main lock is held while calling into the LSM when topics are created or deleted
+	retentionsLock        sync.RWMutex
+	partitionRetentions   map[string]PartitionRetention
+	partitionHashes       [][]byte // The hashes of all partitions, in order
+	pendingDeletions      map[int]*pendingDeletion
+	pendingDeletionsTimer *time.Timer
+	maxDeletedTopicID     int
+}
+
+// PartitionRetention describes the retention of the topic partition with a particular partition hash. If the topic is
+// compacted, DeleteRetention is how long tombstones are retained for.
+type PartitionRetention struct {
+	TopicID         int
+	PartitionID     int
+	RetentionTime   time.Duration
+	RetentionBytes  int64
+	Compacted       bool
+	DeleteRetention time.Duration
 }
 
 type lsmHolder interface {
 	QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.OverlappingTables, error)
+	HasPrefixDeletesInRange(keyStart []byte, keyEnd []byte) (bool, error)
 	ApplyLsmChanges(regBatch lsm.RegistrationBatch, completionFunc func(error) error) error
 }
 
 func NewManager(lsm lsmHolder, objStore objstore.Client, dataBucketName string,
 	dataFormat common.DataFormat, connFactory transport.ConnectionFactory) (*Manager, error) {
 	return &Manager{
-		lsm:              lsm,
-		objStore:         objStore,
-		dataBucketName:   dataBucketName,
-		dataFormat:       dataFormat,
-		topicInfosByName: make(map[string]*TopicInfo),
-		topicInfosByID:   make(map[int]*TopicInfo),
-		connFactory:      connFactory,
-		connections:      make(map[string]transport.Connection),
+		lsm:                 lsm,
+		objStore:            objStore,
+		dataBucketName:      dataBucketName,
+		dataFormat:          dataFormat,
+		topicInfosByName:    make(map[string]*TopicInfo),
+		topicInfosByID:      make(map[int]*TopicInfo),
+		connFactory:         connFactory,
+		connections:         make(map[string]transport.Connection),
+		partitionRetentions: make(map[string]PartitionRetention),
+		pendingDeletions:    make(map[int]*pendingDeletion),
 	}, nil
 }
 
 const (
 	objStoreCallTimeout             = 5 * time.Second
 	unavailabilityRetryDelay        = 1 * time.Second
-	topicMetadataVersion     uint16 = 1
+	topicMetadataVersionV1   uint16 = 1
+	topicMetadataVersionV2   uint16 = 2
+	topicMetadataVersion     uint16 = 3
 	TopicIDSequenceBase             = 1000
 )
 
@@ -75,6 +105,7 @@ func (m *Manager) Start() error {
 	if err := m.loadTopics(); err != nil {
 		return err
 	}
+	m.schedulePendingDeletionsCheck()
 	m.started = true
 	return nil
 }
@@ -86,6 +117,7 @@ func (m *Manager) Stop() error {
 	if !m.started {
 		return nil
 	}
+	m.pendingDeletionsTimer.Stop()
 	m.started = false
 	return nil
 }
@@ -136,12 +168,18 @@ func (m *Manager) CreateTopic(topicInfo TopicInfo) error {
 	if ok {
 		return common.NewTektiteErrorf(common.TopicAlreadyExists, "topic: %s already exists", topicInfo.Name)
 	}
+	if m.isPendingDeletion(topicInfo.Name) {
+		return common.NewTektiteErrorf(common.TopicAlreadyExists, "topic: %s is pending deletion", topicInfo.Name)
+	}
 	topicInfo.ID = int(m.topicIDSequence)
 	log.Debugf("%p created topic with id %d name %s partitions %d", m, topicInfo.ID, topicInfo.Name, topicInfo.PartitionCount)
 	m.topicIDSequence++
 	if err := m.WriteTopic(topicInfo); err != nil {
 		return err
 	}
+	if err := m.addPartitionRetentions(&topicInfo); err != nil {
+		return err
+	}
 	m.topicInfosByName[topicInfo.Name] = &topicInfo
 	m.topicInfosByID[topicInfo.ID] = &topicInfo
 	m.SendTopicNotification(transport.HandlerIDMetaLocalCacheTopicAdded, topicInfo)
@@ -158,12 +196,91 @@ func (m *Manager) DeleteTopic(topicName string) error {
 	// Note, we increment sequence on delete too, this is because it used to track any change in topics and is sent
 	// in notifications so local caches can detect whether they have missed any notifications and invalidate
 	m.topicIDSequence++
-	if err := m.WriteTopicDeletion(info.ID); err != nil {
+	if err := m.WriteTopicDeletion(*info); err != nil {
+		return err
+	}
+	if err := m.removePartitionRetentions(info); err != nil {
 		return err
 	}
 	delete(m.topicInfosByName, topicName)
 	delete(m.topicInfosByID, info.ID)
+	pending := &pendingDeletion{info: *info}
+	m.pendingDeletions[info.ID] = pending
 	m.SendTopicNotification(transport.HandlerIDMetaLocalCacheTopicDeleted, *info)
+	// Now remove the data. If this fails it will be retried when pending deletions are next checked.
+	if err := m.writePrefixDeletes(info); err != nil {
+		log.Warnf("failed to write prefix deletes for topic %s: %v", topicName, err)
+	} else {
+		pending.prefixDeletesWritten = true
+	}
+	return nil
+}
+
+// CreatePartitions increases the number of partitions of the topic to partitionCount. The partition count of a topic
+// can only be increased.
+func (m *Manager) CreatePartitions(topicName string, partitionCount int) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	info, ok := m.topicInfosByName[topicName]
+	if !ok {
+		return common.NewTektiteErrorf(common.TopicDoesNotExist, "topic: %s does not exist", topicName)
+	}
+	if partitionCount <= info.PartitionCount {
+		return common.NewTektiteErrorf(common.InvalidPartitionCount,
+			"topic: %s currently has %d partitions, partition count can only be increased",
+			topicName, info.PartitionCount)
+	}
+	newInfo := *info
+	newInfo.PartitionCount = partitionCount
+	// As with create and delete, we increment the sequence so local caches can detect missed notifications
+	m.topicIDSequence++
+	if err := m.WriteTopic(newInfo); err != nil {
+		return err
+	}
+	if err := m.addPartitionRetentions(&newInfo); err != nil {
+		return err
+	}
+	m.topicInfosByName[topicName] = &newInfo
+	m.topicInfosByID[newInfo.ID] = &newInfo
+	// Local caches replace any cached topic info when they receive a topic added notification
+	m.SendTopicNotification(transport.HandlerIDMetaLocalCacheTopicAdded, newInfo)
+	log.Debugf("%p increased partitions of topic %s to %d", m, topicName, partitionCount)
+	return nil
+}
+
+// AlterTopicConfigs applies the alterations to the configs of the topic. Either all alterations are applied or none are.
+// If validateOnly is true the alterations are validated but not applied.
+func (m *Manager) AlterTopicConfigs(topicName string, alterations []ConfigAlteration, validateOnly bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	info, ok := m.topicInfosByName[topicName]
+	if !ok {
+		return common.NewTektiteErrorf(common.TopicDoesNotExist, "topic: %s does not exist", topicName)
+	}
+	newInfo := *info
+	newInfo.Configs = maps.Clone(info.Configs)
+	for _, alteration := range alterations {
+		if err := newInfo.AlterConfig(alteration); err != nil {
+			return err
+		}
+	}
+	if validateOnly {
+		return nil
+	}
+	m.topicIDSequence++
+	if err := m.WriteTopic(newInfo); err != nil {
+		return err
+	}
+	if err := m.removePartitionRetentions(info); err != nil {
+		return err
+	}
+	if err := m.addPartitionRetentions(&newInfo); err != nil {
+		return err
+	}
+	m.topicInfosByName[topicName] = &newInfo
+	m.topicInfosByID[newInfo.ID] = &newInfo
+	m.SendTopicNotification(transport.HandlerIDMetaLocalCacheTopicAdded, newInfo)
+	log.Debugf("%p altered configs of topic %s", m, topicName)
 	return nil
 }
 
@@ -173,12 +290,111 @@ func (m *Manager) loadTopics() error {
 		return err
 	}
 	for _, topicInfo := range allTopics {
+		if err := m.addPartitionRetentions(&topicInfo); err != nil {
+			return err
+		}
 		m.topicInfosByName[topicInfo.Name] = &topicInfo
 		m.topicInfosByID[topicInfo.ID] = &topicInfo
 	}
+	if err := m.loadPendingDeletions(); err != nil {
+		return err
+	}
+	return m.loadMaxDeletedTopicID()
+}
+
+// GetPartitionRetention returns the retention of the topic partition with the provided partition hash. False is returned
+// if the topic has no retention, or the partition is not known.
+func (m *Manager) GetPartitionRetention(partitionHash []byte) (PartitionRetention, bool, error) {
+	m.retentionsLock.RLock()
+	defer m.retentionsLock.RUnlock()
+	retention, ok := m.partitionRetentions[string(partitionHash)]
+	return retention, ok, nil
+}
+
+// GetMaxRetentionInRange returns the longest retention time of any partition with a partition hash between
+// partitionHashStart and partitionHashEnd inclusive. Zero is returned if any partition in the range has no retention
+// time, or there are no partitions in the range.
+func (m *Manager) GetMaxRetentionInRange(partitionHashStart []byte, partitionHashEnd []byte) (time.Duration, error) {
+	m.retentionsLock.RLock()
+	defer m.retentionsLock.RUnlock()
+	var maxRetention time.Duration
+	i, _ := slices.BinarySearchFunc(m.partitionHashes, partitionHashStart, bytes.Compare)
+	for ; i < len(m.partitionHashes) && bytes.Compare(m.partitionHashes[i], partitionHashEnd) <= 0; i++ {
+		retention, ok := m.partitionRetentions[string(m.partitionHashes[i])]
+		if !ok || retention.RetentionTime <= 0 {
+			return 0, nil
+		}
+		maxRetention = max(maxRetention, retention.RetentionTime)
+	}
+	return maxRetention, nil
+}
+
+func hasRetention(info *TopicInfo) bool {
+	return (info.IsDeleteEnabled() && (info.RetentionTime > 0 || info.RetentionBytes > 0)) || info.IsCompacted()
+}
+
+func (m *Manager) addPartitionRetentions(info *TopicInfo) error {
+	hashes, err := createPartitionHashes(info)
+	if err != nil {
+		return err
+	}
+	m.retentionsLock.Lock()
+	defer m.retentionsLock.Unlock()
+	for partitionID, hash := range hashes {
+		// The partitions of a topic are added again when partitions are created, so we only add hashes we don't have
+		if pos, found := slices.BinarySearchFunc(m.partitionHashes, hash, bytes.Compare); !found {
+			m.partitionHashes = slices.Insert(m.partitionHashes, pos, hash)
+		}
+		if !hasRetention(info) {
+			// Data is retained forever so nothing to store
+			continue
+		}
+		retention := PartitionRetention{
+			TopicID:     info.ID,
+			PartitionID: partitionID,
+		}
+		// Time and size based retention only apply if the cleanup policy includes delete
+		if info.IsDeleteEnabled() {
+			retention.RetentionTime = info.RetentionTime
+			retention.RetentionBytes = info.RetentionBytes
+		}
+		if info.IsCompacted() {
+			retention.Compacted = true
+			retention.DeleteRetention = info.DeleteRetention()
+		}
+		m.partitionRetentions[string(hash)] = retention
+	}
+	return nil
+}
+
+func (m *Manager) removePartitionRetentions(info *TopicInfo) error {
+	hashes, err := createPartitionHashes(info)
+	if err != nil {
+		return err
+	}
+	m.retentionsLock.Lock()
+	defer m.retentionsLock.Unlock()
+	for _, hash := range hashes {
+		if pos, found := slices.BinarySearchFunc(m.partitionHashes, hash, bytes.Compare); found {
+			m.partitionHashes = slices.Delete(m.partitionHashes, pos, pos+1)
+		}
+		delete(m.partitionRetentions, string(hash))
+	}
 	return nil
 }
 
+func createPartitionHashes(info *TopicInfo) ([][]byte, error) {
+	hashes := make([][]byte, info.PartitionCount)
+	for partitionID := 0; partitionID < info.PartitionCount; partitionID++ {
+		hash, err := parthash.CreatePartitionHash(info.ID, partitionID)
+		if err != nil {
+			return nil, err
+		}
+		hashes[partitionID] = hash
+	}
+	return hashes, nil
+}
+
 func (m *Manager) loadAllTopicsFromStorageWithRetry() ([]TopicInfo, error) {
 	for {
 		infos, err := m.loadAllTopicsFromStorage()
@@ -198,11 +414,7 @@ func (m *Manager) loadAllTopicsFromStorageWithRetry() ([]TopicInfo, error) {
 func (m *Manager) loadAllTopicsFromStorage() ([]TopicInfo, error) {
 	prefix := createPrefix()
 	keyEnd := common.IncBigEndianBytes(prefix)
-	tg := &tableGetter{
-		bucketName: m.dataBucketName,
-		objStore:   m.objStore,
-	}
-	mi, err := queryutils.CreateIteratorForKeyRange(prefix, keyEnd, m.lsm, tg.GetSSTable)
+	mi, err := queryutils.CreateIteratorForKeyRange(prefix, keyEnd, m.lsm, m.tableGetter().GetSSTable)
 	if err != nil {
 		return nil, err
 	}
@@ -220,12 +432,10 @@ func (m *Manager) loadAllTopicsFromStorage() ([]TopicInfo, error) {
 		if !ok {
 			break
 		}
-		var info TopicInfo
-		topicMetaVersion := binary.BigEndian.Uint16(kv.Value)
-		if topicMetaVersion != topicMetadataVersion {
-			return nil, errors.Errorf("invalid topic metadata version %d", topicMetaVersion)
+		info, err := deserializeTopicInfo(kv.Value)
+		if err != nil {
+			return nil, err
 		}
-		info.Deserialize(kv.Value, 2)
 		allTopics = append(allTopics, info)
 	}
 	if len(allTopics) > 0 {
@@ -246,16 +456,27 @@ func (m *Manager) WriteTopic(topicInfo TopicInfo) error {
 	return m.writeKV(common.KV{Key: key, Value: value})
 }
 
-func (m *Manager) WriteTopicDeletion(topicID int) error {
+// WriteTopicDeletion writes a tombstone for the topic metadata, along with a pending deletion record for the topic
+func (m *Manager) WriteTopicDeletion(topicInfo TopicInfo) error {
 	prefix := createPrefix()
-	key := encoding.KeyEncodeInt(prefix, int64(topicID))
+	key := encoding.KeyEncodeInt(prefix, int64(topicInfo.ID))
 	key = encoding.EncodeVersion(key, 0)
-	// Write a tombstone (nil value)
-	return m.writeKV(common.KV{Key: key})
+	buff := binary.BigEndian.AppendUint16(nil, topicMetadataVersion)
+	pendingValue := topicInfo.Serialize(buff)
+	// Write a tombstone (nil value) - the pending deletion key comes after the topic key so they are in order
+	return m.writeKVs([]common.KV{
+		{Key: key},
+		{Key: createPendingDeletionKey(topicInfo.ID), Value: pendingValue},
+	})
 }
 
 func (m *Manager) writeKV(kv common.KV) error {
-	iter := common.NewKvSliceIterator([]common.KV{kv})
+	return m.writeKVs([]common.KV{kv})
+}
+
+// writeKVs writes the kvs, which must be in key order, in a single table
+func (m *Manager) writeKVs(kvs []common.KV) error {
+	iter := common.NewKvSliceIterator(kvs)
 	// Build ssTable
 	table, smallestKey, largestKey, minVersion, maxVersion, err := sst.BuildSSTable(m.dataFormat, 0, 0, iter)
 	if err != nil {
@@ -318,6 +539,13 @@ func createPrefix() []byte {
 	return prefix
 }
 
+func (m *Manager) tableGetter() *tableGetter {
+	return &tableGetter{
+		bucketName: m.dataBucketName,
+		objStore:   m.objStore,
+	}
+}
+
 type tableGetter struct {
 	bucketName string
 	objStore   objstore.Client
@@ -1,11 +1,20 @@
 package topicmeta
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spirit-labs/tektite/asl/encoding"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/lsm"
+	"github.com/spirit-labs/tektite/objstore"
 	"github.com/spirit-labs/tektite/objstore/dev"
+	"github.com/spirit-labs/tektite/parthash"
+	"github.com/spirit-labs/tektite/sst"
 	"github.com/stretchr/testify/require"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -34,6 +43,7 @@ func TestManager(t *testing.T) {
 			ID:             expectedSeq,
 			PartitionCount: i + 1,
 			RetentionTime:  time.Duration(1000000 + i),
+			RetentionBytes: int64(2000000 + i),
 		}
 		err = mgr.CreateTopic(info)
 		require.NoError(t, err)
@@ -254,6 +264,610 @@ func TestGetTopicInfoSequencePersisted(t *testing.T) {
 	}
 }
 
+func TestGetPartitionRetention(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-with-retention", PartitionCount: 5, RetentionTime: 1 * time.Hour})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-no-retention", PartitionCount: 5})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-with-retention-bytes", PartitionCount: 5, RetentionBytes: 10000})
+	require.NoError(t, err)
+
+	checkRetentions := func(topicID int, expectedTime time.Duration, expectedBytes int64) {
+		for partitionID := 0; partitionID < 5; partitionID++ {
+			hash, err := parthash.CreatePartitionHash(topicID, partitionID)
+			require.NoError(t, err)
+			retention, ok, err := mgr.GetPartitionRetention(hash)
+			require.NoError(t, err)
+			if expectedTime == 0 && expectedBytes == 0 {
+				require.False(t, ok)
+				continue
+			}
+			require.True(t, ok)
+			require.Equal(t, PartitionRetention{
+				TopicID:        topicID,
+				PartitionID:    partitionID,
+				RetentionTime:  expectedTime,
+				RetentionBytes: expectedBytes,
+			}, retention)
+		}
+	}
+	checkRetentions(TopicIDSequenceBase, 1*time.Hour, 0)
+	checkRetentions(TopicIDSequenceBase+1, 0, 0)
+	checkRetentions(TopicIDSequenceBase+2, 0, 10000)
+
+	// Retentions should be reloaded on restart
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	checkRetentions(TopicIDSequenceBase, 1*time.Hour, 0)
+	checkRetentions(TopicIDSequenceBase+2, 0, 10000)
+
+	err = mgr.DeleteTopic("topic-with-retention")
+	require.NoError(t, err)
+	checkRetentions(TopicIDSequenceBase, 0, 0)
+}
+
+func TestGetMaxRetentionInRange(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-1h", PartitionCount: 3, RetentionTime: 1 * time.Hour})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-2h", PartitionCount: 3, RetentionTime: 2 * time.Hour})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-no-retention", PartitionCount: 2})
+	require.NoError(t, err)
+	// Adding partitions must not add the hashes of the existing partitions again
+	err = mgr.CreatePartitions("topic-1h", 5)
+	require.NoError(t, err)
+
+	type partition struct {
+		hash      []byte
+		retention time.Duration
+	}
+	var partitions []partition
+	addPartitions := func(topicID int, partitionCount int, retention time.Duration) {
+		for partitionID := 0; partitionID < partitionCount; partitionID++ {
+			hash, err := parthash.CreatePartitionHash(topicID, partitionID)
+			require.NoError(t, err)
+			partitions = append(partitions, partition{hash: hash, retention: retention})
+		}
+	}
+	addPartitions(TopicIDSequenceBase, 5, 1*time.Hour)
+	addPartitions(TopicIDSequenceBase+1, 3, 2*time.Hour)
+	addPartitions(TopicIDSequenceBase+2, 2, 0)
+	sort.Slice(partitions, func(i, j int) bool {
+		return bytes.Compare(partitions[i].hash, partitions[j].hash) < 0
+	})
+	require.Equal(t, len(partitions), len(mgr.partitionHashes))
+
+	checkRanges := func() {
+		for i := 0; i < len(partitions); i++ {
+			for j := i; j < len(partitions); j++ {
+				var expected time.Duration
+				for _, p := range partitions[i : j+1] {
+					if p.retention == 0 {
+						expected = 0
+						break
+					}
+					expected = max(expected, p.retention)
+				}
+				retention, err := mgr.GetMaxRetentionInRange(partitions[i].hash, partitions[j].hash)
+				require.NoError(t, err)
+				require.Equal(t, expected, retention)
+			}
+		}
+	}
+	checkRanges()
+
+	err = mgr.DeleteTopic("topic-no-retention")
+	require.NoError(t, err)
+	var remaining []partition
+	for _, p := range partitions {
+		if p.retention != 0 {
+			remaining = append(remaining, p)
+		}
+	}
+	partitions = remaining
+	checkRanges()
+	retention, err := mgr.GetMaxRetentionInRange(partitions[0].hash, partitions[len(partitions)-1].hash)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, retention)
+}
+
+func TestGetPartitionRetentionCompacted(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	// Retention time does not apply unless the cleanup policy includes delete
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-compacted", PartitionCount: 1, RetentionTime: 1 * time.Hour,
+		Configs: map[string]string{ConfigCleanupPolicy: CleanupPolicyCompact}})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic-compacted-delete", PartitionCount: 1, RetentionTime: 1 * time.Hour,
+		Configs: map[string]string{ConfigCleanupPolicy: "compact,delete", ConfigDeleteRetentionMs: "1000"}})
+	require.NoError(t, err)
+
+	getRetention := func(topicID int) (PartitionRetention, bool) {
+		hash, err := parthash.CreatePartitionHash(topicID, 0)
+		require.NoError(t, err)
+		retention, ok, err := mgr.GetPartitionRetention(hash)
+		require.NoError(t, err)
+		return retention, ok
+	}
+	retention, ok := getRetention(TopicIDSequenceBase)
+	require.True(t, ok)
+	require.Equal(t, PartitionRetention{
+		TopicID:         TopicIDSequenceBase,
+		Compacted:       true,
+		DeleteRetention: 24 * time.Hour,
+	}, retention)
+	retention, ok = getRetention(TopicIDSequenceBase + 1)
+	require.True(t, ok)
+	require.Equal(t, PartitionRetention{
+		TopicID:         TopicIDSequenceBase + 1,
+		RetentionTime:   1 * time.Hour,
+		Compacted:       true,
+		DeleteRetention: 1 * time.Second,
+	}, retention)
+
+	// Switching to delete brings back the retention time
+	err = mgr.AlterTopicConfigs("topic-compacted", []ConfigAlteration{
+		{Name: ConfigCleanupPolicy, Operation: ConfigOperationSet, Value: CleanupPolicyDelete},
+	}, false)
+	require.NoError(t, err)
+	retention, ok = getRetention(TopicIDSequenceBase)
+	require.True(t, ok)
+	require.Equal(t, PartitionRetention{
+		TopicID:       TopicIDSequenceBase,
+		RetentionTime: 1 * time.Hour,
+	}, retention)
+
+	err = mgr.AlterTopicConfigs("topic-compacted", []ConfigAlteration{
+		{Name: ConfigRetentionMs, Operation: ConfigOperationDelete},
+	}, false)
+	require.NoError(t, err)
+	_, ok = getRetention(TopicIDSequenceBase)
+	require.False(t, ok)
+}
+
+func TestCreatePartitions(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 5, RetentionTime: 1 * time.Hour})
+	require.NoError(t, err)
+
+	err = mgr.CreatePartitions("topic1", 8)
+	require.NoError(t, err)
+	expected := TopicInfo{
+		ID:             TopicIDSequenceBase,
+		Name:           "topic1",
+		PartitionCount: 8,
+		RetentionTime:  1 * time.Hour,
+	}
+	info, seq, exists, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expected, info)
+	require.Equal(t, TopicIDSequenceBase+2, seq)
+	info, exists, err = mgr.GetTopicInfoByID(TopicIDSequenceBase)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expected, info)
+
+	// New partitions must have retention
+	hash, err := parthash.CreatePartitionHash(TopicIDSequenceBase, 7)
+	require.NoError(t, err)
+	retention, ok, err := mgr.GetPartitionRetention(hash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 7, retention.PartitionID)
+	require.Equal(t, 1*time.Hour, retention.RetentionTime)
+
+	// Partition count can only be increased
+	err = mgr.CreatePartitions("topic1", 8)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidPartitionCount))
+	err = mgr.CreatePartitions("topic1", 3)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidPartitionCount))
+
+	err = mgr.CreatePartitions("unknown", 10)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist))
+
+	// Should be persisted
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	info, _, exists, err = mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expected, info)
+}
+
+func TestLoadTopicMetadataV1(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	// Write topic metadata as it was before retention bytes was added
+	info := TopicInfo{
+		ID:             TopicIDSequenceBase,
+		Name:           "topic1",
+		PartitionCount: 10,
+		RetentionTime:  1 * time.Hour,
+	}
+	key := encoding.KeyEncodeInt(createPrefix(), int64(info.ID))
+	key = encoding.EncodeVersion(key, 0)
+	value := binary.BigEndian.AppendUint16(nil, topicMetadataVersionV1)
+	value = binary.BigEndian.AppendUint64(value, uint64(info.ID))
+	value = binary.BigEndian.AppendUint32(value, uint32(len(info.Name)))
+	value = append(value, info.Name...)
+	value = binary.BigEndian.AppendUint64(value, uint64(info.PartitionCount))
+	value = binary.BigEndian.AppendUint64(value, uint64(info.RetentionTime))
+	err = mgr.writeKV(common.KV{Key: key, Value: value})
+	require.NoError(t, err)
+
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	received, _, exists, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, info, received)
+}
+
+func TestLoadTopicMetadataV2(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	// Write topic metadata as it was before configs were added
+	info := TopicInfo{
+		ID:             TopicIDSequenceBase,
+		Name:           "topic1",
+		PartitionCount: 10,
+		RetentionTime:  1 * time.Hour,
+		RetentionBytes: 10000,
+	}
+	key := encoding.KeyEncodeInt(createPrefix(), int64(info.ID))
+	key = encoding.EncodeVersion(key, 0)
+	value := binary.BigEndian.AppendUint16(nil, topicMetadataVersionV2)
+	value = binary.BigEndian.AppendUint64(value, uint64(info.ID))
+	value = binary.BigEndian.AppendUint32(value, uint32(len(info.Name)))
+	value = append(value, info.Name...)
+	value = binary.BigEndian.AppendUint64(value, uint64(info.PartitionCount))
+	value = binary.BigEndian.AppendUint64(value, uint64(info.RetentionTime))
+	value = binary.BigEndian.AppendUint64(value, uint64(info.RetentionBytes))
+	err = mgr.writeKV(common.KV{Key: key, Value: value})
+	require.NoError(t, err)
+
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	received, _, exists, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, info, received)
+}
+
+func TestAlterTopicConfigs(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 5, RetentionTime: 1 * time.Hour})
+	require.NoError(t, err)
+
+	err = mgr.AlterTopicConfigs("topic1", []ConfigAlteration{
+		{Name: ConfigRetentionMs, Operation: ConfigOperationDelete},
+		{Name: ConfigRetentionBytes, Operation: ConfigOperationSet, Value: "100000"},
+		{Name: ConfigMaxMessageBytes, Operation: ConfigOperationSet, Value: "1000"},
+		{Name: "some.other.config", Operation: ConfigOperationSet, Value: "foo"},
+	}, false)
+	require.NoError(t, err)
+	expected := TopicInfo{
+		ID:             TopicIDSequenceBase,
+		Name:           "topic1",
+		PartitionCount: 5,
+		RetentionBytes: 100000,
+		Configs: map[string]string{
+			ConfigMaxMessageBytes: "1000",
+			"some.other.config":   "foo",
+		},
+	}
+	info, seq, exists, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expected, info)
+	require.Equal(t, TopicIDSequenceBase+2, seq)
+
+	hash, err := parthash.CreatePartitionHash(TopicIDSequenceBase, 3)
+	require.NoError(t, err)
+	retention, ok, err := mgr.GetPartitionRetention(hash)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), retention.RetentionTime)
+	require.Equal(t, int64(100000), retention.RetentionBytes)
+
+	// If any alteration is invalid, none are applied
+	err = mgr.AlterTopicConfigs("topic1", []ConfigAlteration{
+		{Name: ConfigMaxMessageBytes, Operation: ConfigOperationSet, Value: "2000"},
+		{Name: ConfigRetentionMs, Operation: ConfigOperationSet, Value: "-10"},
+	}, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.InvalidConfiguration))
+	info, _, _, err = mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.Equal(t, expected, info)
+
+	// Validate only must not apply the alterations
+	err = mgr.AlterTopicConfigs("topic1", []ConfigAlteration{
+		{Name: ConfigMaxMessageBytes, Operation: ConfigOperationSet, Value: "2000"},
+	}, true)
+	require.NoError(t, err)
+	info, _, _, err = mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.Equal(t, expected, info)
+
+	err = mgr.AlterTopicConfigs("unknown", nil, false)
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicDoesNotExist))
+
+	// Should be persisted
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	info, _, exists, err = mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, expected, info)
+}
+
+func TestDeleteTopicPendingDeletion(t *testing.T) {
+	lsmH := &testLsmHolder{}
+	objStore := dev.NewInMemStore(0)
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 10})
+	require.NoError(t, err)
+	info, _, exists, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.False(t, mgr.IsPendingDeletion("topic1"))
+
+	err = mgr.DeleteTopic("topic1")
+	require.NoError(t, err)
+	require.True(t, mgr.IsPendingDeletion("topic1"))
+
+	// Prefix deletes must have been written for all partitions
+	table := lsmH.getLatestTable(t, objStore)
+	require.Equal(t, info.PartitionCount, table.NumPrefixDeletes())
+	iter, err := table.NewIterator(nil, nil)
+	require.NoError(t, err)
+	hashes, err := createPartitionHashes(&info)
+	require.NoError(t, err)
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i], hashes[j]) < 0
+	})
+	for _, hash := range hashes {
+		for _, expected := range createPrefixDeleteKVs(hash) {
+			ok, kv, err := iter.Next()
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, expected, kv)
+		}
+	}
+	ok, _, err := iter.Next()
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Cannot recreate while pending
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 10})
+	require.Error(t, err)
+	require.True(t, common.IsTektiteErrorWithCode(err, common.TopicAlreadyExists))
+
+	// Pending deletion survives restart, and the topic id is not reused
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	require.True(t, mgr.IsPendingDeletion("topic1"))
+	err = mgr.CreateTopic(TopicInfo{Name: "topic2", PartitionCount: 10})
+	require.NoError(t, err)
+	info2, _, exists, err := mgr.GetTopicInfo("topic2")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, info.ID+1, info2.ID)
+
+	// Prefix deletes are rewritten after restart
+	err = mgr.checkPendingDeletions()
+	require.NoError(t, err)
+	require.True(t, mgr.IsPendingDeletion("topic1"))
+	// There is no live data for the topic, but the prefix deletes have not been compacted away
+	err = mgr.checkPendingDeletions()
+	require.NoError(t, err)
+	require.True(t, mgr.IsPendingDeletion("topic1"))
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 10})
+	require.Error(t, err)
+	// Once they have, the deletion is complete
+	lsmH.removePrefixDeleteTables()
+	err = mgr.checkPendingDeletions()
+	require.NoError(t, err)
+	require.False(t, mgr.IsPendingDeletion("topic1"))
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 10})
+	require.NoError(t, err)
+
+	// And no longer pending after restart
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	require.False(t, mgr.IsPendingDeletion("topic1"))
+	_, _, exists, err = mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestDeleteTopicWithLsmManager(t *testing.T) {
+	objStore := dev.NewInMemStore(0)
+	lsmManager := lsm.NewManager(objStore, func() {}, false, false, lsm.NewConf())
+	err := lsmManager.Start(nil)
+	require.NoError(t, err)
+	defer func() {
+		err := lsmManager.Stop()
+		require.NoError(t, err)
+	}()
+	lsmH := &lsmManagerHolder{Manager: lsmManager}
+	mgr, err := NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+
+	err = mgr.CreateTopic(TopicInfo{Name: "topic1", PartitionCount: 3})
+	require.NoError(t, err)
+	err = mgr.CreateTopic(TopicInfo{Name: "topic2", PartitionCount: 3})
+	require.NoError(t, err)
+	info1, _, _, err := mgr.GetTopicInfo("topic1")
+	require.NoError(t, err)
+	info2, _, _, err := mgr.GetTopicInfo("topic2")
+	require.NoError(t, err)
+
+	// Write data for both topics in a single table, so the table spans the partitions of both topics
+	hashes1, err := createPartitionHashes(&info1)
+	require.NoError(t, err)
+	hashes2, err := createPartitionHashes(&info2)
+	require.NoError(t, err)
+	var kvs []common.KV
+	for _, hash := range append(hashes1, hashes2...) {
+		key := append(common.ByteSliceCopy(hash), common.EntryTypeTopicData)
+		kvs = append(kvs, common.KV{Key: encoding.EncodeVersion(key, 0), Value: []byte("data")})
+	}
+	sort.Slice(kvs, func(i, j int) bool {
+		return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+	})
+	err = mgr.writeKVs(kvs)
+	require.NoError(t, err)
+	dataReg := lsmH.registrations[len(lsmH.registrations)-1]
+
+	// Delete the topic with the highest id
+	err = mgr.DeleteTopic("topic2")
+	require.NoError(t, err)
+	require.True(t, mgr.IsPendingDeletion("topic2"))
+	prefixDeletesReg := lsmH.registrations[len(lsmH.registrations)-1]
+	require.Equal(t, uint32(len(hashes2)), prefixDeletesReg.NumPrefixDeletes)
+
+	// The data is no longer visible, but the deletion is not complete until the prefix deletes have been compacted away
+	for i := 0; i < 2; i++ {
+		err = mgr.checkPendingDeletions()
+		require.NoError(t, err)
+		require.True(t, mgr.IsPendingDeletion("topic2"))
+	}
+	for _, hash := range hashes2 {
+		hasPrefixDeletes, err := lsmManager.HasPrefixDeletesInRange(hash, common.IncBigEndianBytes(hash))
+		require.NoError(t, err)
+		require.True(t, hasPrefixDeletes)
+	}
+
+	// Simulate compaction into the last level, which removes the deleted data and the prefix deletes. A table
+	// containing the data of the other topic remains, and can span the deleted partitions
+	var remaining []common.KV
+	for _, kv := range kvs {
+		for _, hash := range hashes1 {
+			if bytes.HasPrefix(kv.Key, hash) {
+				remaining = append(remaining, kv)
+			}
+		}
+	}
+	ok, err := lsmManager.ApplyChanges(lsm.RegistrationBatch{
+		DeRegistrations: []lsm.RegistrationEntry{dataReg, prefixDeletesReg},
+	}, false)
+	require.NoError(t, err)
+	require.True(t, ok)
+	err = mgr.writeKVs(remaining)
+	require.NoError(t, err)
+	err = mgr.checkPendingDeletions()
+	require.NoError(t, err)
+	require.False(t, mgr.IsPendingDeletion("topic2"))
+
+	// The data of the other topic remains
+	deleted, err := mgr.isDataRemoved(&info1)
+	require.NoError(t, err)
+	require.False(t, deleted)
+
+	// After restart, the name can be reused but the topic id cannot
+	err = mgr.Stop()
+	require.NoError(t, err)
+	mgr, err = NewManager(lsmH, objStore, "test-bucket", common.DataFormatV1, nil)
+	require.NoError(t, err)
+	err = mgr.Start()
+	require.NoError(t, err)
+	require.False(t, mgr.IsPendingDeletion("topic2"))
+	err = mgr.CreateTopic(TopicInfo{Name: "topic2", PartitionCount: 3})
+	require.NoError(t, err)
+	info3, _, _, err := mgr.GetTopicInfo("topic2")
+	require.NoError(t, err)
+	require.Greater(t, info3.ID, info2.ID)
+}
+
 func TestSerializeDeserializeTopicNotification(t *testing.T) {
 	notif := TopicNotification{
 		Sequence: 1234,
@@ -262,6 +876,11 @@ func TestSerializeDeserializeTopicNotification(t *testing.T) {
 			Name:           "topic1234",
 			PartitionCount: 123,
 			RetentionTime:  34123,
+			RetentionBytes: 4565,
+			Configs: map[string]string{
+				ConfigMaxMessageBytes: "10000",
+				ConfigCleanupPolicy:   "delete",
+			},
 		},
 	}
 	var buff []byte
@@ -294,6 +913,64 @@ func (t *testLsmHolder) QueryTablesInRange(keyStart []byte, keyEnd []byte) (lsm.
 	return ids, nil
 }
 
+func (t *testLsmHolder) HasPrefixDeletesInRange(keyStart []byte, keyEnd []byte) (bool, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, batch := range t.batches {
+		for _, reg := range batch.Registrations {
+			if reg.NumPrefixDeletes > 0 && lsm.HasOverlap(keyStart, keyEnd, reg.KeyStart, reg.KeyEnd) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// removePrefixDeleteTables removes the tables containing prefix deletes, as happens when they are compacted into the
+// last level
+func (t *testLsmHolder) removePrefixDeleteTables() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i, batch := range t.batches {
+		var regs []lsm.RegistrationEntry
+		for _, reg := range batch.Registrations {
+			if reg.NumPrefixDeletes == 0 {
+				regs = append(regs, reg)
+			}
+		}
+		t.batches[i].Registrations = regs
+	}
+}
+
+func (t *testLsmHolder) getLatestTable(tst *testing.T, objStore objstore.Client) *sst.SSTable {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	tableID := t.batches[0].Registrations[0].TableID
+	buff, err := objStore.Get(context.Background(), "test-bucket", string(tableID))
+	require.NoError(tst, err)
+	var table sst.SSTable
+	table.Deserialize(buff, 0)
+	return &table
+}
+
+// lsmManagerHolder applies changes to a real LSM manager
+type lsmManagerHolder struct {
+	*lsm.Manager
+	registrations []lsm.RegistrationEntry
+}
+
+func (l *lsmManagerHolder) ApplyLsmChanges(regBatch lsm.RegistrationBatch, completionFunc func(error) error) error {
+	l.registrations = append(l.registrations, regBatch.Registrations...)
+	ok, err := l.ApplyChanges(regBatch, false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("L0 is full")
+	}
+	return completionFunc(nil)
+}
+
 func (t *testLsmHolder) ApplyLsmChanges(regBatch lsm.RegistrationBatch, completionFunc func(error) error) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -1,7 +1,9 @@
 package topicmeta
 
 import (
+	"bytes"
 	"encoding/binary"
+	"sort"
 	"time"
 )
 
@@ -10,6 +12,30 @@ type TopicInfo struct {
 	Name           string
 	PartitionCount int
 	RetentionTime  time.Duration
+	// RetentionBytes is the maximum size of each partition before the oldest data is deleted. <= 0 means no limit
+	RetentionBytes int64
+	// Configs holds any other topic configs which have been set, keyed by config name. See config.go
+	Configs map[string]string
+}
+
+// kafkaTopicIDPrefix is the first 8 bytes of every Kafka topic id
+var kafkaTopicIDPrefix = []byte("tektite\x00")
+
+// KafkaTopicID returns the 16 byte UUID which identifies the topic in the Kafka protocol. Topic ids are generated from a
+// sequence and are never re-used, so the UUID is derived from the topic id.
+func (t *TopicInfo) KafkaTopicID() []byte {
+	buff := make([]byte, 0, 16)
+	buff = append(buff, kafkaTopicIDPrefix...)
+	return binary.BigEndian.AppendUint64(buff, uint64(t.ID))
+}
+
+// TopicIDFromKafkaTopicID returns the topic id for a UUID created with KafkaTopicID, or false if the UUID was not
+// created by KafkaTopicID
+func TopicIDFromKafkaTopicID(kafkaTopicID []byte) (int, bool) {
+	if len(kafkaTopicID) != 16 || !bytes.Equal(kafkaTopicID[:8], kafkaTopicIDPrefix) {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint64(kafkaTopicID[8:])), true
 }
 
 func (t *TopicInfo) Serialize(buff []byte) []byte {
@@ -18,10 +44,54 @@ func (t *TopicInfo) Serialize(buff []byte) []byte {
 	buff = append(buff, t.Name...)
 	buff = binary.BigEndian.AppendUint64(buff, uint64(t.PartitionCount))
 	buff = binary.BigEndian.AppendUint64(buff, uint64(t.RetentionTime))
+	buff = binary.BigEndian.AppendUint64(buff, uint64(t.RetentionBytes))
+	// Configs are written in name order so serialization is deterministic
+	names := make([]string, 0, len(t.Configs))
+	for name := range t.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buff = binary.BigEndian.AppendUint32(buff, uint32(len(names)))
+	for _, name := range names {
+		value := t.Configs[name]
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(name)))
+		buff = append(buff, name...)
+		buff = binary.BigEndian.AppendUint32(buff, uint32(len(value)))
+		buff = append(buff, value...)
+	}
 	return buff
 }
 
 func (t *TopicInfo) Deserialize(buff []byte, offset int) int {
+	offset = t.deserializeV2(buff, offset)
+	numConfigs := int(binary.BigEndian.Uint32(buff[offset:]))
+	offset += 4
+	if numConfigs > 0 {
+		t.Configs = make(map[string]string, numConfigs)
+		for i := 0; i < numConfigs; i++ {
+			ln := int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			name := string(buff[offset : offset+ln])
+			offset += ln
+			ln = int(binary.BigEndian.Uint32(buff[offset:]))
+			offset += 4
+			t.Configs[name] = string(buff[offset : offset+ln])
+			offset += ln
+		}
+	}
+	return offset
+}
+
+// deserializeV2 deserializes the fields that were present before Configs was added
+func (t *TopicInfo) deserializeV2(buff []byte, offset int) int {
+	offset = t.deserializeV1(buff, offset)
+	t.RetentionBytes = int64(binary.BigEndian.Uint64(buff[offset:]))
+	offset += 8
+	return offset
+}
+
+// deserializeV1 deserializes the fields that were present before RetentionBytes was added
+func (t *TopicInfo) deserializeV1(buff []byte, offset int) int {
 	t.ID = int(binary.BigEndian.Uint64(buff[offset:]))
 	offset += 8
 	nl := int(binary.BigEndian.Uint32(buff[offset:]))
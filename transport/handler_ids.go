@@ -14,6 +14,18 @@ const (
 	HandlerIDControllerDeleteTopic
 	HandlerIDControllerGetGroupCoordinatorInfo
 	HandlerIDControllerGenerateSequence
+	HandlerIDControllerPutUserCredentials
+	HandlerIDControllerDeleteUserCredentials
+	HandlerIDControllerGetUserCredentials
+	HandlerIDControllerGetPartitionRetention
+	HandlerIDControllerCreatePartitions
+	HandlerIDControllerAlterTopicConfigs
+	HandlerIDControllerAlterClientQuotas
+	HandlerIDControllerGetClientQuotas
+	HandlerIDControllerCreateAcls
+	HandlerIDControllerDeleteAcls
+	HandlerIDControllerGetAcls
+	HandlerIDControllerDeleteRecords
 	HandlerIDMetaLocalCacheTopicAdded
 	HandlerIDMetaLocalCacheTopicDeleted
 	HandlerIDFetchCacheGetTableBytes
@@ -13,11 +13,11 @@ import (
 	log "github.com/spirit-labs/tektite/logger"
 	"github.com/spirit-labs/tektite/parthash"
 	"github.com/spirit-labs/tektite/pusher"
+	"github.com/spirit-labs/tektite/queryutils"
 	"github.com/spirit-labs/tektite/sst"
 	"github.com/spirit-labs/tektite/topicmeta"
 	"github.com/spirit-labs/tektite/transport"
 	"github.com/spirit-labs/tektite/types"
-	"hash/crc32"
 	"math"
 	"sync"
 	"time"
@@ -28,6 +28,7 @@ type Coordinator struct {
 	controlClientCache *control.ClientCache
 	cfg                Conf
 	tableGetter        sst.TableGetter
+	filterGetter       sst.BloomFilterGetter
 	membership         cluster.MembershipState
 	connFactory        transport.ConnectionFactory
 	topicProvider      topicInfoProvider
@@ -42,12 +43,13 @@ type topicInfoProvider interface {
 }
 
 func NewCoordinator(cfg Conf, controlClientCache *control.ClientCache, tableGetter sst.TableGetter,
-	connFactory transport.ConnectionFactory, topicProvider topicInfoProvider,
+	filterGetter sst.BloomFilterGetter, connFactory transport.ConnectionFactory, topicProvider topicInfoProvider,
 	partHashes *parthash.PartitionHashes) *Coordinator {
 	return &Coordinator{
 		cfg:                cfg,
 		controlClientCache: controlClientCache,
 		tableGetter:        tableGetter,
+		filterGetter:       filterGetter,
 		connFactory:        connFactory,
 		topicProvider:      topicProvider,
 		partHashes:         partHashes,
@@ -91,9 +93,10 @@ func (c *Coordinator) MembershipChanged(_ int32, memberState cluster.MembershipS
 	return nil
 }
 
-func (c *Coordinator) HandleInitProducerID(req *kafkaprotocol.InitProducerIdRequest) *kafkaprotocol.InitProducerIdResponse {
+func (c *Coordinator) HandleInitProducerID(hdr *kafkaprotocol.RequestHeader,
+	req *kafkaprotocol.InitProducerIdRequest) *kafkaprotocol.InitProducerIdResponse {
 	resp := &kafkaprotocol.InitProducerIdResponse{}
-	err := c.handleInitProducerID(req, resp)
+	err := c.handleInitProducerID(hdr.RequestApiVersion, req, resp)
 	resp.ErrorCode = kafkaencoding.ErrorCodeForError(err, kafkaprotocol.ErrorCodeCoordinatorNotAvailable)
 	return resp
 }
@@ -205,7 +208,7 @@ func (c *Coordinator) handleEndTxn(req *kafkaprotocol.EndTxnRequest, _ *kafkapro
 	return info.endTx(req.Committed)
 }
 
-func (c *Coordinator) handleInitProducerID(req *kafkaprotocol.InitProducerIdRequest, resp *kafkaprotocol.InitProducerIdResponse) error {
+func (c *Coordinator) handleInitProducerID(apiVersion int16, req *kafkaprotocol.InitProducerIdRequest, resp *kafkaprotocol.InitProducerIdResponse) error {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if req.ProducerId == -1 && req.ProducerEpoch != -1 {
@@ -241,7 +244,22 @@ func (c *Coordinator) handleInitProducerID(req *kafkaprotocol.InitProducerIdRequ
 		return err
 	}
 
-	// TODO verify passed in producer id and epoch. logic is complex here!!
+	if apiVersion >= 3 && req.ProducerId != -1 && storedState != nil {
+		// In version 3 and higher, a producer can provide its current producer id and epoch to bump its epoch
+		if req.ProducerId != storedState.pid {
+			return &kafkaencoding.KafkaError{
+				ErrorCode: kafkaprotocol.ErrorCodeInvalidProducerIDMapping,
+				ErrorMsg:  fmt.Sprintf("producer id %d does not match producer id for transactional id %s", req.ProducerId, transactionalID),
+			}
+		}
+		if req.ProducerEpoch != storedState.producerEpoch {
+			// The producer has been fenced by another producer with the same transactional id
+			return &kafkaencoding.KafkaError{
+				ErrorCode: kafkaprotocol.ErrorCodeInvalidProducerEpoch,
+				ErrorMsg:  fmt.Sprintf("producer epoch %d does not match current epoch for transactional id %s", req.ProducerEpoch, transactionalID),
+			}
+		}
+	}
 
 	if storedState == nil {
 		// First time transactionalID was used or no transactional id - generate a pid
@@ -345,45 +363,12 @@ func (c *Coordinator) loadTxInfo(transactionalID string) (*txStoredState, error)
 	return info, nil
 }
 
-// TODO combine with other similar methods (e.g. in table pusher, but do a search for all usage of QueryTablesInRange)
 func (c *Coordinator) getLatestValueWithKey(key []byte) ([]byte, error) {
-	keyEnd := common.IncBigEndianBytes(key)
 	cl, err := c.controlClientCache.GetClient()
 	if err != nil {
 		return nil, err
 	}
-	queryRes, err := cl.QueryTablesInRange(key, keyEnd)
-	if err != nil {
-		return nil, err
-	}
-	if len(queryRes) == 0 {
-		// no stored txinfo
-		return nil, nil
-	}
-	// We take the first one as that's the most recent
-	nonOverlapping := queryRes[0]
-	res := nonOverlapping[0]
-	tableID := res.ID
-	sstTable, err := c.tableGetter(tableID)
-	if err != nil {
-		return nil, err
-	}
-	iter, err := sstTable.NewIterator(key, keyEnd)
-	if err != nil {
-		return nil, err
-	}
-	ok, kv, err := iter.Next()
-	if err != nil {
-		return nil, err
-	}
-	if !ok {
-		return nil, nil
-	}
-	if len(kv.Value) == 0 {
-		// tombstone
-		return nil, nil
-	}
-	return kv.Value, nil
+	return queryutils.GetLatestValueWithKey(key, cl, c.tableGetter, c.filterGetter)
 }
 
 func (c *Coordinator) getConnection(address string) (transport.Connection, error) {
@@ -505,7 +490,7 @@ func (t *txInfo) endTx(commit bool) error {
 		return err
 	}
 	// Write transaction markers
-	if err := t.sendTransactionMarkers(); err != nil {
+	if err := t.sendTransactionMarkers(commit); err != nil {
 		return err
 	}
 	// Write committed offsets
@@ -551,19 +536,13 @@ func (t *txInfo) storeCommittedOffsets() error {
 	return nil
 }
 
-func (t *txInfo) sendTransactionMarkers() error {
+func (t *txInfo) sendTransactionMarkers(commit bool) error {
 	timestamp := types.Timestamp{Val: time.Now().UnixMilli()}
 	pusherBatches := map[string]map[int64]map[int32][]byte{}
 	for topicID, topicParts := range t.storedState.partitions {
 		for _, partitionID := range topicParts {
-			batchBytes := make([]byte, 61)
-			batchBytes, ok := kafkaencoding.AppendToBatch(batchBytes, 0, nil, nil, nil, timestamp,
-				timestamp, math.MaxInt, true)
-			if !ok {
-				panic("could not append to batch")
-			}
-			kafkaencoding.SetBatchHeader(batchBytes, 0, 0, timestamp, timestamp, 1, crc32.NewIEEE())
-			batchBytes[21] = batchBytes[21] | (1 << 5) // set as controlBatch
+			batchBytes := kafkaencoding.CreateControlBatch(t.storedState.pid, t.storedState.producerEpoch, commit,
+				timestamp)
 			partitionHash, err := t.c.partHashes.GetPartitionHash(int(topicID), int(partitionID))
 			if err != nil {
 				return err
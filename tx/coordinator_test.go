@@ -4,7 +4,9 @@ import (
 	"encoding/binary"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/spirit-labs/tektite/acls"
 	"github.com/spirit-labs/tektite/asl/encoding"
+	"github.com/spirit-labs/tektite/auth"
 	"github.com/spirit-labs/tektite/cluster"
 	"github.com/spirit-labs/tektite/common"
 	"github.com/spirit-labs/tektite/control"
@@ -34,12 +36,12 @@ func TestInitProducerNoTransactionalID(t *testing.T) {
 	partHashes, err := parthash.NewPartitionHashes(0)
 	require.NoError(t, err)
 	topicProvider := &testTopicInfoProvider{infos: map[string]topicmeta.TopicInfo{}}
-	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, localTransports.CreateConnection,
+	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, nil, localTransports.CreateConnection,
 		topicProvider, partHashes)
 	numRequests := 100
 	for i := 0; i < numRequests; i++ {
 		req := &kafkaprotocol.InitProducerIdRequest{}
-		resp := coordinator.HandleInitProducerID(req)
+		resp := coordinator.HandleInitProducerID(&kafkaprotocol.RequestHeader{}, req)
 		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
 		require.Equal(t, producerID, resp.ProducerId)
 		require.Equal(t, 0, int(resp.ProducerEpoch))
@@ -77,7 +79,7 @@ func testInitProducerError(t *testing.T, injectError error, expectedErrCode int)
 	topicProvider := &testTopicInfoProvider{infos: map[string]topicmeta.TopicInfo{}}
 	partHashes, err := parthash.NewPartitionHashes(0)
 	require.NoError(t, err)
-	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, localTransports.CreateConnection,
+	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, nil, localTransports.CreateConnection,
 		topicProvider, partHashes)
 
 	fp := &fakePusherSink{}
@@ -107,7 +109,7 @@ func testInitProducerError(t *testing.T, injectError error, expectedErrCode int)
 	req := &kafkaprotocol.InitProducerIdRequest{
 		TransactionalId: common.StrPtr(transactionalID),
 	}
-	resp := coordinator.HandleInitProducerID(req)
+	resp := coordinator.HandleInitProducerID(&kafkaprotocol.RequestHeader{}, req)
 	require.Equal(t, expectedErrCode, int(resp.ErrorCode))
 }
 
@@ -130,7 +132,7 @@ func TestInitProducerWithTransactionalID(t *testing.T) {
 	localTransports := transport.NewLocalTransports()
 	topicProvider := &testTopicInfoProvider{infos: map[string]topicmeta.TopicInfo{}}
 	partHashes, err := parthash.NewPartitionHashes(0)
-	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, localTransports.CreateConnection,
+	coordinator := NewCoordinator(cfg, controlClientCache, tableGetter.getTable, nil, localTransports.CreateConnection,
 		topicProvider, partHashes)
 
 	fp := &fakePusherSink{}
@@ -161,7 +163,7 @@ func TestInitProducerWithTransactionalID(t *testing.T) {
 		req := &kafkaprotocol.InitProducerIdRequest{
 			TransactionalId: common.StrPtr(transactionalID),
 		}
-		resp := coordinator.HandleInitProducerID(req)
+		resp := coordinator.HandleInitProducerID(&kafkaprotocol.RequestHeader{}, req)
 		require.NoError(t, err)
 		require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
 		require.Equal(t, producerID, resp.ProducerId)
@@ -209,6 +211,82 @@ func TestInitProducerWithTransactionalID(t *testing.T) {
 	}
 }
 
+func TestInitProducerWithProducerIDAndEpoch(t *testing.T) {
+	producerID := int64(23)
+	transactionalID := "transactionalID1"
+	controlClient := &testControlClient{
+		seq:                 producerID,
+		coordinatorMemberID: 1,
+		coordinatorAddress:  "some-address",
+		coordinatorEpoch:    7,
+	}
+	clientFactory := func() (control.Client, error) {
+		return controlClient, nil
+	}
+	tableGetter := &testTableGetter{}
+	localTransports := transport.NewLocalTransports()
+	topicProvider := &testTopicInfoProvider{infos: map[string]topicmeta.TopicInfo{}}
+	partHashes, err := parthash.NewPartitionHashes(0)
+	require.NoError(t, err)
+	coordinator := NewCoordinator(NewConf(), control.NewClientCache(10, clientFactory), tableGetter.getTable,
+		tableGetter.getBloomFilter, localTransports.CreateConnection, topicProvider, partHashes)
+	fp := &fakePusherSink{}
+	transportServer, err := localTransports.NewLocalServer(uuid.New().String())
+	require.NoError(t, err)
+	transportServer.RegisterHandler(transport.HandlerIDTablePusherDirectWrite, fp.HandleDirectWrite)
+	memberData := common.MembershipData{
+		ClusterListenAddress: transportServer.Address(),
+	}
+	err = coordinator.MembershipChanged(0, cluster.MembershipState{
+		LeaderVersion:  1,
+		ClusterVersion: 1,
+		Members:        []cluster.MembershipEntry{{ID: 0, Data: memberData.Serialize(nil)}},
+	})
+	require.NoError(t, err)
+
+	// setup a table with stored state for the transactional id
+	partHash, err := parthash.CreateHash([]byte("t." + transactionalID))
+	require.NoError(t, err)
+	// The table has a bloom filter, which must contain the transactional id's key although it is looked up without a
+	// version
+	kv := createExpectedKV(partHash, &txStoredState{pid: producerID, producerEpoch: 3})
+	table, _, _, _, _, err := sst.BuildSSTableWithOptions(common.DataFormatV2, sst.BuildOptions{BloomFilterBitsPerKey: 10},
+		0, 0, common.NewKvSliceIterator([]common.KV{kv}))
+	require.NoError(t, err)
+	tableGetter.table = table
+	controlClient.queryRes = []lsm.NonOverlappingTables{[]lsm.QueryTableInfo{{ID: []byte(sst.CreateSSTableId())}}}
+
+	hdr := &kafkaprotocol.RequestHeader{RequestApiVersion: 4}
+	initProducer := func(producerID int64, producerEpoch int16) *kafkaprotocol.InitProducerIdResponse {
+		return coordinator.HandleInitProducerID(hdr, &kafkaprotocol.InitProducerIdRequest{
+			TransactionalId: common.StrPtr(transactionalID),
+			ProducerId:      producerID,
+			ProducerEpoch:   producerEpoch,
+		})
+	}
+
+	// wrong producer id
+	resp := initProducer(producerID+1, 3)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidProducerIDMapping, int(resp.ErrorCode))
+
+	// wrong producer epoch - producer has been fenced
+	resp = initProducer(producerID, 2)
+	require.Equal(t, kafkaprotocol.ErrorCodeInvalidProducerEpoch, int(resp.ErrorCode))
+
+	// matching producer id and epoch, epoch is bumped
+	resp = initProducer(producerID, 3)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, producerID, resp.ProducerId)
+	require.Equal(t, 4, int(resp.ProducerEpoch))
+
+	// producer id and epoch are not provided in versions < 3
+	hdr.RequestApiVersion = 2
+	resp = initProducer(0, 0)
+	require.Equal(t, kafkaprotocol.ErrorCodeNone, int(resp.ErrorCode))
+	require.Equal(t, producerID, resp.ProducerId)
+	require.Equal(t, 4, int(resp.ProducerEpoch))
+}
+
 func createExpectedKV(partHash []byte, storedState *txStoredState) common.KV {
 	kvKey := encoding.EncodeVersion(partHash, 0)
 	value := make([]byte, 0, 32)
@@ -225,6 +303,13 @@ func (t *testTableGetter) getTable(tableID sst.SSTableID) (*sst.SSTable, error)
 	return t.table, nil
 }
 
+func (t *testTableGetter) getBloomFilter(tableID sst.SSTableID) (*sst.BloomFilter, error) {
+	return sst.ReadBloomFilter(tableID, func(_ sst.SSTableID, rangeStart int64, rangeEnd int64) ([]byte, error) {
+		buff := t.table.Serialize()
+		return buff[rangeStart:min(rangeEnd, int64(len(buff)))], nil
+	})
+}
+
 type testControlClient struct {
 	lock                sync.Mutex
 	seq                 int64
@@ -250,7 +335,7 @@ func (t *testControlClient) QueryTablesInRange(keyStart []byte, keyEnd []byte) (
 	return t.queryRes, nil
 }
 
-func (t *testControlClient) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, error) {
+func (t *testControlClient) RegisterTableListener(topicID int, partitionID int, memberID int32, resetSequence int64) (int64, int64, int64, error) {
 	panic("should not be called")
 }
 
@@ -278,6 +363,14 @@ func (t *testControlClient) DeleteTopic(topicName string) error {
 	panic("should not be called")
 }
 
+func (t *testControlClient) CreatePartitions(topicName string, partitionCount int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterTopicConfigs(topicName string, alterations []topicmeta.ConfigAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
 func (t *testControlClient) GetCoordinatorInfo(key string) (memberID int32, address string, groupEpoch int, err error) {
 	return t.coordinatorMemberID, t.coordinatorAddress, t.coordinatorEpoch, nil
 }
@@ -290,6 +383,47 @@ func (t *testControlClient) GenerateSequence(sequenceName string) (int64, error)
 	return seq, nil
 }
 
+func (t *testControlClient) PutUserCredentials(username string, mechanism string, storedKey []byte, serverKey []byte,
+	salt string, iters int) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteUserCredentials(username string, mechanism string) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetUserCredentials(username string, mechanism string) (auth.UserCredentials, bool, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetPartitionRetention(partitionHash []byte) (lsm.PartitionRetention, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) AlterClientQuotas(alterations []control.ClientQuotaAlteration, validateOnly bool) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetClientQuotas() ([]control.ClientQuotaConfig, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) CreateAcls([]acls.AclEntry) error {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteAcls([]acls.AclFilter) ([][]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) GetAcls() ([]acls.AclEntry, error) {
+	panic("should not be called")
+}
+
+func (t *testControlClient) DeleteRecords(topicID int, partitionID int, offset int64) (int64, error) {
+	panic("should not be called")
+}
+
 func (t *testControlClient) Close() error {
 	return nil
 }